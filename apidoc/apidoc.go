@@ -0,0 +1,116 @@
+// Package apidoc presents the commands generated from swagger.json: it
+// names them after their resources and documents them with the summaries,
+// descriptions and parameters of their operations, so that their help is
+// as informative as the documentation of the API.
+package apidoc
+
+//go:generate go run generate.go
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type operation struct {
+	ID          string
+	Summary     string
+	Description string
+	Parameters  []parameter
+}
+
+type parameter struct {
+	Name        string
+	In          string
+	Type        string
+	Description string
+	Required    bool
+	Choices     []string
+}
+
+// Document fills the help of the commands of root named after an operation
+// of the API, leaving the help they already have untouched.
+func Document(root *cobra.Command) {
+	walk(root, documentCommand)
+}
+
+// walk calls visit for each command of root named after an operation.
+// Renamed commands keep the name of their operation as an alias.
+func walk(root *cobra.Command, visit func(command *cobra.Command, op operation)) {
+	byID := make(map[string]operation, len(operations))
+	for _, op := range operations {
+		byID[strings.ToLower(op.ID)] = op
+	}
+
+	var visitAll func(command *cobra.Command)
+	visitAll = func(command *cobra.Command) {
+		for _, name := range append([]string{command.Name()}, command.Aliases...) {
+			if op, ok := byID[strings.ToLower(name)]; ok {
+				visit(command, op)
+				break
+			}
+		}
+		for _, child := range command.Commands() {
+			visitAll(child)
+		}
+	}
+	visitAll(root)
+}
+
+func documentCommand(command *cobra.Command, op operation) {
+	if command.Short == "" {
+		command.Short = op.Summary
+	}
+	if command.Long == "" {
+		command.Long = op.Summary
+		if op.Description != "" {
+			command.Long += "\n\n" + op.Description
+		}
+	}
+
+	example := []string{command.CommandPath()}
+	for _, p := range op.Parameters {
+		flag := command.Flags().Lookup(p.Name)
+		if flag == nil {
+			flag = command.Flags().Lookup(strings.Replace(p.Name, "_", "-", -1))
+		}
+		if flag == nil {
+			continue
+		}
+		if flag.Usage == "" {
+			flag.Usage = p.Description
+			if len(p.Choices) > 0 {
+				flag.Usage = fmt.Sprintf("%s, one of %s", strings.TrimSuffix(p.Description, "."), strings.Join(p.Choices, ", "))
+			}
+		}
+		switch {
+		case !p.Required:
+		case flag.Value.Type() == "bool":
+			example = append(example, "--"+flag.Name)
+		default:
+			example = append(example, "--"+flag.Name, exampleValue(p))
+		}
+	}
+	if command.Example == "" {
+		command.Example = "  " + strings.Join(example, " ")
+	}
+}
+
+// exampleValue returns a plausible value of a parameter for the example
+// invocation of its command.
+func exampleValue(p parameter) string {
+	if len(p.Choices) > 0 {
+		return p.Choices[0]
+	}
+	switch p.Type {
+	case "integer":
+		if strings.HasSuffix(p.Name, "id") {
+			return "1234567890"
+		}
+		return "1"
+	case "number":
+		return "1.5"
+	}
+	return "'<" + strings.Replace(p.Name, "_", " ", -1) + ">'"
+}
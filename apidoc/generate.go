@@ -0,0 +1,114 @@
+//go:build ignore
+// +build ignore
+
+// generate writes operations.go, the summaries, descriptions and parameters
+// of the operations of the API, from swagger.json.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+)
+
+type swagger struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Parameters map[string]parameter                  `json:"parameters"`
+}
+
+type operation struct {
+	ID          string      `json:"operationId"`
+	Summary     string      `json:"summary"`
+	Description string      `json:"description"`
+	Parameters  []parameter `json:"parameters"`
+}
+
+type parameter struct {
+	Ref         string        `json:"$ref"`
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Type        string        `json:"type"`
+	Description string        `json:"description"`
+	Required    bool          `json:"required"`
+	Enum        []interface{} `json:"enum"`
+	Items       struct {
+		Enum []interface{} `json:"enum"`
+	} `json:"items"`
+}
+
+var methods = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "patch": true}
+
+func main() {
+	data, err := ioutil.ReadFile("../swagger.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var spec swagger
+	if err := json.Unmarshal(data, &spec); err != nil {
+		log.Fatal(err)
+	}
+
+	var operations []operation
+	for path, methodsOfPath := range spec.Paths {
+		for method, raw := range methodsOfPath {
+			if !methods[method] {
+				continue
+			}
+			var op operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				log.Fatalf("Unable to read %s %s: %v", method, path, err)
+			}
+			operations = append(operations, op)
+		}
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].ID < operations[j].ID })
+
+	var source bytes.Buffer
+	source.WriteString("// Code generated by generate.go from swagger.json; DO NOT EDIT.\n\n")
+	source.WriteString("package apidoc\n\n")
+	source.WriteString("var operations = []operation{\n")
+	for _, op := range operations {
+		fmt.Fprintf(&source, "\t{ID: %q, Summary: %q, Description: %q, Parameters: []parameter{\n", op.ID, op.Summary, clean(op.Description))
+		for _, p := range op.Parameters {
+			if p.Ref != "" {
+				name := strings.TrimPrefix(p.Ref, "#/parameters/")
+				resolved, ok := spec.Parameters[name]
+				if !ok {
+					log.Fatalf("Unknown parameter %s of %s", p.Ref, op.ID)
+				}
+				p = resolved
+			}
+			var choices []string
+			for _, choice := range append(p.Enum, p.Items.Enum...) {
+				choices = append(choices, fmt.Sprint(choice))
+			}
+			fmt.Fprintf(&source, "\t\t{Name: %q, In: %q, Type: %q, Description: %q, Required: %t",
+				p.Name, p.In, p.Type, clean(p.Description), p.Required || p.In == "path")
+			if len(choices) > 0 {
+				fmt.Fprintf(&source, ", Choices: %#v", choices)
+			}
+			source.WriteString("},\n")
+		}
+		source.WriteString("\t}},\n")
+	}
+	source.WriteString("}\n")
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("operations.go", formatted, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// clean collapses the whitespace of a description, which swagger.json
+// sometimes wraps.
+func clean(description string) string {
+	return strings.Join(strings.Fields(description), " ")
+}
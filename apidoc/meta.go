@@ -0,0 +1,190 @@
+package apidoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/jsilland/sutro/failure"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// The page size of the API when a list does not give one.
+const (
+	defaultPage    = 1
+	defaultPerPage = 30
+)
+
+// envelope is the response of a command given --with-meta.
+type envelope struct {
+	Data json.RawMessage `json:"data"`
+	Meta meta            `json:"meta"`
+}
+
+// meta is the metadata of the requests a command sent.
+type meta struct {
+	Requests   []request   `json:"requests"`
+	RateLimit  *rateLimit  `json:"rate_limit,omitempty"`
+	Pagination *pagination `json:"pagination,omitempty"`
+}
+
+type request struct {
+	Operation  string `json:"operation"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	RequestID  string `json:"request_id,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	// Headers are the headers --include-headers selects.
+	Headers http.Header `json:"headers,omitempty"`
+}
+
+// rateLimit is the usage of the rate limits the last response reported.
+type rateLimit struct {
+	ShortTerm      int `json:"short_term"`
+	ShortTermLimit int `json:"short_term_limit"`
+	Daily          int `json:"daily"`
+	DailyLimit     int `json:"daily_limit"`
+}
+
+// pagination is the page of a list a command returned. The API does not
+// tell how many items a list has, so NextPage is only a guess from a full
+// page.
+type pagination struct {
+	Page     int  `json:"page"`
+	PerPage  int  `json:"per_page"`
+	Count    *int `json:"count,omitempty"`
+	NextPage int  `json:"next_page,omitempty"`
+}
+
+// WithMeta adds a --with-meta flag to the commands of root named after an
+// operation, which prints their JSON response as the data of an envelope
+// whose meta describes the requests sent to apiClient: their status, id
+// and duration, the usage of the rate limits and the page of lists.
+func WithMeta(root *cobra.Command, apiClient *strava.Client) {
+	if apiClient == nil {
+		return
+	}
+	walk(root, func(command *cobra.Command, op operation) {
+		run := runE(command)
+		if run == nil || command.Flags().Lookup("with-meta") != nil {
+			return
+		}
+
+		var withMeta bool
+		command.Flags().BoolVar(&withMeta, "with-meta", false, "Print the JSON response as the data of an object whose meta gives the status, id and duration of the requests, the rate limit usage and the page")
+		command.Run = nil
+		command.RunE = func(cmd *cobra.Command, args []string) error {
+			if !withMeta {
+				return run(cmd, args)
+			}
+			if output := cmd.Flags().Lookup("output"); output != nil && output.Value.String() != outputJSON {
+				return failure.UsageError(errors.New("--with-meta only applies to --output json"))
+			}
+
+			var (
+				mutex     sync.Mutex
+				exchanges []strava.Exchange
+			)
+			apiClient.Observe(func(exchange strava.Exchange) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				exchanges = append(exchanges, exchange)
+			})
+			printed, err := captureStdout(func() error { return run(cmd, args) })
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			wrapped := envelope{Data: data(printed), Meta: describe(cmd, exchanges)}
+			if wrapped.Meta.Pagination != nil {
+				wrapped.Meta.Pagination.count(wrapped.Data)
+			}
+			encoded, marshalErr := json.MarshalIndent(wrapped, "", "  ")
+			if marshalErr != nil {
+				return marshalErr
+			}
+			os.Stdout.Write(append(encoded, '\n'))
+			return err
+		}
+	})
+}
+
+// data returns what a command printed as JSON: as it is when it printed
+// JSON, as a string otherwise, or null when it printed nothing.
+func data(printed []byte) json.RawMessage {
+	trimmed := bytes.TrimSpace(printed)
+	if len(trimmed) == 0 {
+		return json.RawMessage("null")
+	}
+	if json.Valid(trimmed) {
+		return json.RawMessage(trimmed)
+	}
+	quoted, _ := json.Marshal(string(trimmed))
+	return json.RawMessage(quoted)
+}
+
+// describe returns the metadata of the requests command sent.
+func describe(command *cobra.Command, exchanges []strava.Exchange) meta {
+	m := meta{Requests: []request{}}
+	headers, _ := command.Flags().GetStringSlice("include-headers")
+	for _, exchange := range exchanges {
+		r := request{
+			Operation:  exchange.Operation,
+			Method:     exchange.Method,
+			Path:       exchange.Path,
+			Status:     exchange.Status,
+			RequestID:  exchange.RequestID,
+			DurationMS: exchange.Duration.Milliseconds(),
+		}
+		if len(headers) > 0 {
+			r.Headers = exchange.Select(headers)
+		}
+		m.Requests = append(m.Requests, r)
+		if usage := exchange.Usage; usage.ShortTermLimit > 0 {
+			m.RateLimit = &rateLimit{
+				ShortTerm:      usage.ShortTerm,
+				ShortTermLimit: usage.ShortTermLimit,
+				Daily:          usage.Daily,
+				DailyLimit:     usage.DailyLimit,
+			}
+		}
+	}
+
+	page, perPage := command.Flags().Lookup("page"), command.Flags().Lookup("per_page")
+	if page != nil && perPage != nil {
+		m.Pagination = &pagination{
+			Page:    flagInt(page.Value.String(), defaultPage),
+			PerPage: flagInt(perPage.Value.String(), defaultPerPage),
+		}
+	}
+	return m
+}
+
+// count sets the number of items of the page from the list in data, and
+// the next page when the page is full.
+func (p *pagination) count(data json.RawMessage) {
+	var items []json.RawMessage
+	if json.Unmarshal(data, &items) != nil {
+		return
+	}
+	count := len(items)
+	p.Count = &count
+	if count >= p.PerPage {
+		p.NextPage = p.Page + 1
+	}
+}
+
+// flagInt returns the value of an integer flag, or fallback when it is
+// unset or not positive.
+func flagInt(value string, fallback int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,77 @@
+package apidoc
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// names are the names the generated commands are exposed under within the
+// command of their resource, keyed by operation. Operations whose name is
+// taken by a hand-written command, such as activities create, keep their
+// generated name.
+var names = map[string]string{
+	"createActivity":                    "create",
+	"createUpload":                      "create",
+	"exploreSegments":                   "explore",
+	"getActivityById":                   "get",
+	"getActivityStreams":                "activity",
+	"getClubActivitiesById":             "activities",
+	"getClubAdminsById":                 "admins",
+	"getClubById":                       "get",
+	"getClubMembersById":                "members",
+	"getCommentsByActivityId":           "comments",
+	"getEffortsBySegmentId":             "list",
+	"getGearById":                       "get",
+	"getKudoersByActivityId":            "kudoers",
+	"getLapsByActivityId":               "laps",
+	"getLoggedInAthlete":                "me",
+	"getLoggedInAthleteActivities":      "list",
+	"getLoggedInAthleteClubs":           "list",
+	"getLoggedInAthleteStarredSegments": "starred",
+	"getLoggedInAthleteZones":           "zones",
+	"getPhotosByActivityId":             "photos",
+	"getRouteAsGPX":                     "gpx",
+	"getRouteAsTCX":                     "tcx",
+	"getRouteById":                      "get",
+	"getRouteStreams":                   "route",
+	"getRoutesByAthleteId":              "list",
+	"getRunningRaceById":                "get",
+	"getRunningRaces":                   "list",
+	"getSegmentById":                    "get",
+	"getSegmentEffortById":              "get",
+	"getSegmentEffortStreams":           "segment-effort",
+	"getSegmentStreams":                 "segment",
+	"getStats":                          "stats",
+	"getUploadById":                     "get",
+	"getZonesByActivityId":              "zones",
+	"starSegment":                       "star",
+	"updateActivityById":                "update",
+	"updateLoggedInAthlete":             "update",
+}
+
+// Rename exposes the generated commands of root under the names of their
+// operations in names, such as activities list for
+// getLoggedInAthleteActivities. The generated names remain aliases, so that
+// scripts written against them keep working.
+func Rename(root *cobra.Command) {
+	for _, group := range root.Commands() {
+		for _, command := range group.Commands() {
+			name, ok := names[command.Name()]
+			if !ok || hasCommand(group, name) {
+				continue
+			}
+			command.Aliases = append(command.Aliases, command.Name())
+			command.Use = name + strings.TrimPrefix(command.Use, command.Name())
+		}
+	}
+}
+
+func hasCommand(group *cobra.Command, name string) bool {
+	for _, command := range group.Commands() {
+		if command.Name() == name || command.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
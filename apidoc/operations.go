@@ -0,0 +1,160 @@
+// Code generated by generate.go from swagger.json; DO NOT EDIT.
+
+package apidoc
+
+var operations = []operation{
+	{ID: "createActivity", Summary: "Create an Activity", Description: "Creates a manual activity for an athlete, requires activity:write scope.", Parameters: []parameter{
+		{Name: "name", In: "formData", Type: "string", Description: "The name of the activity.", Required: true},
+		{Name: "type", In: "formData", Type: "string", Description: "Type of activity. For example - Run, Ride etc.", Required: true},
+		{Name: "start_date_local", In: "formData", Type: "string", Description: "ISO 8601 formatted date time.", Required: true},
+		{Name: "elapsed_time", In: "formData", Type: "integer", Description: "In seconds.", Required: true},
+		{Name: "description", In: "formData", Type: "string", Description: "Description of the activity.", Required: false},
+		{Name: "distance", In: "formData", Type: "number", Description: "In meters.", Required: false},
+		{Name: "trainer", In: "formData", Type: "integer", Description: "Set to 1 to mark as a trainer activity.", Required: false},
+		{Name: "commute", In: "formData", Type: "integer", Description: "Set to 1 to mark as commute.", Required: false},
+	}},
+	{ID: "createUpload", Summary: "Upload Activity", Description: "Uploads a new data file to create an activity from. Requires activity:write scope.", Parameters: []parameter{
+		{Name: "file", In: "formData", Type: "file", Description: "The uploaded file.", Required: false},
+		{Name: "name", In: "formData", Type: "string", Description: "The desired name of the resulting activity.", Required: false},
+		{Name: "description", In: "formData", Type: "string", Description: "The desired description of the resulting activity.", Required: false},
+		{Name: "trainer", In: "formData", Type: "string", Description: "Whether the resulting activity should be marked as having been performed on a trainer.", Required: false},
+		{Name: "commute", In: "formData", Type: "string", Description: "Whether the resulting activity should be tagged as a commute.", Required: false},
+		{Name: "data_type", In: "formData", Type: "string", Description: "The format of the uploaded file.", Required: false, Choices: []string{"fit", "fit.gz", "tcx", "tcx.gz", "gpx", "gpx.gz"}},
+		{Name: "external_id", In: "formData", Type: "string", Description: "The desired external identifier of the resulting activity.", Required: false},
+	}},
+	{ID: "exploreSegments", Summary: "Explore segments", Description: "Returns the top 10 segments matching a specified query.", Parameters: []parameter{
+		{Name: "bounds", In: "query", Type: "array", Description: "The latitude and longitude for two points describing a rectangular boundary for the search: [southwest corner latitutde, southwest corner longitude, northeast corner latitude, northeast corner longitude]", Required: true},
+		{Name: "activity_type", In: "query", Type: "string", Description: "Desired activity type.", Required: false, Choices: []string{"running", "riding"}},
+		{Name: "min_cat", In: "query", Type: "integer", Description: "The minimum climbing category.", Required: false},
+		{Name: "max_cat", In: "query", Type: "integer", Description: "The maximum climbing category.", Required: false},
+	}},
+	{ID: "getActivityById", Summary: "Get Activity", Description: "Returns the given activity that is owned by the authenticated athlete. Requires activity:read for Everyone and Followers activities. Requires activity:read_all for Only Me activities.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the activity.", Required: true},
+		{Name: "include_all_efforts", In: "query", Type: "boolean", Description: "To include all segments efforts.", Required: false},
+	}},
+	{ID: "getActivityStreams", Summary: "Get Activity Streams", Description: "Returns the given activity's streams. Requires activity:read scope. Requires activity:read_all scope for Only Me activities.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the activity.", Required: true},
+		{Name: "keys", In: "query", Type: "array", Description: "Desired stream types.", Required: true, Choices: []string{"time", "distance", "latlng", "altitude", "velocity_smooth", "heartrate", "cadence", "watts", "temp", "moving", "grade_smooth"}},
+		{Name: "key_by_type", In: "query", Type: "boolean", Description: "Must be true.", Required: true},
+	}},
+	{ID: "getClubActivitiesById", Summary: "List Club Activities", Description: "Retrieve recent activities from members of a specific club. The authenticated athlete must belong to the requested club in order to hit this endpoint. Pagination is supported. Athlete profile visibility is respected for all activities.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the club.", Required: true},
+		{Name: "page", In: "query", Type: "integer", Description: "Page number. Defaults to 1.", Required: false},
+		{Name: "per_page", In: "query", Type: "integer", Description: "Number of items per page. Defaults to 30.", Required: false},
+	}},
+	{ID: "getClubAdminsById", Summary: "List Club Administrators", Description: "Returns a list of the administrators of a given club.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the club.", Required: true},
+		{Name: "page", In: "query", Type: "integer", Description: "Page number. Defaults to 1.", Required: false},
+		{Name: "per_page", In: "query", Type: "integer", Description: "Number of items per page. Defaults to 30.", Required: false},
+	}},
+	{ID: "getClubById", Summary: "Get Club", Description: "Returns a given club using its identifier.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the club.", Required: true},
+	}},
+	{ID: "getClubMembersById", Summary: "List Club Members", Description: "Returns a list of the athletes who are members of a given club.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the club.", Required: true},
+		{Name: "page", In: "query", Type: "integer", Description: "Page number. Defaults to 1.", Required: false},
+		{Name: "per_page", In: "query", Type: "integer", Description: "Number of items per page. Defaults to 30.", Required: false},
+	}},
+	{ID: "getCommentsByActivityId", Summary: "List Activity Comments", Description: "Returns the comments on the given activity. Requires activity:read for Everyone and Followers activities. Requires activity:read_all for Only Me activities.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the activity.", Required: true},
+		{Name: "page", In: "query", Type: "integer", Description: "Page number. Defaults to 1.", Required: false},
+		{Name: "per_page", In: "query", Type: "integer", Description: "Number of items per page. Defaults to 30.", Required: false},
+	}},
+	{ID: "getEffortsBySegmentId", Summary: "List Segment Efforts", Description: "Returns a set of the authenticated athlete's segment efforts for a given segment. Requires subscription.", Parameters: []parameter{
+		{Name: "segment_id", In: "query", Type: "integer", Description: "The identifier of the segment.", Required: true},
+		{Name: "start_date_local", In: "query", Type: "string", Description: "ISO 8601 formatted date time.", Required: false},
+		{Name: "end_date_local", In: "query", Type: "string", Description: "ISO 8601 formatted date time.", Required: false},
+		{Name: "per_page", In: "query", Type: "integer", Description: "Number of items per page. Defaults to 30.", Required: false},
+	}},
+	{ID: "getGearById", Summary: "Get Equipment", Description: "Returns an equipment using its identifier.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "string", Description: "The identifier of the gear.", Required: true},
+	}},
+	{ID: "getKudoersByActivityId", Summary: "List Activity Kudoers", Description: "Returns the athletes who kudoed an activity identified by an identifier. Requires activity:read for Everyone and Followers activities. Requires activity:read_all for Only Me activities.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the activity.", Required: true},
+		{Name: "page", In: "query", Type: "integer", Description: "Page number. Defaults to 1.", Required: false},
+		{Name: "per_page", In: "query", Type: "integer", Description: "Number of items per page. Defaults to 30.", Required: false},
+	}},
+	{ID: "getLapsByActivityId", Summary: "List Activity Laps", Description: "Returns the laps of an activity identified by an identifier. Requires activity:read for Everyone and Followers activities. Requires activity:read_all for Only Me activities.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the activity.", Required: true},
+	}},
+	{ID: "getLoggedInAthlete", Summary: "Get Authenticated Athlete", Description: "Returns the currently authenticated athlete. Tokens with profile:read_all scope will receive a detailed athlete representation; all others will receive a summary representation.", Parameters: []parameter{}},
+	{ID: "getLoggedInAthleteActivities", Summary: "List Athlete Activities", Description: "Returns the activities of an athlete for a specific identifier. Requires activity:read. Only Me activities will be filtered out unless requested by a token with activity:read_all.", Parameters: []parameter{
+		{Name: "before", In: "query", Type: "integer", Description: "An epoch timestamp to use for filtering activities that have taken place before a certain time.", Required: false},
+		{Name: "after", In: "query", Type: "integer", Description: "An epoch timestamp to use for filtering activities that have taken place after a certain time.", Required: false},
+		{Name: "page", In: "query", Type: "integer", Description: "Page number. Defaults to 1.", Required: false},
+		{Name: "per_page", In: "query", Type: "integer", Description: "Number of items per page. Defaults to 30.", Required: false},
+	}},
+	{ID: "getLoggedInAthleteClubs", Summary: "List Athlete Clubs", Description: "Returns a list of the clubs whose membership includes the authenticated athlete.", Parameters: []parameter{
+		{Name: "page", In: "query", Type: "integer", Description: "Page number. Defaults to 1.", Required: false},
+		{Name: "per_page", In: "query", Type: "integer", Description: "Number of items per page. Defaults to 30.", Required: false},
+	}},
+	{ID: "getLoggedInAthleteStarredSegments", Summary: "List Starred Segments", Description: "List of the authenticated athlete's starred segments. Private segments are filtered out unless requested by a token with read_all scope.", Parameters: []parameter{
+		{Name: "page", In: "query", Type: "integer", Description: "Page number. Defaults to 1.", Required: false},
+		{Name: "per_page", In: "query", Type: "integer", Description: "Number of items per page. Defaults to 30.", Required: false},
+	}},
+	{ID: "getLoggedInAthleteZones", Summary: "Get Zones", Description: "Returns the the authenticated athlete's heart rate and power zones. Requires profile:read_all.", Parameters: []parameter{}},
+	{ID: "getPhotosByActivityId", Summary: "List Activity Photos", Description: "Returns the photos attached to an activity identified by an identifier. Requires activity:read for Everyone and Followers activities. Requires activity:read_all for Only Me activities.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the activity.", Required: true},
+		{Name: "size", In: "query", Type: "integer", Description: "The size, in pixels, of the longest side of the returned image URLs. Use 5000 for full-size images.", Required: false},
+		{Name: "photo_sources", In: "query", Type: "boolean", Description: "Whether to include photos from every source, rather than only those uploaded to Strava.", Required: false},
+	}},
+	{ID: "getRouteAsGPX", Summary: "Export Route GPX", Description: "Returns a GPX file of the route. Requires read_all scope for private routes.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the route.", Required: true},
+	}},
+	{ID: "getRouteAsTCX", Summary: "Export Route TCX", Description: "Returns a TCX file of the route. Requires read_all scope for private routes.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the route.", Required: true},
+	}},
+	{ID: "getRouteById", Summary: "Get Route", Description: "Returns a route using its identifier. Requires read_all scope for private routes.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the route.", Required: true},
+	}},
+	{ID: "getRouteStreams", Summary: "Get Route Streams", Description: "Returns the given route's streams. Requires read_all scope for private routes.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the route.", Required: true},
+	}},
+	{ID: "getRoutesByAthleteId", Summary: "List Athlete Routes", Description: "Returns a list of the routes created by the authenticated athlete using their athlete ID. Private routes are filtered out unless requested by a token with read_all scope.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the athlete.", Required: true},
+		{Name: "page", In: "query", Type: "integer", Description: "Page number. Defaults to 1.", Required: false},
+		{Name: "per_page", In: "query", Type: "integer", Description: "Number of items per page. Defaults to 30.", Required: false},
+	}},
+	{ID: "getRunningRaceById", Summary: "Get Running Race", Description: "Returns a running race for a given identifier.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the running race.", Required: true},
+	}},
+	{ID: "getRunningRaces", Summary: "List Running Races", Description: "Returns a list running races based on a set of search criteria.", Parameters: []parameter{
+		{Name: "year", In: "query", Type: "integer", Description: "Filters the list by a given year.", Required: false},
+	}},
+	{ID: "getSegmentById", Summary: "Get Segment", Description: "Returns the specified segment. read_all scope required in order to retrieve athlete-specific segment information, or to retrieve private segments.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the segment.", Required: true},
+	}},
+	{ID: "getSegmentEffortById", Summary: "Get Segment Effort", Description: "Returns a segment effort from an activity that is owned by the authenticated athlete. Requires subscription.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the segment effort.", Required: true},
+	}},
+	{ID: "getSegmentEffortStreams", Summary: "Get Segment Effort Streams", Description: "Returns a set of streams for a segment effort completed by the authenticated athlete. Requires read_all scope.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the segment effort.", Required: true},
+		{Name: "keys", In: "query", Type: "array", Description: "The types of streams to return.", Required: true, Choices: []string{"time", "distance", "latlng", "altitude", "velocity_smooth", "heartrate", "cadence", "watts", "temp", "moving", "grade_smooth"}},
+		{Name: "key_by_type", In: "query", Type: "boolean", Description: "Must be true.", Required: true},
+	}},
+	{ID: "getSegmentStreams", Summary: "Get Segment Streams", Description: "Returns the given segment's streams. Requires read_all scope for private segments.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the segment.", Required: true},
+		{Name: "keys", In: "query", Type: "array", Description: "The types of streams to return.", Required: true, Choices: []string{"distance", "latlng", "altitude"}},
+		{Name: "key_by_type", In: "query", Type: "boolean", Description: "Must be true.", Required: true},
+	}},
+	{ID: "getStats", Summary: "Get Athlete Stats", Description: "Returns the activity stats of an athlete. Only includes data from activities set to Everyone visibilty.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the athlete. Must match the authenticated athlete.", Required: true},
+	}},
+	{ID: "getUploadById", Summary: "Get Upload", Description: "Returns an upload for a given identifier. Requires activity:write scope.", Parameters: []parameter{
+		{Name: "uploadId", In: "path", Type: "integer", Description: "The identifier of the upload.", Required: true},
+	}},
+	{ID: "getZonesByActivityId", Summary: "Get Activity Zones", Description: "Summit Feature. Returns the zones of a given activity. Requires activity:read for Everyone and Followers activities. Requires activity:read_all for Only Me activities.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the activity.", Required: true},
+	}},
+	{ID: "starSegment", Summary: "Star Segment", Description: "Stars/Unstars the given segment for the authenticated athlete. Requires profile:write scope.", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the segment to star.", Required: true},
+		{Name: "starred", In: "formData", Type: "boolean", Description: "If true, star the segment; if false, unstar the segment.", Required: true},
+	}},
+	{ID: "updateActivityById", Summary: "Update Activity", Description: "Updates the given activity that is owned by the authenticated athlete. Requires activity:write. Also requires activity:read_all in order to update Only Me activities", Parameters: []parameter{
+		{Name: "id", In: "path", Type: "integer", Description: "The identifier of the activity.", Required: true},
+		{Name: "body", In: "body", Type: "", Description: "", Required: false},
+	}},
+	{ID: "updateLoggedInAthlete", Summary: "Update Athlete", Description: "Update the currently authenticated athlete. Requires profile:write scope.", Parameters: []parameter{
+		{Name: "body", In: "body", Type: "", Description: "", Required: false},
+	}},
+}
@@ -0,0 +1,90 @@
+package apidoc
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/table"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the generated commands.
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+)
+
+// Tabulate adds an --output flag to the commands of root named after an
+// operation whose responses render as a table, which shows the columns
+// package table picks for the definition instead of the JSON of the
+// response.
+func Tabulate(root *cobra.Command) {
+	walk(root, func(command *cobra.Command, op operation) {
+		definition, ok := table.Response(op.ID)
+		if !ok || table.Columns(definition) == nil || command.Flags().Lookup("output") != nil {
+			return
+		}
+		run := runE(command)
+		if run == nil {
+			return
+		}
+
+		var output string
+		choice.Var(command, &output, "output", outputJSON, "The format of the response: json or table", outputJSON, outputTable)
+		command.Run = nil
+		command.RunE = func(cmd *cobra.Command, args []string) error {
+			if output != outputTable {
+				return run(cmd, args)
+			}
+			printed, err := captureStdout(func() error { return run(cmd, args) })
+			if err != nil {
+				os.Stdout.Write(printed)
+				return err
+			}
+			// Responses that are not the JSON of the definition are printed
+			// as they are.
+			if table.Render(os.Stdout, definition, printed) != nil {
+				_, err = os.Stdout.Write(printed)
+			}
+			return err
+		}
+	})
+}
+
+// runE returns the function command runs, as one returning an error, or
+// nil when it runs none.
+func runE(command *cobra.Command) func(cmd *cobra.Command, args []string) error {
+	if command.RunE != nil || command.Run == nil {
+		return command.RunE
+	}
+	runWithoutError := command.Run
+	return func(cmd *cobra.Command, args []string) error {
+		runWithoutError(cmd, args)
+		return nil
+	}
+}
+
+// captureStdout returns what run prints on stdout.
+func captureStdout(run func() error) ([]byte, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var printed []byte
+	done := make(chan struct{})
+	go func() {
+		printed, _ = ioutil.ReadAll(reader)
+		close(done)
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	err = run()
+	os.Stdout = stdout
+	writer.Close()
+	<-done
+	return printed, err
+}
@@ -0,0 +1,122 @@
+// Package audit keeps an append-only log of the changes sutro makes to the
+// account of the athlete, such as the updates of activities by rules or
+// the uploads of files, so that they can be reviewed afterwards.
+//
+// The log is a file of JSON lines, one per operation changing data, which
+// sutro never rewrites.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/jsilland/sutro/strava"
+)
+
+// Entry is an operation changing data, with its outcome.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Command is the sutro command the operation was made by, such as
+	// rules apply.
+	Command   string `json:"command,omitempty"`
+	Operation string `json:"operation"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Resource  string `json:"resource,omitempty"`
+	Digest    string `json:"payload_digest,omitempty"`
+	// Result is ok, or the error the operation failed with.
+	Result string `json:"result"`
+}
+
+// Log is the audit log of a profile.
+type Log struct {
+	filename string
+	mutex    sync.Mutex
+}
+
+// Path returns the path of the audit log in the state directory of a
+// profile.
+func Path(stateDirectory string) string {
+	return path.Join(stateDirectory, "audit.jsonl")
+}
+
+// Open returns the log at filename, which is created with its first entry.
+func Open(filename string) *Log {
+	return &Log{filename: filename}
+}
+
+// Append adds entry at the end of the log.
+func (l *Log) Append(entry Entry) error {
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if err := os.MkdirAll(path.Dir(l.filename), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(encoded, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Interceptor returns the interceptor recording the operations changing
+// data in the log, as made by the command that command returns. Failures
+// to record them are reported on stderr, as the change was made already.
+func (l *Log) Interceptor(command func() string) strava.Interceptor {
+	return strava.Mutations(func(mutation strava.Mutation, err error) {
+		entry := Entry{
+			Time:      time.Now().UTC(),
+			Command:   command(),
+			Operation: mutation.Operation,
+			Method:    mutation.Method,
+			Path:      mutation.Path,
+			Resource:  mutation.Resource,
+			Digest:    mutation.Digest,
+			Result:    "ok",
+		}
+		if err != nil {
+			entry.Result = err.Error()
+		}
+		if err := l.Append(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to record %s %s in the audit log: %v\n", mutation.Method, mutation.Path, err)
+		}
+	})
+}
+
+// Entries returns the entries of the log, oldest first.
+func (l *Log) Entries() ([]Entry, error) {
+	file, err := os.Open(l.filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("Invalid entry on line %d of %s: %v", line, l.filename, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
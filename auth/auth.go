@@ -0,0 +1,206 @@
+// Package auth implements the OAuth flow that grants sutro access to the
+// data of an athlete: the consent page is opened in a browser, which
+// Strava redirects to a server on the loopback interface with the code to
+// exchange for a token.
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jsilland/sutro/browser"
+	"golang.org/x/oauth2"
+)
+
+// DefaultScopes are requested when the configuration has no scopes.
+var DefaultScopes = []string{"activity:read_all", "profile:read_all", "read_all"}
+
+// Options configure the authorization flow. Only Config is required.
+type Options struct {
+	// Config holds the credentials of the application, its endpoint and the
+	// scopes to request. Its redirect URL is set by Authorize.
+	Config oauth2.Config
+	// Listener accepts the redirect from the consent page, and defaults to
+	// a free port on localhost. Authorize closes it.
+	Listener net.Listener
+	// Prompt is called with the URL of the consent page before opening it
+	// and returns whether to open it in a browser, letting the user open
+	// it themselves otherwise. The browser is opened without asking when
+	// Prompt is nil.
+	Prompt func(authURL string) (bool, error)
+	// OpenBrowser opens the consent page, and defaults to the default
+	// browser of the user.
+	OpenBrowser func(authURL string) error
+	// Certificate, when set, serves the redirect over HTTPS, for the
+	// applications whose redirect URIs must not be plain HTTP.
+	Certificate *Certificate
+}
+
+type redirect struct {
+	code string
+	// scope lists the scopes the athlete granted, separated by commas.
+	scope string
+	err   error
+}
+
+// Authorize runs the authorization flow and returns the token of the
+// athlete who consented, with the scopes granted as its scope extra. It
+// waits for the redirect until ctx is done.
+func Authorize(ctx context.Context, options Options) (*oauth2.Token, error) {
+	listener := options.Listener
+	if listener == nil {
+		var err error
+		listener, err = net.Listen("tcp", "localhost:0")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	state, err := uuid.NewUUID()
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	scheme := "http"
+	if options.Certificate != nil {
+		scheme = "https"
+		listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{options.Certificate.Certificate}})
+	}
+	redirectURL := &url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort("localhost", port),
+		Path:   "/exchange",
+	}
+	config := options.Config
+	config.RedirectURL = redirectURL.String()
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+
+	redirects := make(chan redirect, 1)
+	router := http.NewServeMux()
+	router.Handle(redirectURL.Path, &redirectHandler{state: state.String(), redirects: redirects})
+	server := &http.Server{
+		Handler:        router,
+		ReadTimeout:    10 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		MaxHeaderBytes: 1 << 20,
+	}
+	go server.Serve(listener)
+	// The server is shut down even when ctx is done, as it is once sutro is
+	// interrupted.
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	// Strava expects the scopes separated by commas rather than spaces.
+	authURL := config.AuthCodeURL(
+		state.String(),
+		oauth2.AccessTypeOffline,
+		oauth2.SetAuthURLParam("scope", strings.Join(scopes, ",")),
+	)
+
+	open := true
+	if options.Prompt != nil {
+		if open, err = options.Prompt(authURL); err != nil {
+			return nil, err
+		}
+	}
+	if open {
+		openBrowser := options.OpenBrowser
+		if openBrowser == nil {
+			openBrowser = browser.Open
+		}
+		if err := openBrowser(authURL); err != nil {
+			return nil, err
+		}
+	}
+
+	var received redirect
+	select {
+	case received = <-redirects:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if received.err != nil {
+		return nil, received.err
+	}
+
+	token, err := config.Exchange(
+		ctx,
+		received.code,
+		oauth2.SetAuthURLParam("client_id", config.ClientID),
+		oauth2.SetAuthURLParam("client_secret", config.ClientSecret),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Strava reports the granted scopes in the redirect rather than with
+	// the token, as the athlete may decline some of those requested.
+	if token.Extra("scope") == nil && received.scope != "" {
+		token = token.WithExtra(map[string]interface{}{"scope": received.scope})
+	}
+	return token, nil
+}
+
+// GrantedScopes returns the scopes granted with token by Authorize, or nil
+// when they are unknown.
+func GrantedScopes(token *oauth2.Token) []string {
+	scope, _ := token.Extra("scope").(string)
+	return strings.FieldsFunc(scope, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// redirectHandler receives the redirect of the consent page. Only the first
+// redirect is forwarded.
+type redirectHandler struct {
+	state     string
+	redirects chan redirect
+}
+
+func (handler *redirectHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	var received redirect
+	switch {
+	case query.Get("state") != handler.state:
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte("The returned state does not match the one set for this redirect service."))
+		received.err = errors.New("The state returned by the authorization server does not match")
+	case query.Get("error") != "":
+		writer.WriteHeader(http.StatusForbidden)
+		writer.Write([]byte("The authorization was denied, you can close this tab and go back to your terminal"))
+		received.err = fmt.Errorf("The authorization was denied: %s", query.Get("error"))
+	case query.Get("code") == "":
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte("The redirect is missing the authorization code."))
+		received.err = errors.New("Failed to obtain code from authenticate service")
+	default:
+		writer.WriteHeader(http.StatusOK)
+		writer.Write([]byte("Code successfully received, you can close this tab and go back to your terminal"))
+		received.code = query.Get("code")
+		received.scope = query.Get("scope")
+	}
+
+	select {
+	case handler.redirects <- received:
+	default:
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+)
+
+// certificateLifetime is how long a self-signed certificate is valid for,
+// long enough for the athlete to consent.
+const certificateLifetime = 24 * time.Hour
+
+// Certificate is a certificate for the redirect server, with its PEM
+// encoding to import into the trust store of a browser.
+type Certificate struct {
+	tls.Certificate
+	PEM []byte
+}
+
+// SelfSigned generates an ephemeral certificate for localhost, which
+// browsers warn about unless it is trusted.
+func SelfSigned() (*Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "localhost", Organization: []string{"sutro"}},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(certificateLifetime),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to generate a certificate for the redirect: %v", err)
+	}
+	return &Certificate{
+		Certificate: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+		PEM:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}, nil
+}
+
+// LoadCertificate reads the certificate of the redirect server and its
+// key from PEM files.
+func LoadCertificate(certFile, keyFile string) (*Certificate, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the certificate of the redirect: %v", err)
+	}
+	return &Certificate{
+		Certificate: certificate,
+		PEM:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}),
+	}, nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the certificate, as
+// browsers display it, such as 5E:2A:...
+func (c *Certificate) Fingerprint() string {
+	sum := sha256.Sum256(c.Certificate.Certificate[0])
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hex, ":")
+}
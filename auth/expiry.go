@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DefaultExpiryWarning is how long before a token that cannot be refreshed
+// expires commands warn about it.
+const DefaultExpiryWarning = 24 * time.Hour
+
+// Expiry is how close a token is to expiring for good. Tokens with a
+// refresh token never are, as they are refreshed when they expire until
+// Strava refuses to.
+type Expiry int
+
+const (
+	// Valid is a token that is refreshed, or that does not expire within
+	// the window it is checked for.
+	Valid Expiry = iota
+	// Expiring is a token that cannot be refreshed and expires within the
+	// window it is checked for.
+	Expiring
+	// Expired is a token that cannot be refreshed and expired.
+	Expired
+)
+
+// Check returns how close token is to expiring for good at now, Expiring
+// when it expires within window.
+func Check(token oauth2.Token, window time.Duration, now time.Time) Expiry {
+	switch {
+	case token.RefreshToken != "" || token.Expiry.IsZero():
+		return Valid
+	case !now.Before(token.Expiry):
+		return Expired
+	case now.Add(window).After(token.Expiry):
+		return Expiring
+	default:
+		return Valid
+	}
+}
+
+// ExpiryWarning returns the warning about token expiring for good within
+// window at now, or an empty string when it does not.
+func ExpiryWarning(token oauth2.Token, window time.Duration, now time.Time) string {
+	if Check(token, window, now) != Expiring {
+		return ""
+	}
+	return fmt.Sprintf("Warning: the token of sutro cannot be refreshed and expires in %s, at %s: run sutro authenticate to authorize sutro again",
+		token.Expiry.Sub(now).Round(time.Minute), token.Expiry.Local().Format("Jan 2 15:04"))
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// RevokedError is a token that Strava refused to refresh, as the athlete
+// revoked the access of the application or its refresh token expired.
+type RevokedError struct {
+	// ClientID is the id of the application to authorize again.
+	ClientID string
+	Err      error
+}
+
+func (e *RevokedError) Error() string {
+	return fmt.Sprintf("Strava refused to refresh the token of sutro, whose access was revoked or expired: "+
+		"run sutro authenticate to authorize the application %s again", e.ClientID)
+}
+
+func (e *RevokedError) Unwrap() error { return e.Err }
+
+// Refresh returns a valid token from tokens, which refreshes the token once
+// it expired. Refresh tokens that Strava rejects are reported as a
+// *RevokedError for the application clientID.
+func Refresh(tokens oauth2.TokenSource, clientID string) (*oauth2.Token, error) {
+	token, err := tokens.Token()
+	if err == nil {
+		return token, nil
+	}
+
+	var retrieve *oauth2.RetrieveError
+	if errors.As(err, &retrieve) && retrieve.Response != nil {
+		switch retrieve.Response.StatusCode {
+		case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden:
+			return nil, &RevokedError{ClientID: clientID, Err: err}
+		}
+	}
+	return nil, fmt.Errorf("Unable to refresh the token: %v", err)
+}
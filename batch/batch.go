@@ -0,0 +1,151 @@
+// Package batch runs the items of the commands processing many of them,
+// such as the activities rules update, either stopping at the first
+// failure or carrying on and summarizing the failures at the end, with a
+// report of them in JSON for scripts.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/jsilland/sutro/failure"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// Flags are the flags of a batch command choosing what its failures do.
+type Flags struct {
+	continues       bool
+	failFast        bool
+	continueOnError bool
+	report          string
+}
+
+// Var defines --fail-fast, --continue-on-error and --failure-report on
+// command, and returns them. continues is whether the command carries on
+// after a failure when neither flag is given.
+func Var(command *cobra.Command, continues bool) *Flags {
+	flags := &Flags{continues: continues}
+	command.Flags().BoolVar(&flags.failFast, "fail-fast", !continues, "Stop at the first item that fails")
+	command.Flags().BoolVar(&flags.continueOnError, "continue-on-error", continues, "Carry on after the items that fail, and list their failures at the end")
+	command.Flags().StringVar(&flags.report, "failure-report", "", "Write the items that failed to this file, as JSON")
+	return flags
+}
+
+// Failure is an item that failed, as listed in the report.
+type Failure struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+// Report is the outcome of a batch, as written to the file of
+// --failure-report.
+type Report struct {
+	Total     int       `json:"total"`
+	Succeeded int       `json:"succeeded"`
+	Failed    []Failure `json:"failed"`
+	// Skipped is the number of items left alone after a failure stopped
+	// the batch.
+	Skipped int `json:"skipped"`
+}
+
+// Batch is a run of a batch command over a number of items.
+type Batch struct {
+	unit      string
+	continues bool
+	report    string
+
+	mutex  sync.Mutex
+	result Report
+}
+
+// Start returns the batch of total items named after unit, such as
+// activities, or an error when the flags contradict each other.
+func (f *Flags) Start(command *cobra.Command, total int, unit string) (*Batch, error) {
+	continues, set := f.continues, command.Flags().Changed
+	switch {
+	case set("fail-fast") && set("continue-on-error") && f.failFast == f.continueOnError:
+		return nil, failure.UsageError(errors.New("Give either --fail-fast or --continue-on-error"))
+	case set("fail-fast"):
+		continues = !f.failFast
+	case set("continue-on-error"):
+		continues = f.continueOnError
+	}
+	return &Batch{
+		unit:      unit,
+		continues: continues,
+		report:    f.report,
+		result:    Report{Total: total, Failed: []Failure{}},
+	}, nil
+}
+
+// Do runs the processing of item, whose errors should name it. It returns
+// the error of item when it stops the batch: always at the first failure,
+// and otherwise when the budget of requests runs out or the command is
+// interrupted, as the next items would fail too. Do may be called from
+// several goroutines.
+func (b *Batch) Do(ctx context.Context, item string, process func() error) error {
+	err := process()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err == nil {
+		b.result.Succeeded++
+		return nil
+	}
+	b.result.Failed = append(b.result.Failed, Failure{Item: item, Error: err.Error()})
+	if b.continues && !errors.Is(err, strava.ErrBudgetExhausted) && ctx.Err() == nil {
+		return nil
+	}
+	b.result.Skipped = b.result.Total - b.result.Succeeded - len(b.result.Failed)
+	if reportErr := b.write(); reportErr != nil {
+		return fmt.Errorf("%v, and the failure report could not be written: %v", err, reportErr)
+	}
+	return err
+}
+
+// Done writes the report, and returns an error listing the items that
+// failed, if any.
+func (b *Batch) Done() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if err := b.write(); err != nil {
+		return fmt.Errorf("Unable to write the failure report: %v", err)
+	}
+	if len(b.result.Failed) == 0 {
+		return nil
+	}
+
+	failures := make([]string, len(b.result.Failed))
+	for i, f := range b.result.Failed {
+		failures[i] = f.Error
+	}
+	listed := ""
+	if b.report != "" {
+		listed = ", listed in " + b.report
+	}
+	return fmt.Errorf("Failed on %d of %d %s%s:\n  %s", len(failures), b.result.Total, b.unit, listed, strings.Join(failures, "\n  "))
+}
+
+// Succeeded returns the number of items processed without failing.
+func (b *Batch) Succeeded() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.result.Succeeded
+}
+
+func (b *Batch) write() error {
+	if b.report == "" {
+		return nil
+	}
+	encoded, err := json.MarshalIndent(b.result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(b.report, append(encoded, '\n'), 0644)
+}
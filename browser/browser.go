@@ -0,0 +1,81 @@
+package browser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// command is the command opening URLs instead of the default browser, set
+// with SetCommand.
+var command []string
+
+// SetCommand makes Open run command, such as firefox --private-window %s,
+// with %s replaced by the URL, or with the URL appended when it has no %s.
+// An empty command opens the default browser.
+func SetCommand(line string) {
+	command = strings.Fields(line)
+}
+
+// Open opens the URL in the browser set with SetCommand, or in the user's
+// default browser.
+func Open(url string) error {
+	if len(command) > 0 {
+		return run(command, url)
+	}
+
+	var err error
+
+	switch {
+	case runtime.GOOS == "linux" && wsl():
+		// The browsers of WSL run on Windows, which wslview opens them on
+		// when installed.
+		if _, lookErr := exec.LookPath("wslview"); lookErr == nil {
+			err = exec.Command("wslview", url).Start()
+		} else {
+			err = exec.Command("powershell.exe", "-NoProfile", "-Command",
+				"Start-Process '"+strings.Replace(url, "'", "''", -1)+"'").Start()
+		}
+	case runtime.GOOS == "linux":
+		err = exec.Command("xdg-open", url).Start()
+	case runtime.GOOS == "windows":
+		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case runtime.GOOS == "darwin":
+		err = exec.Command("open", url).Start()
+	default:
+		fmt.Printf("Unable to open a browser - please open the URL yourself: %s\n", url)
+	}
+	return err
+}
+
+// run starts command with url in place of %s, or after its arguments.
+func run(command []string, url string) error {
+	args := make([]string, 0, len(command))
+	replaced := false
+	for _, arg := range command[1:] {
+		if strings.Contains(arg, "%s") {
+			arg = strings.Replace(arg, "%s", url, -1)
+			replaced = true
+		}
+		args = append(args, arg)
+	}
+	if !replaced {
+		args = append(args, url)
+	}
+	if err := exec.Command(command[0], args...).Start(); err != nil {
+		return fmt.Errorf("Unable to open the browser with %s: %v", command[0], err)
+	}
+	return nil
+}
+
+// wsl reports whether sutro runs on the Windows Subsystem for Linux.
+func wsl() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	version, err := ioutil.ReadFile("/proc/version")
+	return err == nil && strings.Contains(strings.ToLower(string(version)), "microsoft")
+}
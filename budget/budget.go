@@ -0,0 +1,77 @@
+// Package budget provides the --budget and --max-duration options of the
+// commands that run long enough to exhaust the quota of the Strava API.
+package budget
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// Flags are the budget options of a command.
+type Flags struct {
+	requests    string
+	maxDuration time.Duration
+}
+
+// Register adds the budget options to command.
+func (f *Flags) Register(command *cobra.Command) {
+	command.Flags().StringVar(&f.requests, "budget", "", "Stop before sending more than this number of requests to the API (e.g. 500req)")
+	command.Flags().DurationVar(&f.maxDuration, "max-duration", 0, "Stop sending requests to the API after this long (e.g. 1h)")
+}
+
+// Apply adds the budget set by the options to the interceptors of
+// apiClient and returns it, or returns nil when the options set no limit.
+func (f *Flags) Apply(apiClient *strava.Client) (*strava.Budget, error) {
+	requests, err := ParseRequests(f.requests)
+	if err != nil {
+		return nil, err
+	}
+	if f.maxDuration < 0 {
+		return nil, fmt.Errorf("Invalid --max-duration %s, expected a positive duration", f.maxDuration)
+	}
+	if requests == 0 && f.maxDuration == 0 {
+		return nil, nil
+	}
+
+	b := &strava.Budget{Requests: requests}
+	if f.maxDuration > 0 {
+		b.Deadline = time.Now().Add(f.maxDuration)
+	}
+	apiClient.Use(b.Interceptor())
+	return b, nil
+}
+
+// ParseRequests parses a number of requests such as 500, 500req or
+// 500requests.
+func ParseRequests(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	number := strings.TrimSpace(strings.ToLower(value))
+	for _, suffix := range []string{"requests", "request", "reqs", "req"} {
+		if strings.HasSuffix(number, suffix) {
+			number = strings.TrimSpace(strings.TrimSuffix(number, suffix))
+			break
+		}
+	}
+
+	requests, err := strconv.Atoi(number)
+	if err != nil || requests <= 0 {
+		return 0, fmt.Errorf("Invalid --budget %q, expected a number of requests such as 500req", value)
+	}
+	return requests, nil
+}
+
+// Describe explains why b stopped a job.
+func Describe(b *strava.Budget) string {
+	if b.Requests > 0 && b.Sent() >= b.Requests {
+		return fmt.Sprintf("Stopped after spending the budget of %d requests", b.Requests)
+	}
+	return "Stopped after running for the --max-duration"
+}
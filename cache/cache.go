@@ -0,0 +1,248 @@
+// Package cache keeps data fetched from the API on disk between runs, such
+// as the streams of activities, which do not change once recorded. The
+// cache is capped in size: once it grows past its maximum, the entries
+// read least recently are evicted.
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSize is the size the cache is capped at unless configured
+// otherwise, in bytes.
+const DefaultMaxSize = 1 << 30
+
+// DefaultPath returns the directory of the cache within the state
+// directory of sutro.
+func DefaultPath(stateDirectory string) string {
+	return path.Join(stateDirectory, "cache")
+}
+
+// Cache is a directory holding a file per entry. Reading an entry updates
+// its modification time, which orders the entries for eviction.
+type Cache struct {
+	directory string
+	maxSize   int64
+
+	mutex sync.Mutex
+	// size is the total size of the entries, or -1 until it is measured.
+	size int64
+}
+
+// Usage describes the entries of a cache.
+type Usage struct {
+	Entries int
+	Size    int64
+}
+
+// Open returns the cache in directory, creating it if needed, capped at
+// maxSize bytes.
+func Open(directory string, maxSize int64) (*Cache, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("Invalid maximum cache size %d, expected a positive number of bytes", maxSize)
+	}
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, err
+	}
+	return &Cache{directory: directory, maxSize: maxSize, size: -1}, nil
+}
+
+// Directory returns the directory holding the entries.
+func (c *Cache) Directory() string {
+	return c.directory
+}
+
+// MaxSize returns the size the cache is capped at, in bytes.
+func (c *Cache) MaxSize() int64 {
+	return c.maxSize
+}
+
+// Get returns the data of an entry, and whether it is cached.
+func (c *Cache) Get(key string) ([]byte, bool) {
+	filename, err := c.filename(key)
+	if err != nil {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(filename, now, now)
+	return data, true
+}
+
+// Put stores the data of an entry, then evicts the least recently read
+// entries if the cache outgrew its maximum size.
+func (c *Cache) Put(key string, data []byte) error {
+	filename, err := c.filename(key)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.size < 0 {
+		usage, err := c.usage()
+		if err != nil {
+			return err
+		}
+		c.size = usage.Size
+	}
+	if info, err := os.Stat(filename); err == nil {
+		c.size -= info.Size()
+	}
+
+	// Entries are written aside then renamed, so that an interrupted write
+	// never leaves a truncated entry behind.
+	temporary := filename + ".tmp"
+	if err := ioutil.WriteFile(temporary, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(temporary, filename); err != nil {
+		os.Remove(temporary)
+		return err
+	}
+	c.size += int64(len(data))
+
+	if c.size > c.maxSize {
+		_, err := c.evict(c.maxSize)
+		return err
+	}
+	return nil
+}
+
+// GC evicts the least recently read entries until the cache fits within
+// maxSize bytes, and returns what it removed.
+func (c *Cache) GC(maxSize int64) (Usage, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.evict(maxSize)
+}
+
+// Usage returns the number and total size of the entries.
+func (c *Cache) Usage() (Usage, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.usage()
+}
+
+func (c *Cache) usage() (Usage, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return Usage{}, err
+	}
+
+	usage := Usage{Entries: len(entries)}
+	for _, entry := range entries {
+		usage.Size += entry.Size()
+	}
+	return usage, nil
+}
+
+// evict removes entries from the least recently read until the cache fits
+// within maxSize bytes.
+func (c *Cache) evict(maxSize int64) (Usage, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return Usage{}, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().Before(entries[j].ModTime())
+	})
+
+	var size int64
+	for _, entry := range entries {
+		size += entry.Size()
+	}
+
+	var removed Usage
+	for _, entry := range entries {
+		if size <= maxSize {
+			break
+		}
+		if err := os.Remove(path.Join(c.directory, entry.Name())); err != nil && !os.IsNotExist(err) {
+			c.size = size
+			return removed, err
+		}
+		size -= entry.Size()
+		removed.Entries++
+		removed.Size += entry.Size()
+	}
+	c.size = size
+	return removed, nil
+}
+
+func (c *Cache) entries() ([]os.FileInfo, error) {
+	infos, err := ioutil.ReadDir(c.directory)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := infos[:0]
+	for _, info := range infos {
+		if info.Mode().IsRegular() && !strings.HasSuffix(info.Name(), ".tmp") {
+			entries = append(entries, info)
+		}
+	}
+	return entries, nil
+}
+
+func (c *Cache) filename(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("Invalid cache key %q", key)
+	}
+	return path.Join(c.directory, key), nil
+}
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a size such as 500MB or 2GB into bytes. A bare number
+// is interpreted as bytes.
+func ParseSize(value string) (int64, error) {
+	trimmed := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(trimmed, unit.suffix) {
+			trimmed = strings.TrimSuffix(trimmed, unit.suffix)
+			multiplier = unit.bytes
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(trimmed), 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("Invalid size %q, expected a value such as 500MB or 2GB", value)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// FormatSize renders a number of bytes with the largest unit it reaches,
+// such as 1.5 GB.
+func FormatSize(bytes int64) string {
+	for _, unit := range sizeUnits[:len(sizeUnits)-1] {
+		if bytes >= unit.bytes {
+			return fmt.Sprintf("%.1f %s", float64(bytes)/float64(unit.bytes), unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
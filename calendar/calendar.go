@@ -0,0 +1,139 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/models"
+)
+
+const timestampLayout = "20060102T150405Z"
+
+// Event is an event of a feed.
+type Event struct {
+	// UID identifies the event across versions of the feed.
+	UID         string
+	Start, End  time.Time
+	Summary     string
+	Description string
+	URL         string
+	Category    string
+	Location    string
+	// Latlng, when set, is the latitude and longitude of the start.
+	Latlng []float64
+}
+
+// Write writes activities as an iCalendar feed, with one event per activity
+// spanning its elapsed time.
+func Write(writer io.Writer, name string, activities []*models.SummaryActivity) error {
+	events := make([]Event, 0, len(activities))
+	for _, activity := range activities {
+		start := time.Time(activity.StartDate)
+		event := Event{
+			UID:         fmt.Sprintf("activity-%d@sutro", activity.ID),
+			Start:       start,
+			End:         start.Add(time.Duration(activity.ElapsedTime) * time.Second),
+			Summary:     activity.Name,
+			Description: describe(activity),
+			URL:         fmt.Sprintf("https://www.strava.com/activities/%d", activity.ID),
+			Category:    string(activity.Type),
+		}
+		if len(activity.StartLatlng) == 2 {
+			event.Latlng = []float64{float64(activity.StartLatlng[0]), float64(activity.StartLatlng[1])}
+			event.Location = fmt.Sprintf("%.5f, %.5f", activity.StartLatlng[0], activity.StartLatlng[1])
+		}
+		events = append(events, event)
+	}
+	return WriteEvents(writer, name, events)
+}
+
+// WriteEvents writes events as an iCalendar feed.
+func WriteEvents(writer io.Writer, name string, events []Event) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//sutro//activities//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:" + escape(name),
+	}
+
+	now := time.Now().UTC().Format(timestampLayout)
+	for _, event := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+event.UID,
+			"DTSTAMP:"+now,
+			"DTSTART:"+event.Start.UTC().Format(timestampLayout),
+			"DTEND:"+event.End.UTC().Format(timestampLayout),
+			"SUMMARY:"+escape(event.Summary),
+		)
+		if event.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+escape(event.Description))
+		}
+		if event.URL != "" {
+			lines = append(lines, "URL:"+event.URL)
+		}
+		if event.Category != "" {
+			lines = append(lines, "CATEGORIES:"+escape(event.Category))
+		}
+		if event.Location != "" {
+			lines = append(lines, "LOCATION:"+escape(event.Location))
+		}
+		if len(event.Latlng) == 2 {
+			lines = append(lines, fmt.Sprintf("GEO:%.5f;%.5f", event.Latlng[0], event.Latlng[1]))
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(fold(line))
+		builder.WriteString("\r\n")
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+func describe(activity *models.SummaryActivity) string {
+	parts := []string{string(activity.Type)}
+	if activity.Distance > 0 {
+		parts = append(parts, format.Kilometers(float64(activity.Distance)))
+	}
+	parts = append(parts, format.Duration(float64(activity.MovingTime))+" moving")
+	if activity.TotalElevationGain > 0 {
+		parts = append(parts, fmt.Sprintf("%.0f m ascent", activity.TotalElevationGain))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// escape escapes the characters that are special in iCalendar text values.
+func escape(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// fold splits a content line into lines of at most 75 octets, continued by
+// a leading space, without breaking multi-byte characters apart.
+func fold(line string) string {
+	var builder strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > 75 {
+			builder.WriteString("\r\n ")
+			length = 1
+		}
+		builder.WriteRune(r)
+		length += size
+	}
+	return builder.String()
+}
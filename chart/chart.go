@@ -0,0 +1,74 @@
+package chart
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a single line of block characters, scaled
+// between the smallest and largest value. NaN values, which stand for
+// missing data points, are rendered as spaces.
+func Sparkline(values []float64) string {
+	min, max := bounds(values)
+
+	var builder strings.Builder
+	for _, value := range values {
+		switch {
+		case math.IsNaN(value):
+			builder.WriteRune(' ')
+		case max == min:
+			builder.WriteRune(sparks[len(sparks)/2])
+		default:
+			index := int((value - min) / (max - min) * float64(len(sparks)-1))
+			builder.WriteRune(sparks[index])
+		}
+	}
+	return builder.String()
+}
+
+// Bars writes one horizontal bar per value, scaled so that the largest
+// value spans width characters. Each bar is preceded by its label and
+// followed by the value as rendered by format.
+func Bars(writer io.Writer, labels []string, values []float64, width int, format func(float64) string) error {
+	_, max := bounds(values)
+
+	labelWidth := 0
+	for _, label := range labels {
+		if len(label) > labelWidth {
+			labelWidth = len(label)
+		}
+	}
+
+	for i, value := range values {
+		length := 0
+		rendered := "-"
+		if !math.IsNaN(value) {
+			rendered = format(value)
+			if max > 0 && value > 0 {
+				length = int(math.Round(value / max * float64(width)))
+			}
+		}
+
+		_, err := fmt.Fprintf(writer, "%-*s │%s %s\n", labelWidth, labels[i], strings.Repeat("█", length), rendered)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bounds(values []float64) (float64, float64) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
+		min = math.Min(min, value)
+		max = math.Max(max, value)
+	}
+	return min, max
+}
@@ -0,0 +1,172 @@
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+// Column is one slice of an elevation profile: its altitude in meters and
+// the grade leading to the next column, in percent.
+type Column struct {
+	Altitude float64
+	Grade    float64
+}
+
+// Profile is an elevation profile resampled into evenly spaced columns.
+type Profile struct {
+	Columns  []Column
+	Distance float64
+	Ascent   float64
+}
+
+var gradeShades = []struct {
+	grade float64
+	r     rune
+	color color.RGBA
+}{
+	{3, '░', color.RGBA{R: 0x7c, G: 0xb3, B: 0x42, A: 0xff}},
+	{6, '▒', color.RGBA{R: 0xfd, G: 0xd8, B: 0x35, A: 0xff}},
+	{10, '▓', color.RGBA{R: 0xfb, G: 0x8c, B: 0x00, A: 0xff}},
+	{math.Inf(1), '█', color.RGBA{R: 0xe5, G: 0x39, B: 0x35, A: 0xff}},
+}
+
+var descentShade = color.RGBA{R: 0x90, G: 0xa4, B: 0xae, A: 0xff}
+
+// WriteASCII renders the profile with one character per column, shading
+// each column by the steepness of its climb.
+func (p Profile) WriteASCII(writer io.Writer, height int) error {
+	if len(p.Columns) == 0 || height < 2 {
+		return errors.New("Nothing to render")
+	}
+
+	min, max := p.altitudeBounds()
+	top, bottom := fmt.Sprintf("%.0f m", max), fmt.Sprintf("%.0f m", min)
+	labelWidth := len(top)
+	if len(bottom) > labelWidth {
+		labelWidth = len(bottom)
+	}
+
+	for row := height - 1; row >= 0; row-- {
+		label := ""
+		if row == height-1 {
+			label = top
+		} else if row == 0 {
+			label = bottom
+		}
+
+		var builder strings.Builder
+		for _, column := range p.Columns {
+			filled := 1
+			if max > min {
+				filled += int(math.Round((column.Altitude - min) / (max - min) * float64(height-1)))
+			}
+			if row < filled {
+				builder.WriteRune(shade(column.Grade))
+			} else {
+				builder.WriteRune(' ')
+			}
+		}
+
+		if _, err := fmt.Fprintf(writer, "%*s │%s\n", labelWidth, label, builder.String()); err != nil {
+			return err
+		}
+	}
+
+	axis := fmt.Sprintf("0 km%*s", len(p.Columns)-4, fmt.Sprintf("%.1f km", p.Distance/1000))
+	_, err := fmt.Fprintf(writer, "%*s └%s\n%*s  %s\n\nTotal ascent: %.0f m  (░ <3%%  ▒ <6%%  ▓ <10%%  █ ≥10%%)\n",
+		labelWidth, "", strings.Repeat("─", len(p.Columns)), labelWidth, "", axis, p.Ascent)
+	return err
+}
+
+// WriteSVG renders the profile as a width by height SVG image.
+func (p Profile) WriteSVG(writer io.Writer, width, height int) error {
+	if len(p.Columns) == 0 {
+		return errors.New("Nothing to render")
+	}
+
+	min, max := p.altitudeBounds()
+	columnWidth := float64(width) / float64(len(p.Columns))
+	plotHeight := float64(height) * 0.85
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	fmt.Fprintf(&builder, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	for i, column := range p.Columns {
+		columnHeight := plotHeight * 0.1
+		if max > min {
+			columnHeight += plotHeight * 0.9 * (column.Altitude - min) / (max - min)
+		}
+		c := shadeColor(column.Grade)
+		fmt.Fprintf(&builder, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="#%02x%02x%02x"/>`+"\n",
+			float64(i)*columnWidth, float64(height)-columnHeight, columnWidth+0.5, columnHeight, c.R, c.G, c.B)
+	}
+	fmt.Fprintf(&builder, `<text x="8" y="20" font-family="sans-serif" font-size="14">%.1f km · ascent %.0f m · %.0f–%.0f m</text>`+"\n",
+		p.Distance/1000, p.Ascent, min, max)
+	builder.WriteString("</svg>\n")
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// WritePNG renders the profile as a width by height PNG image.
+func (p Profile) WritePNG(writer io.Writer, width, height int) error {
+	if len(p.Columns) == 0 {
+		return errors.New("Nothing to render")
+	}
+
+	min, max := p.altitudeBounds()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		column := p.Columns[x*len(p.Columns)/width]
+		columnHeight := 0.1 * float64(height)
+		if max > min {
+			columnHeight += 0.9 * float64(height) * (column.Altitude - min) / (max - min)
+		}
+		for y := 0; y < height; y++ {
+			if float64(height-y) <= columnHeight {
+				img.Set(x, y, shadeColor(column.Grade))
+			} else {
+				img.Set(x, y, color.White)
+			}
+		}
+	}
+	return png.Encode(writer, img)
+}
+
+func (p Profile) altitudeBounds() (float64, float64) {
+	altitudes := make([]float64, len(p.Columns))
+	for i, column := range p.Columns {
+		altitudes[i] = column.Altitude
+	}
+	return bounds(altitudes)
+}
+
+func shade(grade float64) rune {
+	if grade < 0 {
+		return '░'
+	}
+	for _, s := range gradeShades {
+		if grade < s.grade {
+			return s.r
+		}
+	}
+	return '█'
+}
+
+func shadeColor(grade float64) color.RGBA {
+	if grade < 0 {
+		return descentShade
+	}
+	for _, s := range gradeShades {
+		if grade < s.grade {
+			return s.color
+		}
+	}
+	return gradeShades[len(gradeShades)-1].color
+}
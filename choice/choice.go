@@ -0,0 +1,63 @@
+// Package choice defines flags restricted to a set of values, such as the
+// types of activities, which are checked as the flags are parsed and which
+// shells complete.
+package choice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// value is a string flag matching one of choices regardless of case, and
+// set to the spelling of the choice.
+type value struct {
+	target  *string
+	choices []string
+}
+
+func (v *value) String() string {
+	return *v.target
+}
+
+func (v *value) Set(s string) error {
+	for _, choice := range v.choices {
+		if strings.EqualFold(choice, strings.TrimSpace(s)) {
+			*v.target = choice
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of %s", strings.Join(v.choices, ", "))
+}
+
+func (v *value) Type() string {
+	return "string"
+}
+
+// Var defines a flag of command restricted to choices, with initial as its
+// default.
+func Var(command *cobra.Command, target *string, name, initial, usage string, choices ...string) {
+	*target = initial
+	command.Flags().Var(&value{target, choices}, name, usage)
+	_ = command.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var matches []string
+		for _, choice := range choices {
+			if strings.HasPrefix(strings.ToLower(choice), strings.ToLower(toComplete)) {
+				matches = append(matches, choice)
+			}
+		}
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// ActivityTypeVar defines a flag of command restricted to the types of
+// activities, which is empty by default.
+func ActivityTypeVar(command *cobra.Command, target *string, name, usage string) {
+	types := make([]string, len(strava.ActivityTypes))
+	for i, activityType := range strava.ActivityTypes {
+		types[i] = string(activityType)
+	}
+	Var(command, target, name, "", usage, types...)
+}
@@ -0,0 +1,44 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// Commands returns the hand-written commands that complement the
+// generated activities client.
+func Commands(ctx context.Context, apiClient *strava.Client, archive *store.Store, configuration config.Configuration) []*cobra.Command {
+	return []*cobra.Command{
+		autoCommuteCommand(ctx, apiClient, archive),
+		climbsCommand(ctx, apiClient, archive),
+		compareCommand(ctx, apiClient),
+		correctElevationCommand(ctx, apiClient, archive, configuration),
+		createCommand(ctx, apiClient, archive),
+		dedupeCommand(ctx, apiClient, archive),
+		downloadOriginalCommand(ctx, apiClient, archive, configuration),
+		effortsCommand(ctx, apiClient),
+		exportCommand(ctx, apiClient, archive, configuration),
+		intervalsCommand(ctx, apiClient),
+		lintCommand(ctx, apiClient, archive, configuration),
+		mapCommand(ctx, apiClient, archive),
+		photosCommand(ctx, apiClient),
+		profileCommand(ctx, apiClient),
+		undoCommand(ctx, apiClient, archive),
+		updateCommand(ctx, apiClient, archive),
+		zonesCommand(ctx, apiClient, archive),
+	}
+}
+
+func parseID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid activity id %q", arg)
+	}
+	return id, nil
+}
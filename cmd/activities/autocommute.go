@@ -0,0 +1,183 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/batch"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/prompt"
+	"github.com/jsilland/sutro/scopes"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type autoCommuteFlags struct {
+	reference int64
+	tolerance string
+	overlap   float64
+	since     string
+	dryRun    bool
+	yes       bool
+	failures  *batch.Flags
+}
+
+func autoCommuteCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store) *cobra.Command {
+	flags := autoCommuteFlags{}
+
+	command := &cobra.Command{
+		Use:   "auto-commute",
+		Short: "Flag synced activities following a reference commute as commutes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return autoCommute(ctx, cmd, apiClient, archive, flags)
+		},
+	}
+
+	command.Flags().Int64Var(&flags.reference, "reference", 0, "The id of an activity following the commute route")
+	command.MarkFlagRequired("reference")
+	command.Flags().StringVar(&flags.tolerance, "tolerance", "100m", "How far from the reference route an activity may stray")
+	command.Flags().Float64Var(&flags.overlap, "overlap", 0.9, "The fraction of each track that must lie within tolerance of the other")
+	command.Flags().StringVar(&flags.since, "since", "", "Only consider activities started after this date")
+	command.Flags().BoolVar(&flags.dryRun, "dry-run", false, "List the matching activities without updating them")
+	command.Flags().BoolVar(&flags.yes, "yes", false, "Update the matching activities without asking for confirmation")
+	flags.failures = batch.Var(command, false)
+
+	return scopes.Require(command, "activity:write")
+}
+
+func autoCommute(ctx context.Context, cmd *cobra.Command, apiClient *strava.Client, archive *store.Store, flags autoCommuteFlags) error {
+	tolerance, err := geo.ParseDistance(flags.tolerance)
+	if err != nil {
+		return err
+	}
+
+	reference, err := archivedOrFetched(ctx, apiClient, archive, flags.reference)
+	if err != nil {
+		return err
+	}
+
+	spacing := math.Max(10, tolerance/2)
+	referenceTrack, err := track(reference, spacing)
+	if err != nil {
+		return err
+	}
+	if len(referenceTrack) == 0 {
+		return fmt.Errorf("Activity %d has no map to match other activities against", reference.ID)
+	}
+
+	query := store.Query{Type: string(reference.Type)}
+	if flags.since != "" {
+		if query.After, err = dates.Parse(flags.since); err != nil {
+			return err
+		}
+	}
+
+	candidates, err := archive.Activities(query)
+	if err != nil {
+		return err
+	}
+
+	var matches []*models.SummaryActivity
+	for _, candidate := range candidates {
+		if candidate.Commute || candidate.ID == reference.ID {
+			continue
+		}
+
+		points, err := track(candidate, spacing)
+		if err != nil {
+			return err
+		}
+		if geo.SameCourse(referenceTrack, points, tolerance, flags.overlap) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	if len(matches) == 0 {
+		fmt.Println("No synced activity follows the reference route without already being a commute")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "DATE\tID\tNAME")
+	for _, match := range matches {
+		fmt.Fprintf(writer, "%s\t%d\t%s\n", dates.Start(time.Time(match.StartDate), time.Time(match.StartDateLocal)).Format("2006-01-02 15:04"), match.ID, match.Name)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	for _, match := range matches {
+		history.Returned(ctx, match.ID)
+	}
+
+	if flags.dryRun {
+		fmt.Printf("Dry run: %d activities would be flagged as commutes\n", len(matches))
+		return nil
+	}
+
+	if !flags.yes {
+		confirmed, err := prompt.Boolean(fmt.Sprintf("Flag these %d activities as commutes?", len(matches)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return nil
+		}
+	}
+
+	run, err := flags.failures.Start(cmd, len(matches), "activities")
+	if err != nil {
+		return err
+	}
+	commute := true
+	for _, match := range matches {
+		match := match
+		err := run.Do(ctx, fmt.Sprintf("activity %d", match.ID), func() error {
+			updated, err := apiClient.Activities.Update(ctx, match.ID, &models.UpdatableActivity{Commute: &commute})
+			if err != nil {
+				return fmt.Errorf("Failed to update activity %d: %v", match.ID, err)
+			}
+			return archive.PutActivities([]*models.SummaryActivity{&updated.SummaryActivity})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Flagged %d activities as commutes\n", run.Succeeded())
+	return run.Done()
+}
+
+// archivedOrFetched returns the activity from the archive, falling back to
+// the API for activities that have not been synced yet.
+func archivedOrFetched(ctx context.Context, apiClient *strava.Client, archive *store.Store, id int64) (*models.SummaryActivity, error) {
+	activity, err := archive.Activity(id)
+	if err != nil || activity != nil {
+		return activity, err
+	}
+
+	fetched, err := apiClient.Activities.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &fetched.SummaryActivity, nil
+}
+
+func track(activity *models.SummaryActivity, spacing float64) ([]geo.Point, error) {
+	if activity.Map == nil || activity.Map.SummaryPolyline == "" {
+		return nil, nil
+	}
+
+	points, err := geo.DecodePolyline(activity.Map.SummaryPolyline)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode the map of activity %d: %v", activity.ID, err)
+	}
+	return geo.Resample(points, spacing), nil
+}
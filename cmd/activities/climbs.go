@@ -0,0 +1,350 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/stream"
+	"github.com/spf13/cobra"
+)
+
+// climbKeys are the streams climbs are detected in.
+var climbKeys = []string{"time", "distance", "altitude"}
+
+// gradeSpan is the distance, in meters, the grade of each sample is
+// measured over, which evens out the noise of altitudes.
+const gradeSpan = 100
+
+// movingSpeed is the speed, in meters per second, under which the time
+// between two samples is a pause rather than time spent on a grade.
+const movingSpeed = 0.5
+
+// gradeBands are the upper bounds of the bands of grade, in percent, that
+// time is spent in. The last band has no upper bound.
+var gradeBands = []float64{-8, -4, -1, 1, 4, 8, 12}
+
+type climbsFlags struct {
+	minLength    string
+	minGrade     float64
+	since        string
+	until        string
+	activityType string
+	top          int
+	format       string
+}
+
+// climb is a climb detected in the streams of an activity.
+type climb struct {
+	ActivityID int64  `json:"activity_id"`
+	Activity   string `json:"activity"`
+	Date       string `json:"date"`
+	// Start is the distance the climb starts at, in meters.
+	Start  float64 `json:"start"`
+	Length float64 `json:"length"`
+	Gain   float64 `json:"elevation_gain"`
+	Grade  float64 `json:"average_grade"`
+	// Time is in seconds, and VAM, the rate of ascent, in meters per hour;
+	// both are omitted when the activity has no time stream.
+	Time *float64 `json:"time,omitempty"`
+	VAM  *float64 `json:"vam,omitempty"`
+}
+
+// band is the time spent on the grades between From and To, in percent.
+type band struct {
+	From    *float64 `json:"from,omitempty"`
+	To      *float64 `json:"to,omitempty"`
+	Seconds float64  `json:"seconds"`
+}
+
+// climbsTotals add up the climbs of the activities of a summary.
+type climbsTotals struct {
+	Climbs int     `json:"climbs"`
+	Length float64 `json:"length"`
+	Gain   float64 `json:"elevation_gain"`
+	// Time and VAM only account for the climbs of activities with a time
+	// stream.
+	Time float64  `json:"time"`
+	VAM  *float64 `json:"vam,omitempty"`
+}
+
+type climbsReport struct {
+	Activities  int           `json:"activities"`
+	Totals      *climbsTotals `json:"totals,omitempty"`
+	Climbs      []climb       `json:"climbs"`
+	TimeInGrade []band        `json:"time_in_grade"`
+}
+
+func climbsCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store) *cobra.Command {
+	flags := climbsFlags{}
+
+	command := &cobra.Command{
+		Use:   "climbs [<id>...]",
+		Short: "Detect the climbs of activities and the time they spent on each grade",
+		Long: "Detect the climbs in the altitude and distance streams of activities, at least " +
+			"--min-length long and --min-grade steep on average, and report the length, elevation " +
+			"gain, average grade, time and VAM, the rate of ascent in meters per hour, of each along " +
+			"with the time spent moving on each band of grade.\n\n" +
+			"Without ids, the climbs of every synced activity since --since and until --until are " +
+			"summed up, and the --top climbs that gained the most elevation listed. Only the " +
+			"activities whose streams are cached are included, so that the summary runs without " +
+			"requests to the API: cache them first with sutro sync streams.",
+		Example: "  sutro activities climbs 1234 --min-length 1km --min-grade 4\n" +
+			"  sutro activities climbs --since 2024 --type Ride --top 20",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return climbs(ctx, apiClient, archive, args, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.minLength, "min-length", "500m", "The shortest climb to detect")
+	command.Flags().Float64Var(&flags.minGrade, "min-grade", 3, "The lowest average grade of a climb, in percent")
+	command.Flags().StringVar(&flags.since, "since", "", "Without ids, only include activities started after this date")
+	command.Flags().StringVar(&flags.until, "until", "", "Without ids, only include activities started before this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Without ids, only include activities of this type (e.g. Ride)")
+	command.Flags().IntVar(&flags.top, "top", 10, "Without ids, the number of climbs to list, those that gained the most elevation")
+	choice.Var(command, &flags.format, "format", "table", "The format of the report: table or json", "table", "json")
+
+	return command
+}
+
+func climbs(ctx context.Context, apiClient *strava.Client, archive *store.Store, args []string, flags climbsFlags) error {
+	minLength, err := geo.ParseDistance(flags.minLength)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 && (flags.since != "" || flags.until != "" || flags.activityType != "") {
+		return errors.New("--since, --until and --type select synced activities, and cannot be combined with ids")
+	}
+
+	report := climbsReport{TimeInGrade: newBands()}
+	if len(args) > 0 {
+		for _, arg := range args {
+			id, err := parseID(arg)
+			if err != nil {
+				return err
+			}
+			activity, err := archivedOrFetched(ctx, apiClient, archive, id)
+			if err != nil {
+				return err
+			}
+			set, err := apiClient.Streams.Activity(ctx, id, climbKeys...)
+			if err != nil {
+				return err
+			}
+			if !report.add(activity, set, minLength, flags.minGrade) {
+				return fmt.Errorf("Activity %d has no elevation data", id)
+			}
+			history.Returned(ctx, id)
+		}
+		return report.write(flags.format, len(args) > 1, 0)
+	}
+
+	query := store.Query{Type: flags.activityType}
+	if flags.since != "" {
+		if query.After, err = dates.Parse(flags.since); err != nil {
+			return err
+		}
+	}
+	if flags.until != "" {
+		if query.Before, err = dates.Parse(flags.until); err != nil {
+			return err
+		}
+	}
+	synced, uncached := 0, 0
+	err = archive.EachActivity(query, func(activity *models.SummaryActivity) error {
+		synced++
+		if !apiClient.Streams.Cached(activity.ID, climbKeys...) {
+			uncached++
+			return nil
+		}
+		set, err := apiClient.Streams.Activity(ctx, activity.ID, climbKeys...)
+		if err != nil {
+			return fmt.Errorf("Failed to read the streams of activity %d: %v", activity.ID, err)
+		}
+		report.add(activity, set, minLength, flags.minGrade)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if synced == 0 {
+		return errors.New("No synced activity matches, have you run sutro sync?")
+	}
+	if uncached > 0 {
+		fmt.Fprintf(os.Stderr, "Left out %d of %d activities whose streams are not cached, fetch them with sutro sync streams\n", uncached, synced)
+	}
+	if report.Activities == 0 {
+		return errors.New("No matching activity has cached elevation data")
+	}
+
+	sort.SliceStable(report.Climbs, func(i, j int) bool {
+		return report.Climbs[i].Gain > report.Climbs[j].Gain
+	})
+	return report.write(flags.format, true, flags.top)
+}
+
+func newBands() []band {
+	bands := make([]band, len(gradeBands)+1)
+	for i := range bands {
+		if i > 0 {
+			from := gradeBands[i-1]
+			bands[i].From = &from
+		}
+		if i < len(gradeBands) {
+			to := gradeBands[i]
+			bands[i].To = &to
+		}
+	}
+	return bands
+}
+
+// add adds the climbs of an activity and the time it spent on each grade to
+// the report, and returns false when the activity has no elevation data.
+func (r *climbsReport) add(activity *models.SummaryActivity, set *models.StreamSet, minLength, minGrade float64) bool {
+	times, distances, altitudes := stream.Times(set), stream.Distances(set), stream.Altitudes(set)
+	if len(distances) == 0 || len(altitudes) != len(distances) {
+		return false
+	}
+	if len(times) != len(distances) {
+		times = nil
+	}
+	r.Activities++
+
+	date := dates.Start(time.Time(activity.StartDate), time.Time(activity.StartDateLocal)).Format("2006-01-02")
+	for _, c := range stream.Climbs(distances, altitudes, minLength, minGrade) {
+		length, gain := distances[c.To]-distances[c.From], altitudes[c.To]-altitudes[c.From]
+		detected := climb{
+			ActivityID: activity.ID,
+			Activity:   activity.Name,
+			Date:       date,
+			Start:      distances[c.From],
+			Length:     length,
+			Gain:       gain,
+			Grade:      gain / length * 100,
+		}
+		if times != nil && times[c.To] > times[c.From] {
+			elapsed := times[c.To] - times[c.From]
+			vam := gain / elapsed * 3600
+			detected.Time, detected.VAM = &elapsed, &vam
+		}
+		r.Climbs = append(r.Climbs, detected)
+	}
+
+	for i := 1; i < len(times); i++ {
+		elapsed, covered := times[i]-times[i-1], distances[i]-distances[i-1]
+		if elapsed <= 0 || covered/elapsed < movingSpeed {
+			continue
+		}
+		middle := (distances[i] + distances[i-1]) / 2
+		rise := stream.Interpolate(distances, altitudes, middle+gradeSpan/2) - stream.Interpolate(distances, altitudes, middle-gradeSpan/2)
+		if math.IsNaN(rise) {
+			continue
+		}
+		grade := rise / gradeSpan * 100
+		b := sort.SearchFloat64s(gradeBands, grade)
+		r.TimeInGrade[b].Seconds += elapsed
+	}
+	return true
+}
+
+// write prints the report. Summaries add up the climbs of all activities
+// before only listing the top ones, or all of them when top is 0.
+func (r *climbsReport) write(outputFormat string, summary bool, top int) error {
+	if summary {
+		r.Totals = total(r.Climbs)
+	}
+	if top > 0 && len(r.Climbs) > top {
+		r.Climbs = r.Climbs[:top]
+	}
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+
+	if t := r.Totals; t != nil {
+		fmt.Printf("%d climbs in %d activities: %s, %.0f m of elevation gain", t.Climbs, r.Activities, format.Kilometers(t.Length), t.Gain)
+		if t.VAM != nil {
+			fmt.Printf(", %s climbing at %.0f m/h", format.Duration(t.Time), *t.VAM)
+		}
+		fmt.Print("\n\n")
+	}
+	if len(r.Climbs) == 0 {
+		fmt.Println("No climb detected")
+	} else {
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if summary {
+			fmt.Fprint(writer, "DATE\tACTIVITY\t")
+		}
+		fmt.Fprintln(writer, "START\tLENGTH\tGAIN\tGRADE\tTIME\tVAM")
+		for _, c := range r.Climbs {
+			if summary {
+				fmt.Fprintf(writer, "%s\t%s\t", c.Date, c.Activity)
+			}
+			elapsed, vam := "-", "-"
+			if c.Time != nil {
+				elapsed, vam = format.Duration(*c.Time), fmt.Sprintf("%.0f m/h", *c.VAM)
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%.0f m\t%.1f%%\t%s\t%s\n", format.Kilometers(c.Start), format.Kilometers(c.Length), c.Gain, c.Grade, elapsed, vam)
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+
+	moving := 0.0
+	for _, b := range r.TimeInGrade {
+		moving += b.Seconds
+	}
+	if moving == 0 {
+		return nil
+	}
+	fmt.Println()
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "GRADE\tTIME\tSHARE")
+	for _, b := range r.TimeInGrade {
+		var bounds string
+		switch {
+		case b.From == nil:
+			bounds = fmt.Sprintf("under %.0f%%", *b.To)
+		case b.To == nil:
+			bounds = fmt.Sprintf("over %.0f%%", *b.From)
+		default:
+			bounds = fmt.Sprintf("%.0f to %.0f%%", *b.From, *b.To)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%.0f%%\n", bounds, format.Duration(b.Seconds), b.Seconds/moving*100)
+	}
+	return writer.Flush()
+}
+
+func total(climbs []climb) *climbsTotals {
+	t := &climbsTotals{Climbs: len(climbs)}
+	timedGain := 0.0
+	for _, c := range climbs {
+		t.Length += c.Length
+		t.Gain += c.Gain
+		if c.Time != nil {
+			t.Time += *c.Time
+			timedGain += c.Gain
+		}
+	}
+	if t.Time > 0 {
+		vam := timedGain / t.Time * 3600
+		t.VAM = &vam
+	}
+	return t
+}
@@ -0,0 +1,232 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/stream"
+	"github.com/spf13/cobra"
+)
+
+type compareFlags struct {
+	by    string
+	split float64
+}
+
+type compared struct {
+	id         int64
+	times      []float64
+	distances  []float64
+	heartrates []float64
+	watts      []float64
+}
+
+func compareCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := compareFlags{}
+
+	command := &cobra.Command{
+		Use:   "compare <id1> <id2>",
+		Short: "Compare two activities split by split",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return compare(ctx, apiClient, args, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.by, "by", "distance", "How to align the activities: distance or time")
+	command.Flags().Float64Var(&flags.split, "split", 0, "The split length, in meters or seconds (defaults to 1000m or 300s)")
+
+	return command
+}
+
+func compare(ctx context.Context, apiClient *strava.Client, args []string, flags compareFlags) error {
+	if flags.by != "distance" && flags.by != "time" {
+		return fmt.Errorf("Invalid alignment %q, expected distance or time", flags.by)
+	}
+
+	split := flags.split
+	if split <= 0 && flags.by == "distance" {
+		split = 1000
+	} else if split <= 0 {
+		split = 300
+	}
+
+	var activities [2]*compared
+	for i, arg := range args {
+		id, err := parseID(arg)
+		if err != nil {
+			return err
+		}
+
+		set, err := apiClient.Streams.Activity(ctx, id, "time", "distance", "heartrate", "watts")
+		if err != nil {
+			return err
+		}
+
+		activities[i] = &compared{
+			id:         id,
+			times:      stream.Times(set),
+			distances:  stream.Distances(set),
+			heartrates: stream.Heartrates(set),
+			watts:      stream.Watts(set),
+		}
+		if len(activities[i].times) == 0 || len(activities[i].distances) == 0 {
+			return fmt.Errorf("Activity %d has no time or distance data to compare", id)
+		}
+	}
+
+	fmt.Printf("A is %d, B is %d; Delta and Gap are A's advantage over B within each split and overall.\n\n", activities[0].id, activities[1].id)
+	if flags.by == "distance" {
+		return compareByDistance(activities[0], activities[1], split)
+	}
+	return compareByTime(activities[0], activities[1], split)
+}
+
+func compareByDistance(a, b *compared, split float64) error {
+	total := math.Min(last(a.distances), last(b.distances))
+	if total <= 0 {
+		return errors.New("The activities do not cover any common distance")
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "SPLIT\tA TIME\tB TIME\tDELTA\tGAP\tFASTER\tA HR\tB HR\tA W\tB W\t")
+
+	aFaster, bFaster := 0, 0
+	for from := 0.0; from < total; from += split {
+		to := math.Min(from+split, total)
+		aTime := stream.Interpolate(a.distances, a.times, to) - stream.Interpolate(a.distances, a.times, from)
+		bTime := stream.Interpolate(b.distances, b.times, to) - stream.Interpolate(b.distances, b.times, from)
+		gap := stream.Interpolate(b.distances, b.times, to) - stream.Interpolate(a.distances, a.times, to)
+
+		faster := fasterOf(bTime - aTime)
+		if faster == "A" {
+			aFaster++
+		} else if faster == "B" {
+			bFaster++
+		}
+
+		fmt.Fprintf(writer, "%.2f km\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			to/1000,
+			format.Duration(aTime),
+			format.Duration(bTime),
+			formatDelta(bTime-aTime),
+			formatDelta(gap),
+			faster,
+			formatMean(a.distances, a.heartrates, from, to),
+			formatMean(b.distances, b.heartrates, from, to),
+			formatMean(a.distances, a.watts, from, to),
+			formatMean(b.distances, b.watts, from, to),
+		)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	gap := stream.Interpolate(b.distances, b.times, total) - stream.Interpolate(a.distances, a.times, total)
+	fmt.Printf("\nOver %.2f km, %d was faster in %d splits and %d in %d splits; ", total/1000, a.id, aFaster, b.id, bFaster)
+	switch {
+	case gap > 0:
+		fmt.Printf("%d finished %s ahead.\n", a.id, format.Duration(gap))
+	case gap < 0:
+		fmt.Printf("%d finished %s ahead.\n", b.id, format.Duration(-gap))
+	default:
+		fmt.Println("they finished level.")
+	}
+	return nil
+}
+
+func compareByTime(a, b *compared, split float64) error {
+	total := math.Min(last(a.times), last(b.times))
+	if total <= 0 {
+		return errors.New("The activities do not cover any common duration")
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "SPLIT\tA DIST\tB DIST\tDELTA\tGAP\tFASTER\tA HR\tB HR\tA W\tB W\t")
+
+	aFaster, bFaster := 0, 0
+	for from := 0.0; from < total; from += split {
+		to := math.Min(from+split, total)
+		aDistance := stream.Interpolate(a.times, a.distances, to) - stream.Interpolate(a.times, a.distances, from)
+		bDistance := stream.Interpolate(b.times, b.distances, to) - stream.Interpolate(b.times, b.distances, from)
+		gap := stream.Interpolate(a.times, a.distances, to) - stream.Interpolate(b.times, b.distances, to)
+
+		faster := fasterOf(aDistance - bDistance)
+		if faster == "A" {
+			aFaster++
+		} else if faster == "B" {
+			bFaster++
+		}
+
+		fmt.Fprintf(writer, "%s\t%.0f m\t%.0f m\t%+.0f m\t%+.0f m\t%s\t%s\t%s\t%s\t%s\t\n",
+			format.Duration(to),
+			aDistance,
+			bDistance,
+			aDistance-bDistance,
+			gap,
+			faster,
+			formatMean(a.times, a.heartrates, from, to),
+			formatMean(b.times, b.heartrates, from, to),
+			formatMean(a.times, a.watts, from, to),
+			formatMean(b.times, b.watts, from, to),
+		)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	gap := stream.Interpolate(a.times, a.distances, total) - stream.Interpolate(b.times, b.distances, total)
+	fmt.Printf("\nOver %s, %d was faster in %d splits and %d in %d splits; ", format.Duration(total), a.id, aFaster, b.id, bFaster)
+	switch {
+	case gap > 0:
+		fmt.Printf("%d covered %.0f m more.\n", a.id, gap)
+	case gap < 0:
+		fmt.Printf("%d covered %.0f m more.\n", b.id, -gap)
+	default:
+		fmt.Println("they covered the same distance.")
+	}
+	return nil
+}
+
+// fasterOf returns which activity was faster given how much better A did
+// than B over a split, in seconds or meters.
+func fasterOf(advantage float64) string {
+	switch {
+	case advantage > 0:
+		return "A"
+	case advantage < 0:
+		return "B"
+	default:
+		return "="
+	}
+}
+
+func formatMean(xs, ys []float64, from, to float64) string {
+	if ys == nil {
+		return "-"
+	}
+	mean := stream.Mean(xs, ys, from, to)
+	if math.IsNaN(mean) {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f", mean)
+}
+
+func formatDelta(seconds float64) string {
+	if seconds < 0 {
+		return format.Duration(seconds)
+	}
+	return "+" + format.Duration(seconds)
+}
+
+func last(values []float64) float64 {
+	return values[len(values)-1]
+}
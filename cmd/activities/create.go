@@ -0,0 +1,123 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/description"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/scopes"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type createFlags struct {
+	name         string
+	activityType string
+	start        string
+	duration     string
+	distance     string
+	description  string
+	template     string
+	trainer      bool
+	commute      bool
+}
+
+func createCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store) *cobra.Command {
+	flags := createFlags{}
+
+	command := &cobra.Command{
+		Use:   "create",
+		Short: "Create a manual activity, for workouts recorded without a file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return create(ctx, apiClient, archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.name, "name", "", "The name of the activity")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "The type of the activity (e.g. Run, Ride or Workout)")
+	command.Flags().StringVar(&flags.start, "start", "", "The local start time of the activity (e.g. 2024-05-01T18:00)")
+	command.Flags().StringVar(&flags.duration, "duration", "", "The elapsed time of the activity (e.g. 45m, 1h30m or 1:30:00)")
+	command.Flags().StringVar(&flags.distance, "distance", "", "The distance covered (e.g. 5km or 3mi)")
+	command.Flags().StringVar(&flags.description, "description", "", "The description of the activity")
+	command.Flags().StringVar(&flags.template, "description-template", "", "A Go template rendering the description from the created activity, inline or as @path")
+	command.Flags().BoolVar(&flags.trainer, "trainer", false, "Mark the activity as done on a trainer")
+	command.Flags().BoolVar(&flags.commute, "commute", false, "Mark the activity as a commute")
+	for _, name := range []string{"name", "type", "start", "duration"} {
+		_ = command.MarkFlagRequired(name)
+	}
+
+	return scopes.Require(command, "activity:write")
+}
+
+func create(ctx context.Context, apiClient *strava.Client, archive *store.Store, flags createFlags) error {
+	if strings.TrimSpace(flags.name) == "" {
+		return errors.New("The name of the activity cannot be empty")
+	}
+	activityType, err := strava.ParseActivityType(flags.activityType)
+	if err != nil {
+		return err
+	}
+	start, err := dates.Parse(flags.start)
+	if err != nil {
+		return err
+	}
+	duration, err := dates.ParseDuration(flags.duration)
+	if err != nil {
+		return err
+	}
+	if flags.description != "" && flags.template != "" {
+		return errors.New("Pass either --description or --description-template")
+	}
+	template, err := description.Read(flags.template, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	activity := strava.NewActivity{
+		Name:        flags.name,
+		Type:        activityType,
+		Start:       start,
+		ElapsedTime: duration,
+		Description: flags.description,
+		Trainer:     flags.trainer,
+		Commute:     flags.commute,
+	}
+	if flags.distance != "" {
+		meters, err := geo.ParseDistance(flags.distance)
+		if err != nil {
+			return err
+		}
+		activity.Distance = meters
+	}
+
+	created, err := apiClient.Activities.Create(ctx, activity)
+	if err != nil {
+		return fmt.Errorf("Failed to create the activity: %v", err)
+	}
+	// The template renders fields that Strava sets, such as the gear.
+	if template != nil {
+		described, err := template.Apply(ctx, apiClient, created)
+		if err != nil {
+			return fmt.Errorf("Created activity %d without its description: %v", created.ID, err)
+		}
+		created = described
+	}
+
+	if err := archive.PutActivities([]*models.SummaryActivity{&created.SummaryActivity}); err != nil {
+		return err
+	}
+
+	history.Returned(ctx, created.ID)
+	fmt.Printf("Created %s activity %d, %q\n", activityType, created.ID, created.Name)
+	return nil
+}
@@ -0,0 +1,213 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/batch"
+	"github.com/jsilland/sutro/browser"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/prompt"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+const duplicatePrefix = "[Duplicate] "
+
+type dedupeFlags struct {
+	since             string
+	timeWindow        time.Duration
+	distanceTolerance float64
+	trackTolerance    string
+	open              bool
+	flag              bool
+	yes               bool
+	failures          *batch.Flags
+}
+
+type duplicate struct {
+	original  *models.SummaryActivity
+	duplicate *models.SummaryActivity
+}
+
+func dedupeCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store) *cobra.Command {
+	flags := dedupeFlags{}
+
+	command := &cobra.Command{
+		Use:   "dedupe",
+		Short: "Find synced activities that were recorded more than once",
+		Long: "Find pairs of synced activities with nearly identical start times, distances and tracks, " +
+			"as happens when both a watch and a phone upload the same workout. The most recently " +
+			"uploaded activity of each pair is considered the duplicate.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return dedupe(ctx, cmd, apiClient, archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.since, "since", "", "Only scan activities started after this date")
+	command.Flags().DurationVar(&flags.timeWindow, "time-window", 5*time.Minute, "How far apart the start times of duplicates may be")
+	command.Flags().Float64Var(&flags.distanceTolerance, "distance-tolerance", 0.05, "How much the distances of duplicates may differ, as a fraction")
+	command.Flags().StringVar(&flags.trackTolerance, "track-tolerance", "100m", "How far apart the tracks of duplicates may be")
+	command.Flags().BoolVar(&flags.open, "open", false, "Open each pair of duplicates on Strava in the browser")
+	command.Flags().BoolVar(&flags.flag, "flag", false, "Prefix the name of each duplicate with "+strings.TrimSpace(duplicatePrefix)+" so it is easy to find and delete on Strava")
+	command.Flags().BoolVar(&flags.yes, "yes", false, "Flag the duplicates without asking for confirmation")
+	flags.failures = batch.Var(command, false)
+
+	return command
+}
+
+func dedupe(ctx context.Context, cmd *cobra.Command, apiClient *strava.Client, archive *store.Store, flags dedupeFlags) error {
+	tolerance, err := geo.ParseDistance(flags.trackTolerance)
+	if err != nil {
+		return err
+	}
+
+	query := store.Query{}
+	if flags.since != "" {
+		if query.After, err = dates.Parse(flags.since); err != nil {
+			return err
+		}
+	}
+
+	candidates, err := archive.Activities(query)
+	if err != nil {
+		return err
+	}
+
+	duplicates, err := findDuplicates(candidates, flags.timeWindow, flags.distanceTolerance, tolerance)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		fmt.Printf("No duplicate found among %d synced activities\n", len(candidates))
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "START\tORIGINAL\tDUPLICATE\tNAME\tDISTANCES\tSTART GAP")
+	for _, d := range duplicates {
+		gap := time.Time(d.duplicate.StartDate).Sub(time.Time(d.original.StartDate))
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\t%s / %s\t%s\n",
+			dates.Start(time.Time(d.original.StartDate), time.Time(d.original.StartDateLocal)).Format("2006-01-02 15:04"),
+			d.original.ID,
+			d.duplicate.ID,
+			d.original.Name,
+			format.Kilometers(float64(d.original.Distance)),
+			format.Kilometers(float64(d.duplicate.Distance)),
+			format.Duration(math.Abs(gap.Seconds())),
+		)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	for _, d := range duplicates {
+		history.Returned(ctx, d.duplicate.ID)
+	}
+
+	if flags.open {
+		for _, d := range duplicates {
+			for _, activity := range []*models.SummaryActivity{d.original, d.duplicate} {
+				if err := browser.Open(fmt.Sprintf("https://www.strava.com/activities/%d", activity.ID)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if !flags.flag {
+		return nil
+	}
+
+	if !flags.yes {
+		confirmed, err := prompt.Boolean(fmt.Sprintf("Rename these %d duplicates with a %s prefix?", len(duplicates), strings.TrimSpace(duplicatePrefix)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return nil
+		}
+	}
+
+	run, err := flags.failures.Start(cmd, len(duplicates), "duplicates")
+	if err != nil {
+		return err
+	}
+	for _, d := range duplicates {
+		d := d
+		err := run.Do(ctx, fmt.Sprintf("activity %d", d.duplicate.ID), func() error {
+			if strings.HasPrefix(d.duplicate.Name, duplicatePrefix) {
+				return nil
+			}
+
+			updated, err := apiClient.Activities.Update(ctx, d.duplicate.ID, &models.UpdatableActivity{Name: duplicatePrefix + d.duplicate.Name})
+			if err != nil {
+				return fmt.Errorf("Failed to update activity %d: %v", d.duplicate.ID, err)
+			}
+			return archive.PutActivities([]*models.SummaryActivity{&updated.SummaryActivity})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Flagged %d duplicates\n", run.Succeeded())
+	return run.Done()
+}
+
+// findDuplicates compares every activity with the ones that started within
+// window after it. Activities must be sorted by start date.
+func findDuplicates(candidates []*models.SummaryActivity, window time.Duration, distanceTolerance, trackTolerance float64) ([]duplicate, error) {
+	spacing := math.Max(10, trackTolerance/2)
+	tracks := map[int64][]geo.Point{}
+	trackOf := func(activity *models.SummaryActivity) ([]geo.Point, error) {
+		if points, ok := tracks[activity.ID]; ok {
+			return points, nil
+		}
+		points, err := track(activity, spacing)
+		tracks[activity.ID] = points
+		return points, err
+	}
+
+	var duplicates []duplicate
+	for i, a := range candidates {
+		for _, b := range candidates[i+1:] {
+			if time.Time(b.StartDate).Sub(time.Time(a.StartDate)) > window {
+				break
+			}
+
+			longest := math.Max(float64(a.Distance), float64(b.Distance))
+			if math.Abs(float64(a.Distance-b.Distance)) > longest*distanceTolerance {
+				continue
+			}
+
+			trackA, err := trackOf(a)
+			if err != nil {
+				return nil, err
+			}
+			trackB, err := trackOf(b)
+			if err != nil {
+				return nil, err
+			}
+			if (len(trackA) > 0 || len(trackB) > 0) && !geo.SameCourse(trackA, trackB, trackTolerance, 0.9) {
+				continue
+			}
+
+			original, later := a, b
+			if later.ID < original.ID {
+				original, later = later, original
+			}
+			duplicates = append(duplicates, duplicate{original: original, duplicate: later})
+		}
+	}
+	return duplicates, nil
+}
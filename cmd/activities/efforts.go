@@ -0,0 +1,184 @@
+package activities
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type effortsFlags struct {
+	format string
+	out    string
+}
+
+// effort is a segment effort of an activity as listed, with the fields of
+// its CSV and JSON records.
+type effort struct {
+	ID          int64   `json:"id"`
+	SegmentID   int64   `json:"segment_id"`
+	Segment     string  `json:"segment"`
+	Distance    float64 `json:"distance"`
+	ElapsedTime int64   `json:"elapsed_time"`
+	// Best is the elapsed time of the best effort of the athlete on the
+	// segment, and Delta the time lost to it, both nil when Strava did not
+	// return the best effort.
+	Best    *int64 `json:"best_elapsed_time"`
+	Delta   *int64 `json:"delta"`
+	PRRank  int64  `json:"pr_rank"`
+	KOMRank int64  `json:"kom_rank"`
+}
+
+func effortsCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := effortsFlags{}
+
+	command := &cobra.Command{
+		Use:   "efforts <id>",
+		Short: "List the segment efforts of an activity",
+		Long: "List the efforts of an activity on each segment it went through, with their rank among " +
+			"the efforts of the athlete, 1 for a personal record, their rank on the leaderboard when " +
+			"in the top 10, and how far they are from the best effort of the athlete on the segment.",
+		Example: "  sutro activities efforts 1234\n" +
+			"  sutro activities efforts 1234 --format csv --out efforts.csv",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return efforts(ctx, apiClient, args[0], flags)
+		},
+	}
+
+	choice.Var(command, &flags.format, "format", "table", "The format of the list: table, csv or json", "table", "csv", "json")
+	command.Flags().StringVar(&flags.out, "out", "", "The file to write the list to instead of stdout")
+
+	return command
+}
+
+func efforts(ctx context.Context, apiClient *strava.Client, arg string, flags effortsFlags) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	segmentEfforts, err := apiClient.Activities.Efforts(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(segmentEfforts) == 0 {
+		return fmt.Errorf("Activity %d has no segment effort", id)
+	}
+	history.Returned(ctx, id)
+
+	listed := make([]effort, 0, len(segmentEfforts))
+	for _, segmentEffort := range segmentEfforts {
+		listed = append(listed, newEffort(segmentEffort))
+	}
+
+	return writeOut(flags.out, func(file *os.File) error {
+		switch flags.format {
+		case "csv":
+			return writeEffortsCSV(file, listed)
+		case "json":
+			encoder := json.NewEncoder(file)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(listed)
+		default:
+			return writeEfforts(file, listed)
+		}
+	})
+}
+
+func newEffort(segmentEffort *models.DetailedSegmentEffort) effort {
+	listed := effort{
+		ID:          segmentEffort.ID,
+		Segment:     segmentEffort.Name,
+		Distance:    float64(segmentEffort.Distance),
+		ElapsedTime: segmentEffort.ElapsedTime,
+		PRRank:      segmentEffort.PrRank,
+		KOMRank:     segmentEffort.KomRank,
+	}
+	var best int64
+	if segment := segmentEffort.Segment; segment != nil {
+		listed.SegmentID = segment.ID
+		if segment.Name != "" {
+			listed.Segment = segment.Name
+		}
+		if segment.AthletePrEffort != nil {
+			best = segment.AthletePrEffort.ElapsedTime
+		}
+	}
+	// The effort that set the record is the best one whether or not the
+	// segment already accounts for it.
+	if listed.PRRank == 1 || (best > 0 && listed.ElapsedTime < best) {
+		best = listed.ElapsedTime
+	}
+	if best > 0 {
+		delta := listed.ElapsedTime - best
+		listed.Best, listed.Delta = &best, &delta
+	}
+	return listed
+}
+
+func writeEfforts(file *os.File, listed []effort) error {
+	writer := tabwriter.NewWriter(file, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "SEGMENT\tDISTANCE\tTIME\tBEST\tDELTA\tPR\tKOM")
+	records := 0
+	for _, e := range listed {
+		best, delta := "-", "-"
+		if e.Best != nil {
+			best = format.Duration(float64(*e.Best))
+			delta = "+" + format.Duration(float64(*e.Delta))
+		}
+		pr := "-"
+		switch e.PRRank {
+		case 1:
+			pr = "PR"
+			records++
+		case 2:
+			pr = "2nd"
+		case 3:
+			pr = "3rd"
+		}
+		kom := "-"
+		if e.KOMRank > 0 {
+			kom = strconv.FormatInt(e.KOMRank, 10)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Segment, format.Kilometers(e.Distance), format.Duration(float64(e.ElapsedTime)), best, delta, pr, kom)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(file, "\n%d segment efforts, of which %d set a personal record\n", len(listed), records)
+	return err
+}
+
+func writeEffortsCSV(file *os.File, listed []effort) error {
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"id", "segment_id", "segment", "distance", "elapsed_time", "best_elapsed_time", "delta", "pr_rank", "kom_rank"})
+	for _, e := range listed {
+		best, delta := "", ""
+		if e.Best != nil {
+			best, delta = strconv.FormatInt(*e.Best, 10), strconv.FormatInt(*e.Delta, 10)
+		}
+		writer.Write([]string{
+			strconv.FormatInt(e.ID, 10),
+			strconv.FormatInt(e.SegmentID, 10),
+			e.Segment,
+			strconv.FormatFloat(e.Distance, 'f', 1, 64),
+			strconv.FormatInt(e.ElapsedTime, 10),
+			best,
+			delta,
+			strconv.FormatInt(e.PRRank, 10),
+			strconv.FormatInt(e.KOMRank, 10),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
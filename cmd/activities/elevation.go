@@ -0,0 +1,137 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/dem"
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/stream"
+	"github.com/spf13/cobra"
+)
+
+type correctElevationFlags struct {
+	dem       string
+	gpx       string
+	threshold float64
+	privacy   string
+}
+
+func correctElevationCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store, configuration config.Configuration) *cobra.Command {
+	flags := correctElevationFlags{}
+
+	command := &cobra.Command{
+		Use:   "correct-elevation <id>",
+		Short: "Recompute the elevation gain of an activity from a local elevation model",
+		Long: "Look up the elevation of the ground under each point of the track of an activity in " +
+			"the SRTM tiles of --dem, such as N37W123.hgt, and compare the elevation gain it adds up " +
+			"to with the one of Strava and the one of the recorded altitudes. Points no tile covers " +
+			"keep their recorded altitude.",
+		Example: "  sutro activities correct-elevation 1234 --dem ./srtm/ --gpx corrected.gpx",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return correctElevation(ctx, apiClient, archive, configuration.PrivacyZones(), args[0], flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.dem, "dem", "", "The directory of the SRTM .hgt tiles to read elevations from")
+	command.Flags().StringVar(&flags.gpx, "gpx", "", "Write the track with the corrected elevations to this GPX file")
+	command.Flags().Float64Var(&flags.threshold, "threshold", ascentThreshold, "The change of elevation, in meters, below which climbs are considered noise")
+	choice.Var(command, &flags.privacy, "privacy", export.PrivacyTrim, "How to scrub points of the GPX within the configured privacy zones: trim, jitter or off", export.PrivacyTrim, export.PrivacyJitter, export.PrivacyOff)
+	command.MarkFlagRequired("dem")
+
+	return command
+}
+
+func correctElevation(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone, arg string, flags correctElevationFlags) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	model, err := dem.Open(flags.dem)
+	if err != nil {
+		return err
+	}
+
+	activity, err := archivedOrFetched(ctx, apiClient, archive, id)
+	if err != nil {
+		return err
+	}
+	set, err := apiClient.Streams.Activity(ctx, id, export.StreamKeys...)
+	if err != nil {
+		return fmt.Errorf("Failed to obtain the streams of activity %d: %v", id, err)
+	}
+	track := export.NewTrack(activity, set)
+	if len(track.Samples) == 0 {
+		return fmt.Errorf("Activity %d has no track", id)
+	}
+
+	var recorded, corrected []float64
+	covered := 0
+	for i := range track.Samples {
+		sample := &track.Samples[i]
+		if !math.IsNaN(sample.Elevation) {
+			recorded = append(recorded, sample.Elevation)
+		}
+		elevation, ok, err := model.Elevation(sample.Point)
+		if err != nil {
+			return err
+		}
+		if ok {
+			sample.Elevation = elevation
+			covered++
+		}
+		if !math.IsNaN(sample.Elevation) {
+			corrected = append(corrected, sample.Elevation)
+		}
+	}
+	if covered == 0 {
+		start := track.Samples[0].Point
+		return fmt.Errorf("No tile of %s covers the track of activity %d, which starts in %s", flags.dem, id, dem.Name(int(math.Floor(start.Lat)), int(math.Floor(start.Lng))))
+	}
+
+	reported := float64(activity.TotalElevationGain)
+	ascent := stream.Ascent(corrected, flags.threshold)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "Strava\t%.0f m\n", reported)
+	if len(recorded) > 0 {
+		fmt.Fprintf(writer, "Recorded\t%.0f m\n", stream.Ascent(recorded, flags.threshold))
+	}
+	fmt.Fprintf(writer, "Elevation model\t%.0f m\n", ascent)
+	if reported > 0 {
+		fmt.Fprintf(writer, "Difference\t%+.0f m (%+.1f%%)\n", ascent-reported, (ascent-reported)/reported*100)
+	} else {
+		fmt.Fprintf(writer, "Difference\t%+.0f m\n", ascent-reported)
+	}
+	fmt.Fprintf(writer, "Covered\t%d of %d points\n", covered, len(track.Samples))
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	history.Returned(ctx, id)
+
+	if flags.gpx == "" {
+		return nil
+	}
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	if err := track.Scrub(zones, flags.privacy, random); err != nil {
+		return err
+	}
+	if err := writeOut(flags.gpx, func(file *os.File) error {
+		return export.WriteGPX(file, track)
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the corrected track to %s\n", flags.gpx)
+	return nil
+}
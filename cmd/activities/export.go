@@ -0,0 +1,205 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type exportFlags struct {
+	format       string
+	out          string
+	collection   bool
+	since        string
+	activityType string
+	privacy      string
+	filenames    string
+}
+
+func exportCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store, configuration config.Configuration) *cobra.Command {
+	flags := exportFlags{}
+
+	command := &cobra.Command{
+		Use:   "export [id...]",
+		Short: "Export the tracks of activities to files",
+		Long: "Export the tracks of the given activities or, when no id is given, of the synced " +
+			"activities selected by --since and --type.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportActivities(ctx, apiClient, archive, configuration.PrivacyZones(), args, flags)
+		},
+	}
+
+	choice.Var(command, &flags.format, "format", "geojson", fmt.Sprintf("The export format: %s", strings.Join(formatNames(), ", ")), formatNames()...)
+	command.Flags().StringVar(&flags.out, "out", "", "The file, or directory when exporting several activities, to write to instead of stdout")
+	command.Flags().StringVar(&flags.filenames, "filename-template", "", "A Go template naming the file of each activity within the --out directory (e.g. '{{.StartDateLocal.Format \"2006-01-02\"}}-{{.Name | slug}}')")
+	command.Flags().BoolVar(&flags.collection, "collection", false, "Merge all activities into a single GeoJSON FeatureCollection")
+	command.Flags().StringVar(&flags.since, "since", "", "Export synced activities started after this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Export synced activities of this type (e.g. Ride)")
+	choice.Var(command, &flags.privacy, "privacy", export.PrivacyTrim, "How to scrub points within the configured privacy zones: trim, jitter or off", export.PrivacyTrim, export.PrivacyJitter, export.PrivacyOff)
+
+	return command
+}
+
+func exportActivities(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone, args []string, flags exportFlags) error {
+	format, ok := export.Formats[flags.format]
+	if !ok {
+		return fmt.Errorf("Unknown format %q, expected one of %s", flags.format, strings.Join(formatNames(), ", "))
+	}
+	if flags.collection && flags.format != "geojson" {
+		return errors.New("Only the geojson format supports --collection")
+	}
+	if err := export.CheckPrivacyMode(flags.privacy); err != nil {
+		return err
+	}
+	var filenames *export.FilenameTemplate
+	if flags.filenames != "" {
+		if flags.collection || flags.out == "" {
+			return errors.New("A --filename-template names files within the --out directory, without --collection")
+		}
+		parsed, err := export.ParseFilenameTemplate(flags.filenames)
+		if err != nil {
+			return err
+		}
+		filenames = parsed
+	}
+
+	selected, err := selectActivities(ctx, apiClient, archive, args, flags.since, flags.activityType)
+	if err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		return errors.New("No activity to export")
+	}
+	if len(selected) > 1 && !flags.collection && flags.out == "" {
+		return errors.New("Exporting several activities requires --out to name a directory, or --collection")
+	}
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	tracks := make([]*export.Track, 0, len(selected))
+	for _, activity := range selected {
+		set, err := apiClient.Streams.Activity(ctx, activity.ID, export.StreamKeys...)
+		if err != nil {
+			return fmt.Errorf("Failed to obtain the streams of activity %d: %v", activity.ID, err)
+		}
+
+		track := export.NewTrack(activity, set)
+		if err := track.Scrub(zones, flags.privacy, random); err != nil {
+			return err
+		}
+		tracks = append(tracks, track)
+		history.Returned(ctx, activity.ID)
+	}
+
+	if flags.collection {
+		return writeOut(flags.out, func(file *os.File) error {
+			return export.WriteGeoJSONCollection(file, tracks)
+		})
+	}
+
+	if len(tracks) == 1 && filenames == nil {
+		return writeOut(flags.out, func(file *os.File) error {
+			return format.Write(file, tracks[0])
+		})
+	}
+
+	if err := os.MkdirAll(flags.out, 0755); err != nil {
+		return err
+	}
+	for i, track := range tracks {
+		filename := path.Join(flags.out, fmt.Sprintf("%d.%s", track.ID, format.Extension))
+		if filenames != nil {
+			name, err := filenames.Render(selected[i], format.Extension)
+			if err != nil {
+				return err
+			}
+			filename = path.Join(flags.out, name)
+			if err := os.MkdirAll(path.Dir(filename), 0755); err != nil {
+				return err
+			}
+		}
+		err := writeOut(filename, func(file *os.File) error {
+			return format.Write(file, track)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Exported %d activities to %s\n", len(tracks), flags.out)
+	return nil
+}
+
+// selectActivities resolves the activities named by id, or the synced
+// activities matching since and activityType when no id is given.
+func selectActivities(ctx context.Context, apiClient *strava.Client, archive *store.Store, args []string, since, activityType string) ([]*models.SummaryActivity, error) {
+	if len(args) == 0 {
+		query := store.Query{Type: activityType}
+		if since != "" {
+			after, err := dates.Parse(since)
+			if err != nil {
+				return nil, err
+			}
+			query.After = after
+		}
+		return archive.Activities(query)
+	}
+
+	selected := make([]*models.SummaryActivity, 0, len(args))
+	for _, arg := range args {
+		id, err := parseID(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		activity, err := archivedOrFetched(ctx, apiClient, archive, id)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, activity)
+	}
+	return selected, nil
+}
+
+// writeOut calls write with the named file, or with stdout when filename
+// is empty.
+func writeOut(filename string, write func(*os.File) error) error {
+	if filename == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(export.Formats))
+	for name := range export.Formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
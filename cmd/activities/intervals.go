@@ -0,0 +1,275 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/stream"
+	"github.com/spf13/cobra"
+)
+
+type intervalsFlags struct {
+	by          string
+	minDuration time.Duration
+}
+
+// interval is a stretch of an activity, in seconds from its start, along
+// with whether it was an effort or the recovery between efforts.
+type interval struct {
+	kind      string
+	from, to  int
+	mean      float64
+	distance  float64
+	heartrate float64
+}
+
+func intervalsCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := intervalsFlags{}
+
+	command := &cobra.Command{
+		Use:   "intervals <id>",
+		Short: "Detect the work and rest intervals of a structured workout",
+		Long: "Split an activity where its power, or its pace, changes significantly, label the " +
+			"stretches as work or rest, and print the duration, the average power or pace and the " +
+			"average heart rate of each along with a summary of the workout.",
+		Example: "  sutro activities intervals 1234\n" +
+			"  sutro activities intervals 1234 --by pace --min-duration 1m",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return intervals(ctx, apiClient, args[0], flags)
+		},
+	}
+
+	choice.Var(command, &flags.by, "by", "auto", "The stream to detect intervals in: power, pace, or auto for power when the activity has it", "auto", "power", "pace")
+	command.Flags().DurationVar(&flags.minDuration, "min-duration", 30*time.Second, "The shortest interval to detect")
+
+	return command
+}
+
+func intervals(ctx context.Context, apiClient *strava.Client, arg string, flags intervalsFlags) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+
+	set, err := apiClient.Streams.Activity(ctx, id, "time", "distance", "heartrate", "watts")
+	if err != nil {
+		return err
+	}
+	times, distances, watts := stream.Times(set), stream.Distances(set), stream.Watts(set)
+	if len(times) < 2 {
+		return fmt.Errorf("Activity %d has no time data to detect intervals in", id)
+	}
+
+	by := flags.by
+	if by == "auto" {
+		by = "pace"
+		if len(watts) > 0 {
+			by = "power"
+		}
+	}
+	if by == "power" && len(watts) == 0 {
+		return fmt.Errorf("Activity %d has no power data, try --by pace", id)
+	}
+	if by == "pace" && len(distances) == 0 {
+		return fmt.Errorf("Activity %d has no distance data to detect intervals in", id)
+	}
+
+	// Streams are resampled every second, so that each sample weighs the
+	// same whatever the recording interval of the device, and the sample i
+	// of the signal stands for the second from i to i+1.
+	var cumulated []float64
+	if len(distances) == len(times) {
+		cumulated = stream.Resample(times, distances, 1)
+	}
+	var signal []float64
+	if by == "power" {
+		signal = stream.Resample(times, watts, 1)
+		signal = signal[:len(signal)-1]
+	} else {
+		signal = make([]float64, len(cumulated)-1)
+		for i := range signal {
+			signal[i] = cumulated[i+1] - cumulated[i]
+		}
+	}
+	heartrates := stream.Resample(times, stream.Heartrates(set), 1)
+
+	minimum := int(flags.minDuration.Seconds())
+	if minimum < 1 {
+		minimum = 1
+	}
+	found := detectIntervals(signal, stream.Changepoints(signal, minimum))
+	for i := range found {
+		found[i].distance = math.NaN()
+		if cumulated != nil {
+			found[i].distance = cumulated[found[i].to] - cumulated[found[i].from]
+		}
+		found[i].heartrate = math.NaN()
+		if heartrates != nil {
+			found[i].heartrate = meanOf(heartrates[found[i].from:found[i].to])
+		}
+	}
+
+	label := "Power"
+	if by == "pace" {
+		label = "Pace"
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "#\tType\tStart\tDuration\tDistance\t%s\tHR\n", label)
+	for i, stretch := range found {
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			i+1,
+			stretch.kind,
+			format.Duration(float64(stretch.from)),
+			format.Duration(float64(stretch.to-stretch.from)),
+			formatDistance(stretch.distance),
+			formatIntensity(by, stretch),
+			formatHeartrate(stretch.heartrate),
+		)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	history.Returned(ctx, id)
+
+	fmt.Printf("\n%s\n", summarizeIntervals(by, found))
+	return nil
+}
+
+// detectIntervals labels the segments of signal starting at starts as work
+// or rest, whichever of the two levels of the workout their mean is
+// closest to, and merges the consecutive segments of the same kind. When
+// the levels are within a tenth of the average of the activity, the whole
+// activity is a single steady interval.
+func detectIntervals(signal []float64, starts []int) []interval {
+	segments := make([]interval, len(starts))
+	for i, from := range starts {
+		to := len(signal)
+		if i+1 < len(starts) {
+			to = starts[i+1]
+		}
+		segments[i] = interval{from: from, to: to, mean: meanOf(signal[from:to])}
+	}
+
+	// Two-means clustering of the segments, weighted by their duration,
+	// finds the levels of work and rest.
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, segment := range segments {
+		low, high = math.Min(low, segment.mean), math.Max(high, segment.mean)
+	}
+	for iteration := 0; iteration < 20; iteration++ {
+		threshold := (low + high) / 2
+		var sums, weights [2]float64
+		for _, segment := range segments {
+			cluster := 0
+			if segment.mean > threshold {
+				cluster = 1
+			}
+			sums[cluster] += segment.mean * float64(segment.to-segment.from)
+			weights[cluster] += float64(segment.to - segment.from)
+		}
+		if weights[0] == 0 || weights[1] == 0 {
+			break
+		}
+		low, high = sums[0]/weights[0], sums[1]/weights[1]
+	}
+
+	average := meanOf(signal)
+	if len(segments) == 1 || high-low < math.Abs(average)/10 {
+		return []interval{{kind: "Steady", from: 0, to: len(signal), mean: average}}
+	}
+
+	threshold := (low + high) / 2
+	var merged []interval
+	for _, segment := range segments {
+		segment.kind = "Rest"
+		if segment.mean > threshold {
+			segment.kind = "Work"
+		}
+		if n := len(merged); n > 0 && merged[n-1].kind == segment.kind {
+			previous := &merged[n-1]
+			previous.mean = (previous.mean*float64(previous.to-previous.from) + segment.mean*float64(segment.to-segment.from)) / float64(segment.to-previous.from)
+			previous.to = segment.to
+			continue
+		}
+		merged = append(merged, segment)
+	}
+	return merged
+}
+
+// summarizeIntervals describes the workout the way it would be written
+// down, such as 5 × 4:00 at 310 W with 2:00 of rest.
+func summarizeIntervals(by string, found []interval) string {
+	var work []interval
+	rest, recoveries := 0, 0
+	for i, stretch := range found {
+		switch {
+		case stretch.kind == "Work":
+			work = append(work, stretch)
+		case stretch.kind == "Rest" && i > 0 && i < len(found)-1:
+			// Only the rest between efforts counts as recovery, not the
+			// warm-up and the cool-down.
+			rest += stretch.to - stretch.from
+			recoveries++
+		}
+	}
+	if len(work) == 0 {
+		return fmt.Sprintf("A steady effort of %s at %s", format.Duration(float64(found[0].to-found[0].from)), formatIntensity(by, found[0]))
+	}
+
+	seconds, distance, weighted := 0, 0.0, 0.0
+	for _, effort := range work {
+		seconds += effort.to - effort.from
+		distance += effort.distance
+		weighted += effort.mean * float64(effort.to-effort.from)
+	}
+	average := interval{from: 0, to: seconds / len(work), mean: weighted / float64(seconds), distance: distance / float64(len(work))}
+	summary := fmt.Sprintf("%d × %s at %s", len(work), format.Duration(float64(average.to)), formatIntensity(by, average))
+	if recoveries > 0 {
+		summary += fmt.Sprintf(" with %s of rest", format.Duration(float64(rest)/float64(recoveries)))
+	}
+	return summary
+}
+
+func formatIntensity(by string, found interval) string {
+	if by == "power" {
+		return fmt.Sprintf("%.0f W", found.mean)
+	}
+	if math.IsNaN(found.distance) || found.distance <= 0 {
+		return "-"
+	}
+	return format.Pace(float64(found.to-found.from) / 60 / (found.distance / 1000))
+}
+
+func formatDistance(meters float64) string {
+	if math.IsNaN(meters) {
+		return "-"
+	}
+	return format.Kilometers(meters)
+}
+
+func formatHeartrate(heartrate float64) string {
+	if math.IsNaN(heartrate) {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f bpm", heartrate)
+}
+
+func meanOf(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
@@ -0,0 +1,115 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/lint"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type lintFlags struct {
+	maxSpeed float64
+	fix      bool
+	out      string
+	privacy  string
+}
+
+func lintCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store, configuration config.Configuration) *cobra.Command {
+	flags := lintFlags{}
+
+	command := &cobra.Command{
+		Use:   "lint <id>",
+		Short: "Detect GPS and heart rate anomalies in an activity",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return lintActivity(ctx, apiClient, archive, configuration.PrivacyZones(), args[0], flags)
+		},
+	}
+
+	command.Flags().Float64Var(&flags.maxSpeed, "max-speed", 0, "The speed past which a sample is impossible, in m/s (defaults to a value for the activity type)")
+	command.Flags().BoolVar(&flags.fix, "fix", false, "Write a GPX export with the anomalies interpolated away")
+	command.Flags().StringVar(&flags.out, "out", "", "The file to write the fixed GPX export to")
+	choice.Var(command, &flags.privacy, "privacy", export.PrivacyTrim, "How to scrub points of the fixed export within the configured privacy zones: trim, jitter or off", export.PrivacyTrim, export.PrivacyJitter, export.PrivacyOff)
+
+	return command
+}
+
+func lintActivity(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone, arg string, flags lintFlags) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	if flags.fix && flags.out == "" {
+		return errors.New("--fix requires --out to name the GPX file to write")
+	}
+	if err := export.CheckPrivacyMode(flags.privacy); err != nil {
+		return err
+	}
+
+	activity, err := archivedOrFetched(ctx, apiClient, archive, id)
+	if err != nil {
+		return err
+	}
+
+	set, err := apiClient.Streams.Activity(ctx, id, export.StreamKeys...)
+	if err != nil {
+		return err
+	}
+	track := export.NewTrack(activity, set)
+
+	maxSpeed := flags.maxSpeed
+	if maxSpeed <= 0 {
+		maxSpeed = lint.MaxSpeed(track.Type)
+	}
+
+	issues := lint.Check(track, maxSpeed)
+	if len(issues) == 0 {
+		fmt.Printf("No anomaly found in the %d samples of activity %d\n", len(track.Samples), id)
+	} else {
+		counts := map[lint.Kind]int{}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "SAMPLE\tELAPSED\tANOMALY\tDETAIL")
+		for _, issue := range issues {
+			counts[issue.Kind]++
+			elapsed := track.Samples[issue.Index].Time.Sub(track.Start).Seconds()
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", issue.Index, format.Duration(elapsed), issue.Kind, issue.Detail)
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\n%d GPS spikes, %d impossible speeds, %d heart rate dropouts in %d samples\n",
+			counts[lint.GPSSpike], counts[lint.ImpossibleSpeed], counts[lint.HeartrateDropout], len(track.Samples))
+	}
+
+	if !flags.fix {
+		return nil
+	}
+
+	fixed := lint.Fix(track, issues)
+	if err := fixed.Scrub(zones, flags.privacy, rand.New(rand.NewSource(time.Now().UnixNano()))); err != nil {
+		return err
+	}
+
+	err = writeOut(flags.out, func(file *os.File) error {
+		return export.WriteGPX(file, fixed)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the cleaned track to %s\n", flags.out)
+	return nil
+}
@@ -0,0 +1,85 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/heatmap"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type mapFlags struct {
+	width  int
+	height int
+	ascii  bool
+}
+
+func mapCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store) *cobra.Command {
+	flags := mapFlags{}
+
+	command := &cobra.Command{
+		Use:   "map <id>",
+		Short: "Preview the map of an activity in the terminal",
+		Long: "Draw the track of an activity with braille characters, scaled to fit the terminal, " +
+			"with S marking its start and F its finish. The size of the terminal is read from " +
+			"$COLUMNS and $LINES, which shells set but do not always export.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return previewMap(ctx, apiClient, archive, args[0], flags)
+		},
+	}
+
+	command.Flags().IntVar(&flags.width, "width", 0, "The width of the map, in characters (defaults to the width of the terminal, or 80)")
+	command.Flags().IntVar(&flags.height, "height", 0, "The height of the map, in lines (defaults to the height of the terminal, or 24)")
+	command.Flags().BoolVar(&flags.ascii, "ascii", false, "Draw with asterisks, for terminals or fonts without braille characters")
+
+	return command
+}
+
+func previewMap(ctx context.Context, apiClient *strava.Client, archive *store.Store, arg string, flags mapFlags) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+
+	activity, err := archivedOrFetched(ctx, apiClient, archive, id)
+	if err != nil {
+		return err
+	}
+	if activity.Map == nil || activity.Map.SummaryPolyline == "" {
+		return fmt.Errorf("Activity %d has no map", id)
+	}
+	track, err := geo.DecodePolyline(activity.Map.SummaryPolyline)
+	if err != nil {
+		return fmt.Errorf("Unable to decode the map of activity %d: %v", id, err)
+	}
+	history.Returned(ctx, id)
+
+	width, height := flags.width, flags.height
+	if width <= 0 {
+		width = terminalSize("COLUMNS", 80)
+	}
+	if height <= 0 {
+		// A line is left for the name of the activity, and one for the
+		// prompt once the map is drawn.
+		height = terminalSize("LINES", 26) - 2
+	}
+	fmt.Printf("%s · %s\n", activity.Name, format.Kilometers(float64(activity.Distance)))
+	return heatmap.RenderText(os.Stdout, track, width, height, !flags.ascii)
+}
+
+// terminalSize returns the size of the terminal in the environment
+// variable, or fallback when it is not set.
+func terminalSize(variable string, fallback int) int {
+	if size, err := strconv.Atoi(os.Getenv(variable)); err == nil && size > 0 {
+		return size
+	}
+	return fallback
+}
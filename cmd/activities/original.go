@@ -0,0 +1,135 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// originalExtensions are the extensions of the files Strava accepts as
+// uploads, which the external id of an uploaded activity usually ends with.
+var originalExtensions = []string{".fit", ".fit.gz", ".gpx", ".gpx.gz", ".tcx", ".tcx.gz"}
+
+type originalFlags struct {
+	out       string
+	filenames string
+	privacy   string
+}
+
+func downloadOriginalCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store, configuration config.Configuration) *cobra.Command {
+	flags := originalFlags{}
+
+	command := &cobra.Command{
+		Use:   "download-original <id>",
+		Short: "Download the file an activity was uploaded from",
+		Long: "Download the file an activity was uploaded from. The Strava API does not serve " +
+			"uploaded files, so the activity is written as a GPX file reconstructed from its " +
+			"streams instead, which lacks what streams do not record such as laps.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return downloadOriginal(ctx, apiClient, archive, configuration.PrivacyZones(), args[0], flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.out, "out", "", "The file to write to, or - for stdout (defaults to <id>.gpx)")
+	command.Flags().StringVar(&flags.filenames, "filename-template", "", "A Go template naming the file instead of --out (e.g. '{{.StartDateLocal.Format \"2006-01-02\"}}-{{.Name | slug}}')")
+	choice.Var(command, &flags.privacy, "privacy", export.PrivacyTrim, "How to scrub points within the configured privacy zones: trim, jitter or off", export.PrivacyTrim, export.PrivacyJitter, export.PrivacyOff)
+
+	return command
+}
+
+func downloadOriginal(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone, arg string, flags originalFlags) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	if flags.out != "" && flags.filenames != "" {
+		return errors.New("Pass either --out or --filename-template")
+	}
+	var filenames *export.FilenameTemplate
+	if flags.filenames != "" {
+		if filenames, err = export.ParseFilenameTemplate(flags.filenames); err != nil {
+			return err
+		}
+	}
+
+	activity, err := archivedOrFetched(ctx, apiClient, archive, id)
+	if err != nil {
+		return err
+	}
+	if activity.Manual {
+		return fmt.Errorf("Activity %d was entered manually, it has no file nor track to download", id)
+	}
+
+	set, err := apiClient.Streams.Activity(ctx, id, export.StreamKeys...)
+	if err != nil {
+		return fmt.Errorf("Failed to obtain the streams of activity %d: %v", id, err)
+	}
+	track := export.NewTrack(activity, set)
+	if len(track.Samples) == 0 {
+		return fmt.Errorf("Activity %d has no recorded track to reconstruct a GPX file from", id)
+	}
+	if err := track.Scrub(zones, flags.privacy, rand.New(rand.NewSource(time.Now().UnixNano()))); err != nil {
+		return err
+	}
+
+	filename := flags.out
+	switch {
+	case filenames != nil:
+		if filename, err = filenames.Render(activity, "gpx"); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(path.Dir(filename), 0755); err != nil {
+			return err
+		}
+	case filename == "":
+		filename = fmt.Sprintf("%d.gpx", id)
+	case filename == "-":
+		filename = ""
+	}
+	err = writeOut(filename, func(file *os.File) error {
+		return export.WriteGPX(file, track)
+	})
+	if err != nil {
+		return err
+	}
+	history.Returned(ctx, id)
+
+	written := filename
+	if written == "" {
+		written = "the GPX file"
+	}
+	fmt.Fprintf(os.Stderr, "The Strava API does not serve the files uploaded to it: %s was reconstructed from the streams "+
+		"of activity %d, and lacks what streams do not record such as laps and device data\n", written, id)
+	if original := originalName(activity.ExternalID); original != "" {
+		fmt.Fprintf(os.Stderr, "The activity was uploaded from %s, which https://www.strava.com/activities/%d/export_original "+
+			"downloads while signed in to Strava\n", original, id)
+	}
+	return nil
+}
+
+// originalName returns the name of the file an activity was uploaded from,
+// when its external id names one.
+func originalName(externalID string) string {
+	name := path.Base(externalID)
+	for _, extension := range originalExtensions {
+		if strings.HasSuffix(strings.ToLower(name), extension) {
+			return name
+		}
+	}
+	return ""
+}
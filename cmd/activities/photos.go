@@ -0,0 +1,207 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/batch"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// fullSize is the size Strava returns the original images for.
+const fullSize = 5000
+
+type photosFlags struct {
+	download    string
+	concurrency int
+	failures    *batch.Flags
+}
+
+func photosCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := photosFlags{}
+
+	command := &cobra.Command{
+		Use:   "photos <id>",
+		Short: "List and download the photos of an activity",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return photos(ctx, cmd, apiClient, args[0], flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.download, "download", "", "Download the full-size photos to this directory, naming them by the time they were taken")
+	command.Flags().IntVar(&flags.concurrency, "concurrency", 4, "How many photos to download at once")
+	flags.failures = batch.Var(command, true)
+
+	return command
+}
+
+func photos(ctx context.Context, cmd *cobra.Command, apiClient *strava.Client, arg string, flags photosFlags) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	if flags.concurrency < 1 {
+		return errors.New("The concurrency must be at least 1")
+	}
+
+	attached, err := apiClient.Activities.Photos(ctx, id, fullSize)
+	if err != nil {
+		return fmt.Errorf("Failed to list the photos of activity %d: %v", id, err)
+	}
+	if len(attached) == 0 {
+		fmt.Printf("Activity %d has no photos\n", id)
+		return nil
+	}
+
+	if flags.download == "" {
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "TAKEN\tCAPTION\tURL")
+		for _, photo := range attached {
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", takenAt(photo).Local().Format("2006-01-02 15:04:05"), photo.Caption, largestURL(photo))
+		}
+		return writer.Flush()
+	}
+
+	if err := os.MkdirAll(flags.download, 0755); err != nil {
+		return err
+	}
+	run, err := flags.failures.Start(cmd, len(attached), "photos")
+	if err != nil {
+		return err
+	}
+	return downloadPhotos(ctx, run, attached, flags.download, flags.concurrency)
+}
+
+// downloadPhotos fetches the photos with up to concurrency requests in
+// flight, and reports every photo that failed rather than only the first
+// unless run stops at the first failure.
+func downloadPhotos(ctx context.Context, run *batch.Batch, photos []*models.Photo, directory string, concurrency int) error {
+	filenames := photoFilenames(photos, directory)
+
+	var wait sync.WaitGroup
+	var lock sync.Mutex
+	var stopped error
+	queue := make(chan int)
+	bar := progress.New("Downloading photos", int64(len(photos)), "photos")
+
+	for worker := 0; worker < concurrency; worker++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for i := range queue {
+				lock.Lock()
+				skip := stopped != nil
+				lock.Unlock()
+				if skip {
+					continue
+				}
+
+				name := path.Base(filenames[i])
+				err := run.Do(ctx, name, func() error {
+					if err := downloadPhoto(ctx, largestURL(photos[i]), filenames[i]); err != nil {
+						return fmt.Errorf("%s: %v", name, err)
+					}
+					return nil
+				})
+				if err != nil {
+					lock.Lock()
+					stopped = err
+					lock.Unlock()
+				}
+				bar.Add(1)
+			}
+		}()
+	}
+	for i := range photos {
+		queue <- i
+	}
+	close(queue)
+	wait.Wait()
+	bar.Done()
+
+	if stopped != nil {
+		return fmt.Errorf("Failed to download photo %v", stopped)
+	}
+	if err := run.Done(); err != nil {
+		return err
+	}
+	fmt.Printf("Downloaded %d photos to %s\n", len(photos), directory)
+	return nil
+}
+
+func downloadPhoto(ctx context.Context, url, filename string) error {
+	if url == "" {
+		return errors.New("no URL")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", response.Status)
+	}
+
+	return writeOut(filename, func(file *os.File) error {
+		_, err := io.Copy(file, response.Body)
+		return err
+	})
+}
+
+// photoFilenames names each photo after the time it was taken, adding a
+// counter to photos taken within the same second.
+func photoFilenames(photos []*models.Photo, directory string) []string {
+	filenames := make([]string, len(photos))
+	taken := map[string]int{}
+	for i, photo := range photos {
+		name := takenAt(photo).Local().Format("2006-01-02_15-04-05")
+		taken[name]++
+		if count := taken[name]; count > 1 {
+			name = fmt.Sprintf("%s_%d", name, count)
+		}
+
+		extension := path.Ext(strings.SplitN(largestURL(photo), "?", 2)[0])
+		if extension == "" {
+			extension = ".jpg"
+		}
+		filenames[i] = path.Join(directory, name+extension)
+	}
+	return filenames
+}
+
+func takenAt(photo *models.Photo) time.Time {
+	if t := time.Time(photo.CreatedAt); !t.IsZero() {
+		return t
+	}
+	return time.Time(photo.UploadedAt)
+}
+
+// largestURL returns the URL of the largest rendition of a photo.
+func largestURL(photo *models.Photo) string {
+	url, largest := "", -1
+	for key, value := range photo.Urls {
+		if size, err := strconv.Atoi(key); err == nil && size > largest {
+			url, largest = value, size
+		}
+	}
+	return url
+}
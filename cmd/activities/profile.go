@@ -0,0 +1,139 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/jsilland/sutro/chart"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/stream"
+	"github.com/spf13/cobra"
+)
+
+// ascentThreshold filters out barometric and GPS noise when summing up the
+// elevation gain of an activity, in meters.
+const ascentThreshold = 2
+
+type profileFlags struct {
+	out    string
+	width  int
+	height int
+}
+
+func profileCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := profileFlags{}
+
+	command := &cobra.Command{
+		Use:   "profile <id>",
+		Short: "Render the elevation profile of an activity",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return profile(ctx, apiClient, args[0], flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.out, "out", "", "Write the profile to an .svg or .png file instead of the terminal")
+	command.Flags().IntVar(&flags.width, "width", 0, "The width of the profile, in characters or pixels (defaults to 80 or 1200)")
+	command.Flags().IntVar(&flags.height, "height", 0, "The height of the profile, in lines or pixels (defaults to 15 or 400)")
+
+	return command
+}
+
+func profile(ctx context.Context, apiClient *strava.Client, arg string, flags profileFlags) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+
+	extension := strings.ToLower(path.Ext(flags.out))
+	if flags.out != "" && extension != ".svg" && extension != ".png" {
+		return fmt.Errorf("Unsupported output %q, expected an .svg or .png file", flags.out)
+	}
+
+	width, height := flags.width, flags.height
+	if width <= 0 {
+		width = 80
+		if flags.out != "" {
+			width = 1200
+		}
+	}
+	if height <= 0 {
+		height = 15
+		if flags.out != "" {
+			height = 400
+		}
+	}
+
+	set, err := apiClient.Streams.Activity(ctx, id, "distance", "altitude")
+	if err != nil {
+		return err
+	}
+	distances, altitudes := stream.Distances(set), stream.Altitudes(set)
+	if len(distances) == 0 || len(altitudes) != len(distances) {
+		return fmt.Errorf("Activity %d has no elevation data", id)
+	}
+
+	columns := width
+	if flags.out != "" {
+		// Images get one column every few pixels, which keeps the grade
+		// shading readable instead of flickering between samples.
+		columns = int(math.Max(1, float64(width)/4))
+	}
+	p := newProfile(distances, altitudes, columns)
+	if p == nil {
+		return errors.New("The activity does not cover any distance")
+	}
+
+	if flags.out == "" {
+		return p.WriteASCII(os.Stdout, height)
+	}
+
+	return writeOut(flags.out, func(file *os.File) error {
+		if extension == ".svg" {
+			return p.WriteSVG(file, width, height)
+		}
+		return p.WritePNG(file, width, height)
+	})
+}
+
+func newProfile(distances, altitudes []float64, columns int) *chart.Profile {
+	total := last(distances)
+	if total <= 0 || columns <= 0 {
+		return nil
+	}
+
+	step := total / float64(columns)
+	p := &chart.Profile{
+		Columns:  make([]chart.Column, columns),
+		Distance: total,
+		Ascent:   stream.Ascent(altitudes, ascentThreshold),
+	}
+
+	for i := range p.Columns {
+		from, to := float64(i)*step, float64(i+1)*step
+		altitude := stream.Mean(distances, altitudes, from, to)
+		if math.IsNaN(altitude) {
+			altitude = stream.Interpolate(distances, altitudes, (from+to)/2)
+		}
+		p.Columns[i].Altitude = altitude
+	}
+
+	// Each column takes the grade towards the next one, and the last column
+	// the grade from the one before it.
+	for i := range p.Columns {
+		from, to := i, i+1
+		if to == len(p.Columns) {
+			from, to = i-1, i
+		}
+		if from < 0 {
+			continue
+		}
+		p.Columns[i].Grade = (p.Columns[to].Altitude - p.Columns[from].Altitude) / step * 100
+	}
+	return p
+}
@@ -0,0 +1,117 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jsilland/sutro/batch"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/scopes"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/undo"
+	"github.com/spf13/cobra"
+)
+
+type undoFlags struct {
+	last     bool
+	dryRun   bool
+	failures *batch.Flags
+}
+
+func undoCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store) *cobra.Command {
+	flags := undoFlags{}
+
+	command := &cobra.Command{
+		Use:   "undo [id...]",
+		Short: "Undo the last update sutro made to activities",
+		Long: "Restore the fields of the given activities, or of the activity updated last with " +
+			"--last, as they were before the last update sutro made to them, whichever command " +
+			"made it. Undoing an activity again restores it as it was before the update before.",
+		Example: "  sutro activities undo 1234\n" +
+			"  sutro activities undo --last",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return undoUpdates(ctx, cmd, apiClient, archive, args, flags)
+		},
+	}
+
+	command.Flags().BoolVar(&flags.last, "last", false, "Undo the last update sutro made, to any activity")
+	command.Flags().BoolVar(&flags.dryRun, "dry-run", false, "List the fields to restore without restoring them")
+	flags.failures = batch.Var(command, false)
+
+	return scopes.Require(command, "activity:write")
+}
+
+func undoUpdates(ctx context.Context, cmd *cobra.Command, apiClient *strava.Client, archive *store.Store, args []string, flags undoFlags) error {
+	if flags.last == (len(args) > 0) {
+		return errors.New("Give the ids of the activities to undo the last update of, or --last")
+	}
+
+	ids := []int64{0}
+	if !flags.last {
+		ids = ids[:0]
+		for _, arg := range args {
+			id, err := parseID(arg)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	var restorations []*undo.Restoration
+	for _, id := range ids {
+		restoration, err := undo.Plan(archive, id)
+		if err != nil {
+			return err
+		}
+		if restoration == nil {
+			if id == 0 {
+				return errors.New("No update of sutro left to undo")
+			}
+			return fmt.Errorf("No update of sutro left to undo for activity %d", id)
+		}
+		restorations = append(restorations, restoration)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "UPDATED\tID\tCOMMAND\tRESTORES")
+	for _, r := range restorations {
+		fmt.Fprintf(writer, "%s\t%d\tsutro %s\t%s\n", r.Update.Time.Format("2006-01-02 15:04"), r.Update.ActivityID, r.Update.Command, r)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	for _, r := range restorations {
+		if len(r.Unrestorable) > 0 {
+			fmt.Printf("The API does not let sutro restore %s of activity %d\n", strings.Join(r.Unrestorable, " or "), r.Update.ActivityID)
+		}
+	}
+
+	if flags.dryRun {
+		return nil
+	}
+	run, err := flags.failures.Start(cmd, len(restorations), "activities")
+	if err != nil {
+		return err
+	}
+	for _, r := range restorations {
+		r := r
+		err := run.Do(ctx, fmt.Sprintf("activity %d", r.Update.ActivityID), func() error {
+			if _, err := undo.Apply(ctx, apiClient, archive, r); err != nil {
+				return err
+			}
+			history.Returned(ctx, r.Update.ActivityID)
+			fmt.Printf("Restored activity %d\n", r.Update.ActivityID)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return run.Done()
+}
@@ -0,0 +1,127 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jsilland/sutro/batch"
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/payload"
+	"github.com/jsilland/sutro/scopes"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type updateFlags struct {
+	body         string
+	name         string
+	activityType string
+	description  string
+	gearID       string
+	trainer      bool
+	commute      bool
+	failures     *batch.Flags
+}
+
+func updateCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store) *cobra.Command {
+	flags := updateFlags{}
+
+	command := &cobra.Command{
+		Use:   "update <id>...",
+		Short: "Update the name, type, gear and other fields of activities",
+		Long: "Update the fields of activities set by flags, or by a JSON body of the UpdatableActivity " +
+			"schema read from a file with --body @update.json or from stdin with --body -. Flags take " +
+			"precedence over the fields of the body.",
+		Example: "  sutro activities update 1234 --name 'Morning ride' --commute\n" +
+			"  echo '{\"gear_id\": \"b1234\", \"trainer\": true}' | sutro activities update @last --body -",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return update(ctx, cmd, apiClient, archive, args, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.body, "body", "", "The JSON body of the update, from a file as @path or from stdin as -")
+	command.Flags().StringVar(&flags.name, "name", "", "The name of the activity")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "The type of the activity (e.g. Run, Ride or Workout)")
+	command.Flags().StringVar(&flags.description, "description", "", "The description of the activity")
+	command.Flags().StringVar(&flags.gearID, "gear-id", "", "The id of the gear used, or none to clear it")
+	command.Flags().BoolVar(&flags.trainer, "trainer", false, "Whether the activity was done on a trainer")
+	command.Flags().BoolVar(&flags.commute, "commute", false, "Whether the activity is a commute")
+	flags.failures = batch.Var(command, false)
+
+	return scopes.Require(command, "activity:write")
+}
+
+func update(ctx context.Context, cmd *cobra.Command, apiClient *strava.Client, archive *store.Store, args []string, flags updateFlags) error {
+	ids := make([]int64, len(args))
+	for i, arg := range args {
+		id, err := parseID(arg)
+		if err != nil {
+			return err
+		}
+		ids[i] = id
+	}
+
+	changes := &models.UpdatableActivity{}
+	if flags.body != "" {
+		if err := payload.Decode(flags.body, os.Stdin, changes); err != nil {
+			return err
+		}
+	}
+
+	set := cmd.Flags().Changed
+	if set("name") {
+		changes.Name = flags.name
+	}
+	if set("type") {
+		activityType, err := strava.ParseActivityType(flags.activityType)
+		if err != nil {
+			return err
+		}
+		changes.Type = activityType
+	}
+	if set("description") {
+		changes.Description = flags.description
+	}
+	if set("gear-id") {
+		changes.GearID = flags.gearID
+	}
+	if set("trainer") {
+		changes.Trainer = flags.trainer
+	}
+	if set("commute") {
+		changes.Commute = &flags.commute
+	}
+	if *changes == (models.UpdatableActivity{}) {
+		return errors.New("Nothing to update, set the fields with flags or --body")
+	}
+
+	run, err := flags.failures.Start(cmd, len(ids), "activities")
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		id := id
+		err := run.Do(ctx, fmt.Sprintf("activity %d", id), func() error {
+			updated, err := apiClient.Activities.Update(ctx, id, changes)
+			if err != nil {
+				return fmt.Errorf("Failed to update activity %d: %v", id, err)
+			}
+			if err := archive.PutActivities([]*models.SummaryActivity{&updated.SummaryActivity}); err != nil {
+				return err
+			}
+			history.Returned(ctx, id)
+			fmt.Printf("Updated activity %d, %q\n", id, updated.Name)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return run.Done()
+}
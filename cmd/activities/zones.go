@@ -0,0 +1,110 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/stream"
+	"github.com/jsilland/sutro/training"
+	"github.com/spf13/cobra"
+)
+
+func zonesCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store) *cobra.Command {
+	return &cobra.Command{
+		Use:   "zones <id>",
+		Short: "Report the time in zones and the training stress of an activity",
+		Long: "Report the time an activity spent in each zone of power, of the functional threshold " +
+			"power, and of heart rate, of the lactate threshold heart rate, along with its training " +
+			"stress score. The thresholds are the ones recorded with thresholds set that were valid " +
+			"on the day the activity took place.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return zones(ctx, apiClient, archive, args[0])
+		},
+	}
+}
+
+func zones(ctx context.Context, apiClient *strava.Client, archive *store.Store, arg string) error {
+	id, err := parseID(arg)
+	if err != nil {
+		return err
+	}
+	activity, err := archivedOrFetched(ctx, apiClient, archive, id)
+	if err != nil {
+		return err
+	}
+	start := dates.Start(time.Time(activity.StartDate), time.Time(activity.StartDateLocal))
+	thresholds, err := archive.ThresholdsAt(start)
+	if err != nil {
+		return err
+	}
+	if thresholds.FTP <= 0 && thresholds.LTHR <= 0 {
+		return fmt.Errorf("No threshold was recorded on %s or before, record one with sutro thresholds set --ftp or --lthr --date", start.Format("2006-01-02"))
+	}
+
+	set, err := apiClient.Streams.Activity(ctx, id, "time", "watts", "heartrate")
+	if err != nil {
+		return err
+	}
+	times := stream.Times(set)
+	// Streams are resampled every second, so that zones add up time rather
+	// than samples.
+	watts := stream.Resample(times, stream.Watts(set), 1)
+	heartrates := stream.Resample(times, stream.Heartrates(set), 1)
+	if len(watts) == 0 && len(heartrates) == 0 {
+		return fmt.Errorf("Activity %d has no power or heart rate data", id)
+	}
+	history.Returned(ctx, id)
+
+	fmt.Printf("%s on %s\n", activity.Name, start.Format("2006-01-02"))
+	reported := false
+	if len(watts) > 0 && thresholds.FTP > 0 {
+		normalized := training.NormalizedPower(watts)
+		fmt.Printf("\nPower, FTP %.0f W: normalized %.0f W, intensity %.2f, TSS %.0f\n", thresholds.FTP, normalized, normalized/thresholds.FTP, training.PowerStress(watts, thresholds.FTP))
+		if err := writeZones(training.PowerZones, training.TimeInZones(watts, thresholds.FTP, training.PowerZones), thresholds.FTP, "W"); err != nil {
+			return err
+		}
+		reported = true
+	}
+	if len(heartrates) > 0 && thresholds.LTHR > 0 {
+		fmt.Printf("\nHeart rate, LTHR %.0f bpm: hrTSS %.0f\n", thresholds.LTHR, training.HeartrateStress(heartrates, thresholds.LTHR))
+		if err := writeZones(training.HeartrateZones, training.TimeInZones(heartrates, thresholds.LTHR, training.HeartrateZones), thresholds.LTHR, "bpm"); err != nil {
+			return err
+		}
+		reported = true
+	}
+	if !reported {
+		return fmt.Errorf("Activity %d has no data matching the thresholds valid on %s", id, start.Format("2006-01-02"))
+	}
+	return nil
+}
+
+func writeZones(zones []training.Zone, seconds []float64, threshold float64, unit string) error {
+	total := 0.0
+	for _, s := range seconds {
+		total += s
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ZONE\tRANGE\tTIME\tSHARE")
+	for i, zone := range zones {
+		bounds := fmt.Sprintf("%.0f–%.0f %s", zone.From*threshold, zone.To*threshold, unit)
+		if i == len(zones)-1 {
+			bounds = fmt.Sprintf("%.0f+ %s", zone.From*threshold, unit)
+		}
+		share := 0.0
+		if total > 0 {
+			share = seconds[i] / total * 100
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%.0f%%\n", zone.Name, bounds, format.Duration(seconds[i]), share)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,207 @@
+package alias
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jsilland/sutro/config"
+	"github.com/spf13/cobra"
+)
+
+// Command returns the alias command, which manages the shortcuts saved in
+// the configuration.
+func Command(configuration config.Configuration) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage shortcuts for common invocations",
+		Long: "Aliases are shortcuts for the commands you run often: once added with\n\n" +
+			"  sutro alias add week digest email --period week\n\n" +
+			"sutro week runs sutro digest email --period week, followed by any argument given " +
+			"to the alias. Commands of sutro take precedence over aliases of the same name.",
+	}
+
+	add := &cobra.Command{
+		Use:   "add <name> <command> [args...]",
+		Short: "Add an alias, or replace the command of an existing one",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return addAlias(cmd.Root(), configuration, args[0], args[1:])
+		},
+	}
+	// Flags after the name belong to the aliased command.
+	add.Flags().SetInterspersed(false)
+
+	command.AddCommand(
+		add,
+		&cobra.Command{
+			Use:   "list",
+			Short: "List the aliases",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return listAliases(configuration.Aliases())
+			},
+		},
+		&cobra.Command{
+			Use:   "remove <name>",
+			Short: "Remove an alias",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return removeAlias(configuration, args[0])
+			},
+		},
+	)
+	return command
+}
+
+// Expand replaces the alias that args start with, if any, by its command.
+// Aliases may refer to other aliases, but commands of root always take
+// precedence over aliases.
+func Expand(root *cobra.Command, args []string, aliases map[string]string) ([]string, error) {
+	seen := map[string]bool{}
+	for len(args) > 0 {
+		name := args[0]
+		expansion, ok := aliases[name]
+		if !ok || isCommand(root, name) {
+			return args, nil
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("The alias %s refers to itself", name)
+		}
+		seen[name] = true
+
+		words, err := Split(expansion)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid alias %s: %v", name, err)
+		}
+		args = append(words, args[1:]...)
+	}
+	return args, nil
+}
+
+// Split splits a command line into words, as a shell does: words are
+// separated by spaces, which single or double quotes and backslashes keep
+// within a word.
+func Split(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, escaped := false, false
+	var quote rune
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped || quote != 0 {
+		return nil, errors.New("Unterminated quote or escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// join is the inverse of Split, quoting the words that need it.
+func join(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		if word == "" || strings.ContainsAny(word, " \t\n'\"\\") {
+			word = "'" + strings.Replace(word, "'", `'\''`, -1) + "'"
+		}
+		quoted[i] = word
+	}
+	return strings.Join(quoted, " ")
+}
+
+func isCommand(root *cobra.Command, name string) bool {
+	for _, command := range root.Commands() {
+		if command.Name() == name || command.HasAlias(name) {
+			return true
+		}
+	}
+	return name == "help"
+}
+
+func addAlias(root *cobra.Command, configuration config.Configuration, name string, words []string) error {
+	if strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t\n'\"\\") {
+		return fmt.Errorf("Invalid alias name %q", name)
+	}
+	if isCommand(root, name) {
+		return fmt.Errorf("%s is a command of sutro, which would take precedence over the alias", name)
+	}
+
+	aliases := map[string]string{}
+	for existing, expansion := range configuration.Aliases() {
+		aliases[existing] = expansion
+	}
+	aliases[name] = join(words)
+	if _, err := Expand(root, []string{name}, aliases); err != nil {
+		return err
+	}
+	configuration.SetAliases(aliases)
+
+	fmt.Printf("sutro %s now runs sutro %s\n", name, aliases[name])
+	return nil
+}
+
+func listAliases(aliases map[string]string) error {
+	if len(aliases) == 0 {
+		fmt.Println("No alias, add one with sutro alias add")
+		return nil
+	}
+
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(writer, "%s\t%s\n", name, aliases[name])
+	}
+	return writer.Flush()
+}
+
+func removeAlias(configuration config.Configuration, name string) error {
+	if _, ok := configuration.Aliases()[name]; !ok {
+		return fmt.Errorf("No alias named %s", name)
+	}
+
+	aliases := map[string]string{}
+	for existing, expansion := range configuration.Aliases() {
+		if existing != name {
+			aliases[existing] = expansion
+		}
+	}
+	configuration.SetAliases(aliases)
+
+	fmt.Printf("Removed the alias %s\n", name)
+	return nil
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/audit"
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/spf13/cobra"
+)
+
+type listFlags struct {
+	since     string
+	operation string
+	failed    bool
+	limit     int
+	format    string
+}
+
+// Command returns the audit command, which reviews the changes sutro made
+// to the account of the athlete.
+func Command(log *audit.Log) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "audit",
+		Short: "Review the changes sutro made to the account",
+		Long: "Every operation of sutro changing data on Strava, such as updating an activity or " +
+			"uploading a file, is appended to the audit log of the profile in ~/.sutro.d, with " +
+			"the command that made it, the resource it changed, the digest of what it sent and " +
+			"its outcome.",
+	}
+
+	flags := listFlags{}
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the changes sutro made, most recent last",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listEntries(log, flags)
+		},
+	}
+	list.Flags().StringVar(&flags.since, "since", "", "Only list the changes made after this date (e.g. 7d)")
+	list.Flags().StringVar(&flags.operation, "operation", "", "Only list the changes made by this operation, such as updateActivityById")
+	list.Flags().BoolVar(&flags.failed, "failed", false, "Only list the changes that failed")
+	list.Flags().IntVar(&flags.limit, "limit", 50, "The number of changes to list, or 0 for all of them")
+	choice.Var(list, &flags.format, "format", "table", "The format of the list: table or json", "table", "json")
+
+	command.AddCommand(list)
+	return command
+}
+
+func listEntries(log *audit.Log, flags listFlags) error {
+	var since time.Time
+	if flags.since != "" {
+		var err error
+		if since, err = dates.Parse(flags.since); err != nil {
+			return err
+		}
+	}
+
+	entries, err := log.Entries()
+	if err != nil {
+		return err
+	}
+	var selected []audit.Entry
+	for _, entry := range entries {
+		if entry.Time.Before(since) ||
+			(flags.operation != "" && !strings.EqualFold(entry.Operation, flags.operation)) ||
+			(flags.failed && entry.Result == "ok") {
+			continue
+		}
+		selected = append(selected, entry)
+	}
+	if flags.limit > 0 && len(selected) > flags.limit {
+		selected = selected[len(selected)-flags.limit:]
+	}
+
+	if flags.format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range selected {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("No change in the audit log")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TIME\tCOMMAND\tOPERATION\tRESOURCE\tRESULT")
+	for _, entry := range selected {
+		command := "sutro"
+		if entry.Command != "" {
+			command = "sutro " + entry.Command
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", entry.Time.Local().Format("2006-01-02 15:04:05"),
+			command, entry.Operation, entry.Resource, entry.Result)
+	}
+	return writer.Flush()
+}
@@ -4,16 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net"
-	"net/http"
-	"net/url"
-	"os/exec"
-	"runtime"
-	"strings"
+	"io/ioutil"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/jsilland/sutro/auth"
 	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/prompt"
 	"github.com/spf13/cobra"
 	"golang.org/x/oauth2"
 )
@@ -24,40 +20,74 @@ type authenticationFlags struct {
 	authorizationURL string
 	tokenURL         string
 	scopes           []string
+	redirectTLS      bool
+	redirectCert     string
+	redirectKey      string
 }
 
-func Command(ctx context.Context, sink config.ConfigurationSink) *cobra.Command {
+// Command returns the authenticate command. Once sutro is authenticated,
+// the credentials, endpoints and scopes of remembered are reused unless
+// given, so that authorizing sutro again only takes sutro authenticate.
+// Its check subcommand reports tokens expiring within window.
+func Command(ctx context.Context, sink config.ConfigurationSink, remembered config.Configuration, window time.Duration) *cobra.Command {
 	flags := authenticationFlags{}
 
 	command := &cobra.Command{
 		Use:   "authenticate",
 		Short: "Authentication support",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return authenticate(ctx, sink, flags)
+			if remembered != nil {
+				flags.remember(cmd, remembered.OAuthConfiguration())
+			}
+			return authenticate(ctx, sink, remembered, flags)
 		},
 	}
 
-	command.PersistentFlags().StringVar(&flags.clientID, "client_id", "", "The OAuth client ID")
-	command.MarkPersistentFlagRequired("client_id")
-	command.PersistentFlags().StringVar(&flags.clientSecret, "client_secret", "", "The OAuth client secret")
-	command.MarkPersistentFlagRequired("client_secret")
-	command.PersistentFlags().StringVar(&flags.authorizationURL, "authorization_url", "", "The authorization URL")
-	command.MarkPersistentFlagRequired("authorization_url")
-	command.PersistentFlags().StringVar(&flags.tokenURL, "token_url", "", "The token URL")
-	command.MarkPersistentFlagRequired("token_url")
-	command.PersistentFlags().StringSliceVar(&flags.scopes, "scopes", []string{}, "The scopes to request")
+	command.Flags().StringVar(&flags.clientID, "client_id", "", "The OAuth client ID")
+	command.Flags().StringVar(&flags.clientSecret, "client_secret", "", "The OAuth client secret")
+	command.Flags().StringVar(&flags.authorizationURL, "authorization_url", "", "The authorization URL")
+	command.Flags().StringVar(&flags.tokenURL, "token_url", "", "The token URL")
+	command.Flags().StringSliceVar(&flags.scopes, "scopes", []string{}, "The scopes to request")
+	command.Flags().BoolVar(&flags.redirectTLS, "redirect-tls", false, "Serve the redirect over HTTPS, with an ephemeral self-signed certificate unless given --redirect-cert")
+	command.Flags().StringVar(&flags.redirectCert, "redirect-cert", "", "The PEM file of the certificate to serve the redirect over HTTPS with")
+	command.Flags().StringVar(&flags.redirectKey, "redirect-key", "", "The PEM file of the key of --redirect-cert")
+	if remembered == nil {
+		for _, name := range []string{"client_id", "client_secret", "authorization_url", "token_url"} {
+			command.MarkFlagRequired(name)
+		}
+	}
 
+	// The flags of the authorization are not inherited by check, which
+	// runs before sutro is authenticated as well.
+	command.AddCommand(checkCommand(ctx, remembered, window))
 	return command
 }
 
-func authenticate(ctx context.Context, sink config.ConfigurationSink, flags authenticationFlags) error {
-	oAuthCodeChannel := make(chan string)
-	redirectService, err := NewOAuthRedirectService(oAuthCodeChannel)
-	if err != nil {
-		return err
+// remember fills the flags that were not given from the configuration
+// sutro was authenticated with. They are not defaults of the flags, which
+// the help would print along with the secret.
+func (flags *authenticationFlags) remember(cmd *cobra.Command, previous *oauth2.Config) {
+	remembered := []struct {
+		name   string
+		target *string
+		value  string
+	}{
+		{"client_id", &flags.clientID, previous.ClientID},
+		{"client_secret", &flags.clientSecret, previous.ClientSecret},
+		{"authorization_url", &flags.authorizationURL, previous.Endpoint.AuthURL},
+		{"token_url", &flags.tokenURL, previous.Endpoint.TokenURL},
+	}
+	for _, flag := range remembered {
+		if !cmd.Flags().Changed(flag.name) {
+			*flag.target = flag.value
+		}
+	}
+	if !cmd.Flags().Changed("scopes") {
+		flags.scopes = previous.Scopes
 	}
-	defer redirectService.Shutdown(ctx)
+}
 
+func authenticate(ctx context.Context, sink config.ConfigurationSink, remembered config.Configuration, flags authenticationFlags) error {
 	oAuthConfig := oauth2.Config{
 		ClientID:     flags.clientID,
 		ClientSecret: flags.clientSecret,
@@ -65,199 +95,74 @@ func authenticate(ctx context.Context, sink config.ConfigurationSink, flags auth
 			AuthURL:  flags.authorizationURL,
 			TokenURL: flags.tokenURL,
 		},
-		RedirectURL: redirectService.RedirectURL().String(),
-		Scopes:      flags.scopes,
-	}
-
-	url := oAuthConfig.AuthCodeURL(
-		redirectService.State(),
-		oauth2.AccessTypeOffline,
-		oauth2.SetAuthURLParam("scope", "activity:read_all,profile:read_all,read_all"),
-		oauth2.SetAuthURLParam("scope", strings.Join(flags.scopes, ",")),
-	)
-
-	fmt.Printf("Sutro needs to obtain your consent to access your data, which requires going to the following URL: %s\n", url)
-	openInBrowser, err := promptBoolean("Do you want to open it your default browser?")
-
-	if openInBrowser {
-		err = openBrowser(url)
-		if err != nil {
-			return err
-		}
-	} else {
-		fmt.Println("Alright. Please open the URL yourself and come back here after, we'll hang tight…")
+		Scopes: flags.scopes,
 	}
 
-	code := <-oAuthCodeChannel
-
-	if code == "" {
-		return errors.New("Failed to obtain code from authenticate service")
+	certificate, err := redirectCertificate(flags)
+	if err != nil {
+		return err
 	}
 
-	token, err := oAuthConfig.Exchange(
-		ctx,
-		code,
-		oauth2.SetAuthURLParam("client_id", oAuthConfig.ClientID),
-		oauth2.SetAuthURLParam("client_secret", oAuthConfig.ClientSecret),
-	)
+	token, err := auth.Authorize(ctx, auth.Options{
+		Config:      oAuthConfig,
+		Certificate: certificate,
+		Prompt: func(url string) (bool, error) {
+			fmt.Printf("Sutro needs to obtain your consent to access your data, which requires going to the following URL: %s\n", url)
+			openInBrowser, err := prompt.Boolean("Do you want to open it your default browser?")
+			if err == nil && !openInBrowser {
+				fmt.Println("Alright. Please open the URL yourself and come back here after, we'll hang tight…")
+			}
+			return openInBrowser, err
+		},
+	})
 	if err != nil {
 		return err
 	}
 
 	fmt.Println("The authentication was successful, saving the config")
 
-	return sink.Save(ctx, config.NewConfiguration(oAuthConfig, *token))
-}
-
-func openBrowser(url string) error {
-	var err error
-
-	switch runtime.GOOS {
-	case "linux":
-		err = exec.Command("xdg-open", url).Start()
-	case "windows":
-		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
-	case "darwin":
-		err = exec.Command("open", url).Start()
-	default:
-		fmt.Printf("Unable to open a browser - please open the URL yourself and follow the prompt: %s\n", url)
+	// The saved configuration holds the scopes granted rather than requested.
+	oAuthConfig.Scopes = auth.GrantedScopes(token)
+	configuration := config.NewConfiguration(oAuthConfig, *token)
+	if remembered != nil {
+		// Authorizing again keeps the other settings, such as aliases.
+		configuration = config.WithToken(remembered, oAuthConfig, *token)
 	}
-	return err
+	return sink.Save(ctx, configuration)
 }
 
-func getFreeTCPPort() (int, error) {
-	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
-	if err != nil {
-		return 0, err
+// redirectCertificate returns the certificate to serve the redirect with,
+// or nil to serve it over plain HTTP.
+func redirectCertificate(flags authenticationFlags) (*auth.Certificate, error) {
+	if (flags.redirectCert == "") != (flags.redirectKey == "") {
+		return nil, errors.New("Give --redirect-cert and --redirect-key together")
 	}
-
-	l, err := net.ListenTCP("tcp", addr)
-	if err != nil {
-		return 0, err
+	if flags.redirectCert != "" {
+		return auth.LoadCertificate(flags.redirectCert, flags.redirectKey)
 	}
-	defer l.Close()
-	return l.Addr().(*net.TCPAddr).Port, nil
-}
-
-type oAuthHTTPHandler struct {
-	codeChannel chan string
-	state       string
-}
-
-func (handler *oAuthHTTPHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	code := request.URL.Query().Get("code")
-	state := request.URL.Query().Get("state")
-
-	if handler.state != state {
-		writer.WriteHeader(http.StatusBadRequest)
-		writer.Header().Add("Content-Type", "text/plain; charset=utf-8")
-		defer writer.Write([]byte("The returned state does not match the one set for this redirect service."))
-		close(handler.codeChannel)
+	if !flags.redirectTLS {
+		return nil, nil
 	}
 
-	writer.WriteHeader(http.StatusOK)
-	writer.Header().Add("Content-Type", "text/plain; charset=utf-8")
-	defer writer.Write([]byte("Code successfully received, you can close this tab and go back to your terminal"))
-
-	handler.codeChannel <- code
-}
-
-// OAuthRedirectService is a service that implements the second leg of
-// a three-legged OAuth flow by running an ephemeral HTTP server and
-// crafting a unique redirect URL to be passed to the authorization
-// request.
-type OAuthRedirectService interface {
-	Shutdown(context.Context) error
-	RedirectURL() *url.URL
-	State() string
-}
-
-type oAuthRedirectService struct {
-	server      *http.Server
-	state       string
-	redirectURL *url.URL
-}
-
-func (oars *oAuthRedirectService) RedirectURL() *url.URL {
-	return oars.redirectURL
-}
-
-func (oars *oAuthRedirectService) State() string {
-	return oars.state
-}
-
-func (oars *oAuthRedirectService) Shutdown(ctx context.Context) error {
-	return oars.server.Shutdown(ctx)
-}
-
-func NewOAuthRedirectService(codeChannel chan string) (OAuthRedirectService, error) {
-	port, err := getFreeTCPPort()
+	certificate, err := auth.SelfSigned()
 	if err != nil {
 		return nil, err
 	}
-
-	redirectURL := &url.URL{
-		Scheme: "http",
-		Host:   fmt.Sprintf("localhost:%d", port),
-		Path:   "/exchange",
-	}
-
-	state, err := uuid.NewUUID()
+	file, err := ioutil.TempFile("", "sutro-redirect-*.pem")
 	if err != nil {
 		return nil, err
 	}
-
-	router := http.NewServeMux()
-	router.Handle(redirectURL.Path, &oAuthHTTPHandler{
-		codeChannel: codeChannel,
-		state:       state.String(),
-	})
-
-	server := &http.Server{
-		Addr:           fmt.Sprintf(":%d", port),
-		Handler:        router,
-		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
-		MaxHeaderBytes: 1 << 20,
-	}
-
-	go server.ListenAndServe()
-
-	return &oAuthRedirectService{
-		server:      server,
-		redirectURL: redirectURL,
-		state:       state.String(),
-	}, nil
-}
-
-func promptBoolean(prompt string) (bool, error) {
-	fmt.Printf("%s (yes/no):", prompt)
-	return promptBooleanOnce(3)
-}
-
-func promptBooleanOnce(remainingAttempts int) (bool, error) {
-	if remainingAttempts == 0 {
-		return false, errors.New("Failed to obtain result from prompt")
-	}
-
-	var result string
-
-	_, err := fmt.Scan(&result)
-	if err != nil {
-		return false, err
+	defer file.Close()
+	if _, err := file.Write(certificate.PEM); err != nil {
+		return nil, err
 	}
 
-	result = strings.TrimSpace(result)
-	result = strings.ToLower(result)
-
-	switch result {
-	case "yes":
-		return true, nil
-	case "no":
-		return false, nil
-	default:
-		fmt.Print("Please enter 'yes' or 'no': ")
-		remainingAttempts--
-		return promptBooleanOnce(remainingAttempts)
-	}
+	fmt.Printf("The redirect is served over HTTPS with a self-signed certificate, which your browser will warn about.\n"+
+		"Proceed past the warning once the fingerprint it shows is %s,\n"+
+		"or trust the certificate ahead of time by importing %s, for instance with\n"+
+		"  security add-trusted-cert -r trustRoot -k ~/Library/Keychains/login.keychain-db %[2]s   (macOS)\n"+
+		"  certutil -d sql:$HOME/.pki/nssdb -A -t C,, -n sutro -i %[2]s   (Chrome and Chromium on Linux)\n"+
+		"or in the certificate settings of Firefox. The certificate is only valid for a day.\n",
+		certificate.Fingerprint(), file.Name())
+	return certificate, nil
 }
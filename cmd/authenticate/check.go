@@ -0,0 +1,89 @@
+package authenticate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jsilland/sutro/auth"
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/failure"
+	"github.com/spf13/cobra"
+)
+
+type checkFlags struct {
+	quiet  bool
+	window time.Duration
+}
+
+// checkCommand returns the check command, which reports whether the token
+// of configuration is valid, expires within window or expired, by its exit
+// code for scripts.
+func checkCommand(ctx context.Context, configuration config.Configuration, window time.Duration) *cobra.Command {
+	flags := checkFlags{}
+
+	command := &cobra.Command{
+		Use:   "check",
+		Short: "Report whether the token is valid, expiring or expired",
+		Long: "Report whether sutro holds a valid token, and exit with 0 when it does, with 1 when the " +
+			"token cannot be refreshed and expires within --window, and with 2 when it expired or " +
+			"Strava refuses to refresh it, such as after the access of sutro was revoked. A token " +
+			"that expired is refreshed as any command would, which is the only request it sends.",
+		Example: "  sutro authenticate check --quiet || notify-send \"Run sutro authenticate\"",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return check(ctx, configuration, flags)
+		},
+	}
+
+	command.Flags().BoolVarP(&flags.quiet, "quiet", "q", false, "Only report the state of the token by the exit code")
+	command.Flags().DurationVar(&flags.window, "window", window, "How long before it expires a token that cannot be refreshed is reported as expiring")
+
+	return command
+}
+
+func check(ctx context.Context, configuration config.Configuration, flags checkFlags) error {
+	report := func(code int, message string, args ...interface{}) error {
+		if !flags.quiet {
+			fmt.Printf(message+"\n", args...)
+		}
+		if code == 0 {
+			return nil
+		}
+		return failure.Status(code)
+	}
+	if configuration == nil {
+		return report(2, "sutro is not authenticated yet: run sutro authenticate")
+	}
+
+	now := time.Now()
+	saved := configuration.SavedToken()
+	switch auth.Check(saved, flags.window, now) {
+	case auth.Expired:
+		return report(2, "The token cannot be refreshed and expired on %s: run sutro authenticate to authorize sutro again",
+			saved.Expiry.Local().Format("Jan 2 15:04"))
+	case auth.Expiring:
+		return report(1, "The token cannot be refreshed and expires in %s, at %s: run sutro authenticate to authorize sutro again",
+			saved.Expiry.Sub(now).Round(time.Minute), saved.Expiry.Local().Format("Jan 2 15:04"))
+	}
+
+	// Whether Strava still refreshes the token is only known by asking.
+	token, err := auth.Refresh(configuration.TokenSource(ctx), configuration.OAuthConfiguration().ClientID)
+	var revoked *auth.RevokedError
+	if errors.As(err, &revoked) {
+		return report(2, "%v", revoked)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case token.Expiry.IsZero():
+		return report(0, "The token is valid, without expiry")
+	case token.RefreshToken == "":
+		return report(0, "The token is valid until %s, and cannot be refreshed", token.Expiry.Local().Format("Jan 2 15:04"))
+	default:
+		return report(0, "The token is valid until %s, and refreshed once it expires", token.Expiry.Local().Format("Jan 2 15:04"))
+	}
+}
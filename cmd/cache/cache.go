@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/jsilland/sutro/cache"
+	"github.com/spf13/cobra"
+)
+
+type gcFlags struct {
+	maxSize string
+}
+
+func Command(streams *cache.Cache) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the on-disk cache of activity streams",
+		Long: "Streams fetched from the API are cached on disk, so that exporting or comparing " +
+			"activities again does not fetch them again. The cache is capped at the cache_max_size " +
+			"of the configuration, 1GB by default, and evicts the streams read least recently " +
+			"once it outgrows it.",
+	}
+	command.AddCommand(gcCommand(streams))
+	return command
+}
+
+func gcCommand(streams *cache.Cache) *cobra.Command {
+	flags := gcFlags{}
+
+	command := &cobra.Command{
+		Use:   "gc",
+		Short: "Evict the streams read least recently until the cache fits its maximum size",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return gc(streams, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.maxSize, "max-size", "", "The size to shrink the cache to instead of its maximum (e.g. 500MB, or 0 to empty it)")
+
+	return command
+}
+
+func gc(streams *cache.Cache, flags gcFlags) error {
+	maxSize := streams.MaxSize()
+	if flags.maxSize == "0" {
+		maxSize = 0
+	} else if flags.maxSize != "" {
+		size, err := cache.ParseSize(flags.maxSize)
+		if err != nil {
+			return err
+		}
+		maxSize = size
+	}
+
+	removed, err := streams.GC(maxSize)
+	if err != nil {
+		return err
+	}
+	usage, err := streams.Usage()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Evicted %d entries (%s), the cache holds %d entries (%s) of at most %s\n",
+		removed.Entries, cache.FormatSize(removed.Size), usage.Entries, cache.FormatSize(usage.Size), cache.FormatSize(streams.MaxSize()))
+	return nil
+}
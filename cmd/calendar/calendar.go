@@ -0,0 +1,154 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jsilland/sutro/calendar"
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/httpserver"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+const calendarName = "Strava activities"
+
+type exportFlags struct {
+	out          string
+	since        string
+	activityType string
+	serve        string
+}
+
+func Command(ctx context.Context, archive *store.Store, zones []geo.Zone) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "calendar",
+		Short: "Calendar feeds of synced activities",
+	}
+
+	command.AddCommand(exportCommand(ctx, archive, zones))
+	return command
+}
+
+func exportCommand(ctx context.Context, archive *store.Store, zones []geo.Zone) *cobra.Command {
+	flags := exportFlags{}
+
+	command := &cobra.Command{
+		Use:   "export",
+		Short: "Export synced activities as an iCalendar feed",
+		Long: "Export synced activities as iCalendar events, which Google Calendar, Apple Calendar " +
+			"and most other calendars can import. With --serve, the feed is served over HTTP " +
+			"instead, so that a calendar can subscribe to it and pick up newly synced activities. " +
+			"Activities starting within the privacy zones of the configuration have no location.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportFeed(ctx, archive, zones, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.out, "out", "activities.ics", "The file to write the feed to")
+	command.Flags().StringVar(&flags.since, "since", "", "Only include activities started after this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+	command.Flags().StringVar(&flags.serve, "serve", "", "Serve the feed over HTTP on this address instead of writing a file (e.g. localhost:8080), on localhost when given a port alone")
+
+	return command
+}
+
+func exportFeed(ctx context.Context, archive *store.Store, zones []geo.Zone, flags exportFlags) error {
+	// The start of activities within privacy zones, such as home, is left
+	// out of the feed, which calendars may share.
+	scrubber, err := export.NewScrubber(zones, export.PrivacyTrim, nil)
+	if err != nil {
+		return err
+	}
+
+	query := store.Query{Type: flags.activityType}
+	if flags.since != "" {
+		after, err := dates.Parse(flags.since)
+		if err != nil {
+			return err
+		}
+		query.After = after
+	}
+
+	if flags.serve != "" {
+		return serve(ctx, archive, scrubber, query, flags.serve)
+	}
+
+	activities, err := scrubbed(archive, scrubber, query)
+	if err != nil {
+		return err
+	}
+	if len(activities) == 0 {
+		return errors.New("No synced activity matches, have you run sutro sync?")
+	}
+
+	file, err := os.Create(flags.out)
+	if err != nil {
+		return err
+	}
+	if err := calendar.Write(file, calendarName, activities); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d activities to %s\n", len(activities), flags.out)
+	return nil
+}
+
+// serve reads the archive on every request, so that subscribed calendars
+// see the activities synced since the server started.
+func serve(ctx context.Context, archive *store.Store, scrubber *export.Scrubber, query store.Query, address string) error {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		activities, err := scrubbed(archive, scrubber, query)
+		if err != nil {
+			log.Printf("Unable to read the archive: %v", err)
+			http.Error(w, "Unable to read the archive", http.StatusInternalServerError)
+			return
+		}
+
+		var buffer bytes.Buffer
+		if err := calendar.Write(&buffer, calendarName, activities); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		_, _ = buffer.WriteTo(w)
+	})
+
+	// A port alone would listen on every interface, sharing the feed with
+	// the network.
+	if strings.HasPrefix(address, ":") {
+		address = "localhost" + address
+	}
+	fmt.Printf("Serving the calendar at http://%s/activities.ics\n", address)
+	return httpserver.ListenAndServe(ctx, address, handler)
+}
+
+// scrubbed returns the activities of the archive matching query, with
+// their start and end positions within privacy zones left out.
+func scrubbed(archive *store.Store, scrubber *export.Scrubber, query store.Query) ([]*models.SummaryActivity, error) {
+	activities, err := archive.Activities(query)
+	if err != nil {
+		return nil, err
+	}
+	for i, activity := range activities {
+		if activities[i], err = scrubber.Activity(activity); err != nil {
+			return nil, err
+		}
+	}
+	return activities, nil
+}
@@ -0,0 +1,164 @@
+package clubs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/calendar"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// Commands returns the hand-written commands that complement the
+// generated clubs client.
+func Commands(ctx context.Context, apiClient *strava.Client) []*cobra.Command {
+	return []*cobra.Command{
+		eventsCommand(ctx, apiClient),
+	}
+}
+
+type eventsFlags struct {
+	ics      string
+	duration time.Duration
+	joined   bool
+}
+
+// occurrence is an upcoming occurrence of a group event.
+type occurrence struct {
+	event *strava.GroupEvent
+	start time.Time
+}
+
+func eventsCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := eventsFlags{}
+
+	command := &cobra.Command{
+		Use:   "events <club-id>",
+		Short: "List the upcoming events of a club and whether you are going",
+		Long: "List the upcoming occurrences of the rides, runs and other events a club organizes, " +
+			"with their date in the time zone of the event, where they start and whether you are " +
+			"going. With --ics, they are also written as an iCalendar file to add to a calendar. " +
+			"Strava does not document the events of clubs, and only exposes them to some " +
+			"applications.",
+		Example: "  sutro clubs events 1234\n" +
+			"  sutro clubs events 1234 --joined --ics club.ics",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return events(ctx, apiClient, args[0], flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.ics, "ics", "", "Also write the events to this iCalendar file")
+	command.Flags().DurationVar(&flags.duration, "duration", 2*time.Hour, "The duration of the events in the calendar, which Strava does not record")
+	command.Flags().BoolVar(&flags.joined, "joined", false, "Only list the events you are going to")
+
+	return command
+}
+
+func events(ctx context.Context, apiClient *strava.Client, arg string, flags eventsFlags) error {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid club id %q", arg)
+	}
+	if flags.duration <= 0 {
+		return errors.New("The --duration of events is positive")
+	}
+
+	groupEvents, err := apiClient.Clubs.Events(ctx, id)
+	if err != nil {
+		if errors.Is(err, strava.ErrUnauthorized) || errors.Is(err, strava.ErrNotFound) {
+			return fmt.Errorf("No events for club %d: the club does not exist or Strava does not expose its events to this application (%v)", id, err)
+		}
+		return err
+	}
+
+	var occurrences []occurrence
+	now := time.Now()
+	for _, event := range groupEvents {
+		if flags.joined && !event.Joined {
+			continue
+		}
+		for _, start := range event.UpcomingOccurrences {
+			if start.After(now) {
+				occurrences = append(occurrences, occurrence{event: event, start: start})
+			}
+		}
+	}
+	if len(occurrences) == 0 {
+		fmt.Printf("No upcoming event in club %d\n", id)
+		return nil
+	}
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].start.Before(occurrences[j].start)
+	})
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "DATE\tTIME\tTYPE\tEVENT\tLOCATION\tRSVP")
+	for _, o := range occurrences {
+		start := inZone(o.start, o.event.Zone)
+		rsvp := "-"
+		if o.event.Joined {
+			rsvp = "Going"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", start.Format("Mon 2006-01-02"), start.Format("15:04 MST"), o.event.ActivityType, o.event.Title, location(o.event), rsvp)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if flags.ics == "" {
+		return nil
+	}
+	calendarEvents := make([]calendar.Event, 0, len(occurrences))
+	for _, o := range occurrences {
+		calendarEvents = append(calendarEvents, calendar.Event{
+			UID:         fmt.Sprintf("group-event-%d-%d@sutro", o.event.ID, o.start.Unix()),
+			Start:       o.start,
+			End:         o.start.Add(flags.duration),
+			Summary:     o.event.Title,
+			Description: o.event.Description,
+			URL:         fmt.Sprintf("https://www.strava.com/clubs/%d/group_events/%d", id, o.event.ID),
+			Category:    o.event.ActivityType,
+			Location:    o.event.Address,
+			Latlng:      o.event.StartLatlng,
+		})
+	}
+	file, err := os.Create(flags.ics)
+	if err != nil {
+		return err
+	}
+	if err := calendar.WriteEvents(file, fmt.Sprintf("Events of club %d", id), calendarEvents); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("\nWrote %d events to %s\n", len(calendarEvents), flags.ics)
+	return nil
+}
+
+// inZone returns start on the clock of zone, or of the local time zone when
+// zone is unknown.
+func inZone(start time.Time, zone string) time.Time {
+	if location, err := time.LoadLocation(zone); err == nil && zone != "" {
+		return start.In(location)
+	}
+	return start.Local()
+}
+
+func location(event *strava.GroupEvent) string {
+	if event.Address != "" {
+		return event.Address
+	}
+	if len(event.StartLatlng) == 2 {
+		return fmt.Sprintf("%.5f, %.5f", event.StartLatlng[0], event.StartLatlng[1])
+	}
+	return "-"
+}
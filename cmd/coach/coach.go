@@ -0,0 +1,244 @@
+package coach
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/digest"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+type reportFlags struct {
+	profiles     []string
+	period       string
+	activityType string
+}
+
+// athlete is the training of a profile over the reported period.
+type athlete struct {
+	profile    string
+	activities int
+	distance   float64
+	movingTime float64
+	heartrate  float64
+	goals      goals
+}
+
+// goals are the goals of an athlete over the reported period, each of which
+// is zero when unset.
+type goals struct {
+	activities float64
+	distance   float64
+	movingTime float64
+}
+
+// Command returns the coach command, which compares the training of the
+// athletes of several profiles.
+func Command(stateDirectory string) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "coach",
+		Short: "Compare the training of the athletes of several profiles",
+	}
+
+	flags := reportFlags{}
+	report := &cobra.Command{
+		Use:   "report",
+		Short: "Compare the volume, intensity and goals of athletes",
+		Long: "Compare the activities synced by each profile over the last complete week, " +
+			"starting on Monday, or month: their volume, their average heart rate and how " +
+			"much of the weekly goals in the configuration of the profile they met. Run " +
+			"sutro sync --all-profiles first to report on up to date archives.",
+		Example: "  sutro coach report --profiles alice,bob --period week",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return coachReport(stateDirectory, flags)
+		},
+	}
+	report.Flags().StringSliceVar(&flags.profiles, "profiles", nil, "The profiles to compare, defaults to every configured profile")
+	choice.Var(report, &flags.period, "period", "week", "The period to compare: week or month", "week", "month")
+	choice.ActivityTypeVar(report, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+
+	command.AddCommand(report)
+	return command
+}
+
+func coachReport(stateDirectory string, flags reportFlags) error {
+	period, err := digest.LastPeriod(flags.period, time.Now())
+	if err != nil {
+		return err
+	}
+
+	configured, err := config.Profiles("sutro", stateDirectory)
+	if err != nil {
+		return err
+	}
+	profiles := flags.profiles
+	if len(profiles) == 0 {
+		profiles = configured
+	}
+	if len(profiles) == 0 {
+		return errors.New("No profile is configured, run sutro --profile <name> authenticate to add one")
+	}
+
+	athletes := make([]athlete, 0, len(profiles))
+	for _, profile := range profiles {
+		if !contains(configured, profile) {
+			return fmt.Errorf("No profile named %s, run sutro --profile %s authenticate to add it", profile, profile)
+		}
+		a, err := train(stateDirectory, profile, period, flags.activityType)
+		if err != nil {
+			return fmt.Errorf("Unable to report on the profile %s: %v", profile, err)
+		}
+		athletes = append(athletes, a)
+	}
+
+	label := period.Label()
+	fmt.Printf("%s%s\n\n", strings.ToUpper(label[:1]), label[1:])
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ATHLETE\tACTIVITIES\tDISTANCE\tTIME\tAVG HR\tGOALS MET")
+	for _, a := range athletes {
+		heartrate := "-"
+		if !math.IsNaN(a.heartrate) {
+			heartrate = fmt.Sprintf("%.0f", a.heartrate)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", a.profile,
+			against(fmt.Sprint(a.activities), a.goals.activities, func(v float64) string { return fmt.Sprintf("%.0f", v) }),
+			against(format.Kilometers(a.distance), a.goals.distance, format.Kilometers),
+			against(format.Duration(a.movingTime), a.goals.movingTime, format.Duration),
+			heartrate, compliance(a))
+	}
+	return writer.Flush()
+}
+
+// train sums up the activities profile synced over period.
+func train(stateDirectory, profile string, period digest.Period, activityType string) (athlete, error) {
+	a := athlete{profile: profile}
+
+	bridge, err := config.NewProfileConfiguration("sutro", stateDirectory, profile)
+	if err != nil {
+		return a, err
+	}
+	configuration, err := bridge.Get()
+	if err != nil {
+		return a, err
+	}
+	if configuration != nil {
+		if a.goals, err = periodGoals(configuration.Goals(), period); err != nil {
+			return a, fmt.Errorf("Invalid goals in %s: %v", bridge.Path(), err)
+		}
+	}
+
+	directory, err := config.ProfileDirectory(stateDirectory, profile)
+	if err != nil {
+		return a, err
+	}
+	archive, err := store.Open(store.DefaultPath(directory))
+	if err != nil {
+		return a, err
+	}
+	defer archive.Close()
+
+	activities, err := period.Activities(archive, activityType)
+	if err != nil {
+		return a, err
+	}
+	a.activities = len(activities)
+	a.heartrate = averageHeartrate(activities)
+	for _, activity := range activities {
+		a.distance += float64(activity.Distance)
+		a.movingTime += float64(activity.MovingTime)
+	}
+	return a, nil
+}
+
+// periodGoals scales weekly goals to period.
+func periodGoals(weekly config.Goals, period digest.Period) (goals, error) {
+	weeks := period.End.Sub(period.Start).Hours() / 24 / 7
+	g := goals{activities: float64(weekly.Activities) * weeks}
+	if weekly.Distance != "" {
+		distance, err := geo.ParseDistance(weekly.Distance)
+		if err != nil {
+			return g, err
+		}
+		g.distance = distance * weeks
+	}
+	if weekly.MovingTime != "" {
+		movingTime, err := dates.ParseDuration(weekly.MovingTime)
+		if err != nil {
+			return g, err
+		}
+		g.movingTime = movingTime.Seconds() * weeks
+	}
+	return g, nil
+}
+
+// against renders value along with its goal, when set.
+func against(value string, goal float64, render func(float64) string) string {
+	if goal <= 0 {
+		return value
+	}
+	return fmt.Sprintf("%s / %s", value, render(goal))
+}
+
+// compliance is the share of its goals an athlete met, averaged over the
+// goals that are set. Exceeding a goal does not make up for missing
+// another.
+func compliance(a athlete) string {
+	var ratios []float64
+	for _, pair := range [][2]float64{
+		{float64(a.activities), a.goals.activities},
+		{a.distance, a.goals.distance},
+		{a.movingTime, a.goals.movingTime},
+	} {
+		if pair[1] > 0 {
+			ratios = append(ratios, math.Min(pair[0]/pair[1], 1))
+		}
+	}
+	if len(ratios) == 0 {
+		return "-"
+	}
+
+	total := 0.0
+	for _, ratio := range ratios {
+		total += ratio
+	}
+	return fmt.Sprintf("%.0f%%", total/float64(len(ratios))*100)
+}
+
+// averageHeartrate averages the heart rate of activities weighted by their
+// moving time, or returns NaN when none recorded it.
+func averageHeartrate(activities []*models.SummaryActivity) float64 {
+	weighted, duration := 0.0, 0.0
+	for _, activity := range activities {
+		if !activity.HasHeartrate || activity.AverageHeartrate == 0 {
+			continue
+		}
+		weighted += float64(activity.AverageHeartrate) * float64(activity.MovingTime)
+		duration += float64(activity.MovingTime)
+	}
+	if duration == 0 {
+		return math.NaN()
+	}
+	return weighted / duration
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package completion
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Command returns the completion command, which writes the completion
+// script of a shell for the commands of root.
+func Command() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion <bash|zsh|fish|powershell>",
+		Short: "Generate the completion script of a shell",
+		Long: "Write the script completing the commands and flags of sutro, such as the types " +
+			"of activities, for a shell. Load it in the current bash session with\n\n" +
+			"  source <(sutro completion bash)\n\n" +
+			"or save it where the shell loads completions from.",
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletion(os.Stdout)
+			case "zsh":
+				return root.GenZshCompletion(os.Stdout)
+			case "fish":
+				return root.GenFishCompletion(os.Stdout, true)
+			default:
+				return root.GenPowerShellCompletion(os.Stdout)
+			}
+		},
+	}
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+// Formats of the results of queries.
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+)
+
+type queryFlags struct {
+	format string
+}
+
+// Command returns the db command, which queries the archive with SQL.
+func Command(archive *store.Store) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "db",
+		Short: "Query the archive of synced activities with SQL",
+	}
+
+	flags := queryFlags{}
+	query := &cobra.Command{
+		Use:   "query <sql>",
+		Short: "Run a read-only SQL query over the archive",
+		Long: "Run a SQL query over the SQLite archive that sutro sync fills, on a read-only " +
+			"connection: statements that would modify the archive fail. sutro db schema lists " +
+			"the tables and their columns.",
+		Example: "  sutro db query \"SELECT type, COUNT(*), SUM(distance) / 1000 FROM activities GROUP BY type\"\n" +
+			"  sutro db query --format csv \"SELECT id, datetime(start_date, 'unixepoch'), name FROM activities\"",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runQuery(archive, args[0], flags)
+		},
+	}
+	choice.Var(query, &flags.format, "format", formatTable, "The format of the results: table, csv or json", formatTable, formatCSV, formatJSON)
+
+	command.AddCommand(
+		query,
+		&cobra.Command{
+			Use:   "schema",
+			Short: "Print the tables and indexes of the archive",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printSchema(archive)
+			},
+		},
+	)
+	return command
+}
+
+func runQuery(archive *store.Store, statement string, flags queryFlags) error {
+	switch flags.format {
+	case formatCSV:
+		writer := csv.NewWriter(os.Stdout)
+		header := false
+		err := archive.Select(statement, func(columns []string, values []interface{}) error {
+			if !header {
+				header = true
+				if err := writer.Write(columns); err != nil {
+					return err
+				}
+			}
+			record := make([]string, len(values))
+			for i, value := range values {
+				record[i] = text(value, "")
+			}
+			return writer.Write(record)
+		})
+		writer.Flush()
+		if err != nil {
+			return err
+		}
+		return writer.Error()
+
+	case formatJSON:
+		// Rows are written as they are read, as a JSON array of objects.
+		encoder := json.NewEncoder(os.Stdout)
+		count := 0
+		err := archive.Select(statement, func(columns []string, values []interface{}) error {
+			row := map[string]interface{}{}
+			for i, column := range columns {
+				row[column] = values[i]
+			}
+			separator := ","
+			if count == 0 {
+				separator = "["
+			}
+			count++
+			fmt.Print(separator)
+			return encoder.Encode(row)
+		})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			fmt.Print("[")
+		}
+		fmt.Println("]")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	count := 0
+	err := archive.Select(statement, func(columns []string, values []interface{}) error {
+		if count == 0 {
+			fmt.Fprintln(writer, strings.ToUpper(strings.Join(columns, "\t")))
+		}
+		count++
+		cells := make([]string, len(values))
+		for i, value := range values {
+			// Tabs and new lines would break the alignment of the table.
+			cells[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(text(value, "NULL"))
+		}
+		_, err := fmt.Fprintln(writer, strings.Join(cells, "\t"))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		fmt.Println("No rows")
+		return nil
+	}
+	return writer.Flush()
+}
+
+// text renders a value of a column, or null for NULL.
+func text(value interface{}, null string) string {
+	switch v := value.(type) {
+	case nil:
+		return null
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+func printSchema(archive *store.Store) error {
+	statements, err := archive.Schema()
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		fmt.Printf("%s;\n\n", statement)
+	}
+	fmt.Println("-- start_date is in seconds since the epoch, which datetime(start_date, 'unixepoch') renders.")
+	fmt.Println("-- data holds each activity as the JSON of the SummaryActivity schema of the API.")
+	return nil
+}
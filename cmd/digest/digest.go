@@ -0,0 +1,234 @@
+package digest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/digest"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+type emailFlags struct {
+	smtp         string
+	username     string
+	from         string
+	to           []string
+	period       string
+	title        string
+	activityType string
+	dryRun       bool
+}
+
+func Command(archive *store.Store) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "digest",
+		Short: "Training summaries of synced activities",
+	}
+
+	command.AddCommand(emailCommand(archive))
+	return command
+}
+
+func emailCommand(archive *store.Store) *cobra.Command {
+	flags := emailFlags{}
+
+	command := &cobra.Command{
+		Use:   "email",
+		Short: "Email a summary of the last week or month of training",
+		Long: "Render the synced activities of the last complete week, starting on Monday, or " +
+			"month as an HTML email compared with the period before, and send it through an " +
+			"SMTP server. The password of the server is read from SUTRO_SMTP_PASSWORD. Run it " +
+			"from cron after sutro sync to get the summary automatically.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return email(archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.smtp, "smtp", "", "The address of the SMTP server (e.g. smtp.gmail.com:587)")
+	command.Flags().StringVar(&flags.username, "username", "", "The user name to authenticate with, defaults to --from")
+	command.Flags().StringVar(&flags.from, "from", "", "The address to send the digest from")
+	command.Flags().StringSliceVar(&flags.to, "to", nil, "The addresses to send the digest to, defaults to --from")
+	choice.Var(command, &flags.period, "period", "week", "The period to summarize: week or month", "week", "month")
+	command.Flags().StringVar(&flags.title, "title", "Training digest", "The title of the digest")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+	command.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print the email instead of sending it")
+
+	return command
+}
+
+func email(archive *store.Store, flags emailFlags) error {
+	period, err := digest.LastPeriod(flags.period, time.Now())
+	if err != nil {
+		return err
+	}
+	if flags.from == "" {
+		return errors.New("A --from address is required")
+	}
+	if !flags.dryRun && flags.smtp == "" {
+		return errors.New("An --smtp server is required, or --dry-run")
+	}
+
+	from, err := mail.ParseAddress(flags.from)
+	if err != nil {
+		return fmt.Errorf("Invalid --from address %q: %v", flags.from, err)
+	}
+	recipients := flags.to
+	if len(recipients) == 0 {
+		recipients = []string{flags.from}
+	}
+	to := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return fmt.Errorf("Invalid --to address %q: %v", recipient, err)
+		}
+		to = append(to, address.Address)
+	}
+
+	current, err := period.Activities(archive, flags.activityType)
+	if err != nil {
+		return err
+	}
+	previousPeriod := period.Previous()
+	previous, err := previousPeriod.Activities(archive, flags.activityType)
+	if err != nil {
+		return err
+	}
+
+	message, err := compose(digest.New(flags.title, period, current, previous), from.String(), recipients)
+	if err != nil {
+		return err
+	}
+
+	if flags.dryRun {
+		_, err := os.Stdout.Write(message)
+		return err
+	}
+
+	username := flags.username
+	if username == "" {
+		username = from.Address
+	}
+	if err := send(flags.smtp, username, os.Getenv("SUTRO_SMTP_PASSWORD"), from.Address, to, message); err != nil {
+		return err
+	}
+	fmt.Printf("Sent the digest of the %s to %s\n", period.Label(), strings.Join(to, ", "))
+	return nil
+}
+
+// compose builds a multipart email with both the text and HTML renditions
+// of the digest.
+func compose(d *digest.Digest, from string, to []string) ([]byte, error) {
+	var text, html bytes.Buffer
+	if err := d.WriteText(&text); err != nil {
+		return nil, err
+	}
+	if err := d.WriteHTML(&html); err != nil {
+		return nil, err
+	}
+
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	boundary := "sutro-" + hex.EncodeToString(random)
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", d.Subject()))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%q\r\n", boundary)
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{"text/plain", text.Bytes()},
+		{"text/html", html.Bytes()},
+	} {
+		fmt.Fprintf(&message, "\r\n--%s\r\n", boundary)
+		fmt.Fprintf(&message, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		fmt.Fprintf(&message, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		writer := quotedprintable.NewWriter(&message)
+		if _, err := writer.Write(part.body); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(&message, "\r\n--%s--\r\n", boundary)
+	return message.Bytes(), nil
+}
+
+// send delivers a message through an SMTP server, over implicit TLS on port
+// 465 and with STARTTLS, when the server offers it, on other ports.
+func send(address, username, password, from string, to []string, message []byte) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("Invalid SMTP server %q, expected a host and port such as smtp.gmail.com:587", address)
+	}
+
+	var auth smtp.Auth
+	if password != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	if port != "465" {
+		return smtp.SendMail(address, auth, from, to, message)
+	}
+
+	connection, err := tls.Dial("tcp", address, &tls.Config{ServerName: host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(connection, host)
+	if err != nil {
+		connection.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
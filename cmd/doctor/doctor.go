@@ -0,0 +1,239 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/cache"
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// maxClockSkew is the difference with the clock of Strava past which the
+// expiry of tokens and the rate limit windows are misjudged.
+const maxClockSkew = time.Minute
+
+// requestTimeout bounds the request checking that the API is reachable.
+const requestTimeout = 10 * time.Second
+
+// Setup is what the checks inspect. Configuration and Client are nil when
+// sutro is not authenticated, and ConfigurationError is the error reading
+// the configuration, if any.
+type Setup struct {
+	Bridge             config.ConfigurationBridge
+	Configuration      config.Configuration
+	ConfigurationError error
+	Client             *strava.Client
+	Cache              *cache.Cache
+}
+
+type status string
+
+const (
+	pass status = "PASS"
+	fail status = "FAIL"
+	skip status = "SKIP"
+)
+
+// result is the outcome of a check, with a hint to remedy failures.
+type result struct {
+	status status
+	detail string
+	hint   string
+}
+
+func passed(format string, args ...interface{}) result {
+	return result{status: pass, detail: fmt.Sprintf(format, args...)}
+}
+
+func failed(hint, format string, args ...interface{}) result {
+	return result{status: fail, detail: fmt.Sprintf(format, args...), hint: hint}
+}
+
+func skipped(format string, args ...interface{}) result {
+	return result{status: skip, detail: fmt.Sprintf(format, args...)}
+}
+
+func Command(ctx context.Context, setup Setup) *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose problems with the setup of sutro",
+		Long: "Check that the configuration is readable and private, that the token is valid, " +
+			"that the API is reachable with rate limit to spare, that the cache is healthy and " +
+			"that the clock agrees with the one of Strava, with hints to fix what fails.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return diagnose(ctx, setup)
+		},
+	}
+}
+
+func diagnose(ctx context.Context, setup Setup) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	failures := 0
+	report := func(name string, r result) {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", r.status, name, r.detail)
+		if r.hint != "" {
+			fmt.Fprintf(writer, "\t\t%s\n", r.hint)
+		}
+		if r.status == fail {
+			failures++
+		}
+	}
+
+	report("Configuration", checkConfiguration(setup))
+	report("Token", checkToken(ctx, setup.Configuration))
+
+	api, usage, ok := checkAPI(ctx, setup.Client)
+	report("API", api)
+	if ok {
+		report("Rate limit", checkRateLimit(usage))
+		report("Clock", checkClock(usage))
+	} else {
+		report("Rate limit", skipped("Requires a response of the API"))
+		report("Clock", skipped("Requires a response of the API"))
+	}
+
+	report("Cache", checkCache(setup.Cache))
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of the checks failed", failures)
+	}
+	return nil
+}
+
+func checkConfiguration(setup Setup) result {
+	path := setup.Bridge.Path()
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return failed("Run sutro authenticate to authorize sutro to access your Strava account", "No configuration at %s", path)
+	}
+	if err != nil {
+		return failed("Check the permissions of the file and of its directory", "Unable to read %s: %v", path, err)
+	}
+	if setup.ConfigurationError != nil {
+		return failed(fmt.Sprintf("Fix %s, or remove it and run sutro authenticate again", path), "Unable to read %s: %v", path, setup.ConfigurationError)
+	}
+	if setup.Configuration == nil {
+		return failed("Run sutro authenticate to authorize sutro to access your Strava account", "No configuration at %s", path)
+	}
+	// Windows has no permission bits to check.
+	if mode := info.Mode().Perm(); runtime.GOOS != "windows" && mode&0077 != 0 {
+		return failed(fmt.Sprintf("Run chmod 600 %s, as it holds the secret of the application and your token", path),
+			"%s can be read by other users (%04o)", path, mode)
+	}
+	return passed("%s is readable and private", path)
+}
+
+func checkToken(ctx context.Context, configuration config.Configuration) result {
+	if configuration == nil {
+		return skipped("Requires a configuration")
+	}
+
+	// An expired token is refreshed, and the refreshed token saved once the
+	// command completes.
+	token, err := configuration.TokenSource(ctx).Token()
+	if err != nil {
+		return failed("Run sutro authenticate to authorize sutro again", "Unable to obtain a valid token: %v", err)
+	}
+	if token.Expiry.IsZero() {
+		return passed("Valid, without expiry")
+	}
+	return passed("Valid until %s, and refreshed once it expires", token.Expiry.Local().Format("Jan 2 15:04"))
+}
+
+func checkAPI(ctx context.Context, apiClient *strava.Client) (result, strava.Usage, bool) {
+	if apiClient == nil {
+		return skipped("Requires a configuration"), strava.Usage{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	athlete, err := apiClient.Athletes.Current(ctx)
+	usage, ok := apiClient.Usage()
+	if err != nil {
+		var unauthorized *strava.UnauthorizedError
+		var limited *strava.RateLimitError
+		switch {
+		case errors.As(err, &unauthorized):
+			return failed("Run sutro authenticate to authorize sutro again", "%v", err), usage, ok
+		case errors.As(err, &limited):
+			return passed("Reachable, though rate limited until %s", limited.Reset.Local().Format("15:04")), usage, ok
+		case ok:
+			return failed("Strava may be having an outage, try again later", "%v", err), usage, ok
+		}
+		return failed("Check your network connection and proxy settings", "Unable to reach the API: %v", err), usage, ok
+	}
+
+	name := fmt.Sprintf("%s %s", athlete.Firstname, athlete.Lastname)
+	return passed("Authenticated as %s in %s", name, time.Since(start).Round(time.Millisecond)), usage, ok
+}
+
+func checkRateLimit(usage strava.Usage) result {
+	if usage.ShortTermLimit == 0 || usage.DailyLimit == 0 {
+		return skipped("The API did not report the usage of its rate limits")
+	}
+
+	detail := fmt.Sprintf("%d of %d requests used this quarter hour, %d of %d today",
+		usage.ShortTerm, usage.ShortTermLimit, usage.Daily, usage.DailyLimit)
+	if usage.Daily >= usage.DailyLimit {
+		return failed("Wait until midnight UTC, and bound long jobs with --budget", "%s", detail)
+	}
+	if usage.ShortTerm >= usage.ShortTermLimit {
+		return failed("Wait for the next quarter hour, and bound long jobs with --budget", "%s", detail)
+	}
+	return passed("%s", detail)
+}
+
+func checkClock(usage strava.Usage) result {
+	if usage.ServerTime.IsZero() {
+		return skipped("The API did not report its time")
+	}
+
+	// The date of the response has a precision of a second.
+	skew := usage.Received.Sub(usage.ServerTime).Round(time.Second)
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return failed("Synchronize the clock of this machine, for instance with NTP", "The clock is %s off the one of Strava", abs(skew))
+	}
+	return passed("In sync with the clock of Strava, %s off", abs(skew))
+}
+
+func checkCache(streams *cache.Cache) result {
+	directory := streams.Directory()
+	usage, err := streams.Usage()
+	if err != nil {
+		return failed(fmt.Sprintf("Check the permissions of %s, or remove it", directory), "Unable to read the cache: %v", err)
+	}
+
+	probe, err := ioutil.TempFile(directory, ".doctor-")
+	if err != nil {
+		return failed(fmt.Sprintf("Check the permissions of %s and the free space of its disk", directory), "Unable to write to the cache: %v", err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	detail := fmt.Sprintf("%d entries (%s) of at most %s in %s", usage.Entries, cache.FormatSize(usage.Size), cache.FormatSize(streams.MaxSize()), directory)
+	if usage.Size > streams.MaxSize() {
+		return failed("Run sutro cache gc to evict the streams read least recently", "%s", detail)
+	}
+	return passed("%s", detail)
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
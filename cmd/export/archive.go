@@ -0,0 +1,333 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jsilland/sutro/budget"
+	"github.com/jsilland/sutro/choice"
+	tracks "github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/journal"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/stream"
+	"github.com/spf13/cobra"
+)
+
+// sampleKeys are the streams flattened into the samples file.
+var sampleKeys = []string{"time", "distance", "latlng", "altitude", "heartrate", "cadence", "watts"}
+
+type archiveFlags struct {
+	format       string
+	out          string
+	since        string
+	until        string
+	activityType string
+	samples      bool
+	resume       bool
+	privacy      string
+	budget       budget.Flags
+}
+
+// sample is a single point of the streams of an activity. Measurements that
+// were not recorded are NaN, and time is zero without a time stream.
+type sample struct {
+	activityID int64
+	index      int
+	time       time.Time
+	elapsed    float64
+	lat, lng   float64
+	distance   float64
+	altitude   float64
+	heartrate  float64
+	cadence    float64
+	watts      float64
+}
+
+// archiveWriter writes the two files of an archive export: one row per
+// activity, and one row per stream sample keyed by activity id.
+type archiveWriter interface {
+	WriteActivity(activity *models.SummaryActivity) error
+	WriteSample(s sample) error
+	Close() error
+}
+
+// archiveFormats create the writers of each export format in a directory.
+// samples is the name of the samples file without its extension, or empty
+// when samples are not exported.
+var archiveFormats = map[string]func(directory, samples string) (archiveWriter, error){
+	"csv":     newCSVArchive,
+	"parquet": newParquetArchive,
+}
+
+func archiveCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone, stateDirectory string) *cobra.Command {
+	flags := archiveFlags{}
+
+	command := &cobra.Command{
+		Use:   "archive",
+		Short: "Export synced activities and their stream samples as columnar files",
+		Long: "Export synced activities to activities.<format> and, unless --samples=false, their " +
+			"stream samples to samples.<format>, keyed by activity id. Parquet files can be " +
+			"queried directly with DuckDB or Spark. Samples are fetched from the API; when an " +
+			"export is interrupted, --resume writes the samples it did not fetch to another " +
+			"part such as samples.1.<format>.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var b *strava.Budget
+			if apiClient != nil {
+				var err error
+				if b, err = flags.budget.Apply(apiClient); err != nil {
+					return err
+				}
+			}
+			return exportArchive(ctx, apiClient, archive, zones, journal.Path(stateDirectory, "export-archive"), b, flags)
+		},
+	}
+
+	choice.Var(command, &flags.format, "format", "parquet", "The export format: csv or parquet", "csv", "parquet")
+	command.Flags().StringVar(&flags.out, "out", "archive", "The directory to write the files to")
+	command.Flags().StringVar(&flags.since, "since", "", "Only include activities started after this date")
+	command.Flags().StringVar(&flags.until, "until", "", "Only include activities started before this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+	command.Flags().BoolVar(&flags.samples, "samples", true, "Also export the stream samples of each activity")
+	command.Flags().BoolVar(&flags.resume, "resume", false, "Continue an interrupted export, only fetching the samples it did not write")
+	choice.Var(command, &flags.privacy, "privacy", tracks.PrivacyTrim, "How to scrub positions within the configured privacy zones: trim, jitter or off", tracks.PrivacyTrim, tracks.PrivacyJitter, tracks.PrivacyOff)
+	flags.budget.Register(command)
+
+	return command
+}
+
+func exportArchive(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone, journalPath string, b *strava.Budget, flags archiveFlags) error {
+	newWriter, ok := archiveFormats[flags.format]
+	if !ok {
+		return fmt.Errorf("Unknown format %q, expected csv or parquet", flags.format)
+	}
+	if flags.samples && apiClient == nil {
+		return errors.New("Exporting stream samples requires running sutro authenticate first, or pass --samples=false")
+	}
+	scrubber, err := tracks.NewScrubber(zones, flags.privacy, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err != nil {
+		return err
+	}
+	query, err := newQuery(flags.since, flags.until, flags.activityType)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(flags.out, 0755); err != nil {
+		return err
+	}
+
+	// Fetching samples is what spends the quota of the API, so their
+	// progress is journaled: each run writes the samples it fetches to a
+	// part of its own, numbered after the parts written before.
+	var j *journal.Journal
+	part, samplesName := 0, ""
+	if flags.samples {
+		key := fmt.Sprintf("format=%s out=%s since=%s until=%s type=%s", flags.format, path.Clean(flags.out), flags.since, flags.until, flags.activityType)
+		if j, err = journal.Open(journalPath, key, flags.resume); err != nil {
+			return err
+		}
+		if cursor := j.Cursor(); cursor != "" {
+			if part, err = strconv.Atoi(cursor); err != nil {
+				j.Close()
+				return fmt.Errorf("Invalid progress %q in %s", cursor, journalPath)
+			}
+		}
+		if !j.Resumed() {
+			if err := removeParts(flags.out, flags.format); err != nil {
+				j.Close()
+				return err
+			}
+		}
+		samplesName = partName(part)
+	}
+
+	writer, err := newWriter(flags.out, samplesName)
+	if err != nil {
+		if j != nil {
+			j.Close()
+		}
+		return err
+	}
+
+	// Only the ids and start dates are kept while the activities are
+	// written, the streams are fetched once the archive has been read.
+	var ids []int64
+	var starts []time.Time
+	err = archive.EachActivity(query, func(activity *models.SummaryActivity) error {
+		ids = append(ids, activity.ID)
+		starts = append(starts, time.Time(activity.StartDate))
+		scrubbed, err := scrubber.Activity(activity)
+		if err != nil {
+			return err
+		}
+		return writer.WriteActivity(scrubbed)
+	})
+	if err != nil {
+		writer.Close()
+		if j != nil {
+			j.Close()
+		}
+		return err
+	}
+
+	samples := 0
+	var completed []int64
+	var fetchErr error
+	if flags.samples {
+		if j.CompletedCount() > 0 {
+			fmt.Printf("Resuming the export, the samples of %d activities were written by previous runs\n", j.CompletedCount())
+		}
+		bar := progress.New("Exporting samples", int64(len(ids)-j.CompletedCount()), "activities")
+		for i, id := range ids {
+			if j.Completed(id) {
+				continue
+			}
+			// Cached streams are read without a request, which would
+			// otherwise notice the interruption.
+			if err := ctx.Err(); err != nil {
+				fetchErr = err
+				break
+			}
+			count, err := writeSamples(ctx, apiClient, writer, scrubber, id, starts[i])
+			if err != nil {
+				fetchErr = err
+				break
+			}
+			samples += count
+			completed = append(completed, id)
+			bar.Add(1)
+		}
+		bar.Done()
+	}
+
+	// Samples are only committed once their part has been closed, as
+	// Parquet files are unreadable until then.
+	closeErr := writer.Close()
+	stopped := b.Stopped(fetchErr)
+	if stopped {
+		fetchErr = nil
+	}
+	if j != nil {
+		if closeErr == nil && len(completed) > 0 {
+			if err := j.Commit(strconv.Itoa(part+1), completed...); err != nil {
+				j.Close()
+				return err
+			}
+		}
+		if fetchErr != nil && ctx.Err() != nil {
+			j.Close()
+			return fmt.Errorf("Interrupted after exporting the samples of %d activities, run the export again with --resume to continue", len(completed))
+		}
+		if fetchErr != nil || closeErr != nil {
+			j.Close()
+			if fetchErr == nil {
+				fetchErr = closeErr
+			}
+			return fmt.Errorf("%v\nThe export stopped after the samples of %d activities, run it again with --resume to continue it", fetchErr, len(completed))
+		}
+		if stopped {
+			fmt.Printf("%s, exported the samples of %d activities. Run the export again with --resume to continue\n", budget.Describe(b), len(completed))
+			return j.Close()
+		}
+		if err := j.Finish(); err != nil {
+			return err
+		}
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	fmt.Printf("Exported %d activities and %d samples to %s\n", len(ids), samples, path.Clean(flags.out))
+	return nil
+}
+
+// partName returns the name of a part of the samples: samples, then
+// samples.1, samples.2 and so on for the parts written by resumed runs.
+func partName(part int) string {
+	if part == 0 {
+		return "samples"
+	}
+	return fmt.Sprintf("samples.%d", part)
+}
+
+// removeParts removes the parts of samples left in directory by resumed
+// exports, which would otherwise be read along with a new export.
+func removeParts(directory, format string) error {
+	parts, err := filepath.Glob(path.Join(directory, "samples.[0-9]*."+format))
+	if err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if err := os.Remove(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSamples writes the stream samples of an activity, leaving out the
+// positions scrubber drops.
+func writeSamples(ctx context.Context, apiClient *strava.Client, writer archiveWriter, scrubber *tracks.Scrubber, id int64, start time.Time) (int, error) {
+	set, err := apiClient.Streams.Activity(ctx, id, sampleKeys...)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to obtain the streams of activity %d: %w", id, err)
+	}
+
+	times, distances := stream.Times(set), stream.Distances(set)
+	positions, altitudes := stream.Positions(set), stream.Altitudes(set)
+	heartrates, cadences, watts := stream.Heartrates(set), stream.Cadences(set), stream.Watts(set)
+
+	count := 0
+	for _, length := range []int{len(times), len(distances), len(positions), len(altitudes), len(heartrates), len(cadences), len(watts)} {
+		if length > count {
+			count = length
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		s := sample{
+			activityID: id,
+			index:      i,
+			lat:        math.NaN(),
+			lng:        math.NaN(),
+			elapsed:    at(times, i),
+			distance:   at(distances, i),
+			altitude:   at(altitudes, i),
+			heartrate:  at(heartrates, i),
+			cadence:    at(cadences, i),
+			watts:      at(watts, i),
+		}
+		if i < len(positions) {
+			if position, ok := scrubber.Point(positions[i]); ok {
+				s.lat, s.lng = position.Lat, position.Lng
+			}
+		}
+		if i < len(times) {
+			s.time = start.Add(time.Duration(times[i] * float64(time.Second)))
+		}
+		if err := writer.WriteSample(s); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+func at(values []float64, i int) float64 {
+	if i >= len(values) {
+		return math.NaN()
+	}
+	return values[i]
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/jsilland/sutro/models"
+)
+
+type csvFile struct {
+	file *os.File
+	rows *csv.Writer
+}
+
+type csvArchive struct {
+	activities *csvFile
+	samples    *csvFile
+}
+
+var sampleHeader = []string{"activity_id", "index", "time", "elapsed_s", "lat", "lng", "distance_m", "altitude_m", "heartrate_bpm", "cadence", "watts_w"}
+
+func newCSVArchive(directory, samples string) (archiveWriter, error) {
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.header(metric)
+	}
+
+	archive := &csvArchive{}
+	var err error
+	if archive.activities, err = newCSVFile(path.Join(directory, "activities.csv"), header); err != nil {
+		return nil, err
+	}
+	if samples != "" {
+		if archive.samples, err = newCSVFile(path.Join(directory, samples+".csv"), sampleHeader); err != nil {
+			archive.activities.close()
+			return nil, err
+		}
+	}
+	return archive, nil
+}
+
+func newCSVFile(filename string, header []string) (*csvFile, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := csv.NewWriter(file)
+	if err := rows.Write(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &csvFile{file: file, rows: rows}, nil
+}
+
+func (c *csvFile) close() error {
+	c.rows.Flush()
+	if err := c.rows.Error(); err != nil {
+		c.file.Close()
+		return err
+	}
+	return c.file.Close()
+}
+
+func (a *csvArchive) WriteActivity(activity *models.SummaryActivity) error {
+	record := make([]string, len(fields))
+	for i, f := range fields {
+		record[i] = f.value(activity, metric)
+	}
+	return a.activities.rows.Write(record)
+}
+
+func (a *csvArchive) WriteSample(s sample) error {
+	timestamp := ""
+	if !s.time.IsZero() {
+		timestamp = s.time.UTC().Format(time.RFC3339)
+	}
+
+	return a.samples.rows.Write([]string{
+		strconv.FormatInt(s.activityID, 10),
+		strconv.Itoa(s.index),
+		timestamp,
+		number(s.elapsed, 1),
+		number(s.lat, 7),
+		number(s.lng, 7),
+		number(s.distance, 1),
+		number(s.altitude, 1),
+		number(s.heartrate, 0),
+		number(s.cadence, 0),
+		number(s.watts, 0),
+	})
+}
+
+func (a *csvArchive) Close() error {
+	err := a.activities.close()
+	if a.samples != nil {
+		if samplesErr := a.samples.close(); err == nil {
+			err = samplesErr
+		}
+	}
+	return err
+}
+
+// number formats a measurement, leaving the cell empty when it is NaN.
+func number(value float64, precision int) string {
+	if math.IsNaN(value) {
+		return ""
+	}
+	return decimal(value, precision)
+}
@@ -0,0 +1,148 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type csvFlags struct {
+	fields       string
+	since        string
+	until        string
+	activityType string
+	units        string
+	out          string
+}
+
+// Command returns the export commands. apiClient is nil when sutro has not
+// been authenticated, which only the commands needing streams require. The
+// positions written by the archive and sqlite exports are scrubbed of zones.
+func Command(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone, stateDirectory string) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "export",
+		Short: "Export the local archive for analysis in other tools",
+	}
+
+	command.AddCommand(archiveCommand(ctx, apiClient, archive, zones, stateDirectory))
+	command.AddCommand(csvCommand(archive))
+	command.AddCommand(influxCommand(archive))
+	command.AddCommand(sqliteCommand(ctx, apiClient, archive, zones))
+	return command
+}
+
+func csvCommand(archive *store.Store) *cobra.Command {
+	flags := csvFlags{}
+
+	command := &cobra.Command{
+		Use:   "csv",
+		Short: "Flatten synced activities into a CSV file, one row per activity",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportCSV(archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.fields, "fields", "", fmt.Sprintf("The comma separated columns to write, in order (defaults to all: %s)", strings.Join(fieldNames(), ",")))
+	command.Flags().StringVar(&flags.since, "since", "", "Only include activities started after this date")
+	command.Flags().StringVar(&flags.until, "until", "", "Only include activities started before this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+	command.Flags().StringVar(&flags.units, "units", metric, "The units to convert values to: metric or imperial")
+	command.Flags().StringVar(&flags.out, "out", "", "The file to write to instead of stdout")
+
+	return command
+}
+
+func exportCSV(archive *store.Store, flags csvFlags) error {
+	selected, err := selectFields(flags.fields)
+	if err != nil {
+		return err
+	}
+	if err := checkUnits(flags.units); err != nil {
+		return err
+	}
+	query, err := newQuery(flags.since, flags.until, flags.activityType)
+	if err != nil {
+		return err
+	}
+
+	return writeOut(flags.out, func(writer io.Writer) error {
+		buffered := bufio.NewWriter(writer)
+		rows := csv.NewWriter(buffered)
+
+		header := make([]string, len(selected))
+		for i, f := range selected {
+			header[i] = f.header(flags.units)
+		}
+		if err := rows.Write(header); err != nil {
+			return err
+		}
+
+		record := make([]string, len(selected))
+		err := archive.EachActivity(query, func(activity *models.SummaryActivity) error {
+			for i, f := range selected {
+				record[i] = f.value(activity, flags.units)
+			}
+			return rows.Write(record)
+		})
+		if err != nil {
+			return err
+		}
+
+		rows.Flush()
+		if err := rows.Error(); err != nil {
+			return err
+		}
+		return buffered.Flush()
+	})
+}
+
+func newQuery(since, until, activityType string) (store.Query, error) {
+	query := store.Query{Type: activityType}
+	if since != "" {
+		after, err := dates.Parse(since)
+		if err != nil {
+			return query, err
+		}
+		query.After = after
+	}
+	if until != "" {
+		before, err := dates.Parse(until)
+		if err != nil {
+			return query, err
+		}
+		query.Before = before
+	}
+	return query, nil
+}
+
+// writeOut calls write with the named file, or with stdout when filename
+// is empty.
+func writeOut(filename string, write func(io.Writer) error) error {
+	if filename == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
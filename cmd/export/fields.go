@@ -0,0 +1,174 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/models"
+)
+
+const (
+	metric   = "metric"
+	imperial = "imperial"
+
+	metersPerMile = 1609.344
+	feetPerMeter  = 3.28084
+)
+
+// field is a column of the flattened archive. Its header carries the unit
+// its values are converted to.
+type field struct {
+	name  string
+	units map[string]string
+	value func(activity *models.SummaryActivity, units string) string
+}
+
+// fields are listed in the order columns are written when --fields is not
+// given, which must stay stable for spreadsheets built on the export.
+var fields = []field{
+	{name: "id", value: func(a *models.SummaryActivity, units string) string {
+		return strconv.FormatInt(a.ID, 10)
+	}},
+	{name: "start", value: func(a *models.SummaryActivity, units string) string {
+		return time.Time(a.StartDateLocal).Format("2006-01-02 15:04:05")
+	}},
+	{name: "start_utc", value: func(a *models.SummaryActivity, units string) string {
+		return time.Time(a.StartDate).UTC().Format(time.RFC3339)
+	}},
+	{name: "name", value: func(a *models.SummaryActivity, units string) string {
+		return a.Name
+	}},
+	{name: "type", value: func(a *models.SummaryActivity, units string) string {
+		return string(a.Type)
+	}},
+	{name: "distance", units: map[string]string{metric: "km", imperial: "mi"}, value: func(a *models.SummaryActivity, units string) string {
+		return decimal(distance(float64(a.Distance), units), 3)
+	}},
+	{name: "moving_time", units: map[string]string{metric: "s", imperial: "s"}, value: func(a *models.SummaryActivity, units string) string {
+		return strconv.FormatInt(a.MovingTime, 10)
+	}},
+	{name: "elapsed_time", units: map[string]string{metric: "s", imperial: "s"}, value: func(a *models.SummaryActivity, units string) string {
+		return strconv.FormatInt(a.ElapsedTime, 10)
+	}},
+	{name: "elevation_gain", units: map[string]string{metric: "m", imperial: "ft"}, value: func(a *models.SummaryActivity, units string) string {
+		return decimal(elevation(float64(a.TotalElevationGain), units), 1)
+	}},
+	{name: "average_speed", units: map[string]string{metric: "kmh", imperial: "mph"}, value: func(a *models.SummaryActivity, units string) string {
+		return decimal(distance(float64(a.AverageSpeed)*3600, units), 2)
+	}},
+	{name: "max_speed", units: map[string]string{metric: "kmh", imperial: "mph"}, value: func(a *models.SummaryActivity, units string) string {
+		return decimal(distance(float64(a.MaxSpeed)*3600, units), 2)
+	}},
+	{name: "pace", units: map[string]string{metric: "per_km", imperial: "per_mi"}, value: func(a *models.SummaryActivity, units string) string {
+		if a.Distance <= 0 || a.MovingTime <= 0 {
+			return ""
+		}
+		return format.Duration(float64(a.MovingTime) / distance(float64(a.Distance), units))
+	}},
+	{name: "average_heartrate", units: map[string]string{metric: "bpm", imperial: "bpm"}, value: func(a *models.SummaryActivity, units string) string {
+		if !a.HasHeartrate {
+			return ""
+		}
+		return decimal(float64(a.AverageHeartrate), 1)
+	}},
+	{name: "max_heartrate", units: map[string]string{metric: "bpm", imperial: "bpm"}, value: func(a *models.SummaryActivity, units string) string {
+		if !a.HasHeartrate {
+			return ""
+		}
+		return decimal(float64(a.MaxHeartrate), 0)
+	}},
+	{name: "average_watts", units: map[string]string{metric: "w", imperial: "w"}, value: func(a *models.SummaryActivity, units string) string {
+		if a.AverageWatts == 0 {
+			return ""
+		}
+		return decimal(float64(a.AverageWatts), 1)
+	}},
+	{name: "kilojoules", value: func(a *models.SummaryActivity, units string) string {
+		if a.Kilojoules == 0 {
+			return ""
+		}
+		return decimal(float64(a.Kilojoules), 1)
+	}},
+	{name: "commute", value: func(a *models.SummaryActivity, units string) string {
+		return strconv.FormatBool(a.Commute)
+	}},
+	{name: "trainer", value: func(a *models.SummaryActivity, units string) string {
+		return strconv.FormatBool(a.Trainer)
+	}},
+	{name: "gear_id", value: func(a *models.SummaryActivity, units string) string {
+		return a.GearID
+	}},
+	{name: "kudos", value: func(a *models.SummaryActivity, units string) string {
+		return strconv.FormatInt(a.KudosCount, 10)
+	}},
+}
+
+func (f field) header(units string) string {
+	if unit, ok := f.units[units]; ok {
+		return f.name + "_" + unit
+	}
+	return f.name
+}
+
+// selectFields resolves a comma separated list of field names, or all
+// fields when the list is empty.
+func selectFields(names string) ([]field, error) {
+	if strings.TrimSpace(names) == "" {
+		return fields, nil
+	}
+
+	var selected []field
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, f := range fields {
+			if f.name == name {
+				selected = append(selected, f)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown field %q, expected one of %s", name, strings.Join(fieldNames(), ", "))
+		}
+	}
+	return selected, nil
+}
+
+func fieldNames() []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+func checkUnits(units string) error {
+	if units != metric && units != imperial {
+		return fmt.Errorf("Invalid units %q, expected metric or imperial", units)
+	}
+	return nil
+}
+
+// distance converts meters into kilometers or miles.
+func distance(meters float64, units string) float64 {
+	if units == imperial {
+		return meters / metersPerMile
+	}
+	return meters / 1000
+}
+
+// elevation converts meters into meters or feet.
+func elevation(meters float64, units string) float64 {
+	if units == imperial {
+		return meters * feetPerMeter
+	}
+	return meters
+}
+
+func decimal(value float64, precision int) string {
+	return strconv.FormatFloat(value, 'f', precision, 64)
+}
@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/metrics"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+// influxBatchSize is the number of points written per request, within the
+// range InfluxDB recommends.
+const influxBatchSize = 5000
+
+type influxFlags struct {
+	url          string
+	org          string
+	bucket       string
+	token        string
+	since        string
+	activityType string
+	dryRun       bool
+}
+
+func influxCommand(archive *store.Store) *cobra.Command {
+	flags := influxFlags{}
+
+	command := &cobra.Command{
+		Use:   "influx",
+		Short: "Push per-activity and daily metrics of synced activities to InfluxDB",
+		Long: "Write an activity point per synced activity and a daily point per day and activity " +
+			"type to an InfluxDB 2 bucket, for dashboards in Grafana or Chronograf. Points are " +
+			"keyed by time and tags, so pushing the same activities again overwrites them.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportInflux(archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.url, "url", "http://localhost:8086", "The URL of the InfluxDB server")
+	command.Flags().StringVar(&flags.org, "org", "", "The InfluxDB organization")
+	command.Flags().StringVar(&flags.bucket, "bucket", "", "The InfluxDB bucket to write to")
+	command.Flags().StringVar(&flags.token, "token", "", "The InfluxDB API token (defaults to the INFLUX_TOKEN environment variable)")
+	command.Flags().StringVar(&flags.since, "since", "", "Only include activities started after this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+	command.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print the points in line protocol instead of writing them")
+
+	return command
+}
+
+func exportInflux(archive *store.Store, flags influxFlags) error {
+	token := flags.token
+	if token == "" {
+		token = os.Getenv("INFLUX_TOKEN")
+	}
+	if !flags.dryRun && flags.bucket == "" {
+		return errors.New("The --bucket to write to is required")
+	}
+	query, err := newQuery(flags.since, "", flags.activityType)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	var activities []*models.SummaryActivity
+	err = archive.EachActivity(query, func(activity *models.SummaryActivity) error {
+		activities = append(activities, activity)
+		lines = append(lines, metrics.ActivityLine(activity))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, day := range metrics.Daily(activities) {
+		lines = append(lines, metrics.DayLine(day))
+	}
+
+	if flags.dryRun {
+		fmt.Println(strings.Join(lines, "\n"))
+		return nil
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(flags.url, "/") + "/api/v2/write")
+	if err != nil {
+		return fmt.Errorf("Invalid InfluxDB URL %q: %v", flags.url, err)
+	}
+	parameters := url.Values{"bucket": {flags.bucket}, "precision": {"s"}}
+	if flags.org != "" {
+		parameters.Set("org", flags.org)
+	}
+	endpoint.RawQuery = parameters.Encode()
+
+	for from := 0; from < len(lines); from += influxBatchSize {
+		to := from + influxBatchSize
+		if to > len(lines) {
+			to = len(lines)
+		}
+		if err := writeInflux(endpoint.String(), token, lines[from:to]); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Wrote %d activities and %d daily points to %s\n", len(activities), len(lines)-len(activities), flags.bucket)
+	return nil
+}
+
+func writeInflux(endpoint, token string, lines []string) error {
+	request, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBufferString(strings.Join(lines, "\n")))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if token != "" {
+		request.Header.Set("Authorization", "Token "+token)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("InfluxDB rejected the points with %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
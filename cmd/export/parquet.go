@@ -0,0 +1,172 @@
+package export
+
+import (
+	"math"
+	"os"
+	"path"
+	"time"
+
+	"github.com/jsilland/sutro/models"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Parquet columns keep SI units, meters, seconds and meters per second, so
+// that queries do not depend on the units an export was made in.
+type activityRow struct {
+	ID               int64    `parquet:"name=id, type=INT64"`
+	Start            int64    `parquet:"name=start, type=TIMESTAMP_MILLIS"`
+	StartLocal       int64    `parquet:"name=start_local, type=TIMESTAMP_MILLIS"`
+	Timezone         string   `parquet:"name=timezone, type=UTF8, encoding=PLAIN_DICTIONARY"`
+	Name             string   `parquet:"name=name, type=UTF8"`
+	Type             string   `parquet:"name=type, type=UTF8, encoding=PLAIN_DICTIONARY"`
+	Distance         float64  `parquet:"name=distance, type=DOUBLE"`
+	MovingTime       int64    `parquet:"name=moving_time, type=INT64"`
+	ElapsedTime      int64    `parquet:"name=elapsed_time, type=INT64"`
+	ElevationGain    float64  `parquet:"name=elevation_gain, type=DOUBLE"`
+	AverageSpeed     float64  `parquet:"name=average_speed, type=DOUBLE"`
+	MaxSpeed         float64  `parquet:"name=max_speed, type=DOUBLE"`
+	AverageHeartrate *float64 `parquet:"name=average_heartrate, type=DOUBLE"`
+	MaxHeartrate     *float64 `parquet:"name=max_heartrate, type=DOUBLE"`
+	AverageWatts     *float64 `parquet:"name=average_watts, type=DOUBLE"`
+	Kilojoules       *float64 `parquet:"name=kilojoules, type=DOUBLE"`
+	Commute          bool     `parquet:"name=commute, type=BOOLEAN"`
+	Trainer          bool     `parquet:"name=trainer, type=BOOLEAN"`
+	GearID           string   `parquet:"name=gear_id, type=UTF8, encoding=PLAIN_DICTIONARY"`
+}
+
+type sampleRow struct {
+	ActivityID int64    `parquet:"name=activity_id, type=INT64"`
+	Index      int32    `parquet:"name=index, type=INT32"`
+	Time       *int64   `parquet:"name=time, type=TIMESTAMP_MILLIS"`
+	Elapsed    *float64 `parquet:"name=elapsed, type=DOUBLE"`
+	Lat        *float64 `parquet:"name=lat, type=DOUBLE"`
+	Lng        *float64 `parquet:"name=lng, type=DOUBLE"`
+	Distance   *float64 `parquet:"name=distance, type=DOUBLE"`
+	Altitude   *float64 `parquet:"name=altitude, type=DOUBLE"`
+	Heartrate  *float64 `parquet:"name=heartrate, type=DOUBLE"`
+	Cadence    *float64 `parquet:"name=cadence, type=DOUBLE"`
+	Watts      *float64 `parquet:"name=watts, type=DOUBLE"`
+}
+
+type parquetFile struct {
+	file   *os.File
+	writer *writer.ParquetWriter
+}
+
+type parquetArchive struct {
+	activities *parquetFile
+	samples    *parquetFile
+}
+
+func newParquetArchive(directory, samples string) (archiveWriter, error) {
+	archive := &parquetArchive{}
+
+	var err error
+	if archive.activities, err = newParquetFile(path.Join(directory, "activities.parquet"), new(activityRow)); err != nil {
+		return nil, err
+	}
+	if samples != "" {
+		if archive.samples, err = newParquetFile(path.Join(directory, samples+".parquet"), new(sampleRow)); err != nil {
+			archive.activities.close()
+			return nil, err
+		}
+	}
+	return archive, nil
+}
+
+func newParquetFile(filename string, schema interface{}) (*parquetFile, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := writer.NewParquetWriterFromWriter(file, schema, 1)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &parquetFile{file: file, writer: w}, nil
+}
+
+func (p *parquetFile) close() error {
+	if err := p.writer.WriteStop(); err != nil {
+		p.file.Close()
+		return err
+	}
+	return p.file.Close()
+}
+
+func (a *parquetArchive) WriteActivity(activity *models.SummaryActivity) error {
+	row := activityRow{
+		ID:            activity.ID,
+		Start:         milliseconds(time.Time(activity.StartDate)),
+		StartLocal:    milliseconds(time.Time(activity.StartDateLocal)),
+		Timezone:      activity.Timezone,
+		Name:          activity.Name,
+		Type:          string(activity.Type),
+		Distance:      float64(activity.Distance),
+		MovingTime:    activity.MovingTime,
+		ElapsedTime:   activity.ElapsedTime,
+		ElevationGain: float64(activity.TotalElevationGain),
+		AverageSpeed:  float64(activity.AverageSpeed),
+		MaxSpeed:      float64(activity.MaxSpeed),
+		Commute:       activity.Commute,
+		Trainer:       activity.Trainer,
+		GearID:        activity.GearID,
+	}
+	if activity.HasHeartrate {
+		row.AverageHeartrate = optional(float64(activity.AverageHeartrate))
+		row.MaxHeartrate = optional(float64(activity.MaxHeartrate))
+	}
+	if activity.AverageWatts > 0 {
+		row.AverageWatts = optional(float64(activity.AverageWatts))
+	}
+	if activity.Kilojoules > 0 {
+		row.Kilojoules = optional(float64(activity.Kilojoules))
+	}
+	return a.activities.writer.Write(row)
+}
+
+func (a *parquetArchive) WriteSample(s sample) error {
+	row := sampleRow{
+		ActivityID: s.activityID,
+		Index:      int32(s.index),
+		Elapsed:    optional(s.elapsed),
+		Lat:        optional(s.lat),
+		Lng:        optional(s.lng),
+		Distance:   optional(s.distance),
+		Altitude:   optional(s.altitude),
+		Heartrate:  optional(s.heartrate),
+		Cadence:    optional(s.cadence),
+		Watts:      optional(s.watts),
+	}
+	if !s.time.IsZero() {
+		t := milliseconds(s.time)
+		row.Time = &t
+	}
+	return a.samples.writer.Write(row)
+}
+
+func (a *parquetArchive) Close() error {
+	err := a.activities.close()
+	if a.samples != nil {
+		if samplesErr := a.samples.close(); err == nil {
+			err = samplesErr
+		}
+	}
+	return err
+}
+
+// milliseconds returns the Unix time of t in milliseconds. Local start
+// dates are stored with their wall clock read as UTC, as Strava does.
+func milliseconds(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// optional maps NaN to a null column value.
+func optional(value float64) *float64 {
+	if math.IsNaN(value) {
+		return nil
+	}
+	return &value
+}
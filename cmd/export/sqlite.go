@@ -0,0 +1,475 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	tracks "github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// snapshotVersion is the version of the schema of SQLite snapshots, stored
+// as their user_version.
+const snapshotVersion = 1
+
+type sqliteFlags struct {
+	out          string
+	since        string
+	until        string
+	activityType string
+	laps         bool
+	gear         bool
+	samples      bool
+	privacy      string
+}
+
+type column struct {
+	name        string
+	definition  string
+	description string
+}
+
+type table struct {
+	name        string
+	description string
+	columns     []column
+	// constraints follow the columns in the definition of the table.
+	constraints string
+}
+
+// snapshotTables are the tables of a snapshot, which are documented in
+// its schema table. Distances are in meters, durations in seconds, speeds
+// in meters per second and dates in RFC 3339.
+var snapshotTables = []table{
+	{
+		name:        "activities",
+		description: "The activities of the athlete, one row per activity",
+		columns: []column{
+			{"id", "INTEGER PRIMARY KEY", "The id of the activity on Strava"},
+			{"name", "TEXT NOT NULL", "The name of the activity"},
+			{"type", "TEXT NOT NULL", "The type of the activity, such as Ride or Run"},
+			{"start_date", "TEXT NOT NULL", "The time the activity started, in UTC"},
+			{"start_date_local", "TEXT", "The time on the clock where the activity started, written in UTC"},
+			{"timezone", "TEXT", "The time zone the activity started in"},
+			{"distance", "REAL", "The distance covered, in meters"},
+			{"moving_time", "INTEGER", "The time spent moving, in seconds"},
+			{"elapsed_time", "INTEGER", "The time from start to finish, in seconds"},
+			{"total_elevation_gain", "REAL", "The elevation climbed, in meters"},
+			{"average_speed", "REAL", "The average speed, in meters per second"},
+			{"max_speed", "REAL", "The maximum speed, in meters per second"},
+			{"average_heartrate", "REAL", "The average heart rate, in beats per minute, or NULL without a monitor"},
+			{"max_heartrate", "REAL", "The maximum heart rate, in beats per minute, or NULL without a monitor"},
+			{"average_watts", "REAL", "The average power, in watts, or NULL without power"},
+			{"kilojoules", "REAL", "The work done, in kilojoules, or NULL without power"},
+			{"commute", "INTEGER NOT NULL", "1 when the activity is flagged as a commute"},
+			{"trainer", "INTEGER NOT NULL", "1 when the activity was recorded on a trainer"},
+			{"manual", "INTEGER NOT NULL", "1 when the activity was entered by hand"},
+			{"private", "INTEGER NOT NULL", "1 when the activity is only visible to the athlete"},
+			{"gear_id", "TEXT", "The id of the gear used, in the gear table"},
+			{"kudos_count", "INTEGER", "The number of kudos the activity received"},
+			{"summary_polyline", "TEXT", "The track of the activity, as an encoded polyline"},
+			{"data", "TEXT NOT NULL", "The activity as returned by the API, as JSON"},
+		},
+	},
+	{
+		name:        "gear",
+		description: "The bikes and shoes of the athlete",
+		columns: []column{
+			{"id", "TEXT PRIMARY KEY", "The id of the gear, such as b1234 for a bike or g1234 for shoes"},
+			{"kind", "TEXT NOT NULL", "bike or shoes"},
+			{"name", "TEXT", "The name of the gear"},
+			{"distance", "REAL", "The distance covered with the gear, in meters"},
+			{"is_primary", "INTEGER NOT NULL", "1 for the default gear of its kind"},
+		},
+	},
+	{
+		name:        "laps",
+		description: "The laps of the activities",
+		columns: []column{
+			{"activity_id", "INTEGER NOT NULL REFERENCES activities (id)", "The activity of the lap"},
+			{"lap_index", "INTEGER NOT NULL", "The position of the lap in the activity, from 1"},
+			{"name", "TEXT", "The name of the lap"},
+			{"start_date", "TEXT", "The time the lap started, in UTC"},
+			{"distance", "REAL", "The distance of the lap, in meters"},
+			{"moving_time", "INTEGER", "The time spent moving in the lap, in seconds"},
+			{"elapsed_time", "INTEGER", "The duration of the lap, in seconds"},
+			{"total_elevation_gain", "REAL", "The elevation climbed in the lap, in meters"},
+			{"average_speed", "REAL", "The average speed of the lap, in meters per second"},
+			{"max_speed", "REAL", "The maximum speed of the lap, in meters per second"},
+			{"average_cadence", "REAL", "The average cadence of the lap"},
+			{"start_index", "INTEGER", "The index of the first sample of the lap in the samples of its activity"},
+			{"end_index", "INTEGER", "The index of the last sample of the lap in the samples of its activity"},
+		},
+		constraints: "PRIMARY KEY (activity_id, lap_index)",
+	},
+	{
+		name:        "samples",
+		description: "The stream samples of the activities, one row per point",
+		columns: []column{
+			{"activity_id", "INTEGER NOT NULL REFERENCES activities (id)", "The activity of the sample"},
+			{"sample_index", "INTEGER NOT NULL", "The position of the sample in the activity, from 0"},
+			{"time", "TEXT", "The time of the sample, in UTC"},
+			{"elapsed", "REAL", "The time since the start of the activity, in seconds"},
+			{"lat", "REAL", "The latitude of the sample, in degrees"},
+			{"lng", "REAL", "The longitude of the sample, in degrees"},
+			{"distance", "REAL", "The distance since the start of the activity, in meters"},
+			{"altitude", "REAL", "The altitude of the sample, in meters"},
+			{"heartrate", "REAL", "The heart rate, in beats per minute"},
+			{"cadence", "REAL", "The cadence"},
+			{"watts", "REAL", "The power, in watts"},
+		},
+		constraints: "PRIMARY KEY (activity_id, sample_index)",
+	},
+	{
+		name:        "metadata",
+		description: "How and when the snapshot was made",
+		columns: []column{
+			{"key", "TEXT PRIMARY KEY", "exported_at, schema_version, since, until, type, laps, gear or samples"},
+			{"value", "TEXT", "The value of the key"},
+		},
+	},
+	{
+		name:        "schema",
+		description: "The documentation of the tables and columns of the snapshot",
+		columns: []column{
+			{"table_name", "TEXT NOT NULL", "The name of the table"},
+			{"column_name", "TEXT", "The name of the column, or NULL for the table itself"},
+			{"description", "TEXT NOT NULL", "What the table or column holds"},
+		},
+	},
+}
+
+func sqliteCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone) *cobra.Command {
+	flags := sqliteFlags{}
+
+	command := &cobra.Command{
+		Use:   "sqlite",
+		Short: "Export synced activities as a standalone SQLite database",
+		Long: "Export synced activities to a single SQLite file meant for sharing and archival, " +
+			"independent of the archive of sutro: one table per kind of data, with the units " +
+			"of each column documented in its schema table. The gear and, with --laps, the laps " +
+			"of each activity are fetched from the API, as are, with --samples, the stream " +
+			"samples. The file is replaced once the export succeeds.",
+		Example: "  sutro export sqlite --out strava.db\n" +
+			"  sqlite3 strava.db 'SELECT table_name, column_name, description FROM schema'",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportSQLite(ctx, cmd, apiClient, archive, zones, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.out, "out", "strava.db", "The SQLite file to write")
+	command.Flags().StringVar(&flags.since, "since", "", "Only include activities started after this date")
+	command.Flags().StringVar(&flags.until, "until", "", "Only include activities started before this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+	command.Flags().BoolVar(&flags.gear, "gear", true, "Also export the bikes and shoes of the athlete")
+	command.Flags().BoolVar(&flags.laps, "laps", false, "Also export the laps of each activity, with a request per activity")
+	command.Flags().BoolVar(&flags.samples, "samples", false, "Also export the stream samples of each activity, with a request per activity not cached")
+	choice.Var(command, &flags.privacy, "privacy", tracks.PrivacyTrim, "How to scrub positions within the configured privacy zones: trim, jitter or off", tracks.PrivacyTrim, tracks.PrivacyJitter, tracks.PrivacyOff)
+
+	return command
+}
+
+func exportSQLite(ctx context.Context, cmd *cobra.Command, apiClient *strava.Client, archive *store.Store, zones []geo.Zone, flags sqliteFlags) error {
+	if apiClient == nil {
+		if flags.laps || flags.samples {
+			return errors.New("Exporting laps or stream samples requires running sutro authenticate first")
+		}
+		if cmd.Flags().Changed("gear") && flags.gear {
+			return errors.New("Exporting the gear requires running sutro authenticate first, or pass --gear=false")
+		}
+		flags.gear = false
+	}
+	scrubber, err := tracks.NewScrubber(zones, flags.privacy, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err != nil {
+		return err
+	}
+	query, err := newQuery(flags.since, flags.until, flags.activityType)
+	if err != nil {
+		return err
+	}
+
+	// The snapshot is written next to its destination and renamed once
+	// complete, so that a failed export leaves the previous one intact.
+	temporary := path.Join(path.Dir(flags.out), "."+path.Base(flags.out)+".tmp")
+	os.Remove(temporary)
+	snapshot, err := newSnapshot(temporary)
+	if err != nil {
+		return err
+	}
+	counts, err := snapshot.fill(ctx, apiClient, archive, scrubber, query, flags)
+	if closeErr := snapshot.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporary, flags.out)
+	}
+	if err != nil {
+		os.Remove(temporary)
+		return err
+	}
+
+	fmt.Printf("Exported %s to %s\n", counts, flags.out)
+	return nil
+}
+
+// snapshot is a SQLite database being exported, written in a single
+// transaction.
+type snapshot struct {
+	db *sql.DB
+	tx *sql.Tx
+
+	activities, samples, laps *sql.Stmt
+}
+
+func newSnapshot(filename string) (*snapshot, error) {
+	db, err := sql.Open("sqlite3", filename)
+	if err != nil {
+		return nil, err
+	}
+	s := &snapshot{db: db}
+	if err := s.create(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *snapshot) create() error {
+	if _, err := s.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", snapshotVersion)); err != nil {
+		return err
+	}
+	var err error
+	if s.tx, err = s.db.Begin(); err != nil {
+		return err
+	}
+	for _, t := range snapshotTables {
+		definitions := make([]string, len(t.columns))
+		for i, c := range t.columns {
+			definitions[i] = c.name + " " + c.definition
+		}
+		if t.constraints != "" {
+			definitions = append(definitions, t.constraints)
+		}
+		if _, err := s.tx.Exec(fmt.Sprintf("CREATE TABLE %s (\n\t%s\n)", t.name, strings.Join(definitions, ",\n\t"))); err != nil {
+			return err
+		}
+	}
+	for _, t := range snapshotTables {
+		if _, err := s.tx.Exec("INSERT INTO schema VALUES (?, NULL, ?)", t.name, t.description); err != nil {
+			return err
+		}
+		for _, c := range t.columns {
+			if _, err := s.tx.Exec("INSERT INTO schema VALUES (?, ?, ?)", t.name, c.name, c.description); err != nil {
+				return err
+			}
+		}
+	}
+
+	if s.activities, err = s.tx.Prepare(insertInto("activities")); err != nil {
+		return err
+	}
+	if s.laps, err = s.tx.Prepare(insertInto("laps")); err != nil {
+		return err
+	}
+	s.samples, err = s.tx.Prepare(insertInto("samples"))
+	return err
+}
+
+// insertInto returns the statement inserting a row in the table name of
+// snapshots.
+func insertInto(name string) string {
+	var t table
+	for _, t = range snapshotTables {
+		if t.name == name {
+			break
+		}
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(t.columns)), ", ")
+	return fmt.Sprintf("INSERT INTO %s VALUES (%s)", t.name, placeholders)
+}
+
+// snapshotCounts are the rows written to a snapshot.
+type snapshotCounts struct {
+	activities, gear, laps, samples int
+}
+
+func (c snapshotCounts) String() string {
+	return fmt.Sprintf("%d activities, %d pieces of gear, %d laps and %d samples", c.activities, c.gear, c.laps, c.samples)
+}
+
+// fill writes the activities of archive matching query to the snapshot,
+// along with what flags ask for from the API, their positions scrubbed by
+// scrubber.
+func (s *snapshot) fill(ctx context.Context, apiClient *strava.Client, archive *store.Store, scrubber *tracks.Scrubber, query store.Query, flags sqliteFlags) (snapshotCounts, error) {
+	var counts snapshotCounts
+	metadata := map[string]string{
+		"exported_at":    time.Now().UTC().Format(time.RFC3339),
+		"schema_version": fmt.Sprint(snapshotVersion),
+		"since":          flags.since,
+		"until":          flags.until,
+		"type":           flags.activityType,
+		"gear":           fmt.Sprint(flags.gear),
+		"laps":           fmt.Sprint(flags.laps),
+		"samples":        fmt.Sprint(flags.samples),
+		"privacy":        flags.privacy,
+	}
+	for key, value := range metadata {
+		if _, err := s.tx.Exec("INSERT INTO metadata VALUES (?, ?)", key, value); err != nil {
+			return counts, err
+		}
+	}
+
+	var ids []int64
+	var starts []time.Time
+	err := archive.EachActivity(query, func(activity *models.SummaryActivity) error {
+		ids = append(ids, activity.ID)
+		starts = append(starts, time.Time(activity.StartDate))
+		scrubbed, err := scrubber.Activity(activity)
+		if err != nil {
+			return err
+		}
+		return s.WriteActivity(scrubbed)
+	})
+	if err != nil {
+		return counts, err
+	}
+	counts.activities = len(ids)
+
+	if flags.gear {
+		athlete, err := apiClient.Athletes.Current(ctx)
+		if err != nil {
+			return counts, fmt.Errorf("Failed to obtain the gear of the athlete: %v", err)
+		}
+		for kind, gear := range map[string][]*models.SummaryGear{"bike": athlete.Bikes, "shoes": athlete.Shoes} {
+			for _, g := range gear {
+				if _, err := s.tx.Exec("INSERT INTO gear VALUES (?, ?, ?, ?, ?)", g.ID, kind, g.Name, float64(g.Distance), g.Primary); err != nil {
+					return counts, err
+				}
+				counts.gear++
+			}
+		}
+	}
+
+	if !flags.laps && !flags.samples {
+		return counts, nil
+	}
+	bar := progress.New("Exporting laps and samples", int64(len(ids)), "activities")
+	defer bar.Done()
+	for i, id := range ids {
+		if flags.laps {
+			laps, err := apiClient.Activities.Laps(ctx, id)
+			if err != nil {
+				return counts, fmt.Errorf("Failed to obtain the laps of activity %d: %v", id, err)
+			}
+			for _, lap := range laps {
+				if err := s.writeLap(id, lap); err != nil {
+					return counts, err
+				}
+			}
+			counts.laps += len(laps)
+		}
+		if flags.samples {
+			count, err := writeSamples(ctx, apiClient, s, scrubber, id, starts[i])
+			if err != nil {
+				return counts, err
+			}
+			counts.samples += count
+		}
+		bar.Add(1)
+	}
+	return counts, nil
+}
+
+func (s *snapshot) WriteActivity(activity *models.SummaryActivity) error {
+	data, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	var heartrate, maxHeartrate, watts, kilojoules interface{}
+	if activity.HasHeartrate {
+		heartrate, maxHeartrate = float64(activity.AverageHeartrate), float64(activity.MaxHeartrate)
+	}
+	if activity.AverageWatts > 0 {
+		watts = float64(activity.AverageWatts)
+	}
+	if activity.Kilojoules > 0 {
+		kilojoules = float64(activity.Kilojoules)
+	}
+	var polyline, gearID interface{}
+	if activity.Map != nil && activity.Map.SummaryPolyline != "" {
+		polyline = activity.Map.SummaryPolyline
+	}
+	if activity.GearID != "" {
+		gearID = activity.GearID
+	}
+
+	_, err = s.activities.Exec(activity.ID, activity.Name, string(activity.Type),
+		rfc3339(time.Time(activity.StartDate)), rfc3339(time.Time(activity.StartDateLocal)), activity.Timezone,
+		float64(activity.Distance), activity.MovingTime, activity.ElapsedTime, float64(activity.TotalElevationGain),
+		float64(activity.AverageSpeed), float64(activity.MaxSpeed), heartrate, maxHeartrate, watts, kilojoules,
+		activity.Commute, activity.Trainer, activity.Manual, activity.Private, gearID, activity.KudosCount,
+		polyline, string(data))
+	return err
+}
+
+func (s *snapshot) writeLap(activityID int64, lap *models.Lap) error {
+	_, err := s.laps.Exec(activityID, lap.LapIndex, lap.Name, rfc3339(time.Time(lap.StartDate)),
+		float64(lap.Distance), lap.MovingTime, lap.ElapsedTime, float64(lap.TotalElevationGain),
+		float64(lap.AverageSpeed), float64(lap.MaxSpeed), float64(lap.AverageCadence), lap.StartIndex, lap.EndIndex)
+	return err
+}
+
+func (s *snapshot) WriteSample(sample sample) error {
+	var t interface{}
+	if !sample.time.IsZero() {
+		t = rfc3339(sample.time)
+	}
+	_, err := s.samples.Exec(sample.activityID, sample.index, t, nullable(sample.elapsed), nullable(sample.lat),
+		nullable(sample.lng), nullable(sample.distance), nullable(sample.altitude), nullable(sample.heartrate),
+		nullable(sample.cadence), nullable(sample.watts))
+	return err
+}
+
+// Close commits the snapshot and closes its database.
+func (s *snapshot) Close() error {
+	var err error
+	if s.tx != nil {
+		err = s.tx.Commit()
+	}
+	if closeErr := s.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// rfc3339 returns t in UTC as RFC 3339, or nil for the zero time.
+func rfc3339(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// nullable maps NaN to NULL.
+func nullable(value float64) interface{} {
+	if math.IsNaN(value) {
+		return nil
+	}
+	return value
+}
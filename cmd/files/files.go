@@ -0,0 +1,149 @@
+package files
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jsilland/sutro/files"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/stream"
+	"github.com/spf13/cobra"
+)
+
+// ascentThreshold filters out barometric and GPS noise when summing up the
+// elevation gain of a file, in meters.
+const ascentThreshold = 2
+
+func Command() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "files",
+		Short: "Work with local FIT, GPX and TCX activity files",
+	}
+
+	command.AddCommand(inspectCommand())
+	return command
+}
+
+func inspectCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "inspect <file>...",
+		Short: "Print summary statistics of activity files and check them before upload",
+		Long: "Decode FIT, GPX and TCX files, optionally gzipped, print their summary statistics " +
+			"and report the problems that would make an upload fail. Exits with an error if any " +
+			"file is invalid.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return inspect(args)
+		},
+	}
+}
+
+func inspect(filenames []string) error {
+	invalid := 0
+	for i, filename := range filenames {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		file, err := files.Open(filename)
+		if err != nil {
+			fmt.Printf("%s\n  ✗ %v\n", filename, err)
+			invalid++
+			continue
+		}
+
+		if err := summarize(filename, file); err != nil {
+			return err
+		}
+
+		problems := file.Validate()
+		for _, problem := range problems {
+			fmt.Printf("  ✗ %s\n", problem)
+		}
+		if len(problems) > 0 {
+			invalid++
+		} else {
+			fmt.Println("  ✓ Ready to upload")
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d files are invalid", invalid, len(filenames))
+	}
+	return nil
+}
+
+func summarize(filename string, file *files.File) error {
+	fmt.Println(filename)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	kind := strings.ToUpper(file.Format)
+	if file.Gzipped {
+		kind += ", gzipped"
+	}
+	fmt.Fprintf(writer, "  Format\t%s\t\n", kind)
+	if file.Sport != "" {
+		fmt.Fprintf(writer, "  Sport\t%s\t\n", file.Sport)
+	}
+	if file.Name != "" {
+		fmt.Fprintf(writer, "  Name\t%s\t\n", file.Name)
+	}
+	fmt.Fprintf(writer, "  Samples\t%d (%d with a position)\t\n", len(file.Samples), len(file.Positions()))
+
+	if start, end := file.Start(), file.End(); !start.IsZero() {
+		fmt.Fprintf(writer, "  Start\t%s\t\n", start.Local().Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(writer, "  Duration\t%s\t\n", format.Duration(end.Sub(start).Seconds()))
+	}
+	fmt.Fprintf(writer, "  Distance\t%s\t\n", format.Kilometers(file.TotalDistance()))
+
+	elevations, heartrates, cadences, watts := measurements(file)
+	if len(elevations) > 0 {
+		fmt.Fprintf(writer, "  Ascent\t%.0f m\t\n", stream.Ascent(elevations, ascentThreshold))
+	}
+	if len(heartrates) > 0 {
+		mean, max := meanAndMax(heartrates)
+		fmt.Fprintf(writer, "  Heart rate\t%.0f bpm average, %.0f max\t\n", mean, max)
+	}
+	if len(cadences) > 0 {
+		mean, _ := meanAndMax(cadences)
+		fmt.Fprintf(writer, "  Cadence\t%.0f average\t\n", mean)
+	}
+	if len(watts) > 0 {
+		mean, max := meanAndMax(watts)
+		fmt.Fprintf(writer, "  Power\t%.0f W average, %.0f max\t\n", mean, max)
+	}
+
+	return writer.Flush()
+}
+
+// measurements returns the recorded values of each measurement, leaving out
+// the samples that did not record it.
+func measurements(file *files.File) (elevations, heartrates, cadences, watts []float64) {
+	for _, sample := range file.Samples {
+		if !math.IsNaN(sample.Elevation) {
+			elevations = append(elevations, sample.Elevation)
+		}
+		if !math.IsNaN(sample.Heartrate) {
+			heartrates = append(heartrates, sample.Heartrate)
+		}
+		if !math.IsNaN(sample.Cadence) {
+			cadences = append(cadences, sample.Cadence)
+		}
+		if !math.IsNaN(sample.Watts) {
+			watts = append(watts, sample.Watts)
+		}
+	}
+	return
+}
+
+func meanAndMax(values []float64) (float64, float64) {
+	sum, max := 0.0, math.Inf(-1)
+	for _, v := range values {
+		sum += v
+		max = math.Max(max, v)
+	}
+	return sum / float64(len(values)), max
+}
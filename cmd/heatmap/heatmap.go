@@ -0,0 +1,106 @@
+package heatmap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/heatmap"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+type heatmapFlags struct {
+	since        string
+	activityType string
+	out          string
+	width        int
+}
+
+func Command(archive *store.Store) *cobra.Command {
+	flags := heatmapFlags{}
+
+	command := &cobra.Command{
+		Use:   "heatmap",
+		Short: "Render a heatmap of synced activities",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return render(archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.since, "since", "", "Only include activities started after this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+	command.Flags().StringVar(&flags.out, "out", "heatmap.png", "The file to write, either a .png image or an interactive .html page")
+	command.Flags().IntVar(&flags.width, "width", 2000, "The width of the PNG image, in pixels, or its height when the tracks span more from north to south")
+
+	return command
+}
+
+func render(archive *store.Store, flags heatmapFlags) error {
+	extension := strings.ToLower(path.Ext(flags.out))
+	if extension != ".png" && extension != ".html" {
+		return fmt.Errorf("Unsupported output %q, expected a .png or .html file", flags.out)
+	}
+	if flags.width < 16 {
+		return errors.New("The width must be at least 16 pixels")
+	}
+
+	query := store.Query{Type: flags.activityType}
+	if flags.since != "" {
+		after, err := dates.Parse(flags.since)
+		if err != nil {
+			return err
+		}
+		query.After = after
+	}
+
+	activities, err := archive.Activities(query)
+	if err != nil {
+		return err
+	}
+
+	var tracks [][]geo.Point
+	for _, activity := range activities {
+		if activity.Map == nil || activity.Map.SummaryPolyline == "" {
+			continue
+		}
+
+		points, err := geo.DecodePolyline(activity.Map.SummaryPolyline)
+		if err != nil {
+			return fmt.Errorf("Unable to decode the map of activity %d: %v", activity.ID, err)
+		}
+		if extension == ".html" {
+			points = geo.Resample(points, 25)
+		}
+		tracks = append(tracks, points)
+	}
+	if len(tracks) == 0 {
+		return errors.New("No synced activity with a map matches, have you run sutro sync?")
+	}
+
+	file, err := os.Create(flags.out)
+	if err != nil {
+		return err
+	}
+
+	if extension == ".png" {
+		err = heatmap.RenderPNG(file, tracks, flags.width)
+	} else {
+		err = heatmap.RenderHTML(file, "Sutro heatmap", tracks)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Rendered %d activities to %s\n", len(tracks), flags.out)
+	return nil
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+// shownIDs is the number of ids listed for each command, the others being
+// counted.
+const shownIDs = 5
+
+type historyFlags struct {
+	limit int
+}
+
+func Command(archive *store.Store) *cobra.Command {
+	flags := historyFlags{}
+
+	command := &cobra.Command{
+		Use:   "history",
+		Short: "List recent commands and the ids they returned",
+		Long: "List the recent commands of sutro with the ids they returned, such as the activities " +
+			"synced or created. Pass @last instead of ids to refer to the ids returned by the last " +
+			"command that returned any:\n\n" +
+			"  sutro sync\n" +
+			"  sutro activities export @last --out new",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return list(archive, flags)
+		},
+	}
+
+	command.Flags().IntVar(&flags.limit, "limit", 20, "The number of commands to list, or 0 for all of them")
+
+	return command
+}
+
+func list(archive *store.Store, flags historyFlags) error {
+	entries, err := archive.History(flags.limit)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No command in the history yet")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	// The most recent command is listed last, next to the prompt.
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		fmt.Fprintf(writer, "%s\tsutro %s\t%s\n", entry.Time.Format("Jan 2 15:04"), entry.Command, describeIDs(entry.IDs))
+	}
+	return writer.Flush()
+}
+
+func describeIDs(ids []int64) string {
+	if len(ids) == 0 {
+		return ""
+	}
+
+	shown := make([]string, 0, shownIDs)
+	for i := 0; i < len(ids) && i < shownIDs; i++ {
+		shown = append(shown, strconv.FormatInt(ids[i], 10))
+	}
+	description := strings.Join(shown, " ")
+	if len(ids) > shownIDs {
+		description += fmt.Sprintf(" and %d more", len(ids)-shownIDs)
+	}
+	return description
+}
@@ -0,0 +1,223 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/budget"
+	"github.com/jsilland/sutro/cmd/alias"
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/jobs"
+	"github.com/jsilland/sutro/profiles"
+	"github.com/spf13/cobra"
+)
+
+// Command returns the jobs command, which runs the jobs of the
+// configuration as the given profile.
+func Command(ctx context.Context, configuration config.Configuration, profile string) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "jobs",
+		Short: "Run sequences of commands defined in the configuration",
+		Long: "Jobs are named sequences of sutro commands, defined in the jobs section of ~/.sutro, " +
+			"which run one after the other within a budget of requests shared by all of them, and " +
+			"report how each fared once they are done.",
+	}
+
+	command.AddCommand(runCommand(ctx, configuration, profile), listCommand(configuration))
+	return command
+}
+
+type runFlags struct {
+	continueOnError bool
+}
+
+// result is the outcome of a step of a job.
+type result struct {
+	line     string
+	status   string
+	requests int
+	duration time.Duration
+}
+
+func runCommand(ctx context.Context, configuration config.Configuration, profile string) *cobra.Command {
+	flags := runFlags{}
+
+	command := &cobra.Command{
+		Use:     "run <job>",
+		Short:   "Run the steps of a job and summarize them",
+		Example: "  sutro jobs run nightly",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(ctx, configuration, profile, args[0], flags)
+		},
+	}
+
+	command.Flags().BoolVar(&flags.continueOnError, "continue-on-error", false, "Run the steps after a failed one, as continue_on_error does for a job")
+
+	return command
+}
+
+func run(ctx context.Context, configuration config.Configuration, profile, name string, flags runFlags) error {
+	if os.Getenv(jobs.Environment) != "" {
+		return errors.New("A job cannot run as a step of another job")
+	}
+	job, ok := configuration.Jobs()[name]
+	if !ok {
+		return fmt.Errorf("No job named %s, list them with sutro jobs list", name)
+	}
+	if len(job.Steps) == 0 {
+		return fmt.Errorf("The job %s has no steps", name)
+	}
+	steps := make([][]string, len(job.Steps))
+	for i, line := range job.Steps {
+		words, err := alias.Split(line)
+		if err != nil {
+			return fmt.Errorf("Invalid step %q of job %s: %v", line, name, err)
+		}
+		if len(words) == 0 {
+			return fmt.Errorf("The step %d of job %s is empty", i+1, name)
+		}
+		steps[i] = words
+	}
+
+	var allowance jobs.Allowance
+	requests, err := budget.ParseRequests(job.Budget)
+	if err != nil {
+		return fmt.Errorf("Invalid budget of job %s: %v", name, err)
+	}
+	allowance.Requests = requests
+	if job.MaxDuration != "" {
+		maxDuration, err := time.ParseDuration(job.MaxDuration)
+		if err != nil || maxDuration <= 0 {
+			return fmt.Errorf("Invalid max_duration %q of job %s, expected a duration such as 1h", job.MaxDuration, name)
+		}
+		allowance.Deadline = time.Now().Add(maxDuration)
+	}
+
+	file, err := ioutil.TempFile("", "sutro-job-*.json")
+	if err != nil {
+		return err
+	}
+	path := file.Name()
+	file.Close()
+	defer os.Remove(path)
+	if err := jobs.Write(path, allowance); err != nil {
+		return err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	continues := job.ContinueOnError || flags.continueOnError
+	results := make([]result, 0, len(steps))
+	failed, skipped, stopped := 0, 0, false
+	for i, words := range steps {
+		line := strings.Join(words, " ")
+		if stopped || allowance.Exhausted() {
+			status := "skipped"
+			if allowance.Exhausted() {
+				status = "skipped, no budget left"
+			}
+			results = append(results, result{line: line, status: status})
+			skipped++
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "[%d/%d] sutro %s\n", i+1, len(steps), line)
+		command := exec.CommandContext(ctx, executable, words...)
+		command.Env = append(os.Environ(), profiles.Environment+"="+profile, jobs.Environment+"="+path)
+		command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+		sent := allowance.Sent
+		start := time.Now()
+		err := command.Run()
+		outcome := result{line: line, status: "ok", duration: time.Since(start)}
+		// The allowance is left as it was by the steps failing before they
+		// record what they spent.
+		if after, readErr := jobs.Read(path); readErr == nil {
+			allowance = after
+		}
+		if err != nil {
+			outcome.status = "failed"
+			failed++
+			stopped = !continues
+		}
+		outcome.requests = allowance.Sent - sent
+		results = append(results, outcome)
+	}
+
+	fmt.Println()
+	if err := writeResults(results); err != nil {
+		return err
+	}
+	summary := fmt.Sprintf("%d requests", allowance.Sent)
+	if allowance.Requests > 0 {
+		summary = fmt.Sprintf("%d of the budget of %d requests", allowance.Sent, allowance.Requests)
+	}
+	fmt.Printf("\n%d of %d steps succeeded, %s\n", len(steps)-failed-skipped, len(steps), summary)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d steps of job %s failed", failed, len(steps), name)
+	}
+	return nil
+}
+
+func writeResults(results []result) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STEP\tSTATUS\tREQUESTS\tTIME")
+	for _, r := range results {
+		requests, duration := "-", "-"
+		if r.duration > 0 {
+			requests, duration = fmt.Sprint(r.requests), format.Duration(r.duration.Seconds())
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", r.line, r.status, requests, duration)
+	}
+	return writer.Flush()
+}
+
+func listCommand(configuration config.Configuration) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the jobs of the configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			defined := configuration.Jobs()
+			if len(defined) == 0 {
+				fmt.Println("No job, define one in the jobs section of ~/.sutro")
+				return nil
+			}
+
+			names := make([]string, 0, len(defined))
+			for name := range defined {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "JOB\tBUDGET\tMAX DURATION\tSTEPS")
+			for _, name := range names {
+				job := defined[name]
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", name, orDash(job.Budget), orDash(job.MaxDuration), strings.Join(job.Steps, "; "))
+			}
+			return writer.Flush()
+		},
+	}
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,185 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/mcp"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// maxActivities caps the activities list_activities returns at once, which
+// keeps responses within the context of assistants.
+const maxActivities = 200
+
+func Command(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve the Model Context Protocol over stdio for AI assistants",
+		Long: "Serve the Model Context Protocol on stdin and stdout, so that assistants can " +
+			"query Strava through the authentication and rate limiting of sutro. Configure " +
+			"the assistant to run sutro mcp; it gets the list_activities, get_activity and " +
+			"get_stats tools.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server := &mcp.Server{
+				Name:    "sutro",
+				Version: "1.0.0",
+				Tools:   tools(apiClient),
+			}
+			return server.Serve(ctx, os.Stdin, os.Stdout)
+		},
+	}
+}
+
+func tools(apiClient *strava.Client) []mcp.Tool {
+	return []mcp.Tool{
+		{
+			Name: "list_activities",
+			Description: "List the activities of the athlete, most recent first unless after is given, in which case " +
+				"Strava lists them oldest first. Activities come with their distance in meters, " +
+				"times in seconds, elevation gain in meters and speeds in meters per second.",
+			InputSchema: schema(map[string]interface{}{
+				"after":  property("string", "Only list activities started after this date, such as 2020-06-01 or 4w ago"),
+				"before": property("string", "Only list activities started before this date"),
+				"type":   property("string", "Only list activities of this type, such as Ride or Run"),
+				"limit":  property("integer", fmt.Sprintf("The number of activities to list, 30 by default and at most %d", maxActivities)),
+			}),
+			Call: func(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+				return listActivities(ctx, apiClient, arguments)
+			},
+		},
+		{
+			Name:        "get_activity",
+			Description: "Get the details of an activity, including its description, splits, laps and segment efforts.",
+			InputSchema: schema(map[string]interface{}{
+				"id": property("integer", "The id of the activity"),
+			}, "id"),
+			Call: func(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+				var args struct {
+					ID int64 `json:"id"`
+				}
+				if err := json.Unmarshal(arguments, &args); err != nil {
+					return nil, err
+				}
+				return apiClient.Activities.Get(ctx, args.ID)
+			},
+		},
+		{
+			Name: "get_stats",
+			Description: "Get the totals of the athlete for rides, runs and swims: over the last four weeks, " +
+				"the current year and all time, with distances in meters and times in seconds.",
+			InputSchema: schema(map[string]interface{}{}),
+			Call: func(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+				athlete, err := apiClient.Athletes.Current(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return apiClient.Athletes.Stats(ctx, athlete.ID)
+			},
+		},
+	}
+}
+
+func schema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	s := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func property(kind, description string) map[string]interface{} {
+	return map[string]interface{}{"type": kind, "description": description}
+}
+
+// activity is the part of a summary activity assistants need, leaving out
+// maps and other bulky fields.
+type activity struct {
+	ID                 int64               `json:"id"`
+	Name               string              `json:"name"`
+	Type               models.ActivityType `json:"type"`
+	StartDateLocal     strfmt.DateTime     `json:"start_date_local"`
+	Distance           float32             `json:"distance"`
+	MovingTime         int64               `json:"moving_time"`
+	ElapsedTime        int64               `json:"elapsed_time"`
+	TotalElevationGain float32             `json:"total_elevation_gain"`
+	AverageSpeed       float32             `json:"average_speed"`
+	AverageHeartrate   float32             `json:"average_heartrate,omitempty"`
+	AverageWatts       float32             `json:"average_watts,omitempty"`
+	KudosCount         int64               `json:"kudos_count"`
+	Commute            bool                `json:"commute,omitempty"`
+	Trainer            bool                `json:"trainer,omitempty"`
+}
+
+func listActivities(ctx context.Context, apiClient *strava.Client, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		After  string `json:"after"`
+		Before string `json:"before"`
+		Type   string `json:"type"`
+		Limit  int    `json:"limit"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Limit <= 0 {
+		args.Limit = 30
+	}
+	if args.Limit > maxActivities {
+		args.Limit = maxActivities
+	}
+
+	options := strava.ListOptions{}
+	for _, bound := range []struct {
+		value  string
+		target *time.Time
+	}{
+		{args.After, &options.After},
+		{args.Before, &options.Before},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		parsed, err := dates.Parse(bound.value)
+		if err != nil {
+			return nil, err
+		}
+		*bound.target = parsed
+	}
+	listed := []activity{}
+	iterator := apiClient.Activities.List(ctx, options)
+	for len(listed) < args.Limit && iterator.Next() {
+		a := iterator.Value()
+		if args.Type != "" && !strings.EqualFold(string(a.Type), args.Type) {
+			continue
+		}
+		listed = append(listed, activity{
+			ID:                 a.ID,
+			Name:               a.Name,
+			Type:               a.Type,
+			StartDateLocal:     a.StartDateLocal,
+			Distance:           a.Distance,
+			MovingTime:         a.MovingTime,
+			ElapsedTime:        a.ElapsedTime,
+			TotalElevationGain: a.TotalElevationGain,
+			AverageSpeed:       a.AverageSpeed,
+			AverageHeartrate:   a.AverageHeartrate,
+			AverageWatts:       a.AverageWatts,
+			KudosCount:         a.KudosCount,
+			Commute:            a.Commute,
+			Trainer:            a.Trainer,
+		})
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+	return listed, nil
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/httpserver"
+	"github.com/jsilland/sutro/metrics"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+type serveFlags struct {
+	listen string
+}
+
+func Command(ctx context.Context, archive *store.Store) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "metrics",
+		Short: "Metrics of synced activities for monitoring systems",
+	}
+
+	command.AddCommand(serveCommand(ctx, archive))
+	return command
+}
+
+func serveCommand(ctx context.Context, archive *store.Store) *cobra.Command {
+	flags := serveFlags{}
+
+	command := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve metrics of synced activities to Prometheus",
+		Long: "Serve totals per activity type and the distance of the last 7 and 28 days on " +
+			"/metrics, in the Prometheus exposition format. The archive is read on every " +
+			"scrape, so metrics follow sutro sync.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(ctx, archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.listen, "listen", ":9464", "The address to listen on")
+
+	return command
+}
+
+func serve(ctx context.Context, archive *store.Store, flags serveFlags) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		activities, err := archive.Activities(store.Query{})
+		if err != nil {
+			log.Printf("Unable to read the archive: %v", err)
+			http.Error(w, "Unable to read the archive", http.StatusInternalServerError)
+			return
+		}
+
+		var buffer bytes.Buffer
+		if err := metrics.WritePrometheus(&buffer, activities, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = buffer.WriteTo(w)
+	})
+
+	host := flags.listen
+	if strings.HasPrefix(host, ":") {
+		host = "localhost" + host
+	}
+	fmt.Printf("Serving metrics at http://%s/metrics\n", host)
+	return httpserver.ListenAndServe(ctx, flags.listen, mux)
+}
@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/notify"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+type webhookFlags struct {
+	webhookURL string
+	mapsKey    string
+	remove     bool
+}
+
+// Command returns the notify command, which configures the webhooks sutro
+// watch posts its events to.
+func Command(archive *store.Store, configuration config.Configuration) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "notify",
+		Short: "Configure the chat webhooks new activities are posted to",
+	}
+
+	command.AddCommand(
+		webhookCommand("slack", "Slack", archive, configuration, func(n *config.Notifications) *string { return &n.Slack }),
+		webhookCommand("discord", "Discord", archive, configuration, func(n *config.Notifications) *string { return &n.Discord }),
+	)
+	return command
+}
+
+func webhookCommand(name, service string, archive *store.Store, configuration config.Configuration, field func(*config.Notifications) *string) *cobra.Command {
+	flags := webhookFlags{}
+
+	command := &cobra.Command{
+		Use:   name,
+		Short: fmt.Sprintf("Post new activities to a %s channel", service),
+		Long: fmt.Sprintf("Save the %s webhook that sutro watch posts new activities to, and post "+
+			"the latest synced activity to it as a test. With --maps-key, posts include a map "+
+			"thumbnail rendered by the Google Static Maps API.", service),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notifications := configuration.Notifications()
+			webhook := field(&notifications)
+
+			if flags.remove {
+				*webhook = ""
+				configuration.SetNotifications(notifications)
+				fmt.Printf("Removed the %s webhook\n", service)
+				return nil
+			}
+
+			if flags.webhookURL == "" {
+				return errors.New("A --webhook-url is required, or --remove")
+			}
+			if parsed, err := url.Parse(flags.webhookURL); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+				return fmt.Errorf("Invalid webhook URL %q, expected an https address", flags.webhookURL)
+			}
+			*webhook = flags.webhookURL
+			if cmd.Flags().Changed("maps-key") {
+				notifications.MapsKey = flags.mapsKey
+			}
+			configuration.SetNotifications(notifications)
+
+			return test(archive, notify.Webhooks(notifications)[name], service)
+		},
+	}
+
+	command.Flags().StringVar(&flags.webhookURL, "webhook-url", "", fmt.Sprintf("The address of the %s incoming webhook", service))
+	command.Flags().StringVar(&flags.mapsKey, "maps-key", "", "The Google Static Maps API key used to attach map thumbnails")
+	command.Flags().BoolVar(&flags.remove, "remove", false, "Stop posting to the webhook")
+
+	return command
+}
+
+// test posts the latest synced activity, so that a wrong webhook is noticed
+// right away rather than on the next activity.
+func test(archive *store.Store, sink notify.Sink, service string) error {
+	synced, err := archive.Activities(store.Query{})
+	if err != nil {
+		return err
+	}
+	if len(synced) == 0 {
+		fmt.Printf("Saved the %s webhook, run sutro sync to be able to test it\n", service)
+		return nil
+	}
+
+	latest := synced[len(synced)-1]
+	if err := sink.Send(notify.Event{Kind: notify.NewActivity, Activity: latest}); err != nil {
+		return fmt.Errorf("Posting a test message to the %s webhook failed, so it was not saved: %v", service, err)
+	}
+	fmt.Printf("Saved the %s webhook and posted %s to it\n", service, latest.Name)
+	return nil
+}
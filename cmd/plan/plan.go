@@ -0,0 +1,182 @@
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/plan"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+// Command returns the plan command, which keeps a training plan in the
+// local archive and reports how closely synced activities followed it.
+func Command(archive *store.Store) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "plan",
+		Short: "Import a training plan and track its compliance",
+	}
+
+	command.AddCommand(importCommand(archive), statusCommand(archive))
+	return command
+}
+
+func importCommand(archive *store.Store) *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <plan.csv|plan.ics>",
+		Short: "Import the sessions of a training plan",
+		Long: "Import the sessions of a training plan from a CSV file with date, type, name, duration " +
+			"and distance columns, or from an iCalendar feed such as the ones coaching platforms " +
+			"publish. The sessions planned over the days of the plan are replaced, so that a plan " +
+			"can be imported again once it changes.",
+		Example: "  sutro plan import plan.csv\n" +
+			"  sutro plan import marathon.ics",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sessions, err := plan.Read(args[0])
+			if err != nil {
+				return err
+			}
+			first, last := sessions[0].Date, sessions[len(sessions)-1].Date
+			if err := archive.ReplaceSessions(first, last, sessions); err != nil {
+				return err
+			}
+			fmt.Printf("Imported %d sessions from %s to %s\n", len(sessions), first.Format("2006-01-02"), last.Format("2006-01-02"))
+			return nil
+		},
+	}
+}
+
+type statusFlags struct {
+	since    string
+	sessions bool
+}
+
+func statusCommand(archive *store.Store) *cobra.Command {
+	flags := statusFlags{}
+
+	command := &cobra.Command{
+		Use:   "status",
+		Short: "Report the compliance with the plan, week by week",
+		Long: "Match the synced activities with the sessions planned up to today, by day, type and " +
+			"duration, and report for each week how many sessions were completed and how much of " +
+			"their planned duration, or distance, was done.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return status(archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.since, "since", "", "Report on the sessions planned from this date (defaults to the start of the plan)")
+	command.Flags().BoolVar(&flags.sessions, "sessions", false, "List each session with the activity that completed it")
+
+	return command
+}
+
+func status(archive *store.Store, flags statusFlags) error {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	var since time.Time
+	if flags.since != "" {
+		parsed, err := dates.Parse(flags.since)
+		if err != nil {
+			return err
+		}
+		since = parsed
+	}
+
+	sessions, err := archive.Sessions(since, today)
+	if err != nil {
+		return err
+	}
+	upcoming, err := archive.Sessions(today.AddDate(0, 0, 1), time.Time{})
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 && len(upcoming) == 0 {
+		return errors.New("No session planned, import a plan with sutro plan import first")
+	}
+
+	if len(sessions) > 0 {
+		// Activities are stored by their start in UTC, a day away at most
+		// from the day they took place on where they took place.
+		activities, err := archive.Activities(store.Query{
+			After:  sessions[0].Date.AddDate(0, 0, -1),
+			Before: today.AddDate(0, 0, 2),
+		})
+		if err != nil {
+			return err
+		}
+		matches := plan.MatchSessions(sessions, activities)
+		if flags.sessions {
+			if err := writeSessions(matches); err != nil {
+				return err
+			}
+			fmt.Println()
+		}
+		if err := writeWeeks(plan.Weeks(matches)); err != nil {
+			return err
+		}
+	}
+	if len(upcoming) > 0 {
+		fmt.Printf("\nSessions planned after today: %d, until %s\n", len(upcoming), upcoming[len(upcoming)-1].Date.Format("2006-01-02"))
+	}
+	return nil
+}
+
+func writeWeeks(weeks []plan.Week) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "WEEK\tCOMPLETED\tPLANNED TIME\tDONE\tCOMPLIANCE")
+	var total plan.Week
+	for _, week := range weeks {
+		fmt.Fprintf(writer, "%s\t%d of %d\t%s\t%s\t%.0f%%\n",
+			week.Start.Format("2006-01-02"), week.Completed, week.Planned, format.Duration(week.Duration.Seconds()), format.Duration(week.Done.Seconds()), week.Compliance*100)
+		total.Planned += week.Planned
+		total.Completed += week.Completed
+		total.Duration += week.Duration
+		total.Done += week.Done
+		total.Compliance += week.Compliance * float64(week.Planned)
+	}
+	if len(weeks) > 1 {
+		fmt.Fprintf(writer, "Total\t%d of %d\t%s\t%s\t%.0f%%\n",
+			total.Completed, total.Planned, format.Duration(total.Duration.Seconds()), format.Duration(total.Done.Seconds()), total.Compliance/float64(total.Planned)*100)
+	}
+	return writer.Flush()
+}
+
+func writeSessions(matches []plan.Match) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "DATE\tTYPE\tSESSION\tPLANNED\tACTIVITY\tDONE\tCOMPLIANCE")
+	for _, match := range matches {
+		session := match.Session
+		activityType, name, planned := session.Type, session.Name, "-"
+		if activityType == "" {
+			activityType = "Any"
+		}
+		if name == "" {
+			name = "-"
+		}
+		switch {
+		case session.Duration > 0:
+			planned = format.Duration(session.Duration.Seconds())
+		case session.Distance > 0:
+			planned = format.Kilometers(session.Distance)
+		}
+		activity, done := "missed", "-"
+		if match.Activity != nil {
+			activity = fmt.Sprintf("%d %s", match.Activity.ID, match.Activity.Name)
+			done = format.Duration(float64(match.Activity.MovingTime))
+			if session.Duration == 0 && session.Distance > 0 {
+				done = format.Kilometers(float64(match.Activity.Distance))
+			}
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%.0f%%\n",
+			session.Date.Format("2006-01-02"), activityType, name, planned, activity, done, match.Compliance*100)
+	}
+	return writer.Flush()
+}
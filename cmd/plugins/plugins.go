@@ -0,0 +1,158 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/config"
+	"github.com/spf13/cobra"
+)
+
+// prefix starts the name of the executables exposed as subcommands, the way
+// git exposes git-foo as git foo.
+const prefix = "sutro-"
+
+// Environment is what sutro passes on to the plugins it runs.
+type Environment struct {
+	ConfigPath     string
+	StateDirectory string
+	Profile        string
+	// Configuration is nil until sutro authenticate has run, in which case
+	// plugins get no access token.
+	Configuration config.Configuration
+}
+
+// Commands returns a command for each sutro-<name> executable on the PATH,
+// except those whose name root already has a command for.
+func Commands(ctx context.Context, root *cobra.Command, environment Environment) []*cobra.Command {
+	taken := map[string]bool{"help": true}
+	for _, child := range root.Commands() {
+		taken[child.Name()] = true
+	}
+
+	var commands []*cobra.Command
+	for _, plugin := range discover() {
+		if taken[plugin.name] {
+			continue
+		}
+		taken[plugin.name] = true
+		commands = append(commands, pluginCommand(ctx, plugin, environment))
+	}
+	return commands
+}
+
+type plugin struct {
+	name string
+	path string
+}
+
+// discover lists the plugins on the PATH. The first directory of the PATH
+// providing a plugin wins, as it would in a shell.
+func discover() []plugin {
+	found := map[string]plugin{}
+	for _, directory := range filepath.SplitList(os.Getenv("PATH")) {
+		if directory == "" {
+			directory = "."
+		}
+
+		entries, err := ioutil.ReadDir(directory)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, prefix) || entry.IsDir() || !executable(entry) {
+				continue
+			}
+
+			name = strings.TrimPrefix(name, prefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if _, ok := found[name]; name == "" || ok {
+				continue
+			}
+			found[name] = plugin{name: name, path: filepath.Join(directory, entry.Name())}
+		}
+	}
+
+	plugins := make([]plugin, 0, len(found))
+	for _, p := range found {
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].name < plugins[j].name
+	})
+	return plugins
+}
+
+func executable(entry os.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		extension := strings.ToLower(filepath.Ext(entry.Name()))
+		return extension == ".exe" || extension == ".bat" || extension == ".cmd"
+	}
+	return entry.Mode()&0111 != 0
+}
+
+func pluginCommand(ctx context.Context, p plugin, environment Environment) *cobra.Command {
+	return &cobra.Command{
+		Use:   fmt.Sprintf("%s [args...]", p.name),
+		Short: fmt.Sprintf("Run the %s%s plugin", prefix, p.name),
+		Long:  fmt.Sprintf("Run %s, passing it the remaining arguments.", p.path),
+		// Plugins parse their own flags, including --help.
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(ctx, p, environment, args)
+		},
+	}
+}
+
+func run(ctx context.Context, p plugin, environment Environment, args []string) error {
+	variables, err := variables(ctx, environment)
+	if err != nil {
+		return err
+	}
+
+	command := exec.CommandContext(ctx, p.path, args...)
+	command.Env = append(os.Environ(), variables...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("Plugin %s%s failed: %v", prefix, p.name, err)
+	}
+	return nil
+}
+
+// variables returns the environment variables describing sutro to a plugin.
+// The access token is refreshed beforehand so that it stays valid for as
+// long as Strava allows, which is a few hours.
+func variables(ctx context.Context, environment Environment) ([]string, error) {
+	variables := []string{
+		"SUTRO_CONFIG=" + environment.ConfigPath,
+		"SUTRO_STATE_DIRECTORY=" + environment.StateDirectory,
+		"SUTRO_PROFILE=" + environment.Profile,
+	}
+	if environment.Configuration == nil {
+		return variables, nil
+	}
+
+	token, err := environment.Configuration.TokenSource(ctx).Token()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to obtain an access token for the plugin: %v", err)
+	}
+	variables = append(variables, "SUTRO_ACCESS_TOKEN="+token.AccessToken)
+	if !token.Expiry.IsZero() {
+		variables = append(variables, "SUTRO_TOKEN_EXPIRY="+token.Expiry.UTC().Format(time.RFC3339))
+	}
+	return variables, nil
+}
@@ -0,0 +1,299 @@
+package polyline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/geo"
+	"github.com/spf13/cobra"
+)
+
+// Command returns the polyline command, which converts, simplifies and
+// measures the encoded polylines of the API, such as the summary_polyline
+// of the map of an activity.
+func Command() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "polyline",
+		Short: "Decode, encode, simplify and measure encoded polylines",
+		Long: "Work with the Google encoded polylines the API describes tracks with, such as the " +
+			"summary_polyline of the map of activities, segments and routes. Commands read the " +
+			"polyline given as argument, or stdin without one.",
+	}
+
+	command.AddCommand(decodeCommand(), encodeCommand(), simplifyCommand(), statsCommand())
+	return command
+}
+
+func decodeCommand() *cobra.Command {
+	var outputFormat string
+	command := &cobra.Command{
+		Use:   "decode [polyline]",
+		Short: "Decode a polyline to GeoJSON or CSV",
+		Example: "  sutro polyline decode '_p~iF~ps|U_ulLnnqC_mqNvxq`@'\n" +
+			"  sutro activities get 1234 | jq -r .map.summary_polyline | sutro polyline decode --format csv",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			track, err := readPolyline(args)
+			if err != nil {
+				return err
+			}
+			if outputFormat == "csv" {
+				return writeCSV(os.Stdout, track)
+			}
+			return writeGeoJSON(os.Stdout, track)
+		},
+	}
+	choice.Var(command, &outputFormat, "format", "geojson", "The format to decode to: geojson or csv", "geojson", "csv")
+	return command
+}
+
+func encodeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "encode [file]",
+		Short: "Encode a GeoJSON LineString or a CSV of points as a polyline",
+		Long: "Encode the points of a GeoJSON LineString, or of the first one of a Feature or " +
+			"FeatureCollection, or of a CSV file of latitudes and longitudes such as the one " +
+			"decode writes, read from file or from stdin.",
+		Example: "  sutro polyline encode track.geojson\n" +
+			"  printf '38.5,-120.2\\n40.7,-120.95\\n' | sutro polyline encode",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var input io.Reader = os.Stdin
+			if len(args) == 1 && args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				input = file
+			}
+			track, err := readPoints(input)
+			if err != nil {
+				return err
+			}
+			fmt.Println(geo.EncodePolyline(track))
+			return nil
+		},
+	}
+}
+
+func simplifyCommand() *cobra.Command {
+	var tolerance string
+	command := &cobra.Command{
+		Use:   "simplify [polyline]",
+		Short: "Simplify a polyline with the Douglas-Peucker algorithm",
+		Long: "Simplify a polyline, leaving out the points that lie within --tolerance of the " +
+			"simplified track, and print it encoded. The number of points left is reported on " +
+			"stderr.",
+		Example: "  sutro polyline simplify --tolerance 25m '_p~iF~ps|U_ulLnnqC_mqNvxq`@'",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			meters, err := geo.ParseDistance(tolerance)
+			if err != nil {
+				return err
+			}
+			track, err := readPolyline(args)
+			if err != nil {
+				return err
+			}
+			simplified := geo.Simplify(track, meters)
+			fmt.Println(geo.EncodePolyline(simplified))
+			fmt.Fprintf(os.Stderr, "Kept %d of %d points\n", len(simplified), len(track))
+			return nil
+		},
+	}
+	command.Flags().StringVar(&tolerance, "tolerance", "10m", "How far from the simplified track the points left out may be")
+	return command
+}
+
+func statsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats [polyline]",
+		Short: "Print the number of points, the length and the bounds of a polyline",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			track, err := readPolyline(args)
+			if err != nil {
+				return err
+			}
+			bounds := geo.BoundsOf(track)
+			writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintf(writer, "Points\t%d\n", len(track))
+			fmt.Fprintf(writer, "Length\t%s\n", format.Kilometers(geo.Length(track)))
+			fmt.Fprintf(writer, "South-west\t%.5f, %.5f\n", bounds.South, bounds.West)
+			fmt.Fprintf(writer, "North-east\t%.5f, %.5f\n", bounds.North, bounds.East)
+			return writer.Flush()
+		},
+	}
+}
+
+// readPolyline decodes the polyline of args, or else the one read from
+// stdin. Polylines are not read from @path, as @ is one of their
+// characters.
+func readPolyline(args []string) ([]geo.Point, error) {
+	encoded := ""
+	if len(args) == 1 && args[0] != "-" {
+		encoded = args[0]
+	} else {
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the polyline from stdin: %v", err)
+		}
+		encoded = string(data)
+	}
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, errors.New("The polyline is empty")
+	}
+
+	track, err := geo.DecodePolyline(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return track, nil
+}
+
+// geometry is a GeoJSON object holding a LineString: a geometry itself, a
+// Feature or a FeatureCollection.
+type geometry struct {
+	Type        string      `json:"type"`
+	Coordinates [][]float64 `json:"coordinates,omitempty"`
+	Geometry    *geometry   `json:"geometry,omitempty"`
+	Features    []*geometry `json:"features,omitempty"`
+}
+
+// lineString returns the coordinates of the first LineString of g.
+func (g *geometry) lineString() ([][]float64, bool) {
+	switch g.Type {
+	case "LineString":
+		return g.Coordinates, true
+	case "Feature":
+		if g.Geometry != nil {
+			return g.Geometry.lineString()
+		}
+	case "FeatureCollection":
+		for _, feature := range g.Features {
+			if coordinates, ok := feature.lineString(); ok {
+				return coordinates, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// readPoints reads the points of a GeoJSON object or of a CSV file.
+func readPoints(input io.Reader) ([]geo.Point, error) {
+	data, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+
+	var track []geo.Point
+	if bytes.HasPrefix(data, []byte("{")) {
+		var g geometry
+		if err := json.Unmarshal(data, &g); err != nil {
+			return nil, fmt.Errorf("Invalid GeoJSON: %v", err)
+		}
+		coordinates, ok := g.lineString()
+		if !ok {
+			return nil, errors.New("The GeoJSON has no LineString to encode")
+		}
+		for i, c := range coordinates {
+			if len(c) < 2 {
+				return nil, fmt.Errorf("Invalid GeoJSON: position %d has no longitude and latitude", i)
+			}
+			// Positions are longitude first.
+			track = append(track, geo.Point{Lat: c[1], Lng: c[0]})
+		}
+		return track, nil
+	}
+
+	rows := csv.NewReader(bytes.NewReader(data))
+	rows.FieldsPerRecord = -1
+	for line := 1; ; line++ {
+		record, err := rows.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CSV: %v", err)
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("Invalid CSV: line %d has no latitude and longitude", line)
+		}
+		lat, latErr := strconv.ParseFloat(strings.TrimSpace(record[0]), 64)
+		lng, lngErr := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if latErr != nil || lngErr != nil {
+			// The first line may be a header such as lat,lng.
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("Invalid CSV: line %d is not a latitude and a longitude", line)
+		}
+		track = append(track, geo.Point{Lat: lat, Lng: lng})
+	}
+	if len(track) == 0 {
+		return nil, errors.New("No point to encode")
+	}
+	return track, nil
+}
+
+func writeCSV(output io.Writer, track []geo.Point) error {
+	buffered := bufio.NewWriter(output)
+	rows := csv.NewWriter(buffered)
+	if err := rows.Write([]string{"lat", "lng"}); err != nil {
+		return err
+	}
+	for _, p := range track {
+		if err := rows.Write([]string{strconv.FormatFloat(p.Lat, 'f', 5, 64), strconv.FormatFloat(p.Lng, 'f', 5, 64)}); err != nil {
+			return err
+		}
+	}
+	rows.Flush()
+	if err := rows.Error(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// writeGeoJSON writes track as a Feature with its length in meters and its
+// bounding box.
+func writeGeoJSON(output io.Writer, track []geo.Point) error {
+	bounds := geo.BoundsOf(track)
+	coordinates := make([][]float64, len(track))
+	for i, p := range track {
+		coordinates[i] = []float64{p.Lng, p.Lat}
+	}
+	feature := struct {
+		Type       string    `json:"type"`
+		BBox       []float64 `json:"bbox"`
+		Geometry   geometry  `json:"geometry"`
+		Properties struct {
+			Points int     `json:"points"`
+			Length float64 `json:"length"`
+		} `json:"properties"`
+	}{
+		Type:     "Feature",
+		BBox:     []float64{bounds.West, bounds.South, bounds.East, bounds.North},
+		Geometry: geometry{Type: "LineString", Coordinates: coordinates},
+	}
+	feature.Properties.Points = len(track)
+	feature.Properties.Length = geo.Length(track)
+
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(feature)
+}
@@ -0,0 +1,189 @@
+package predict
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/predict"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// shortest is the shortest best effort predictions are made from, in
+// meters: shorter efforts are run too far above VO2max for the models.
+const shortest = 1500
+
+type predictFlags struct {
+	distances []string
+	since     string
+	from      string
+}
+
+// performance is the run predictions are made from.
+type performance struct {
+	description string
+	meters      float64
+	seconds     float64
+}
+
+// Command returns the predict command, which estimates race times and
+// training paces from the best efforts of recent runs.
+func Command(ctx context.Context, apiClient *strava.Client, archive *store.Store) *cobra.Command {
+	flags := predictFlags{}
+
+	command := &cobra.Command{
+		Use:   "predict",
+		Short: "Predict race times and training paces from recent best efforts",
+		Long: "Estimate race times with the models of Riegel and of Daniels from the best of the " +
+			"best efforts Strava found in the synced runs since --since, such as the fastest 5k " +
+			"within a longer run, along with the training paces Daniels recommends. Each run is " +
+			"fetched once to read its best efforts. --from predicts from a given performance " +
+			"instead, without the API.",
+		Example: "  sutro predict --distance marathon\n" +
+			"  sutro predict --from 5k=20:00 --distance 10k,half",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return predictRaces(ctx, apiClient, archive, flags)
+		},
+	}
+
+	command.Flags().StringSliceVar(&flags.distances, "distance", predict.DefaultDistances, fmt.Sprintf("The distances to predict: %s, or lengths such as 30km", strings.Join(predict.DistanceNames(), ", ")))
+	command.Flags().StringVar(&flags.since, "since", "12w", "Predict from the best efforts of the runs started after this date")
+	command.Flags().StringVar(&flags.from, "from", "", "Predict from this performance, such as 10k=45:00, instead of recent best efforts")
+
+	return command
+}
+
+func predictRaces(ctx context.Context, apiClient *strava.Client, archive *store.Store, flags predictFlags) error {
+	targets := make([]predict.Distance, 0, len(flags.distances))
+	for _, value := range flags.distances {
+		distance, err := predict.ParseDistance(value)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, distance)
+	}
+
+	var best *performance
+	var err error
+	if flags.from != "" {
+		best, err = parsePerformance(flags.from)
+	} else {
+		best, err = bestEffort(ctx, apiClient, archive, flags.since)
+	}
+	if err != nil {
+		return err
+	}
+
+	vdot := predict.VDOT(best.meters, best.seconds)
+	fmt.Printf("Based on %s in %s, a VDOT of %.1f\n\n", best.description, format.Duration(best.seconds), vdot)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "DISTANCE\tRIEGEL\tDANIELS\tPACE")
+	for _, target := range targets {
+		daniels := predict.TimeFor(vdot, target.Meters)
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n",
+			target.Name,
+			format.Duration(predict.Riegel(best.meters, best.seconds, target.Meters)),
+			format.Duration(daniels),
+			format.Pace(daniels/60/(target.Meters/1000)),
+		)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	writer = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TRAINING\tPACE")
+	marathon := predict.Distances["marathon"].Meters
+	for i, zone := range predict.Zones {
+		fmt.Fprintf(writer, "%s\t%s – %s\n", zone.Name, format.Pace(predict.Pace(vdot, zone.From)), format.Pace(predict.Pace(vdot, zone.To)))
+		if i == 0 {
+			fmt.Fprintf(writer, "Marathon\t%s\n", format.Pace(predict.TimeFor(vdot, marathon)/60/(marathon/1000)))
+		}
+	}
+	return writer.Flush()
+}
+
+// parsePerformance reads a performance such as 10k=45:00.
+func parsePerformance(value string) (*performance, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("Invalid performance %q, expected a distance and a time such as 10k=45:00", value)
+	}
+	distance, err := predict.ParseDistance(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	duration, err := dates.ParseDuration(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	return &performance{description: distance.Name, meters: distance.Meters, seconds: duration.Seconds()}, nil
+}
+
+// bestEffort returns the best effort of the runs started after since with
+// the highest VDOT, which is the best performance whatever its distance.
+func bestEffort(ctx context.Context, apiClient *strava.Client, archive *store.Store, since string) (*performance, error) {
+	after, err := dates.Parse(since)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := archive.Activities(store.Query{Type: "Run", After: after})
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("No run synced since %s, sync first or predict --from a performance", after.Format("2006-01-02"))
+	}
+
+	var best *performance
+	var source int64
+	bestVDOT := 0.0
+	bar := progress.New("Reading best efforts", int64(len(runs)), "runs")
+	defer bar.Done()
+	for _, run := range runs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		detailed, err := apiClient.Activities.Get(ctx, run.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to obtain the best efforts of activity %d: %v", run.ID, err)
+		}
+		bar.Add(1)
+
+		for _, effort := range detailed.BestEfforts {
+			meters, seconds := float64(effort.Distance), float64(effort.ElapsedTime)
+			if meters < shortest || seconds <= 0 {
+				continue
+			}
+			if vdot := predict.VDOT(meters, seconds); vdot > bestVDOT {
+				bestVDOT = vdot
+				best = &performance{
+					description: fmt.Sprintf("the %s of %s on %s", effort.Name, run.Name, time.Time(run.StartDateLocal).Format("2006-01-02")),
+					meters:      meters,
+					seconds:     seconds,
+				}
+				source = run.ID
+			}
+		}
+	}
+	bar.Done()
+
+	if best == nil {
+		return nil, errors.New("No best effort of 1500m or more in the synced runs, predict --from a performance instead")
+	}
+	history.Returned(ctx, source)
+	return best, nil
+}
@@ -0,0 +1,233 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/report"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+type runFlags struct {
+	since        string
+	until        string
+	activityType string
+}
+
+// Command returns the report command, which runs the reports defined as
+// YAML files over the archive.
+func Command(archive *store.Store) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "report",
+		Short: "Run the reports defined over the synced activities",
+		Long: "Reports are YAML files in ~/.config/sutro/reports, or in $XDG_CONFIG_HOME/sutro/reports, " +
+			"such as rides.yaml:\n\n" +
+			"  title: Rides by month\n" +
+			"  filters: {type: Ride, since: 1y, commute: false, min_distance: 10km}\n" +
+			"  group_by: month\n" +
+			"  metrics: [count, distance, moving_time, elevation, avg_speed]\n\n" +
+			"Filters select the activities by type, start date (since, until), distance " +
+			"(min_distance, max_distance), name, gear and commute or trainer flags. Activities are " +
+			"grouped by day, week, month, year, weekday, type or gear, or totaled together without " +
+			"group_by. The metrics are count, distance, moving_time, elapsed_time, elevation, " +
+			"longest, kudos, avg_speed, avg_pace and avg_heartrate. A report is printed as a table, " +
+			"unless it has a template, a Go text/template given the Title, Groups and Total of the " +
+			"report, each group having a Key and the Values and Formatted values of its metrics.",
+	}
+
+	flags := runFlags{}
+	run := &cobra.Command{
+		Use:   "run <name>",
+		Short: "Run a report over the synced activities",
+		Long: "Run the report defined in <name>.yaml in the directory of the reports, or in the " +
+			"YAML file <name>, over the synced activities.",
+		Example: "  sutro report run rides\n" +
+			"  sutro report run ./weekly.yaml --since 4w",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReport(archive, args[0], flags)
+		},
+	}
+	run.Flags().StringVar(&flags.since, "since", "", "Only include the activities started after this date, instead of the since of the report")
+	run.Flags().StringVar(&flags.until, "until", "", "Only include the activities started before this date, instead of the until of the report")
+	choice.ActivityTypeVar(run, &flags.activityType, "type", "Only include activities of this type, instead of the type of the report")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the reports defined",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listReports()
+		},
+	}
+
+	command.AddCommand(run, list, balanceCommand(archive))
+	return command
+}
+
+type balanceFlags struct {
+	since   string
+	until   string
+	maxRamp float64
+}
+
+func balanceCommand(archive *store.Store) *cobra.Command {
+	flags := balanceFlags{}
+
+	command := &cobra.Command{
+		Use:   "balance",
+		Short: "Break the weekly volume down by sport and intensity",
+		Long: "Report the moving time of each week by sport, swim, bike, run and others, and by " +
+			"intensity, low, moderate or high against the thresholds recorded with thresholds set, " +
+			"and flag the weeks whose volume grew by more than --max-ramp from the week before.",
+		Example: "  sutro report balance --since 16w --max-ramp 15",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return balance(archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.since, "since", "12w", "Report on the activities started after this date")
+	command.Flags().StringVar(&flags.until, "until", "", "Report on the activities started before this date")
+	command.Flags().Float64Var(&flags.maxRamp, "max-ramp", 10, "The weekly growth of volume, in percent, past which weeks are flagged")
+
+	return command
+}
+
+func balance(archive *store.Store, flags balanceFlags) error {
+	since, err := dates.Parse(flags.since)
+	if err != nil {
+		return err
+	}
+	var until time.Time
+	if flags.until != "" {
+		if until, err = dates.Parse(flags.until); err != nil {
+			return err
+		}
+	}
+
+	weeks, err := report.Balance(archive, since, until)
+	if err != nil {
+		return err
+	}
+	if len(weeks) == 0 {
+		fmt.Println("No activity in the period")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := append([]string{"WEEK"}, upper(report.Sports)...)
+	header = append(header, "TOTAL")
+	header = append(header, upper(report.Intensities)...)
+	fmt.Fprintln(writer, strings.Join(append(header, "RAMP"), "\t"))
+
+	flagged := false
+	for _, week := range weeks {
+		row := []string{week.Start.Format("2006-01-02")}
+		for _, sport := range report.Sports {
+			row = append(row, formatHours(week.Sports[sport]))
+		}
+		row = append(row, formatHours(week.Total))
+		for _, intensity := range report.Intensities {
+			share := "-"
+			if week.Total > 0 && week.Intensities[intensity] > 0 {
+				share = fmt.Sprintf("%.0f%%", week.Intensities[intensity].Seconds()/week.Total.Seconds()*100)
+			}
+			row = append(row, share)
+		}
+		ramp := "-"
+		if !math.IsNaN(week.Ramp) {
+			ramp = fmt.Sprintf("%+.0f%%", week.Ramp*100)
+			if week.Ramp*100 > flags.maxRamp {
+				ramp += " !"
+				flagged = true
+			}
+		}
+		fmt.Fprintln(writer, strings.Join(append(row, ramp), "\t"))
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if flagged {
+		fmt.Printf("\nThe weeks flagged with ! grew by more than %.0f%% from the week before\n", flags.maxRamp)
+	}
+	return nil
+}
+
+func upper(names []string) []string {
+	uppered := make([]string, len(names))
+	for i, name := range names {
+		uppered[i] = strings.ToUpper(name)
+	}
+	return uppered
+}
+
+// formatHours renders a weekly volume as h:mm, or - for none.
+func formatHours(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	minutes := int64(d.Round(time.Minute) / time.Minute)
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
+
+func runReport(archive *store.Store, name string, flags runFlags) error {
+	directory, err := report.Directory()
+	if err != nil {
+		return err
+	}
+	definition, err := report.Load(directory, name)
+	if err != nil {
+		return err
+	}
+	if flags.since != "" {
+		definition.Filters.Since = flags.since
+	}
+	if flags.until != "" {
+		definition.Filters.Until = flags.until
+	}
+	if flags.activityType != "" {
+		definition.Filters.Type = flags.activityType
+	}
+
+	result, err := definition.Run(archive)
+	if err != nil {
+		return err
+	}
+	return definition.Render(os.Stdout, result)
+}
+
+func listReports() error {
+	directory, err := report.Directory()
+	if err != nil {
+		return err
+	}
+	names, err := report.Names(directory)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Printf("No report defined in %s\n", directory)
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tTITLE")
+	for _, name := range names {
+		title := ""
+		if definition, err := report.Load(directory, name); err != nil {
+			title = err.Error()
+		} else {
+			title = definition.Title
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", name, title)
+	}
+	return writer.Flush()
+}
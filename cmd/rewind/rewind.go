@@ -0,0 +1,135 @@
+package rewind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/digest"
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/rewind"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type rewindFlags struct {
+	year         int
+	out          string
+	activityType string
+	offline      bool
+}
+
+func Command(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone) *cobra.Command {
+	flags := rewindFlags{}
+
+	command := &cobra.Command{
+		Use:   "rewind",
+		Short: "Compile a year of synced activities into a report to share",
+		Long: "Compile the synced activities of a year into a single HTML page, or Markdown " +
+			"document when --out ends with .md: the totals of the year and of each sport, the " +
+			"biggest days, the longest streak of active days, the personal records set and the " +
+			"segments gone through most, and heatmaps of the days of the year and, in HTML, of the " +
+			"tracks, trimmed to the privacy zones of the configuration.\n\n" +
+			"The records and the segments are read from the details of each activity, fetched from " +
+			"the API; --offline leaves them out to only read the archive.",
+		Example: "  sutro rewind --year 2024 --out rewind.html\n" +
+			"  sutro rewind --type Run --out runs.md --offline",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return compile(ctx, apiClient, archive, zones, flags)
+		},
+	}
+
+	command.Flags().IntVar(&flags.year, "year", time.Now().Year(), "The year to compile")
+	command.Flags().StringVar(&flags.out, "out", "rewind.html", "The file to write, either an .html page or a .md document")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+	command.Flags().BoolVar(&flags.offline, "offline", false, "Only read the archive, leaving out the records and the segments")
+
+	return command
+}
+
+func compile(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone, flags rewindFlags) error {
+	extension := strings.ToLower(path.Ext(flags.out))
+	if extension != ".html" && extension != ".md" {
+		return fmt.Errorf("Unsupported output %q, expected an .html or .md file", flags.out)
+	}
+	if !flags.offline && apiClient == nil {
+		return errors.New("Reading the records and the segments requires running sutro authenticate first, or pass --offline")
+	}
+
+	start := time.Date(flags.year, time.January, 1, 0, 0, 0, 0, time.Local)
+	year := digest.Period{Name: "year", Start: start, End: start.AddDate(1, 0, 0)}
+	activities, err := year.Activities(archive, flags.activityType)
+	if err != nil {
+		return err
+	}
+	if len(activities) == 0 {
+		return fmt.Errorf("No synced activity matches in %d, have you run sutro sync?", flags.year)
+	}
+
+	var details []*models.DetailedActivity
+	if !flags.offline {
+		details = make([]*models.DetailedActivity, 0, len(activities))
+		bar := progress.New("Reading records and segments", int64(len(activities)), "activities")
+		defer bar.Done()
+		for _, activity := range activities {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			detailed, err := apiClient.Activities.Get(ctx, activity.ID)
+			if err != nil {
+				return fmt.Errorf("Failed to obtain the details of activity %d: %v", activity.ID, err)
+			}
+			details = append(details, detailed)
+			bar.Add(1)
+		}
+		bar.Done()
+	}
+
+	// The rewind is meant to be shared, so the points of its heatmap within
+	// privacy zones are trimmed, as site build does.
+	scrubber, err := export.NewScrubber(zones, export.PrivacyTrim, nil)
+	if err != nil {
+		return err
+	}
+	scrubbed := make([]*models.SummaryActivity, len(activities))
+	for i, activity := range activities {
+		if scrubbed[i], err = scrubber.Activity(activity); err != nil {
+			return err
+		}
+	}
+
+	r, err := rewind.New(flags.year, scrubbed, details)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(flags.out)
+	if err != nil {
+		return err
+	}
+	if extension == ".html" {
+		err = r.WriteHTML(file)
+	} else {
+		err = r.WriteMarkdown(file)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Compiled %d activities of %d to %s\n", len(activities), flags.year, flags.out)
+	return nil
+}
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jsilland/sutro/cache"
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type exportFlags struct {
+	format  string
+	out     string
+	privacy string
+}
+
+func exportCommand(ctx context.Context, apiClient *strava.Client, zones []geo.Zone) *cobra.Command {
+	flags := exportFlags{}
+
+	command := &cobra.Command{
+		Use:   "export <route-id>",
+		Short: "Download a route as the GPX or TCX file Strava exports",
+		Long: "Download a route as the GPX or TCX file Strava exports, with the points lying within the " +
+			"configured privacy zones trimmed, or jittered with --privacy jitter.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportRoute(ctx, apiClient, zones, args[0], flags)
+		},
+	}
+
+	choice.Var(command, &flags.format, "format", strava.RouteGPX, "The format of the file: gpx or tcx", strava.RouteGPX, strava.RouteTCX)
+	command.Flags().StringVar(&flags.out, "out", "", "The file to write to instead of stdout")
+	choice.Var(command, &flags.privacy, "privacy", export.PrivacyTrim, "How to scrub points within the configured privacy zones: trim, jitter or off", export.PrivacyTrim, export.PrivacyJitter, export.PrivacyOff)
+
+	return command
+}
+
+func exportRoute(ctx context.Context, apiClient *strava.Client, zones []geo.Zone, arg string, flags exportFlags) error {
+	if apiClient == nil {
+		return errors.New("Exporting a route requires running sutro authenticate first")
+	}
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid route id %q", arg)
+	}
+	scrubber, err := export.NewScrubber(zones, flags.privacy, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err != nil {
+		return err
+	}
+
+	// The route is scrubbed once downloaded, so that no point within a
+	// privacy zone reaches the file.
+	var downloaded bytes.Buffer
+	writer := progress.NewWriter(&downloaded, fmt.Sprintf("Downloading route %d", id))
+	_, err = apiClient.Routes.Export(ctx, id, flags.format, writer)
+	writer.Done()
+	if err != nil {
+		return fmt.Errorf("Failed to export route %d: %v", id, err)
+	}
+	var scrubbed bytes.Buffer
+	if err := export.ScrubDocument(&scrubbed, &downloaded, scrubber); err != nil {
+		return fmt.Errorf("Unable to decode route %d: %v", id, err)
+	}
+
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if flags.out != "" {
+		if file, err = os.Create(flags.out); err != nil {
+			return err
+		}
+		out = file
+	}
+
+	written, err := scrubbed.WriteTo(out)
+	if file != nil {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(flags.out)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write route %d: %v", id, err)
+	}
+
+	if file != nil {
+		fmt.Fprintf(os.Stderr, "Wrote route %d to %s (%s)\n", id, flags.out, cache.FormatSize(written))
+	}
+	return nil
+}
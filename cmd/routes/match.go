@@ -0,0 +1,169 @@
+package routes
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+type matchFlags struct {
+	tolerance    string
+	overlap      float64
+	since        string
+	activityType string
+	minAttempts  int
+}
+
+type track struct {
+	activity *models.SummaryActivity
+	points   []geo.Point
+}
+
+type course struct {
+	reference *track
+	attempts  []*track
+}
+
+func matchCommand(archive *store.Store) *cobra.Command {
+	flags := matchFlags{}
+
+	command := &cobra.Command{
+		Use:   "match",
+		Short: "Group synced activities that cover the same course",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return match(archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.tolerance, "tolerance", "100m", "How far apart two tracks may be while still covering the same course")
+	command.Flags().Float64Var(&flags.overlap, "overlap", 0.9, "The fraction of each track that must lie within tolerance of the other")
+	command.Flags().StringVar(&flags.since, "since", "", "Only consider activities started after this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only consider activities of this type (e.g. Ride)")
+	command.Flags().IntVar(&flags.minAttempts, "min-attempts", 2, "Only list courses with at least this many attempts")
+
+	return command
+}
+
+func match(archive *store.Store, flags matchFlags) error {
+	tolerance, err := geo.ParseDistance(flags.tolerance)
+	if err != nil {
+		return err
+	}
+
+	query := store.Query{Type: flags.activityType}
+	if flags.since != "" {
+		if query.After, err = dates.Parse(flags.since); err != nil {
+			return err
+		}
+	}
+
+	activities, err := archive.Activities(query)
+	if err != nil {
+		return err
+	}
+
+	tracks, err := decodeTracks(activities, tolerance)
+	if err != nil {
+		return err
+	}
+
+	courses := cluster(tracks, tolerance, flags.overlap)
+	sort.SliceStable(courses, func(i, j int) bool {
+		return len(courses[i].attempts) > len(courses[j].attempts)
+	})
+
+	listed := 0
+	for _, c := range courses {
+		if len(c.attempts) < flags.minAttempts {
+			continue
+		}
+		listed++
+		if err := printCourse(listed, c); err != nil {
+			return err
+		}
+	}
+
+	if listed == 0 {
+		fmt.Printf("No course was covered at least %d times among %d synced activities\n", flags.minAttempts, len(tracks))
+	}
+	return nil
+}
+
+func decodeTracks(activities []*models.SummaryActivity, tolerance float64) ([]*track, error) {
+	spacing := math.Max(10, tolerance/2)
+
+	var tracks []*track
+	for _, activity := range activities {
+		if activity.Map == nil || activity.Map.SummaryPolyline == "" {
+			continue
+		}
+
+		points, err := geo.DecodePolyline(activity.Map.SummaryPolyline)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decode the map of activity %d: %v", activity.ID, err)
+		}
+		tracks = append(tracks, &track{activity: activity, points: geo.Resample(points, spacing)})
+	}
+	return tracks, nil
+}
+
+// cluster greedily groups tracks into courses, comparing each track to the
+// first track of every course found so far.
+func cluster(tracks []*track, tolerance, overlap float64) []*course {
+	var courses []*course
+	for _, t := range tracks {
+		matched := false
+		for _, c := range courses {
+			if geo.SameCourse(c.reference.points, t.points, tolerance, overlap) {
+				c.attempts = append(c.attempts, t)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			courses = append(courses, &course{reference: t, attempts: []*track{t}})
+		}
+	}
+	return courses
+}
+
+func printCourse(index int, c *course) error {
+	attempts := append([]*track(nil), c.attempts...)
+	sort.SliceStable(attempts, func(i, j int) bool {
+		return attempts[i].activity.MovingTime < attempts[j].activity.MovingTime
+	})
+
+	fmt.Printf("Course %d: %s, %s, %d attempts\n", index, c.reference.activity.Name, format.Kilometers(geo.Length(c.reference.points)), len(attempts))
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "RANK\tDATE\tID\tNAME\tMOVING TIME\tBEHIND BEST")
+
+	best := float64(attempts[0].activity.MovingTime)
+	for i, attempt := range attempts {
+		fmt.Fprintf(writer, "%d\t%s\t%d\t%s\t%s\t+%s\n",
+			i+1,
+			dates.Start(time.Time(attempt.activity.StartDate), time.Time(attempt.activity.StartDateLocal)).Format("2006-01-02"),
+			attempt.activity.ID,
+			attempt.activity.Name,
+			format.Duration(float64(attempt.activity.MovingTime)),
+			format.Duration(float64(attempt.activity.MovingTime)-best),
+		)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,202 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// maxFilenameLength keeps course names readable on the small screens of
+// head units, some of which truncate longer names.
+const maxFilenameLength = 40
+
+// layout is a folder in which a brand of head units picks up new courses,
+// relative to the root of the device.
+type layout struct {
+	brand  string
+	folder string
+}
+
+// layouts are tried in order, the first one that exists on the device wins.
+var layouts = []layout{
+	{"Garmin", filepath.Join("Garmin", "NewFiles")},
+	{"Garmin", filepath.Join("Garmin", "Courses")},
+	{"Garmin", "NewFiles"},
+	{"Garmin", "Courses"},
+	{"Wahoo", "routes"},
+	{"Wahoo", filepath.Join("ELEMNT", "routes")},
+}
+
+type pushFlags struct {
+	device string
+	dryRun bool
+}
+
+func pushCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := pushFlags{}
+
+	command := &cobra.Command{
+		Use:   "push <route-id>",
+		Short: "Copy a route to a connected GPS head unit",
+		Long: "Copy the GPX file Strava exports for a route, with its elevation, into the folder " +
+			"where the connected device picks up new courses: Garmin/NewFiles or Garmin/Courses " +
+			"on Garmin devices, and routes on Wahoo devices. Without --device, the mounted volumes " +
+			"are searched for a device with one of these folders.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return push(ctx, apiClient, args[0], flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.device, "device", "", "The mount point of the device (e.g. /Volumes/GARMIN)")
+	command.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Print where the course would be copied without writing it")
+
+	return command
+}
+
+func push(ctx context.Context, apiClient *strava.Client, arg string, flags pushFlags) error {
+	if apiClient == nil {
+		return errors.New("Pushing a route requires running sutro authenticate first")
+	}
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid route id %q", arg)
+	}
+
+	device, found, err := findDevice(flags.device)
+	if err != nil {
+		return err
+	}
+
+	route, err := apiClient.Routes.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	filename := filepath.Join(device, found.folder, courseFilename(route.Name, id))
+	if flags.dryRun {
+		fmt.Printf("Would copy %s to %s (%s)\n", route.Name, filename, found.brand)
+		return nil
+	}
+
+	// The course is the file Strava exports, which unlike the map of the
+	// route has the elevation head units draw their climb profiles from.
+	var course bytes.Buffer
+	if _, err := apiClient.Routes.Export(ctx, id, strava.RouteGPX, &course); err != nil {
+		return fmt.Errorf("Failed to export route %d: %v", id, err)
+	}
+	if err := ioutil.WriteFile(filename, course.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Copied %s to %s, eject the %s device to load it\n", route.Name, filename, found.brand)
+	return nil
+}
+
+// findDevice returns the device at the given mount point or, when none is
+// given, the only mounted device with a known layout.
+func findDevice(device string) (string, layout, error) {
+	if device != "" {
+		if found, ok := detectLayout(device); ok {
+			return device, found, nil
+		}
+		return "", layout{}, fmt.Errorf("%s does not look like a Garmin or Wahoo device, none of its folders take courses", device)
+	}
+
+	var devices []string
+	var found []layout
+	for _, volume := range volumes() {
+		if l, ok := detectLayout(volume); ok {
+			devices = append(devices, volume)
+			found = append(found, l)
+		}
+	}
+
+	switch len(devices) {
+	case 0:
+		return "", layout{}, errors.New("No connected Garmin or Wahoo device found, pass its mount point with --device")
+	case 1:
+		return devices[0], found[0], nil
+	}
+	return "", layout{}, fmt.Errorf("Several devices are connected, pass one of %s with --device", strings.Join(devices, ", "))
+}
+
+func detectLayout(device string) (layout, bool) {
+	for _, l := range layouts {
+		if info, err := os.Stat(filepath.Join(device, l.folder)); err == nil && info.IsDir() {
+			return l, true
+		}
+	}
+	return layout{}, false
+}
+
+// volumes lists the places removable drives are usually mounted.
+func volumes() []string {
+	var parents []string
+	switch runtime.GOOS {
+	case "darwin":
+		parents = []string{"/Volumes"}
+	case "windows":
+		var drives []string
+		for letter := 'D'; letter <= 'Z'; letter++ {
+			drives = append(drives, string(letter)+`:\`)
+		}
+		return drives
+	default:
+		parents = []string{"/media", "/mnt"}
+		if u, err := user.Current(); err == nil {
+			parents = append(parents, filepath.Join("/media", u.Username), filepath.Join("/run/media", u.Username))
+		}
+	}
+
+	var mounted []string
+	for _, parent := range parents {
+		entries, err := ioutil.ReadDir(parent)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				mounted = append(mounted, filepath.Join(parent, entry.Name()))
+			}
+		}
+	}
+	return mounted
+}
+
+// courseFilename names the course after the route, with only the characters
+// every device accepts in file names.
+func courseFilename(name string, id int64) string {
+	var builder strings.Builder
+	separated := false
+	for _, r := range name {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			builder.WriteRune(r)
+			separated = false
+		} else if !separated && builder.Len() > 0 {
+			builder.WriteRune('_')
+			separated = true
+		}
+	}
+
+	base := strings.TrimRight(builder.String(), "_")
+	if len(base) > maxFilenameLength {
+		base = strings.TrimRight(base[:maxFilenameLength], "_")
+	}
+	if base == "" {
+		base = fmt.Sprintf("route_%d", id)
+	}
+	return base + ".gpx"
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"context"
+
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// Commands returns the hand-written commands that complement the
+// generated routes client.
+func Commands(ctx context.Context, apiClient *strava.Client, archive *store.Store, zones []geo.Zone) []*cobra.Command {
+	return []*cobra.Command{
+		exportCommand(ctx, apiClient, zones),
+		matchCommand(archive),
+		pushCommand(ctx, apiClient),
+	}
+}
@@ -0,0 +1,282 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/batch"
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/prompt"
+	"github.com/jsilland/sutro/rules"
+	"github.com/jsilland/sutro/scopes"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type applyFlags struct {
+	since    string
+	dryRun   bool
+	yes      bool
+	failures *batch.Flags
+}
+
+// Command returns the rules command, which applies the rules of the
+// configuration naming, describing and equipping activities.
+func Command(ctx context.Context, apiClient *strava.Client, archive *store.Store, configuration config.Configuration) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "rules",
+		Short: "Name, describe and equip activities from rules",
+		Long: "Rules are listed under rules in ~/.sutro, such as\n\n" +
+			"  {\"name\": \"commute\", \"type\": \"Ride\", \"after\": \"06:00\", \"before\": \"10:00\",\n" +
+			"   \"route\": 1234, \"default_name\": true, \"title\": \"Commute\", \"gear\": \"b5678\"}\n\n" +
+			"An activity matches a rule when it meets all of its conditions: its type, the local " +
+			"time of day it starts at (after, before), the day of the week it starts on (days, " +
+			"such as [\"weekdays\"]), its distance (min_distance, max_distance), following the " +
+			"route of another activity (route, within tolerance), being flagged, or not, as a " +
+			"commute or as recorded on a trainer (commute, trainer) and still having the name " +
+			"Strava gave it (default_name). The title and description are templates as the ones " +
+			"of --description-template, and the gear is the id or the name of a bike or of shoes. " +
+			"Each field takes the value of the first matching " +
+			"rule that sets it, and sutro watch applies the rules to every new activity.",
+	}
+
+	flags := applyFlags{}
+	apply := &cobra.Command{
+		Use:     "apply [id...]",
+		Aliases: []string{"run"},
+		Short:   "Apply the rules to activities",
+		Long: "Apply the rules to the given activities, or to the synced activities started " +
+			"after --since, listing the changes before updating the activities on Strava.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return applyRules(ctx, cmd, apiClient, archive, configuration, args, flags)
+		},
+	}
+	apply.Flags().StringVar(&flags.since, "since", "", "Apply the rules to the synced activities started after this date (e.g. 7d)")
+	apply.Flags().BoolVar(&flags.dryRun, "dry-run", false, "List the changes without updating the activities")
+	apply.Flags().BoolVar(&flags.yes, "yes", false, "Update the activities without asking for confirmation")
+	flags.failures = batch.Var(apply, false)
+
+	command.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List the rules",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return listRules(configuration.Rules())
+			},
+		},
+		scopes.Require(apply, "activity:write"),
+	)
+	return command
+}
+
+func listRules(configured []config.Rule) error {
+	if len(configured) == 0 {
+		fmt.Println("No rule, add some under rules in ~/.sutro")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tMATCHES\tSETS")
+	for i, rule := range configured {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", name, conditions(rule), settings(rule))
+	}
+	return writer.Flush()
+}
+
+// conditions describes what activities rule matches.
+func conditions(rule config.Rule) string {
+	var described []string
+	if rule.Type != "" {
+		described = append(described, rule.Type)
+	}
+	switch {
+	case rule.After != "" && rule.Before != "":
+		described = append(described, fmt.Sprintf("%s to %s", rule.After, rule.Before))
+	case rule.After != "":
+		described = append(described, "after "+rule.After)
+	case rule.Before != "":
+		described = append(described, "before "+rule.Before)
+	}
+	switch {
+	case rule.MinDistance != "" && rule.MaxDistance != "":
+		described = append(described, fmt.Sprintf("%s to %s", rule.MinDistance, rule.MaxDistance))
+	case rule.MinDistance != "":
+		described = append(described, "over "+rule.MinDistance)
+	case rule.MaxDistance != "":
+		described = append(described, "under "+rule.MaxDistance)
+	}
+	if rule.Route != 0 {
+		tolerance := rule.Tolerance
+		if tolerance == "" {
+			tolerance = "100m"
+		}
+		described = append(described, fmt.Sprintf("route of %d within %s", rule.Route, tolerance))
+	}
+	if len(rule.Days) > 0 {
+		described = append(described, strings.Join(rule.Days, ", "))
+	}
+	if rule.Commute != nil {
+		described = append(described, flagged("commute", *rule.Commute))
+	}
+	if rule.Trainer != nil {
+		described = append(described, flagged("trainer", *rule.Trainer))
+	}
+	if rule.DefaultName {
+		described = append(described, "default name")
+	}
+	if len(described) == 0 {
+		return "every activity"
+	}
+	return strings.Join(described, ", ")
+}
+
+func flagged(flag string, value bool) string {
+	if value {
+		return flag
+	}
+	return "not " + flag
+}
+
+// settings describes what rule sets.
+func settings(rule config.Rule) string {
+	var described []string
+	if rule.Title != "" {
+		described = append(described, strconv.Quote(rule.Title))
+	}
+	if rule.Description != "" {
+		described = append(described, "description")
+	}
+	if rule.Gear != "" {
+		described = append(described, "gear "+rule.Gear)
+	}
+	return strings.Join(described, ", ")
+}
+
+func applyRules(ctx context.Context, cmd *cobra.Command, apiClient *strava.Client, archive *store.Store, configuration config.Configuration, args []string, flags applyFlags) error {
+	if len(args) == 0 && flags.since == "" {
+		return errors.New("Give the ids of the activities to apply the rules to, or --since")
+	}
+
+	engine, err := rules.New(ctx, apiClient, archive, configuration.Rules())
+	if err != nil {
+		return err
+	}
+	if engine.Empty() {
+		return errors.New("No rule to apply, add some under rules in ~/.sutro")
+	}
+
+	candidates, err := activities(ctx, apiClient, archive, args, flags.since)
+	if err != nil {
+		return err
+	}
+
+	var changes []*rules.Change
+	for _, candidate := range candidates {
+		change, err := engine.Plan(ctx, candidate)
+		if err != nil {
+			return err
+		}
+		if change != nil {
+			changes = append(changes, change)
+		}
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("The rules leave every activity as it is")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "DATE\tID\tNAME\tRULES\tCHANGES")
+	for _, change := range changes {
+		activity := change.Activity
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%s\t%s\n", dates.Start(time.Time(activity.StartDate), time.Time(activity.StartDateLocal)).Format("2006-01-02 15:04"),
+			activity.ID, activity.Name, strings.Join(change.Rules, ", "), change)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	for _, change := range changes {
+		history.Returned(ctx, change.Activity.ID)
+	}
+
+	if flags.dryRun {
+		fmt.Printf("Dry run: %d activities would be updated\n", len(changes))
+		return nil
+	}
+
+	if !flags.yes {
+		confirmed, err := prompt.Boolean(fmt.Sprintf("Update these %d activities?", len(changes)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return nil
+		}
+	}
+
+	run, err := flags.failures.Start(cmd, len(changes), "activities")
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		change := change
+		err := run.Do(ctx, fmt.Sprintf("activity %d", change.Activity.ID), func() error {
+			_, err := engine.Apply(ctx, change)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Updated %d activities\n", run.Succeeded())
+	return run.Done()
+}
+
+// activities returns the activities of ids, or else the synced activities
+// started after since.
+func activities(ctx context.Context, apiClient *strava.Client, archive *store.Store, ids []string, since string) ([]*models.SummaryActivity, error) {
+	if len(ids) == 0 {
+		after, err := dates.Parse(since)
+		if err != nil {
+			return nil, err
+		}
+		return archive.Activities(store.Query{After: after})
+	}
+
+	var found []*models.SummaryActivity
+	for _, arg := range ids {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid activity id %q", arg)
+		}
+		activity, err := archive.Activity(id)
+		if err != nil {
+			return nil, err
+		}
+		if activity == nil {
+			fetched, err := apiClient.Activities.Get(ctx, id)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to read activity %d: %v", id, err)
+			}
+			activity = &fetched.SummaryActivity
+		}
+		found = append(found, activity)
+	}
+	return found, nil
+}
@@ -0,0 +1,76 @@
+package script
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/jsilland/sutro/script"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+	"go.starlark.net/repl"
+	"go.starlark.net/starlark"
+)
+
+// Commands returns the repl and run commands, which evaluate Starlark
+// against the API.
+func Commands(ctx context.Context, apiClient *strava.Client) []*cobra.Command {
+	return []*cobra.Command{
+		replCommand(ctx, apiClient),
+		runCommand(ctx, apiClient),
+	}
+}
+
+func replCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "repl",
+		Short: "Evaluate Starlark interactively against the API",
+		Long: "Start a Starlark prompt whose strava module calls the API, for instance " +
+			"[a.name for a in strava.activities(after=\"4w ago\") if a.type == \"Ride\"]. " +
+			"Control-C interrupts the current call, Control-D exits.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repl.REPL(script.NewThread(ctx, "repl"), script.Globals(apiClient))
+			return nil
+		},
+	}
+}
+
+func runCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "run <script.star> [args...]",
+		Short: "Run a Starlark script against the API",
+		Long:  "Run a Starlark script whose strava module calls the API. The remaining arguments are available to the script as argv.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(ctx, apiClient, args[0], args[1:])
+		},
+	}
+}
+
+func run(ctx context.Context, apiClient *strava.Client, filename string, arguments []string) error {
+	var source []byte
+	var err error
+	if filename == "-" {
+		source, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		source, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return err
+	}
+
+	argv := make([]starlark.Value, 0, len(arguments))
+	for _, argument := range arguments {
+		argv = append(argv, starlark.String(argument))
+	}
+	globals := script.Globals(apiClient)
+	globals["argv"] = starlark.NewList(argv)
+
+	_, err = starlark.ExecFile(script.NewThread(ctx, filename), filename, source, globals)
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		// The backtrace points at the line of the script that failed.
+		os.Stderr.WriteString(evalErr.Backtrace() + "\n")
+	}
+	return err
+}
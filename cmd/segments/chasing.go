@@ -0,0 +1,177 @@
+package segments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jsilland/sutro/budget"
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// leaders name the leaders of segments that --against compares to.
+var leaders = map[string]string{"kom": "KOM", "qom": "QOM", "overall": "leader"}
+
+type chasingFlags struct {
+	against string
+	limit   int
+	budget  budget.Flags
+}
+
+// chase is a starred segment with the best effort of the athlete and the
+// time of its leader, in seconds.
+type chase struct {
+	segment *strava.Segment
+	pr      int64
+	leader  int64
+}
+
+// ratio is how much slower the best effort is than the leader, as a
+// fraction of the time of the leader.
+func (c chase) ratio() float64 {
+	return float64(c.pr-c.leader) / float64(c.leader)
+}
+
+func chasingCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := chasingFlags{}
+
+	command := &cobra.Command{
+		Use:   "chasing",
+		Short: "Rank starred segments by how close your best effort is to the leader",
+		Long: "Compare your best effort on each starred segment to the time of its KOM, QOM or " +
+			"overall leader, and list the segments from the closest to the furthest. Segments you " +
+			"have no effort on, or whose leaders the API does not report, are left out. Each " +
+			"segment takes a request, which --budget bounds.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := flags.budget.Apply(apiClient)
+			if err != nil {
+				return err
+			}
+			return chasing(ctx, apiClient, b, flags)
+		},
+	}
+
+	choice.Var(command, &flags.against, "against", "kom", "The leader to compare to: kom, qom or overall", "kom", "qom", "overall")
+	command.Flags().IntVar(&flags.limit, "limit", 0, "List at most this many segments")
+	flags.budget.Register(command)
+
+	return command
+}
+
+func chasing(ctx context.Context, apiClient *strava.Client, b *strava.Budget, flags chasingFlags) error {
+	starred, err := apiClient.Segments.Starred(ctx, strava.ListOptions{}).All()
+	if err != nil {
+		return fmt.Errorf("Failed to list the starred segments: %v", err)
+	}
+
+	var chases []chase
+	unridden, unreported := 0, 0
+	stopped := false
+	for _, summary := range starred {
+		segment, err := apiClient.Segments.Get(ctx, summary.ID)
+		if errors.Is(err, strava.ErrBudgetExhausted) {
+			stopped = true
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to obtain segment %d: %v", summary.ID, err)
+		}
+
+		pr := personalRecord(segment)
+		leader, ok := leaderTime(segment, flags.against)
+		switch {
+		case pr == 0:
+			unridden++
+		case !ok:
+			unreported++
+		default:
+			chases = append(chases, chase{segment: segment, pr: pr, leader: leader})
+		}
+	}
+
+	sort.SliceStable(chases, func(i, j int) bool { return chases[i].ratio() < chases[j].ratio() })
+	if flags.limit > 0 && len(chases) > flags.limit {
+		chases = chases[:flags.limit]
+	}
+
+	if len(chases) == 0 {
+		fmt.Println("No starred segment to compare, star the segments you chase on Strava")
+	} else {
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(writer, "BEHIND\tGAP\tPR\t%s\tSEGMENT\tID\n", strings.ToUpper(leaders[flags.against]))
+		for _, c := range chases {
+			behind := "held"
+			switch {
+			case c.pr > c.leader:
+				behind = fmt.Sprintf("%.1f%%", c.ratio()*100)
+			case c.pr < c.leader:
+				behind = "ahead"
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%d\n", behind, format.Duration(float64(c.pr-c.leader)),
+				format.Duration(float64(c.pr)), format.Duration(float64(c.leader)), c.segment.Name, c.segment.ID)
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+
+	var left []string
+	if unridden > 0 {
+		left = append(left, fmt.Sprintf("%s without an effort of yours", segments(unridden)))
+	}
+	if unreported > 0 {
+		left = append(left, fmt.Sprintf("%s whose %s the API did not report", segments(unreported), leaders[flags.against]))
+	}
+	if len(left) > 0 {
+		fmt.Fprintf(os.Stderr, "Left out %s\n", strings.Join(left, " and "))
+	}
+	if stopped {
+		fmt.Fprintf(os.Stderr, "%s, after comparing %d of the %d starred segments\n", budget.Describe(b), unridden+unreported+len(chases), len(starred))
+	}
+	return nil
+}
+
+// personalRecord returns the time of the best effort of the athlete on a
+// segment, in seconds, or 0 without an effort.
+func personalRecord(segment *strava.Segment) int64 {
+	if effort := segment.AthletePrEffort; effort != nil && effort.ElapsedTime > 0 {
+		return effort.ElapsedTime
+	}
+	if stats := segment.AthleteStats; stats != nil {
+		return stats.PRElapsedTime
+	}
+	return 0
+}
+
+// leaderTime returns the time of the leader of a segment, such as its KOM,
+// in seconds.
+func leaderTime(segment *strava.Segment, against string) (int64, bool) {
+	if segment.Xoms == nil {
+		return 0, false
+	}
+	value := map[string]string{"kom": segment.Xoms.KOM, "qom": segment.Xoms.QOM, "overall": segment.Xoms.Overall}[against]
+	if value == "" {
+		return 0, false
+	}
+	d, err := dates.ParseDuration(value)
+	if err != nil || d.Seconds() < 1 {
+		return 0, false
+	}
+	return int64(d.Seconds()), true
+}
+
+func segments(count int) string {
+	if count == 1 {
+		return "1 segment"
+	}
+	return fmt.Sprintf("%d segments", count)
+}
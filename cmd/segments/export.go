@@ -0,0 +1,126 @@
+package segments
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type effortsExportFlags struct {
+	out   string
+	since string
+	until string
+}
+
+func effortsExportCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := effortsExportFlags{}
+
+	command := &cobra.Command{
+		Use:   "efforts-export <segment-id>",
+		Short: "Export your efforts on a segment to CSV",
+		Long: "Export every effort of the authenticated athlete on a segment, from the oldest, with " +
+			"its date, elapsed and moving times, average power and heart rate, to chart the " +
+			"progress on the segment in a spreadsheet or another tool.",
+		Example: "  sutro segments efforts-export 229781 --out efforts.csv",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportEfforts(ctx, apiClient, args[0], flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.out, "out", "", "The file to write the efforts to instead of stdout")
+	command.Flags().StringVar(&flags.since, "since", "", "Only export the efforts started after this date")
+	command.Flags().StringVar(&flags.until, "until", "", "Only export the efforts started before this date")
+
+	return command
+}
+
+func exportEfforts(ctx context.Context, apiClient *strava.Client, arg string, flags effortsExportFlags) error {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid segment id %q", arg)
+	}
+	var options strava.ListOptions
+	if flags.since != "" {
+		if options.After, err = dates.Parse(flags.since); err != nil {
+			return err
+		}
+	}
+	if flags.until != "" {
+		if options.Before, err = dates.Parse(flags.until); err != nil {
+			return err
+		}
+	}
+
+	efforts, err := apiClient.Segments.Efforts(ctx, id, options)
+	if err != nil {
+		return fmt.Errorf("Failed to list the efforts on segment %d: %v", id, err)
+	}
+	if len(efforts) == 0 {
+		return fmt.Errorf("You have no effort on segment %d over the period", id)
+	}
+	sort.Slice(efforts, func(i, j int) bool {
+		return time.Time(efforts[i].StartDateLocal).Before(time.Time(efforts[j].StartDateLocal))
+	})
+
+	if flags.out == "" {
+		return writeEfforts(os.Stdout, efforts)
+	}
+	file, err := os.Create(flags.out)
+	if err != nil {
+		return err
+	}
+	if err := writeEfforts(file, efforts); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d efforts on segment %d to %s\n", len(efforts), id, flags.out)
+	return nil
+}
+
+func writeEfforts(w io.Writer, efforts []*models.DetailedSegmentEffort) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"date", "activity_id", "effort_id", "elapsed_time", "moving_time", "distance", "average_watts", "average_heartrate", "max_heartrate", "pr_rank"})
+	for _, effort := range efforts {
+		var activity int64
+		if effort.Activity != nil {
+			activity = effort.Activity.ID
+		}
+		writer.Write([]string{
+			time.Time(effort.StartDateLocal).Format("2006-01-02T15:04:05"),
+			strconv.FormatInt(activity, 10),
+			strconv.FormatInt(effort.ID, 10),
+			strconv.FormatInt(effort.ElapsedTime, 10),
+			strconv.FormatInt(effort.MovingTime, 10),
+			strconv.FormatFloat(float64(effort.Distance), 'f', 1, 32),
+			optional(effort.AverageWatts, 0),
+			optional(effort.AverageHeartrate, 1),
+			optional(effort.MaxHeartrate, 0),
+			strconv.FormatInt(effort.PrRank, 10),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// optional formats value with precision decimals, or leaves it empty when
+// the effort has no such data.
+func optional(value float32, precision int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(value), 'f', precision, 32)
+}
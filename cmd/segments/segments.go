@@ -0,0 +1,17 @@
+package segments
+
+import (
+	"context"
+
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// Commands returns the hand-written commands that complement the
+// generated segments client.
+func Commands(ctx context.Context, apiClient *strava.Client) []*cobra.Command {
+	return []*cobra.Command{
+		chasingCommand(ctx, apiClient),
+		effortsExportCommand(ctx, apiClient),
+	}
+}
@@ -0,0 +1,314 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/digest"
+	"github.com/jsilland/sutro/httpserver"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+// defaultLimit caps the number of activities listed unless the request
+// asks for another limit.
+const defaultLimit = 100
+
+type serveFlags struct {
+	host string
+	port int
+}
+
+func Command(ctx context.Context, archive *store.Store) *cobra.Command {
+	flags := serveFlags{}
+
+	command := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve synced activities as a read-only JSON API",
+		Long: "Serve the local archive as JSON, so that dashboards and other local tools can " +
+			"read it without Strava credentials:\n\n" +
+			"  GET /api/activities          synced activities, most recent first, filtered by\n" +
+			"                               after, before and type, paged by limit and offset\n" +
+			"  GET /api/activities/<id>     a synced activity\n" +
+			"  GET /api/search?q=<text>     synced activities whose name contains the text\n" +
+			"  GET /api/reports/<period>    totals of the week or month containing date,\n" +
+			"                               compared with the period before it\n\n" +
+			"The archive is read on every request, so responses follow sutro sync.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(ctx, archive, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.host, "host", "localhost", "The address to listen on, localhost keeping the archive private to this machine")
+	command.Flags().IntVar(&flags.port, "port", 9876, "The port to listen on")
+
+	return command
+}
+
+func serve(ctx context.Context, archive *store.Store, flags serveFlags) error {
+	mux := http.NewServeMux()
+	mux.Handle("/api/activities", readOnly(func(r *http.Request) (interface{}, error) {
+		return listActivities(archive, r)
+	}))
+	mux.Handle("/api/activities/", readOnly(func(r *http.Request) (interface{}, error) {
+		return getActivity(archive, strings.TrimPrefix(r.URL.Path, "/api/activities/"))
+	}))
+	mux.Handle("/api/search", readOnly(func(r *http.Request) (interface{}, error) {
+		return search(archive, r)
+	}))
+	mux.Handle("/api/reports/", readOnly(func(r *http.Request) (interface{}, error) {
+		return report(archive, strings.TrimPrefix(r.URL.Path, "/api/reports/"), r)
+	}))
+
+	address := net.JoinHostPort(flags.host, strconv.Itoa(flags.port))
+	fmt.Printf("Serving the archive at http://%s/api/activities\n", address)
+	return httpserver.ListenAndServe(ctx, address, mux)
+}
+
+// httpError is an error to report to the client with its status code.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{http.StatusBadRequest, fmt.Sprintf(format, args...)}
+}
+
+// readOnly serves the JSON encoding of what handle returns to GET and HEAD
+// requests, and errors as a JSON object with an error message.
+func readOnly(handle func(*http.Request) (interface{}, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "The API is read-only"})
+			return
+		}
+
+		body, err := handle(r)
+		if err != nil {
+			status, message := http.StatusInternalServerError, "Unable to read the archive"
+			if e, ok := err.(*httpError); ok {
+				status, message = e.status, e.message
+			} else {
+				log.Printf("Unable to serve %s: %v", r.URL, err)
+			}
+			writeJSON(w, status, map[string]string{"error": message})
+			return
+		}
+		writeJSON(w, http.StatusOK, body)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	encoded, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		log.Printf("Unable to encode a response: %v", err)
+		http.Error(w, "Unable to encode the response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(encoded, '\n'))
+}
+
+// page is a page of activities, with the number of activities matching
+// the request across all pages.
+type page struct {
+	Total      int                       `json:"total"`
+	Offset     int                       `json:"offset"`
+	Activities []*models.SummaryActivity `json:"activities"`
+}
+
+func listActivities(archive *store.Store, r *http.Request) (interface{}, error) {
+	query, err := parseQuery(r)
+	if err != nil {
+		return nil, err
+	}
+	activities, err := archive.Activities(query)
+	if err != nil {
+		return nil, err
+	}
+	return paginate(activities, r)
+}
+
+func search(archive *store.Store, r *http.Request) (interface{}, error) {
+	text := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if text == "" {
+		return nil, badRequest("Missing the text to search for, as q")
+	}
+	query, err := parseQuery(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*models.SummaryActivity
+	err = archive.EachActivity(query, func(activity *models.SummaryActivity) error {
+		if strings.Contains(strings.ToLower(activity.Name), text) {
+			matches = append(matches, activity)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paginate(matches, r)
+}
+
+func getActivity(archive *store.Store, arg string) (interface{}, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return nil, badRequest("Invalid activity id %q", arg)
+	}
+	activity, err := archive.Activity(id)
+	if err != nil {
+		return nil, err
+	}
+	if activity == nil {
+		return nil, &httpError{http.StatusNotFound, fmt.Sprintf("Activity %d has not been synced", id)}
+	}
+	return activity, nil
+}
+
+// reportBody is the JSON form of a digest, without its activities.
+type reportBody struct {
+	Period   string    `json:"period"`
+	Label    string    `json:"label"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Totals   totals    `json:"totals"`
+	Previous totals    `json:"previous"`
+	Types    []totals  `json:"types"`
+}
+
+type totals struct {
+	Type       string  `json:"type,omitempty"`
+	Count      int     `json:"count"`
+	Distance   float64 `json:"distance"`
+	MovingTime float64 `json:"moving_time"`
+	Ascent     float64 `json:"total_elevation_gain"`
+}
+
+func newTotals(t digest.Totals) totals {
+	return totals{Type: t.Type, Count: t.Count, Distance: t.Distance, MovingTime: t.MovingTime, Ascent: t.Ascent}
+}
+
+func report(archive *store.Store, name string, r *http.Request) (interface{}, error) {
+	date := time.Now()
+	if value := r.URL.Query().Get("date"); value != "" {
+		parsed, err := dates.Parse(value)
+		if err != nil {
+			return nil, badRequest("%v", err)
+		}
+		date = parsed
+	}
+
+	period, err := periodContaining(name, date)
+	if err != nil {
+		return nil, &httpError{http.StatusNotFound, err.Error()}
+	}
+	previous := period.Previous()
+
+	current, err := period.Activities(archive, "")
+	if err != nil {
+		return nil, err
+	}
+	before, err := previous.Activities(archive, "")
+	if err != nil {
+		return nil, err
+	}
+
+	d := digest.New("", period, current, before)
+	body := reportBody{
+		Period:   period.Name,
+		Label:    period.Label(),
+		Start:    period.Start,
+		End:      period.End,
+		Totals:   newTotals(d.Totals),
+		Previous: newTotals(d.Previous),
+		Types:    []totals{},
+	}
+	for _, t := range d.Types {
+		body.Types = append(body.Types, newTotals(t))
+	}
+	return body, nil
+}
+
+// periodContaining returns the week or month containing date, which is the
+// last complete period as of the start of the next one.
+func periodContaining(name string, date time.Time) (digest.Period, error) {
+	year, month, _ := date.Date()
+	switch name {
+	case "week":
+		return digest.LastPeriod(name, date.AddDate(0, 0, 7))
+	case "month":
+		return digest.LastPeriod(name, time.Date(year, month+1, 1, 0, 0, 0, 0, date.Location()))
+	}
+	return digest.LastPeriod(name, date)
+}
+
+func parseQuery(r *http.Request) (store.Query, error) {
+	values := r.URL.Query()
+	query := store.Query{Type: values.Get("type")}
+	for _, bound := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{"after", &query.After},
+		{"before", &query.Before},
+	} {
+		value := values.Get(bound.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := dates.Parse(value)
+		if err != nil {
+			return query, badRequest("Invalid %s: %v", bound.name, err)
+		}
+		*bound.target = parsed
+	}
+	return query, nil
+}
+
+// paginate returns the page of activities, most recent first, selected by
+// the limit and offset of the request.
+func paginate(activities []*models.SummaryActivity, r *http.Request) (interface{}, error) {
+	limit, offset := defaultLimit, 0
+	for _, parameter := range []struct {
+		name   string
+		target *int
+	}{
+		{"limit", &limit},
+		{"offset", &offset},
+	} {
+		value := r.URL.Query().Get(parameter.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return nil, badRequest("Invalid %s %q, expected a positive number", parameter.name, value)
+		}
+		*parameter.target = parsed
+	}
+
+	p := page{Total: len(activities), Offset: offset, Activities: []*models.SummaryActivity{}}
+	for i := len(activities) - 1 - offset; i >= 0 && len(p.Activities) < limit; i-- {
+		p.Activities = append(p.Activities, activities[i])
+	}
+	return p, nil
+}
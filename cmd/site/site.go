@@ -0,0 +1,89 @@
+package site
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/site"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+type buildFlags struct {
+	out          string
+	title        string
+	theme        string
+	since        string
+	activityType string
+}
+
+func Command(archive *store.Store, zones []geo.Zone) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "site",
+		Short: "Static sites of synced activities",
+	}
+
+	command.AddCommand(buildCommand(archive, zones))
+	return command
+}
+
+func buildCommand(archive *store.Store, zones []geo.Zone) *cobra.Command {
+	flags := buildFlags{}
+
+	command := &cobra.Command{
+		Use:   "build",
+		Short: "Render synced activities into a static HTML training log",
+		Long: "Render synced activities into a static site with totals, weekly volume, a heatmap " +
+			"and a page per activity, ready to be hosted anywhere. The points of the tracks within " +
+			"the privacy zones of the configuration are left out. A theme directory may override " +
+			"any of layout.html, index.html, activity.html and style.css.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return build(archive, zones, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.out, "out", "public", "The directory to write the site to")
+	command.Flags().StringVar(&flags.title, "title", "Training log", "The title of the site")
+	command.Flags().StringVar(&flags.theme, "theme", "", "A directory of templates overriding the default theme")
+	command.Flags().StringVar(&flags.since, "since", "", "Only include activities started after this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+
+	return command
+}
+
+func build(archive *store.Store, zones []geo.Zone, flags buildFlags) error {
+	theme := site.DefaultTheme()
+	if flags.theme != "" {
+		var err error
+		if theme, err = site.LoadTheme(flags.theme); err != nil {
+			return err
+		}
+	}
+
+	query := store.Query{Type: flags.activityType}
+	if flags.since != "" {
+		after, err := dates.Parse(flags.since)
+		if err != nil {
+			return err
+		}
+		query.After = after
+	}
+
+	activities, err := archive.Activities(query)
+	if err != nil {
+		return err
+	}
+	if len(activities) == 0 {
+		return errors.New("No synced activity matches, have you run sutro sync?")
+	}
+
+	if err := site.Build(flags.out, flags.title, activities, zones, theme); err != nil {
+		return err
+	}
+	fmt.Printf("Rendered %d activities to %s\n", len(activities), flags.out)
+	return nil
+}
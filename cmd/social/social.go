@@ -0,0 +1,179 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// Command returns the social command, which reports on the kudos and the
+// comments the activities of the authenticated athlete received. It only
+// reads them: sutro never gives kudos nor comments on anyone's behalf.
+func Command(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "social",
+		Short: "Report on the kudos and comments of your activities",
+	}
+
+	command.AddCommand(digestCommand(ctx, apiClient))
+	return command
+}
+
+type digestFlags struct {
+	since string
+	until string
+	top   int
+}
+
+// supporter counts the kudos and comments of an athlete.
+type supporter struct {
+	name     string
+	kudos    int
+	comments int
+}
+
+func digestCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := digestFlags{}
+
+	command := &cobra.Command{
+		Use:   "digest",
+		Short: "Summarize the kudos and comments received over a period",
+		Long: "Summarize the kudos and comments each activity started over a period received, and " +
+			"rank the athletes who gave them, instead of opening the activities one by one. The " +
+			"digest only reads from Strava, one request for the kudoers and one for the comments of " +
+			"each activity that has any.",
+		Example: "  sutro social digest --since 4w --top 5",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return digest(ctx, apiClient, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.since, "since", "4w", "Summarize the activities started after this date")
+	command.Flags().StringVar(&flags.until, "until", "", "Summarize the activities started before this date (defaults to now)")
+	command.Flags().IntVar(&flags.top, "top", 10, "The number of top supporters to list, 0 for all of them")
+
+	return command
+}
+
+func digest(ctx context.Context, apiClient *strava.Client, flags digestFlags) error {
+	since, err := dates.Parse(flags.since)
+	if err != nil {
+		return err
+	}
+	var until time.Time
+	if flags.until != "" {
+		if until, err = dates.Parse(flags.until); err != nil {
+			return err
+		}
+	}
+	if flags.top < 0 {
+		return fmt.Errorf("Invalid --top %d, expected a positive number", flags.top)
+	}
+
+	activities, err := apiClient.Activities.List(ctx, strava.ListOptions{After: since, Before: until}).All()
+	if err != nil {
+		return err
+	}
+	if len(activities) == 0 {
+		fmt.Printf("No activity started since %s\n", since.Format("2006-01-02"))
+		return nil
+	}
+
+	var reacted []*models.SummaryActivity
+	for _, activity := range activities {
+		if activity.KudosCount > 0 || activity.CommentCount > 0 {
+			reacted = append(reacted, activity)
+		}
+	}
+	supporters := map[string]*supporter{}
+	count := func(athlete *models.SummaryAthlete) *supporter {
+		name := "Unknown athlete"
+		if athlete != nil {
+			if full := strings.TrimSpace(athlete.Firstname + " " + athlete.Lastname); full != "" {
+				name = full
+			}
+		}
+		if _, ok := supporters[name]; !ok {
+			supporters[name] = &supporter{name: name}
+		}
+		return supporters[name]
+	}
+
+	bar := progress.New("Reading kudos and comments", int64(len(reacted)), "activities")
+	defer bar.Done()
+	for _, activity := range reacted {
+		if activity.KudosCount > 0 {
+			kudoers, err := apiClient.Activities.Kudoers(ctx, activity.ID, strava.ListOptions{}).All()
+			if err != nil {
+				return fmt.Errorf("Failed to obtain the kudos of activity %d: %v", activity.ID, err)
+			}
+			for _, kudoer := range kudoers {
+				count(kudoer).kudos++
+			}
+		}
+		if activity.CommentCount > 0 {
+			comments, err := apiClient.Activities.Comments(ctx, activity.ID, strava.ListOptions{}).All()
+			if err != nil {
+				return fmt.Errorf("Failed to obtain the comments of activity %d: %v", activity.ID, err)
+			}
+			for _, comment := range comments {
+				count(comment.Athlete).comments++
+			}
+		}
+		bar.Add(1)
+	}
+	bar.Done()
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "DATE\tID\tACTIVITY\tKUDOS\tCOMMENTS")
+	var kudos, comments int64
+	ids := make([]int64, 0, len(activities))
+	for _, activity := range activities {
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%d\t%d\n", time.Time(activity.StartDateLocal).Format("2006-01-02"), activity.ID, activity.Name, activity.KudosCount, activity.CommentCount)
+		kudos += activity.KudosCount
+		comments += activity.CommentCount
+		ids = append(ids, activity.ID)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	history.Returned(ctx, ids...)
+	fmt.Printf("\n%d kudos and %d comments on %d activities\n", kudos, comments, len(activities))
+
+	if len(supporters) == 0 {
+		return nil
+	}
+	ranked := make([]*supporter, 0, len(supporters))
+	for _, s := range supporters {
+		ranked = append(ranked, s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ri, rj := ranked[i].kudos+ranked[i].comments, ranked[j].kudos+ranked[j].comments; ri != rj {
+			return ri > rj
+		}
+		return ranked[i].name < ranked[j].name
+	})
+	if flags.top > 0 && len(ranked) > flags.top {
+		ranked = ranked[:flags.top]
+	}
+
+	fmt.Println()
+	writer = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "SUPPORTER\tKUDOS\tCOMMENTS")
+	for _, s := range ranked {
+		fmt.Fprintf(writer, "%s\t%d\t%d\n", s.name, s.kudos, s.comments)
+	}
+	return writer.Flush()
+}
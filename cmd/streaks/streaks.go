@@ -0,0 +1,135 @@
+package streaks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/streak"
+	"github.com/spf13/cobra"
+)
+
+type streaksFlags struct {
+	activityType string
+	minDuration  time.Duration
+	format       string
+}
+
+// span is a streak as listed, from its first to its last day.
+type span struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Length int    `json:"length"`
+}
+
+type streaks struct {
+	Current *span `json:"current"`
+	Longest *span `json:"longest"`
+}
+
+func Command(archive *store.Store) *cobra.Command {
+	flags := streaksFlags{}
+
+	command := &cobra.Command{
+		Use:   "streaks",
+		Short: "Report the current and longest streaks of active days and weeks",
+		Long: "Report the current and the longest streaks of consecutive days, and of consecutive " +
+			"weeks starting on Monday, with at least one synced activity of --type lasting " +
+			"--min-duration or more. A streak is current until a whole day, or week, passed " +
+			"without activity, so that a daily streak still counts on a day not yet active.",
+		Example: "  sutro streaks\n" +
+			"  sutro streaks --type Run --min-duration 20m",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return report(archive, flags)
+		},
+	}
+
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only count activities of this type (e.g. Run)")
+	command.Flags().DurationVar(&flags.minDuration, "min-duration", 0, "Only count activities of at least this moving time")
+	choice.Var(command, &flags.format, "format", "table", "The format of the report: table or json", "table", "json")
+
+	return command
+}
+
+func report(archive *store.Store, flags streaksFlags) error {
+	var days []time.Time
+	err := archive.EachActivity(store.Query{Type: flags.activityType}, func(activity *models.SummaryActivity) error {
+		if time.Duration(activity.MovingTime)*time.Second < flags.minDuration {
+			return nil
+		}
+		days = append(days, streak.Day(dates.Start(time.Time(activity.StartDate), time.Time(activity.StartDateLocal))))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(days) == 0 {
+		return errors.New("No synced activity matches, have you run sutro sync?")
+	}
+
+	now := time.Now()
+	daily, weekly := streak.Find(days, streak.Daily), streak.Find(days, streak.Weekly)
+	byPeriod := map[string]streaks{
+		"daily": {
+			Current: newSpan(streak.Current(daily, now, streak.Daily), streak.Daily),
+			Longest: newSpan(streak.Longest(daily), streak.Daily),
+		},
+		"weekly": {
+			Current: newSpan(streak.Current(weekly, now, streak.Weekly), streak.Weekly),
+			Longest: newSpan(streak.Longest(weekly), streak.Weekly),
+		},
+	}
+
+	if flags.format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(byPeriod)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STREAK\tCURRENT\tSINCE\tLONGEST\tFROM\tTO")
+	for _, period := range []struct{ name, unit string }{{"daily", "day"}, {"weekly", "week"}} {
+		s := byPeriod[period.name]
+		current, since := "-", "-"
+		if s.Current != nil {
+			current, since = length(s.Current.Length, period.unit), s.Current.Start
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", period.name, current, since, length(s.Longest.Length, period.unit), s.Longest.Start, s.Longest.End)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if current := streak.Current(daily, now, streak.Daily); current.Length > 0 && current.End.Before(streak.Day(now)) {
+		fmt.Printf("\nNo activity yet today: the daily streak of %s ends at midnight\n", length(current.Length, "day"))
+	}
+	return nil
+}
+
+// newSpan returns the span of s, or nil for the zero Streak. Weekly streaks
+// end on the Sunday of their last week.
+func newSpan(s streak.Streak, p streak.Period) *span {
+	if s.Length == 0 {
+		return nil
+	}
+	end := s.End
+	if p == streak.Weekly {
+		end = end.AddDate(0, 0, 6)
+	}
+	return &span{Start: s.Start.Format("2006-01-02"), End: end.Format("2006-01-02"), Length: s.Length}
+}
+
+func length(count int, unit string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
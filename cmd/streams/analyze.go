@@ -0,0 +1,310 @@
+package streams
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/stream"
+	"github.com/jsilland/sutro/training"
+	"github.com/spf13/cobra"
+)
+
+// minimumSpeed is the speed under which an athlete is standing rather than
+// moving, which has no pace.
+const minimumSpeed = 0.5
+
+// columns are the series of an analysis, in the order of the columns of
+// its CSV.
+var columns = []string{
+	"time", "distance", "watts", "heartrate", "speed", "grade",
+	"grade_adjusted_speed", "grade_adjusted_pace", "normalized_power", "efficiency_factor",
+}
+
+type analyzeFlags struct {
+	window      time.Duration
+	gradeWindow time.Duration
+	format      string
+	summary     bool
+}
+
+// value is a number of an analysis, which is null in JSON when it is not
+// known.
+type value float64
+
+func (v value) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+		return []byte("null"), nil
+	}
+	return []byte(v.String()), nil
+}
+
+func (v value) String() string {
+	if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+		return ""
+	}
+	return strconv.FormatFloat(math.Round(float64(v)*100)/100, 'f', -1, 64)
+}
+
+// summary is what the series of an activity add up to.
+type summary struct {
+	// Duration is in seconds.
+	Duration          value `json:"duration"`
+	NormalizedPower   value `json:"normalized_power"`
+	AverageHeartrate  value `json:"average_heartrate"`
+	GradeAdjustedPace value `json:"grade_adjusted_pace"`
+	EfficiencyFactor  value `json:"efficiency_factor"`
+	// Decoupling is in percent.
+	Decoupling value `json:"decoupling"`
+}
+
+type analysis struct {
+	ID      int64              `json:"id"`
+	Summary summary            `json:"summary"`
+	Series  map[string][]value `json:"series,omitempty"`
+}
+
+func analyzeCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := analyzeFlags{}
+
+	command := &cobra.Command{
+		Use:   "analyze <id>...",
+		Short: "Derive normalized power, grade-adjusted pace and efficiency from the streams of activities",
+		Long: "Resample the streams of activities every second and derive series from them: power, " +
+			"heart rate, speed and grade smoothed over --window, or --grade-window for the grade, the " +
+			"speed and pace, in seconds per kilometer, on flat ground costing as much energy as " +
+			"running on the grade, the normalized power up to each second, and the efficiency " +
+			"factor, the ratio of power, or of grade-adjusted speed in meters per minute, to heart " +
+			"rate. The summary of each activity adds its aerobic decoupling: how much its efficiency " +
+			"factor dropped from the first half to the second, in percent.\n\n" +
+			"CSV has a row per second and activity, and JSON an object per activity with its " +
+			"summary and its series; --summary only prints the summaries.",
+		Example: "  sutro streams analyze 1234 --window 60s > 1234.csv\n" +
+			"  sutro streams analyze 1234 5678 --summary --format json",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return analyze(ctx, apiClient, args, flags)
+		},
+	}
+
+	command.Flags().DurationVar(&flags.window, "window", 30*time.Second, "The window power, heart rate and speed are smoothed over")
+	command.Flags().DurationVar(&flags.gradeWindow, "grade-window", 10*time.Second, "The window the grade is smoothed over")
+	choice.Var(command, &flags.format, "format", "csv", "The format of the analysis: csv or json", "csv", "json")
+	command.Flags().BoolVar(&flags.summary, "summary", false, "Only print the summary of each activity")
+
+	return command
+}
+
+func analyze(ctx context.Context, apiClient *strava.Client, args []string, flags analyzeFlags) error {
+	var analyses []analysis
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid activity id %q", arg)
+		}
+		set, err := apiClient.Streams.Activity(ctx, id, "time", "distance", "watts", "heartrate", "velocity_smooth", "grade_smooth")
+		if err != nil {
+			return err
+		}
+		times := stream.Times(set)
+		if len(times) < 2 {
+			return fmt.Errorf("Activity %d has no time stream to analyze", id)
+		}
+		analyses = append(analyses, derive(id, times, set, flags))
+		history.Returned(ctx, id)
+	}
+
+	if flags.format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, a := range analyses {
+			if flags.summary {
+				a.Series = nil
+			}
+			if err := encoder.Encode(a); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if flags.summary {
+		return writeSummaries(analyses)
+	}
+	return writeSeries(analyses)
+}
+
+// derive returns the analysis of the streams of an activity, resampled
+// every second along times.
+func derive(id int64, times []float64, set *models.StreamSet, flags analyzeFlags) analysis {
+	resample := func(ys []float64) []float64 {
+		return stream.Resample(times, ys, 1)
+	}
+	window, gradeWindow := seconds(flags.window), seconds(flags.gradeWindow)
+
+	watts := resample(stream.Watts(set))
+	heartrates := stream.Smooth(resample(stream.Heartrates(set)), window)
+	speeds := stream.Smooth(resample(stream.Speeds(set)), window)
+	grades := stream.Smooth(resample(stream.Grades(set)), gradeWindow)
+
+	length := int(times[len(times)-1]-times[0]) + 1
+	clock := make([]float64, length)
+	for i := range clock {
+		clock[i] = times[0] + float64(i)
+	}
+
+	var adjusted, paces []float64
+	if len(speeds) > 0 {
+		adjusted, paces = make([]float64, length), make([]float64, length)
+		for i, speed := range speeds {
+			grade := 0.0
+			if i < len(grades) && !math.IsNaN(grades[i]) {
+				grade = grades[i] / 100
+			}
+			adjusted[i] = training.GradeAdjustedSpeed(speed, grade)
+			paces[i] = pace(adjusted[i])
+		}
+	}
+
+	// The efficiency of rides is measured by their power, and that of the
+	// other activities by their speed in meters per minute.
+	var output, efficiency []float64
+	switch {
+	case len(watts) > 0:
+		output = watts
+	case len(adjusted) > 0:
+		output = make([]float64, length)
+		for i, speed := range adjusted {
+			output[i] = speed * 60
+		}
+	}
+	if len(output) > 0 && len(heartrates) > 0 {
+		smoothed := stream.Smooth(output, window)
+		efficiency = make([]float64, length)
+		for i := range efficiency {
+			efficiency[i] = smoothed[i] / heartrates[i]
+		}
+	}
+
+	a := analysis{
+		ID: id,
+		Summary: summary{
+			Duration:          value(length - 1),
+			NormalizedPower:   value(math.NaN()),
+			AverageHeartrate:  value(average(heartrates)),
+			GradeAdjustedPace: value(pace(average(adjusted))),
+			EfficiencyFactor:  value(math.NaN()),
+			Decoupling:        value(math.NaN()),
+		},
+		Series: map[string][]value{
+			"time":                 values(clock),
+			"distance":             values(resample(stream.Distances(set))),
+			"watts":                values(stream.Smooth(watts, window)),
+			"heartrate":            values(heartrates),
+			"speed":                values(speeds),
+			"grade":                values(grades),
+			"grade_adjusted_speed": values(adjusted),
+			"grade_adjusted_pace":  values(paces),
+			"normalized_power":     values(training.CumulativeNormalizedPower(watts)),
+			"efficiency_factor":    values(efficiency),
+		},
+	}
+	if len(watts) > 0 {
+		a.Summary.NormalizedPower = value(training.NormalizedPower(watts))
+	}
+	switch {
+	case len(watts) > 0 && len(heartrates) > 0:
+		a.Summary.EfficiencyFactor = value(training.NormalizedPower(watts) / average(heartrates))
+	case len(output) > 0 && len(heartrates) > 0:
+		a.Summary.EfficiencyFactor = value(training.EfficiencyFactor(output, heartrates))
+	}
+	if len(output) > 0 && len(heartrates) > 0 {
+		a.Summary.Decoupling = value(training.Decoupling(output, heartrates))
+	}
+	return a
+}
+
+func writeSeries(analyses []analysis) error {
+	writer := csv.NewWriter(os.Stdout)
+	if err := writer.Write(append([]string{"activity_id"}, columns...)); err != nil {
+		return err
+	}
+	for _, a := range analyses {
+		for i := range a.Series["time"] {
+			row := []string{strconv.FormatInt(a.ID, 10)}
+			for _, column := range columns {
+				cell := ""
+				if series := a.Series[column]; i < len(series) {
+					cell = series[i].String()
+				}
+				row = append(row, cell)
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeSummaries(analyses []analysis) error {
+	writer := csv.NewWriter(os.Stdout)
+	writer.Write([]string{"activity_id", "duration", "normalized_power", "average_heartrate", "grade_adjusted_pace", "efficiency_factor", "decoupling"})
+	for _, a := range analyses {
+		s := a.Summary
+		writer.Write([]string{strconv.FormatInt(a.ID, 10), s.Duration.String(), s.NormalizedPower.String(), s.AverageHeartrate.String(),
+			s.GradeAdjustedPace.String(), s.EfficiencyFactor.String(), s.Decoupling.String()})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// pace returns the pace at speed, in seconds per kilometer, or NaN when
+// standing.
+func pace(speed float64) float64 {
+	if math.IsNaN(speed) || speed < minimumSpeed {
+		return math.NaN()
+	}
+	return 1000 / speed
+}
+
+func average(signal []float64) float64 {
+	total, count := 0.0, 0
+	for _, v := range signal {
+		if !math.IsNaN(v) {
+			total += v
+			count++
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return total / float64(count)
+}
+
+func values(signal []float64) []value {
+	if len(signal) == 0 {
+		return nil
+	}
+	converted := make([]value, len(signal))
+	for i, v := range signal {
+		converted[i] = value(v)
+	}
+	return converted
+}
+
+// seconds returns the number of samples, one per second, in window.
+func seconds(window time.Duration) int {
+	if window < time.Second {
+		return 1
+	}
+	return int(window / time.Second)
+}
@@ -0,0 +1,16 @@
+package streams
+
+import (
+	"context"
+
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// Commands returns the hand-written commands that complement the
+// generated streams client.
+func Commands(ctx context.Context, apiClient *strava.Client) []*cobra.Command {
+	return []*cobra.Command{
+		analyzeCommand(ctx, apiClient),
+	}
+}
@@ -0,0 +1,159 @@
+package synchronize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jsilland/sutro/budget"
+	"github.com/jsilland/sutro/cache"
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// defaultStreamKeys are the stream types that exports and analyses read.
+var defaultStreamKeys = []string{"time", "distance", "latlng", "altitude", "heartrate", "cadence", "watts"}
+
+type streamsFlags struct {
+	keys         []string
+	since        string
+	until        string
+	activityType string
+	budget       budget.Flags
+}
+
+func streamsCommand(ctx context.Context, apiClient *strava.Client, archive *store.Store, streams *cache.Cache) *cobra.Command {
+	flags := streamsFlags{}
+
+	command := &cobra.Command{
+		Use:   "streams",
+		Short: "Fetch the streams of synced activities into the cache",
+		Long: "Fetch the streams of the activities in the archive into the cache, so that " +
+			"the commands reading them later run without requests to the API. Streams " +
+			"already cached are skipped, so that running the command again continues " +
+			"where a budget or the rate limit stopped it.",
+		Example: "  sutro sync streams --keys heartrate,watts --since 2024",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := flags.budget.Apply(apiClient)
+			if err != nil {
+				return err
+			}
+			return prefetch(ctx, apiClient, archive, streams, b, flags)
+		},
+	}
+
+	command.Flags().StringSliceVar(&flags.keys, "keys", defaultStreamKeys, "The stream types to fetch")
+	command.Flags().StringVar(&flags.since, "since", "", "Only fetch the streams of activities started after this date")
+	command.Flags().StringVar(&flags.until, "until", "", "Only fetch the streams of activities started before this date")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only fetch the streams of activities of this type (e.g. Run)")
+	flags.budget.Register(command)
+
+	return command
+}
+
+func prefetch(ctx context.Context, apiClient *strava.Client, archive *store.Store, streams *cache.Cache, b *strava.Budget, flags streamsFlags) error {
+	if len(flags.keys) == 0 {
+		return errors.New("Pass at least one stream type with --keys")
+	}
+	query := store.Query{Type: flags.activityType}
+	if flags.since != "" {
+		after, err := dates.Parse(flags.since)
+		if err != nil {
+			return err
+		}
+		query.After = after
+	}
+	if flags.until != "" {
+		before, err := dates.Parse(flags.until)
+		if err != nil {
+			return err
+		}
+		query.Before = before
+	}
+
+	// Manual activities have no streams to fetch.
+	var ids []int64
+	cached := 0
+	err := archive.EachActivity(query, func(activity *models.SummaryActivity) error {
+		switch {
+		case activity.Manual:
+		case apiClient.Streams.Cached(activity.ID, flags.keys...):
+			cached++
+		default:
+			ids = append(ids, activity.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		fmt.Printf("The streams of all %d activities are cached\n", cached)
+		return nil
+	}
+	if cached > 0 {
+		fmt.Printf("Skipping %d activities whose streams are cached\n", cached)
+	}
+
+	fetched := 0
+	bar := progress.New("Fetching streams", int64(len(ids)), "activities")
+	defer bar.Done()
+	for _, id := range ids {
+		err := fetchStreams(ctx, apiClient, id, flags.keys, b, bar)
+		if b.Stopped(err) {
+			bar.Done()
+			fmt.Printf("%s, fetched the streams of %d of %d activities. Run sutro sync streams again to continue\n", budget.Describe(b), fetched, len(ids))
+			return nil
+		}
+		if err != nil && ctx.Err() != nil {
+			return fmt.Errorf("Interrupted after fetching the streams of %d of %d activities, run sutro sync streams again to continue", fetched, len(ids))
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to obtain the streams of activity %d: %v\nStopped after fetching the streams of %d of %d activities, run sutro sync streams again to continue", id, err, fetched, len(ids))
+		}
+		fetched++
+		bar.Add(1)
+	}
+
+	bar.Done()
+	fmt.Printf("Fetched the streams of %d activities\n", fetched)
+	// Streams evicted to make room for others would be fetched again.
+	if usage, err := streams.Usage(); err == nil && usage.Size > streams.MaxSize()*9/10 {
+		fmt.Printf("The cache holds %s of at most %s, raise cache_max_size in the configuration to keep the streams of more activities\n",
+			cache.FormatSize(usage.Size), cache.FormatSize(streams.MaxSize()))
+	}
+	return nil
+}
+
+// fetchStreams fetches the streams of an activity into the cache, waiting
+// for the rate limit to reset as long as b allows, which bar reports.
+func fetchStreams(ctx context.Context, apiClient *strava.Client, id int64, keys []string, b *strava.Budget, bar *progress.Bar) error {
+	for {
+		// Cached streams are read without a request, which would otherwise
+		// notice the interruption.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		_, err := apiClient.Streams.Activity(ctx, id, keys...)
+		var limited *strava.RateLimitError
+		if !errors.As(err, &limited) || b.ExhaustedAt(limited.Reset) {
+			return err
+		}
+
+		bar.Pause(limited.Reset)
+		timer := time.NewTimer(time.Until(limited.Reset))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package synchronize
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jsilland/sutro/budget"
+	"github.com/jsilland/sutro/cache"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/journal"
+	"github.com/jsilland/sutro/lock"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+const perPage = 200
+
+type syncFlags struct {
+	full     bool
+	resume   bool
+	budget   budget.Flags
+	instance lock.Flags
+}
+
+// Command returns the sync command, which synchronizes activities into the
+// archive and, with its streams subcommand, their streams into the cache.
+func Command(ctx context.Context, apiClient *strava.Client, archive *store.Store, streams *cache.Cache, stateDirectory string) *cobra.Command {
+	flags := syncFlags{}
+
+	command := &cobra.Command{
+		Use:   "sync",
+		Short: "Synchronize activities into the local archive",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			l, err := flags.instance.Acquire(ctx, stateDirectory, "sync")
+			if err != nil {
+				return err
+			}
+			defer l.Release()
+			b, err := flags.budget.Apply(apiClient)
+			if err != nil {
+				return err
+			}
+			return synchronize(ctx, apiClient, archive, journal.Path(stateDirectory, "sync"), b, flags)
+		},
+	}
+
+	command.Flags().BoolVar(&flags.full, "full", false, "Synchronize all activities instead of only the ones newer than the archive")
+	command.Flags().BoolVar(&flags.resume, "resume", false, "Continue an interrupted sync where it stopped")
+	flags.budget.Register(command)
+	flags.instance.Register(command)
+
+	command.AddCommand(streamsCommand(ctx, apiClient, archive, streams))
+	return command
+}
+
+func synchronize(ctx context.Context, apiClient *strava.Client, archive *store.Store, journalPath string, b *strava.Budget, flags syncFlags) error {
+	// A full sync lists activities from the oldest, by starting at the
+	// epoch rather than leaving the start unbounded, so that its progress
+	// is the start of the last activity it archived.
+	key, after := "new", time.Unix(0, 0)
+	if flags.full {
+		key = "full"
+	} else {
+		latest, err := archive.LatestActivityStart()
+		if err != nil {
+			return err
+		}
+		if !latest.IsZero() {
+			after = latest
+		}
+	}
+	// Syncing new activities after an interrupted sync naturally continues
+	// from the last activity it archived, a full sync starts over.
+	if flags.full && !flags.resume && journal.Exists(journalPath) {
+		fmt.Println("Starting over the full sync that was interrupted, pass --resume to continue it instead")
+	}
+
+	j, err := journal.Open(journalPath, key, flags.resume)
+	if err != nil {
+		return err
+	}
+	if cursor := j.Cursor(); cursor != "" {
+		start, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			j.Close()
+			return fmt.Errorf("Invalid progress %q in %s", cursor, journalPath)
+		}
+		after = time.Unix(start, 0)
+		fmt.Printf("Resuming the sync after activities started until %s\n", after.Format("Jan 2, 2006 15:04"))
+	}
+
+	// Only the number of synced activities is kept, so that memory stays
+	// flat however large the archive.
+	bar := progress.New("Synchronizing", 0, "activities")
+	synced, err := fetch(ctx, apiClient, archive, after, j, b, bar, nil)
+	bar.Done()
+	if b.Stopped(err) {
+		fmt.Printf("%s, synchronized %d activities. Run sutro sync --resume to continue\n", budget.Describe(b), synced)
+		return j.Close()
+	}
+	if err != nil && ctx.Err() != nil {
+		// The activities of the pages fetched before the interruption are
+		// archived and journaled.
+		j.Close()
+		return fmt.Errorf("Interrupted after synchronizing %d activities, run sutro sync --resume to continue", synced)
+	}
+	if err != nil {
+		j.Close()
+		return fmt.Errorf("%v\nThe sync stopped after %d activities, run sutro sync --resume to continue it", err, synced)
+	}
+	if err := j.Finish(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Synchronized %d activities\n", synced)
+	return nil
+}
+
+// New synchronizes the activities started after the most recent one in the
+// archive, or every activity if the archive is empty, and returns them.
+func New(ctx context.Context, apiClient *strava.Client, archive *store.Store) ([]*models.SummaryActivity, error) {
+	latest, err := archive.LatestActivityStart()
+	if err != nil {
+		return nil, err
+	}
+
+	var synced []*models.SummaryActivity
+	_, err = fetch(ctx, apiClient, archive, latest, nil, nil, nil, func(page []*models.SummaryActivity) {
+		synced = append(synced, page...)
+	})
+	return synced, err
+}
+
+// fetch archives the activities started after the given time, a page at a
+// time so that an interrupted sync keeps what it fetched. Each page is
+// committed to j, when given, with the start of its last activity, then
+// passed to archived, when given. The number of activities archived before
+// an error is returned along with it, and the rate limit is only waited for
+// within b. The progress is reported on bar, when given.
+func fetch(ctx context.Context, apiClient *strava.Client, archive *store.Store, after time.Time, j *journal.Journal, b *strava.Budget, bar *progress.Bar, archived func([]*models.SummaryActivity)) (int, error) {
+	var synced int
+	var batch []*models.SummaryActivity
+	iterator := apiClient.Activities.List(ctx, strava.ListOptions{After: after, PerPage: perPage})
+	iterator.OnRateLimit(func(resume time.Time) bool {
+		if b.ExhaustedAt(resume) {
+			return false
+		}
+		if bar != nil {
+			bar.Pause(resume)
+		} else {
+			fmt.Printf("Reached the rate limit of the API after %d activities, resuming at %s\n", synced+len(batch), resume.Format("15:04"))
+		}
+		return true
+	})
+
+	flush := func() error {
+		if err := archive.PutActivities(batch); err != nil {
+			return err
+		}
+		if j != nil && len(batch) > 0 {
+			var last time.Time
+			for _, activity := range batch {
+				if start := time.Time(activity.StartDate); start.After(last) {
+					last = start
+				}
+			}
+			if err := j.Commit(strconv.FormatInt(last.Unix(), 10)); err != nil {
+				return err
+			}
+		}
+		for _, activity := range batch {
+			history.Returned(ctx, activity.ID)
+		}
+		if archived != nil && len(batch) > 0 {
+			archived(batch)
+		}
+		synced, batch = synced+len(batch), nil
+		return nil
+	}
+
+	for iterator.Next() {
+		batch = append(batch, iterator.Value())
+		bar.Add(1)
+		if len(batch) == perPage {
+			if err := flush(); err != nil {
+				return synced, err
+			}
+		}
+	}
+	if err := iterator.Err(); err != nil {
+		// The activities fetched so far are kept, so that resuming does not
+		// fetch them again.
+		if flushErr := flush(); flushErr != nil {
+			return synced, flushErr
+		}
+		return synced, err
+	}
+
+	if err := flush(); err != nil {
+		return synced, err
+	}
+	return synced, nil
+}
@@ -0,0 +1,111 @@
+package thresholds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/store"
+	"github.com/spf13/cobra"
+)
+
+// Command returns the thresholds command, which keeps the history of the
+// functional threshold power and lactate threshold heart rate of the
+// athlete, so that activities are measured against the thresholds valid
+// when they took place.
+func Command(archive *store.Store) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "thresholds",
+		Short: "Keep the history of the power and heart rate thresholds",
+		Long: "Record the functional threshold power (FTP) and the lactate threshold heart rate " +
+			"(LTHR) of the athlete as they change. The time in zones and the training stress of " +
+			"activities, as reported by activities zones, use the thresholds valid on the day each " +
+			"activity took place.",
+	}
+
+	command.AddCommand(setCommand(archive), listCommand(archive))
+	return command
+}
+
+type setFlags struct {
+	ftp  float64
+	lthr float64
+	date string
+}
+
+func setCommand(archive *store.Store) *cobra.Command {
+	flags := setFlags{}
+
+	command := &cobra.Command{
+		Use:     "set",
+		Short:   "Record the thresholds from a day on",
+		Example: "  sutro thresholds set --ftp 265 --lthr 172 --date 2024-06-01",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.ftp <= 0 && flags.lthr <= 0 {
+				return errors.New("Give the --ftp, the --lthr or both to record")
+			}
+			if flags.ftp < 0 || flags.lthr < 0 {
+				return errors.New("Thresholds are positive")
+			}
+			date := time.Now()
+			if flags.date != "" {
+				parsed, err := dates.Parse(flags.date)
+				if err != nil {
+					return err
+				}
+				date = parsed
+			}
+			if err := archive.SetThresholds(store.Thresholds{Date: date, FTP: flags.ftp, LTHR: flags.lthr}); err != nil {
+				return err
+			}
+			valid, err := archive.ThresholdsAt(date)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("From %s on: FTP %s, LTHR %s\n", date.Format("2006-01-02"), formatThreshold(valid.FTP, "W"), formatThreshold(valid.LTHR, "bpm"))
+			return nil
+		},
+	}
+
+	command.Flags().Float64Var(&flags.ftp, "ftp", 0, "The functional threshold power, in watts")
+	command.Flags().Float64Var(&flags.lthr, "lthr", 0, "The lactate threshold heart rate, in beats per minute")
+	command.Flags().StringVar(&flags.date, "date", "", "The day the thresholds are valid from (defaults to today)")
+
+	return command
+}
+
+func listCommand(archive *store.Store) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the recorded thresholds",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			history, err := archive.ThresholdHistory()
+			if err != nil {
+				return err
+			}
+			if len(history) == 0 {
+				fmt.Println("No threshold recorded yet, record one with sutro thresholds set")
+				return nil
+			}
+
+			writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "FROM\tFTP\tLTHR")
+			for _, thresholds := range history {
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", thresholds.Date.Format("2006-01-02"), formatThreshold(thresholds.FTP, "W"), formatThreshold(thresholds.LTHR, "bpm"))
+			}
+			return writer.Flush()
+		},
+	}
+}
+
+func formatThreshold(value float64, unit string) string {
+	if value <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f %s", value, unit)
+}
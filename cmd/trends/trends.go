@@ -0,0 +1,160 @@
+package trends
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/chart"
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+type trendsFlags struct {
+	metric       string
+	window       string
+	activityType string
+	width        int
+}
+
+type metric struct {
+	unit      string
+	aggregate func([]*models.SummaryActivity) float64
+	format    func(float64) string
+}
+
+var metrics = map[string]metric{
+	"distance": {
+		unit:      "km",
+		aggregate: totalDistance,
+		format:    func(value float64) string { return fmt.Sprintf("%.1f km", value) },
+	},
+	"avg_hr": {
+		unit:      "bpm",
+		aggregate: averageHeartrate,
+		format:    func(value float64) string { return fmt.Sprintf("%.0f bpm", value) },
+	},
+	"pace": {
+		unit:      "min/km",
+		aggregate: averagePace,
+		format:    format.Pace,
+	},
+}
+
+func Command(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := trendsFlags{}
+
+	command := &cobra.Command{
+		Use:   "trends",
+		Short: "Charts of weekly training trends",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return trends(ctx, apiClient, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.metric, "metric", "distance", "The metric to chart: avg_hr, pace or distance")
+	command.Flags().StringVar(&flags.window, "window", "12w", "How far back to look, in weeks (e.g. 12w)")
+	choice.ActivityTypeVar(command, &flags.activityType, "type", "Only include activities of this type (e.g. Run)")
+	command.Flags().IntVar(&flags.width, "width", 40, "The width of the chart bars, in characters")
+
+	return command
+}
+
+func trends(ctx context.Context, apiClient *strava.Client, flags trendsFlags) error {
+	m, ok := metrics[flags.metric]
+	if !ok {
+		return fmt.Errorf("Unknown metric %q, expected one of avg_hr, pace or distance", flags.metric)
+	}
+
+	weeks, err := parseWindow(flags.window)
+	if err != nil {
+		return err
+	}
+
+	start := startOfWeek(time.Now()).AddDate(0, 0, -7*(weeks-1))
+	summaries, err := apiClient.Activities.List(ctx, strava.ListOptions{After: start}).All()
+	if err != nil {
+		return err
+	}
+
+	buckets := make([][]*models.SummaryActivity, weeks)
+	for _, summary := range summaries {
+		if flags.activityType != "" && !strings.EqualFold(string(summary.Type), flags.activityType) {
+			continue
+		}
+		week := int(startOfWeek(time.Time(summary.StartDateLocal)).Sub(start).Hours() / (24 * 7))
+		if week >= 0 && week < weeks {
+			buckets[week] = append(buckets[week], summary)
+		}
+	}
+
+	labels := make([]string, weeks)
+	values := make([]float64, weeks)
+	for i, bucket := range buckets {
+		labels[i] = start.AddDate(0, 0, 7*i).Format("2006-01-02")
+		values[i] = m.aggregate(bucket)
+	}
+
+	fmt.Printf("Weekly %s (%s), last %d weeks: %s\n\n", flags.metric, m.unit, weeks, chart.Sparkline(values))
+	return chart.Bars(os.Stdout, labels, values, flags.width, m.format)
+}
+
+func parseWindow(window string) (int, error) {
+	if !strings.HasSuffix(window, "w") {
+		return 0, fmt.Errorf("Invalid window %q, expected a number of weeks such as 12w", window)
+	}
+
+	weeks, err := strconv.Atoi(strings.TrimSuffix(window, "w"))
+	if err != nil || weeks <= 0 {
+		return 0, fmt.Errorf("Invalid window %q, expected a number of weeks such as 12w", window)
+	}
+	return weeks, nil
+}
+
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	year, month, day := t.AddDate(0, 0, -offset).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func totalDistance(summaries []*models.SummaryActivity) float64 {
+	total := 0.0
+	for _, summary := range summaries {
+		total += float64(summary.Distance)
+	}
+	return total / 1000
+}
+
+func averageHeartrate(summaries []*models.SummaryActivity) float64 {
+	weighted, duration := 0.0, 0.0
+	for _, summary := range summaries {
+		if !summary.HasHeartrate || summary.AverageHeartrate == 0 {
+			continue
+		}
+		weighted += float64(summary.AverageHeartrate) * float64(summary.MovingTime)
+		duration += float64(summary.MovingTime)
+	}
+	if duration == 0 {
+		return math.NaN()
+	}
+	return weighted / duration
+}
+
+func averagePace(summaries []*models.SummaryActivity) float64 {
+	distance, duration := 0.0, 0.0
+	for _, summary := range summaries {
+		distance += float64(summary.Distance)
+		duration += float64(summary.MovingTime)
+	}
+	if distance == 0 {
+		return math.NaN()
+	}
+	return duration / 60 / (distance / 1000)
+}
@@ -0,0 +1,159 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/jsilland/sutro/description"
+	"github.com/jsilland/sutro/files"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/scopes"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// pollInterval is the time between two checks of an upload being
+// processed, which Strava says takes about 8 seconds on average.
+const pollInterval = 2 * time.Second
+
+type createFlags struct {
+	file        string
+	name        string
+	description string
+	template    string
+	externalID  string
+	trainer     bool
+	commute     bool
+	wait        bool
+}
+
+// Commands returns the hand-written commands that complement the
+// generated uploads client.
+func Commands(ctx context.Context, apiClient *strava.Client) []*cobra.Command {
+	return []*cobra.Command{
+		createCommand(ctx, apiClient),
+	}
+}
+
+func createCommand(ctx context.Context, apiClient *strava.Client) *cobra.Command {
+	flags := createFlags{}
+
+	command := &cobra.Command{
+		Use:   "create",
+		Short: "Upload a FIT, GPX or TCX file to create an activity",
+		Long: "Upload an activity file, optionally gzipped, which is streamed to Strava rather " +
+			"than read in memory. Strava processes uploads asynchronously: --wait polls the upload " +
+			"until it becomes an activity, or until Strava reports why it could not.",
+		Example: "  sutro uploads create --file ride.fit --name 'Morning ride' --wait",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return create(ctx, apiClient, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.file, "file", "", "The FIT, GPX or TCX file to upload, optionally gzipped")
+	command.Flags().StringVar(&flags.name, "name", "", "The name of the activity, instead of the one Strava picks")
+	command.Flags().StringVar(&flags.description, "description", "", "The description of the activity")
+	command.Flags().StringVar(&flags.template, "description-template", "", "A Go template rendering the description from the processed activity, inline or as @path, which implies --wait")
+	command.Flags().StringVar(&flags.externalID, "external-id", "", "An identifier of the file in another system")
+	command.Flags().BoolVar(&flags.trainer, "trainer", false, "Mark the activity as done on a trainer")
+	command.Flags().BoolVar(&flags.commute, "commute", false, "Mark the activity as a commute")
+	command.Flags().BoolVar(&flags.wait, "wait", false, "Wait for Strava to process the upload into an activity")
+	_ = command.MarkFlagRequired("file")
+	_ = command.MarkFlagFilename("file", "fit", "gpx", "tcx", "gz")
+
+	return scopes.Require(command, "activity:write")
+}
+
+func create(ctx context.Context, apiClient *strava.Client, flags createFlags) error {
+	format, gzipped, err := files.Format(flags.file)
+	if err != nil {
+		return err
+	}
+	if flags.description != "" && flags.template != "" {
+		return errors.New("Pass either --description or --description-template")
+	}
+	template, err := description.Read(flags.template, os.Stdin)
+	if err != nil {
+		return err
+	}
+	dataType := format
+	if gzipped {
+		dataType += ".gz"
+	}
+
+	file, err := os.Open(flags.file)
+	if err != nil {
+		return err
+	}
+	upload, err := apiClient.Uploads.Create(ctx, strava.NewUpload{
+		File:        progress.NewReader(file, fmt.Sprintf("Uploading %s", path.Base(flags.file))),
+		DataType:    dataType,
+		Name:        flags.name,
+		Description: flags.description,
+		ExternalID:  flags.externalID,
+		Trainer:     flags.trainer,
+		Commute:     flags.commute,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to upload %s: %v", flags.file, err)
+	}
+
+	// The template renders the activity, which only exists once Strava
+	// processed the upload.
+	if !flags.wait && template == nil {
+		fmt.Printf("Uploaded %s as upload %d: %s\n", flags.file, upload.ID, upload.Status)
+		return nil
+	}
+
+	upload, err = waitForActivity(ctx, apiClient, upload)
+	if err != nil {
+		return err
+	}
+	history.Returned(ctx, upload.ActivityID)
+	if template != nil {
+		activity, err := apiClient.Activities.Get(ctx, upload.ActivityID)
+		if err != nil {
+			return fmt.Errorf("Uploaded %s as activity %d, but failed to read it: %v", flags.file, upload.ActivityID, err)
+		}
+		if _, err := template.Apply(ctx, apiClient, activity); err != nil {
+			return fmt.Errorf("Uploaded %s as activity %d without its description: %v", flags.file, upload.ActivityID, err)
+		}
+	}
+	fmt.Printf("Uploaded %s as activity %d\n", flags.file, upload.ActivityID)
+	return nil
+}
+
+// waitForActivity polls an upload until Strava processed it into an
+// activity, or failed to.
+func waitForActivity(ctx context.Context, apiClient *strava.Client, upload *models.Upload) (*models.Upload, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if upload.Error != "" {
+			return nil, fmt.Errorf("Strava could not process upload %d: %s", upload.ID, upload.Error)
+		}
+		if upload.ActivityID != 0 {
+			return upload, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("Interrupted before Strava processed the upload, check it with sutro uploads get")
+		case <-ticker.C:
+		}
+
+		checked, err := apiClient.Uploads.Get(ctx, upload.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to check upload %d: %v", upload.ID, err)
+		}
+		upload = checked
+	}
+}
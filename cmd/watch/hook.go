@@ -0,0 +1,41 @@
+package watch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jsilland/sutro/notify"
+	"github.com/jsilland/sutro/shell"
+)
+
+// hook runs a shell command for each new activity, with the activity as
+// JSON on its standard input.
+type hook struct {
+	command string
+}
+
+func (h hook) Send(event notify.Event) error {
+	if event.Kind != notify.NewActivity {
+		return nil
+	}
+	activity := event.Activity
+
+	data, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+
+	cmd := shell.Command(h.command, map[string]string{
+		"id":   strconv.FormatInt(activity.ID, 10),
+		"type": string(activity.Type),
+		"name": activity.Name,
+	})
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(cmd.Env, fmt.Sprintf("SUTRO_ACTIVITY_ID=%d", activity.ID))
+	return cmd.Run()
+}
@@ -0,0 +1,249 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/budget"
+	"github.com/jsilland/sutro/cmd/synchronize"
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/lock"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/notify"
+	"github.com/jsilland/sutro/rules"
+	"github.com/jsilland/sutro/scopes"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// recentActivities is how many of the latest activities are checked for
+// new kudos on each poll.
+const recentActivities = 30
+
+type watchFlags struct {
+	interval time.Duration
+	exec     string
+	once     bool
+	desktop  bool
+	kudos    bool
+	webhooks map[string]notify.Sink
+	rules    *rules.Engine
+	budget   budget.Flags
+	instance lock.Flags
+}
+
+func Command(ctx context.Context, apiClient *strava.Client, archive *store.Store, configuration config.Configuration, stateDirectory string) *cobra.Command {
+	flags := watchFlags{}
+	notifications := configuration.Notifications()
+
+	command := &cobra.Command{
+		Use:   "watch",
+		Short: "Poll for new activities and run a hook for each of them",
+		Long: "Poll Strava for activities newer than the most recent one in the archive, add them " +
+			"to the archive and run the --exec hook for each one, with the activity as JSON on " +
+			"its standard input. {id}, {type} and {name} in the hook are replaced with the " +
+			"quoted values of the activity; on Windows the hook runs with cmd /V:ON, in which " +
+			"!VARIABLE! expands variables. Desktop notifications and kudos default to the " +
+			"notifications settings of ~/.sutro, and events are also posted to the webhooks " +
+			"configured with sutro notify. The rules of sutro rules are applied to new activities " +
+			"before they are reported.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags.webhooks = notify.Webhooks(notifications)
+			l, err := flags.instance.Acquire(ctx, stateDirectory, "watch")
+			if err != nil {
+				return err
+			}
+			defer l.Release()
+			b, err := flags.budget.Apply(apiClient)
+			if err != nil {
+				return err
+			}
+			if flags.rules, err = rules.New(ctx, apiClient, archive, configuration.Rules()); err != nil {
+				return err
+			}
+			// Applying rules updates the new activities.
+			if !flags.rules.Empty() {
+				if err := scopes.Check(scopes.Require(cmd, "activity:write"), configuration.OAuthConfiguration().Scopes); err != nil {
+					return err
+				}
+			}
+			return watch(ctx, apiClient, archive, b, flags)
+		},
+	}
+
+	command.Flags().DurationVar(&flags.interval, "interval", 15*time.Minute, "How often to poll for new activities")
+	command.Flags().StringVar(&flags.exec, "exec", "", "The shell command to run for each new activity (e.g. './notify.sh {id}')")
+	command.Flags().BoolVar(&flags.once, "once", false, "Poll a single time and exit, for running from cron")
+	command.Flags().BoolVar(&flags.desktop, "desktop", notifications.Desktop, "Show a desktop notification for each event")
+	command.Flags().BoolVar(&flags.kudos, "kudos", notifications.Kudos, "Also report the kudos received by recent activities")
+	flags.budget.Register(command)
+	flags.instance.Register(command)
+
+	return command
+}
+
+func watch(ctx context.Context, apiClient *strava.Client, archive *store.Store, b *strava.Budget, flags watchFlags) error {
+	if flags.interval < time.Minute {
+		return errors.New("The interval must be at least a minute, to stay within the API rate limits")
+	}
+
+	sinks := []notify.Sink{console{}}
+	if flags.exec != "" {
+		sinks = append(sinks, hook{flags.exec})
+	}
+	if flags.desktop {
+		sinks = append(sinks, notify.Desktop{})
+	}
+	for _, webhook := range flags.webhooks {
+		sinks = append(sinks, webhook)
+	}
+
+	// Without a baseline, every activity ever recorded would look new.
+	latest, err := archive.LatestActivityStart()
+	if err != nil {
+		return err
+	}
+	if latest.IsZero() {
+		fmt.Println("The archive is empty, synchronizing it before watching for new activities")
+		if _, err := synchronize.New(ctx, apiClient, archive); b.Stopped(err) {
+			fmt.Printf("%s, run sutro sync to finish synchronizing the archive\n", budget.Describe(b))
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// The archive holds the activities seen so far, so a watch stopped by
+	// its budget picks up the activities it missed on its next run.
+	for {
+		err := poll(ctx, apiClient, archive, flags.rules, flags.kudos, sinks)
+		if b.Stopped(err) {
+			fmt.Printf("%s, the next watch will report the activities recorded in the meantime\n", budget.Describe(b))
+			return nil
+		}
+		if err != nil {
+			if flags.once {
+				return err
+			}
+			log.Printf("Polling failed, retrying in %s: %v", flags.interval, err)
+		}
+		if flags.once {
+			return nil
+		}
+		if b.ExhaustedAt(time.Now().Add(flags.interval)) {
+			fmt.Printf("%s, the next watch will report the activities recorded in the meantime\n", budget.Describe(b))
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(flags.interval):
+		}
+	}
+}
+
+// poll applies the rules to the new activities, then sends them, oldest
+// first, and the new kudos to every sink. The failures of rules and sinks
+// are logged without stopping the watch.
+func poll(ctx context.Context, apiClient *strava.Client, archive *store.Store, engine *rules.Engine, kudos bool, sinks []notify.Sink) error {
+	var events []notify.Event
+	if kudos {
+		// Kudos are compared before synchronizing, so that the activities
+		// new to the archive do not report all of theirs.
+		kudosEvents, err := newKudos(ctx, apiClient, archive)
+		if err != nil {
+			return err
+		}
+		events = kudosEvents
+	}
+
+	// The activities archived before an error are still reported, as the
+	// next poll will not see them as new.
+	synced, syncErr := synchronize.New(ctx, apiClient, archive)
+	sort.Slice(synced, func(i, j int) bool {
+		return time.Time(synced[i].StartDate).Before(time.Time(synced[j].StartDate))
+	})
+
+	for i, activity := range synced {
+		if updated, err := applyRules(ctx, engine, activity); err != nil {
+			log.Printf("Applying the rules to activity %d failed: %v", activity.ID, err)
+		} else {
+			synced[i] = updated
+		}
+	}
+
+	newActivities := make([]notify.Event, 0, len(synced))
+	for _, activity := range synced {
+		newActivities = append(newActivities, notify.Event{Kind: notify.NewActivity, Activity: activity})
+	}
+	events = append(newActivities, events...)
+
+	for _, event := range events {
+		for _, sink := range sinks {
+			if err := sink.Send(event); err != nil {
+				log.Printf("Notifying about activity %d failed: %v", event.Activity.ID, err)
+			}
+		}
+	}
+	return syncErr
+}
+
+// applyRules applies the rules to activity, and returns it as updated.
+func applyRules(ctx context.Context, engine *rules.Engine, activity *models.SummaryActivity) (*models.SummaryActivity, error) {
+	change, err := engine.Plan(ctx, activity)
+	if err != nil || change == nil {
+		return activity, err
+	}
+	updated, err := engine.Apply(ctx, change)
+	if err != nil {
+		return activity, err
+	}
+	fmt.Printf("%s\t%d\tApplied %s: %s\n", time.Now().Format("2006-01-02 15:04"), activity.ID, strings.Join(change.Rules, ", "), change)
+	return updated, nil
+}
+
+// newKudos compares the kudos of the most recent activities with their
+// archived counts, and archives the current counts.
+func newKudos(ctx context.Context, apiClient *strava.Client, archive *store.Store) ([]notify.Event, error) {
+	var events []notify.Event
+	var known []*models.SummaryActivity
+	iterator := apiClient.Activities.List(ctx, strava.ListOptions{PerPage: recentActivities})
+	for checked := 0; checked < recentActivities && iterator.Next(); checked++ {
+		activity := iterator.Value()
+		archived, err := archive.Activity(activity.ID)
+		if err != nil {
+			return nil, err
+		}
+		if archived == nil {
+			continue
+		}
+		if received := activity.KudosCount - archived.KudosCount; received > 0 {
+			events = append(events, notify.Event{Kind: notify.NewKudos, Activity: activity, Kudos: received})
+		}
+		known = append(known, activity)
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := archive.PutActivities(known); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// console prints events on stdout.
+type console struct{}
+
+func (console) Send(event notify.Event) error {
+	fmt.Printf("%s\t%d\t%s · %s\n", time.Now().Format("2006-01-02 15:04"), event.Activity.ID, event.Title(), event.Body())
+	return nil
+}
@@ -0,0 +1,165 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jsilland/sutro/choice"
+	"github.com/jsilland/sutro/failure"
+	"github.com/jsilland/sutro/webhook"
+	"github.com/spf13/cobra"
+)
+
+type listFlags struct {
+	status string
+	limit  int
+	format string
+}
+
+type replayFlags struct {
+	exec   string
+	failed bool
+}
+
+func eventsCommand(queue *webhook.Queue) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "events",
+		Short: "List and replay the events received",
+		Long: "Every event sutro webhooks serve receives is kept in the queue of the profile in " +
+			"~/.sutro.d, with the outcome of each time its hook ran, so that the events whose hook " +
+			"failed or that were received while the hook was broken can be processed again.",
+	}
+
+	list := listFlags{}
+	listCommand := &cobra.Command{
+		Use:   "list",
+		Short: "List the events received, most recent last",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listEvents(queue, list)
+		},
+	}
+	choice.Var(listCommand, &list.status, "status", "", "Only list the events with this status: pending, done or failed", "", string(webhook.Pending), string(webhook.Done), string(webhook.Failed))
+	listCommand.Flags().IntVar(&list.limit, "limit", 50, "The number of events to list, or 0 for all of them")
+	choice.Var(listCommand, &list.format, "format", "table", "The format of the list: table or json", "table", "json")
+
+	replay := replayFlags{}
+	replayCommand := &cobra.Command{
+		Use:   "replay <id>...",
+		Short: "Run the hook again for events received",
+		Long: "Run the hook again for the events with the given ids, or for every event that failed " +
+			"with --failed, once each. The hook is the one given with --exec, or the one the event " +
+			"was received with.",
+		Example: "  sutro webhooks events replay 42\n" +
+			"  sutro webhooks events replay --failed --exec 'sutro sync'",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !replay.failed {
+				return failure.UsageError(errors.New("Expected the ids of the events to replay, or --failed"))
+			}
+			return replayEvents(queue, args, replay)
+		},
+	}
+	replayCommand.Flags().StringVar(&replay.exec, "exec", "", "The shell command to run for each event instead of the one it was received with")
+	replayCommand.Flags().BoolVar(&replay.failed, "failed", false, "Replay every event that failed")
+
+	command.AddCommand(listCommand, replayCommand)
+	return command
+}
+
+func listEvents(queue *webhook.Queue, flags listFlags) error {
+	entries, err := queue.Entries()
+	if err != nil {
+		return err
+	}
+	var selected []webhook.Entry
+	for _, entry := range entries {
+		if flags.status == "" || string(entry.Status) == flags.status {
+			selected = append(selected, entry)
+		}
+	}
+	if flags.limit > 0 && len(selected) > flags.limit {
+		selected = selected[len(selected)-flags.limit:]
+	}
+
+	if flags.format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range selected {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("No event in the queue")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tRECEIVED\tEVENT\tATTEMPTS\tSTATUS\tERROR")
+	for _, entry := range selected {
+		status := string(entry.Status)
+		if entry.RetryAt != nil {
+			status += ", retried at " + entry.RetryAt.Local().Format("15:04:05")
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s %s %d\t%d\t%s\t%s\n", entry.ID, entry.Received.Local().Format("2006-01-02 15:04:05"),
+			entry.Event.AspectType, entry.Event.ObjectType, entry.Event.ObjectID, entry.Attempts, status, entry.Error)
+	}
+	return writer.Flush()
+}
+
+func replayEvents(queue *webhook.Queue, ids []string, flags replayFlags) error {
+	var entries []webhook.Entry
+	for _, arg := range ids {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return failure.UsageError(fmt.Errorf("Invalid event id %q", arg))
+		}
+		entry, err := queue.Entry(id)
+		if err != nil {
+			return failure.UsageError(err)
+		}
+		entries = append(entries, entry)
+	}
+	if flags.failed {
+		all, err := queue.Entries()
+		if err != nil {
+			return err
+		}
+		for _, entry := range all {
+			if entry.Status == webhook.Failed {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	failed := 0
+	for _, entry := range entries {
+		command := flags.exec
+		if command == "" {
+			command = entry.Hook
+		}
+		if command == "" {
+			return failure.UsageError(fmt.Errorf("Event %d was received without a hook, pass --exec", entry.ID))
+		}
+		err := hook{command: command}.run(entry.Event)
+		if recordErr := queue.Record(entry.ID, err, time.Time{}); recordErr != nil {
+			return recordErr
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("Event %d failed again: %v\n", entry.ID, err)
+			continue
+		}
+		fmt.Printf("Replayed event %d, the %s event of %s %d\n", entry.ID, entry.Event.AspectType, entry.Event.ObjectType, entry.Event.ObjectID)
+	}
+	if failed > 0 {
+		return fmt.Errorf("The hook failed for %d of the %d events replayed", failed, len(entries))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jsilland/sutro/shell"
+	"github.com/jsilland/sutro/webhook"
+)
+
+// hook runs a shell command for each event, with the event as JSON on its
+// standard input.
+type hook struct {
+	command string
+}
+
+func (h hook) run(event webhook.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	cmd := shell.Command(h.command, map[string]string{
+		"id":     strconv.FormatInt(event.ObjectID, 10),
+		"object": event.ObjectType,
+		"aspect": event.AspectType,
+	})
+	cmd.Stdin = bytes.NewReader(data)
+	// The standard output prints the events received.
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(cmd.Env,
+		fmt.Sprintf("SUTRO_OBJECT_ID=%d", event.ObjectID),
+		"SUTRO_OBJECT_TYPE="+event.ObjectType,
+		"SUTRO_ASPECT_TYPE="+event.AspectType,
+	)
+	return cmd.Run()
+}
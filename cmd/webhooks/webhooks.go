@@ -0,0 +1,305 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/failure"
+	"github.com/jsilland/sutro/httpserver"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/tunnel"
+	"github.com/jsilland/sutro/webhook"
+	"github.com/spf13/cobra"
+)
+
+// unsubscribeTimeout bounds how long deleting the subscription of the
+// tunnel may take once the command is interrupted.
+const unsubscribeTimeout = 10 * time.Second
+
+type serveFlags struct {
+	host           string
+	port           int
+	path           string
+	verifyToken    string
+	subscriptionID int64
+	allow          []string
+	behindProxy    bool
+	exec           string
+	retry          webhook.Retry
+	tunnel         bool
+	tunnelServer   string
+	replace        bool
+}
+
+// Command returns the webhooks command, which receives the events of the
+// push subscription of the application of configuration into the queue of
+// the state directory.
+func Command(ctx context.Context, apiClient *strava.Client, configuration config.Configuration, stateDirectory string) *cobra.Command {
+	queue := webhook.OpenQueue(webhook.QueuePath(stateDirectory))
+	command := &cobra.Command{
+		Use:   "webhooks",
+		Short: "Receive the events Strava pushes to the application",
+	}
+	command.AddCommand(serveCommand(ctx, apiClient, configuration, queue))
+	command.AddCommand(eventsCommand(queue))
+	return command
+}
+
+func serveCommand(ctx context.Context, apiClient *strava.Client, configuration config.Configuration, queue *webhook.Queue) *cobra.Command {
+	flags := serveFlags{}
+
+	command := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the callback of the push subscription and print its events",
+		Long: "Serve the callback URL of the push subscription of the application, which Strava " +
+			"posts an event to whenever an athlete who authorized it creates, updates or deletes " +
+			"an activity, or revokes its access. Each event is printed as a line of JSON and " +
+			"appended to the queue of the profile in ~/.sutro.d before it is acknowledged, then " +
+			"passed to the --exec hook on its standard input, with {id}, {object} and {aspect} " +
+			"in the hook replaced with its quoted values, run with cmd /V:ON on Windows. Events " +
+			"whose hook fails are retried --retries times, waiting --retry-delay and twice as " +
+			"long each time after, and those left " +
+			"pending when sutro stopped are processed with the hook they were received with when " +
+			"it serves again. Events received without --exec stay pending. sutro webhooks events " +
+			"lists the queue and replays its events.\n\n" +
+			"Strava does not sign its events, so they are checked instead: the validation of the " +
+			"subscription must give --verify-token when it is set, events must come from the " +
+			"subscription of the application, looked up unless --subscription-id is given, and " +
+			"requests must come from the --allow networks when they are set. Behind a reverse " +
+			"proxy, --behind-proxy takes the address of clients from the X-Forwarded-For header " +
+			"the proxy sets, which must not be trusted otherwise.\n\n" +
+			"To try webhooks on a machine Strava cannot reach, --tunnel opens a public tunnel " +
+			"through a localtunnel server, subscribes the application to the events posted to it " +
+			"with a random verify token, and deletes the subscription when interrupted. Strava " +
+			"only allows one subscription per application, so an existing one is only replaced " +
+			"with --replace-subscription.",
+		Example: "  sutro webhooks serve --host 0.0.0.0 --verify-token \"$TOKEN\" --exec 'sutro sync'\n" +
+			"  sutro webhooks serve --tunnel --exec 'jq .'",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(ctx, apiClient, configuration, queue, flags)
+		},
+	}
+
+	command.Flags().StringVar(&flags.host, "host", "localhost", "The address to listen on, which Strava must be able to reach directly or through a proxy")
+	command.Flags().IntVar(&flags.port, "port", 9877, "The port to listen on")
+	command.Flags().StringVar(&flags.path, "path", "/webhook", "The path of the callback URL")
+	command.Flags().StringVar(&flags.verifyToken, "verify-token", "", "The token given when creating the subscription, which its validation must send back")
+	command.Flags().Int64Var(&flags.subscriptionID, "subscription-id", 0, "The subscription whose events are accepted, instead of looking it up")
+	command.Flags().StringSliceVar(&flags.allow, "allow", nil, "The addresses or CIDR networks requests are accepted from, any by default")
+	command.Flags().BoolVar(&flags.behindProxy, "behind-proxy", false, "Take the address of clients from the X-Forwarded-For header of a reverse proxy")
+	command.Flags().StringVar(&flags.exec, "exec", "", "The shell command to run for each event, with the event as JSON on its standard input")
+	command.Flags().IntVar(&flags.retry.Retries, "retries", 3, "How many times to run the hook again for an event it failed for")
+	command.Flags().DurationVar(&flags.retry.Delay, "retry-delay", 30*time.Second, "How long to wait before running the hook again the first time")
+	command.Flags().BoolVar(&flags.tunnel, "tunnel", false, "Serve through a public tunnel, subscribing to the events posted to it until interrupted")
+	command.Flags().StringVar(&flags.tunnelServer, "tunnel-server", tunnel.DefaultServer, "The localtunnel server to open the tunnel through")
+	command.Flags().BoolVar(&flags.replace, "replace-subscription", false, "Delete the existing subscription of the application to subscribe through the tunnel")
+
+	return command
+}
+
+func serve(ctx context.Context, apiClient *strava.Client, configuration config.Configuration, queue *webhook.Queue, flags serveFlags) error {
+	allowed, err := webhook.ParseNetworks(flags.allow)
+	if err != nil {
+		return failure.UsageError(err)
+	}
+	if flags.tunnel && flags.subscriptionID != 0 {
+		return failure.UsageError(errors.New("--tunnel subscribes to the events posted to the tunnel, so --subscription-id cannot be given"))
+	}
+	subscriptionID := flags.subscriptionID
+	if subscriptionID == 0 {
+		existing, err := lookup(ctx, apiClient, configuration)
+		if err != nil {
+			return err
+		}
+		switch {
+		case flags.tunnel && existing != nil && !flags.replace:
+			return fmt.Errorf("The application already has subscription %d, whose callback is %s, and Strava only allows one: pass --replace-subscription to delete it", existing.ID, existing.CallbackURL)
+		case flags.tunnel && existing != nil:
+			if err := unsubscribe(ctx, apiClient, configuration, existing.ID); err != nil {
+				return err
+			}
+		case flags.tunnel:
+		case existing != nil:
+			subscriptionID = existing.ID
+			fmt.Fprintf(os.Stderr, "Accepting the events of subscription %d, whose callback is %s\n", existing.ID, existing.CallbackURL)
+		default:
+			fmt.Fprintln(os.Stderr, "Warning: the application has no push subscription yet, so the events of any subscription are accepted")
+		}
+	}
+	verifyToken := flags.verifyToken
+	if flags.tunnel && verifyToken == "" {
+		if verifyToken, err = randomToken(); err != nil {
+			return err
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	receiver := &webhook.Receiver{
+		VerifyToken:    verifyToken,
+		SubscriptionID: subscriptionID,
+		Allowed:        allowed,
+		BehindProxy:    flags.behindProxy,
+		// Events are processed once acknowledged, as Strava gives up on
+		// those it has no response for within two seconds.
+		Handle: func(event webhook.Event) error {
+			if _, err := queue.Add(event, flags.exec); err != nil {
+				return err
+			}
+			return encoder.Encode(event)
+		},
+	}
+	// Events are processed with the hook they were received with, which
+	// events left pending by a previous run may not share. Those received
+	// without one stay pending, for webhooks events replay --exec.
+	withoutHook := map[int64]bool{}
+	process := func(entry webhook.Entry) error {
+		if entry.Hook == "" {
+			if !withoutHook[entry.ID] {
+				withoutHook[entry.ID] = true
+				log.Printf("Event %d was received without a hook, it is left pending", entry.ID)
+			}
+			return webhook.ErrLeftPending
+		}
+		err := hook{command: entry.Hook}.run(entry.Event)
+		if err != nil {
+			log.Printf("The hook failed for event %d, the %s event of %s %d: %v", entry.ID, entry.Event.AspectType, entry.Event.ObjectType, entry.Event.ObjectID, err)
+		}
+		return err
+	}
+
+	address := net.JoinHostPort(flags.host, strconv.Itoa(flags.port))
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	processed := make(chan error, 1)
+	go func() {
+		processed <- queue.Process(ctx, process, flags.retry)
+		// The events received could not be processed anymore.
+		cancel()
+	}()
+	mux := http.NewServeMux()
+	mux.Handle(flags.path, receiver)
+	// The server stops after the tunnel closed, as it waits for the idle
+	// connections of the tunnel otherwise.
+	serveCtx, stopServing := context.WithCancel(context.Background())
+	defer stopServing()
+	served := make(chan error, 1)
+	go func() {
+		served <- httpserver.Serve(serveCtx, listener, mux)
+	}()
+	fmt.Fprintf(os.Stderr, "Receiving the events of the push subscription at http://%s%s\n", address, flags.path)
+
+	// Strava validates the callback of a subscription before creating it,
+	// so the subscription is created once the receiver serves.
+	teardown := func() {}
+	if flags.tunnel {
+		if teardown, err = subscribe(ctx, apiClient, configuration, receiver, listener.Addr().String(), flags); err != nil {
+			cancel()
+			stopServing()
+			<-served
+			<-processed
+			return err
+		}
+	}
+
+	select {
+	case err = <-served:
+		teardown()
+	case <-ctx.Done():
+		teardown()
+		stopServing()
+		err = <-served
+	}
+	cancel()
+	if processErr := <-processed; processErr != nil {
+		return processErr
+	}
+	return err
+}
+
+// subscribe opens a tunnel to the receiver at address and subscribes the
+// application to the events posted to it, expected by receiver. It returns
+// the function deleting the subscription and closing the tunnel.
+func subscribe(ctx context.Context, apiClient *strava.Client, configuration config.Configuration, receiver *webhook.Receiver, address string, flags serveFlags) (func(), error) {
+	var provider tunnel.Provider = tunnel.Localtunnel(flags.tunnelServer)
+	t, err := provider.Open(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	callback := strings.TrimSuffix(t.URL(), "/") + flags.path
+
+	oauth := configuration.OAuthConfiguration()
+	subscription, err := apiClient.Subscriptions.Create(ctx, oauth.ClientID, oauth.ClientSecret, callback, receiver.VerifyToken)
+	if err != nil {
+		t.Close()
+		return nil, fmt.Errorf("Unable to subscribe to the events posted to %s: %w", callback, err)
+	}
+	receiver.Expect(subscription.ID)
+	fmt.Fprintf(os.Stderr, "Subscribed to the events posted to %s, as subscription %d\n", callback, subscription.ID)
+
+	return func() {
+		// The context of the command is done once it is interrupted.
+		deleteCtx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+		defer cancel()
+		if err := unsubscribe(deleteCtx, apiClient, configuration, subscription.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+		t.Close()
+	}, nil
+}
+
+// unsubscribe deletes the subscription with the given id.
+func unsubscribe(ctx context.Context, apiClient *strava.Client, configuration config.Configuration, id int64) error {
+	oauth := configuration.OAuthConfiguration()
+	if err := apiClient.Subscriptions.Delete(ctx, id, oauth.ClientID, oauth.ClientSecret); err != nil {
+		return fmt.Errorf("Unable to delete subscription %d: %w", id, err)
+	}
+	fmt.Fprintf(os.Stderr, "Deleted subscription %d\n", id)
+	return nil
+}
+
+// randomToken returns a verify token for the subscriptions sutro creates.
+func randomToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// lookup returns the push subscription of the application, or nil when it
+// has none yet, as is the case until the callback is served.
+func lookup(ctx context.Context, apiClient *strava.Client, configuration config.Configuration) (*strava.Subscription, error) {
+	oauth := configuration.OAuthConfiguration()
+	subscriptions, err := apiClient.Subscriptions.List(ctx, oauth.ClientID, oauth.ClientSecret)
+	// Subscriptions are authenticated by the client id and secret in
+	// ~/.sutro, not by the token.
+	var unauthorized *strava.UnauthorizedError
+	if errors.As(err, &unauthorized) {
+		return nil, failure.ConfigurationError(fmt.Errorf("Strava refused the client id and secret of the application in ~/.sutro: %w", err))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to look up the push subscription of the application, pass --subscription-id to skip it: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+	return subscriptions[0], nil
+}
@@ -7,10 +7,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/user"
 	"path"
 	"strings"
 
+	"github.com/jsilland/sutro/geo"
 	"golang.org/x/oauth2"
 )
 
@@ -25,24 +25,61 @@ type ConfigurationSink interface {
 type ConfigurationBridge interface {
 	ConfigurationSource
 	ConfigurationSink
+	// Path is the file the configuration is read from and saved to.
+	Path() string
+	// Profile names the configuration within the ones of the athlete.
+	Profile() string
 }
 
+// DefaultProfile names the configuration of the dotfile. Other profiles,
+// such as one per athlete a coach follows, keep their configuration in the
+// state directory.
+const DefaultProfile = "default"
+
 func NewDotFileConfiguration(filename string) (ConfigurationBridge, error) {
 	if !strings.HasPrefix(filename, ".") {
 		filename = fmt.Sprintf(".%s", filename)
 	}
 
-	u, err := user.Current()
+	home, err := os.UserHomeDir()
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &fileConfiguration{path.Join(u.HomeDir, filename)}, nil
+	return &fileConfiguration{path: path.Join(home, filename), profile: DefaultProfile}, nil
+}
+
+// NewStateDirectory returns the path of the directory in which sutro keeps
+// local state such as its activity archive, creating it if needed.
+func NewStateDirectory(name string) (string, error) {
+	if !strings.HasPrefix(name, ".") {
+		name = fmt.Sprintf(".%s", name)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	directory := path.Join(home, fmt.Sprintf("%s.d", name))
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return "", err
+	}
+	return directory, nil
 }
 
 type fileConfiguration struct {
-	path string
+	path    string
+	profile string
+}
+
+func (fcs *fileConfiguration) Path() string {
+	return fcs.path
+}
+
+func (fcs *fileConfiguration) Profile() string {
+	return fcs.profile
 }
 
 func (fcs *fileConfiguration) Get() (Configuration, error) {
@@ -87,7 +124,19 @@ func (fcs *fileConfiguration) Save(ctx context.Context, c Configuration) error {
 			AuthURL:  oAuthConfig.Endpoint.AuthURL,
 			TokenURL: oAuthConfig.Endpoint.TokenURL,
 		},
-		Token: *token,
+		Token:        *token,
+		Scopes:       oAuthConfig.Scopes,
+		Zones:        newPrivacyZones(c.PrivacyZones()),
+		Notify:       c.Notifications(),
+		CacheSize:    c.CacheMaxSize(),
+		Shortcuts:    c.Aliases(),
+		Targets:      c.Goals(),
+		Automation:   c.Rules(),
+		Zone:         c.Timezone(),
+		Browser:      c.BrowserCommand(),
+		FlagDefaults: c.Defaults(),
+		Batches:      c.Jobs(),
+		TokenWarning: c.TokenExpiryWarning(),
 	}
 
 	file, err := os.OpenFile(fcs.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
@@ -112,20 +161,182 @@ func NewConfiguration(oAuthConfiguration oauth2.Config, token oauth2.Token) Conf
 			AuthURL:  oAuthConfiguration.Endpoint.AuthURL,
 			TokenURL: oAuthConfiguration.Endpoint.TokenURL,
 		},
-		Token: token,
+		Token:  token,
+		Scopes: oAuthConfiguration.Scopes,
 	}
 }
 
+// WithToken returns the settings of previous with the credentials and token
+// of an authorization granted again.
+func WithToken(previous Configuration, oAuthConfiguration oauth2.Config, token oauth2.Token) Configuration {
+	c := NewConfiguration(oAuthConfiguration, token).(*configuration)
+	c.Zones = newPrivacyZones(previous.PrivacyZones())
+	c.Notify = previous.Notifications()
+	c.CacheSize = previous.CacheMaxSize()
+	c.Shortcuts = previous.Aliases()
+	c.Targets = previous.Goals()
+	c.Automation = previous.Rules()
+	c.Zone = previous.Timezone()
+	c.Browser = previous.BrowserCommand()
+	c.FlagDefaults = previous.Defaults()
+	c.Batches = previous.Jobs()
+	c.TokenWarning = previous.TokenExpiryWarning()
+	return c
+}
+
 type Configuration interface {
+	// OAuthConfiguration holds the credentials of the application, and the
+	// scopes the athlete granted unless they are unknown, as they are for
+	// configurations saved before sutro remembered them.
 	OAuthConfiguration() *oauth2.Config
 	TokenSource(context.Context) oauth2.TokenSource
+	// SavedToken is the token as saved, before TokenSource refreshes it.
+	SavedToken() oauth2.Token
+	// PrivacyZones are the areas, typically around home or work, whose
+	// points are scrubbed from exported tracks.
+	PrivacyZones() []geo.Zone
+	// Notifications are the notifications sutro watch sends besides
+	// running its hook.
+	Notifications() Notifications
+	SetNotifications(Notifications)
+	// CacheMaxSize is the size the cache of streams is capped at, such as
+	// 2GB, or empty for the default.
+	CacheMaxSize() string
+	// Aliases are the shortcuts the root command expands before running
+	// them, such as week for digest --period week, by name.
+	Aliases() map[string]string
+	SetAliases(map[string]string)
+	// Goals are the weekly training goals of the athlete, which sutro coach
+	// report compares their training with.
+	Goals() Goals
+	// Rules are the rules naming, describing and equipping activities,
+	// which sutro rules apply and sutro watch apply, in order.
+	Rules() []Rule
+	// Timezone is the time zone dates are read and displayed in, such as
+	// Europe/Paris, or empty for the local time zone of the system.
+	Timezone() string
+	// BrowserCommand is the command opening URLs, such as
+	// firefox --private-window %s, or empty for the default browser.
+	BrowserCommand() string
+	// Defaults are the values of the flags commands run with unless given,
+	// by path of command such as activities list and by name of flag.
+	Defaults() map[string]map[string]interface{}
+	// Jobs are the sequences of commands sutro jobs run runs, by name.
+	Jobs() map[string]Job
+	// TokenExpiryWarning is how long before a token that cannot be
+	// refreshed expires commands warn about it, such as 72h, or empty for
+	// the default.
+	TokenExpiryWarning() string
+}
+
+// Goals are weekly training goals, each of which is unset when empty.
+type Goals struct {
+	Activities int `json:"activities,omitempty"`
+	// Distance is a distance such as 40km, and MovingTime a duration such
+	// as 5h.
+	Distance   string `json:"distance,omitempty"`
+	MovingTime string `json:"moving_time,omitempty"`
+}
+
+// Rule names, describes or equips the activities meeting all of its
+// conditions, each of which is unset when empty.
+type Rule struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type,omitempty"`
+	// After and Before bound the local time of day activities start at,
+	// such as 06:30.
+	After  string `json:"after,omitempty"`
+	Before string `json:"before,omitempty"`
+	// MinDistance and MaxDistance are distances such as 5km.
+	MinDistance string `json:"min_distance,omitempty"`
+	MaxDistance string `json:"max_distance,omitempty"`
+	// Route is the id of an activity following a route, which activities
+	// must follow within Tolerance, 100m unless set.
+	Route     int64  `json:"route,omitempty"`
+	Tolerance string `json:"tolerance,omitempty"`
+	// DefaultName only matches the activities still named as Strava names
+	// them, such as Morning Ride.
+	DefaultName bool `json:"default_name,omitempty"`
+	// Days are the days of the week activities start on, such as Monday,
+	// or weekdays and weekends.
+	Days []string `json:"days,omitempty"`
+	// Commute and Trainer match the activities flagged, or not flagged, as
+	// commutes or as recorded on a trainer.
+	Commute *bool `json:"commute,omitempty"`
+	Trainer *bool `json:"trainer,omitempty"`
+
+	// Title and Description are templates as the ones of
+	// --description-template, and Gear the id of a gear such as b1234 or
+	// the name of one of the bikes or shoes of the athlete.
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Gear        string `json:"gear,omitempty"`
+}
+
+// Job is a sequence of sutro commands run one after the other, within a
+// budget shared by all of them.
+type Job struct {
+	// Steps are the command lines of the commands, without sutro, such as
+	// sync --since 1d.
+	Steps []string `json:"steps"`
+	// Budget is the number of requests the steps may send, such as 500req,
+	// and MaxDuration the time they may send them for, such as 1h. Either
+	// is unlimited when empty.
+	Budget      string `json:"budget,omitempty"`
+	MaxDuration string `json:"max_duration,omitempty"`
+	// ContinueOnError runs the steps after a failed one instead of stopping.
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+}
+
+// Notifications toggles the notifications of sutro watch.
+type Notifications struct {
+	// Desktop shows native desktop notifications.
+	Desktop bool `json:"desktop,omitempty"`
+	// Kudos also reports the kudos received by recent activities.
+	Kudos bool `json:"kudos,omitempty"`
+	// Slack and Discord are the incoming webhooks events are posted to.
+	Slack   string `json:"slack_webhook_url,omitempty"`
+	Discord string `json:"discord_webhook_url,omitempty"`
+	// MapsKey is the Google Static Maps API key used to attach a map
+	// thumbnail to the activities posted to webhooks.
+	MapsKey string `json:"static_maps_key,omitempty"`
 }
 
 type configuration struct {
-	ClientID     string       `json:"client_id"`
-	ClientSecret string       `json:"client_secret"`
-	Endpoints    endpoints    `json:"endpoints"`
-	Token        oauth2.Token `json:"token"`
+	ClientID     string                            `json:"client_id"`
+	ClientSecret string                            `json:"client_secret"`
+	Endpoints    endpoints                         `json:"endpoints"`
+	Token        oauth2.Token                      `json:"token"`
+	Scopes       []string                          `json:"scopes,omitempty"`
+	Zones        []privacyZone                     `json:"privacy_zones,omitempty"`
+	Notify       Notifications                     `json:"notifications"`
+	CacheSize    string                            `json:"cache_max_size,omitempty"`
+	Shortcuts    map[string]string                 `json:"aliases,omitempty"`
+	Targets      Goals                             `json:"goals"`
+	Automation   []Rule                            `json:"rules,omitempty"`
+	Zone         string                            `json:"timezone,omitempty"`
+	Browser      string                            `json:"browser_command,omitempty"`
+	FlagDefaults map[string]map[string]interface{} `json:"defaults,omitempty"`
+	Batches      map[string]Job                    `json:"jobs,omitempty"`
+	TokenWarning string                            `json:"token_expiry_warning,omitempty"`
+}
+
+type privacyZone struct {
+	Lat    float64 `json:"lat"`
+	Lng    float64 `json:"lng"`
+	Radius float64 `json:"radius"`
+}
+
+func newPrivacyZones(zones []geo.Zone) []privacyZone {
+	var persistent []privacyZone
+	for _, zone := range zones {
+		persistent = append(persistent, privacyZone{
+			Lat:    zone.Center.Lat,
+			Lng:    zone.Center.Lng,
+			Radius: zone.Radius,
+		})
+	}
+	return persistent
 }
 
 type endpoints struct {
@@ -141,9 +352,73 @@ func (c *configuration) OAuthConfiguration() *oauth2.Config {
 			AuthURL:  c.Endpoints.AuthURL,
 			TokenURL: c.Endpoints.TokenURL,
 		},
+		Scopes: c.Scopes,
 	}
 }
 
 func (c *configuration) TokenSource(ctx context.Context) oauth2.TokenSource {
 	return c.OAuthConfiguration().TokenSource(ctx, &c.Token)
 }
+
+func (c *configuration) SavedToken() oauth2.Token {
+	return c.Token
+}
+
+func (c *configuration) PrivacyZones() []geo.Zone {
+	var zones []geo.Zone
+	for _, zone := range c.Zones {
+		zones = append(zones, geo.Zone{
+			Center: geo.Point{Lat: zone.Lat, Lng: zone.Lng},
+			Radius: zone.Radius,
+		})
+	}
+	return zones
+}
+
+func (c *configuration) Notifications() Notifications {
+	return c.Notify
+}
+
+func (c *configuration) SetNotifications(notifications Notifications) {
+	c.Notify = notifications
+}
+
+func (c *configuration) CacheMaxSize() string {
+	return c.CacheSize
+}
+
+func (c *configuration) Aliases() map[string]string {
+	return c.Shortcuts
+}
+
+func (c *configuration) SetAliases(aliases map[string]string) {
+	c.Shortcuts = aliases
+}
+
+func (c *configuration) Goals() Goals {
+	return c.Targets
+}
+
+func (c *configuration) Rules() []Rule {
+	return c.Automation
+}
+
+func (c *configuration) Timezone() string {
+	return c.Zone
+}
+
+func (c *configuration) BrowserCommand() string {
+	return c.Browser
+}
+
+func (c *configuration) Defaults() map[string]map[string]interface{} {
+	return c.FlagDefaults
+}
+
+func (c *configuration) Jobs() map[string]Job {
+	return c.Batches
+}
+
+func (c *configuration) TokenExpiryWarning() string {
+	return c.TokenWarning
+}
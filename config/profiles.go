@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// profilesDirectory is the directory of the state directory holding the
+// profiles other than the default one, a directory each.
+const profilesDirectory = "profiles"
+
+// profileFile is the configuration file within the directory of a profile.
+const profileFile = "sutro.json"
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// CheckProfile returns an error unless name can name a profile.
+func CheckProfile(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("Invalid profile %q, expected letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// ProfileDirectory returns the directory holding the local state of
+// profile, such as its archive, creating it if needed. The default profile
+// keeps its state in the state directory itself.
+func ProfileDirectory(stateDirectory, profile string) (string, error) {
+	if profile == DefaultProfile {
+		return stateDirectory, nil
+	}
+	if err := CheckProfile(profile); err != nil {
+		return "", err
+	}
+
+	directory := path.Join(stateDirectory, profilesDirectory, profile)
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return "", err
+	}
+	return directory, nil
+}
+
+// NewProfileConfiguration returns the configuration of profile: the dotfile
+// for the default profile, and a file of the directory of the profile for
+// the others.
+func NewProfileConfiguration(filename, stateDirectory, profile string) (ConfigurationBridge, error) {
+	if profile == DefaultProfile {
+		return NewDotFileConfiguration(filename)
+	}
+
+	directory, err := ProfileDirectory(stateDirectory, profile)
+	if err != nil {
+		return nil, err
+	}
+	return &fileConfiguration{path: path.Join(directory, profileFile), profile: profile}, nil
+}
+
+// Profiles lists the profiles that have a configuration, the default one
+// first and the others by name.
+func Profiles(filename, stateDirectory string) ([]string, error) {
+	if !strings.HasPrefix(filename, ".") {
+		filename = fmt.Sprintf(".%s", filename)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []string
+	if _, err := os.Stat(path.Join(home, filename)); err == nil {
+		profiles = append(profiles, DefaultProfile)
+	}
+
+	entries, err := ioutil.ReadDir(path.Join(stateDirectory, profilesDirectory))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var others []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || CheckProfile(name) != nil || name == DefaultProfile {
+			continue
+		}
+		if _, err := os.Stat(path.Join(stateDirectory, profilesDirectory, name, profileFile)); err == nil {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(profiles, others...), nil
+}
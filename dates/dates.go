@@ -0,0 +1,131 @@
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var layouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// Parse interprets a date given either as an absolute date, from a year
+// such as 2024 down to a full RFC3339 timestamp, or as a duration relative
+// to now such as 7d, 12w or 1y. Absolute dates without a zone are read in
+// the local time zone.
+func Parse(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	if t, ok := parseRelative(value, time.Now()); ok {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("Invalid date %q, expected a date such as 2024-01-31 or a relative duration such as 7d, 12w or 1y", value)
+}
+
+func parseRelative(value string, now time.Time) (time.Time, bool) {
+	if len(value) < 2 {
+		return time.Time{}, false
+	}
+
+	count, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || count < 0 {
+		return time.Time{}, false
+	}
+
+	switch value[len(value)-1] {
+	case 'd':
+		return now.AddDate(0, 0, -count), true
+	case 'w':
+		return now.AddDate(0, 0, -7*count), true
+	case 'y':
+		return now.AddDate(-count, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// ParseDuration interprets a duration given either as a Go duration such
+// as 45m or 1h30m, as a clock duration such as 1:30:00 or 45:00, or as a
+// bare number of minutes.
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, nil
+	}
+
+	if minutes, err := strconv.ParseFloat(value, 64); err == nil && minutes > 0 {
+		return time.Duration(minutes * float64(time.Minute)), nil
+	}
+
+	if parts := strings.Split(value, ":"); len(parts) == 2 || len(parts) == 3 {
+		total := 0
+		for i, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 || (i > 0 && n >= 60) {
+				total = 0
+				break
+			}
+			total = total*60 + n
+		}
+		if total > 0 {
+			return time.Duration(total) * time.Second, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Invalid duration %q, expected a duration such as 45m, 1h30m or 1:30:00", value)
+}
+
+// zoned is set once the time zone of sutro was chosen with SetTimezone,
+// rather than taken from the system.
+var zoned bool
+
+// SetTimezone makes the time zone name, such as Europe/Paris, the one in
+// which dates are read and displayed instead of the local time zone of the
+// system. An empty name keeps the local time zone.
+func SetTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("Unknown time zone %q, expected a zone such as Europe/Paris or UTC", name)
+	}
+	time.Local = location
+	zoned = true
+	return nil
+}
+
+// WallClock returns the local start date of an activity, which Strava gives
+// as the time on the clock where the activity took place written in UTC, as
+// the same time on the clock in location. Activities are so grouped by the
+// days they took place on wherever they took place.
+func WallClock(local time.Time, location *time.Location) time.Time {
+	local = local.UTC()
+	year, month, day := local.Date()
+	return time.Date(year, month, day, local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), location)
+}
+
+// Start returns the start date of an activity to display, given its start
+// date and its local start date: the time on the clock where the activity
+// took place, unless a time zone was chosen with SetTimezone, in which case
+// the start date is displayed in that zone.
+func Start(start, local time.Time) time.Time {
+	if zoned || local.IsZero() {
+		return start.In(time.Local)
+	}
+	return local.UTC()
+}
@@ -0,0 +1,84 @@
+// Package defaults applies the default values of flags that the
+// configuration sets per command, such as --per-page 100 for every
+// activities list, to the flags the command line left out.
+package defaults
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Apply sets the flags of command left out of its command line to their
+// values in defaults, by path of command without the name of the root,
+// such as activities list, and by name of flag. Flags are named as on the
+// command line, without dashes, or with underscores instead of the dashes
+// within their names, such as per_page. Values are the strings, numbers and
+// booleans of JSON, or arrays of them for flags given several times.
+func Apply(command *cobra.Command, defaults map[string]map[string]interface{}) error {
+	path := strings.TrimPrefix(command.CommandPath(), command.Root().Name()+" ")
+	values, ok := defaults[path]
+	if !ok {
+		return nil
+	}
+
+	// Flags are applied in order, so that errors do not depend on the order
+	// of the keys of a map.
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flag := command.Flags().Lookup(name)
+		if flag == nil {
+			flag = command.Flags().Lookup(strings.Replace(name, "_", "-", -1))
+		}
+		if flag == nil {
+			return fmt.Errorf("Unknown flag %s in the defaults of %s", name, path)
+		}
+		if flag.Changed {
+			continue
+		}
+		if err := set(command, flag.Name, values[name]); err != nil {
+			return fmt.Errorf("Invalid default of --%s for %s: %v", flag.Name, path, err)
+		}
+	}
+	return nil
+}
+
+// set sets the flag of command with the given name to value, once per
+// element when value is an array, which flags of lists append to the first.
+func set(command *cobra.Command, name string, value interface{}) error {
+	elements, ok := value.([]interface{})
+	if !ok {
+		elements = []interface{}{value}
+	}
+	for _, element := range elements {
+		text, err := format(element)
+		if err != nil {
+			return err
+		}
+		if err := command.Flags().Set(name, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func format(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("expected a string, a number or a boolean, got %v", value)
+	}
+}
@@ -0,0 +1,146 @@
+// Package dem reads the elevation of the ground from a digital elevation
+// model kept locally, as the SRTM tiles of one degree by one degree named
+// after their south-west corner, such as N37W123.hgt.
+package dem
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+
+	"github.com/jsilland/sutro/geo"
+)
+
+// void is the value of the samples of a tile where the elevation is not
+// known.
+const void = -32768
+
+// tile is a grid of size by size elevations in meters, from its north-west
+// corner, row by row.
+type tile struct {
+	size    int
+	samples []int16
+}
+
+// Model is a digital elevation model read from the tiles of a directory,
+// which are loaded as they are needed.
+type Model struct {
+	directory string
+	tiles     map[string]*tile
+}
+
+// Open returns the model of the tiles in directory, which may be gzipped
+// as N37W123.hgt.gz.
+func Open(directory string) (*Model, error) {
+	info, err := os.Stat(directory)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory of SRTM tiles", directory)
+	}
+	return &Model{directory: directory, tiles: map[string]*tile{}}, nil
+}
+
+// Elevation returns the elevation of the ground at p, interpolated between
+// the four samples around it, or false when no tile of the directory
+// covers p or the tile does not know the elevation there.
+func (m *Model) Elevation(p geo.Point) (float64, bool, error) {
+	south, west := math.Floor(p.Lat), math.Floor(p.Lng)
+	t, err := m.tile(int(south), int(west))
+	if err != nil || t == nil {
+		return 0, false, err
+	}
+
+	// Rows go from north to south, columns from west to east, and the
+	// samples on the edges are shared with the neighbouring tiles.
+	cells := float64(t.size - 1)
+	y, x := (south+1-p.Lat)*cells, (p.Lng-west)*cells
+	row, column := int(math.Min(math.Floor(y), cells-1)), int(math.Min(math.Floor(x), cells-1))
+	dy, dx := y-float64(row), x-float64(column)
+
+	var corners [4]float64
+	for i, offset := range [4][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}} {
+		sample := t.samples[(row+offset[0])*t.size+column+offset[1]]
+		if sample == void {
+			return 0, false, nil
+		}
+		corners[i] = float64(sample)
+	}
+	top := corners[0]*(1-dx) + corners[1]*dx
+	bottom := corners[2]*(1-dx) + corners[3]*dx
+	return top*(1-dy) + bottom*dy, true, nil
+}
+
+// tile returns the tile whose south-west corner is at south, west, or nil
+// when the directory has none.
+func (m *Model) tile(south, west int) (*tile, error) {
+	name := Name(south, west)
+	if t, ok := m.tiles[name]; ok {
+		return t, nil
+	}
+
+	t, err := readTile(path.Join(m.directory, name))
+	if err != nil {
+		return nil, err
+	}
+	m.tiles[name] = t
+	return t, nil
+}
+
+// Name returns the name of the tile whose south-west corner is at south,
+// west, such as N37W123.hgt.
+func Name(south, west int) string {
+	latitude, longitude := 'N', 'E'
+	if south < 0 {
+		latitude, south = 'S', -south
+	}
+	if west < 0 {
+		longitude, west = 'W', -west
+	}
+	return fmt.Sprintf("%c%02d%c%03d.hgt", latitude, south, longitude, west)
+}
+
+// readTile reads the tile at filename, or at filename.gz, or returns nil
+// when there is neither.
+func readTile(filename string) (*tile, error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		data, err = readGzipped(filename + ".gz")
+	}
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the tile %s: %v", filename, err)
+	}
+
+	// Tiles are 1201 samples wide at 3 arc seconds, and 3601 at 1 arc
+	// second, of big-endian 16-bit integers.
+	size := int(math.Sqrt(float64(len(data) / 2)))
+	if size < 2 || size*size*2 != len(data) {
+		return nil, fmt.Errorf("Invalid tile %s, expected a square grid of 16-bit samples such as the 1201 by 1201 of SRTM3", filename)
+	}
+	samples := make([]int16, size*size)
+	for i := range samples {
+		samples[i] = int16(binary.BigEndian.Uint16(data[2*i:]))
+	}
+	return &tile{size: size, samples: samples}, nil
+}
+
+func readGzipped(filename string) ([]byte, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(reader)
+}
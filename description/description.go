@@ -0,0 +1,156 @@
+// Package description renders the descriptions of activities from Go
+// templates, such as
+//
+//	{{kilometers .Distance}} on {{.Gear}}{{with .Weather}}, {{.}}{{end}}
+//
+// which sutro applies to the activities it uploads or creates.
+package description
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/payload"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/weather"
+)
+
+var functions = map[string]interface{}{
+	"kilometers": func(meters interface{}) string { return format.Kilometers(number(meters)) },
+	"meters":     func(meters interface{}) string { return fmt.Sprintf("%.0f m", number(meters)) },
+	"duration":   func(seconds interface{}) string { return format.Duration(number(seconds)) },
+	"pace": func(meters, seconds interface{}) string {
+		if number(meters) == 0 {
+			return ""
+		}
+		return format.Pace(number(seconds) / 60 / (number(meters) / 1000))
+	},
+	"date": func(date strfmt.DateTime) string {
+		return time.Time(date).Format("Mon Jan 2, 15:04")
+	},
+}
+
+// number converts the numbers of models, which templates cannot convert
+// by themselves.
+func number(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	}
+	return 0
+}
+
+// Template is a parsed description template.
+type Template struct {
+	template *template.Template
+	// Weather looks up the weather of activities, from Open-Meteo unless
+	// set otherwise.
+	Weather *weather.Client
+}
+
+// Parse parses a description template.
+func Parse(text string) (*Template, error) {
+	parsed, err := template.New("description").Funcs(functions).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid description template: %v", err)
+	}
+	// Rendering an empty activity fails on misspelled fields before any
+	// activity is created.
+	probe := &Activity{DetailedActivity: &models.DetailedActivity{}, looked: true, conditions: &weather.Conditions{}}
+	if err := parsed.Execute(ioutil.Discard, probe); err != nil {
+		return nil, fmt.Errorf("Invalid description template: %v", err)
+	}
+	return &Template{template: parsed, Weather: &weather.Client{}}, nil
+}
+
+// Read parses the template value refers to, inline or as @path as the
+// bodies of package payload, or returns nil when value is empty.
+func Read(value string, stdin io.Reader) (*Template, error) {
+	if value == "" {
+		return nil, nil
+	}
+	text, err := payload.Read(value, stdin)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(text))
+}
+
+// Activity is what templates render: the fields of the activity, along
+// with the name of its gear and the weather at its start.
+type Activity struct {
+	*models.DetailedActivity
+
+	ctx        context.Context
+	weather    *weather.Client
+	conditions *weather.Conditions
+	looked     bool
+}
+
+// Gear returns the name of the gear of the activity, if any.
+func (a *Activity) Gear() string {
+	if a.DetailedActivity.Gear == nil {
+		return ""
+	}
+	return a.DetailedActivity.Gear.Name
+}
+
+// Weather returns the weather at the start of the activity, or nil for
+// activities without a location such as manual ones. It is only looked up
+// when the template renders it.
+func (a *Activity) Weather() (*weather.Conditions, error) {
+	if a.looked {
+		return a.conditions, nil
+	}
+	a.looked = true
+	if len(a.StartLatlng) != 2 {
+		return nil, nil
+	}
+
+	start := geo.Point{Lat: float64(a.StartLatlng[0]), Lng: float64(a.StartLatlng[1])}
+	conditions, err := a.weather.At(a.ctx, start, time.Time(a.StartDate))
+	if err != nil {
+		return nil, err
+	}
+	a.conditions = conditions
+	return conditions, nil
+}
+
+// Render returns the description of activity.
+func (t *Template) Render(ctx context.Context, activity *models.DetailedActivity) (string, error) {
+	var description strings.Builder
+	data := &Activity{DetailedActivity: activity, ctx: ctx, weather: t.Weather}
+	if err := t.template.Execute(&description, data); err != nil {
+		return "", fmt.Errorf("Unable to render the description of activity %d: %v", activity.ID, err)
+	}
+	return strings.TrimSpace(description.String()), nil
+}
+
+// Apply renders the description of activity and sets it on Strava, and
+// returns the updated activity.
+func (t *Template) Apply(ctx context.Context, apiClient *strava.Client, activity *models.DetailedActivity) (*models.DetailedActivity, error) {
+	description, err := t.Render(ctx, activity)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := apiClient.Activities.Update(ctx, activity.ID, &models.UpdatableActivity{Description: description})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to set the description of activity %d: %v", activity.ID, err)
+	}
+	return updated, nil
+}
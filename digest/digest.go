@@ -0,0 +1,155 @@
+package digest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/locale"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+)
+
+// zoneSpan bounds how far the local time of an activity can be from UTC.
+const zoneSpan = 14 * time.Hour
+
+// Period is the span of time a digest covers, from Start inclusive to End
+// exclusive.
+type Period struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// LastPeriod returns the last complete week, starting on Monday, or month
+// before now.
+func LastPeriod(name string, now time.Time) (Period, error) {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	switch name {
+	case "week":
+		end := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		return Period{Name: name, Start: end.AddDate(0, 0, -7), End: end}, nil
+	case "month":
+		end := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+		return Period{Name: name, Start: end.AddDate(0, -1, 0), End: end}, nil
+	}
+	return Period{}, fmt.Errorf("Unknown period %q, expected week or month", name)
+}
+
+// Previous returns the period of the same length just before p.
+func (p Period) Previous() Period {
+	if p.Name == "month" {
+		return Period{Name: p.Name, Start: p.Start.AddDate(0, -1, 0), End: p.Start}
+	}
+	return Period{Name: p.Name, Start: p.Start.AddDate(0, 0, -7), End: p.Start}
+}
+
+// Activities returns the archived activities of the given type, or of
+// every type when empty, which took place within p on the clock where they
+// took place: a ride at 8:00 in Tokyo on Monday belongs to the week
+// starting that Monday, whatever the time zone of sutro.
+func (p Period) Activities(archive *store.Store, activityType string) ([]*models.SummaryActivity, error) {
+	// The archive is queried by start date in UTC, which is within a day of
+	// the local start dates.
+	candidates, err := archive.Activities(store.Query{After: p.Start.Add(-zoneSpan), Before: p.End.Add(zoneSpan), Type: activityType})
+	if err != nil {
+		return nil, err
+	}
+	var activities []*models.SummaryActivity
+	for _, activity := range candidates {
+		start := time.Time(activity.StartDate).In(p.Start.Location())
+		if local := time.Time(activity.StartDateLocal); !local.IsZero() {
+			start = dates.WallClock(local, p.Start.Location())
+		}
+		if !start.Before(p.Start) && start.Before(p.End) {
+			activities = append(activities, activity)
+		}
+	}
+	return activities, nil
+}
+
+// Label describes the period in the language of sutro, such as "week of
+// Mar 2, 2020" or "March 2020".
+func (p Period) Label() string {
+	if p.Name == "month" {
+		return locale.Date(p.Start, locale.T("MonthOf", nil))
+	}
+	return locale.T("WeekOf", map[string]string{"Date": locale.Date(p.Start, locale.T("WeekOfDate", nil))})
+}
+
+// Totals sums up a set of activities.
+type Totals struct {
+	Type       string
+	Count      int
+	Distance   float64
+	MovingTime float64
+	Ascent     float64
+}
+
+func (t *Totals) add(activity *models.SummaryActivity) {
+	t.Count++
+	t.Distance += float64(activity.Distance)
+	t.MovingTime += float64(activity.MovingTime)
+	t.Ascent += float64(activity.TotalElevationGain)
+}
+
+// Digest is the training summary of a period, compared with the period
+// before it.
+type Digest struct {
+	Title    string
+	Period   Period
+	Totals   Totals
+	Previous Totals
+	// Types are the totals of each activity type, by decreasing moving
+	// time.
+	Types      []Totals
+	Activities []*models.SummaryActivity
+}
+
+// New summarizes the activities of period, comparing them with the
+// activities of the previous period.
+func New(title string, period Period, activities, previous []*models.SummaryActivity) *Digest {
+	d := &Digest{Title: title, Period: period, Activities: activities}
+
+	byType := map[string]*Totals{}
+	for _, activity := range activities {
+		d.Totals.add(activity)
+
+		name := string(activity.Type)
+		if _, ok := byType[name]; !ok {
+			byType[name] = &Totals{Type: name}
+		}
+		byType[name].add(activity)
+	}
+	for _, activity := range previous {
+		d.Previous.add(activity)
+	}
+
+	for _, totals := range byType {
+		d.Types = append(d.Types, *totals)
+	}
+	sort.Slice(d.Types, func(i, j int) bool {
+		if d.Types[i].MovingTime != d.Types[j].MovingTime {
+			return d.Types[i].MovingTime > d.Types[j].MovingTime
+		}
+		return d.Types[i].Type < d.Types[j].Type
+	})
+	return d
+}
+
+// Subject returns the subject line of the digest.
+func (d *Digest) Subject() string {
+	return fmt.Sprintf("%s: %s", d.Title, d.Period.Label())
+}
+
+// change describes the relative change from previous to current, such as
+// +12%, or an empty string when there is nothing to compare to.
+func change(current, previous float64) string {
+	if previous <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%+.0f%%", (current-previous)/previous*100)
+}
@@ -0,0 +1,118 @@
+package digest
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/locale"
+	"github.com/jsilland/sutro/models"
+)
+
+var functions = map[string]interface{}{
+	"kilometers": func(meters interface{}) string { return format.Kilometers(number(meters)) },
+	"duration":   func(seconds interface{}) string { return format.Duration(number(seconds)) },
+	"meters":     func(meters interface{}) string { return locale.Number(number(meters), 0) + " m" },
+	"change":     change,
+	"date": func(activity *models.SummaryActivity) string {
+		start := dates.Start(time.Time(activity.StartDate), time.Time(activity.StartDateLocal))
+		return locale.Date(start, locale.T("ActivityTime", nil))
+	},
+	"t":          func(id string) string { return locale.T(id, nil) },
+	"activities": func(count int) string { return locale.Plural("Activities", count) },
+	// period translates the message of a period, such as DigestThisWeek
+	// for This and week.
+	"period": func(message, name string) string {
+		return locale.T("Digest"+message+strings.ToUpper(name[:1])+name[1:], nil)
+	},
+	"totals": func(id, period string, totals Totals) string {
+		return locale.T(id, map[string]string{
+			"Period":     period,
+			"Activities": locale.Plural("Activities", totals.Count),
+			"Distance":   format.Kilometers(totals.Distance),
+			"MovingTime": format.Duration(totals.MovingTime),
+			"Ascent":     locale.Number(totals.Ascent, 0) + " m",
+		})
+	},
+	"in": func(activity *models.SummaryActivity) string {
+		return locale.T("ActivityIn", map[string]string{
+			"Distance": format.Kilometers(float64(activity.Distance)),
+			"Duration": format.Duration(float64(activity.MovingTime)),
+		})
+	},
+}
+
+// number converts the numbers of both totals and models, which templates
+// cannot convert by themselves.
+func number(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case int:
+		return float64(v)
+	}
+	return 0
+}
+
+var htmlDigest = htmltemplate.Must(htmltemplate.New("digest").Funcs(functions).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Subject}}</title></head>
+<body style="font-family: -apple-system, Helvetica, Arial, sans-serif; color: #222; max-width: 640px; margin: 0 auto; padding: 1em;">
+<h1 style="color: #fc4c02; font-size: 1.4em;">{{.Title}}</h1>
+<p>{{totals "DigestTotals" .Period.Label .Totals}}</p>
+<table style="border-collapse: collapse; width: 100%;">
+<tr><th style="text-align: left;"></th><th style="text-align: right;">{{period "This" .Period.Name}}</th><th style="text-align: right;">{{period "Previous" .Period.Name}}</th><th style="text-align: right;">{{t "DigestChange"}}</th></tr>
+<tr><td>{{t "DigestActivities"}}</td><td style="text-align: right;">{{.Totals.Count}}</td><td style="text-align: right;">{{.Previous.Count}}</td><td style="text-align: right;"></td></tr>
+<tr><td>{{t "DigestDistance"}}</td><td style="text-align: right;">{{kilometers .Totals.Distance}}</td><td style="text-align: right;">{{kilometers .Previous.Distance}}</td><td style="text-align: right;">{{change .Totals.Distance .Previous.Distance}}</td></tr>
+<tr><td>{{t "DigestMovingTime"}}</td><td style="text-align: right;">{{duration .Totals.MovingTime}}</td><td style="text-align: right;">{{duration .Previous.MovingTime}}</td><td style="text-align: right;">{{change .Totals.MovingTime .Previous.MovingTime}}</td></tr>
+<tr><td>{{t "DigestAscent"}}</td><td style="text-align: right;">{{meters .Totals.Ascent}}</td><td style="text-align: right;">{{meters .Previous.Ascent}}</td><td style="text-align: right;">{{change .Totals.Ascent .Previous.Ascent}}</td></tr>
+</table>
+{{if .Types}}<h2 style="font-size: 1.1em;">{{t "DigestByType"}}</h2>
+<table style="border-collapse: collapse; width: 100%;">
+{{range .Types}}<tr><td>{{.Type}}</td><td style="text-align: right;">{{.Count}}</td><td style="text-align: right;">{{kilometers .Distance}}</td><td style="text-align: right;">{{duration .MovingTime}}</td></tr>
+{{end}}</table>
+<h2 style="font-size: 1.1em;">{{t "DigestActivities"}}</h2>
+<ul style="padding-left: 1.2em;">
+{{range .Activities}}<li><a href="https://www.strava.com/activities/{{.ID}}" style="color: #fc4c02;">{{.Name}}</a> · {{.Type}} · {{date .}} · {{in .}}</li>
+{{end}}</ul>
+{{else}}<p>{{period "None" .Period.Name}}</p>
+{{end}}<p style="color: #888; font-size: 0.85em;">{{t "DigestSentBy"}}</p>
+</body>
+</html>
+`))
+
+var textDigest = texttemplate.Must(texttemplate.New("digest").Funcs(functions).Parse(`{{.Title}}
+
+{{totals "DigestTotals" .Period.Label .Totals}}
+{{totals "DigestPreviousTotals" (period "Previous" .Period.Name) .Previous}}
+{{if .Types}}
+{{t "DigestByType"}}:
+{{range .Types}}  {{.Type}}: {{activities .Count}}, {{kilometers .Distance}}, {{duration .MovingTime}}
+{{end}}
+{{t "DigestActivities"}}:
+{{range .Activities}}  {{date .}}  {{.Name}} ({{.Type}}, {{in .}})
+{{end}}{{end}}`))
+
+// WriteHTML renders the digest as an HTML page with inline styles, which
+// mail clients display more reliably than stylesheets.
+func (d *Digest) WriteHTML(writer io.Writer) error {
+	return htmlDigest.Execute(writer, d)
+}
+
+// WriteText renders the digest as plain text.
+func (d *Digest) WriteText(writer io.Writer) error {
+	var builder strings.Builder
+	if err := textDigest.Execute(&builder, d); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
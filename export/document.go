@@ -0,0 +1,128 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+
+	"github.com/jsilland/sutro/geo"
+)
+
+// positionElements are the elements of GPX and TCX documents that carry a
+// position: the points of GPX tracks, routes and waypoints, with lat and lon
+// attributes, and the track and course points of TCX, with a Position. The
+// begin and end positions of TCX laps are positions themselves.
+var positionElements = map[string]bool{
+	"trkpt":         true,
+	"rtept":         true,
+	"wpt":           true,
+	"Trackpoint":    true,
+	"CoursePoint":   true,
+	"BeginPosition": true,
+	"EndPosition":   true,
+}
+
+var (
+	latAttribute     = regexp.MustCompile(`(\blat\s*=\s*["'])[^"']*`)
+	lonAttribute     = regexp.MustCompile(`(\blon\s*=\s*["'])[^"']*`)
+	latitudeElement  = regexp.MustCompile(`(<(?:[\w.-]+:)?LatitudeDegrees\s*>)[^<]*`)
+	longitudeElement = regexp.MustCompile(`(<(?:[\w.-]+:)?LongitudeDegrees\s*>)[^<]*`)
+)
+
+type documentPosition struct {
+	Lat       *float64 `xml:"lat,attr"`
+	Lon       *float64 `xml:"lon,attr"`
+	Latitude  *float64 `xml:"Position>LatitudeDegrees"`
+	Longitude *float64 `xml:"Position>LongitudeDegrees"`
+	// LapLatitude and LapLongitude are those of BeginPosition and
+	// EndPosition, which have no Position element.
+	LapLatitude  *float64 `xml:"LatitudeDegrees"`
+	LapLongitude *float64 `xml:"LongitudeDegrees"`
+}
+
+func (p *documentPosition) point() (float64, float64, bool) {
+	switch {
+	case p.Lat != nil && p.Lon != nil:
+		return *p.Lat, *p.Lon, true
+	case p.Latitude != nil && p.Longitude != nil:
+		return *p.Latitude, *p.Longitude, true
+	case p.LapLatitude != nil && p.LapLongitude != nil:
+		return *p.LapLatitude, *p.LapLongitude, true
+	}
+	return 0, 0, false
+}
+
+// ScrubDocument copies a GPX or TCX document from reader to writer with the
+// positions of its points scrubbed: points dropped by the scrubber are
+// removed along with their measurements, and jittered ones are moved. The
+// rest of the document is copied as is.
+func ScrubDocument(writer io.Writer, reader io.Reader, s *Scrubber) error {
+	document, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	copied := int64(0)
+	for {
+		begin := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || !positionElements[start.Name.Local] {
+			continue
+		}
+
+		var position documentPosition
+		if err := decoder.DecodeElement(&position, &start); err != nil {
+			return err
+		}
+		end := decoder.InputOffset()
+		lat, lng, ok := position.point()
+		if !ok {
+			continue
+		}
+
+		scrubbed, keep := s.Point(geo.Point{Lat: lat, Lng: lng})
+		if !keep {
+			// The indentation of the dropped element goes with it.
+			preceding := bytes.TrimRight(document[copied:begin], " \t\r\n")
+			if _, err := writer.Write(preceding); err != nil {
+				return err
+			}
+			copied = end
+			continue
+		}
+		if scrubbed.Lat == lat && scrubbed.Lng == lng {
+			continue
+		}
+
+		if _, err := writer.Write(document[copied:begin]); err != nil {
+			return err
+		}
+		element := document[begin:end]
+		latitude := []byte("${1}" + strconv.FormatFloat(scrubbed.Lat, 'f', 7, 64))
+		longitude := []byte("${1}" + strconv.FormatFloat(scrubbed.Lng, 'f', 7, 64))
+		if position.Lat != nil {
+			element = latAttribute.ReplaceAll(element, latitude)
+			element = lonAttribute.ReplaceAll(element, longitude)
+		} else {
+			element = latitudeElement.ReplaceAll(element, latitude)
+			element = longitudeElement.ReplaceAll(element, longitude)
+		}
+		if _, err := writer.Write(element); err != nil {
+			return err
+		}
+		copied = end
+	}
+	_, err = writer.Write(document[copied:])
+	return err
+}
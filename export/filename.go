@@ -0,0 +1,114 @@
+package export
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/jsilland/sutro/models"
+)
+
+// The functions of filename templates take values of any type, such as the
+// type of an activity, as their text.
+var filenameFunctions = map[string]interface{}{
+	"slug":  func(value interface{}) string { return slug(fmt.Sprint(value)) },
+	"lower": func(value interface{}) string { return strings.ToLower(fmt.Sprint(value)) },
+	"truncate": func(length int, value interface{}) string {
+		s := fmt.Sprint(value)
+		runes := []rune(s)
+		if len(runes) <= length {
+			return s
+		}
+		return strings.TrimRight(string(runes[:length]), "-_ ")
+	},
+}
+
+// FilenameTemplate names the files activities are exported to, from a Go
+// template such as
+//
+//	{{.StartDateLocal.Format "2006-01-02"}}-{{.Name | slug}}.gpx
+//
+// which renders the fields of the activity, with slug, lower and truncate
+// to shape them.
+type FilenameTemplate struct {
+	template *template.Template
+	// used counts the filenames rendered so far, so that activities whose
+	// names collide are still written to distinct files.
+	used map[string]int
+}
+
+// filenameActivity is what filename templates render: the fields of the
+// activity, with its start dates as times so that they can be formatted.
+type filenameActivity struct {
+	*models.SummaryActivity
+	StartDate      time.Time
+	StartDateLocal time.Time
+}
+
+// ParseFilenameTemplate parses a filename template.
+func ParseFilenameTemplate(text string) (*FilenameTemplate, error) {
+	parsed, err := template.New("filename").Funcs(filenameFunctions).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid filename template: %v", err)
+	}
+	// Rendering an empty activity fails on misspelled fields before any
+	// activity is exported.
+	if err := parsed.Execute(ioutil.Discard, &filenameActivity{SummaryActivity: &models.SummaryActivity{}}); err != nil {
+		return nil, fmt.Errorf("Invalid filename template: %v", err)
+	}
+	return &FilenameTemplate{template: parsed, used: map[string]int{}}, nil
+}
+
+// Render returns the path of the file activity is exported to, relative to
+// the directory of the export. The extension is appended when the
+// template renders none, and a counter when the name was rendered before.
+func (t *FilenameTemplate) Render(activity *models.SummaryActivity, extension string) (string, error) {
+	var name strings.Builder
+	data := &filenameActivity{
+		SummaryActivity: activity,
+		StartDate:       time.Time(activity.StartDate),
+		// The local start date is the local time of the activity, in UTC.
+		StartDateLocal: time.Time(activity.StartDateLocal).UTC(),
+	}
+	if err := t.template.Execute(&name, data); err != nil {
+		return "", fmt.Errorf("Unable to name the file of activity %d: %v", activity.ID, err)
+	}
+
+	filename := path.Clean(strings.TrimSpace(name.String()))
+	if filename == "." || path.IsAbs(filename) || filename == ".." || strings.HasPrefix(filename, "../") {
+		return "", fmt.Errorf("The filename template renders %q for activity %d, which is not a file within the export", name.String(), activity.ID)
+	}
+	if path.Ext(filename) == "" {
+		filename += "." + extension
+	}
+
+	t.used[filename]++
+	if count := t.used[filename]; count > 1 {
+		extension := path.Ext(filename)
+		filename = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(filename, extension), count, extension)
+	}
+	return filename, nil
+}
+
+// slug lowercases s into words of letters and digits separated by dashes,
+// such as morning-ride-to-work for Morning Ride (to work!).
+func slug(s string) string {
+	var slugged strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && slugged.Len() > 0 {
+				slugged.WriteRune('-')
+			}
+			slugged.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return slugged.String()
+}
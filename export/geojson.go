@@ -0,0 +1,80 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+)
+
+type featureCollection struct {
+	Type     string     `json:"type"`
+	Features []*feature `json:"features"`
+}
+
+type feature struct {
+	Type       string            `json:"type"`
+	Geometry   lineString        `json:"geometry"`
+	Properties featureProperties `json:"properties"`
+}
+
+type lineString struct {
+	Type        string      `json:"type"`
+	Coordinates [][]float64 `json:"coordinates"`
+}
+
+type featureProperties struct {
+	ID       int64   `json:"id"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Date     string  `json:"date"`
+	Distance float64 `json:"distance"`
+}
+
+// WriteGeoJSON writes a track as a GeoJSON LineString feature whose
+// coordinates include the elevation when it was recorded.
+func WriteGeoJSON(writer io.Writer, track *Track) error {
+	return writeJSON(writer, newFeature(track))
+}
+
+// WriteGeoJSONCollection writes several tracks as a single GeoJSON
+// FeatureCollection.
+func WriteGeoJSONCollection(writer io.Writer, tracks []*Track) error {
+	collection := featureCollection{Type: "FeatureCollection", Features: []*feature{}}
+	for _, track := range tracks {
+		collection.Features = append(collection.Features, newFeature(track))
+	}
+	return writeJSON(writer, collection)
+}
+
+func newFeature(track *Track) *feature {
+	coordinates := make([][]float64, 0, len(track.Samples))
+	for _, sample := range track.Samples {
+		coordinate := []float64{sample.Lng, sample.Lat}
+		if !math.IsNaN(sample.Elevation) {
+			coordinate = append(coordinate, sample.Elevation)
+		}
+		coordinates = append(coordinates, coordinate)
+	}
+
+	return &feature{
+		Type: "Feature",
+		Geometry: lineString{
+			Type:        "LineString",
+			Coordinates: coordinates,
+		},
+		Properties: featureProperties{
+			ID:       track.ID,
+			Name:     track.Name,
+			Type:     track.Type,
+			Date:     track.Start.UTC().Format(time.RFC3339),
+			Distance: track.Distance,
+		},
+	}
+}
+
+func writeJSON(writer io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+type gpx struct {
+	XMLName      xml.Name   `xml:"gpx"`
+	Version      string     `xml:"version,attr"`
+	Creator      string     `xml:"creator,attr"`
+	Namespace    string     `xml:"xmlns,attr"`
+	TrackPointNS string     `xml:"xmlns:gpxtpx,attr"`
+	MetadataTime string     `xml:"metadata>time,omitempty"`
+	Name         string     `xml:"trk>name"`
+	Type         string     `xml:"trk>type,omitempty"`
+	Points       []gpxPoint `xml:"trk>trkseg>trkpt"`
+}
+
+type gpxPoint struct {
+	Lat        string         `xml:"lat,attr"`
+	Lon        string         `xml:"lon,attr"`
+	Elevation  string         `xml:"ele,omitempty"`
+	Time       string         `xml:"time,omitempty"`
+	Extensions *gpxExtensions `xml:"extensions,omitempty"`
+}
+
+type gpxExtensions struct {
+	Power      string                  `xml:"power,omitempty"`
+	TrackPoint *gpxTrackPointExtension `xml:"gpxtpx:TrackPointExtension,omitempty"`
+}
+
+type gpxTrackPointExtension struct {
+	Heartrate string `xml:"gpxtpx:hr,omitempty"`
+	Cadence   string `xml:"gpxtpx:cad,omitempty"`
+}
+
+// WriteGPX writes a track as a GPX 1.1 document, with heart rate and
+// cadence in the Garmin track point extension and power in a power
+// extension element.
+func WriteGPX(writer io.Writer, track *Track) error {
+	document := gpx{
+		Version:      "1.1",
+		Creator:      "sutro",
+		Namespace:    "http://www.topografix.com/GPX/1/1",
+		TrackPointNS: "http://www.garmin.com/xmlschemas/TrackPointExtension/v1",
+		Name:         track.Name,
+		Type:         track.Type,
+	}
+	if !track.Start.IsZero() {
+		document.MetadataTime = track.Start.UTC().Format(time.RFC3339)
+	}
+
+	document.Points = make([]gpxPoint, 0, len(track.Samples))
+	for _, sample := range track.Samples {
+		point := gpxPoint{
+			Lat:       fmt.Sprintf("%.7f", sample.Lat),
+			Lon:       fmt.Sprintf("%.7f", sample.Lng),
+			Elevation: formatMeasurement(sample.Elevation, "%.1f"),
+		}
+		if !sample.Time.IsZero() {
+			point.Time = sample.Time.UTC().Format(time.RFC3339)
+		}
+
+		extensions := &gpxExtensions{Power: formatMeasurement(sample.Watts, "%.0f")}
+		if !math.IsNaN(sample.Heartrate) || !math.IsNaN(sample.Cadence) {
+			extensions.TrackPoint = &gpxTrackPointExtension{
+				Heartrate: formatMeasurement(sample.Heartrate, "%.0f"),
+				Cadence:   formatMeasurement(sample.Cadence, "%.0f"),
+			}
+		}
+		if extensions.Power != "" || extensions.TrackPoint != nil {
+			point.Extensions = extensions
+		}
+
+		document.Points = append(document.Points, point)
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+func formatMeasurement(value float64, format string) string {
+	if math.IsNaN(value) {
+		return ""
+	}
+	return fmt.Sprintf(format, value)
+}
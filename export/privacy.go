@@ -0,0 +1,159 @@
+package export
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+)
+
+const (
+	// PrivacyTrim drops the samples that lie within a privacy zone.
+	PrivacyTrim = "trim"
+	// PrivacyJitter drops the samples that lie within a privacy zone, as
+	// trim does, and displaces each of those lying within a radius of its
+	// edge by its own random offset, so that the track fades out around
+	// the zone rather than ending on its edge.
+	PrivacyJitter = "jitter"
+	// PrivacyOff leaves samples untouched.
+	PrivacyOff = "off"
+)
+
+// CheckPrivacyMode returns an error if mode is not a known privacy mode.
+func CheckPrivacyMode(mode string) error {
+	switch mode {
+	case PrivacyTrim, PrivacyJitter, PrivacyOff:
+		return nil
+	}
+	return fmt.Errorf("Unknown privacy mode %q, expected %s, %s or %s", mode, PrivacyTrim, PrivacyJitter, PrivacyOff)
+}
+
+// Scrubber applies a privacy mode to the positions of an activity lying
+// within privacy zones. Positions are jittered independently of each
+// other: a constant offset would be revealed by the jump where the track
+// crosses into the band around a zone, and could be subtracted.
+type Scrubber struct {
+	zones  []geo.Zone
+	random *rand.Rand
+}
+
+// NewScrubber returns a Scrubber applying mode within zones, drawing its
+// jitter offsets from random, which may be nil unless mode is jitter.
+func NewScrubber(zones []geo.Zone, mode string, random *rand.Rand) (*Scrubber, error) {
+	if err := CheckPrivacyMode(mode); err != nil {
+		return nil, err
+	}
+	switch mode {
+	case PrivacyOff:
+		return &Scrubber{}, nil
+	case PrivacyTrim:
+		return &Scrubber{zones: zones}, nil
+	}
+	return &Scrubber{zones: zones, random: random}, nil
+}
+
+// Point returns point as scrubbed, and false when it is to be dropped.
+func (s *Scrubber) Point(point geo.Point) (geo.Point, bool) {
+	for _, zone := range s.zones {
+		if zone.Contains(point) {
+			return point, false
+		}
+	}
+	if s.random == nil {
+		return point, true
+	}
+
+	for _, zone := range s.zones {
+		if geo.Distance(zone.Center, point) > 2*zone.Radius {
+			continue
+		}
+		bearing := s.random.Float64() * 2 * math.Pi
+		distance := zone.Radius / 2 * s.random.Float64()
+		point = geo.Point{
+			Lat: point.Lat + distance*math.Cos(bearing)/111320,
+			Lng: point.Lng + distance*math.Sin(bearing)/(111320*math.Cos(point.Lat*math.Pi/180)),
+		}
+		break
+	}
+	// A point displaced into a zone is dropped like those recorded there.
+	for _, zone := range s.zones {
+		if zone.Contains(point) {
+			return point, false
+		}
+	}
+	return point, true
+}
+
+// Points returns the points that are not dropped, as scrubbed.
+func (s *Scrubber) Points(points []geo.Point) []geo.Point {
+	scrubbed := make([]geo.Point, 0, len(points))
+	for _, point := range points {
+		if point, ok := s.Point(point); ok {
+			scrubbed = append(scrubbed, point)
+		}
+	}
+	return scrubbed
+}
+
+// Activity returns a copy of activity with its maps and its start and end
+// positions scrubbed. Dropped positions are left empty.
+func (s *Scrubber) Activity(activity *models.SummaryActivity) (*models.SummaryActivity, error) {
+	scrubbed := *activity
+	scrubbed.StartLatlng = s.latLng(activity.StartLatlng)
+	scrubbed.EndLatlng = s.latLng(activity.EndLatlng)
+	if activity.Map != nil {
+		m := *activity.Map
+		var err error
+		if m.Polyline, err = s.polyline(m.Polyline); err != nil {
+			return nil, fmt.Errorf("Unable to decode the map of activity %d: %v", activity.ID, err)
+		}
+		if m.SummaryPolyline, err = s.polyline(m.SummaryPolyline); err != nil {
+			return nil, fmt.Errorf("Unable to decode the map of activity %d: %v", activity.ID, err)
+		}
+		scrubbed.Map = &m
+	}
+	return &scrubbed, nil
+}
+
+func (s *Scrubber) latLng(position models.LatLng) models.LatLng {
+	if len(position) != 2 {
+		return position
+	}
+	point, ok := s.Point(geo.Point{Lat: float64(position[0]), Lng: float64(position[1])})
+	if !ok {
+		return nil
+	}
+	return models.LatLng{float32(point.Lat), float32(point.Lng)}
+}
+
+func (s *Scrubber) polyline(encoded string) (string, error) {
+	if encoded == "" || len(s.zones) == 0 {
+		return encoded, nil
+	}
+	points, err := geo.DecodePolyline(encoded)
+	if err != nil {
+		return "", err
+	}
+	return geo.EncodePolyline(s.Points(points)), nil
+}
+
+// Scrub applies a privacy mode to the samples of the track lying within
+// any of the zones.
+func (t *Track) Scrub(zones []geo.Zone, mode string, random *rand.Rand) error {
+	s, err := NewScrubber(zones, mode, random)
+	if err != nil {
+		return err
+	}
+
+	scrubbed := t.Samples[:0]
+	for _, sample := range t.Samples {
+		if point, ok := s.Point(sample.Point); ok {
+			sample.Point = point
+			scrubbed = append(scrubbed, sample)
+		}
+	}
+	t.Samples = scrubbed
+	return nil
+}
@@ -0,0 +1,93 @@
+package export
+
+import (
+	"io"
+	"math"
+	"time"
+
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/stream"
+)
+
+// StreamKeys are the stream types needed to build a complete track.
+var StreamKeys = []string{"latlng", "time", "altitude", "heartrate", "cadence", "watts"}
+
+// Track is an activity along with its recorded samples, ready to be
+// written in any of the export formats.
+type Track struct {
+	ID       int64
+	Name     string
+	Type     string
+	Start    time.Time
+	Distance float64
+	Samples  []Sample
+}
+
+// Sample is a single recorded position. Measurements that were not
+// recorded are NaN, and Time is zero when the activity has no time stream.
+type Sample struct {
+	geo.Point
+	Time      time.Time
+	Elevation float64
+	Heartrate float64
+	Cadence   float64
+	Watts     float64
+}
+
+// NewTrack combines an activity with its streams. Samples without a valid
+// position are dropped.
+func NewTrack(activity *models.SummaryActivity, set *models.StreamSet) *Track {
+	track := &Track{
+		ID:       activity.ID,
+		Name:     activity.Name,
+		Type:     string(activity.Type),
+		Start:    time.Time(activity.StartDate),
+		Distance: float64(activity.Distance),
+	}
+
+	positions := stream.Positions(set)
+	times := stream.Times(set)
+	altitudes := stream.Altitudes(set)
+	heartrates := stream.Heartrates(set)
+	cadences := stream.Cadences(set)
+	watts := stream.Watts(set)
+
+	for i, position := range positions {
+		if math.IsNaN(position.Lat) || math.IsNaN(position.Lng) {
+			continue
+		}
+
+		sample := Sample{
+			Point:     position,
+			Elevation: at(altitudes, i),
+			Heartrate: at(heartrates, i),
+			Cadence:   at(cadences, i),
+			Watts:     at(watts, i),
+		}
+		if i < len(times) {
+			sample.Time = track.Start.Add(time.Duration(times[i]) * time.Second)
+		}
+		track.Samples = append(track.Samples, sample)
+	}
+	return track
+}
+
+func at(values []float64, i int) float64 {
+	if i >= len(values) {
+		return math.NaN()
+	}
+	return values[i]
+}
+
+// Format describes how tracks are written in a given file format.
+type Format struct {
+	Extension string
+	Write     func(io.Writer, *Track) error
+}
+
+// Formats are the supported export formats, by name.
+var Formats = map[string]Format{
+	"geojson": {Extension: "geojson", Write: WriteGeoJSON},
+	"gpx":     {Extension: "gpx", Write: WriteGPX},
+}
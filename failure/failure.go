@@ -0,0 +1,190 @@
+// Package failure presents the errors sutro fails with on stderr, as text
+// or as JSON for scripts, with a hint on how to recover from the common
+// ones, and maps them to the exit codes of sutro.
+package failure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/jsilland/sutro/auth"
+	"github.com/jsilland/sutro/lock"
+	"github.com/jsilland/sutro/strava"
+	"github.com/spf13/cobra"
+)
+
+// The exit codes of sutro. Interrupted commands exit as shells report
+// processes killed by a signal, with 130 for SIGINT and 143 for SIGTERM.
+const (
+	// Failed is the exit code of the errors of no other kind.
+	Failed = 1
+	// Usage is the exit code of invalid commands, flags or arguments.
+	Usage = 2
+	// Configuration is the exit code of a configuration or state that
+	// cannot be read.
+	Configuration = 3
+	// Unauthorized is the exit code of a token that expired, was revoked
+	// or lacks a scope.
+	Unauthorized = 4
+	// RateLimited is the exit code of the rate limit of the API, or the
+	// budget of a job, running out.
+	RateLimited = 5
+	// NotFound is the exit code of resources that do not exist.
+	NotFound = 6
+	// Network is the exit code of the requests that could not be sent.
+	Network = 7
+	// Locked is the exit code of a command given --single-instance while
+	// another invocation of it is running.
+	Locked = 8
+)
+
+// Presentation is how an error is presented: its message, the kind of
+// failure it is, such as rate_limited, a hint on how to recover from it
+// and the exit code it maps to.
+type Presentation struct {
+	Error string `json:"error"`
+	Kind  string `json:"kind"`
+	Hint  string `json:"hint,omitempty"`
+	Code  int    `json:"exit_code"`
+}
+
+// classified is an error whose kind was set where it happened.
+type classified struct {
+	err  error
+	kind string
+	code int
+}
+
+func (e *classified) Error() string { return e.err.Error() }
+func (e *classified) Unwrap() error { return e.err }
+
+// UsageError marks err as the failure of an invalid command, flag or
+// argument.
+func UsageError(err error) error {
+	return &classified{err: err, kind: "usage", code: Usage}
+}
+
+// ConfigurationError marks err as the failure to read the configuration
+// or the state of sutro.
+func ConfigurationError(err error) error {
+	return &classified{err: err, kind: "configuration", code: Configuration}
+}
+
+// status is the exit code of a command whose exit code is its result.
+type status struct {
+	code int
+}
+
+func (s *status) Error() string { return fmt.Sprintf("exit status %d", s.code) }
+
+// Status makes a command exit with code without presenting anything, for
+// the commands whose exit code is their result, such as sutro authenticate
+// check, which print what they report themselves.
+func Status(code int) error {
+	return &status{code: code}
+}
+
+// Classify marks the errors of the flags and arguments of root and of its
+// subcommands as usage errors.
+func Classify(root *cobra.Command) {
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return UsageError(err)
+	})
+	var walk func(command *cobra.Command)
+	walk = func(command *cobra.Command) {
+		if args := command.Args; args != nil {
+			command.Args = func(cmd *cobra.Command, arguments []string) error {
+				if err := args(cmd, arguments); err != nil {
+					return UsageError(err)
+				}
+				return nil
+			}
+		}
+		for _, child := range command.Commands() {
+			walk(child)
+		}
+	}
+	walk(root)
+}
+
+// Describe returns the presentation of err, which command, when known,
+// failed with.
+func Describe(command *cobra.Command, err error) Presentation {
+	p := Presentation{Error: err.Error(), Kind: "error", Code: Failed}
+
+	var (
+		marked       *classified
+		revoked      *auth.RevokedError
+		unauthorized *strava.UnauthorizedError
+		limited      *strava.RateLimitError
+		network      net.Error
+		held         *lock.HeldError
+	)
+	switch {
+	case errors.As(err, &marked):
+		p.Kind, p.Code = marked.kind, marked.code
+		if marked.code == Usage && command != nil {
+			p.Hint = fmt.Sprintf("Run %s --help for usage", command.CommandPath())
+		}
+	// Cobra reports unknown commands before any command runs.
+	case strings.HasPrefix(err.Error(), "unknown command"):
+		p.Kind, p.Code = "usage", Usage
+		p.Hint = "Run sutro --help for the list of commands"
+	case errors.As(err, &revoked):
+		// The message of the error already tells how to authorize sutro
+		// again.
+		p.Kind, p.Code = "unauthorized", Unauthorized
+	case errors.As(err, &unauthorized):
+		p.Kind, p.Code = "unauthorized", Unauthorized
+		if len(unauthorized.MissingScopes) == 0 {
+			p.Hint = "The token of sutro was refused: run sutro authenticate to authorize sutro again"
+		}
+	case errors.As(err, &limited):
+		p.Kind, p.Code = "rate_limited", RateLimited
+		p.Hint = fmt.Sprintf("The rate limit of the API resets at %s; syncs and exports continue where they stopped with --resume",
+			limited.Reset.Local().Format("15:04"))
+	case errors.Is(err, strava.ErrBudgetExhausted):
+		p.Kind, p.Code = "budget_exhausted", RateLimited
+		p.Hint = "Continue where the job stopped with --resume, or give it a larger --budget"
+	case errors.Is(err, strava.ErrNotFound):
+		p.Kind, p.Code = "not_found", NotFound
+		p.Hint = "Check the id, and that the athlete can see it: private activities need the activity:read_all scope"
+	case errors.Is(err, strava.ErrValidation):
+		p.Kind, p.Code = "invalid_request", Usage
+	case errors.As(err, &held):
+		p.Kind, p.Code = "locked", Locked
+		p.Hint = "Pass --wait to run once the other invocation finishes instead"
+	case errors.Is(err, context.Canceled):
+		p.Kind = "interrupted"
+	case errors.As(err, &network):
+		p.Kind, p.Code = "network", Network
+		p.Hint = "Check the network connection, or run again with --verbose to log the requests"
+	}
+	return p
+}
+
+// Present writes the presentation of err, which command failed with, to
+// writer as JSON or as text, and returns the exit code it maps to.
+func Present(writer io.Writer, command *cobra.Command, err error, asJSON bool) int {
+	var s *status
+	if errors.As(err, &s) {
+		return s.code
+	}
+	p := Describe(command, err)
+	if asJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(p)
+		return p.Code
+	}
+	fmt.Fprintf(writer, "Error: %s\n", p.Error)
+	if p.Hint != "" {
+		fmt.Fprintf(writer, "Hint: %s\n", p.Hint)
+	}
+	return p.Code
+}
@@ -0,0 +1,203 @@
+package files
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+)
+
+// The formats accepted by the Strava upload endpoint, which may also be
+// gzipped.
+const (
+	FIT = "fit"
+	GPX = "gpx"
+	TCX = "tcx"
+)
+
+// File is an activity file decoded locally. Samples without a recorded
+// position have NaN coordinates.
+type File struct {
+	Format string
+	// Gzipped is true for files compressed with gzip, such as ride.fit.gz.
+	Gzipped bool
+	Sport   string
+	Name    string
+	Samples []export.Sample
+	// Distance is the distance reported by the recording device, in
+	// meters, or NaN when the file does not include one.
+	Distance float64
+}
+
+// Format returns the format of a file from its name, along with whether it
+// is gzipped, or an error if Strava does not accept it.
+func Format(filename string) (string, bool, error) {
+	name := strings.ToLower(path.Base(filename))
+	gzipped := strings.HasSuffix(name, ".gz")
+	name = strings.TrimSuffix(name, ".gz")
+
+	switch extension := strings.TrimPrefix(path.Ext(name), "."); extension {
+	case FIT, GPX, TCX:
+		return extension, gzipped, nil
+	default:
+		return "", false, fmt.Errorf("Unsupported file %s, expected a .fit, .gpx or .tcx file, optionally gzipped", filename)
+	}
+}
+
+// Open decodes the activity file at the given path.
+func Open(filename string) (*File, error) {
+	format, gzipped, err := Format(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if gzipped {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	var decoded *File
+	switch format {
+	case FIT:
+		decoded, err = decodeFIT(reader)
+	case GPX:
+		decoded, err = decodeGPX(reader)
+	case TCX:
+		decoded, err = decodeTCX(reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode %s: %v", filename, err)
+	}
+
+	decoded.Format = format
+	decoded.Gzipped = gzipped
+	return decoded, nil
+}
+
+// Start returns the time of the first timed sample, or the zero time.
+func (f *File) Start() time.Time {
+	for _, sample := range f.Samples {
+		if !sample.Time.IsZero() {
+			return sample.Time
+		}
+	}
+	return time.Time{}
+}
+
+// End returns the time of the last timed sample, or the zero time.
+func (f *File) End() time.Time {
+	for i := len(f.Samples) - 1; i >= 0; i-- {
+		if !f.Samples[i].Time.IsZero() {
+			return f.Samples[i].Time
+		}
+	}
+	return time.Time{}
+}
+
+// Positions returns the recorded positions of the file, in order.
+func (f *File) Positions() []geo.Point {
+	var points []geo.Point
+	for _, sample := range f.Samples {
+		if !math.IsNaN(sample.Lat) && !math.IsNaN(sample.Lng) {
+			points = append(points, sample.Point)
+		}
+	}
+	return points
+}
+
+// TotalDistance returns the distance reported by the device, or the
+// length of the recorded track when the file does not report one.
+func (f *File) TotalDistance() float64 {
+	if !math.IsNaN(f.Distance) {
+		return f.Distance
+	}
+	return geo.Length(f.Positions())
+}
+
+// Validate returns the problems that would make Strava reject the file or
+// produce a broken activity. An empty result means the file looks sound.
+func (f *File) Validate() []string {
+	var problems []string
+
+	if len(f.Samples) == 0 {
+		return append(problems, "the file contains no samples")
+	}
+
+	start, end := f.Start(), f.End()
+	switch {
+	case start.IsZero():
+		problems = append(problems, "no sample has a timestamp")
+	case !end.After(start):
+		problems = append(problems, "the recording has no duration")
+	case start.After(time.Now().Add(24 * time.Hour)):
+		problems = append(problems, fmt.Sprintf("the recording starts in the future, on %s", start.Format(time.RFC3339)))
+	case start.Year() < 1990:
+		problems = append(problems, fmt.Sprintf("the recording starts on %s, the device clock was probably not set", start.Format(time.RFC3339)))
+	}
+
+	backwards := 0
+	var previous time.Time
+	for _, sample := range f.Samples {
+		if sample.Time.IsZero() {
+			continue
+		}
+		if !previous.IsZero() && sample.Time.Before(previous) {
+			backwards++
+		}
+		previous = sample.Time
+	}
+	if backwards > 0 {
+		problems = append(problems, fmt.Sprintf("%d samples go back in time", backwards))
+	}
+
+	invalid := 0
+	for _, sample := range f.Samples {
+		if math.Abs(sample.Lat) > 90 || math.Abs(sample.Lng) > 180 {
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		problems = append(problems, fmt.Sprintf("%d samples have coordinates out of range", invalid))
+	}
+
+	if len(f.Positions()) == 0 && math.IsNaN(f.Distance) {
+		problems = append(problems, "the file has neither positions nor a distance")
+	}
+	return problems
+}
+
+// value returns the decoded number, or NaN when the element was missing.
+func value(number *float64) float64 {
+	if number == nil {
+		return math.NaN()
+	}
+	return *number
+}
+
+// parseTime parses the timestamps of GPX and TCX files, which are RFC3339
+// with or without fractional seconds.
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid timestamp %q", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,287 @@
+package files
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+)
+
+// The global message numbers and field numbers of the FIT profile that
+// sutro reads. Every other message is skipped.
+const (
+	fitSession = 18
+	fitRecord  = 20
+
+	fitSessionSport         = 5
+	fitSessionTotalDistance = 9
+
+	fitRecordLat              = 0
+	fitRecordLng              = 1
+	fitRecordAltitude         = 2
+	fitRecordHeartrate        = 3
+	fitRecordCadence          = 4
+	fitRecordPower            = 7
+	fitRecordEnhancedAltitude = 78
+
+	fitTimestamp = 253
+)
+
+// fitEpoch is the origin of FIT timestamps.
+var fitEpoch = time.Date(1989, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+var fitSports = map[uint64]string{
+	0:  "Generic",
+	1:  "Run",
+	2:  "Ride",
+	4:  "Workout",
+	5:  "Swim",
+	10: "Workout",
+	11: "Walk",
+	13: "AlpineSki",
+	15: "Rowing",
+	17: "Hike",
+	19: "Kayaking",
+	21: "InlineSkate",
+	37: "StandUpPaddling",
+}
+
+type fitField struct {
+	number   byte
+	size     int
+	baseType byte
+}
+
+type fitDefinition struct {
+	global    uint16
+	order     binary.ByteOrder
+	fields    []fitField
+	developer int
+}
+
+// decodeFIT decodes the record and session messages of a FIT file, after
+// checking its header and CRC.
+func decodeFIT(reader io.Reader) (*File, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 12 {
+		return nil, errors.New("The file is too short to be a FIT file")
+	}
+	headerSize := int(data[0])
+	if (headerSize != 12 && headerSize != 14) || !bytes.Equal(data[8:12], []byte(".FIT")) {
+		return nil, errors.New("The file does not have a FIT header")
+	}
+	end := headerSize + int(binary.LittleEndian.Uint32(data[4:8]))
+	if len(data) < end+2 {
+		return nil, fmt.Errorf("The file is truncated, expected %d bytes but found %d", end+2, len(data))
+	}
+	if expected := binary.LittleEndian.Uint16(data[end : end+2]); fitCRC(data[:end]) != expected {
+		return nil, errors.New("The file is corrupt, its checksum does not match")
+	}
+
+	file := &File{Distance: math.NaN()}
+	definitions := map[byte]*fitDefinition{}
+	var timestamp uint32
+
+	for offset := headerSize; offset < end; {
+		header := data[offset]
+		offset++
+
+		local := header & 0x0f
+		compressed := header&0x80 != 0
+		if compressed {
+			local = (header >> 5) & 0x03
+			delta := uint32(header & 0x1f)
+			if delta >= timestamp&0x1f {
+				timestamp = timestamp&^0x1f + delta
+			} else {
+				timestamp = timestamp&^0x1f + delta + 0x20
+			}
+		}
+
+		if !compressed && header&0x40 != 0 {
+			definition, size, err := readFITDefinition(data[offset:end], header&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			definitions[local] = definition
+			offset += size
+			continue
+		}
+
+		definition, ok := definitions[local]
+		if !ok {
+			return nil, fmt.Errorf("The file is corrupt, a message at byte %d has no definition", offset-1)
+		}
+
+		values := map[byte]uint64{}
+		for _, field := range definition.fields {
+			if offset+field.size > end {
+				return nil, errors.New("The file is corrupt, a message is truncated")
+			}
+			if v, ok := fitValue(data[offset:offset+field.size], field.baseType, definition.order); ok {
+				values[field.number] = v
+			}
+			offset += field.size
+		}
+		offset += definition.developer
+		if offset > end {
+			return nil, errors.New("The file is corrupt, a message is truncated")
+		}
+
+		if v, ok := values[fitTimestamp]; ok {
+			timestamp = uint32(v)
+		}
+
+		switch definition.global {
+		case fitRecord:
+			file.Samples = append(file.Samples, fitSample(values, timestamp, compressed))
+		case fitSession:
+			if v, ok := values[fitSessionSport]; ok && file.Sport == "" {
+				file.Sport = fitSports[v]
+			}
+			if v, ok := values[fitSessionTotalDistance]; ok {
+				if math.IsNaN(file.Distance) {
+					file.Distance = 0
+				}
+				file.Distance += float64(v) / 100
+			}
+		}
+	}
+	return file, nil
+}
+
+func readFITDefinition(data []byte, developer bool) (*fitDefinition, int, error) {
+	if len(data) < 5 {
+		return nil, 0, errors.New("The file is corrupt, a definition is truncated")
+	}
+
+	definition := &fitDefinition{order: binary.LittleEndian}
+	if data[1] == 1 {
+		definition.order = binary.BigEndian
+	}
+	definition.global = definition.order.Uint16(data[2:4])
+
+	count := int(data[4])
+	size := 5 + 3*count
+	if len(data) < size {
+		return nil, 0, errors.New("The file is corrupt, a definition is truncated")
+	}
+	for i := 0; i < count; i++ {
+		field := data[5+3*i : 8+3*i]
+		definition.fields = append(definition.fields, fitField{number: field[0], size: int(field[1]), baseType: field[2]})
+	}
+
+	if developer {
+		if len(data) < size+1 {
+			return nil, 0, errors.New("The file is corrupt, a definition is truncated")
+		}
+		count := int(data[size])
+		size++
+		if len(data) < size+3*count {
+			return nil, 0, errors.New("The file is corrupt, a definition is truncated")
+		}
+		for i := 0; i < count; i++ {
+			definition.developer += int(data[size+3*i+1])
+		}
+		size += 3 * count
+	}
+	return definition, size, nil
+}
+
+// fitValue decodes an integer field, reporting false for the invalid value
+// of its base type, and for types and sizes sutro does not need.
+func fitValue(data []byte, baseType byte, order binary.ByteOrder) (uint64, bool) {
+	switch baseType & 0x1f {
+	case 0x00, 0x02: // enum, uint8
+		if len(data) != 1 || data[0] == 0xff {
+			return 0, false
+		}
+		return uint64(data[0]), true
+	case 0x0a: // uint8z
+		if len(data) != 1 || data[0] == 0 {
+			return 0, false
+		}
+		return uint64(data[0]), true
+	case 0x04: // uint16
+		if len(data) != 2 || order.Uint16(data) == math.MaxUint16 {
+			return 0, false
+		}
+		return uint64(order.Uint16(data)), true
+	case 0x05: // sint32
+		if len(data) != 4 || order.Uint32(data) == math.MaxInt32 {
+			return 0, false
+		}
+		return uint64(int64(int32(order.Uint32(data)))), true
+	case 0x06: // uint32
+		if len(data) != 4 || order.Uint32(data) == math.MaxUint32 {
+			return 0, false
+		}
+		return uint64(order.Uint32(data)), true
+	default:
+		return 0, false
+	}
+}
+
+func fitSample(values map[byte]uint64, timestamp uint32, compressed bool) export.Sample {
+	scaled := func(field byte, scale, offset float64) float64 {
+		if v, ok := values[field]; ok {
+			return float64(v)/scale - offset
+		}
+		return math.NaN()
+	}
+
+	sample := export.Sample{
+		Point:     geo.Point{Lat: math.NaN(), Lng: math.NaN()},
+		Elevation: scaled(fitRecordEnhancedAltitude, 5, 500),
+		Heartrate: scaled(fitRecordHeartrate, 1, 0),
+		Cadence:   scaled(fitRecordCadence, 1, 0),
+		Watts:     scaled(fitRecordPower, 1, 0),
+	}
+	if math.IsNaN(sample.Elevation) {
+		sample.Elevation = scaled(fitRecordAltitude, 5, 500)
+	}
+
+	// Positions are in semicircles, signed 32 bit integers spanning 360°.
+	lat, hasLat := values[fitRecordLat]
+	lng, hasLng := values[fitRecordLng]
+	if hasLat && hasLng {
+		sample.Lat = float64(int32(lat)) * 180 / (1 << 31)
+		sample.Lng = float64(int32(lng)) * 180 / (1 << 31)
+	}
+
+	if _, ok := values[fitTimestamp]; ok || compressed {
+		sample.Time = fitEpoch.Add(time.Duration(timestamp) * time.Second)
+	}
+	return sample
+}
+
+var fitCRCTable = [16]uint16{
+	0x0000, 0xcc01, 0xd801, 0x1400, 0xf001, 0x3c00, 0x2800, 0xe401,
+	0xa001, 0x6c00, 0x7800, 0xb401, 0x5000, 0x9c01, 0x8801, 0x4400,
+}
+
+// fitCRC computes the CRC-16 that FIT files end with.
+func fitCRC(data []byte) uint16 {
+	crc := uint16(0)
+	for _, b := range data {
+		tmp := fitCRCTable[crc&0xf]
+		crc = (crc >> 4) & 0x0fff
+		crc = crc ^ tmp ^ fitCRCTable[b&0xf]
+
+		tmp = fitCRCTable[crc&0xf]
+		crc = (crc >> 4) & 0x0fff
+		crc = crc ^ tmp ^ fitCRCTable[(b>>4)&0xf]
+	}
+	return crc
+}
@@ -0,0 +1,66 @@
+package files
+
+import (
+	"encoding/xml"
+	"io"
+	"math"
+
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+)
+
+// The decoding structures match elements by local name, so that the Garmin
+// track point extension is read whatever prefix the file binds it to.
+type gpxDocument struct {
+	Tracks []struct {
+		Name     string `xml:"name"`
+		Type     string `xml:"type"`
+		Segments []struct {
+			Points []gpxTrackPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+type gpxTrackPoint struct {
+	Lat       float64  `xml:"lat,attr"`
+	Lon       float64  `xml:"lon,attr"`
+	Elevation *float64 `xml:"ele"`
+	Time      string   `xml:"time"`
+	Heartrate *float64 `xml:"extensions>TrackPointExtension>hr"`
+	Cadence   *float64 `xml:"extensions>TrackPointExtension>cad"`
+	Power     *float64 `xml:"extensions>power"`
+}
+
+func decodeGPX(reader io.Reader) (*File, error) {
+	var document gpxDocument
+	if err := xml.NewDecoder(reader).Decode(&document); err != nil {
+		return nil, err
+	}
+
+	file := &File{Distance: math.NaN()}
+	for _, track := range document.Tracks {
+		if file.Name == "" {
+			file.Name, file.Sport = track.Name, track.Type
+		}
+		for _, segment := range track.Segments {
+			for _, point := range segment.Points {
+				sample := export.Sample{
+					Point:     geo.Point{Lat: point.Lat, Lng: point.Lon},
+					Elevation: value(point.Elevation),
+					Heartrate: value(point.Heartrate),
+					Cadence:   value(point.Cadence),
+					Watts:     value(point.Power),
+				}
+				if point.Time != "" {
+					t, err := parseTime(point.Time)
+					if err != nil {
+						return nil, err
+					}
+					sample.Time = t
+				}
+				file.Samples = append(file.Samples, sample)
+			}
+		}
+	}
+	return file, nil
+}
@@ -0,0 +1,71 @@
+package files
+
+import (
+	"encoding/xml"
+	"io"
+	"math"
+
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+)
+
+type tcxDocument struct {
+	Activities []struct {
+		Sport string `xml:"Sport,attr"`
+		Laps  []struct {
+			Distance *float64        `xml:"DistanceMeters"`
+			Points   []tcxTrackPoint `xml:"Track>Trackpoint"`
+		} `xml:"Lap"`
+	} `xml:"Activities>Activity"`
+}
+
+type tcxTrackPoint struct {
+	Time      string   `xml:"Time"`
+	Lat       *float64 `xml:"Position>LatitudeDegrees"`
+	Lng       *float64 `xml:"Position>LongitudeDegrees"`
+	Altitude  *float64 `xml:"AltitudeMeters"`
+	Heartrate *float64 `xml:"HeartRateBpm>Value"`
+	Cadence   *float64 `xml:"Cadence"`
+	Watts     *float64 `xml:"Extensions>TPX>Watts"`
+}
+
+func decodeTCX(reader io.Reader) (*File, error) {
+	var document tcxDocument
+	if err := xml.NewDecoder(reader).Decode(&document); err != nil {
+		return nil, err
+	}
+
+	file := &File{Distance: math.NaN()}
+	for _, activity := range document.Activities {
+		if file.Sport == "" {
+			file.Sport = activity.Sport
+		}
+		for _, lap := range activity.Laps {
+			if lap.Distance != nil {
+				if math.IsNaN(file.Distance) {
+					file.Distance = 0
+				}
+				file.Distance += *lap.Distance
+			}
+
+			for _, point := range lap.Points {
+				sample := export.Sample{
+					Point:     geo.Point{Lat: value(point.Lat), Lng: value(point.Lng)},
+					Elevation: value(point.Altitude),
+					Heartrate: value(point.Heartrate),
+					Cadence:   value(point.Cadence),
+					Watts:     value(point.Watts),
+				}
+				if point.Time != "" {
+					t, err := parseTime(point.Time)
+					if err != nil {
+						return nil, err
+					}
+					sample.Time = t
+				}
+				file.Samples = append(file.Samples, sample)
+			}
+		}
+	}
+	return file, nil
+}
@@ -0,0 +1,37 @@
+package format
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jsilland/sutro/locale"
+)
+
+// Duration renders a number of seconds as m:ss, or h:mm:ss past an hour.
+func Duration(seconds float64) string {
+	total := int64(math.Round(seconds))
+	if total < 0 {
+		return "-" + Duration(-seconds)
+	}
+
+	hours, minutes, remainder := total/3600, total%3600/60, total%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, remainder)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, remainder)
+}
+
+// Pace renders a pace given in minutes per kilometer as m:ss /km.
+func Pace(minutesPerKilometer float64) string {
+	minutes := math.Floor(minutesPerKilometer)
+	seconds := math.Round((minutesPerKilometer - minutes) * 60)
+	if seconds == 60 {
+		minutes, seconds = minutes+1, 0
+	}
+	return fmt.Sprintf("%.0f:%02.0f /km", minutes, seconds)
+}
+
+// Kilometers renders a distance given in meters as kilometers.
+func Kilometers(meters float64) string {
+	return locale.Number(meters/1000, 2) + " km"
+}
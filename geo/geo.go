@@ -0,0 +1,214 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const earthRadius = 6371008.8
+
+// Point is a position on the globe, in decimal degrees.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// DecodePolyline decodes a Google encoded polyline, as returned in the
+// map of activities, segments and routes.
+func DecodePolyline(encoded string) ([]Point, error) {
+	var points []Point
+
+	lat, lng := 0, 0
+	for index := 0; index < len(encoded); {
+		var deltas [2]int
+		for i := range deltas {
+			result, shift := 0, uint(0)
+			for {
+				if index >= len(encoded) {
+					return nil, errors.New("Truncated polyline")
+				}
+				b := int(encoded[index]) - 63
+				index++
+				if b < 0 {
+					return nil, fmt.Errorf("Invalid polyline character at position %d", index-1)
+				}
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[i] = ^(result >> 1)
+			} else {
+				deltas[i] = result >> 1
+			}
+		}
+
+		lat += deltas[0]
+		lng += deltas[1]
+		points = append(points, Point{Lat: float64(lat) / 1e5, Lng: float64(lng) / 1e5})
+	}
+	return points, nil
+}
+
+// Distance returns the great-circle distance between two points, in meters.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat, dLng := lat2-lat1, radians(b.Lng-a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Length returns the length of a track, in meters.
+func Length(track []Point) float64 {
+	total := 0.0
+	for i := 1; i < len(track); i++ {
+		total += Distance(track[i-1], track[i])
+	}
+	return total
+}
+
+// Resample returns points spaced evenly every spacing meters along the
+// track, including its first and last points.
+func Resample(track []Point, spacing float64) []Point {
+	if len(track) < 2 || spacing <= 0 {
+		return track
+	}
+
+	resampled := []Point{track[0]}
+	carried := 0.0
+	for i := 1; i < len(track); i++ {
+		from, to := track[i-1], track[i]
+		length := Distance(from, to)
+		for carried+length >= spacing {
+			ratio := (spacing - carried) / length
+			from = Point{
+				Lat: from.Lat + ratio*(to.Lat-from.Lat),
+				Lng: from.Lng + ratio*(to.Lng-from.Lng),
+			}
+			resampled = append(resampled, from)
+			length = Distance(from, to)
+			carried = 0
+		}
+		carried += length
+	}
+
+	if last := track[len(track)-1]; resampled[len(resampled)-1] != last {
+		resampled = append(resampled, last)
+	}
+	return resampled
+}
+
+// DistanceToTrack returns the shortest distance from a point to any
+// segment of a track, in meters.
+func DistanceToTrack(p Point, track []Point) float64 {
+	if len(track) == 1 {
+		return Distance(p, track[0])
+	}
+
+	shortest := math.Inf(1)
+	for i := 1; i < len(track); i++ {
+		shortest = math.Min(shortest, distanceToSegment(p, track[i-1], track[i]))
+	}
+	return shortest
+}
+
+// Overlap returns the fraction of the points of a that lie within
+// tolerance meters of track b.
+func Overlap(a, b []Point, tolerance float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	within := 0
+	for _, p := range a {
+		if DistanceToTrack(p, b) <= tolerance {
+			within++
+		}
+	}
+	return float64(within) / float64(len(a))
+}
+
+// SameCourse reports whether two tracks cover the same course: at least
+// threshold of the points of each must lie within tolerance meters of the
+// other, regardless of direction. Both tracks should have been resampled
+// at a spacing no greater than the tolerance.
+func SameCourse(a, b []Point, tolerance, threshold float64) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+
+	lengthA, lengthB := Length(a), Length(b)
+	if math.Abs(lengthA-lengthB) > math.Max(lengthA, lengthB)*(1-threshold)+tolerance {
+		return false
+	}
+
+	return Overlap(a, b, tolerance) >= threshold && Overlap(b, a, tolerance) >= threshold
+}
+
+// ParseDistance parses a distance such as 100m, 1.5km or 2mi into meters.
+// A bare number is interpreted as meters.
+func ParseDistance(value string) (float64, error) {
+	units := []struct {
+		suffix string
+		meters float64
+	}{
+		{"km", 1000},
+		{"mi", 1609.344},
+		{"ft", 0.3048},
+		{"m", 1},
+	}
+
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+	multiplier := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(trimmed, unit.suffix) {
+			trimmed = strings.TrimSuffix(trimmed, unit.suffix)
+			multiplier = unit.meters
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(trimmed), 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("Invalid distance %q, expected a value such as 100m or 1.5km", value)
+	}
+	return number * multiplier, nil
+}
+
+// Zone is a circular area around a center point.
+type Zone struct {
+	Center Point
+	Radius float64
+}
+
+// Contains reports whether a point lies within the zone.
+func (z Zone) Contains(p Point) bool {
+	return Distance(z.Center, p) <= z.Radius
+}
+
+// distanceToSegment projects the segment on a local equirectangular plane
+// centered on p, which is accurate enough at the scale of a GPS track.
+func distanceToSegment(p, a, b Point) float64 {
+	scale := math.Cos(radians(p.Lat))
+	ax, ay := radians(a.Lng-p.Lng)*scale, radians(a.Lat-p.Lat)
+	bx, by := radians(b.Lng-p.Lng)*scale, radians(b.Lat-p.Lat)
+
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if lengthSquared := dx*dx + dy*dy; lengthSquared > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSquared))
+	}
+
+	x, y := ax+t*dx, ay+t*dy
+	return math.Sqrt(x*x+y*y) * earthRadius
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,86 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+// EncodePolyline encodes a track as a Google encoded polyline, rounding its
+// points to five decimals as the API does.
+func EncodePolyline(track []Point) string {
+	var encoded strings.Builder
+	previousLat, previousLng := 0, 0
+	for _, p := range track {
+		lat, lng := int(math.Round(p.Lat*1e5)), int(math.Round(p.Lng*1e5))
+		encodeValue(&encoded, lat-previousLat)
+		encodeValue(&encoded, lng-previousLng)
+		previousLat, previousLng = lat, lng
+	}
+	return encoded.String()
+}
+
+func encodeValue(encoded *strings.Builder, value int) {
+	shifted := value << 1
+	if value < 0 {
+		shifted = ^shifted
+	}
+	for shifted >= 0x20 {
+		encoded.WriteByte(byte((0x20 | (shifted & 0x1f)) + 63))
+		shifted >>= 5
+	}
+	encoded.WriteByte(byte(shifted + 63))
+}
+
+// Simplify returns the points of track that the Douglas-Peucker algorithm
+// keeps, so that no point left out is further than tolerance meters from
+// the simplified track. The first and last points are always kept.
+func Simplify(track []Point, tolerance float64) []Point {
+	if len(track) < 3 {
+		return append([]Point(nil), track...)
+	}
+
+	kept := make([]bool, len(track))
+	kept[0], kept[len(track)-1] = true, true
+	// The ranges left to simplify are kept on a stack rather than handled
+	// recursively, as recorded tracks can have tens of thousands of points.
+	stack := [][2]int{{0, len(track) - 1}}
+	for len(stack) > 0 {
+		first, last := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		farthest, distance := -1, tolerance
+		for i := first + 1; i < last; i++ {
+			if d := distanceToSegment(track[i], track[first], track[last]); d > distance {
+				farthest, distance = i, d
+			}
+		}
+		if farthest >= 0 {
+			kept[farthest] = true
+			stack = append(stack, [2]int{first, farthest}, [2]int{farthest, last})
+		}
+	}
+
+	var simplified []Point
+	for i, p := range track {
+		if kept[i] {
+			simplified = append(simplified, p)
+		}
+	}
+	return simplified
+}
+
+// Bounds is the smallest box of latitudes and longitudes enclosing a
+// track.
+type Bounds struct {
+	South, West, North, East float64
+}
+
+// BoundsOf returns the bounds of track, which has at least a point.
+func BoundsOf(track []Point) Bounds {
+	b := Bounds{South: math.Inf(1), West: math.Inf(1), North: math.Inf(-1), East: math.Inf(-1)}
+	for _, p := range track {
+		b.South, b.North = math.Min(b.South, p.Lat), math.Max(b.North, p.Lat)
+		b.West, b.East = math.Min(b.West, p.Lng), math.Max(b.East, p.Lng)
+	}
+	return b
+}
@@ -9,6 +9,13 @@ require (
 	github.com/go-openapi/swag v0.19.9
 	github.com/go-openapi/validate v0.19.8
 	github.com/google/uuid v1.1.1
+	github.com/mattn/go-sqlite3 v1.14.0
+	github.com/nicksnyder/go-i18n/v2 v2.1.1
 	github.com/spf13/cobra v1.0.0
+	github.com/xitongsys/parquet-go v1.5.4
+	github.com/xitongsys/parquet-go-source v0.0.0-20200817004010-026bad9b25d0
+	go.starlark.net v0.0.0-20200821142938-949cc6f4b097
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
+	golang.org/x/text v0.3.3
+	gopkg.in/yaml.v2 v2.3.0
 )
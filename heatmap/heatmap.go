@@ -0,0 +1,194 @@
+package heatmap
+
+import (
+	"errors"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/jsilland/sutro/geo"
+)
+
+// RenderPNG rasterizes the tracks onto an image whose longer side is size
+// pixels, preserving the aspect ratio of their bounds in the Web Mercator
+// projection. Pixels crossed by more tracks are rendered brighter.
+func RenderPNG(writer io.Writer, tracks [][]geo.Point, size int) error {
+	minX, minY, maxX, maxY, ok := projectedBounds(tracks)
+	if !ok {
+		return errors.New("No track to render")
+	}
+
+	margin := 0.02 * math.Max(maxX-minX, maxY-minY)
+	minX, minY, maxX, maxY = minX-margin, minY-margin, maxX+margin, maxY+margin
+	// The longer side is scaled to size, so that tracks running north to
+	// south do not make the image as tall as they are narrow.
+	scale := float64(size-1) / math.Max(math.Max(maxX-minX, maxY-minY), 1e-9)
+	width := int(math.Ceil((maxX-minX)*scale)) + 1
+	height := int(math.Ceil((maxY-minY)*scale)) + 1
+
+	counts := make([]int, width*height)
+	for _, track := range tracks {
+		// Each track only counts once per pixel, so that a single GPS
+		// wobble does not light up a street.
+		visited := map[int]bool{}
+		for i := 1; i < len(track); i++ {
+			x0, y0 := mercator(track[i-1])
+			x1, y1 := mercator(track[i])
+			line(
+				int((x0-minX)*scale), int((maxY-y0)*scale),
+				int((x1-minX)*scale), int((maxY-y1)*scale),
+				func(x, y int) {
+					if x < 0 || y < 0 || x >= width || y >= height {
+						return
+					}
+					index := y*width + x
+					if !visited[index] {
+						visited[index] = true
+						counts[index]++
+					}
+				},
+			)
+		}
+	}
+
+	max := 0
+	for _, count := range counts {
+		if count > max {
+			max = count
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, heat(counts[y*width+x], max))
+		}
+	}
+	return png.Encode(writer, img)
+}
+
+var page = template.Must(template.New("heatmap").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="https://unpkg.com/leaflet@1.9.4/dist/leaflet.css">
+<script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js"></script>
+<script src="https://unpkg.com/leaflet.heat@0.2.0/dist/leaflet-heat.js"></script>
+<style>html, body, #map { height: 100%; margin: 0; }</style>
+</head>
+<body>
+<div id="map"></div>
+<script>
+var points = {{.Points}};
+var map = L.map('map');
+L.tileLayer('https://{s}.basemaps.cartocdn.com/dark_all/{z}/{x}/{y}{r}.png', {
+  attribution: '&copy; OpenStreetMap contributors &copy; CARTO',
+  maxZoom: 19
+}).addTo(map);
+L.heatLayer(points, {radius: 4, blur: 6, minOpacity: 0.3}).addTo(map);
+map.fitBounds(points);
+</script>
+</body>
+</html>
+`))
+
+// RenderHTML writes an interactive Leaflet page showing the tracks as a
+// heat layer over a base map. Tracks should be resampled beforehand to keep
+// the page reasonably small.
+func RenderHTML(writer io.Writer, title string, tracks [][]geo.Point) error {
+	points := [][2]float64{}
+	for _, track := range tracks {
+		for _, point := range track {
+			points = append(points, [2]float64{
+				math.Round(point.Lat*1e5) / 1e5,
+				math.Round(point.Lng*1e5) / 1e5,
+			})
+		}
+	}
+	if len(points) == 0 {
+		return errors.New("No track to render")
+	}
+
+	return page.Execute(writer, struct {
+		Title  string
+		Points [][2]float64
+	}{title, points})
+}
+
+// mercator projects a point on the unit Web Mercator square.
+func mercator(p geo.Point) (float64, float64) {
+	x := (p.Lng + 180) / 360
+	lat := p.Lat * math.Pi / 180
+	y := 0.5 - math.Log(math.Tan(lat)+1/math.Cos(lat))/(2*math.Pi)
+	return x, 1 - y
+}
+
+func projectedBounds(tracks [][]geo.Point) (float64, float64, float64, float64, bool) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, track := range tracks {
+		for _, point := range track {
+			x, y := mercator(point)
+			minX, minY = math.Min(minX, x), math.Min(minY, y)
+			maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+		}
+	}
+	return minX, minY, maxX, maxY, !math.IsInf(minX, 1)
+}
+
+// line calls plot for every pixel of the segment between two pixels,
+// following Bresenham's algorithm.
+func line(x0, y0, x1, y1 int, plot func(int, int)) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	err := dx + dy
+	for {
+		plot(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// heat maps a pixel count to a color going from black through red and
+// orange to white, on a logarithmic scale.
+func heat(count, max int) color.Color {
+	if count == 0 || max == 0 {
+		return color.RGBA{A: 255}
+	}
+
+	ratio := math.Log1p(float64(count)) / math.Log1p(float64(max))
+	ratio = 0.35 + 0.65*ratio
+	channel := func(from, to float64) uint8 {
+		value := (ratio - from) / (to - from)
+		return uint8(255 * math.Max(0, math.Min(1, value)))
+	}
+	return color.RGBA{R: channel(0, 0.5), G: channel(0.4, 0.85), B: channel(0.8, 1), A: 255}
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,30 @@
+package heatmap
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/jsilland/sutro/geo"
+)
+
+func TestRenderPNGNorthSouth(t *testing.T) {
+	// A straight run from north to south has no east–west extent.
+	var track []geo.Point
+	for lat := 37.0; lat <= 38.0; lat += 0.001 {
+		track = append(track, geo.Point{Lat: lat, Lng: -122.42})
+	}
+
+	var encoded bytes.Buffer
+	if err := RenderPNG(&encoded, [][]geo.Point{track}, 1600); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(&encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := decoded.Bounds()
+	if bounds.Dy() != 1600 || bounds.Dx() > 1600 {
+		t.Errorf("Expected an image 1600 pixels tall and at most as wide, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
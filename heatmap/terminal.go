@@ -0,0 +1,118 @@
+package heatmap
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jsilland/sutro/geo"
+)
+
+// brailleDots are the bits of the dots of a braille character, by row and
+// column of the dot within the character.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// RenderText draws track on a grid at most width characters wide and
+// height lines tall, preserving the aspect ratio of its bounds with
+// characters twice as tall as they are wide. Braille characters draw 2 by
+// 4 dots each, and otherwise the track is drawn with asterisks. S marks
+// the start of the track and F its finish.
+func RenderText(writer io.Writer, track []geo.Point, width, height int, braille bool) error {
+	if len(track) == 0 {
+		return errors.New("No track to render")
+	}
+	if width < 2 || height < 2 {
+		return errors.New("The map must be at least 2 characters wide and 2 lines tall")
+	}
+	minX, minY, maxX, maxY, _ := projectedBounds([][]geo.Point{track})
+
+	// Dots are square in braille, while a character drawing a single dot
+	// is twice as tall as it is wide.
+	dotsX, dotsY, aspect := width, height, 2.0
+	if braille {
+		dotsX, dotsY, aspect = width*2, height*4, 1
+	}
+	spanX, spanY := math.Max(maxX-minX, 1e-12), math.Max(maxY-minY, 1e-12)
+	scale := math.Min(float64(dotsX-1)/spanX, float64(dotsY-1)*aspect/spanY)
+	dotsX = int(math.Round(spanX*scale)) + 1
+	dotsY = int(math.Round(spanY*scale/aspect)) + 1
+
+	project := func(p geo.Point) (int, int) {
+		x, y := mercator(p)
+		return int(math.Round((x - minX) * scale)), int(math.Round((maxY - y) * scale / aspect))
+	}
+
+	dots := make([][]bool, dotsY)
+	for y := range dots {
+		dots[y] = make([]bool, dotsX)
+	}
+	plot := func(x, y int) {
+		if x >= 0 && y >= 0 && x < dotsX && y < dotsY {
+			dots[y][x] = true
+		}
+	}
+	x0, y0 := project(track[0])
+	plot(x0, y0)
+	for _, point := range track[1:] {
+		x1, y1 := project(point)
+		line(x0, y0, x1, y1, plot)
+		x0, y0 = x1, y1
+	}
+
+	cellX, cellY := 1, 1
+	if braille {
+		cellX, cellY = 2, 4
+	}
+	columns, rows := (dotsX+cellX-1)/cellX, (dotsY+cellY-1)/cellY
+	grid := make([][]rune, rows)
+	for row := range grid {
+		grid[row] = make([]rune, columns)
+		for column := range grid[row] {
+			grid[row][column] = cell(dots, column, row, braille)
+		}
+	}
+
+	// The start is marked last, as it shares its character with the
+	// finish of loops.
+	finishX, finishY := project(track[len(track)-1])
+	grid[finishY/cellY][finishX/cellX] = 'F'
+	startX, startY := project(track[0])
+	grid[startY/cellY][startX/cellX] = 'S'
+
+	for _, row := range grid {
+		if _, err := io.WriteString(writer, strings.TrimRight(string(row), " ")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cell returns the character drawing the dots of a cell of the grid.
+func cell(dots [][]bool, column, row int, braille bool) rune {
+	if !braille {
+		if dots[row][column] {
+			return '*'
+		}
+		return ' '
+	}
+
+	var pattern rune
+	for dy := 0; dy < 4; dy++ {
+		for dx := 0; dx < 2; dx++ {
+			y, x := row*4+dy, column*2+dx
+			if y < len(dots) && x < len(dots[y]) && dots[y][x] {
+				pattern |= brailleDots[dy][dx]
+			}
+		}
+	}
+	if pattern == 0 {
+		return ' '
+	}
+	return 0x2800 + pattern
+}
@@ -0,0 +1,78 @@
+// Package history keeps track of the ids that commands return, such as the
+// activities a sync archived or the one a create made, so that the next
+// command can refer to them as @last instead of copying them.
+package history
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/jsilland/sutro/store"
+)
+
+// Last is the placeholder for the ids returned by the last command that
+// returned any.
+const Last = "@last"
+
+type recorderKey struct{}
+
+// Recorder collects the ids returned by a command.
+type Recorder struct {
+	mutex sync.Mutex
+	ids   []int64
+}
+
+// NewContext returns a context whose commands return their ids to recorder.
+func NewContext(ctx context.Context, recorder *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// Returned records ids as returned by the command running with ctx. It does
+// nothing when ctx has no recorder, as when commands are used as a library.
+func Returned(ctx context.Context, ids ...int64) {
+	recorder, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok || recorder == nil {
+		return
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.ids = append(recorder.ids, ids...)
+}
+
+// IDs returns the ids recorded so far, in the order they were returned.
+func (r *Recorder) IDs() []int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]int64(nil), r.ids...)
+}
+
+// Expand replaces each @last argument by the ids returned by the last
+// command of the history that returned any.
+func Expand(args []string, archive *store.Store) ([]string, error) {
+	var ids []int64
+	var expanded []string
+	for _, arg := range args {
+		if arg != Last {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		if ids == nil {
+			last, err := archive.LastReturned()
+			if err != nil {
+				return nil, err
+			}
+			if len(last) == 0 {
+				return nil, errors.New("No previous command returned ids to substitute for @last, see sutro history")
+			}
+			ids = last
+		}
+		for _, id := range ids {
+			expanded = append(expanded, strconv.FormatInt(id, 10))
+		}
+	}
+	return expanded, nil
+}
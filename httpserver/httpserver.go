@@ -0,0 +1,46 @@
+// Package httpserver serves the local HTTP endpoints of sutro, such as the
+// archive API and the metrics, until the command is interrupted.
+package httpserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// shutdownTimeout bounds how long requests in flight may take to complete
+// once the server is shut down.
+const shutdownTimeout = 5 * time.Second
+
+// ListenAndServe serves handler on address until ctx is done, then shuts the
+// server down gracefully and returns nil.
+func ListenAndServe(ctx context.Context, address string, handler http.Handler) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, listener, handler)
+}
+
+// Serve serves handler on listener until ctx is done, as ListenAndServe
+// does, for the commands that need to know the server listens before it
+// serves.
+func Serve(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	server := &http.Server{Handler: handler}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,99 @@
+// Package jobs shares the budget of a job between its steps. sutro jobs run
+// runs each step as a command of its own, which finds the budget it may
+// spend in the file named by Environment and records there the requests it
+// sent once it is done.
+package jobs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/jsilland/sutro/strava"
+)
+
+// Environment is the variable naming the file of the allowance of the job
+// a command runs as a step of.
+const Environment = "SUTRO_JOB_ALLOWANCE"
+
+// Allowance is the budget of a job and what its steps spent of it so far.
+type Allowance struct {
+	// Requests is the number of requests the steps may send, or 0 for no
+	// limit, and Deadline the time after which they may send none, or the
+	// zero time for no limit.
+	Requests int       `json:"requests,omitempty"`
+	Deadline time.Time `json:"deadline,omitempty"`
+	// Sent is the number of requests sent by the steps that ran.
+	Sent int `json:"sent"`
+}
+
+// Exhausted reports whether the allowance leaves no request to send.
+func (a Allowance) Exhausted() bool {
+	return a.Requests > 0 && a.Sent >= a.Requests || !a.Deadline.IsZero() && !time.Now().Before(a.Deadline)
+}
+
+// Read reads the allowance in the file at path.
+func Read(path string) (Allowance, error) {
+	var allowance Allowance
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return allowance, err
+	}
+	err = json.Unmarshal(data, &allowance)
+	return allowance, err
+}
+
+// Write writes allowance to the file at path.
+func Write(path string, allowance Allowance) error {
+	data, err := json.Marshal(allowance)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// Step is a command running as a step of a job, within what remains of its
+// allowance.
+type Step struct {
+	path      string
+	allowance Allowance
+	budget    *strava.Budget
+}
+
+// Join bounds the requests of apiClient by the allowance of the job the
+// command runs as a step of, if any, and returns the step, or nil when the
+// command does not run as a step.
+func Join(apiClient *strava.Client) (*Step, error) {
+	path := os.Getenv(Environment)
+	if path == "" {
+		return nil, nil
+	}
+	allowance, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &strava.Budget{Deadline: allowance.Deadline}
+	if allowance.Requests > 0 {
+		b.Requests = allowance.Requests - allowance.Sent
+		if b.Requests <= 0 {
+			// A budget of no request would be unlimited.
+			b.Deadline = time.Now()
+		}
+	}
+	if apiClient != nil {
+		apiClient.Use(b.Interceptor())
+	}
+	return &Step{path: path, allowance: allowance, budget: b}, nil
+}
+
+// Finish records the requests the step sent in the allowance of its job. It
+// does nothing for a nil step.
+func (s *Step) Finish() error {
+	if s == nil {
+		return nil
+	}
+	s.allowance.Sent += s.budget.Sent()
+	return Write(s.path, s.allowance)
+}
@@ -0,0 +1,185 @@
+// Package journal records the progress of bulk operations, such as syncs
+// and exports, so that a run interrupted by a crash or the rate limit can
+// be resumed where it stopped instead of spending the quota of the API
+// again.
+//
+// A journal is an append-only file of lines: the key of the operation,
+// which captures the options it runs with, then one line per commit with
+// the cursor of the operation and the ids it completed.
+package journal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Journal is the journal of a running operation.
+type Journal struct {
+	filename  string
+	file      *os.File
+	key       string
+	resumed   bool
+	cursor    string
+	completed map[int64]bool
+}
+
+// Path returns the path of the journal of the named operation in sutro's
+// state directory.
+func Path(stateDirectory, name string) string {
+	return path.Join(stateDirectory, "journals", name+".journal")
+}
+
+// Open opens the journal at filename for an operation whose options key
+// describes. With resume, the progress of a previous run is loaded, which
+// must have had the same key; without it, or without a previous run, the
+// operation starts over.
+func Open(filename, key string, resume bool) (*Journal, error) {
+	j := &Journal{filename: filename, key: key, completed: map[int64]bool{}}
+
+	if resume {
+		if err := j.load(); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(path.Dir(filename), 0700); err != nil {
+		return nil, err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !j.resumed {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(filename, flags, 0600)
+	if err != nil {
+		return nil, err
+	}
+	j.file = file
+
+	if !j.resumed {
+		if _, err := fmt.Fprintf(file, "key %s\n", key); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// Exists reports whether an operation left a journal at filename, which
+// means it was interrupted.
+func Exists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+func (j *Journal) load() error {
+	file, err := os.Open(j.filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) != 2 {
+			// The last line of a run that crashed may be incomplete.
+			continue
+		}
+
+		switch fields[0] {
+		case "key":
+			if fields[1] != j.key {
+				return fmt.Errorf("The interrupted run used other options (%s), pass them again or run without --resume", fields[1])
+			}
+			j.resumed = true
+		case "commit":
+			j.commit(fields[1])
+		}
+	}
+	return scanner.Err()
+}
+
+// commit applies a commit line: the cursor, then the completed ids.
+func (j *Journal) commit(line string) {
+	fields := strings.Split(line, " ")
+	cursor, err := strconv.Unquote(fields[0])
+	if err != nil {
+		return
+	}
+	ids := make([]int64, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	j.cursor = cursor
+	for _, id := range ids {
+		j.completed[id] = true
+	}
+}
+
+// Resumed reports whether the journal holds the progress of a previous
+// run.
+func (j *Journal) Resumed() bool {
+	return j.resumed
+}
+
+// Cursor returns the cursor of the last commit, or an empty string.
+func (j *Journal) Cursor() string {
+	return j.cursor
+}
+
+// Completed reports whether a commit completed the item with the given id.
+func (j *Journal) Completed(id int64) bool {
+	return j.completed[id]
+}
+
+// CompletedCount returns the number of items commits completed.
+func (j *Journal) CompletedCount() int {
+	return len(j.completed)
+}
+
+// Commit records the cursor of the operation along with the ids of the
+// items it completed since the last commit. Both are written at once, so
+// that a crash cannot leave one without the other.
+func (j *Journal) Commit(cursor string, ids ...int64) error {
+	var line strings.Builder
+	line.WriteString("commit ")
+	line.WriteString(strconv.Quote(cursor))
+	for _, id := range ids {
+		line.WriteString(" ")
+		line.WriteString(strconv.FormatInt(id, 10))
+	}
+	line.WriteString("\n")
+
+	if _, err := j.file.WriteString(line.String()); err != nil {
+		return err
+	}
+	j.cursor = cursor
+	for _, id := range ids {
+		j.completed[id] = true
+	}
+	return nil
+}
+
+// Close closes the journal of an interrupted operation, keeping it for
+// the run that resumes it.
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// Finish removes the journal of a completed operation.
+func (j *Journal) Finish() error {
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	return os.Remove(j.filename)
+}
@@ -0,0 +1,215 @@
+package lint
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+)
+
+// Kind identifies a class of recording anomaly.
+type Kind string
+
+const (
+	// GPSSpike is a position that jumps away from the track and straight
+	// back.
+	GPSSpike Kind = "gps_spike"
+	// ImpossibleSpeed is a position that could only have been reached
+	// faster than the activity type allows.
+	ImpossibleSpeed Kind = "impossible_speed"
+	// HeartrateDropout is a heart rate reading lost by the sensor, either
+	// missing or far out of line with its neighbours.
+	HeartrateDropout Kind = "heartrate_dropout"
+)
+
+// Issue is an anomaly found at a given sample of a track.
+type Issue struct {
+	Index  int
+	Kind   Kind
+	Detail string
+}
+
+// maxSpeeds are the speeds, in meters per second, past which a sample is
+// considered impossible for the activity type.
+var maxSpeeds = map[string]float64{
+	"Run":         12,
+	"VirtualRun":  12,
+	"Walk":        5,
+	"Hike":        5,
+	"Ride":        30,
+	"VirtualRide": 30,
+	"EBikeRide":   30,
+	"Swim":        4,
+}
+
+const defaultMaxSpeed = 45
+
+// MaxSpeed returns the speed past which a sample is considered impossible
+// for an activity type, in meters per second.
+func MaxSpeed(activityType string) float64 {
+	if speed, ok := maxSpeeds[activityType]; ok {
+		return speed
+	}
+	return defaultMaxSpeed
+}
+
+// Check returns the anomalies of a track, ordered by sample.
+func Check(track *export.Track, maxSpeed float64) []Issue {
+	var issues []Issue
+	flagged := map[int]bool{}
+
+	samples := track.Samples
+	spike := func(i int, detail string) {
+		flagged[i] = true
+		issues = append(issues, Issue{Index: i, Kind: GPSSpike, Detail: detail})
+	}
+	for i := 1; i+1 < len(samples); i++ {
+		previous, current, next := samples[i-1].Point, samples[i].Point, samples[i+1].Point
+		away, back := geo.Distance(previous, current), geo.Distance(current, next)
+		direct := geo.Distance(previous, next)
+		if away > 50 && back > 50 && away+back > 3*direct+50 {
+			spike(i, fmt.Sprintf("jumps %.0f m off the track", math.Min(away, back)))
+		}
+	}
+	// The first and the last samples have a single neighbour, so they are
+	// off the track when they lie much further from it than it moves next.
+	// A bad first fix is the most common glitch of all.
+	if n := len(samples); n >= 3 {
+		for _, end := range [][3]int{{0, 1, 2}, {n - 1, n - 2, n - 3}} {
+			off := geo.Distance(samples[end[0]].Point, samples[end[1]].Point)
+			step := geo.Distance(samples[end[1]].Point, samples[end[2]].Point)
+			if !flagged[end[1]] && off > 50 && off > 3*step+50 {
+				spike(end[0], fmt.Sprintf("lies %.0f m off the track", off))
+			}
+		}
+	}
+
+	speed := func(from, to int) float64 {
+		elapsed := samples[to].Time.Sub(samples[from].Time).Seconds()
+		if elapsed <= 0 {
+			return 0
+		}
+		return geo.Distance(samples[from].Point, samples[to].Point) / elapsed
+	}
+	lastGood := -1
+	for i := 0; i < len(samples); i++ {
+		if flagged[i] {
+			continue
+		}
+		// The first sample is only trusted once the track moves on from it
+		// at a possible speed.
+		if lastGood < 0 {
+			if i+2 < len(samples) && !flagged[i+1] && speed(i, i+1) > maxSpeed && speed(i+1, i+2) <= maxSpeed {
+				flagged[i] = true
+				issues = append(issues, Issue{
+					Index:  i,
+					Kind:   ImpossibleSpeed,
+					Detail: fmt.Sprintf("%.1f m/s exceeds %.1f m/s", speed(i, i+1), maxSpeed),
+				})
+				continue
+			}
+			lastGood = i
+			continue
+		}
+		if v := speed(lastGood, i); v > maxSpeed {
+			flagged[i] = true
+			issues = append(issues, Issue{
+				Index:  i,
+				Kind:   ImpossibleSpeed,
+				Detail: fmt.Sprintf("%.1f m/s exceeds %.1f m/s", v, maxSpeed),
+			})
+			continue
+		}
+		lastGood = i
+	}
+
+	previous := math.NaN()
+	for i, sample := range samples {
+		hr := sample.Heartrate
+		if math.IsNaN(hr) {
+			continue
+		}
+		switch {
+		case hr < 40:
+			issues = append(issues, Issue{Index: i, Kind: HeartrateDropout, Detail: fmt.Sprintf("reads %.0f bpm", hr)})
+		case !math.IsNaN(previous) && math.Abs(hr-previous) > 40 && i > 0 && sample.Time.Sub(samples[i-1].Time) <= 2*time.Second:
+			issues = append(issues, Issue{Index: i, Kind: HeartrateDropout, Detail: fmt.Sprintf("jumps from %.0f to %.0f bpm", previous, hr)})
+		default:
+			previous = hr
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Index < issues[j].Index
+	})
+	return issues
+}
+
+// Fix returns a copy of the track in which the positions of GPS spikes and
+// impossible speeds, and the heart rate of dropouts, are interpolated from
+// the closest valid samples.
+func Fix(track *export.Track, issues []Issue) *export.Track {
+	fixed := *track
+	fixed.Samples = append([]export.Sample(nil), track.Samples...)
+
+	badPosition := map[int]bool{}
+	badHeartrate := map[int]bool{}
+	for _, issue := range issues {
+		if issue.Kind == HeartrateDropout {
+			badHeartrate[issue.Index] = true
+		} else {
+			badPosition[issue.Index] = true
+		}
+	}
+
+	interpolate(fixed.Samples, badPosition, func(sample *export.Sample, before, after export.Sample, ratio float64) {
+		sample.Lat = before.Lat + ratio*(after.Lat-before.Lat)
+		sample.Lng = before.Lng + ratio*(after.Lng-before.Lng)
+	})
+	interpolate(fixed.Samples, badHeartrate, func(sample *export.Sample, before, after export.Sample, ratio float64) {
+		sample.Heartrate = math.Round(before.Heartrate + ratio*(after.Heartrate-before.Heartrate))
+	})
+	return &fixed
+}
+
+// interpolate calls set for every bad sample with the closest good samples
+// on either side and how far it lies between them, by time when available
+// and by index otherwise. Bad samples at either end of the track take the
+// value of their only good neighbour.
+func interpolate(samples []export.Sample, bad map[int]bool, set func(*export.Sample, export.Sample, export.Sample, float64)) {
+	for i := range samples {
+		if !bad[i] {
+			continue
+		}
+
+		before, after := i-1, i+1
+		for before >= 0 && bad[before] {
+			before--
+		}
+		for after < len(samples) && bad[after] {
+			after++
+		}
+
+		switch {
+		case before < 0 && after >= len(samples):
+			continue
+		case before < 0:
+			before = after
+		case after >= len(samples):
+			after = before
+		}
+
+		ratio := 0.0
+		if after != before {
+			ratio = float64(i-before) / float64(after-before)
+			span := samples[after].Time.Sub(samples[before].Time)
+			if span > 0 && !samples[i].Time.IsZero() {
+				ratio = float64(samples[i].Time.Sub(samples[before].Time)) / float64(span)
+			}
+		}
+		set(&samples[i], samples[before], samples[after], ratio)
+	}
+}
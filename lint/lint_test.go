@@ -0,0 +1,54 @@
+package lint
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+)
+
+// run is a track running north at 3 m/s, a sample per second.
+func run(samples int) *export.Track {
+	start := time.Date(2024, time.May, 1, 7, 0, 0, 0, time.UTC)
+	track := &export.Track{Type: "Run", Start: start}
+	for i := 0; i < samples; i++ {
+		track.Samples = append(track.Samples, export.Sample{
+			Point:     geo.Point{Lat: 37.77 + float64(i)*3/111320, Lng: -122.42},
+			Time:      start.Add(time.Duration(i) * time.Second),
+			Elevation: math.NaN(),
+			Heartrate: math.NaN(),
+			Cadence:   math.NaN(),
+			Watts:     math.NaN(),
+		})
+	}
+	return track
+}
+
+func TestCheckBadFirstSample(t *testing.T) {
+	track := run(60)
+	// The first fix lands 2 km away before the receiver locks on.
+	track.Samples[0].Point = geo.Point{Lat: 37.79, Lng: -122.42}
+
+	issues := Check(track, MaxSpeed("Run"))
+	if len(issues) != 1 || issues[0].Index != 0 {
+		t.Fatalf("Expected the first sample to be flagged alone, got %+v", issues)
+	}
+
+	fixed := Fix(track, issues)
+	if d := geo.Distance(fixed.Samples[0].Point, track.Samples[1].Point); d > 10 {
+		t.Errorf("Expected the first sample to be moved next to the second, %.0f m away", d)
+	}
+	for i := 1; i < len(track.Samples); i++ {
+		if fixed.Samples[i].Point != track.Samples[i].Point {
+			t.Errorf("Expected sample %d to be left alone, moved to %v", i, fixed.Samples[i].Point)
+		}
+	}
+}
+
+func TestCheckCleanTrack(t *testing.T) {
+	if issues := Check(run(60), MaxSpeed("Run")); len(issues) != 0 {
+		t.Errorf("Expected no issue, got %+v", issues)
+	}
+}
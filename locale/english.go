@@ -0,0 +1,40 @@
+package locale
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+// english is the default catalog, which the other catalogs translate.
+var english = catalog{
+	messages: []*i18n.Message{
+		// Activities
+		{ID: "Activities", One: "{{.Count}} activity", Other: "{{.Count}} activities"},
+		{ID: "ActivityOn", Other: "{{.Duration}} on {{.Date}}"},
+		{ID: "ActivityIn", Other: "{{.Distance}} in {{.Duration}}"},
+		{ID: "ActivityDate", Other: "Mon Jan 2"},
+		{ID: "ActivityTime", Other: "Mon Jan 2, 15:04"},
+
+		// Digests
+		{ID: "WeekOf", Other: "week of {{.Date}}"},
+		{ID: "WeekOfDate", Other: "Jan 2, 2006"},
+		{ID: "MonthOf", Other: "January 2006"},
+		{ID: "DigestTotals", Other: "Your {{.Period}}: {{.Activities}}, {{.Distance}}, {{.MovingTime}} moving and {{.Ascent}} of ascent."},
+		{ID: "DigestPreviousTotals", Other: "{{.Period}}: {{.Activities}}, {{.Distance}}, {{.MovingTime}} moving and {{.Ascent}} of ascent."},
+		{ID: "DigestThisWeek", Other: "This week"},
+		{ID: "DigestThisMonth", Other: "This month"},
+		{ID: "DigestPreviousWeek", Other: "Previous week"},
+		{ID: "DigestPreviousMonth", Other: "Previous month"},
+		{ID: "DigestNoneWeek", Other: "No activity this week."},
+		{ID: "DigestNoneMonth", Other: "No activity this month."},
+		{ID: "DigestActivities", Other: "Activities"},
+		{ID: "DigestDistance", Other: "Distance"},
+		{ID: "DigestMovingTime", Other: "Moving time"},
+		{ID: "DigestAscent", Other: "Ascent"},
+		{ID: "DigestChange", Other: "Change"},
+		{ID: "DigestByType", Other: "By type"},
+		{ID: "DigestSentBy", Other: "Sent by sutro."},
+	},
+	months: [12]string{"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"},
+	shortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	shortDays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+}
@@ -0,0 +1,39 @@
+package locale
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+var french = catalog{
+	messages: []*i18n.Message{
+		// Activities
+		{ID: "Activities", One: "{{.Count}} activité", Other: "{{.Count}} activités"},
+		{ID: "ActivityOn", Other: "{{.Duration}} le {{.Date}}"},
+		{ID: "ActivityIn", Other: "{{.Distance}} en {{.Duration}}"},
+		{ID: "ActivityDate", Other: "Mon 2 Jan"},
+		{ID: "ActivityTime", Other: "Mon 2 Jan, 15:04"},
+
+		// Digests
+		{ID: "WeekOf", Other: "semaine du {{.Date}}"},
+		{ID: "WeekOfDate", Other: "2 Jan 2006"},
+		{ID: "MonthOf", Other: "January 2006"},
+		{ID: "DigestTotals", Other: "Votre {{.Period}} : {{.Activities}}, {{.Distance}}, {{.MovingTime}} en mouvement et {{.Ascent}} de dénivelé."},
+		{ID: "DigestPreviousTotals", Other: "{{.Period}} : {{.Activities}}, {{.Distance}}, {{.MovingTime}} en mouvement et {{.Ascent}} de dénivelé."},
+		{ID: "DigestThisWeek", Other: "Cette semaine"},
+		{ID: "DigestThisMonth", Other: "Ce mois-ci"},
+		{ID: "DigestPreviousWeek", Other: "Semaine précédente"},
+		{ID: "DigestPreviousMonth", Other: "Mois précédent"},
+		{ID: "DigestNoneWeek", Other: "Aucune activité cette semaine."},
+		{ID: "DigestNoneMonth", Other: "Aucune activité ce mois-ci."},
+		{ID: "DigestActivities", Other: "Activités"},
+		{ID: "DigestDistance", Other: "Distance"},
+		{ID: "DigestMovingTime", Other: "Temps en mouvement"},
+		{ID: "DigestAscent", Other: "Dénivelé"},
+		{ID: "DigestChange", Other: "Évolution"},
+		{ID: "DigestByType", Other: "Par type"},
+		{ID: "DigestSentBy", Other: "Envoyé par sutro."},
+	},
+	months: [12]string{"janvier", "février", "mars", "avril", "mai", "juin",
+		"juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+	shortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+	days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+	shortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+}
@@ -0,0 +1,184 @@
+// Package locale translates the messages of sutro and formats numbers and
+// dates for the language of the athlete, chosen with --locale or from the
+// LANG variable of the environment. English is the default, and messages
+// missing from another catalog fall back to it.
+package locale
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// catalogs are the messages of each supported language.
+var catalogs = map[language.Tag]catalog{
+	language.English: english,
+	language.French:  french,
+}
+
+// catalog is the translation of the messages of sutro, and of the names of
+// months and days, in a language.
+type catalog struct {
+	messages []*i18n.Message
+	// months and days are the full and abbreviated names time.Format
+	// writes, in the order of time.Month and time.Weekday.
+	months, shortMonths [12]string
+	days, shortDays     [7]string
+}
+
+var (
+	bundle    = newBundle()
+	tag       = language.English
+	localizer = i18n.NewLocalizer(bundle, tag.String())
+	printer   = message.NewPrinter(tag)
+)
+
+func newBundle() *i18n.Bundle {
+	b := i18n.NewBundle(language.English)
+	for language, catalog := range catalogs {
+		if err := b.AddMessages(language, catalog.messages...); err != nil {
+			panic(err)
+		}
+	}
+	return b
+}
+
+// Set selects the language of the messages, numbers and dates, given as a
+// tag such as fr or fr-CA. An empty tag selects the language of the
+// environment, from LC_ALL, LC_MESSAGES or LANG, and English when it is
+// not supported.
+func Set(name string) error {
+	if name == "" {
+		selected, _ := match(environment())
+		use(selected)
+		return nil
+	}
+	selected, ok := match(name)
+	if !ok {
+		return fmt.Errorf("Unsupported locale %q, expected one of %s", name, strings.Join(Supported(), ", "))
+	}
+	use(selected)
+	return nil
+}
+
+// Supported returns the tags of the supported languages.
+func Supported() []string {
+	return []string{language.English.String(), language.French.String()}
+}
+
+func use(selected language.Tag) {
+	tag = selected
+	localizer = i18n.NewLocalizer(bundle, tag.String())
+	printer = message.NewPrinter(tag)
+}
+
+// environment returns the locale of the environment, such as fr_FR.UTF-8
+// written as fr-FR.
+func environment() string {
+	for _, variable := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(variable); value != "" {
+			value = strings.SplitN(strings.SplitN(value, ".", 2)[0], "@", 2)[0]
+			return strings.Replace(value, "_", "-", -1)
+		}
+	}
+	return ""
+}
+
+// match returns the supported language of name, or English.
+func match(name string) (language.Tag, bool) {
+	parsed, err := language.Parse(name)
+	if err != nil {
+		return language.English, false
+	}
+	base, _ := parsed.Base()
+	for supported := range catalogs {
+		if supportedBase, _ := supported.Base(); supportedBase == base {
+			return supported, true
+		}
+	}
+	return language.English, false
+}
+
+// T returns the message id in the selected language, rendering the fields
+// of data, a map or a struct, into its template.
+func T(id string, data interface{}) string {
+	localized, err := localizer.Localize(&i18n.LocalizeConfig{MessageID: id, TemplateData: data})
+	if err != nil {
+		return id
+	}
+	return localized
+}
+
+// Plural returns the message id in the selected language in the plural
+// form of count, which its template renders as {{.Count}}.
+func Plural(id string, count int) string {
+	localized, err := localizer.Localize(&i18n.LocalizeConfig{
+		MessageID:    id,
+		PluralCount:  count,
+		TemplateData: map[string]interface{}{"Count": Number(float64(count), 0)},
+	})
+	if err != nil {
+		return id
+	}
+	return localized
+}
+
+// Number renders value with decimals digits after the decimal separator of
+// the selected language, such as 1,234.57 in English or 1 234,57 in
+// French.
+func Number(value float64, decimals int) string {
+	return printer.Sprint(number.Decimal(value, number.Scale(decimals)))
+}
+
+// Date formats t with layout, as time.Format does, naming months and days
+// in the selected language. Layouts whose order differs by language, such
+// as Jan 2 and 2 Jan, are messages of their own.
+func Date(t time.Time, layout string) string {
+	names := catalogs[tag]
+	var formatted strings.Builder
+	for layout != "" {
+		name, rest, ok := nameOf(t, layout, names)
+		if !ok {
+			// Layouts are formatted up to the next name, which no other
+			// element of a layout starts with.
+			next := len(layout)
+			for i := 1; i < len(layout); i++ {
+				if _, _, found := nameOf(t, layout[i:], names); found {
+					next = i
+					break
+				}
+			}
+			formatted.WriteString(t.Format(layout[:next]))
+			layout = layout[next:]
+			continue
+		}
+		formatted.WriteString(name)
+		layout = rest
+	}
+	return formatted.String()
+}
+
+// nameOf returns the name of the month or day of t that layout starts with,
+// and the rest of the layout.
+func nameOf(t time.Time, layout string, names catalog) (string, string, bool) {
+	for _, element := range []struct {
+		prefix string
+		name   string
+	}{
+		{"January", names.months[t.Month()-1]},
+		{"Jan", names.shortMonths[t.Month()-1]},
+		{"Monday", names.days[t.Weekday()]},
+		{"Mon", names.shortDays[t.Weekday()]},
+	} {
+		if strings.HasPrefix(layout, element.prefix) {
+			return element.name, layout[len(element.prefix):], true
+		}
+	}
+	return "", "", false
+}
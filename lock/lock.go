@@ -0,0 +1,153 @@
+// Package lock provides the --single-instance option of the commands run
+// from cron, such as sync and watch, so that an invocation overlapping the
+// previous one exits, or waits for it, rather than doing the same work and
+// spending the quota of the API twice.
+//
+// Locks are files in the state directory, locked by the operating system
+// for as long as the process holding them runs, so that a crashed process
+// never leaves a stale lock behind.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// errHeld is the error of opening a lock another process holds.
+var errHeld = errors.New("The lock is held by another process")
+
+// HeldError is the error of a lock another process holds.
+type HeldError struct {
+	// Name is the name of the lock, such as sync.
+	Name string
+	// PID is the id of the process holding the lock, or 0 when unknown.
+	PID int
+}
+
+func (e *HeldError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("Another sutro %s is running, as process %d", e.Name, e.PID)
+	}
+	return fmt.Sprintf("Another sutro %s is running", e.Name)
+}
+
+// Lock is a lock held until it is released or the process exits.
+type Lock struct {
+	file *os.File
+}
+
+// Path returns the path of the named lock in sutro's state directory.
+func Path(stateDirectory, name string) string {
+	return path.Join(stateDirectory, "locks", name+".lock")
+}
+
+// Acquire takes the named lock in stateDirectory, or fails with a
+// HeldError when another process holds it.
+func Acquire(stateDirectory, name string) (*Lock, error) {
+	filename := Path(stateDirectory, name)
+	if err := os.MkdirAll(path.Dir(filename), 0700); err != nil {
+		return nil, err
+	}
+	file, err := open(filename)
+	if errors.Is(err, errHeld) {
+		return nil, &HeldError{Name: name, PID: holder(filename)}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The id of the process only tells who holds the lock, which the
+	// operating system keeps track of.
+	if err := file.Truncate(0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(file, "%d\n", os.Getpid()); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &Lock{file: file}, nil
+}
+
+// Wait takes the named lock in stateDirectory once the process holding it,
+// if any, releases it, checking every interval until ctx is done. waiting
+// is called once when the lock is held by another process.
+func Wait(ctx context.Context, stateDirectory, name string, interval time.Duration, waiting func(*HeldError)) (*Lock, error) {
+	notified := false
+	for {
+		l, err := Acquire(stateDirectory, name)
+		var held *HeldError
+		if !errors.As(err, &held) {
+			return l, err
+		}
+		if !notified && waiting != nil {
+			waiting(held)
+			notified = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Release releases the lock. It does nothing for a nil lock.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	// The file is left in place: removing it would let another process
+	// lock a new file while a third one waits on this one.
+	return l.file.Close()
+}
+
+// holder returns the id of the process holding the lock at filename, or 0
+// when it cannot be read.
+func holder(filename string) int {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// Flags are the --single-instance and --wait options of a command.
+type Flags struct {
+	singleInstance bool
+	wait           bool
+}
+
+// Register adds the options to command.
+func (f *Flags) Register(command *cobra.Command) {
+	command.Flags().BoolVar(&f.singleInstance, "single-instance", false, "Exit right away when another invocation of the command is running, as overlapping cron jobs would")
+	command.Flags().BoolVar(&f.wait, "wait", false, "Wait for the other invocation of the command to finish instead of exiting, which implies --single-instance")
+}
+
+// Acquire takes the named lock in stateDirectory as the options ask, and
+// returns it, or returns nil when they do not ask for one.
+func (f *Flags) Acquire(ctx context.Context, stateDirectory, name string) (*Lock, error) {
+	if f.wait {
+		return Wait(ctx, stateDirectory, name, time.Second, func(held *HeldError) {
+			fmt.Fprintf(os.Stderr, "%s, waiting for it to finish\n", held)
+		})
+	}
+	if f.singleInstance {
+		return Acquire(stateDirectory, name)
+	}
+	return nil, nil
+}
@@ -0,0 +1,27 @@
+package lock
+
+import (
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation is the error of opening a file another process
+// opened without sharing it.
+const errorSharingViolation syscall.Errno = 32
+
+// open opens the file at filename, creating it if needed, without sharing
+// it for writing, which locks it until it is closed.
+func open(filename string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(filename)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, syscall.FILE_SHARE_READ, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err == errorSharingViolation {
+		return nil, errHeld
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: filename, Err: err}
+	}
+	return os.NewFile(uintptr(handle), filename), nil
+}
@@ -2,14 +2,73 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"os"
+	"strings"
+	"time"
 
-	runtimeClient "github.com/go-openapi/runtime/client"
+	"github.com/jsilland/sutro/apidoc"
+	"github.com/jsilland/sutro/audit"
+	"github.com/jsilland/sutro/auth"
+	"github.com/jsilland/sutro/browser"
+	"github.com/jsilland/sutro/cache"
 	"github.com/jsilland/sutro/client"
+	"github.com/jsilland/sutro/cmd/activities"
+	"github.com/jsilland/sutro/cmd/alias"
+	auditCommand "github.com/jsilland/sutro/cmd/audit"
 	"github.com/jsilland/sutro/cmd/authenticate"
+	cacheCommand "github.com/jsilland/sutro/cmd/cache"
+	"github.com/jsilland/sutro/cmd/calendar"
+	"github.com/jsilland/sutro/cmd/clubs"
+	"github.com/jsilland/sutro/cmd/coach"
+	"github.com/jsilland/sutro/cmd/completion"
+	"github.com/jsilland/sutro/cmd/db"
+	"github.com/jsilland/sutro/cmd/digest"
+	"github.com/jsilland/sutro/cmd/doctor"
+	"github.com/jsilland/sutro/cmd/export"
+	"github.com/jsilland/sutro/cmd/files"
+	"github.com/jsilland/sutro/cmd/heatmap"
+	historyCommand "github.com/jsilland/sutro/cmd/history"
+	jobsCommand "github.com/jsilland/sutro/cmd/jobs"
+	"github.com/jsilland/sutro/cmd/mcp"
+	"github.com/jsilland/sutro/cmd/metrics"
+	"github.com/jsilland/sutro/cmd/notify"
+	"github.com/jsilland/sutro/cmd/plan"
+	"github.com/jsilland/sutro/cmd/plugins"
+	"github.com/jsilland/sutro/cmd/polyline"
+	"github.com/jsilland/sutro/cmd/predict"
+	"github.com/jsilland/sutro/cmd/report"
+	"github.com/jsilland/sutro/cmd/rewind"
+	"github.com/jsilland/sutro/cmd/routes"
+	"github.com/jsilland/sutro/cmd/rules"
+	"github.com/jsilland/sutro/cmd/script"
+	"github.com/jsilland/sutro/cmd/segments"
+	"github.com/jsilland/sutro/cmd/serve"
+	"github.com/jsilland/sutro/cmd/site"
+	"github.com/jsilland/sutro/cmd/social"
+	"github.com/jsilland/sutro/cmd/streaks"
+	streamsCommand "github.com/jsilland/sutro/cmd/streams"
+	"github.com/jsilland/sutro/cmd/synchronize"
+	"github.com/jsilland/sutro/cmd/thresholds"
+	"github.com/jsilland/sutro/cmd/trends"
+	"github.com/jsilland/sutro/cmd/uploads"
+	"github.com/jsilland/sutro/cmd/watch"
+	"github.com/jsilland/sutro/cmd/webhooks"
 	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/defaults"
+	"github.com/jsilland/sutro/failure"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/history"
+	"github.com/jsilland/sutro/jobs"
+	"github.com/jsilland/sutro/locale"
+	"github.com/jsilland/sutro/profiles"
+	"github.com/jsilland/sutro/progress"
+	"github.com/jsilland/sutro/scopes"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"github.com/jsilland/sutro/undo"
 	"github.com/spf13/cobra"
 	"golang.org/x/oauth2"
 )
@@ -17,79 +76,369 @@ import (
 //go:generate swagger generate client -f swagger.json -t . --template-dir=go-swagger-cli/templates --allow-template-override -C go-swagger-cli/config.yml
 
 type globalFlags struct {
-	verbose bool
+	verbose   bool
+	timezone  string
+	locale    string
+	browser   string
+	progress  string
+	headers   []string
+	profiling profilingFlags
 }
 
 func main() {
 	flags := globalFlags{}
 
-	ctx := context.Background()
-	bridge, err := config.NewDotFileConfiguration("sutro")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recorder := &history.Recorder{}
+	ctx = history.NewContext(ctx, recorder)
+	stateDirectory, err := config.NewStateDirectory("sutro")
 
 	if err != nil {
-		fmt.Errorf(err.Error())
-		os.Exit(-1)
+		fail(failure.ConfigurationError(err))
 	}
 
-	config, err := bridge.Get()
+	// Each profile has its own configuration, archive and journals, while
+	// the cache of streams is shared as activities have unique ids.
+	profile := profiles.Selected(os.Args[1:])
+	bridge, err := config.NewProfileConfiguration("sutro", stateDirectory, profile)
 
 	if err != nil {
-		fmt.Errorf(err.Error())
-		os.Exit(-2)
+		fail(failure.ConfigurationError(err))
 	}
 
+	profileDirectory, err := config.ProfileDirectory(stateDirectory, profile)
+
+	if err != nil {
+		fail(failure.ConfigurationError(err))
+	}
+
+	archive, err := store.Open(store.DefaultPath(profileDirectory))
+
+	if err != nil {
+		fail(failure.ConfigurationError(err))
+	}
+	defer archive.Close()
+
+	listProfiles := func() ([]string, error) {
+		return config.Profiles("sutro", stateDirectory)
+	}
+
+	// A configuration that cannot be read fails every command but doctor,
+	// which reports it.
+	config, configErr := bridge.Get()
+
+	cacheSize := int64(cache.DefaultMaxSize)
+	if config != nil && config.CacheMaxSize() != "" {
+		if cacheSize, err = cache.ParseSize(config.CacheMaxSize()); err != nil {
+			fail(failure.ConfigurationError(fmt.Errorf("Invalid cache_max_size in %s: %v", bridge.Path(), err)))
+		}
+	}
+	tokenWarning := auth.DefaultExpiryWarning
+	if config != nil && config.TokenExpiryWarning() != "" {
+		if tokenWarning, err = time.ParseDuration(config.TokenExpiryWarning()); err != nil || tokenWarning < 0 {
+			fail(failure.ConfigurationError(fmt.Errorf("Invalid token_expiry_warning %q in %s, expected a duration such as 72h", config.TokenExpiryWarning(), bridge.Path())))
+		}
+	}
+	streams, err := cache.Open(cache.DefaultPath(stateDirectory), cacheSize)
+
+	if err != nil {
+		fail(failure.ConfigurationError(err))
+	}
+
+	// running is the path of the command running, which the audit log and
+	// the updates to undo record changes along with.
+	running := ""
+	auditLog := audit.Open(audit.Path(profileDirectory))
+
 	command := &cobra.Command{}
+	var apiClient *strava.Client
+	// step is the step of a job the command runs as, if any.
+	var step *jobs.Step
 	if config != nil {
-		httpClient := oauth2.NewClient(ctx, config.TokenSource(ctx))
-		transportConfig := client.DefaultTransportConfig()
-		runtime := runtimeClient.NewWithClient(
-			transportConfig.Host,
-			transportConfig.BasePath,
-			transportConfig.Schemes,
-			httpClient,
-		)
-		apiClient := client.New(runtime, nil)
-
-		command = client.NewCommand(apiClient)
-
-		command.PersistentPreRun = func(cmd *cobra.Command, args []string) {
-			if flags.verbose {
-				httpClient.Transport = &verboseTransport{httpClient.Transport}
+		tokens := config.TokenSource(ctx)
+		httpClient := oauth2.NewClient(ctx, tokens)
+		apiClient = strava.New(httpClient)
+		// Tests point sutro at a fake of the API, such as sutrotest.Server.
+		if apiURL := os.Getenv("SUTRO_API_URL"); apiURL != "" {
+			if apiClient, err = strava.NewWithURL(httpClient, apiURL); err != nil {
+				fail(failure.ConfigurationError(err))
 			}
 		}
+
+		apiClient.Use(preflight(tokens, config.OAuthConfiguration().ClientID))
+		apiClient.Use(auditLog.Interceptor(func() string { return running }))
+		apiClient.Use(undo.Interceptor(apiClient, archive, func() string { return running }))
+		apiClient.CacheStreams(streams)
+		if step, err = jobs.Join(apiClient); err != nil {
+			fail(failure.ConfigurationError(fmt.Errorf("Unable to read the budget of the job: %v", err)))
+		}
+
+		command = client.NewCommand(apiClient.API)
+		scopes.Require(subcommand(command, "activities"), "activity:read").AddCommand(activities.Commands(ctx, apiClient, archive, config)...)
+		subcommand(command, "clubs").AddCommand(clubs.Commands(ctx, apiClient)...)
+		subcommand(command, "segments").AddCommand(segments.Commands(ctx, apiClient)...)
+		subcommand(command, "streams").AddCommand(streamsCommand.Commands(ctx, apiClient)...)
+		subcommand(command, "uploads").AddCommand(uploads.Commands(ctx, apiClient)...)
+		syncCommand := synchronize.Command(ctx, apiClient, archive, streams, profileDirectory)
+		profiles.Fanout(subcommand(syncCommand, "streams"), listProfiles)
+		command.AddCommand(scopes.Require(profiles.Fanout(syncCommand, listProfiles), "activity:read"))
+		command.AddCommand(scopes.Require(profiles.Fanout(trends.Command(ctx, apiClient), listProfiles), "activity:read"))
+		command.AddCommand(scopes.Require(mcp.Command(ctx, apiClient), "activity:read"))
+		command.AddCommand(script.Commands(ctx, apiClient)...)
+		command.AddCommand(alias.Command(config))
+		command.AddCommand(jobsCommand.Command(ctx, config, bridge.Profile()))
+		command.AddCommand(notify.Command(archive, config))
+		command.AddCommand(scopes.Require(predict.Command(ctx, apiClient, archive), "activity:read"))
+		command.AddCommand(scopes.Require(rules.Command(ctx, apiClient, archive, config), "activity:read"))
+		command.AddCommand(scopes.Require(social.Command(ctx, apiClient), "activity:read"))
+		command.AddCommand(scopes.Require(watch.Command(ctx, apiClient, archive, config, profileDirectory), "activity:read"))
+		command.AddCommand(webhooks.Command(ctx, apiClient, config, profileDirectory))
 	}
-	command.AddCommand(authenticate.Command(ctx, bridge))
+	var zones []geo.Zone
+	if config != nil {
+		zones = config.PrivacyZones()
+	}
+	subcommand(command, "routes").AddCommand(routes.Commands(ctx, apiClient, archive, zones)...)
+	command.AddCommand(auditCommand.Command(auditLog))
+	command.AddCommand(authenticate.Command(ctx, bridge, config, tokenWarning))
+	command.AddCommand(cacheCommand.Command(streams))
+	command.AddCommand(calendar.Command(ctx, archive, zones))
+	command.AddCommand(coach.Command(stateDirectory))
+	command.AddCommand(completion.Command())
+	dbCommand := db.Command(archive)
+	profiles.Fanout(subcommand(dbCommand, "query"), listProfiles)
+	command.AddCommand(dbCommand)
+	command.AddCommand(digest.Command(archive))
+	command.AddCommand(doctor.Command(ctx, doctor.Setup{
+		Bridge:             bridge,
+		Configuration:      config,
+		ConfigurationError: configErr,
+		Client:             apiClient,
+		Cache:              streams,
+	}))
+	command.AddCommand(export.Command(ctx, apiClient, archive, zones, profileDirectory))
+	command.AddCommand(files.Command())
+	command.AddCommand(heatmap.Command(archive))
+	command.AddCommand(historyCommand.Command(archive))
+	command.AddCommand(metrics.Command(ctx, archive))
+	command.AddCommand(plan.Command(archive))
+	command.AddCommand(polyline.Command())
+	command.AddCommand(report.Command(archive))
+	command.AddCommand(rewind.Command(ctx, apiClient, archive, zones))
+	command.AddCommand(serve.Command(ctx, archive))
+	command.AddCommand(site.Command(archive, zones))
+	command.AddCommand(streaks.Command(archive))
+	command.AddCommand(thresholds.Command(archive))
+	command.AddCommand(plugins.Commands(ctx, command, plugins.Environment{
+		ConfigPath:     bridge.Path(),
+		StateDirectory: profileDirectory,
+		Profile:        bridge.Profile(),
+		Configuration:  config,
+	})...)
+	// The generated commands are only named after their operations.
+	apidoc.Rename(command)
+	apidoc.Document(command)
+	apidoc.Tabulate(command)
+	apidoc.WithMeta(command, apiClient)
+	scopes.Annotate(command)
+	failure.Classify(command)
+	// Errors are presented once the command returns, with a hint rather
+	// than the usage of the command.
+	command.SilenceErrors, command.SilenceUsage = true, true
 
 	command.PersistentFlags().BoolVarP(&flags.verbose, "verbose", "v", false, "verbose output")
+	timezone, browserCommand := "", ""
+	if config != nil {
+		timezone, browserCommand = config.Timezone(), config.BrowserCommand()
+	}
+	command.PersistentFlags().StringVar(&flags.timezone, "timezone", timezone, "The time zone dates are read and displayed in, such as Europe/Paris, instead of the local one")
+	command.PersistentFlags().StringVar(&flags.browser, "browser", browserCommand, "The command opening URLs, such as \"firefox --private-window %s\", instead of the default browser")
+	command.PersistentFlags().StringVar(&flags.locale, "locale", "", "The language of digest, notify, report, rewind and the summaries of watch, en or fr, instead of the one of LANG")
+	command.PersistentFlags().StringVar(&flags.progress, "progress", progress.Auto, "How long-running commands report their progress on stderr: auto, as a bar on a terminal and a line every 30s otherwise, or json, as an event per line")
+	command.PersistentFlags().StringSliceVar(&flags.headers, "include-headers", nil, "Print these headers of the responses, or all of them when given no value, on stderr or in the meta of --with-meta, with sensitive values redacted (e.g. --include-headers=Date,X-RateLimit-Usage)")
+	command.PersistentFlags().Lookup("include-headers").NoOptDefVal = "*"
+	profiles.Register(command, profile)
+	flags.profiling.register(command)
+
+	command.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		running = strings.TrimPrefix(cmd.CommandPath(), command.Name()+" ")
+		if configErr != nil && cmd.Name() != "doctor" {
+			return failure.ConfigurationError(fmt.Errorf("Unable to read %s: %v", bridge.Path(), configErr))
+		}
+		if config != nil {
+			if err := defaults.Apply(cmd, config.Defaults()); err != nil {
+				return failure.ConfigurationError(fmt.Errorf("%v in %s", err, bridge.Path()))
+			}
+			if err := scopes.Check(cmd, config.OAuthConfiguration().Scopes); err != nil {
+				return err
+			}
+			// sutro authenticate check reports the expiry itself.
+			if !strings.HasPrefix(running, "authenticate") {
+				if warning := auth.ExpiryWarning(config.SavedToken(), tokenWarning, time.Now()); warning != "" {
+					fmt.Fprintln(os.Stderr, warning)
+				}
+			}
+		}
+		if err := dates.SetTimezone(flags.timezone); err != nil {
+			return err
+		}
+		if err := locale.Set(flags.locale); err != nil {
+			return err
+		}
+		if err := progress.SetFormat(flags.progress); err != nil {
+			return err
+		}
+		browser.SetCommand(flags.browser)
+		if flags.verbose && apiClient != nil {
+			// Logs go to stderr, as sutro mcp talks to its client on stdout.
+			apiClient.Use(strava.Verbose(os.Stderr))
+		}
+		// --with-meta includes the headers in its meta instead.
+		if withMeta := cmd.Flags().Lookup("with-meta"); len(flags.headers) > 0 && apiClient != nil && (withMeta == nil || withMeta.Value.String() != "true") {
+			apiClient.Observe(strava.PrintHeaders(os.Stderr, flags.headers))
+		}
+		return flags.profiling.start()
+	}
 
 	command.Use = "sutro"
 	command.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
-		if cmd.Name() == "authenticate" {
+		if cmd.Name() == "authenticate" || config == nil {
 			return nil
 		}
 
 		return bridge.Save(ctx, config)
 	}
 
-	_, err = command.ExecuteC()
+	args := os.Args[1:]
+	if config != nil {
+		if args, err = alias.Expand(command, args, config.Aliases()); err != nil {
+			fail(failure.UsageError(err))
+		}
+	}
+	if args, err = history.Expand(args, archive); err != nil {
+		fail(failure.UsageError(err))
+	}
+	command.SetArgs(args)
+
+	interrupted := handleSignals(cancel, command)
+	executed, err := command.ExecuteC()
+	flags.profiling.stop()
+	record(archive, executed, recorder)
+	if err := step.Finish(); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to record the requests spent by the step of the job: %v\n", err)
+	}
+
+	if s := interrupted.Signal(); s != nil {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			failure.Present(os.Stderr, executed, err, jsonOutput(executed))
+		}
+		archive.Close()
+		os.Exit(exitCode(s))
+	}
 
 	if err != nil {
-		_ = fmt.Errorf(err.Error())
-		os.Exit(-3)
+		code := failure.Present(os.Stderr, executed, err, jsonOutput(executed))
+		archive.Close()
+		os.Exit(code)
+	}
+}
+
+// fail presents err, which sutro failed with before running any command,
+// and exits.
+func fail(err error) {
+	os.Exit(failure.Present(os.Stderr, nil, err, false))
+}
+
+// jsonOutput reports whether command was asked for --output json, in which
+// case it fails with JSON as well.
+func jsonOutput(command *cobra.Command) bool {
+	if command == nil {
+		return false
+	}
+	output := command.Flags().Lookup("output")
+	return output != nil && output.Changed && output.Value.String() == "json"
+}
+
+// subcommand returns the direct child of command with the given name,
+// creating it if the generated client does not provide one.
+func subcommand(command *cobra.Command, name string) *cobra.Command {
+	for _, child := range command.Commands() {
+		if child.Name() == name {
+			return child
+		}
 	}
+
+	child := &cobra.Command{
+		Use:   name,
+		Short: fmt.Sprintf("Client for %s", name),
+	}
+	command.AddCommand(child)
+	return child
 }
 
-type verboseTransport struct {
-	http.RoundTripper
+// record adds the command that ran to the history, except for the commands
+// that only describe sutro.
+func record(archive *store.Store, executed *cobra.Command, recorder *history.Recorder) {
+	if executed == nil || !executed.Runnable() {
+		return
+	}
+	switch executed.Name() {
+	case "history", "help", "doctor", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return
+	}
+	if help, _ := executed.Flags().GetBool("help"); help {
+		return
+	}
+
+	entry := store.HistoryEntry{
+		Time:    time.Now(),
+		Command: strings.Join(redact(executed, os.Args[1:]), " "),
+		IDs:     recorder.IDs(),
+	}
+	if err := archive.AddHistory(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to record the command in the history: %v\n", err)
+	}
 }
 
-func (vt *verboseTransport) RoundTrip(request *http.Request) (*http.Response, error) {
-	fmt.Fprintf(os.Stdout, "%s %s\n", request.Method, request.URL.String())
-	for header, values := range request.Header {
-		for _, value := range values {
-			fmt.Fprintf(os.Stdout, "%s: %s\n", header, value)
+// secretWords are the last words of the names of the flags whose values
+// stay out of the history, such as --client_secret or --verify-token.
+var secretWords = map[string]bool{"secret": true, "token": true, "password": true, "key": true}
+
+// redact returns args with the values of the secret flags of executed
+// replaced, whether given as --flag value or --flag=value.
+func redact(executed *cobra.Command, args []string) []string {
+	secret := func(arg string) bool {
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return false
+		}
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		flag := executed.Flags().Lookup(name)
+		if flag == nil && len(name) == 1 {
+			flag = executed.Flags().ShorthandLookup(name)
+		}
+		if flag == nil || flag.Value.Type() == "bool" {
+			return false
+		}
+		words := strings.FieldsFunc(flag.Name, func(r rune) bool { return r == '-' || r == '_' })
+		return len(words) > 0 && secretWords[words[len(words)-1]]
+	}
+
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := 0; i < len(redacted); i++ {
+		if redacted[i] == "--" {
+			break
+		}
+		if !secret(redacted[i]) {
+			continue
+		}
+		if equals := strings.Index(redacted[i], "="); equals >= 0 {
+			redacted[i] = redacted[i][:equals+1] + "REDACTED"
+		} else if i+1 < len(redacted) {
+			i++
+			redacted[i] = "REDACTED"
 		}
 	}
-	response, err := vt.RoundTripper.RoundTrip(request)
-	return response, err
+	return redacted
 }
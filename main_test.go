@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jsilland/sutro/sutrotest"
+)
+
+// binary is sutro, built once for the tests, which run it as a separate
+// process against a sutrotest.Server.
+var binary string
+
+func TestMain(m *testing.M) {
+	directory, err := ioutil.TempDir("", "sutro")
+	if err != nil {
+		panic(err)
+	}
+	binary = filepath.Join(directory, "sutro")
+	build := exec.Command("go", "build", "-o", binary, ".")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(directory)
+	os.Exit(code)
+}
+
+// run runs sutro with the arguments against a server, as an athlete whose
+// token has not expired, and returns its output.
+func run(t *testing.T, server *sutrotest.Server, args ...string) (string, error) {
+	t.Helper()
+	home := t.TempDir()
+	configuration := `{"client_id":"1","client_secret":"secret","token":{"access_token":"access","token_type":"Bearer","refresh_token":"refresh","expiry":"2999-01-01T00:00:00Z"}}`
+	if err := ioutil.WriteFile(filepath.Join(home, ".sutro"), []byte(configuration), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return runIn(home, server.Environment(), args...)
+}
+
+// runIn runs sutro with the arguments in home, with the environment
+// variables added to those of the test.
+func runIn(home string, environment []string, args ...string) (string, error) {
+	command := exec.Command(binary, args...)
+	command.Env = append(os.Environ(), "HOME="+home, "LANG=en_US.UTF-8")
+	command.Env = append(command.Env, environment...)
+	output, err := command.CombinedOutput()
+	return string(output), err
+}
+
+func TestHelpWithoutConfiguration(t *testing.T) {
+	// A fresh install has no configuration until sutro authenticate.
+	for _, args := range [][]string{{"--help"}, {"authenticate", "--help"}, {"routes", "--help"}} {
+		output, err := runIn(t.TempDir(), nil, args...)
+		if err != nil {
+			t.Errorf("sutro %s failed without a configuration: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+}
+
+func TestStreamsAnalyze(t *testing.T) {
+	server := sutrotest.NewServer()
+	defer server.Close()
+	server.Respond("getActivityStreams", http.StatusOK, map[string]interface{}{
+		"time":     map[string]interface{}{"data": []int{0, 1, 2, 3}},
+		"distance": map[string]interface{}{"data": []float64{0, 3, 6, 9}},
+	})
+
+	output, err := run(t, server, "streams", "analyze", "1234", "--summary", "--format", "json")
+	if err != nil {
+		t.Fatalf("streams analyze failed: %v\n%s", err, output)
+	}
+
+	request := server.AssertCalled(t, "getActivityStreams")
+	server.AssertAllMatched(t)
+	if request == nil {
+		return
+	}
+	if request.Params["id"] != "1234" {
+		t.Errorf("Expected the streams of activity 1234, got %s", request.Params["id"])
+	}
+	if got := request.Query.Get("key_by_type"); got != "true" {
+		t.Errorf("Expected key_by_type=true, got %q", got)
+	}
+	if keys := request.Query.Get("keys"); !strings.Contains(keys, "time") {
+		t.Errorf("Expected the time stream among the keys, got %q", keys)
+	}
+
+	var a struct {
+		ID      int64 `json:"id"`
+		Summary struct {
+			Duration float64 `json:"duration"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(output), &a); err != nil {
+		t.Fatalf("Invalid analysis %q: %v", output, err)
+	}
+	if a.ID != 1234 || a.Summary.Duration != 3 {
+		t.Errorf("Expected 3s of activity 1234, got %+v", a)
+	}
+}
+
+func TestStreamsAnalyzeDefaultStreamSet(t *testing.T) {
+	server := sutrotest.NewServer()
+	defer server.Close()
+
+	// The default stream set only has a distance stream, which decodes but
+	// has no time to analyze.
+	output, err := run(t, server, "streams", "analyze", "1234")
+	if err == nil {
+		t.Fatalf("Expected streams analyze to fail, got\n%s", output)
+	}
+	server.AssertCalled(t, "getActivityStreams")
+	if !strings.Contains(output, "Activity 1234 has no time stream to analyze") {
+		t.Errorf("Expected the activity to have no time stream, got\n%s", output)
+	}
+}
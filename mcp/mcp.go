@@ -0,0 +1,191 @@
+// Package mcp implements the server side of the Model Context Protocol over
+// a stream such as stdio: JSON-RPC 2.0 messages, one per line, through which
+// assistants list and call tools.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ProtocolVersion is the revision of the protocol the server implements.
+const ProtocolVersion = "2024-11-05"
+
+// Tool is a function assistants can call.
+type Tool struct {
+	Name        string
+	Description string
+	// InputSchema is the JSON schema of the arguments of the tool.
+	InputSchema map[string]interface{}
+	// Call returns the result of the tool, which is sent to the assistant
+	// as JSON, for the given arguments. Its errors are reported to the
+	// assistant as failed calls rather than as protocol errors.
+	Call func(ctx context.Context, arguments json.RawMessage) (interface{}, error)
+}
+
+// Server answers the requests of a client.
+type Server struct {
+	Name    string
+	Version string
+	Tools   []Tool
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error codes defined by JSON-RPC 2.0.
+const (
+	parseError     = -32700
+	invalidRequest = -32600
+	methodNotFound = -32601
+	invalidParams  = -32602
+	internalError  = -32603
+)
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type callResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Serve answers the requests read from reader on writer until reader is
+// exhausted or ctx is done.
+func (s *Server) Serve(ctx context.Context, reader io.Reader, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+
+	// Lines are read aside, as reading stdin blocks until the client writes
+	// or closes it, regardless of ctx.
+	lines := make(chan []byte)
+	scanned := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- append([]byte(nil), scanner.Bytes()...):
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanned <- scanner.Err()
+	}()
+
+	for {
+		var line []byte
+		select {
+		case line = <-lines:
+		case err := <-scanned:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		var r request
+		if err := json.Unmarshal(line, &r); err != nil {
+			if err := encoder.Encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{parseError, err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, failure := s.handle(ctx, r)
+		// Notifications, which have no id, get no response.
+		if len(r.ID) == 0 {
+			continue
+		}
+		if err := encoder.Encode(response{JSONRPC: "2.0", ID: r.ID, Result: result, Error: failure}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, r request) (interface{}, *rpcError) {
+	if r.JSONRPC != "2.0" {
+		return nil, &rpcError{invalidRequest, "Expected a JSON-RPC 2.0 request"}
+	}
+
+	switch r.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": s.Name, "version": s.Version},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		tools := make([]map[string]interface{}, 0, len(s.Tools))
+		for _, tool := range s.Tools {
+			tools = append(tools, map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"inputSchema": tool.InputSchema,
+			})
+		}
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		return s.call(ctx, r.Params)
+	}
+
+	if strings.HasPrefix(r.Method, "notifications/") {
+		return nil, nil
+	}
+	return nil, &rpcError{methodNotFound, fmt.Sprintf("Unknown method %q", r.Method)}
+}
+
+func (s *Server) call(ctx context.Context, params json.RawMessage) (interface{}, *rpcError) {
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, &rpcError{invalidParams, err.Error()}
+	}
+	if len(call.Arguments) == 0 || string(call.Arguments) == "null" {
+		call.Arguments = json.RawMessage("{}")
+	}
+
+	for _, tool := range s.Tools {
+		if tool.Name != call.Name {
+			continue
+		}
+
+		result, err := tool.Call(ctx, call.Arguments)
+		if err != nil {
+			return callResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		text, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, &rpcError{internalError, err.Error()}
+		}
+		return callResult{Content: []content{{Type: "text", Text: string(text)}}}, nil
+	}
+	return nil, &rpcError{invalidParams, fmt.Sprintf("Unknown tool %q", call.Name)}
+}
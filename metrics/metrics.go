@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/models"
+)
+
+// Day sums up the activities of a type started on the same local day.
+type Day struct {
+	Date          time.Time
+	Type          string
+	Count         int
+	Distance      float64
+	MovingTime    float64
+	ElevationGain float64
+	Kilojoules    float64
+}
+
+// Daily aggregates activities by local day and type, oldest first.
+func Daily(activities []*models.SummaryActivity) []Day {
+	days := map[string]*Day{}
+	for _, activity := range activities {
+		year, month, dayOfMonth := time.Time(activity.StartDateLocal).Date()
+		date := time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC)
+		key := date.Format("2006-01-02") + "|" + string(activity.Type)
+
+		day, ok := days[key]
+		if !ok {
+			day = &Day{Date: date, Type: string(activity.Type)}
+			days[key] = day
+		}
+		day.Count++
+		day.Distance += float64(activity.Distance)
+		day.MovingTime += float64(activity.MovingTime)
+		day.ElevationGain += float64(activity.TotalElevationGain)
+		day.Kilojoules += float64(activity.Kilojoules)
+	}
+
+	result := make([]Day, 0, len(days))
+	for _, day := range days {
+		result = append(result, *day)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Date.Equal(result[j].Date) {
+			return result[i].Date.Before(result[j].Date)
+		}
+		return result[i].Type < result[j].Type
+	})
+	return result
+}
+
+// ActivityLine formats an activity as an InfluxDB line protocol point of
+// the activity measurement, timestamped in seconds at its start.
+func ActivityLine(activity *models.SummaryActivity) string {
+	fields := []string{
+		"id=" + strconv.FormatInt(activity.ID, 10) + "i",
+		"distance=" + float(float64(activity.Distance)),
+		"moving_time=" + strconv.FormatInt(activity.MovingTime, 10) + "i",
+		"elapsed_time=" + strconv.FormatInt(activity.ElapsedTime, 10) + "i",
+		"elevation_gain=" + float(float64(activity.TotalElevationGain)),
+		"average_speed=" + float(float64(activity.AverageSpeed)),
+		"name=" + quote(activity.Name),
+	}
+	if activity.HasHeartrate {
+		fields = append(fields,
+			"average_heartrate="+float(float64(activity.AverageHeartrate)),
+			"max_heartrate="+float(float64(activity.MaxHeartrate)))
+	}
+	if activity.AverageWatts > 0 {
+		fields = append(fields, "average_watts="+float(float64(activity.AverageWatts)))
+	}
+	if activity.Kilojoules > 0 {
+		fields = append(fields, "kilojoules="+float(float64(activity.Kilojoules)))
+	}
+
+	tags := fmt.Sprintf("activity,type=%s,commute=%t,trainer=%t", escapeTag(string(activity.Type)), activity.Commute, activity.Trainer)
+	return fmt.Sprintf("%s %s %d", tags, strings.Join(fields, ","), time.Time(activity.StartDate).Unix())
+}
+
+// DayLine formats a daily aggregate as an InfluxDB line protocol point of
+// the daily measurement, timestamped at the start of the day.
+func DayLine(day Day) string {
+	return fmt.Sprintf("daily,type=%s count=%di,distance=%s,moving_time=%s,elevation_gain=%s,kilojoules=%s %d",
+		escapeTag(day.Type), day.Count, float(day.Distance), float(day.MovingTime), float(day.ElevationGain), float(day.Kilojoules), day.Date.Unix())
+}
+
+// WritePrometheus writes totals per activity type, and the volume of the
+// last 7 and 28 days, in the Prometheus text exposition format.
+func WritePrometheus(writer io.Writer, activities []*models.SummaryActivity, now time.Time) error {
+	type totals struct {
+		count, distance, movingTime, elevationGain float64
+		last                                       time.Time
+		windows                                    map[string]float64
+	}
+
+	windows := []struct {
+		name string
+		from time.Time
+	}{
+		{"7d", now.AddDate(0, 0, -7)},
+		{"28d", now.AddDate(0, 0, -28)},
+	}
+
+	byType := map[string]*totals{}
+	for _, activity := range activities {
+		t, ok := byType[string(activity.Type)]
+		if !ok {
+			t = &totals{windows: map[string]float64{}}
+			byType[string(activity.Type)] = t
+		}
+
+		start := time.Time(activity.StartDate)
+		t.count++
+		t.distance += float64(activity.Distance)
+		t.movingTime += float64(activity.MovingTime)
+		t.elevationGain += float64(activity.TotalElevationGain)
+		if start.After(t.last) {
+			t.last = start
+		}
+		for _, window := range windows {
+			if start.After(window.from) {
+				t.windows[window.name] += float64(activity.Distance)
+			}
+		}
+	}
+
+	types := make([]string, 0, len(byType))
+	for activityType := range byType {
+		types = append(types, activityType)
+	}
+	sort.Strings(types)
+
+	metrics := []struct {
+		name, kind, help string
+		value            func(*totals) float64
+	}{
+		{"sutro_activities_total", "counter", "Number of synced activities.", func(t *totals) float64 { return t.count }},
+		{"sutro_distance_meters_total", "counter", "Distance covered by synced activities.", func(t *totals) float64 { return t.distance }},
+		{"sutro_moving_time_seconds_total", "counter", "Moving time of synced activities.", func(t *totals) float64 { return t.movingTime }},
+		{"sutro_elevation_gain_meters_total", "counter", "Elevation gained during synced activities.", func(t *totals) float64 { return t.elevationGain }},
+		{"sutro_last_activity_timestamp_seconds", "gauge", "Start time of the most recent synced activity.", func(t *totals) float64 { return float64(t.last.Unix()) }},
+	}
+
+	var builder strings.Builder
+	for _, metric := range metrics {
+		fmt.Fprintf(&builder, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for _, activityType := range types {
+			fmt.Fprintf(&builder, "%s{type=%q} %s\n", metric.name, activityType, float(metric.value(byType[activityType])))
+		}
+	}
+
+	builder.WriteString("# HELP sutro_distance_meters Distance covered during the trailing window.\n# TYPE sutro_distance_meters gauge\n")
+	for _, activityType := range types {
+		for _, window := range windows {
+			fmt.Fprintf(&builder, "sutro_distance_meters{type=%q,window=%q} %s\n", activityType, window.name, float(byType[activityType].windows[window.name]))
+		}
+	}
+
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+func float(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// escapeTag escapes the characters line protocol gives a meaning to in tag
+// values.
+func escapeTag(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `).Replace(value)
+}
+
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// The scripts read the title and body from the environment, which spares
+// escaping them for AppleScript or PowerShell.
+const (
+	appleScript = `display notification (system attribute "SUTRO_BODY") with title (system attribute "SUTRO_TITLE")`
+
+	toastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$texts = $template.GetElementsByTagName("text")
+$texts.Item(0).AppendChild($template.CreateTextNode($env:SUTRO_TITLE)) > $null
+$texts.Item(1).AppendChild($template.CreateTextNode($env:SUTRO_BODY)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier("sutro").Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+)
+
+// Desktop shows events as native notifications: Notification Center on
+// macOS, toasts on Windows and notify-send elsewhere.
+type Desktop struct{}
+
+func (Desktop) Send(event Event) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", appleScript)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+	default:
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return errors.New("Desktop notifications require notify-send, usually provided by libnotify")
+		}
+		cmd = exec.Command("notify-send", "--app-name=sutro", event.Title(), event.Body())
+	}
+
+	cmd.Env = append(os.Environ(), "SUTRO_TITLE="+event.Title(), "SUTRO_BODY="+event.Body())
+	return cmd.Run()
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/locale"
+	"github.com/jsilland/sutro/models"
+)
+
+// Kind is the kind of an event.
+type Kind int
+
+const (
+	// NewActivity is reported for each activity recorded since the last
+	// poll.
+	NewActivity Kind = iota
+	// NewKudos is reported when an activity received kudos since the last
+	// poll.
+	NewKudos
+)
+
+// Event is something the watch noticed about an activity.
+type Event struct {
+	Kind     Kind
+	Activity *models.SummaryActivity
+	// Kudos is the number of kudos received since the last poll.
+	Kudos int64
+}
+
+// Sink delivers events, for instance as desktop notifications.
+type Sink interface {
+	Send(event Event) error
+}
+
+// Title returns a one line summary of the event.
+func (e Event) Title() string {
+	if e.Kind == NewKudos {
+		if e.Kudos == 1 {
+			return fmt.Sprintf("1 new kudo on %s", e.Activity.Name)
+		}
+		return fmt.Sprintf("%d new kudos on %s", e.Kudos, e.Activity.Name)
+	}
+	return fmt.Sprintf("New %s: %s", e.Activity.Type, e.Activity.Name)
+}
+
+// Body returns the statistics of the activity of the event.
+func (e Event) Body() string {
+	if e.Kind == NewKudos {
+		return fmt.Sprintf("%d kudos in total", e.Activity.KudosCount)
+	}
+	return Summary(e.Activity)
+}
+
+// Summary describes an activity in a line, such as 10.21 km in 52:10
+// (5:06 /km), with a pace for activities on foot and a speed otherwise.
+func Summary(activity *models.SummaryActivity) string {
+	moving := format.Duration(float64(activity.MovingTime))
+	if activity.Distance <= 0 || activity.MovingTime <= 0 {
+		start := dates.Start(time.Time(activity.StartDate), time.Time(activity.StartDateLocal))
+		return locale.T("ActivityOn", map[string]string{"Duration": moving, "Date": locale.Date(start, locale.T("ActivityDate", nil))})
+	}
+
+	kilometers, hours := float64(activity.Distance)/1000, float64(activity.MovingTime)/3600
+	speed := locale.Number(kilometers/hours, 1) + " km/h"
+	switch activity.Type {
+	case models.ActivityTypeRun, models.ActivityTypeVirtualRun, models.ActivityTypeWalk, models.ActivityTypeHike:
+		speed = format.Pace(hours * 60 / kilometers)
+	}
+	return locale.T("ActivityIn", map[string]string{"Distance": format.Kilometers(float64(activity.Distance)), "Duration": moving}) + " (" + speed + ")"
+}
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/models"
+)
+
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+// slackEscaper escapes the characters Slack reserves for links and
+// mentions within mrkdwn text.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Slack posts events to a Slack incoming webhook.
+type Slack struct {
+	URL string
+	// MapsKey, when set, attaches a map thumbnail to activities.
+	MapsKey string
+}
+
+func (s Slack) Send(event Event) error {
+	text := fmt.Sprintf("*<%s|%s>*\n%s", ActivityURL(event.Activity), slackEscaper.Replace(event.Title()), slackEscaper.Replace(event.Body()))
+	section := map[string]interface{}{
+		"type": "section",
+		"text": map[string]string{"type": "mrkdwn", "text": text},
+	}
+	if thumbnail := MapThumbnail(event.Activity, s.MapsKey); thumbnail != "" {
+		section["accessory"] = map[string]string{
+			"type":      "image",
+			"image_url": thumbnail,
+			"alt_text":  "Map of " + event.Activity.Name,
+		}
+	}
+
+	return post(s.URL, map[string]interface{}{
+		// The text is shown in notifications, where blocks are not.
+		"text":   event.Title(),
+		"blocks": []interface{}{section},
+	})
+}
+
+// Discord posts events to a Discord webhook, as embeds.
+type Discord struct {
+	URL string
+	// MapsKey, when set, attaches a map thumbnail to activities.
+	MapsKey string
+}
+
+func (d Discord) Send(event Event) error {
+	embed := map[string]interface{}{
+		"title":       event.Title(),
+		"url":         ActivityURL(event.Activity),
+		"description": event.Body(),
+		"color":       0xfc4c02,
+	}
+	if thumbnail := MapThumbnail(event.Activity, d.MapsKey); thumbnail != "" {
+		embed["thumbnail"] = map[string]string{"url": thumbnail}
+	}
+
+	return post(d.URL, map[string]interface{}{
+		"embeds": []interface{}{embed},
+	})
+}
+
+// Webhooks returns the webhook sinks configured in notifications, by name.
+func Webhooks(notifications config.Notifications) map[string]Sink {
+	sinks := map[string]Sink{}
+	if notifications.Slack != "" {
+		sinks["slack"] = Slack{URL: notifications.Slack, MapsKey: notifications.MapsKey}
+	}
+	if notifications.Discord != "" {
+		sinks["discord"] = Discord{URL: notifications.Discord, MapsKey: notifications.MapsKey}
+	}
+	return sinks
+}
+
+// ActivityURL returns the address of the page of an activity on Strava.
+func ActivityURL(activity *models.SummaryActivity) string {
+	return fmt.Sprintf("https://www.strava.com/activities/%d", activity.ID)
+}
+
+// MapThumbnail returns the address of a Google Static Maps image of the
+// track of an activity, or an empty string when the activity has no map
+// or no key is given.
+func MapThumbnail(activity *models.SummaryActivity, key string) string {
+	if key == "" || activity.Map == nil || activity.Map.SummaryPolyline == "" {
+		return ""
+	}
+
+	query := url.Values{}
+	query.Set("size", "300x300")
+	query.Set("path", "color:0xfc4c02ff|weight:3|enc:"+activity.Map.SummaryPolyline)
+	query.Set("key", key)
+	return "https://maps.googleapis.com/maps/api/staticmap?" + query.Encode()
+}
+
+func post(address string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	response, err := webhookClient.Post(address, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("The webhook responded with %s: %s", response.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Package payload reads the request bodies of operations from a file or
+// from stdin, so that updates touching many fields do not need a flag for
+// each of them.
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-openapi/strfmt"
+)
+
+// Stdin is the value of a body flag reading the body from stdin.
+const Stdin = "-"
+
+// Model is a model generated from the swagger definitions, which validates
+// itself against the constraints of its schema.
+type Model interface {
+	Validate(formats strfmt.Registry) error
+}
+
+// Read returns the body value refers to: the contents of a file for
+// @path, stdin for -, and value itself otherwise.
+func Read(value string, stdin io.Reader) ([]byte, error) {
+	switch {
+	case value == Stdin:
+		data, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the body from stdin: %v", err)
+		}
+		return data, nil
+	case strings.HasPrefix(value, "@"):
+		data, err := ioutil.ReadFile(strings.TrimPrefix(value, "@"))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the body: %v", err)
+		}
+		return data, nil
+	}
+	return []byte(value), nil
+}
+
+// Decode reads the body value refers to into model, then validates it.
+// Properties the schema does not define are rejected rather than ignored,
+// as they are most likely misspelled.
+func Decode(value string, stdin io.Reader, model Model) error {
+	data, err := Read(value, stdin)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return errors.New("The body is empty")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(model); err != nil {
+		return fmt.Errorf("Invalid body: %v", err)
+	}
+	if decoder.More() {
+		return errors.New("Invalid body: expected a single JSON object")
+	}
+	if err := model.Validate(strfmt.Default); err != nil {
+		return fmt.Errorf("Invalid body: %v", err)
+	}
+	return nil
+}
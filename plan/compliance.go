@@ -0,0 +1,100 @@
+package plan
+
+import (
+	"math"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+)
+
+// Match is a planned session along with the activity that completed it, or
+// nil when it was missed.
+type Match struct {
+	Session  store.Session
+	Activity *models.SummaryActivity
+	// Compliance is how much of the session was done, from 0 when it was
+	// missed to 1 when its duration, or else its distance, was reached.
+	Compliance float64
+}
+
+// Week is the compliance of the sessions planned for a week, which starts
+// on Monday.
+type Week struct {
+	Start      time.Time
+	Planned    int
+	Completed  int
+	Duration   time.Duration
+	Done       time.Duration
+	Compliance float64
+}
+
+// MatchSessions matches each session with the activity of the same day
+// and type, if any, whose moving time, or else distance, is the closest to
+// the one planned. Each activity completes a single session.
+func MatchSessions(sessions []store.Session, activities []*models.SummaryActivity) []Match {
+	byDay := map[string][]*models.SummaryActivity{}
+	for _, activity := range activities {
+		day := dates.Start(time.Time(activity.StartDate), time.Time(activity.StartDateLocal)).Format("2006-01-02")
+		byDay[day] = append(byDay[day], activity)
+	}
+
+	used := map[int64]bool{}
+	matches := make([]Match, len(sessions))
+	for i, session := range sessions {
+		matches[i].Session = session
+		best := math.Inf(1)
+		for _, activity := range byDay[session.Date.Format("2006-01-02")] {
+			if used[activity.ID] || (session.Type != "" && string(activity.Type) != session.Type) {
+				continue
+			}
+			if gap := math.Abs(1 - ratio(session, activity)); gap < best {
+				best, matches[i].Activity = gap, activity
+			}
+		}
+		if matches[i].Activity != nil {
+			used[matches[i].Activity.ID] = true
+			matches[i].Compliance = math.Min(1, ratio(session, matches[i].Activity))
+		}
+	}
+	return matches
+}
+
+// ratio is how much of session activity did, or 1 when the session sets
+// neither a duration nor a distance.
+func ratio(session store.Session, activity *models.SummaryActivity) float64 {
+	switch {
+	case session.Duration > 0:
+		return float64(activity.MovingTime) / session.Duration.Seconds()
+	case session.Distance > 0:
+		return float64(activity.Distance) / session.Distance
+	default:
+		return 1
+	}
+}
+
+// Weeks sums matches up by week, in order.
+func Weeks(matches []Match) []Week {
+	var weeks []Week
+	for _, match := range matches {
+		day := match.Session.Date
+		monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		if len(weeks) == 0 || !weeks[len(weeks)-1].Start.Equal(monday) {
+			weeks = append(weeks, Week{Start: monday})
+		}
+
+		week := &weeks[len(weeks)-1]
+		week.Planned++
+		week.Duration += match.Session.Duration
+		week.Compliance += match.Compliance
+		if match.Activity != nil {
+			week.Completed++
+			week.Done += time.Duration(match.Activity.MovingTime) * time.Second
+		}
+	}
+	for i := range weeks {
+		weeks[i].Compliance /= float64(weeks[i].Planned)
+	}
+	return weeks
+}
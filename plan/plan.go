@@ -0,0 +1,294 @@
+// Package plan reads training plans from CSV files and iCalendar feeds,
+// and measures how closely the activities of an athlete followed them.
+package plan
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+)
+
+// Read returns the sessions of the plan in filename, an iCalendar feed when
+// it ends in .ics and a CSV file otherwise, in the order of their days.
+func Read(filename string) ([]store.Session, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sessions []store.Session
+	if strings.EqualFold(path.Ext(filename), ".ics") {
+		sessions, err = ParseICS(file)
+	} else {
+		sessions, err = ParseCSV(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid plan %s: %v", filename, err)
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("The plan %s has no session", filename)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].Date.Before(sessions[j].Date) })
+	return sessions, nil
+}
+
+// ParseCSV reads sessions from a CSV file whose header names its columns:
+// date, such as 2024-06-01, and optionally type, name, duration, such as
+// 45m or 1:30:00, and distance, such as 10km.
+//
+//	date,type,name,duration,distance
+//	2024-06-01,Run,Easy run,45m,
+//	2024-06-02,Ride,Long ride,3h,80km
+func ParseCSV(input io.Reader) ([]store.Session, error) {
+	rows := csv.NewReader(input)
+	rows.FieldsPerRecord = -1
+	rows.TrimLeadingSpace = true
+
+	header, err := rows.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, errors.New("the header has no date column")
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var sessions []store.Session
+	for line := 2; ; line++ {
+		record, err := rows.Read()
+		if err == io.EOF {
+			return sessions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var session store.Session
+		if session.Date, err = time.ParseInLocation("2006-01-02", field(record, "date"), time.Local); err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q, expected a day such as 2024-06-01", line, field(record, "date"))
+		}
+		if value := field(record, "type"); value != "" {
+			activityType, err := strava.ParseActivityType(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			session.Type = string(activityType)
+		}
+		session.Name = field(record, "name")
+		if value := field(record, "duration"); value != "" {
+			if session.Duration, err = dates.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		if value := field(record, "distance"); value != "" {
+			if session.Distance, err = geo.ParseDistance(value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		sessions = append(sessions, session)
+	}
+}
+
+// ParseICS reads a session from each event of an iCalendar feed, such as
+// the ones coaching platforms publish. The type of a session is taken from
+// the categories of its event or the first word of its summary, such as
+// Run in "Run: 5x1k", and its duration from the end or the duration of the
+// event.
+func ParseICS(input io.Reader) ([]store.Session, error) {
+	lines, err := unfold(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []store.Session
+	var event map[string]property
+	for _, line := range lines {
+		name, p := parseProperty(line)
+		switch {
+		case name == "BEGIN" && p.value == "VEVENT":
+			event = map[string]property{}
+		case name == "END" && p.value == "VEVENT":
+			if event == nil {
+				continue
+			}
+			session, err := eventSession(event)
+			if err != nil {
+				return nil, err
+			}
+			sessions = append(sessions, session)
+			event = nil
+		case event != nil:
+			if _, ok := event[name]; !ok {
+				event[name] = p
+			}
+		}
+	}
+	return sessions, nil
+}
+
+// property is the value of a content line of iCalendar, with its
+// parameters such as VALUE=DATE or TZID=Europe/Paris.
+type property struct {
+	parameters map[string]string
+	value      string
+}
+
+// unfold returns the content lines of an iCalendar feed, joining the lines
+// continued by a leading space or tab.
+func unfold(input io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseProperty(line string) (string, property) {
+	p := property{parameters: map[string]string{}}
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), p
+	}
+	p.value = line[colon+1:]
+	parts := strings.Split(line[:colon], ";")
+	for _, parameter := range parts[1:] {
+		if equals := strings.Index(parameter, "="); equals >= 0 {
+			p.parameters[strings.ToUpper(parameter[:equals])] = strings.Trim(parameter[equals+1:], `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), p
+}
+
+func eventSession(event map[string]property) (store.Session, error) {
+	start, ok := event["DTSTART"]
+	if !ok {
+		return store.Session{}, errors.New("an event has no DTSTART")
+	}
+	begins, allDay, err := parseTime(start)
+	if err != nil {
+		return store.Session{}, err
+	}
+
+	session := store.Session{
+		Date: time.Date(begins.Year(), begins.Month(), begins.Day(), 0, 0, 0, 0, time.Local),
+		Name: unescape(event["SUMMARY"].value),
+	}
+	if end, ok := event["DTEND"]; ok && !allDay {
+		ends, _, err := parseTime(end)
+		if err != nil {
+			return store.Session{}, err
+		}
+		if ends.After(begins) {
+			session.Duration = ends.Sub(begins)
+		}
+	} else if duration, ok := event["DURATION"]; ok {
+		if session.Duration, err = parseDuration(duration.value); err != nil {
+			return store.Session{}, err
+		}
+	}
+
+	candidates := strings.Split(unescape(event["CATEGORIES"].value), ",")
+	if fields := strings.FieldsFunc(session.Name, func(r rune) bool { return r == ' ' || r == ':' || r == '-' }); len(fields) > 0 {
+		candidates = append(candidates, fields[0])
+	}
+	for _, candidate := range candidates {
+		if activityType, err := strava.ParseActivityType(candidate); err == nil {
+			session.Type = string(activityType)
+			break
+		}
+	}
+	return session, nil
+}
+
+// parseTime reads a DTSTART or DTEND, in the zone it names, in UTC, or else
+// in the local time zone, and tells whether it is a day without a time.
+func parseTime(p property) (time.Time, bool, error) {
+	location := time.Local
+	if name, ok := p.parameters["TZID"]; ok {
+		if zone, err := time.LoadLocation(name); err == nil {
+			location = zone
+		}
+	}
+	value := strings.TrimSpace(p.value)
+	switch {
+	case len(value) == 8:
+		day, err := time.ParseInLocation("20060102", value, location)
+		return day, true, err
+	case strings.HasSuffix(value, "Z"):
+		parsed, err := time.Parse("20060102T150405Z", value)
+		return parsed.In(time.Local), false, err
+	default:
+		parsed, err := time.ParseInLocation("20060102T150405", value, location)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+		}
+		return parsed, false, nil
+	}
+}
+
+// parseDuration reads a duration of iCalendar, such as PT1H30M or P1D.
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "+")
+	if !strings.HasPrefix(value, "P") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	var total time.Duration
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	number := ""
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == 'T':
+		case c >= '0' && c <= '9':
+			number += string(c)
+		default:
+			unit, ok := units[c]
+			n, err := strconv.Atoi(number)
+			if !ok || err != nil {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			total += time.Duration(n) * unit
+			number = ""
+		}
+	}
+	return total, nil
+}
+
+// unescape reverts the escaping of the text values of iCalendar.
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
@@ -0,0 +1,130 @@
+// Package predict estimates race times and training paces from a
+// performance, with the models of Peter Riegel and of Jack Daniels.
+package predict
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jsilland/sutro/geo"
+)
+
+// Distance is a race distance, in meters.
+type Distance struct {
+	Name   string
+	Meters float64
+}
+
+// Distances are the usual race distances, by name.
+var Distances = map[string]Distance{
+	"1500m":    {Name: "1500m", Meters: 1500},
+	"mile":     {Name: "Mile", Meters: 1609.344},
+	"5k":       {Name: "5k", Meters: 5000},
+	"10k":      {Name: "10k", Meters: 10000},
+	"15k":      {Name: "15k", Meters: 15000},
+	"half":     {Name: "Half marathon", Meters: 21097.5},
+	"marathon": {Name: "Marathon", Meters: 42195},
+}
+
+// DefaultDistances are the distances predicted when none is asked for.
+var DefaultDistances = []string{"5k", "10k", "half", "marathon"}
+
+// DistanceNames returns the names of Distances, sorted.
+func DistanceNames() []string {
+	names := make([]string, 0, len(Distances))
+	for name := range Distances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseDistance returns the race distance named by value, such as
+// marathon, or the one of a length such as 30km.
+func ParseDistance(value string) (Distance, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	switch name {
+	case "half-marathon", "half marathon":
+		name = "half"
+	}
+	if distance, ok := Distances[name]; ok {
+		return distance, nil
+	}
+
+	meters, err := geo.ParseDistance(value)
+	if err != nil || meters <= 0 {
+		return Distance{}, fmt.Errorf("Unknown distance %q, expected one of %s or a length such as 30km", value, strings.Join(DistanceNames(), ", "))
+	}
+	return Distance{Name: value, Meters: meters}, nil
+}
+
+// riegelExponent is the fatigue factor of Riegel's model for runners.
+const riegelExponent = 1.06
+
+// Riegel returns the time, in seconds, to run meters at the level of a run
+// of from meters in seconds.
+func Riegel(from, seconds, meters float64) float64 {
+	return seconds * math.Pow(meters/from, riegelExponent)
+}
+
+// VDOT returns the VDOT of Jack Daniels of a run of meters in seconds: the
+// VO2max, in ml/kg/min, its time implies.
+func VDOT(meters, seconds float64) float64 {
+	minutes := seconds / 60
+	return oxygenCost(meters/minutes) / sustained(minutes)
+}
+
+// TimeFor returns the time, in seconds, in which a runner of vdot races
+// meters.
+func TimeFor(vdot, meters float64) float64 {
+	// VDOT falls as the time grows for a given distance, which a bisection
+	// between a world record pace and a walk inverts.
+	low, high := meters/400*60, meters/50*60
+	for i := 0; i < 100; i++ {
+		middle := (low + high) / 2
+		if VDOT(meters, middle) > vdot {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2
+}
+
+// Pace returns the pace, in minutes per kilometer, at which a runner of
+// vdot runs at intensity, the fraction of their VO2max.
+func Pace(vdot, intensity float64) float64 {
+	// The oxygen cost is a quadratic of the speed, in meters per minute.
+	a, b, c := 0.000104, 0.182258, -4.60-vdot*intensity
+	speed := (-b + math.Sqrt(b*b-4*a*c)) / (2 * a)
+	return 1000 / speed
+}
+
+// Zone is a training intensity of Jack Daniels, as fractions of VO2max.
+type Zone struct {
+	Name     string
+	From, To float64
+}
+
+// Zones are the training intensities of Daniels' Running Formula. The pace
+// of marathon training is the predicted marathon pace rather than a
+// fraction of VO2max.
+var Zones = []Zone{
+	{Name: "Easy", From: 0.59, To: 0.74},
+	{Name: "Threshold", From: 0.83, To: 0.88},
+	{Name: "Interval", From: 0.95, To: 1},
+	{Name: "Repetition", From: 1.05, To: 1.1},
+}
+
+// oxygenCost is the oxygen consumed running at speed meters per minute, in
+// ml/kg/min.
+func oxygenCost(speed float64) float64 {
+	return -4.60 + 0.182258*speed + 0.000104*speed*speed
+}
+
+// sustained is the fraction of VO2max that can be sustained for minutes.
+func sustained(minutes float64) float64 {
+	return 0.8 + 0.1894393*math.Exp(-0.012778*minutes) + 0.2989558*math.Exp(-0.1932605*minutes)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/go-openapi/runtime"
+	"github.com/jsilland/sutro/auth"
+	"github.com/jsilland/sutro/strava"
+	"golang.org/x/oauth2"
+)
+
+// preflight checks that the token is valid before each operation,
+// refreshing it once it expired. Without it, a refresh token that Strava
+// rejects surfaces as a failure to send the request, with the response of
+// Strava as its only explanation.
+func preflight(tokens oauth2.TokenSource, clientID string) strava.Interceptor {
+	return func(next strava.Runner) strava.Runner {
+		return func(operation *runtime.ClientOperation) (interface{}, error) {
+			if _, err := auth.Refresh(tokens, clientID); err != nil {
+				return nil, err
+			}
+			return next(operation)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package profiles selects the profile sutro runs as, such as one per
+// athlete a coach follows, and runs read-only commands as every profile at
+// once.
+package profiles
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/jsilland/sutro/config"
+	"github.com/spf13/cobra"
+)
+
+// Environment is the variable selecting the profile when --profile is not
+// given, which the commands run for --all-profiles are given.
+const Environment = "SUTRO_PROFILE"
+
+const (
+	profileFlag = "profile"
+	allFlag     = "all-profiles"
+)
+
+// Selected returns the profile args select with --profile, else the one
+// of the environment, else the default profile. Profiles are selected
+// before the commands are built, as each has its own configuration.
+func Selected(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--"+profileFlag && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "--"+profileFlag+"=") {
+			return strings.TrimPrefix(arg, "--"+profileFlag+"=")
+		}
+	}
+	if profile := os.Getenv(Environment); profile != "" {
+		return profile
+	}
+	return config.DefaultProfile
+}
+
+// Register adds --profile to the flags of root and its subcommands, which
+// Selected reads ahead of them.
+func Register(root *cobra.Command, selected string) {
+	root.PersistentFlags().String(profileFlag, selected, "The profile to run as, such as one per athlete, each with its own configuration and archive")
+}
+
+// Fanout adds --all-profiles to command, which then runs as each of the
+// profiles listed concurrently instead, and returns it. The lines each
+// profile outputs are prefixed with its name.
+func Fanout(command *cobra.Command, list func() ([]string, error)) *cobra.Command {
+	all := command.Flags().Bool(allFlag, false, "Run the command as every configured profile concurrently")
+
+	run := command.RunE
+	command.RunE = func(cmd *cobra.Command, args []string) error {
+		if !*all {
+			return run(cmd, args)
+		}
+		profiles, err := list()
+		if err != nil {
+			return err
+		}
+		if len(profiles) == 0 {
+			return fmt.Errorf("No profile is configured, run sutro --profile <name> authenticate to add one")
+		}
+		return runAll(profiles, strip(os.Args[1:]))
+	}
+	return command
+}
+
+// runAll runs sutro with args as each of profiles, and waits for them all.
+func runAll(profiles []string, args []string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	width := 0
+	for _, profile := range profiles {
+		if len(profile) > width {
+			width = len(profile)
+		}
+	}
+
+	var mutex sync.Mutex
+	var group sync.WaitGroup
+	errs := make([]error, len(profiles))
+	for i, profile := range profiles {
+		prefix := fmt.Sprintf("%-*s | ", width, profile)
+		stdout := &prefixWriter{writer: os.Stdout, prefix: prefix, mutex: &mutex}
+		stderr := &prefixWriter{writer: os.Stderr, prefix: prefix, mutex: &mutex}
+
+		command := exec.Command(executable, args...)
+		command.Env = append(os.Environ(), Environment+"="+profile)
+		command.Stdout, command.Stderr = stdout, stderr
+
+		group.Add(1)
+		go func(i int) {
+			defer group.Done()
+			errs[i] = command.Run()
+			stdout.Flush()
+			stderr.Flush()
+		}(i)
+	}
+	group.Wait()
+
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, profiles[i])
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("The command failed for %d of %d profiles: %s", len(failed), len(profiles), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// strip removes the flags selecting profiles from args, which the profile
+// of each command replaces.
+func strip(args []string) []string {
+	var stripped []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(stripped, args[i:]...)
+		case arg == "--"+profileFlag:
+			// The value of the flag is dropped along with it.
+			i++
+		case arg == "--"+allFlag || strings.HasPrefix(arg, "--"+allFlag+"="):
+		case strings.HasPrefix(arg, "--"+profileFlag+"="):
+		default:
+			stripped = append(stripped, arg)
+		}
+	}
+	return stripped
+}
+
+// prefixWriter writes whole lines to writer, each after prefix, so that
+// the lines of concurrent commands sharing mutex do not interleave.
+type prefixWriter struct {
+	writer  io.Writer
+	prefix  string
+	mutex   *sync.Mutex
+	pending []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		end := bytes.IndexByte(w.pending, '\n')
+		if end < 0 {
+			return len(p), nil
+		}
+		if err := w.line(w.pending[:end+1]); err != nil {
+			return len(p), err
+		}
+		w.pending = w.pending[end+1:]
+	}
+}
+
+// Flush writes the last line, when it does not end with a new line.
+func (w *prefixWriter) Flush() {
+	if len(w.pending) > 0 {
+		_ = w.line(append(w.pending, '\n'))
+		w.pending = nil
+	}
+}
+
+func (w *prefixWriter) line(line []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	_, err := fmt.Fprintf(w.writer, "%s%s", w.prefix, line)
+	return err
+}
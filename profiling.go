@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	rpprof "runtime/pprof"
+
+	"github.com/spf13/cobra"
+)
+
+// profilingFlags are hidden flags diagnosing performance issues with the
+// standard Go tooling, such as go tool pprof.
+type profilingFlags struct {
+	cpuProfile string
+	memProfile string
+	pprofAddr  string
+
+	cpuFile *os.File
+}
+
+func (p *profilingFlags) register(command *cobra.Command) {
+	flags := command.PersistentFlags()
+	flags.StringVar(&p.cpuProfile, "cpuprofile", "", "Write a CPU profile of the command to this file")
+	flags.StringVar(&p.memProfile, "memprofile", "", "Write a heap profile to this file once the command completes")
+	flags.StringVar(&p.pprofAddr, "pprof-addr", "", "Serve the runtime profiles at /debug/pprof/ on this address (e.g. localhost:6060)")
+	for _, name := range []string{"cpuprofile", "memprofile", "pprof-addr"} {
+		_ = flags.MarkHidden(name)
+	}
+}
+
+// start starts the CPU profile and the pprof server, as requested.
+func (p *profilingFlags) start() error {
+	if p.pprofAddr != "" {
+		listener, err := net.Listen("tcp", p.pprofAddr)
+		if err != nil {
+			return err
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		fmt.Fprintf(os.Stderr, "Serving profiles at http://%s/debug/pprof/\n", listener.Addr())
+		go http.Serve(listener, mux)
+	}
+
+	if p.cpuProfile != "" {
+		file, err := os.Create(p.cpuProfile)
+		if err != nil {
+			return err
+		}
+		if err := rpprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return err
+		}
+		p.cpuFile = file
+	}
+	return nil
+}
+
+// stop completes the CPU profile and writes the heap profile, as
+// requested. It is called whether or not the command succeeded.
+func (p *profilingFlags) stop() {
+	if p.cpuFile != nil {
+		rpprof.StopCPUProfile()
+		if err := p.cpuFile.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write the CPU profile: %v\n", err)
+		}
+		p.cpuFile = nil
+	}
+
+	if p.memProfile != "" {
+		file, err := os.Create(p.memProfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write the heap profile: %v\n", err)
+			return
+		}
+		defer file.Close()
+		// Collecting garbage first reports the memory still in use.
+		runtime.GC()
+		if err := rpprof.WriteHeapProfile(file); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to write the heap profile: %v\n", err)
+		}
+	}
+}
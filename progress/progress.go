@@ -0,0 +1,375 @@
+// Package progress reports the progress of long transfers and jobs, such
+// as the downloads of files or the activities of a sync, on stderr. On a
+// terminal, a bar is redrawn in place; otherwise, as when stderr goes to a
+// log file, a line is printed every now and then. Wrappers displaying
+// progress themselves can ask for events as lines of JSON instead.
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jsilland/sutro/cache"
+)
+
+// interval is the time between two redraws of a bar on a terminal.
+const interval = 250 * time.Millisecond
+
+// logInterval is the time between two lines reporting the progress when
+// stderr is not a terminal.
+const logInterval = 30 * time.Second
+
+// jsonInterval is the time between two events when progress is reported
+// as JSON.
+const jsonInterval = time.Second
+
+// barWidth is the number of characters of the bar itself.
+const barWidth = 24
+
+// The formats progress is reported in.
+const (
+	// Auto draws a bar on a terminal, and otherwise prints a line every now
+	// and then.
+	Auto = "auto"
+	// JSON prints an Event as a line of JSON every second, and once the job
+	// is done.
+	JSON = "json"
+)
+
+// format is the format progress is reported in.
+var format = Auto
+
+// SetFormat sets the format progress is reported in, Auto or JSON, for the
+// bars created afterwards.
+func SetFormat(name string) error {
+	switch strings.ToLower(name) {
+	case Auto, "":
+		format = Auto
+	case JSON:
+		format = JSON
+	default:
+		return fmt.Errorf("Invalid --progress %q, expected auto or json", name)
+	}
+	return nil
+}
+
+// Event is a report of progress as JSON.
+type Event struct {
+	Time time.Time `json:"time"`
+	// Phase is what the job is doing, such as Synchronizing.
+	Phase string `json:"phase"`
+	// Status is running, paused while the job waits for the rate limit of
+	// the API to reset, or done once it completed or failed.
+	Status    string `json:"status"`
+	Completed int64  `json:"completed"`
+	// Total is omitted while it is unknown.
+	Total int64 `json:"total,omitempty"`
+	// Unit is what is counted, such as activities or bytes.
+	Unit string `json:"unit,omitempty"`
+	// Rate is the number of units done per second, and ETA the number of
+	// seconds left, once they can be estimated.
+	Rate float64 `json:"rate,omitempty"`
+	ETA  float64 `json:"eta_seconds,omitempty"`
+	// ResumeAt is when a paused job resumes.
+	ResumeAt *time.Time `json:"resume_at,omitempty"`
+}
+
+// Bar reports the progress of a job towards a total, which is zero when it
+// is unknown. Reports never go to stdout, which may be the destination of
+// the job. The methods of a nil Bar do nothing, for jobs run without
+// reporting their progress.
+type Bar struct {
+	label    string
+	unit     string
+	bytes    bool
+	total    int64
+	done     int64
+	report   io.Writer
+	terminal bool
+	json     bool
+
+	mutex   sync.Mutex
+	start   time.Time
+	last    time.Time
+	resume  time.Time
+	paused  time.Duration
+	drawn   bool
+	stopped bool
+}
+
+// New returns a bar counting items named after unit, such as activities,
+// towards total, and reporting them as label, such as Synchronizing.
+func New(label string, total int64, unit string) *Bar {
+	return newBar(label, total, unit, false)
+}
+
+// NewBytes returns a bar counting bytes towards total.
+func NewBytes(label string, total int64) *Bar {
+	return newBar(label, total, "", true)
+}
+
+func newBar(label string, total int64, unit string, bytes bool) *Bar {
+	now := time.Now()
+	b := &Bar{label: label, unit: unit, bytes: bytes, total: total, report: os.Stderr, start: now, last: now, json: format == JSON}
+	if info, err := os.Stderr.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		b.terminal = true
+	}
+	return b
+}
+
+// Add counts n more items or bytes done.
+func (b *Bar) Add(n int64) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.done += n
+	b.draw(false)
+}
+
+// SetTotal sets the total once it is known.
+func (b *Bar) SetTotal(total int64) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.total = total
+}
+
+// Pause reports that the job waits for the rate limit of the API to reset
+// at resume. The time paused is left out of the throughput.
+func (b *Bar) Pause(resume time.Time) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.resume = resume
+	b.draw(true)
+	// The pause lasts until the next progress, which is reported as soon
+	// as it happens.
+	if wait := time.Until(resume); wait > 0 {
+		b.paused += wait
+	}
+	b.last = time.Time{}
+}
+
+// Done ends the report, once the job completed or failed.
+func (b *Bar) Done() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.stopped {
+		return
+	}
+	b.stopped = true
+	b.resume = time.Time{}
+	if b.json {
+		b.emit("done")
+		return
+	}
+	if b.terminal && b.drawn {
+		fmt.Fprintf(b.report, "\r\033[K%s\n", b.line())
+	}
+}
+
+// draw reports the progress if it is time to, or right away when forced.
+func (b *Bar) draw(force bool) {
+	if b.stopped {
+		return
+	}
+	every := logInterval
+	switch {
+	case b.json:
+		every = jsonInterval
+	case b.terminal:
+		every = interval
+	}
+	if !force && time.Since(b.last) < every {
+		return
+	}
+	b.last = time.Now()
+	b.drawn = true
+	if b.json {
+		status := "running"
+		if b.waiting() {
+			status = "paused"
+		}
+		b.emit(status)
+	} else if b.terminal {
+		fmt.Fprintf(b.report, "\r\033[K%s", b.line())
+	} else {
+		fmt.Fprintln(b.report, b.line())
+	}
+}
+
+// line renders the progress, such as
+//
+//	Synchronizing [==========>             ] 400/900 activities  12.3/s  ETA 41s
+func (b *Bar) line() string {
+	parts := []string{b.label}
+	if b.total > 0 {
+		filled := int(float64(barWidth) * float64(b.done) / float64(b.total))
+		if filled > barWidth {
+			filled = barWidth
+		}
+		bar := strings.Repeat("=", filled)
+		if filled < barWidth {
+			bar += ">" + strings.Repeat(" ", barWidth-filled-1)
+		}
+		parts = append(parts, "["+bar+"]", b.count(b.done)+"/"+b.count(b.total)+b.units())
+	} else {
+		parts = append(parts, b.count(b.done)+b.units())
+	}
+
+	if b.waiting() {
+		return strings.Join(append(parts, "rate limited, resuming at "+b.resume.Format("15:04")), "  ")
+	}
+
+	rate, eta := b.estimate()
+	if rate > 0 {
+		parts = append(parts, b.rate(rate))
+	}
+	if eta > 0 {
+		parts = append(parts, "ETA "+eta.Round(time.Second).String())
+	}
+	return strings.Join(parts, "  ")
+}
+
+// emit reports the progress as an Event with the given status.
+func (b *Bar) emit(status string) {
+	event := Event{Time: time.Now(), Phase: b.label, Status: status, Completed: b.done, Total: b.total, Unit: b.unit}
+	if b.bytes {
+		event.Unit = "bytes"
+	}
+	if status == "paused" {
+		resume := b.resume
+		event.ResumeAt = &resume
+	} else {
+		rate, eta := b.estimate()
+		event.Rate, event.ETA = rate, eta.Seconds()
+	}
+	json.NewEncoder(b.report).Encode(event)
+}
+
+// waiting reports whether the job waits for the rate limit to reset.
+func (b *Bar) waiting() bool {
+	return !b.resume.IsZero() && time.Now().Before(b.resume)
+}
+
+// estimate returns the throughput of the job, in units per second, and the
+// time it has left, each of which is zero until it can be estimated.
+func (b *Bar) estimate() (float64, time.Duration) {
+	elapsed := time.Since(b.start) - b.paused
+	if elapsed < time.Second || b.done == 0 {
+		return 0, 0
+	}
+	rate := float64(b.done) / elapsed.Seconds()
+	if b.total <= b.done {
+		return rate, 0
+	}
+	return rate, time.Duration(float64(b.total-b.done) / rate * float64(time.Second))
+}
+
+// live reports whether transfers, which are short, are reported: on a
+// terminal, or as JSON for the wrappers that asked for it.
+func (b *Bar) live() bool {
+	return b.terminal || b.json
+}
+
+func (b *Bar) count(n int64) string {
+	if b.bytes {
+		return cache.FormatSize(n)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func (b *Bar) units() string {
+	if b.unit == "" {
+		return ""
+	}
+	return " " + b.unit
+}
+
+func (b *Bar) rate(rate float64) string {
+	if b.bytes {
+		return cache.FormatSize(int64(rate)) + "/s"
+	}
+	if rate < 1 {
+		return fmt.Sprintf("%.1f/min", rate*60)
+	}
+	return fmt.Sprintf("%.1f/s", rate)
+}
+
+// Writer counts the bytes written through it on a bar.
+type Writer struct {
+	writer io.Writer
+	bar    *Bar
+}
+
+// NewWriter returns a writer to writer reporting the bytes written as
+// label, such as Downloading route 1234. Its progress is only reported on
+// a terminal, as transfers are short.
+func NewWriter(writer io.Writer, label string) *Writer {
+	w := &Writer{writer: writer}
+	if bar := NewBytes(label, 0); bar.live() {
+		w.bar = bar
+	}
+	return w
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.bar.Add(int64(n))
+	return n, err
+}
+
+// Done ends the report, once the transfer completed or failed.
+func (w *Writer) Done() {
+	w.bar.Done()
+}
+
+// Reader counts the bytes read from a file on a bar, such as the ones of
+// an upload.
+type Reader struct {
+	*os.File
+	bar *Bar
+}
+
+// NewReader returns a reader of file reporting the bytes read out of its
+// size as label, on a terminal.
+func NewReader(file *os.File, label string) *Reader {
+	r := &Reader{File: file}
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	if bar := NewBytes(label, size); bar.live() {
+		r.bar = bar
+	}
+	return r
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.File.Read(p)
+	r.bar.Add(int64(n))
+	if err == io.EOF {
+		r.bar.Done()
+	}
+	return n, err
+}
+
+// Close closes the file and ends the report.
+func (r *Reader) Close() error {
+	r.bar.Done()
+	return r.File.Close()
+}
@@ -0,0 +1,41 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Boolean asks the user a yes/no question on the terminal, allowing a few
+// attempts at a valid answer.
+func Boolean(prompt string) (bool, error) {
+	fmt.Printf("%s (yes/no):", prompt)
+	return booleanOnce(3)
+}
+
+func booleanOnce(remainingAttempts int) (bool, error) {
+	if remainingAttempts == 0 {
+		return false, errors.New("Failed to obtain result from prompt")
+	}
+
+	var result string
+
+	_, err := fmt.Scan(&result)
+	if err != nil {
+		return false, err
+	}
+
+	result = strings.TrimSpace(result)
+	result = strings.ToLower(result)
+
+	switch result {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	default:
+		fmt.Print("Please enter 'yes' or 'no': ")
+		remainingAttempts--
+		return booleanOnce(remainingAttempts)
+	}
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"math"
+	"time"
+
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+)
+
+// Sports are the sports balance reports break volume down by, a triathlon
+// and the rest.
+var Sports = []string{"Swim", "Bike", "Run", "Other"}
+
+// Intensities are the intensities balance reports break volume down by,
+// from the thresholds valid when each activity took place.
+var Intensities = []string{"Low", "Moderate", "High", "Unknown"}
+
+// sports maps the types of activities to their sport.
+var sports = map[models.ActivityType]string{
+	models.ActivityTypeSwim:        "Swim",
+	models.ActivityTypeRide:        "Bike",
+	models.ActivityTypeVirtualRide: "Bike",
+	models.ActivityTypeEBikeRide:   "Bike",
+	models.ActivityTypeHandcycle:   "Bike",
+	models.ActivityTypeVelomobile:  "Bike",
+	models.ActivityTypeRun:         "Run",
+	models.ActivityTypeVirtualRun:  "Run",
+}
+
+// BalanceWeek is the volume of the activities of a week, starting on
+// Monday, by sport and by intensity.
+type BalanceWeek struct {
+	Start       time.Time
+	Total       time.Duration
+	Sports      map[string]time.Duration
+	Intensities map[string]time.Duration
+	// Ramp is the change of the total volume from the week before, as a
+	// fraction, or NaN for the first week and after a week off.
+	Ramp float64
+}
+
+// Balance returns the weeks of the activities of archive started from
+// since until until, including the weeks without activity in between.
+func Balance(archive *store.Store, since, until time.Time) ([]BalanceWeek, error) {
+	activities, err := archive.Activities(store.Query{After: since, Before: until})
+	if err != nil {
+		return nil, err
+	}
+	history, err := archive.ThresholdHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	byStart := map[string]*BalanceWeek{}
+	var first, last time.Time
+	for _, activity := range activities {
+		start := localStart(activity)
+		monday := mondayOf(start)
+		key := monday.Format("2006-01-02")
+		week, ok := byStart[key]
+		if !ok {
+			week = newBalanceWeek(monday)
+			byStart[key] = week
+		}
+		if first.IsZero() || monday.Before(first) {
+			first = monday
+		}
+		if monday.After(last) {
+			last = monday
+		}
+
+		moving := time.Duration(activity.MovingTime) * time.Second
+		sport, ok := sports[activity.Type]
+		if !ok {
+			sport = "Other"
+		}
+		week.Total += moving
+		week.Sports[sport] += moving
+		week.Intensities[intensity(activity, store.ValidThresholds(history, start))] += moving
+	}
+	if first.IsZero() {
+		return nil, nil
+	}
+
+	var weeks []BalanceWeek
+	for monday := first; !monday.After(last); monday = monday.AddDate(0, 0, 7) {
+		week, ok := byStart[monday.Format("2006-01-02")]
+		if !ok {
+			week = newBalanceWeek(monday)
+		}
+		week.Ramp = math.NaN()
+		if n := len(weeks); n > 0 && weeks[n-1].Total > 0 {
+			week.Ramp = week.Total.Seconds()/weeks[n-1].Total.Seconds() - 1
+		}
+		weeks = append(weeks, *week)
+	}
+	return weeks, nil
+}
+
+func newBalanceWeek(monday time.Time) *BalanceWeek {
+	return &BalanceWeek{Start: monday, Sports: map[string]time.Duration{}, Intensities: map[string]time.Duration{}}
+}
+
+// mondayOf returns the Monday of the week of day, as weeks start on Monday
+// in reports and digests.
+func mondayOf(day time.Time) time.Time {
+	year, month, date := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)).Date()
+	return time.Date(year, month, date, 0, 0, 0, 0, day.Location())
+}
+
+// intensity rates an activity Low, Moderate or High from its weighted
+// average power against the FTP or else its average heart rate against the
+// LTHR, in the three zones split around the aerobic and the anaerobic
+// thresholds, or Unknown without data or thresholds to rate it with.
+func intensity(activity *models.SummaryActivity, thresholds store.Thresholds) string {
+	var fraction, low, high float64
+	switch {
+	case activity.DeviceWatts && activity.WeightedAverageWatts > 0 && thresholds.FTP > 0:
+		fraction, low, high = float64(activity.WeightedAverageWatts)/thresholds.FTP, 0.76, 0.91
+	case activity.AverageHeartrate > 0 && thresholds.LTHR > 0:
+		fraction, low, high = float64(activity.AverageHeartrate)/thresholds.LTHR, 0.9, 0.95
+	default:
+		return "Unknown"
+	}
+	switch {
+	case fraction < low:
+		return "Low"
+	case fraction < high:
+		return "Moderate"
+	default:
+		return "High"
+	}
+}
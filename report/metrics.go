@@ -0,0 +1,212 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/locale"
+	"github.com/jsilland/sutro/models"
+)
+
+// metric is a value computed over the activities of a group.
+type metric struct {
+	label   string
+	compute func([]*models.SummaryActivity) float64
+	format  func(float64) string
+}
+
+// metrics are the metrics reports can compute, by name.
+var metrics = map[string]metric{
+	"count": {
+		label:   "ACTIVITIES",
+		compute: func(activities []*models.SummaryActivity) float64 { return float64(len(activities)) },
+		format:  func(value float64) string { return strconv.Itoa(int(value)) },
+	},
+	"distance": {
+		label:   "DISTANCE",
+		compute: sum(func(a *models.SummaryActivity) float64 { return float64(a.Distance) }),
+		format:  format.Kilometers,
+	},
+	"moving_time": {
+		label:   "MOVING TIME",
+		compute: sum(func(a *models.SummaryActivity) float64 { return float64(a.MovingTime) }),
+		format:  format.Duration,
+	},
+	"elapsed_time": {
+		label:   "ELAPSED TIME",
+		compute: sum(func(a *models.SummaryActivity) float64 { return float64(a.ElapsedTime) }),
+		format:  format.Duration,
+	},
+	"elevation": {
+		label:   "ELEVATION",
+		compute: sum(func(a *models.SummaryActivity) float64 { return float64(a.TotalElevationGain) }),
+		format:  meters,
+	},
+	"kudos": {
+		label:   "KUDOS",
+		compute: sum(func(a *models.SummaryActivity) float64 { return float64(a.KudosCount) }),
+		format:  func(value float64) string { return strconv.Itoa(int(value)) },
+	},
+	"longest": {
+		label: "LONGEST",
+		compute: func(activities []*models.SummaryActivity) float64 {
+			longest := 0.0
+			for _, a := range activities {
+				longest = math.Max(longest, float64(a.Distance))
+			}
+			return longest
+		},
+		format: format.Kilometers,
+	},
+	"avg_speed": {
+		label:   "AVG SPEED",
+		compute: averageSpeed,
+		format:  unless(func(value float64) string { return locale.Number(value*3.6, 1) + " km/h" }),
+	},
+	"avg_pace": {
+		label:   "AVG PACE",
+		compute: averageSpeed,
+		format:  unless(func(value float64) string { return format.Pace(1000 / value / 60) }),
+	},
+	"avg_heartrate": {
+		label: "AVG HR",
+		compute: func(activities []*models.SummaryActivity) float64 {
+			// Activities are weighted by their moving time, and those
+			// recorded without a heart rate monitor left out.
+			beats, seconds := 0.0, 0.0
+			for _, a := range activities {
+				if a.AverageHeartrate > 0 {
+					beats += float64(a.AverageHeartrate) * float64(a.MovingTime)
+					seconds += float64(a.MovingTime)
+				}
+			}
+			if seconds == 0 {
+				return math.NaN()
+			}
+			return beats / seconds
+		},
+		format: unless(func(value float64) string { return locale.Number(value, 0) + " bpm" }),
+	},
+}
+
+func sum(value func(*models.SummaryActivity) float64) func([]*models.SummaryActivity) float64 {
+	return func(activities []*models.SummaryActivity) float64 {
+		total := 0.0
+		for _, a := range activities {
+			total += value(a)
+		}
+		return total
+	}
+}
+
+// averageSpeed returns the distance covered per second of moving time, or
+// NaN without any.
+func averageSpeed(activities []*models.SummaryActivity) float64 {
+	distance, seconds := 0.0, 0.0
+	for _, a := range activities {
+		distance += float64(a.Distance)
+		seconds += float64(a.MovingTime)
+	}
+	if seconds == 0 || distance == 0 {
+		return math.NaN()
+	}
+	return distance / seconds
+}
+
+func meters(value float64) string {
+	return locale.Number(value, 0) + " m"
+}
+
+// unless formats the values that are not NaN with format, and NaN as -.
+func unless(format func(float64) string) func(float64) string {
+	return func(value float64) string {
+		if math.IsNaN(value) {
+			return "-"
+		}
+		return format(value)
+	}
+}
+
+func metricNames() []string {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// grouping is a way to group the activities of a report, by a key that is
+// sorted by sort.
+type grouping struct {
+	label string
+	key   func(*models.SummaryActivity) string
+	sort  func([]string)
+}
+
+// groupings are the groupings of reports, by the name of group_by.
+var groupings = map[string]grouping{
+	"":      {label: "", key: func(*models.SummaryActivity) string { return "All" }, sort: sort.Strings},
+	"day":   {label: "DAY", key: byStart("2006-01-02"), sort: sort.Strings},
+	"month": {label: "MONTH", key: byStart("2006-01"), sort: sort.Strings},
+	"year":  {label: "YEAR", key: byStart("2006"), sort: sort.Strings},
+	"week": {
+		label: "WEEK",
+		key: func(a *models.SummaryActivity) string {
+			start := localStart(a)
+			// Weeks start on Monday, as the ones of digests.
+			monday := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+			return monday.Format("2006-01-02")
+		},
+		sort: sort.Strings,
+	},
+	"weekday": {
+		label: "WEEKDAY",
+		key:   func(a *models.SummaryActivity) string { return localStart(a).Weekday().String() },
+		sort: func(keys []string) {
+			order := map[string]int{}
+			for day := time.Sunday; day <= time.Saturday; day++ {
+				order[day.String()] = (int(day) + 6) % 7
+			}
+			sort.Slice(keys, func(i, j int) bool { return order[keys[i]] < order[keys[j]] })
+		},
+	},
+	"type": {label: "TYPE", key: func(a *models.SummaryActivity) string { return string(a.Type) }, sort: sort.Strings},
+	"gear": {
+		label: "GEAR",
+		key: func(a *models.SummaryActivity) string {
+			if a.GearID == "" {
+				return "none"
+			}
+			return a.GearID
+		},
+		sort: sort.Strings,
+	},
+}
+
+func byStart(layout string) func(*models.SummaryActivity) string {
+	return func(a *models.SummaryActivity) string {
+		return localStart(a).Format(layout)
+	}
+}
+
+// localStart returns the start of the activity on the clock where it took
+// place.
+func localStart(a *models.SummaryActivity) time.Time {
+	return dates.Start(time.Time(a.StartDate), time.Time(a.StartDateLocal))
+}
+
+func groupingNames() []string {
+	var names []string
+	for name := range groupings {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
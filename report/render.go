@@ -0,0 +1,82 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/locale"
+)
+
+var functions = template.FuncMap{
+	"kilometers": format.Kilometers,
+	"duration":   format.Duration,
+	"meters":     meters,
+	"number":     locale.Number,
+	"upper":      strings.ToUpper,
+	// metric formats the value of the metric name as tables do, such as
+	// {{metric "distance" .Total.Values.distance}}.
+	"metric": func(name string, value float64) (string, error) {
+		m, ok := metrics[name]
+		if !ok {
+			return "", fmt.Errorf("unknown metric %q", name)
+		}
+		return m.format(value), nil
+	},
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	parsed, err := template.New("report").Funcs(functions).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return parsed, nil
+}
+
+// Render writes the result of d to w with the template of d, or else as a
+// table with a row per group and one for the total.
+func (d *Definition) Render(w io.Writer, result *Result) error {
+	parsed, err := parseTemplate(d.Template)
+	if err != nil {
+		return err
+	}
+	if parsed != nil {
+		return parsed.Execute(w, result)
+	}
+
+	fmt.Fprintf(w, "%s\n", result.Title)
+	if result.Description != "" {
+		fmt.Fprintf(w, "%s\n", result.Description)
+	}
+	fmt.Fprintln(w)
+	if len(result.Groups) == 0 {
+		fmt.Fprintln(w, "No activity matches the report")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{groupings[result.GroupBy].label}
+	for _, name := range result.Metrics {
+		header = append(header, metrics[name].label)
+	}
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+
+	groups := result.Groups
+	if result.GroupBy != "" {
+		groups = append(groups, result.Total)
+	}
+	for _, group := range groups {
+		row := []string{group.Key}
+		for _, name := range result.Metrics {
+			row = append(row, group.Formatted[name])
+		}
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,253 @@
+// Package report runs the reports defined by the athlete, as YAML files
+// under ~/.config/sutro/reports, over the archive: which activities they
+// include, how they group them, the metrics they compute for each group
+// and the template they are rendered with.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+	"gopkg.in/yaml.v2"
+)
+
+// Definition is a report, as defined in YAML:
+//
+//	title: Rides by month
+//	filters:
+//	  type: Ride
+//	  since: 1y
+//	  commute: false
+//	group_by: month
+//	metrics: [count, distance, moving_time, elevation]
+type Definition struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Filters     Filter `yaml:"filters"`
+	// GroupBy is one of day, week, month, year, weekday, type and gear, or
+	// empty to total every activity together.
+	GroupBy string   `yaml:"group_by"`
+	Metrics []string `yaml:"metrics"`
+	// Template is a text/template rendering the Result of the report,
+	// which is rendered as a table when empty.
+	Template string `yaml:"template"`
+}
+
+// Filter selects the activities of a report. Every condition that is set
+// must be met.
+type Filter struct {
+	Type string `yaml:"type"`
+	// Since and Until are dates such as 2020-03-01 or 4w.
+	Since string `yaml:"since"`
+	Until string `yaml:"until"`
+	// MinDistance and MaxDistance are distances such as 5km.
+	MinDistance string `yaml:"min_distance"`
+	MaxDistance string `yaml:"max_distance"`
+	// Name matches the activities whose name contains it, regardless of
+	// case.
+	Name    string `yaml:"name"`
+	Gear    string `yaml:"gear"`
+	Commute *bool  `yaml:"commute"`
+	Trainer *bool  `yaml:"trainer"`
+}
+
+// Directory returns the directory of the report definitions, in
+// $XDG_CONFIG_HOME/sutro/reports or else ~/.config/sutro/reports.
+func Directory() (string, error) {
+	if configDirectory := os.Getenv("XDG_CONFIG_HOME"); configDirectory != "" {
+		return path.Join(configDirectory, "sutro", "reports"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(home, ".config", "sutro", "reports"), nil
+}
+
+// Names returns the names of the reports defined in directory, sorted.
+func Names(directory string) ([]string, error) {
+	entries, err := ioutil.ReadDir(directory)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		extension := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (extension == ".yaml" || extension == ".yml") {
+			names = append(names, strings.TrimSuffix(entry.Name(), extension))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load reads the report named name in directory, or at the path name when
+// it names a YAML file.
+func Load(directory, name string) (*Definition, error) {
+	filename := name
+	if extension := filepath.Ext(name); extension != ".yaml" && extension != ".yml" {
+		filename = path.Join(directory, name+".yaml")
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			filename = path.Join(directory, name+".yml")
+		}
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("No report named %s, define it in %s", name, path.Join(directory, name+".yaml"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var definition Definition
+	if err := yaml.UnmarshalStrict(content, &definition); err != nil {
+		return nil, fmt.Errorf("Invalid report %s: %v", filename, err)
+	}
+	if err := definition.validate(); err != nil {
+		return nil, fmt.Errorf("Invalid report %s: %v", filename, err)
+	}
+	if definition.Title == "" {
+		definition.Title = strings.TrimSuffix(path.Base(filename), filepath.Ext(filename))
+	}
+	return &definition, nil
+}
+
+func (d *Definition) validate() error {
+	if _, ok := groupings[d.GroupBy]; !ok {
+		return fmt.Errorf("unknown group_by %q, expected one of %s", d.GroupBy, strings.Join(groupingNames(), ", "))
+	}
+	if len(d.Metrics) == 0 {
+		return errors.New("no metrics, list some such as [count, distance]")
+	}
+	for _, name := range d.Metrics {
+		if _, ok := metrics[name]; !ok {
+			return fmt.Errorf("unknown metric %q, expected one of %s", name, strings.Join(metricNames(), ", "))
+		}
+	}
+	if d.Filters.Type != "" {
+		if _, err := strava.ParseActivityType(d.Filters.Type); err != nil {
+			return err
+		}
+	}
+	_, err := parseTemplate(d.Template)
+	return err
+}
+
+// Group is the activities of a report sharing a key, such as the month
+// they took place in, with the values of the metrics of the report.
+type Group struct {
+	Key        string
+	Activities []*models.SummaryActivity
+	// Values are the values of the metrics by name, and Formatted the
+	// values as rendered in tables, such as 12.50 km.
+	Values    map[string]float64
+	Formatted map[string]string
+}
+
+// Result is a report run over the archive, as rendered by its template.
+type Result struct {
+	Title       string
+	Description string
+	GroupBy     string
+	Metrics     []string
+	Groups      []Group
+	// Total are the metrics of every activity of the report.
+	Total Group
+}
+
+// Run runs the report over the activities of archive.
+func (d *Definition) Run(archive *store.Store) (*Result, error) {
+	selected, err := d.Filters.activities(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	grouping := groupings[d.GroupBy]
+	byKey := map[string][]*models.SummaryActivity{}
+	var keys []string
+	for _, activity := range selected {
+		key := grouping.key(activity)
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], activity)
+	}
+	grouping.sort(keys)
+
+	result := &Result{Title: d.Title, Description: d.Description, GroupBy: d.GroupBy, Metrics: d.Metrics}
+	for _, key := range keys {
+		result.Groups = append(result.Groups, d.group(key, byKey[key]))
+	}
+	result.Total = d.group("Total", selected)
+	return result, nil
+}
+
+func (d *Definition) group(key string, activities []*models.SummaryActivity) Group {
+	group := Group{Key: key, Activities: activities, Values: map[string]float64{}, Formatted: map[string]string{}}
+	for _, name := range d.Metrics {
+		m := metrics[name]
+		value := m.compute(activities)
+		group.Values[name] = value
+		group.Formatted[name] = m.format(value)
+	}
+	return group
+}
+
+// activities returns the archived activities matching f, oldest first.
+func (f Filter) activities(archive *store.Store) ([]*models.SummaryActivity, error) {
+	query := store.Query{Type: f.Type}
+	var err error
+	if f.Since != "" {
+		if query.After, err = dates.Parse(f.Since); err != nil {
+			return nil, err
+		}
+	}
+	if f.Until != "" {
+		if query.Before, err = dates.Parse(f.Until); err != nil {
+			return nil, err
+		}
+	}
+	minDistance, maxDistance := 0.0, 0.0
+	if f.MinDistance != "" {
+		if minDistance, err = geo.ParseDistance(f.MinDistance); err != nil {
+			return nil, err
+		}
+	}
+	if f.MaxDistance != "" {
+		if maxDistance, err = geo.ParseDistance(f.MaxDistance); err != nil {
+			return nil, err
+		}
+	}
+
+	var selected []*models.SummaryActivity
+	err = archive.EachActivity(query, func(activity *models.SummaryActivity) error {
+		distance := float64(activity.Distance)
+		if distance < minDistance || (maxDistance > 0 && distance > maxDistance) ||
+			(f.Name != "" && !strings.Contains(strings.ToLower(activity.Name), strings.ToLower(f.Name))) ||
+			(f.Gear != "" && activity.GearID != f.Gear) ||
+			(f.Commute != nil && activity.Commute != *f.Commute) ||
+			(f.Trainer != nil && activity.Trainer != *f.Trainer) {
+			return nil
+		}
+		selected = append(selected, activity)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return selected, nil
+}
@@ -0,0 +1,244 @@
+package rewind
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/jsilland/sutro/format"
+	"github.com/jsilland/sutro/heatmap"
+	"github.com/jsilland/sutro/locale"
+)
+
+// shades are the colors of the levels of the heatmap of the year in HTML,
+// and the characters of its levels in Markdown.
+var (
+	shades     = []string{"#eeeeee", "#fdd0b8", "#fca57a", "#fc7a3d", "#fc4c02"}
+	characters = []string{"·", "░", "▒", "▓", "█"}
+)
+
+var weekdays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+var functions = map[string]interface{}{
+	"kilometers": format.Kilometers,
+	"duration":   format.Duration,
+	"meters":     func(meters float64) string { return locale.Number(meters, 0) + " m" },
+	"day":        func(t time.Time) string { return t.Format("Mon, Jan 2") },
+	"join":       strings.Join,
+	// cell escapes the pipes of a cell of a Markdown table.
+	"cell": func(text string) string { return strings.ReplaceAll(text, "|", `\|`) },
+	"plural": func(count int, noun string) string {
+		if count == 1 {
+			return fmt.Sprintf("%d %s", count, noun)
+		}
+		return fmt.Sprintf("%d %ss", count, noun)
+	},
+}
+
+var htmlRewind = htmltemplate.Must(htmltemplate.New("rewind").Funcs(functions).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Year}} in review</title>
+<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; color: #222; max-width: 860px; margin: 0 auto; padding: 1em; }
+h1 { color: #fc4c02; }
+.totals { display: grid; grid-template-columns: repeat(auto-fit, minmax(150px, 1fr)); gap: 1em; margin: 1em 0 2em; }
+.totals div { background: #f6f6f6; padding: 0.8em; border-radius: 6px; }
+.totals strong { display: block; font-size: 1.6em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: right; padding: 0.3em 0.6em; border-bottom: 1px solid #eee; }
+th:first-child, td:first-child { text-align: left; }
+a { color: #fc4c02; }
+svg.calendar { width: 100%; height: auto; }
+img.heatmap { width: 100%; height: auto; background: #000; }
+footer { color: #888; font-size: 0.85em; margin-top: 2em; }
+</style>
+</head>
+<body>
+<h1>{{.Year}} in review</h1>
+<section class="totals">
+<div><strong>{{.Totals.Count}}</strong> activities</div>
+<div><strong>{{kilometers .Totals.Distance}}</strong> covered</div>
+<div><strong>{{duration .Totals.MovingTime}}</strong> moving</div>
+<div><strong>{{meters .Totals.Ascent}}</strong> climbed</div>
+<div><strong>{{.ActiveDays}}</strong> active days</div>
+<div><strong>{{plural .LongestStreak.Length "day"}}</strong> longest streak{{if .LongestStreak.Length}}, {{day .LongestStreak.Start}} to {{day .LongestStreak.End}}{{end}}</div>
+</section>
+{{.Calendar}}
+<h2>By sport</h2>
+<table>
+<tr><th>Sport</th><th>Activities</th><th>Distance</th><th>Moving time</th><th>Ascent</th></tr>
+{{range .Types}}<tr><td>{{.Type}}</td><td>{{.Count}}</td><td>{{kilometers .Distance}}</td><td>{{duration .MovingTime}}</td><td>{{meters .Ascent}}</td></tr>
+{{end}}</table>
+<h2>Biggest days</h2>
+<table>
+<tr><th>Day</th><th>Activities</th><th>Distance</th><th>Moving time</th><th>Ascent</th></tr>
+{{range .BiggestDays}}<tr><td>{{day .Date}}</td><td>{{join .Activities ", "}}</td><td>{{kilometers .Distance}}</td><td>{{duration .MovingTime}}</td><td>{{meters .Ascent}}</td></tr>
+{{end}}</table>
+{{if .Detailed}}<h2>New personal records</h2>
+{{if .Records}}<table>
+<tr><th>Record</th><th>Activity</th><th>Day</th><th>Distance</th><th>Time</th></tr>
+{{range .Records}}<tr><td>{{if .SegmentID}}<a href="https://www.strava.com/segments/{{.SegmentID}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td><td><a href="https://www.strava.com/activities/{{.ActivityID}}">{{.ActivityName}}</a></td><td>{{day .Date}}</td><td>{{kilometers .Distance}}</td><td>{{duration .ElapsedTime}}</td></tr>
+{{end}}</table>
+{{else}}<p>No personal record this year.</p>
+{{end}}<h2>Top segments</h2>
+{{if .Segments}}<table>
+<tr><th>Segment</th><th>Efforts</th><th>Distance</th><th>Best time</th></tr>
+{{range .Segments}}<tr><td><a href="https://www.strava.com/segments/{{.ID}}">{{.Name}}</a></td><td>{{.Efforts}}</td><td>{{kilometers .Distance}}</td><td>{{duration .Best}}</td></tr>
+{{end}}</table>
+{{else}}<p>No segment this year.</p>
+{{end}}{{end}}{{with .Heatmap}}<h2>Heatmap</h2>
+<img class="heatmap" src="{{.}}" alt="Heatmap of the activities of the year">
+{{end}}<footer>Generated on {{.Generated.Format "Jan 2 2006"}} by sutro</footer>
+</body>
+</html>
+`))
+
+var markdownRewind = texttemplate.Must(texttemplate.New("rewind").Funcs(functions).Parse(`# {{.Year}} in review
+
+- **{{.Totals.Count}}** activities
+- **{{kilometers .Totals.Distance}}** covered
+- **{{duration .Totals.MovingTime}}** moving
+- **{{meters .Totals.Ascent}}** climbed
+- **{{.ActiveDays}}** active days
+- **{{plural .LongestStreak.Length "day"}}** longest streak{{if .LongestStreak.Length}}, {{day .LongestStreak.Start}} to {{day .LongestStreak.End}}{{end}}
+
+` + "```" + `
+{{.Calendar}}` + "```" + `
+
+## By sport
+
+| Sport | Activities | Distance | Moving time | Ascent |
+| --- | ---: | ---: | ---: | ---: |
+{{range .Types}}| {{.Type}} | {{.Count}} | {{kilometers .Distance}} | {{duration .MovingTime}} | {{meters .Ascent}} |
+{{end}}
+## Biggest days
+
+| Day | Activities | Distance | Moving time | Ascent |
+| --- | --- | ---: | ---: | ---: |
+{{range .BiggestDays}}| {{day .Date}} | {{cell (join .Activities ", ")}} | {{kilometers .Distance}} | {{duration .MovingTime}} | {{meters .Ascent}} |
+{{end}}{{if .Detailed}}
+## New personal records
+{{if .Records}}
+| Record | Activity | Day | Distance | Time |
+| --- | --- | --- | ---: | ---: |
+{{range .Records}}| {{if .SegmentID}}[{{cell .Name}}](https://www.strava.com/segments/{{.SegmentID}}){{else}}{{cell .Name}}{{end}} | [{{cell .ActivityName}}](https://www.strava.com/activities/{{.ActivityID}}) | {{day .Date}} | {{kilometers .Distance}} | {{duration .ElapsedTime}} |
+{{end}}{{else}}
+No personal record this year.
+{{end}}
+## Top segments
+{{if .Segments}}
+| Segment | Efforts | Distance | Best time |
+| --- | ---: | ---: | ---: |
+{{range .Segments}}| [{{cell .Name}}](https://www.strava.com/segments/{{.ID}}) | {{.Efforts}} | {{kilometers .Distance}} | {{duration .Best}} |
+{{end}}{{else}}
+No segment this year.
+{{end}}{{end}}
+Generated on {{.Generated.Format "Jan 2 2006"}} by sutro
+`))
+
+// WriteHTML renders the rewind as a single HTML page, with its heatmaps
+// inlined so that the page can be shared on its own.
+func (r *Rewind) WriteHTML(writer io.Writer) error {
+	page := struct {
+		*Rewind
+		Calendar htmltemplate.HTML
+		Heatmap  htmltemplate.URL
+	}{Rewind: r, Calendar: r.calendarSVG()}
+
+	if len(r.tracks) > 0 {
+		var image bytes.Buffer
+		if err := heatmap.RenderPNG(&image, r.tracks, 1600); err != nil {
+			return err
+		}
+		page.Heatmap = htmltemplate.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(image.Bytes()))
+	}
+	return htmlRewind.Execute(writer, page)
+}
+
+// WriteMarkdown renders the rewind as Markdown, with the heatmap of the
+// year drawn with characters. Markdown has no room for the heatmap of the
+// tracks.
+func (r *Rewind) WriteMarkdown(writer io.Writer) error {
+	page := struct {
+		*Rewind
+		Calendar string
+	}{Rewind: r, Calendar: r.calendarText()}
+	return markdownRewind.Execute(writer, page)
+}
+
+// calendarSVG draws the heatmap of the year as a grid of a column per week
+// and a row per day of the week, with the months labeled on top.
+func (r *Rewind) calendarSVG() htmltemplate.HTML {
+	const cell, gap, left, top = 12, 2, 30, 16
+	width, height := left+len(r.Weeks)*(cell+gap), top+7*(cell+gap)
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, `<svg class="calendar" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" font-size="9" fill="#888">`, width, height)
+	for i, name := range weekdays {
+		if i%2 == 0 {
+			fmt.Fprintf(&builder, `<text x="0" y="%d">%s</text>`, top+i*(cell+gap)+cell-2, name)
+		}
+	}
+	month := time.Month(0)
+	for column, week := range r.Weeks {
+		x := left + column*(cell+gap)
+		for row, c := range week {
+			if c.Outside {
+				continue
+			}
+			if c.Date.Day() == 1 && c.Date.Month() != month {
+				month = c.Date.Month()
+				fmt.Fprintf(&builder, `<text x="%d" y="10">%s</text>`, x, month.String()[:3])
+			}
+			fmt.Fprintf(&builder, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s: %s</title></rect>`,
+				x, top+row*(cell+gap), cell, cell, shades[c.Level], c.Date.Format("Mon, Jan 2"), format.Duration(c.MovingTime))
+		}
+	}
+	builder.WriteString("</svg>")
+	return htmltemplate.HTML(builder.String())
+}
+
+// calendarText draws the heatmap of the year with a character per day, a
+// line per day of the week.
+func (r *Rewind) calendarText() string {
+	var builder strings.Builder
+	builder.WriteString("    ")
+	for column := 0; column < len(r.Weeks); {
+		// Each month is labeled above the week of its first day.
+		label := ""
+		for _, c := range r.Weeks[column] {
+			if !c.Outside && c.Date.Day() == 1 {
+				label = c.Date.Month().String()[:3]
+			}
+		}
+		if label == "" || column+len(label) > len(r.Weeks) {
+			builder.WriteString(" ")
+			column++
+			continue
+		}
+		builder.WriteString(label)
+		column += len(label)
+	}
+	builder.WriteString("\n")
+
+	for row, name := range weekdays {
+		builder.WriteString(name + " ")
+		for _, week := range r.Weeks {
+			if week[row].Outside {
+				builder.WriteString(" ")
+				continue
+			}
+			builder.WriteString(characters[week[row].Level])
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
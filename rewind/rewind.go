@@ -0,0 +1,288 @@
+// Package rewind compiles the year of an athlete into a report to share:
+// its totals, biggest days, longest streak, personal records, most ridden
+// or run segments, and heatmaps of its days and of its tracks.
+package rewind
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/streak"
+)
+
+// biggestDays and topSegments are how many days and segments a rewind
+// lists.
+const (
+	biggestDays = 5
+	topSegments = 10
+)
+
+// Totals sums up a set of activities.
+type Totals struct {
+	Type       string
+	Count      int
+	Distance   float64
+	MovingTime float64
+	Ascent     float64
+}
+
+func (t *Totals) add(activity *models.SummaryActivity) {
+	t.Count++
+	t.Distance += float64(activity.Distance)
+	t.MovingTime += float64(activity.MovingTime)
+	t.Ascent += float64(activity.TotalElevationGain)
+}
+
+// Day sums up the activities of a day.
+type Day struct {
+	Date time.Time
+	Totals
+	// Activities are the names of the activities of the day.
+	Activities []string
+}
+
+// Record is a personal record set during the year, on a segment or over a
+// distance such as 5k.
+type Record struct {
+	// Name is the name of the segment or of the distance.
+	Name string
+	// SegmentID is 0 for the records over a distance.
+	SegmentID    int64
+	ActivityID   int64
+	ActivityName string
+	Date         time.Time
+	Distance     float64
+	ElapsedTime  float64
+}
+
+// Segment is a segment gone through during the year.
+type Segment struct {
+	ID       int64
+	Name     string
+	Distance float64
+	Efforts  int
+	// Best is the elapsed time of the best effort of the year.
+	Best float64
+}
+
+// Cell is a day of the heatmap of the year, shaded from 0, for a day
+// without activity, to 4 by the moving time of the day.
+type Cell struct {
+	Date       time.Time
+	MovingTime float64
+	Level      int
+	// Outside is set for the days of the first and last weeks that belong
+	// to the previous or the next year.
+	Outside bool
+}
+
+// Rewind is the report of a year.
+type Rewind struct {
+	Year      int
+	Generated time.Time
+	Totals    Totals
+	// Types are the totals of each activity type, by decreasing moving
+	// time.
+	Types         []Totals
+	ActiveDays    int
+	BiggestDays   []Day
+	LongestStreak streak.Streak
+	// Detailed is set when the details of the activities were read, which
+	// the records and the segments come from.
+	Detailed bool
+	Records  []Record
+	Segments []Segment
+	// Weeks are the columns of the heatmap of the year, from Monday to
+	// Sunday.
+	Weeks  [][7]Cell
+	tracks [][]geo.Point
+}
+
+// New compiles the rewind of year from its activities, and from their
+// details, which may be nil, for the records and the segments.
+func New(year int, activities []*models.SummaryActivity, details []*models.DetailedActivity) (*Rewind, error) {
+	r := &Rewind{Year: year, Generated: time.Now(), Detailed: details != nil}
+
+	byType := map[string]*Totals{}
+	byDay := map[time.Time]*Day{}
+	for _, activity := range activities {
+		r.Totals.add(activity)
+		name := string(activity.Type)
+		if _, ok := byType[name]; !ok {
+			byType[name] = &Totals{Type: name}
+		}
+		byType[name].add(activity)
+
+		date := day(activity)
+		if _, ok := byDay[date]; !ok {
+			byDay[date] = &Day{Date: date}
+		}
+		byDay[date].add(activity)
+		byDay[date].Activities = append(byDay[date].Activities, activity.Name)
+
+		if activity.Map != nil && activity.Map.SummaryPolyline != "" {
+			track, err := geo.DecodePolyline(activity.Map.SummaryPolyline)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to decode the map of activity %d: %v", activity.ID, err)
+			}
+			if len(track) > 1 {
+				r.tracks = append(r.tracks, track)
+			}
+		}
+	}
+
+	for _, totals := range byType {
+		r.Types = append(r.Types, *totals)
+	}
+	sort.Slice(r.Types, func(i, j int) bool {
+		if r.Types[i].MovingTime != r.Types[j].MovingTime {
+			return r.Types[i].MovingTime > r.Types[j].MovingTime
+		}
+		return r.Types[i].Type < r.Types[j].Type
+	})
+
+	days := make([]time.Time, 0, len(byDay))
+	for date, d := range byDay {
+		days = append(days, date)
+		r.BiggestDays = append(r.BiggestDays, *d)
+	}
+	r.ActiveDays = len(days)
+	r.LongestStreak = streak.Longest(streak.Find(days, streak.Daily))
+	sort.Slice(r.BiggestDays, func(i, j int) bool {
+		if r.BiggestDays[i].MovingTime != r.BiggestDays[j].MovingTime {
+			return r.BiggestDays[i].MovingTime > r.BiggestDays[j].MovingTime
+		}
+		return r.BiggestDays[i].Date.Before(r.BiggestDays[j].Date)
+	})
+	if len(r.BiggestDays) > biggestDays {
+		r.BiggestDays = r.BiggestDays[:biggestDays]
+	}
+
+	r.addDetails(details)
+	r.Weeks = calendar(year, byDay)
+	return r, nil
+}
+
+func day(activity *models.SummaryActivity) time.Time {
+	return streak.Day(dates.Start(time.Time(activity.StartDate), time.Time(activity.StartDateLocal)))
+}
+
+// addDetails adds the records set and the segments gone through by the
+// detailed activities.
+func (r *Rewind) addDetails(details []*models.DetailedActivity) {
+	records := map[string]Record{}
+	segments := map[int64]*Segment{}
+	for _, activity := range details {
+		date := day(&activity.SummaryActivity)
+		record := func(effort *models.DetailedSegmentEffort, key, name string, segmentID int64) {
+			// Only the last record on a segment or over a distance still
+			// stands at the end of the year.
+			if previous, ok := records[key]; ok && previous.Date.After(date) {
+				return
+			}
+			records[key] = Record{
+				Name:         name,
+				SegmentID:    segmentID,
+				ActivityID:   activity.ID,
+				ActivityName: activity.Name,
+				Date:         date,
+				Distance:     float64(effort.Distance),
+				ElapsedTime:  float64(effort.ElapsedTime),
+			}
+		}
+
+		for _, effort := range activity.BestEfforts {
+			if effort.PrRank == 1 {
+				record(effort, "distance:"+effort.Name, effort.Name, 0)
+			}
+		}
+		for _, effort := range activity.SegmentEfforts {
+			if effort.Segment == nil {
+				continue
+			}
+			id, name, distance := effort.Segment.ID, effort.Segment.Name, float64(effort.Segment.Distance)
+			if name == "" {
+				name = effort.Name
+			}
+			if distance == 0 {
+				distance = float64(effort.Distance)
+			}
+			if effort.PrRank == 1 {
+				record(effort, fmt.Sprintf("segment:%d", id), name, id)
+			}
+			segment, ok := segments[id]
+			if !ok {
+				segment = &Segment{ID: id, Name: name, Distance: distance}
+				segments[id] = segment
+			}
+			segment.Efforts++
+			if elapsed := float64(effort.ElapsedTime); elapsed > 0 && (segment.Best == 0 || elapsed < segment.Best) {
+				segment.Best = elapsed
+			}
+		}
+	}
+
+	for _, record := range records {
+		r.Records = append(r.Records, record)
+	}
+	sort.Slice(r.Records, func(i, j int) bool {
+		if !r.Records[i].Date.Equal(r.Records[j].Date) {
+			return r.Records[i].Date.Before(r.Records[j].Date)
+		}
+		return r.Records[i].Name < r.Records[j].Name
+	})
+
+	for _, segment := range segments {
+		r.Segments = append(r.Segments, *segment)
+	}
+	sort.Slice(r.Segments, func(i, j int) bool {
+		if r.Segments[i].Efforts != r.Segments[j].Efforts {
+			return r.Segments[i].Efforts > r.Segments[j].Efforts
+		}
+		return r.Segments[i].Name < r.Segments[j].Name
+	})
+	if len(r.Segments) > topSegments {
+		r.Segments = r.Segments[:topSegments]
+	}
+}
+
+// calendar lays the days of year out in weeks starting on Monday, shaded by
+// their moving time relative to the biggest day.
+func calendar(year int, byDay map[time.Time]*Day) [][7]Cell {
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	next := first.AddDate(1, 0, 0)
+	start := first.AddDate(0, 0, -((int(first.Weekday()) + 6) % 7))
+
+	most := 0.0
+	for _, d := range byDay {
+		if d.MovingTime > most {
+			most = d.MovingTime
+		}
+	}
+
+	var weeks [][7]Cell
+	for monday := start; monday.Before(next); monday = monday.AddDate(0, 0, 7) {
+		var week [7]Cell
+		for i := range week {
+			date := monday.AddDate(0, 0, i)
+			cell := Cell{Date: date, Outside: date.Before(first) || !date.Before(next)}
+			if d, ok := byDay[date]; ok && !cell.Outside {
+				cell.MovingTime = d.MovingTime
+				cell.Level = 1
+				if most > 0 {
+					cell.Level = 1 + int(3*d.MovingTime/most+0.5)
+					if cell.Level > 4 {
+						cell.Level = 4
+					}
+				}
+			}
+			week[i] = cell
+		}
+		weeks = append(weeks, week)
+	}
+	return weeks
+}
@@ -0,0 +1,422 @@
+// Package rules names, describes and equips activities from the rules of
+// the configuration, such as one renaming the rides following the commute
+// route on weekday mornings, which Strava would name Morning Ride.
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/config"
+	"github.com/jsilland/sutro/description"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+)
+
+// defaultTolerance is how far from their route activities may stray when
+// rules do not say.
+const defaultTolerance = "100m"
+
+// overlap is the fraction of each track that must lie within tolerance of
+// the other for an activity to follow the route of a rule.
+const overlap = 0.9
+
+// defaultName matches the names Strava gives activities, after the time
+// of day they started at.
+var defaultName = regexp.MustCompile(`^(Morning|Lunch|Afternoon|Evening|Night) [A-Za-z ]+$`)
+
+// gearID matches the ids of gear, which are those of bikes or shoes.
+var gearID = regexp.MustCompile(`^[bg][0-9]+$`)
+
+// weekdays are the days that days of rules are named after.
+var weekdays = map[string][]time.Weekday{
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends": {time.Saturday, time.Sunday},
+}
+
+func init() {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		weekdays[strings.ToLower(day.String())] = []time.Weekday{day}
+	}
+}
+
+// rule is a rule of the configuration, parsed.
+type rule struct {
+	name string
+	// activityType is empty for rules matching every type.
+	activityType models.ActivityType
+	// after and before are minutes since midnight, or -1 when unset.
+	after, before            int
+	minDistance, maxDistance float64
+	route                    []geo.Point
+	tolerance                float64
+	defaultName              bool
+	// days is nil for rules matching every day of the week.
+	days             map[time.Weekday]bool
+	commute, trainer *bool
+
+	title, description *description.Template
+	gear               string
+}
+
+// Engine applies rules to activities.
+type Engine struct {
+	rules     []*rule
+	apiClient *strava.Client
+	archive   *store.Store
+	// gear is the gear of the athlete, by lowercase name, once read.
+	gear map[string]*models.SummaryGear
+}
+
+// Change is what the rules change of an activity.
+type Change struct {
+	Activity *models.SummaryActivity
+	// Rules are the names of the rules the activity matched.
+	Rules  []string
+	Update models.UpdatableActivity
+}
+
+// New parses the rules of the configuration. The activities the rules
+// refer to for their route are read from the archive, or else from the
+// API.
+func New(ctx context.Context, apiClient *strava.Client, archive *store.Store, configured []config.Rule) (*Engine, error) {
+	engine := &Engine{apiClient: apiClient, archive: archive}
+	for i, c := range configured {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		parsed, err := engine.parse(ctx, name, c)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid rule %s: %v", name, err)
+		}
+		engine.rules = append(engine.rules, parsed)
+	}
+	return engine, nil
+}
+
+// Empty reports whether there are no rules to apply.
+func (e *Engine) Empty() bool {
+	return len(e.rules) == 0
+}
+
+func (e *Engine) parse(ctx context.Context, name string, c config.Rule) (*rule, error) {
+	r := &rule{name: name, defaultName: c.DefaultName, commute: c.Commute, trainer: c.Trainer}
+	var err error
+
+	if c.Type != "" {
+		if r.activityType, err = strava.ParseActivityType(c.Type); err != nil {
+			return nil, err
+		}
+	}
+	if r.after, err = timeOfDay(c.After); err != nil {
+		return nil, err
+	}
+	if r.before, err = timeOfDay(c.Before); err != nil {
+		return nil, err
+	}
+	if r.minDistance, err = distance(c.MinDistance, 0); err != nil {
+		return nil, err
+	}
+	if r.maxDistance, err = distance(c.MaxDistance, math.Inf(1)); err != nil {
+		return nil, err
+	}
+
+	for _, day := range c.Days {
+		named, ok := weekdays[strings.ToLower(strings.TrimSpace(day))]
+		if !ok {
+			return nil, fmt.Errorf("Invalid day %q, expected a day of the week such as Monday, weekdays or weekends", day)
+		}
+		if r.days == nil {
+			r.days = map[time.Weekday]bool{}
+		}
+		for _, weekday := range named {
+			r.days[weekday] = true
+		}
+	}
+
+	if c.Route != 0 {
+		tolerance := c.Tolerance
+		if tolerance == "" {
+			tolerance = defaultTolerance
+		}
+		if r.tolerance, err = geo.ParseDistance(tolerance); err != nil {
+			return nil, err
+		}
+		if r.route, err = e.routeOf(ctx, c.Route, r.tolerance); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.title, err = parseTemplate(c.Title); err != nil {
+		return nil, err
+	}
+	if r.description, err = parseTemplate(c.Description); err != nil {
+		return nil, err
+	}
+	if r.gear, err = e.gearOf(ctx, c.Gear); err != nil {
+		return nil, err
+	}
+	if r.title == nil && r.description == nil && r.gear == "" {
+		return nil, errors.New("Sets neither a title, a description nor a gear")
+	}
+	return r, nil
+}
+
+func (e *Engine) routeOf(ctx context.Context, id int64, tolerance float64) ([]geo.Point, error) {
+	activity, err := e.archive.Activity(id)
+	if err != nil {
+		return nil, err
+	}
+	if activity == nil {
+		fetched, err := e.apiClient.Activities.Get(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read activity %d: %v", id, err)
+		}
+		activity = &fetched.SummaryActivity
+	}
+
+	route, err := track(activity, tolerance)
+	if err != nil {
+		return nil, err
+	}
+	if len(route) == 0 {
+		return nil, fmt.Errorf("Activity %d has no map to match other activities against", id)
+	}
+	return route, nil
+}
+
+// gearOf returns the id of the gear named value, which is either an id or
+// the name of one of the bikes or shoes of the athlete.
+func (e *Engine) gearOf(ctx context.Context, value string) (string, error) {
+	if value == "" || gearID.MatchString(value) {
+		return value, nil
+	}
+
+	if e.gear == nil {
+		athlete, err := e.apiClient.Athletes.Current(ctx)
+		if err != nil {
+			return "", fmt.Errorf("Unable to read the gear of the athlete: %v", err)
+		}
+		e.gear = map[string]*models.SummaryGear{}
+		for _, gear := range append(athlete.Bikes, athlete.Shoes...) {
+			e.gear[strings.ToLower(gear.Name)] = gear
+		}
+	}
+
+	gear, ok := e.gear[strings.ToLower(strings.TrimSpace(value))]
+	if !ok {
+		names := make([]string, 0, len(e.gear))
+		for _, gear := range e.gear {
+			names = append(names, gear.Name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return "", fmt.Errorf("No gear named %q, the athlete has no bikes or shoes", value)
+		}
+		return "", fmt.Errorf("No gear named %q, expected one of %s", value, strings.Join(names, ", "))
+	}
+	return gear.ID, nil
+}
+
+// Plan returns what the rules change of activity, or nil when the rules
+// it matches leave it as it is. Each field takes the value of the first
+// matching rule that sets it.
+func (e *Engine) Plan(ctx context.Context, activity *models.SummaryActivity) (*Change, error) {
+	var matched []*rule
+	for _, r := range e.rules {
+		ok, err := r.matches(activity)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, r)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	// Templates render the detailed activity, with its gear and
+	// description, which the archive does not hold.
+	var detailed *models.DetailedActivity
+	render := func(template *description.Template) (string, error) {
+		if detailed == nil {
+			fetched, err := e.apiClient.Activities.Get(ctx, activity.ID)
+			if err != nil {
+				return "", fmt.Errorf("Unable to read activity %d: %v", activity.ID, err)
+			}
+			detailed = fetched
+		}
+		return template.Render(ctx, detailed)
+	}
+
+	change := &Change{Activity: activity}
+	var name, text, gear string
+	var named, described, equipped bool
+	for _, r := range matched {
+		change.Rules = append(change.Rules, r.name)
+		var err error
+		if r.title != nil && !named {
+			if name, err = render(r.title); err != nil {
+				return nil, err
+			}
+			named = true
+		}
+		if r.description != nil && !described {
+			if text, err = render(r.description); err != nil {
+				return nil, err
+			}
+			described = true
+		}
+		if r.gear != "" && !equipped {
+			gear, equipped = r.gear, true
+		}
+	}
+
+	// Only the fields that differ are updated, so that applying the rules
+	// again leaves activities alone.
+	changed := false
+	if named && name != "" && name != activity.Name {
+		change.Update.Name, changed = name, true
+	}
+	if described && text != "" && text != detailed.Description {
+		change.Update.Description, changed = text, true
+	}
+	if equipped && gear != activity.GearID {
+		change.Update.GearID, changed = gear, true
+	}
+	if !changed {
+		return nil, nil
+	}
+	return change, nil
+}
+
+// String summarizes the fields the change sets.
+func (c *Change) String() string {
+	var described []string
+	if c.Update.Name != "" {
+		described = append(described, "title "+strconv.Quote(c.Update.Name))
+	}
+	if c.Update.Description != "" {
+		described = append(described, "description")
+	}
+	if c.Update.GearID != "" {
+		described = append(described, "gear "+c.Update.GearID)
+	}
+	return strings.Join(described, ", ")
+}
+
+// Apply updates the activity of change on Strava and in the archive, and
+// returns the updated activity.
+func (e *Engine) Apply(ctx context.Context, change *Change) (*models.SummaryActivity, error) {
+	updated, err := e.apiClient.Activities.Update(ctx, change.Activity.ID, &change.Update)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to update activity %d: %v", change.Activity.ID, err)
+	}
+	if err := e.archive.PutActivities([]*models.SummaryActivity{&updated.SummaryActivity}); err != nil {
+		return nil, err
+	}
+	return &updated.SummaryActivity, nil
+}
+
+func (r *rule) matches(activity *models.SummaryActivity) (bool, error) {
+	if r.activityType != "" && activity.Type != r.activityType {
+		return false, nil
+	}
+	if r.defaultName && !defaultName.MatchString(activity.Name) {
+		return false, nil
+	}
+	if r.commute != nil && activity.Commute != *r.commute {
+		return false, nil
+	}
+	if r.trainer != nil && activity.Trainer != *r.trainer {
+		return false, nil
+	}
+	if meters := float64(activity.Distance); meters < r.minDistance || meters > r.maxDistance {
+		return false, nil
+	}
+
+	// The local start date is the local time of the activity, in UTC.
+	start := time.Time(activity.StartDateLocal).UTC()
+	if r.days != nil && !r.days[start.Weekday()] {
+		return false, nil
+	}
+	if !withinTimeOfDay(start.Hour()*60+start.Minute(), r.after, r.before) {
+		return false, nil
+	}
+
+	if r.route != nil {
+		points, err := track(activity, r.tolerance)
+		if err != nil {
+			return false, err
+		}
+		if len(points) == 0 || !geo.SameCourse(r.route, points, r.tolerance, overlap) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// withinTimeOfDay reports whether minute is between after and before,
+// either of which is -1 when unset. Bounds such as after 22:00 and before
+// 04:00 span midnight.
+func withinTimeOfDay(minute, after, before int) bool {
+	switch {
+	case after >= 0 && before >= 0 && before < after:
+		return minute >= after || minute < before
+	case after >= 0 && minute < after:
+		return false
+	case before >= 0 && minute >= before:
+		return false
+	}
+	return true
+}
+
+func timeOfDay(value string) (int, error) {
+	if value == "" {
+		return -1, nil
+	}
+	parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("Invalid time of day %q, expected a time such as 06:30", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+func distance(value string, unset float64) (float64, error) {
+	if value == "" {
+		return unset, nil
+	}
+	return geo.ParseDistance(value)
+}
+
+func parseTemplate(text string) (*description.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return description.Parse(text)
+}
+
+// track returns the map of activity resampled for matching within
+// tolerance.
+func track(activity *models.SummaryActivity, tolerance float64) ([]geo.Point, error) {
+	if activity.Map == nil || activity.Map.SummaryPolyline == "" {
+		return nil, nil
+	}
+
+	points, err := geo.DecodePolyline(activity.Map.SummaryPolyline)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode the map of activity %d: %v", activity.ID, err)
+	}
+	return geo.Resample(points, math.Max(10, tolerance/2)), nil
+}
@@ -0,0 +1,140 @@
+// Package scopes annotates commands with the OAuth scopes of Strava their
+// requests need, so that a command is refused before its first request
+// when the athlete did not grant one of them.
+package scopes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// annotation is the annotation of commands listing the scopes they need,
+// separated by commas.
+const annotation = "scopes"
+
+// implied are the scopes granting the access of other scopes, which Strava
+// does not necessarily grant along with them.
+var implied = map[string][]string{
+	"activity:read_all": {"activity:read"},
+	"read_all":          {"read"},
+}
+
+// operations are the scopes of the generated commands, by operation id,
+// as documented by swagger.json. Scopes only needed for private resources,
+// such as read_all for private routes, are left out.
+var operations = map[string][]string{
+	"createActivity":               {"activity:write"},
+	"createUpload":                 {"activity:write"},
+	"getActivityById":              {"activity:read"},
+	"getActivityStreams":           {"activity:read"},
+	"getCommentsByActivityId":      {"activity:read"},
+	"getKudoersByActivityId":       {"activity:read"},
+	"getLapsByActivityId":          {"activity:read"},
+	"getLoggedInAthleteActivities": {"activity:read"},
+	"getLoggedInAthleteZones":      {"profile:read_all"},
+	"getPhotosByActivityId":        {"activity:read"},
+	"getSegmentEffortStreams":      {"read_all"},
+	"getUploadById":                {"activity:write"},
+	"getZonesByActivityId":         {"activity:read"},
+	"starSegment":                  {"profile:write"},
+	"updateActivityById":           {"activity:write"},
+	"updateLoggedInAthlete":        {"profile:write"},
+}
+
+// MissingError is a command refused as the token lacks scopes it needs.
+type MissingError struct {
+	// Command is the path of the command, such as sutro activities update.
+	Command string
+	Missing []string
+	Granted []string
+}
+
+func (e *MissingError) Error() string {
+	missing, pronoun := fmt.Sprintf("the %s scope, which was", e.Missing[0]), "it"
+	if len(e.Missing) > 1 {
+		missing, pronoun = fmt.Sprintf("the %s scopes, which were", strings.Join(e.Missing, " and ")), "them"
+	}
+	// Authorizing again replaces the granted scopes, which are kept.
+	scopes := append(append([]string{}, e.Granted...), e.Missing...)
+	return fmt.Sprintf("%s requires %s not granted: run sutro authenticate --scopes %s to grant %s",
+		e.Command, missing, strings.Join(scopes, ","), pronoun)
+}
+
+// Require annotates command with scopes, which its subcommands need too,
+// and returns it.
+func Require(command *cobra.Command, scopes ...string) *cobra.Command {
+	if command.Annotations == nil {
+		command.Annotations = map[string]string{}
+	}
+	required := append(split(command.Annotations[annotation]), scopes...)
+	command.Annotations[annotation] = strings.Join(required, ",")
+	return command
+}
+
+// Required returns the scopes that command and its parents need, sorted.
+func Required(command *cobra.Command) []string {
+	seen := map[string]bool{}
+	var required []string
+	for c := command; c != nil; c = c.Parent() {
+		for _, scope := range split(c.Annotations[annotation]) {
+			if !seen[scope] {
+				seen[scope] = true
+				required = append(required, scope)
+			}
+		}
+	}
+	sort.Strings(required)
+	return required
+}
+
+// Check returns a *MissingError if granted lacks some of the scopes that
+// command needs. Commands are not checked when the granted scopes are
+// unknown, as when sutro was authenticated before remembering them.
+func Check(command *cobra.Command, granted []string) error {
+	if len(granted) == 0 {
+		return nil
+	}
+
+	has := map[string]bool{}
+	for _, scope := range granted {
+		has[scope] = true
+		for _, other := range implied[scope] {
+			has[other] = true
+		}
+	}
+
+	var missing []string
+	for _, scope := range Required(command) {
+		if !has[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return &MissingError{Command: command.CommandPath(), Missing: missing, Granted: granted}
+}
+
+// Annotate annotates the generated commands under root, which are named or
+// aliased after their operation, with the scopes of their operation.
+func Annotate(root *cobra.Command) {
+	for _, command := range root.Commands() {
+		for _, name := range append([]string{command.Name()}, command.Aliases...) {
+			if scopes, ok := operations[name]; ok {
+				Require(command, scopes...)
+				break
+			}
+		}
+		Annotate(command)
+	}
+}
+
+func split(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,267 @@
+// Package script runs Starlark programs against the Strava API. Programs see
+// a strava module whose functions call the API and return its models as
+// Starlark structs, and the json module to encode them.
+package script
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jsilland/sutro/dates"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/strava"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkjson"
+	"go.starlark.net/starlarkstruct"
+)
+
+// defaultLimit caps the number of items returned by list functions unless
+// scripts ask for more, as listing every activity takes a while.
+const defaultLimit = 30
+
+func init() {
+	// Scripts are one-off programs rather than configuration, so they get
+	// the whole language: loops and conditionals at the top level, floats
+	// for distances, lambdas and while loops.
+	resolve.AllowGlobalReassign = true
+	resolve.AllowRecursion = true
+	resolve.AllowFloat = true
+	resolve.AllowLambda = true
+	resolve.AllowNestedDef = true
+	resolve.AllowSet = true
+}
+
+// Globals returns the predeclared names of programs, which call the API
+// with apiClient.
+func Globals(apiClient *strava.Client) starlark.StringDict {
+	functions := map[string]func(*strava.Client, *starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error){
+		"athlete":         athlete,
+		"activity":        activity,
+		"activities":      listActivities,
+		"photos":          photos,
+		"route":           route,
+		"streams":         streams,
+		"update_activity": updateActivity,
+	}
+
+	members := starlark.StringDict{}
+	for name, function := range functions {
+		function := function
+		members[name] = starlark.NewBuiltin(name, func(thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			return function(apiClient, thread, builtin, args, kwargs)
+		})
+	}
+
+	return starlark.StringDict{
+		"strava": &starlarkstruct.Module{Name: "strava", Members: members},
+		"json":   starlarkjson.Module,
+	}
+}
+
+// NewThread returns a thread printing to stdout whose API calls use ctx,
+// unless a REPL replaces it with a context of its own.
+func NewThread(ctx context.Context, name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, message string) {
+			fmt.Println(message)
+		},
+	}
+	thread.SetLocal("context", ctx)
+	return thread
+}
+
+func threadContext(thread *starlark.Thread) context.Context {
+	if ctx, ok := thread.Local("context").(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
+
+// id unpacks the identifiers of activities and routes, which exceed the
+// 32 bits starlark.UnpackArgs supports for plain ints.
+type id int64
+
+func (i *id) Unpack(value starlark.Value) error {
+	n, ok := value.(starlark.Int)
+	if !ok {
+		return fmt.Errorf("got %s, want int", value.Type())
+	}
+	v, ok := n.Int64()
+	if !ok {
+		return fmt.Errorf("%s is out of range", n)
+	}
+	*i = id(v)
+	return nil
+}
+
+// date unpacks dates such as 2020-06-01 or 4w ago, as accepted on the
+// command line.
+type date time.Time
+
+func (d *date) Unpack(value starlark.Value) error {
+	s, ok := starlark.AsString(value)
+	if !ok {
+		return fmt.Errorf("got %s, want string", value.Type())
+	}
+	t, err := dates.Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = date(t)
+	return nil
+}
+
+func athlete(apiClient *strava.Client, thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(builtin.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+	current, err := apiClient.Athletes.Current(threadContext(thread))
+	if err != nil {
+		return nil, err
+	}
+	return toValue(current), nil
+}
+
+func activity(apiClient *strava.Client, thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var activityID id
+	if err := starlark.UnpackArgs(builtin.Name(), args, kwargs, "id", &activityID); err != nil {
+		return nil, err
+	}
+	detailed, err := apiClient.Activities.Get(threadContext(thread), int64(activityID))
+	if err != nil {
+		return nil, err
+	}
+	return toValue(detailed), nil
+}
+
+func listActivities(apiClient *strava.Client, thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var after, before date
+	limit := defaultLimit
+	if err := starlark.UnpackArgs(builtin.Name(), args, kwargs, "after?", &after, "before?", &before, "limit?", &limit); err != nil {
+		return nil, err
+	}
+	options := strava.ListOptions{After: time.Time(after), Before: time.Time(before)}
+	return collectActivities(apiClient.Activities.List(threadContext(thread), options), limit)
+}
+
+// collectActivities returns at most limit activities of iterator, or all of
+// them when limit is 0.
+func collectActivities(iterator *strava.ActivityIterator, limit int) (starlark.Value, error) {
+	var values []starlark.Value
+	for (limit <= 0 || len(values) < limit) && iterator.Next() {
+		values = append(values, toValue(iterator.Value()))
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+	return starlark.NewList(values), nil
+}
+
+func photos(apiClient *strava.Client, thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var activityID id
+	size := 2048
+	if err := starlark.UnpackArgs(builtin.Name(), args, kwargs, "id", &activityID, "size?", &size); err != nil {
+		return nil, err
+	}
+	attached, err := apiClient.Activities.Photos(threadContext(thread), int64(activityID), size)
+	if err != nil {
+		return nil, err
+	}
+	return toValue(attached), nil
+}
+
+func route(apiClient *strava.Client, thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var routeID id
+	if err := starlark.UnpackArgs(builtin.Name(), args, kwargs, "id", &routeID); err != nil {
+		return nil, err
+	}
+	r, err := apiClient.Routes.Get(threadContext(thread), int64(routeID))
+	if err != nil {
+		return nil, err
+	}
+	return toValue(r), nil
+}
+
+// streams takes the id of an activity followed by the keys of its streams,
+// such as "heartrate" or "watts".
+func streams(apiClient *strava.Client, thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s: missing argument for id", builtin.Name())
+	}
+	var activityID id
+	if err := starlark.UnpackPositionalArgs(builtin.Name(), args[:1], kwargs, 1, &activityID); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		key, ok := starlark.AsString(arg)
+		if !ok {
+			return nil, fmt.Errorf("%s: got %s for a stream key, want string", builtin.Name(), arg.Type())
+		}
+		keys = append(keys, key)
+	}
+
+	set, err := apiClient.Streams.Activity(threadContext(thread), int64(activityID), keys...)
+	if err != nil {
+		return nil, err
+	}
+	return toValue(set), nil
+}
+
+// updateActivity only changes the fields it is given. The trainer flag can
+// only be set, as the generated model leaves false out of the update like
+// any unset field.
+func updateActivity(apiClient *strava.Client, thread *starlark.Thread, builtin *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var activityID id
+	var name, activityType, description, gearID starlark.Value = starlark.None, starlark.None, starlark.None, starlark.None
+	var commute, trainer starlark.Value = starlark.None, starlark.None
+	err := starlark.UnpackArgs(builtin.Name(), args, kwargs, "id", &activityID,
+		"name?", &name, "type?", &activityType, "description?", &description, "gear_id?", &gearID,
+		"commute?", &commute, "trainer?", &trainer)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &models.UpdatableActivity{}
+	for _, field := range []struct {
+		value  starlark.Value
+		target *string
+	}{
+		{name, &update.Name},
+		{description, &update.Description},
+		{gearID, &update.GearID},
+	} {
+		if field.value == starlark.None {
+			continue
+		}
+		s, ok := starlark.AsString(field.value)
+		if !ok {
+			return nil, fmt.Errorf("%s: got %s, want string", builtin.Name(), field.value.Type())
+		}
+		*field.target = s
+	}
+	if activityType != starlark.None {
+		s, ok := starlark.AsString(activityType)
+		if !ok {
+			return nil, fmt.Errorf("%s: got %s for type, want string", builtin.Name(), activityType.Type())
+		}
+		update.Type = models.ActivityType(s)
+	}
+	if commute != starlark.None {
+		c := bool(commute.Truth())
+		update.Commute = &c
+	}
+	if trainer != starlark.None {
+		update.Trainer = bool(trainer.Truth())
+	}
+
+	updated, err := apiClient.Activities.Update(threadContext(thread), int64(activityID), update)
+	if err != nil {
+		return nil, err
+	}
+	return toValue(updated), nil
+}
@@ -0,0 +1,114 @@
+package script
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// toValue converts a model of the API into Starlark values: structs become
+// structs whose fields are named after their JSON keys, so that scripts read
+// activity.moving_time as the API documents it, and slices become lists.
+// Unlike the JSON encoding, zero values are kept so that every field of a
+// model can be read.
+func toValue(value interface{}) starlark.Value {
+	if value == nil {
+		return starlark.None
+	}
+	return convert(reflect.ValueOf(value))
+}
+
+func convert(value reflect.Value) starlark.Value {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return starlark.None
+		}
+		if text, ok := marshalText(value); ok {
+			return text
+		}
+		return convert(value.Elem())
+	}
+
+	if text, ok := marshalText(value); ok {
+		return text
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return starlark.Bool(value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return starlark.MakeInt64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return starlark.MakeUint64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return starlark.Float(value.Float())
+	case reflect.String:
+		return starlark.String(value.String())
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return starlark.NewList(nil)
+		}
+		elements := make([]starlark.Value, value.Len())
+		for i := range elements {
+			elements[i] = convert(value.Index(i))
+		}
+		return starlark.NewList(elements)
+	case reflect.Map:
+		dict := starlark.NewDict(value.Len())
+		for _, key := range value.MapKeys() {
+			dict.SetKey(convert(key), convert(value.MapIndex(key)))
+		}
+		return dict
+	case reflect.Struct:
+		fields := starlark.StringDict{}
+		addFields(fields, value)
+		return starlarkstruct.FromStringDict(starlarkstruct.Default, fields)
+	}
+	return starlark.String(fmt.Sprint(value.Interface()))
+}
+
+// addFields adds the fields of a struct to fields, flattening the embedded
+// structs the generated models use to compose their definitions.
+func addFields(fields starlark.StringDict, value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addFields(fields, value.Field(i))
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = convert(value.Field(i))
+	}
+}
+
+// marshalText converts dates and other values marshalling to text into
+// strings.
+func marshalText(value reflect.Value) (starlark.Value, bool) {
+	if !value.CanInterface() {
+		return nil, false
+	}
+	marshaler, ok := value.Interface().(encoding.TextMarshaler)
+	if !ok || value.Kind() == reflect.String {
+		return nil, false
+	}
+	text, err := marshaler.MarshalText()
+	if err != nil {
+		return nil, false
+	}
+	return starlark.String(text), true
+}
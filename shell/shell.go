@@ -0,0 +1,59 @@
+// Package shell runs the hooks users configure, such as the --exec hooks of
+// watch and webhooks serve, through the shell of the system.
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Command returns the command running script with sh, or with cmd on
+// Windows, after replacing its placeholders, such as {name} for the key
+// name, with the values. Values may come from Strava, where anyone can
+// name an activity, so they are quoted rather than pasted into the script.
+//
+// The environment of the command is the one of sutro, which callers may
+// add to.
+func Command(script string, values map[string]string) *exec.Cmd {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	environment := os.Environ()
+	var replacements []string
+	for _, key := range keys {
+		placeholder := "{" + key + "}"
+		if runtime.GOOS != "windows" {
+			replacements = append(replacements, placeholder, quote(values[key]))
+			continue
+		}
+		// cmd expands %VARIABLES% even within double quotes, and has no
+		// quoting that stops it, so values are passed in the environment
+		// and referenced with the delayed expansion of /V:ON, which
+		// happens after the script is parsed and expands only once.
+		variable := "SUTRO_HOOK_" + strings.ToUpper(key)
+		environment = append(environment, variable+"="+values[key])
+		replacements = append(replacements, placeholder, `"!`+variable+`!"`)
+	}
+	expanded := strings.NewReplacer(replacements...).Replace(script)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/V:ON", "/C", expanded)
+	} else {
+		cmd = exec.Command("sh", "-c", expanded)
+	}
+	cmd.Env = environment
+	return cmd
+}
+
+// quote quotes a value for sh, in which nothing is special between single
+// quotes.
+func quote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,27 @@
+package shell
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestCommandQuotesValues(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on Windows")
+	}
+	names := []string{
+		"Morning Run",
+		"'; echo injected; '",
+		"$(echo injected) `echo injected` %PATH%",
+		`"double" \back\ 'single'`,
+	}
+	for _, name := range names {
+		output, err := Command("printf %s {name}", map[string]string{"name": name}).Output()
+		if err != nil {
+			t.Fatalf("Running the hook for %q failed: %v", name, err)
+		}
+		if string(output) != name {
+			t.Errorf("Expected the hook to print %q, got %q", name, output)
+		}
+	}
+}
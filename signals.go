@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// interruption cancels the context of the commands on SIGINT or SIGTERM, so
+// that they stop cleanly: syncs and exports journal their progress, and
+// servers shut down. A second signal exits right away.
+type interruption struct {
+	mutex  sync.Mutex
+	signal os.Signal
+}
+
+func handleSignals(cancel context.CancelFunc, command *cobra.Command) *interruption {
+	i := &interruption{}
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		s := <-signals
+		i.mutex.Lock()
+		i.signal = s
+		i.mutex.Unlock()
+
+		// Commands report the progress they kept, there is no point in
+		// also printing their usage or the cancellation of their context.
+		command.SilenceErrors, command.SilenceUsage = true, true
+		fmt.Fprintln(os.Stderr, "Interrupted, stopping. Interrupt again to exit right away")
+		cancel()
+
+		s = <-signals
+		os.Exit(exitCode(s))
+	}()
+	return i
+}
+
+// Signal returns the signal that interrupted sutro, or nil.
+func (i *interruption) Signal() os.Signal {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.signal
+}
+
+// exitCode follows the convention of shells, which report a process killed
+// by a signal with 128 plus the number of the signal.
+func exitCode(s os.Signal) int {
+	if s == syscall.SIGTERM {
+		return 128 + 15
+	}
+	return 128 + 2
+}
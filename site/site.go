@@ -0,0 +1,243 @@
+package site
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jsilland/sutro/export"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/heatmap"
+	"github.com/jsilland/sutro/models"
+)
+
+// Activity is the view of an activity the templates render.
+type Activity struct {
+	ID               int64
+	Name             string
+	Type             string
+	Start            time.Time
+	Distance         float64
+	MovingTime       float64
+	ElapsedTime      float64
+	Ascent           float64
+	AverageHeartrate float64
+	// Track is an inline SVG drawing of the route, empty for activities
+	// without a map.
+	Track template.HTML
+}
+
+// Week sums up the activities started during the week beginning on Start.
+type Week struct {
+	Start      time.Time
+	Count      int
+	Distance   float64
+	MovingTime float64
+	Ascent     float64
+}
+
+// Total sums up the activities of a type.
+type Total struct {
+	Type       string
+	Count      int
+	Distance   float64
+	MovingTime float64
+}
+
+type page struct {
+	Title     string
+	Site      string
+	Root      string
+	Generated time.Time
+}
+
+// Build renders the activities into a static site in directory: an index
+// with totals, weekly volume and a heatmap, and a page per activity. The
+// points of the tracks within zones are trimmed before they are drawn.
+func Build(directory, title string, activities []*models.SummaryActivity, zones []geo.Zone, theme *Theme) error {
+	if err := os.MkdirAll(path.Join(directory, "activities"), 0755); err != nil {
+		return err
+	}
+
+	scrubber, err := export.NewScrubber(zones, export.PrivacyTrim, nil)
+	if err != nil {
+		return err
+	}
+
+	generated := time.Now()
+	views := make([]Activity, 0, len(activities))
+	var tracks [][]geo.Point
+	for _, activity := range activities {
+		view, track, err := newActivity(activity, scrubber)
+		if err != nil {
+			return err
+		}
+		views = append(views, view)
+		if len(track) > 1 {
+			tracks = append(tracks, track)
+		}
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Start.After(views[j].Start) })
+
+	for _, view := range views {
+		data := struct {
+			page
+			Activity Activity
+		}{page{view.Name + " · " + title, title, "../", generated}, view}
+
+		filename := path.Join(directory, "activities", fmt.Sprintf("%d.html", view.ID))
+		if err := theme.render(filename, "activity.html", data); err != nil {
+			return err
+		}
+	}
+
+	hasHeatmap := len(tracks) > 0
+	if hasHeatmap {
+		file, err := os.Create(path.Join(directory, "heatmap.png"))
+		if err != nil {
+			return err
+		}
+		if err := heatmap.RenderPNG(file, tracks, 1600); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+
+	data := struct {
+		page
+		Totals     []Total
+		Weeks      []Week
+		Activities []Activity
+		Heatmap    bool
+	}{page{title, title, "", generated}, totals(views), weeks(views), views, hasHeatmap}
+	if err := theme.render(path.Join(directory, "index.html"), "index.html", data); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path.Join(directory, "style.css"), []byte(theme.stylesheet), 0644)
+}
+
+func newActivity(activity *models.SummaryActivity, scrubber *export.Scrubber) (Activity, []geo.Point, error) {
+	view := Activity{
+		ID:               activity.ID,
+		Name:             activity.Name,
+		Type:             string(activity.Type),
+		Start:            time.Time(activity.StartDateLocal),
+		Distance:         float64(activity.Distance),
+		MovingTime:       float64(activity.MovingTime),
+		ElapsedTime:      float64(activity.ElapsedTime),
+		Ascent:           float64(activity.TotalElevationGain),
+		AverageHeartrate: float64(activity.AverageHeartrate),
+	}
+
+	if activity.Map == nil || activity.Map.SummaryPolyline == "" {
+		return view, nil, nil
+	}
+	track, err := geo.DecodePolyline(activity.Map.SummaryPolyline)
+	if err != nil {
+		return view, nil, fmt.Errorf("Unable to decode the map of activity %d: %v", activity.ID, err)
+	}
+	track = scrubber.Points(track)
+	if len(track) < 2 {
+		return view, nil, nil
+	}
+	view.Track = trackSVG(track, 800, 500)
+	return view, track, nil
+}
+
+func totals(views []Activity) []Total {
+	byType := map[string]*Total{}
+	for _, view := range views {
+		total, ok := byType[view.Type]
+		if !ok {
+			total = &Total{Type: view.Type}
+			byType[view.Type] = total
+		}
+		total.Count++
+		total.Distance += view.Distance
+		total.MovingTime += view.MovingTime
+	}
+
+	result := make([]Total, 0, len(byType))
+	for _, total := range byType {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Count > result[j].Count })
+	return result
+}
+
+// weeks groups the activities, sorted newest first, by the Monday starting
+// their week.
+func weeks(views []Activity) []Week {
+	var result []Week
+	for _, view := range views {
+		offset := (int(view.Start.Weekday()) + 6) % 7
+		year, month, day := view.Start.AddDate(0, 0, -offset).Date()
+		start := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+		if len(result) == 0 || !result[len(result)-1].Start.Equal(start) {
+			result = append(result, Week{Start: start})
+		}
+		week := &result[len(result)-1]
+		week.Count++
+		week.Distance += view.Distance
+		week.MovingTime += view.MovingTime
+		week.Ascent += view.Ascent
+	}
+	return result
+}
+
+// trackSVG draws a track as an SVG path fitted within width by height,
+// using an equirectangular projection scaled at the latitude of the track.
+func trackSVG(track []geo.Point, width, height int) template.HTML {
+	if len(track) < 2 {
+		return ""
+	}
+
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	minLng, maxLng := math.Inf(1), math.Inf(-1)
+	for _, p := range track {
+		minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+		minLng, maxLng = math.Min(minLng, p.Lng), math.Max(maxLng, p.Lng)
+	}
+
+	aspect := math.Cos((minLat + maxLat) / 2 * math.Pi / 180)
+	spanX, spanY := (maxLng-minLng)*aspect, maxLat-minLat
+	margin := 20.0
+	scale := math.Min((float64(width)-2*margin)/math.Max(spanX, 1e-9), (float64(height)-2*margin)/math.Max(spanY, 1e-9))
+	offsetX := (float64(width) - spanX*scale) / 2
+	offsetY := (float64(height) - spanY*scale) / 2
+
+	var builder strings.Builder
+	for i, p := range track {
+		command := "L"
+		if i == 0 {
+			command = "M"
+		}
+		fmt.Fprintf(&builder, "%s%.1f %.1f ", command, offsetX+(p.Lng-minLng)*aspect*scale, offsetY+(maxLat-p.Lat)*scale)
+	}
+
+	return template.HTML(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d"><path d="%s" fill="none" stroke="#fc4c02" stroke-width="3" stroke-linejoin="round"/></svg>`,
+		width, height, strings.TrimSpace(builder.String())))
+}
+
+func (t *Theme) render(filename, name string, data interface{}) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := t.templates.ExecuteTemplate(file, name, data); err != nil {
+		file.Close()
+		return fmt.Errorf("Unable to render %s: %v", filename, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,148 @@
+package site
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/jsilland/sutro/format"
+)
+
+// Theme is the set of templates and the stylesheet a site is rendered
+// with. A theme directory may override any of the files of the default
+// theme: layout.html, which defines the header and footer templates,
+// index.html, activity.html and style.css.
+type Theme struct {
+	templates  *template.Template
+	stylesheet string
+}
+
+var themeFiles = []string{"layout.html", "index.html", "activity.html"}
+
+var functions = template.FuncMap{
+	"kilometers": format.Kilometers,
+	"duration":   format.Duration,
+	"date": func(t time.Time) string {
+		return t.Format("Mon, Jan 2 2006 15:04")
+	},
+	"day": func(t time.Time) string {
+		return t.Format("Jan 2 2006")
+	},
+}
+
+// DefaultTheme returns the theme sutro ships with.
+func DefaultTheme() *Theme {
+	theme, err := newTheme(defaultFiles)
+	if err != nil {
+		panic(err)
+	}
+	return theme
+}
+
+// LoadTheme returns the default theme with the files found in directory
+// taking precedence.
+func LoadTheme(directory string) (*Theme, error) {
+	files := map[string]string{}
+	for name, content := range defaultFiles {
+		files[name] = content
+	}
+
+	found := 0
+	for name := range defaultFiles {
+		content, err := ioutil.ReadFile(path.Join(directory, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files[name] = string(content)
+		found++
+	}
+	if found == 0 {
+		return nil, fmt.Errorf("The theme directory %s contains none of layout.html, index.html, activity.html or style.css", directory)
+	}
+
+	return newTheme(files)
+}
+
+func newTheme(files map[string]string) (*Theme, error) {
+	templates := template.New("site").Funcs(functions)
+	for _, name := range themeFiles {
+		if _, err := templates.New(name).Parse(files[name]); err != nil {
+			return nil, fmt.Errorf("Invalid template %s: %v", name, err)
+		}
+	}
+	return &Theme{templates: templates, stylesheet: files["style.css"]}, nil
+}
+
+var defaultFiles = map[string]string{
+	"layout.html": `{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<header><a href="{{.Root}}index.html">{{.Site}}</a></header>
+<main>
+{{end}}
+{{define "footer"}}</main>
+<footer>Generated on {{day .Generated}} by sutro</footer>
+</body>
+</html>
+{{end}}`,
+
+	"index.html": `{{template "header" .}}
+<h1>{{.Site}}</h1>
+<section class="totals">
+{{range .Totals}}<div><strong>{{.Type}}</strong> {{.Count}} activities · {{kilometers .Distance}} · {{duration .MovingTime}}</div>
+{{end}}</section>
+{{if .Heatmap}}<img class="heatmap" src="heatmap.png" alt="Heatmap of all activities">{{end}}
+<h2>Weekly volume</h2>
+<table class="weeks">
+<tr><th>Week of</th><th>Activities</th><th>Distance</th><th>Moving time</th><th>Ascent</th></tr>
+{{range .Weeks}}<tr><td>{{day .Start}}</td><td>{{.Count}}</td><td>{{kilometers .Distance}}</td><td>{{duration .MovingTime}}</td><td>{{printf "%.0f m" .Ascent}}</td></tr>
+{{end}}</table>
+<h2>Activities</h2>
+<ul class="activities">
+{{range .Activities}}<li><a href="activities/{{.ID}}.html">{{.Name}}</a> <span>{{.Type}} · {{date .Start}} · {{kilometers .Distance}}</span></li>
+{{end}}</ul>
+{{template "footer" .}}`,
+
+	"activity.html": `{{template "header" .}}
+{{with .Activity}}<h1>{{.Name}}</h1>
+<p>{{.Type}} · {{date .Start}}</p>
+<dl>
+<dt>Distance</dt><dd>{{kilometers .Distance}}</dd>
+<dt>Moving time</dt><dd>{{duration .MovingTime}}</dd>
+<dt>Elapsed time</dt><dd>{{duration .ElapsedTime}}</dd>
+<dt>Ascent</dt><dd>{{printf "%.0f m" .Ascent}}</dd>
+{{if .AverageHeartrate}}<dt>Average heart rate</dt><dd>{{printf "%.0f bpm" .AverageHeartrate}}</dd>{{end}}
+</dl>
+{{if .Track}}<figure class="track">{{.Track}}</figure>{{end}}
+<p><a href="https://www.strava.com/activities/{{.ID}}">View on Strava</a></p>
+{{end}}
+{{template "footer" .}}`,
+
+	"style.css": `body { font-family: -apple-system, Helvetica, Arial, sans-serif; margin: 0; color: #222; }
+header, main, footer { max-width: 860px; margin: 0 auto; padding: 1em; }
+header a { color: #fc4c02; font-weight: bold; text-decoration: none; }
+footer { color: #888; font-size: 0.85em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: right; padding: 0.3em 0.6em; border-bottom: 1px solid #eee; }
+th:first-child, td:first-child { text-align: left; }
+ul.activities { list-style: none; padding: 0; }
+ul.activities li { padding: 0.4em 0; border-bottom: 1px solid #eee; }
+ul.activities span { color: #888; font-size: 0.9em; }
+img.heatmap, figure.track svg { width: 100%; height: auto; background: #000; }
+figure.track { margin: 1em 0; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 0.3em 1em; }
+dd { margin: 0; }
+`,
+}
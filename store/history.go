@@ -0,0 +1,118 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historySize is the number of commands kept in the history.
+const historySize = 200
+
+// HistoryEntry is a command that ran, with the ids it returned.
+type HistoryEntry struct {
+	Time    time.Time
+	Command string
+	IDs     []int64
+}
+
+// AddHistory records a command in the history, forgetting the oldest ones
+// past the size of the history.
+func (s *Store) AddHistory(entry HistoryEntry) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	ids := make([]string, len(entry.IDs))
+	for i, id := range entry.IDs {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO history (time, command, ids) VALUES (?, ?, ?)", entry.Time.Unix(), entry.Command, strings.Join(ids, ","))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM history WHERE id <= (SELECT MAX(id) FROM history) - ?", historySize)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// History returns the most recent commands of the history, most recent
+// first, or all of them when limit is zero.
+func (s *Store) History(limit int) ([]HistoryEntry, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+
+	statement := "SELECT time, command, ids FROM history ORDER BY id DESC"
+	var args []interface{}
+	if limit > 0 {
+		statement += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var unix int64
+		var entry HistoryEntry
+		var ids string
+		if err := rows.Scan(&unix, &entry.Command, &ids); err != nil {
+			return nil, err
+		}
+		entry.Time = time.Unix(unix, 0)
+		if entry.IDs, err = parseIDs(ids); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// LastReturned returns the ids returned by the most recent command of the
+// history that returned any, or nil.
+func (s *Store) LastReturned() ([]int64, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+
+	var ids string
+	err := s.db.QueryRow("SELECT ids FROM history WHERE ids != '' ORDER BY id DESC LIMIT 1").Scan(&ids)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseIDs(ids)
+}
+
+func parseIDs(value string) ([]int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	ids := make([]int64, len(parts))
+	for i, part := range parts {
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
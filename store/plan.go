@@ -0,0 +1,91 @@
+package store
+
+import (
+	"time"
+)
+
+// dayLayout is the layout of the days of planned sessions, which sort as
+// text in the order of the days.
+const dayLayout = "2006-01-02"
+
+// Session is a training session planned for a day. Its Type is empty when
+// any type of activity completes it, and its Duration and Distance are
+// zero when the plan does not set them.
+type Session struct {
+	ID       int64
+	Date     time.Time
+	Type     string
+	Name     string
+	Duration time.Duration
+	Distance float64
+}
+
+// ReplaceSessions replaces the planned sessions of the days from first to
+// last, included, with sessions, so that importing a plan again updates it
+// rather than duplicating it.
+func (s *Store) ReplaceSessions(first, last time.Time, sessions []Session) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM plan WHERE date >= ? AND date <= ?", first.Format(dayLayout), last.Format(dayLayout))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, session := range sessions {
+		_, err = tx.Exec("INSERT INTO plan (date, type, name, duration, distance) VALUES (?, ?, ?, ?, ?)",
+			session.Date.Format(dayLayout), session.Type, session.Name, int64(session.Duration.Seconds()), session.Distance)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Sessions returns the planned sessions of the days from first to last,
+// included, in the order of their days. Zero dates apply no restriction.
+func (s *Store) Sessions(first, last time.Time) ([]Session, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+
+	statement := "SELECT id, date, type, name, duration, distance FROM plan WHERE 1 = 1"
+	var args []interface{}
+	if !first.IsZero() {
+		statement += " AND date >= ?"
+		args = append(args, first.Format(dayLayout))
+	}
+	if !last.IsZero() {
+		statement += " AND date <= ?"
+		args = append(args, last.Format(dayLayout))
+	}
+	statement += " ORDER BY date, id"
+
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var session Session
+		var day string
+		var seconds int64
+		if err := rows.Scan(&session.ID, &day, &session.Type, &session.Name, &seconds, &session.Distance); err != nil {
+			return nil, err
+		}
+		if session.Date, err = time.ParseInLocation(dayLayout, day, time.Local); err != nil {
+			return nil, err
+		}
+		session.Duration = time.Duration(seconds) * time.Second
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Select runs a SQL statement over the archive, such as
+// SELECT type, COUNT(*) FROM activities GROUP BY type, and calls fn with
+// the names of its columns and the values of each row. Statements that
+// would modify the archive fail, as the connection running them is read
+// only. Iteration stops at the first error fn returns.
+func (s *Store) Select(statement string, fn func(columns []string, values []interface{}) error) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return err
+	}
+	// The connection returns to the pool, for the other methods to write.
+	defer conn.ExecContext(ctx, "PRAGMA query_only = OFF")
+
+	rows, err := conn.QueryContext(ctx, statement)
+	if err != nil {
+		return queryError(err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		// Text is scanned as bytes.
+		for i, value := range values {
+			if bytes, ok := value.([]byte); ok {
+				values[i] = string(bytes)
+			}
+		}
+		if err := fn(columns, values); err != nil {
+			return err
+		}
+	}
+	return queryError(rows.Err())
+}
+
+// queryError explains the errors of statements writing to the archive. They
+// are told apart by the message of SQLite rather than by the error type of
+// the driver, which only exists in builds with cgo.
+func queryError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "attempt to write a readonly database") {
+		return fmt.Errorf("Queries cannot modify the archive: %v", err)
+	}
+	return err
+}
+
+// Schema returns the statements creating the tables and indexes of the
+// archive.
+func (s *Store) Schema() ([]string, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query("SELECT sql FROM sqlite_master WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' ORDER BY type DESC, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var statement string
+		if err := rows.Scan(&statement); err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+	}
+	return statements, rows.Err()
+}
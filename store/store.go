@@ -0,0 +1,273 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jsilland/sutro/models"
+
+	// Registers the sqlite3 driver with database/sql.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// migrations are applied in order, each one bringing the schema to the
+// version matching its index plus one.
+var migrations = []string{
+	`CREATE TABLE activities (
+		id INTEGER PRIMARY KEY,
+		start_date INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		name TEXT NOT NULL,
+		distance REAL NOT NULL,
+		moving_time INTEGER NOT NULL,
+		summary_polyline TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE INDEX activities_start_date ON activities (start_date);`,
+	`CREATE TABLE history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time INTEGER NOT NULL,
+		command TEXT NOT NULL,
+		ids TEXT NOT NULL
+	);`,
+	`CREATE TABLE updates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		time INTEGER NOT NULL,
+		command TEXT NOT NULL,
+		previous TEXT NOT NULL,
+		undone INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX updates_activity_id ON updates (activity_id);`,
+	`CREATE TABLE plan (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT NOT NULL,
+		type TEXT NOT NULL,
+		name TEXT NOT NULL,
+		duration INTEGER NOT NULL,
+		distance REAL NOT NULL
+	);
+	CREATE INDEX plan_date ON plan (date);`,
+	`CREATE TABLE thresholds (
+		date TEXT PRIMARY KEY,
+		ftp REAL NOT NULL,
+		lthr REAL NOT NULL
+	);`,
+}
+
+// Store is the local archive of synced Strava data, kept in a SQLite
+// database so that analysis commands can run without network access.
+type Store struct {
+	db   *sql.DB
+	once sync.Once
+	err  error
+}
+
+// Query restricts the activities returned by the store. Zero values
+// apply no restriction.
+type Query struct {
+	After  time.Time
+	Before time.Time
+	Type   string
+}
+
+// DefaultPath returns the location of the archive in sutro's state
+// directory.
+func DefaultPath(stateDirectory string) string {
+	return path.Join(stateDirectory, "sutro.db")
+}
+
+// Open returns a store backed by the database at the given path. The
+// database is created and migrated on first use.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) init() error {
+	s.once.Do(func() {
+		var version int
+		if s.err = s.db.QueryRow("PRAGMA user_version").Scan(&version); s.err != nil {
+			return
+		}
+
+		for ; version < len(migrations); version++ {
+			if _, s.err = s.db.Exec(migrations[version]); s.err != nil {
+				return
+			}
+			// PRAGMA statements do not support bound parameters.
+			if _, s.err = s.db.Exec("PRAGMA user_version = " + strconv.Itoa(version+1)); s.err != nil {
+				return
+			}
+		}
+	})
+	return s.err
+}
+
+// PutActivities inserts or replaces the given activities in the archive.
+func (s *Store) PutActivities(activities []*models.SummaryActivity) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	statement, err := tx.Prepare(`INSERT OR REPLACE INTO activities
+		(id, start_date, type, name, distance, moving_time, summary_polyline, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer statement.Close()
+
+	for _, activity := range activities {
+		data, err := json.Marshal(activity)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		polyline := ""
+		if activity.Map != nil {
+			polyline = activity.Map.SummaryPolyline
+		}
+
+		_, err = statement.Exec(
+			activity.ID,
+			time.Time(activity.StartDate).Unix(),
+			string(activity.Type),
+			activity.Name,
+			activity.Distance,
+			activity.MovingTime,
+			polyline,
+			string(data),
+		)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Activities returns the archived activities matching the query, oldest
+// first.
+func (s *Store) Activities(query Query) ([]*models.SummaryActivity, error) {
+	var activities []*models.SummaryActivity
+	err := s.EachActivity(query, func(activity *models.SummaryActivity) error {
+		activities = append(activities, activity)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// EachActivity calls fn with each archived activity matching the query,
+// oldest first, without loading them all in memory. Iteration stops at the
+// first error fn returns.
+func (s *Store) EachActivity(query Query, fn func(*models.SummaryActivity) error) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	var conditions []string
+	var args []interface{}
+	if !query.After.IsZero() {
+		conditions = append(conditions, "start_date > ?")
+		args = append(args, query.After.Unix())
+	}
+	if !query.Before.IsZero() {
+		conditions = append(conditions, "start_date < ?")
+		args = append(args, query.Before.Unix())
+	}
+	if query.Type != "" {
+		conditions = append(conditions, "type = ? COLLATE NOCASE")
+		args = append(args, query.Type)
+	}
+
+	statement := "SELECT data FROM activities"
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += " ORDER BY start_date"
+
+	rows, err := s.db.Query(statement, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+
+		var activity models.SummaryActivity
+		if err := json.Unmarshal([]byte(data), &activity); err != nil {
+			return err
+		}
+		if err := fn(&activity); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// LatestActivityStart returns the start date of the most recent archived
+// activity, or the zero time if the archive is empty.
+func (s *Store) LatestActivityStart() (time.Time, error) {
+	if err := s.init(); err != nil {
+		return time.Time{}, err
+	}
+
+	var latest sql.NullInt64
+	if err := s.db.QueryRow("SELECT MAX(start_date) FROM activities").Scan(&latest); err != nil {
+		return time.Time{}, err
+	}
+	if !latest.Valid {
+		return time.Time{}, nil
+	}
+	return time.Unix(latest.Int64, 0), nil
+}
+
+// Activity returns the archived activity with the given id, or nil if it
+// has not been synced.
+func (s *Store) Activity(id int64) (*models.SummaryActivity, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+
+	var data string
+	err := s.db.QueryRow("SELECT data FROM activities WHERE id = ?", id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var activity models.SummaryActivity
+	if err := json.Unmarshal([]byte(data), &activity); err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Thresholds are the functional threshold power, in watts, and the lactate
+// threshold heart rate, in beats per minute, of the athlete from Date on.
+// Either is zero when it did not change that day.
+type Thresholds struct {
+	Date time.Time
+	FTP  float64
+	LTHR float64
+}
+
+// SetThresholds records the thresholds of a day, keeping the ones it
+// leaves at zero of a previous record of the same day.
+func (s *Store) SetThresholds(thresholds Thresholds) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	day := thresholds.Date.Format(dayLayout)
+	var ftp, lthr float64
+	err = tx.QueryRow("SELECT ftp, lthr FROM thresholds WHERE date = ?", day).Scan(&ftp, &lthr)
+	if err != nil && err != sql.ErrNoRows {
+		tx.Rollback()
+		return err
+	}
+	if thresholds.FTP == 0 {
+		thresholds.FTP = ftp
+	}
+	if thresholds.LTHR == 0 {
+		thresholds.LTHR = lthr
+	}
+	_, err = tx.Exec("INSERT OR REPLACE INTO thresholds (date, ftp, lthr) VALUES (?, ?, ?)", day, thresholds.FTP, thresholds.LTHR)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ThresholdHistory returns the records of thresholds, oldest first.
+func (s *Store) ThresholdHistory() ([]Thresholds, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query("SELECT date, ftp, lthr FROM thresholds ORDER BY date")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []Thresholds
+	for rows.Next() {
+		var thresholds Thresholds
+		var day string
+		if err := rows.Scan(&day, &thresholds.FTP, &thresholds.LTHR); err != nil {
+			return nil, err
+		}
+		if thresholds.Date, err = time.ParseInLocation(dayLayout, day, time.Local); err != nil {
+			return nil, err
+		}
+		history = append(history, thresholds)
+	}
+	return history, rows.Err()
+}
+
+// ThresholdsAt returns the thresholds valid on the day of date, as
+// ValidThresholds does.
+func (s *Store) ThresholdsAt(date time.Time) (Thresholds, error) {
+	history, err := s.ThresholdHistory()
+	if err != nil {
+		return Thresholds{}, err
+	}
+	return ValidThresholds(history, date), nil
+}
+
+// ValidThresholds returns the thresholds of history valid on the day of
+// date: each the last one recorded on that day or before it, or zero when
+// none was.
+func ValidThresholds(history []Thresholds, date time.Time) Thresholds {
+	valid := Thresholds{Date: date}
+	day := date.Format(dayLayout)
+	for _, thresholds := range history {
+		if thresholds.Date.Format(dayLayout) > day {
+			break
+		}
+		if thresholds.FTP > 0 {
+			valid.FTP = thresholds.FTP
+		}
+		if thresholds.LTHR > 0 {
+			valid.LTHR = thresholds.LTHR
+		}
+	}
+	return valid
+}
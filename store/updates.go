@@ -0,0 +1,102 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// updatesSize is the number of updates kept to undo.
+const updatesSize = 1000
+
+// Update is an update of an activity by sutro, with the values the fields
+// it changed had before, so that it can be undone.
+type Update struct {
+	ID         int64
+	ActivityID int64
+	Time       time.Time
+	Command    string
+	Previous   PreviousFields
+}
+
+// PreviousFields are the values of the fields of an activity before an
+// update, each of which is nil when the update left it alone.
+type PreviousFields struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Type        *string `json:"type,omitempty"`
+	GearID      *string `json:"gear_id,omitempty"`
+	Commute     *bool   `json:"commute,omitempty"`
+	Trainer     *bool   `json:"trainer,omitempty"`
+}
+
+// AddUpdate records an update of an activity, forgetting the oldest ones
+// past the number of updates kept.
+func (s *Store) AddUpdate(update Update) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	previous, err := json.Marshal(update.Previous)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO updates (activity_id, time, command, previous) VALUES (?, ?, ?, ?)",
+		update.ActivityID, update.Time.Unix(), update.Command, string(previous))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM updates WHERE id <= (SELECT MAX(id) FROM updates) - ?", updatesSize)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// LastUpdate returns the most recent update of the activity activityID, or
+// of any activity when it is zero, that was not undone yet, or nil.
+func (s *Store) LastUpdate(activityID int64) (*Update, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+
+	statement := "SELECT id, activity_id, time, command, previous FROM updates WHERE undone = 0"
+	var args []interface{}
+	if activityID != 0 {
+		statement += " AND activity_id = ?"
+		args = append(args, activityID)
+	}
+	statement += " ORDER BY id DESC LIMIT 1"
+
+	var update Update
+	var unix int64
+	var previous string
+	err := s.db.QueryRow(statement, args...).Scan(&update.ID, &update.ActivityID, &unix, &update.Command, &previous)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	update.Time = time.Unix(unix, 0)
+	if err := json.Unmarshal([]byte(previous), &update.Previous); err != nil {
+		return nil, err
+	}
+	return &update, nil
+}
+
+// MarkUndone records that the update id was undone.
+func (s *Store) MarkUndone(id int64) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("UPDATE updates SET undone = 1 WHERE id = ?", id)
+	return err
+}
@@ -0,0 +1,161 @@
+package strava
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/jsilland/sutro/client"
+	"github.com/jsilland/sutro/client/activities"
+	"github.com/jsilland/sutro/models"
+)
+
+// ActivitiesService reads and writes the activities of the authenticated
+// athlete.
+type ActivitiesService struct {
+	api *client.StravaAPIV3
+}
+
+// NewActivity describes a manual activity to create.
+type NewActivity struct {
+	Name string
+	Type models.ActivityType
+	// Start is the local time at which the activity started.
+	Start       time.Time
+	ElapsedTime time.Duration
+	// Distance is in meters, and omitted when zero.
+	Distance    float64
+	Description string
+	Trainer     bool
+	Commute     bool
+}
+
+// Get returns the activity with the given id.
+func (s *ActivitiesService) Get(ctx context.Context, id int64) (*models.DetailedActivity, error) {
+	response, err := s.api.Activities.GetActivityByID(activities.NewGetActivityByIDParamsWithContext(ctx).WithID(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.Payload == nil {
+		return nil, fmt.Errorf("Failed to obtain activity %d from the API", id)
+	}
+	return response.Payload, nil
+}
+
+// Efforts returns the segment efforts of an activity, hidden ones
+// included, in the order they started.
+func (s *ActivitiesService) Efforts(ctx context.Context, id int64) ([]*models.DetailedSegmentEffort, error) {
+	all := true
+	response, err := s.api.Activities.GetActivityByID(activities.NewGetActivityByIDParamsWithContext(ctx).WithID(id).WithIncludeAllEfforts(&all), nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.Payload == nil {
+		return nil, fmt.Errorf("Failed to obtain activity %d from the API", id)
+	}
+	return response.Payload.SegmentEfforts, nil
+}
+
+// List iterates over the activities of the authenticated athlete that
+// started within the bounds of options, from the most recent.
+func (s *ActivitiesService) List(ctx context.Context, options ListOptions) *ActivityIterator {
+	perPage := options.perPage()
+	params := activities.NewGetLoggedInAthleteActivitiesParamsWithContext(ctx).WithPerPage(&perPage)
+	if !options.After.IsZero() {
+		after := options.After.Unix()
+		params.SetAfter(&after)
+	}
+	if !options.Before.IsZero() {
+		before := options.Before.Unix()
+		params.SetBefore(&before)
+	}
+	return NewActivityIterator(s.api, params)
+}
+
+// Create creates a manual activity, without a GPS track.
+func (s *ActivitiesService) Create(ctx context.Context, activity NewActivity) (*models.DetailedActivity, error) {
+	params := activities.NewCreateActivityParamsWithContext(ctx).
+		WithName(activity.Name).
+		WithType(string(activity.Type)).
+		WithStartDateLocal(strfmt.DateTime(activity.Start)).
+		WithElapsedTime(int64(activity.ElapsedTime.Seconds()))
+	if activity.Distance > 0 {
+		distance := float32(activity.Distance)
+		params.SetDistance(&distance)
+	}
+	if activity.Description != "" {
+		params.SetDescription(&activity.Description)
+	}
+	if activity.Trainer {
+		trainer := int64(1)
+		params.SetTrainer(&trainer)
+	}
+	if activity.Commute {
+		commute := int64(1)
+		params.SetCommute(&commute)
+	}
+
+	response, err := s.api.Activities.CreateActivity(params, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.Payload == nil {
+		return nil, errors.New("Strava did not return the created activity")
+	}
+	return response.Payload, nil
+}
+
+// Update changes the fields of an activity set in update.
+func (s *ActivitiesService) Update(ctx context.Context, id int64, update *models.UpdatableActivity) (*models.DetailedActivity, error) {
+	params := activities.NewUpdateActivityByIDParamsWithContext(ctx).
+		WithID(id).
+		WithBody(update)
+
+	response, err := s.api.Activities.UpdateActivityByID(params, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.Payload == nil {
+		return nil, fmt.Errorf("Strava did not return the updated activity %d", id)
+	}
+	return response.Payload, nil
+}
+
+// Photos returns the photos of an activity, with URLs of images at most size
+// pixels wide.
+func (s *ActivitiesService) Photos(ctx context.Context, id int64, size int) ([]*models.Photo, error) {
+	size64, sources := int64(size), true
+	params := activities.NewGetPhotosByActivityIDParamsWithContext(ctx).
+		WithID(id).
+		WithSize(&size64).
+		WithPhotoSources(&sources)
+
+	response, err := s.api.Activities.GetPhotosByActivityID(params, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Payload, nil
+}
+
+// Laps returns the laps of an activity, in order.
+func (s *ActivitiesService) Laps(ctx context.Context, id int64) ([]*models.Lap, error) {
+	response, err := s.api.Activities.GetLapsByActivityID(activities.NewGetLapsByActivityIDParamsWithContext(ctx).WithID(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Payload, nil
+}
+
+// Kudoers iterates over the athletes who gave kudos to an activity.
+func (s *ActivitiesService) Kudoers(ctx context.Context, id int64, options ListOptions) *AthleteIterator {
+	perPage := options.perPage()
+	return NewKudoerIterator(s.api, activities.NewGetKudoersByActivityIDParamsWithContext(ctx).WithID(id).WithPerPage(&perPage))
+}
+
+// Comments iterates over the comments on an activity, from the oldest.
+func (s *ActivitiesService) Comments(ctx context.Context, id int64, options ListOptions) *CommentIterator {
+	perPage := options.perPage()
+	return NewCommentIterator(s.api, activities.NewGetCommentsByActivityIDParamsWithContext(ctx).WithID(id).WithPerPage(&perPage))
+}
@@ -0,0 +1,33 @@
+package strava
+
+import (
+	"context"
+
+	"github.com/jsilland/sutro/client"
+	"github.com/jsilland/sutro/client/athletes"
+	"github.com/jsilland/sutro/models"
+)
+
+// AthletesService reads the authenticated athlete and their statistics.
+type AthletesService struct {
+	api *client.StravaAPIV3
+}
+
+// Current returns the authenticated athlete.
+func (s *AthletesService) Current(ctx context.Context) (*models.DetailedAthlete, error) {
+	response, err := s.api.Athletes.GetLoggedInAthlete(athletes.NewGetLoggedInAthleteParamsWithContext(ctx), nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Payload, nil
+}
+
+// Stats returns the totals of the athlete with the given id, which must be
+// the authenticated athlete.
+func (s *AthletesService) Stats(ctx context.Context, id int64) (*models.ActivityStats, error) {
+	response, err := s.api.Athletes.GetStats(athletes.NewGetStatsParamsWithContext(ctx).WithID(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Payload, nil
+}
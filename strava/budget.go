@@ -0,0 +1,85 @@
+package strava
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/runtime"
+)
+
+// ErrBudgetExhausted is returned instead of sending the requests a budget
+// does not allow.
+var ErrBudgetExhausted = errors.New("The request budget of the job is exhausted")
+
+// Budget bounds the requests of a long-running job, so that it stops
+// before spending the daily quota of the application. Its interceptor
+// fails the requests past the budget with ErrBudgetExhausted.
+type Budget struct {
+	// Requests is the number of requests allowed, or 0 for no limit.
+	Requests int
+	// Deadline is the time after which no request is sent, or the zero
+	// time for no limit.
+	Deadline time.Time
+
+	mutex sync.Mutex
+	sent  int
+}
+
+// Interceptor returns the interceptor counting requests against the
+// budget.
+func (b *Budget) Interceptor() Interceptor {
+	return func(next Runner) Runner {
+		return func(operation *runtime.ClientOperation) (interface{}, error) {
+			b.mutex.Lock()
+			if b.exhausted(time.Now()) {
+				b.mutex.Unlock()
+				return nil, ErrBudgetExhausted
+			}
+			b.sent++
+			b.mutex.Unlock()
+
+			return next(operation)
+		}
+	}
+}
+
+// Sent returns the number of requests sent within the budget.
+func (b *Budget) Sent() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.sent
+}
+
+// Exhausted reports whether the budget allows no more requests.
+func (b *Budget) Exhausted() bool {
+	return b.ExhaustedAt(time.Now())
+}
+
+// ExhaustedAt reports whether the budget allows no more requests at t, for
+// instance once a rate limit resets. A nil budget is never exhausted.
+func (b *Budget) ExhaustedAt(t time.Time) bool {
+	if b == nil {
+		return false
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.exhausted(t)
+}
+
+// Stopped reports whether err is the budget stopping a job: either the
+// budget is exhausted, or the rate limit resets after its deadline.
+func (b *Budget) Stopped(err error) bool {
+	if b == nil {
+		return false
+	}
+	if errors.Is(err, ErrBudgetExhausted) {
+		return true
+	}
+	var limited *RateLimitError
+	return errors.As(err, &limited) && b.ExhaustedAt(limited.Reset)
+}
+
+func (b *Budget) exhausted(t time.Time) bool {
+	return b.Requests > 0 && b.sent >= b.Requests || !b.Deadline.IsZero() && !t.Before(b.Deadline)
+}
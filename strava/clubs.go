@@ -0,0 +1,95 @@
+package strava
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/jsilland/sutro/client"
+	"github.com/jsilland/sutro/client/clubs"
+)
+
+// ClubsService reads the clubs of the authenticated athlete and what they
+// organize.
+type ClubsService struct {
+	api       *client.StravaAPIV3
+	transport runtime.ClientTransport
+}
+
+// GroupEvent is a ride, run or other event a club organizes, once or on a
+// schedule. Group events are missing from swagger.json, and Strava only
+// exposes them to some applications.
+type GroupEvent struct {
+	ID           int64     `json:"id"`
+	ClubID       int64     `json:"club_id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	ActivityType string    `json:"activity_type"`
+	Address      string    `json:"address"`
+	StartLatlng  []float64 `json:"start_latlng"`
+	// Zone is the time zone of the event, such as America/Los_Angeles.
+	Zone    string `json:"zone"`
+	RouteID int64  `json:"route_id"`
+	Private bool   `json:"private"`
+	// Joined is whether the authenticated athlete is going.
+	Joined bool `json:"joined"`
+	// UpcomingOccurrences are the starts of the next occurrences of the
+	// event, the only one for events that do not repeat.
+	UpcomingOccurrences []time.Time `json:"upcoming_occurrences"`
+}
+
+// Events returns the group events of the club with the given id that have
+// upcoming occurrences.
+func (s *ClubsService) Events(ctx context.Context, id int64) ([]*GroupEvent, error) {
+	result, err := s.transport.Submit(&runtime.ClientOperation{
+		ID:                 "getClubGroupEvents",
+		Method:             http.MethodGet,
+		PathPattern:        "/clubs/{id}/group_events",
+		ProducesMediaTypes: []string{runtime.JSONMime},
+		ConsumesMediaTypes: []string{runtime.JSONMime},
+		Schemes:            []string{"https"},
+		Params: runtime.ClientRequestWriterFunc(func(request runtime.ClientRequest, _ strfmt.Registry) error {
+			if err := request.SetPathParam("id", strconv.FormatInt(id, 10)); err != nil {
+				return err
+			}
+			return request.SetQueryParam("upcoming", "true")
+		}),
+		Reader:  &groupEventsReader{faults: &clubs.GetClubByIDReader{}},
+		Context: ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	events, ok := result.([]*GroupEvent)
+	if !ok {
+		return nil, fmt.Errorf("Failed to obtain the events of club %d from the API", id)
+	}
+	return events, nil
+}
+
+// groupEventsReader decodes group events, which have no generated model.
+type groupEventsReader struct {
+	// faults reads the other responses, which are faults.
+	faults runtime.ClientResponseReader
+}
+
+func (r *groupEventsReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	if response.Code() != http.StatusOK {
+		return r.faults.ReadResponse(response, consumer)
+	}
+	data, err := ioutil.ReadAll(response.Body())
+	if err != nil {
+		return nil, err
+	}
+	var events []*GroupEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,57 @@
+package strava
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/go-openapi/runtime"
+)
+
+// jsonConsumer decodes JSON responses like the consumer of the runtime,
+// except that arrays are decoded one element at a time. The decoder of the
+// runtime reads a whole value before decoding it, which buffers pages of
+// 200 activities in full on top of the activities decoded from them.
+func jsonConsumer() runtime.Consumer {
+	return runtime.ConsumerFunc(func(reader io.Reader, data interface{}) error {
+		decoder := json.NewDecoder(reader)
+		decoder.UseNumber()
+
+		slice := reflect.ValueOf(data)
+		if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+			return decoder.Decode(data)
+		}
+		return decodeArray(decoder, slice.Elem())
+	})
+}
+
+// decodeArray decodes a JSON array into slice, which is reset to nil when
+// the array is null.
+func decodeArray(decoder *json.Decoder, slice reflect.Value) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		slice.Set(reflect.Zero(slice.Type()))
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("Expected a JSON array, got %v", token)
+	}
+
+	elements := reflect.MakeSlice(slice.Type(), 0, 0)
+	for decoder.More() {
+		element := reflect.New(slice.Type().Elem())
+		if err := decoder.Decode(element.Interface()); err != nil {
+			return err
+		}
+		elements = reflect.Append(elements, element.Elem())
+	}
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	slice.Set(elements)
+	return nil
+}
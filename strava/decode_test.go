@@ -0,0 +1,77 @@
+package strava
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+)
+
+// page is a page of 200 activities, as Strava answers listActivities with
+// per_page=200, each with the polyline of an hour long ride.
+func page(b *testing.B) []byte {
+	track := make([]geo.Point, 3600)
+	for i := range track {
+		track[i] = geo.Point{Lat: 37.77 + float64(i)*1e-4, Lng: -122.42 + float64(i)*1e-4}
+	}
+	polyline := geo.EncodePolyline(track)
+
+	activities := make([]*models.SummaryActivity, 200)
+	for i := range activities {
+		activities[i] = &models.SummaryActivity{
+			Name:               "Morning Ride",
+			Type:               models.ActivityTypeRide,
+			Distance:           42195,
+			MovingTime:         3600,
+			ElapsedTime:        3900,
+			TotalElevationGain: 512,
+			AverageSpeed:       11.7,
+			AverageWatts:       210,
+			AverageHeartrate:   148,
+			StartDate:          strfmt.DateTime(time.Date(2020, 1, 1, 7, 0, 0, 0, time.UTC).AddDate(0, 0, i)),
+			StartLatlng:        models.LatLng{37.77, -122.42},
+			EndLatlng:          models.LatLng{38.13, -122.06},
+			Map:                &models.PolylineMap{ID: "a1", SummaryPolyline: polyline},
+		}
+		activities[i].ID = int64(i)
+	}
+	encoded, err := json.Marshal(activities)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return encoded
+}
+
+func benchmarkConsumer(b *testing.B, consumer runtime.Consumer) {
+	encoded := page(b)
+	b.SetBytes(int64(len(encoded)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var activities []*models.SummaryActivity
+		if err := consumer.Consume(bytes.NewReader(encoded), &activities); err != nil {
+			b.Fatal(err)
+		}
+		if len(activities) != 200 {
+			b.Fatalf("Expected 200 activities, got %d", len(activities))
+		}
+	}
+}
+
+// BenchmarkJSONConsumer compares the consumer decoding pages one activity
+// at a time with the one of the runtime, which it replaces:
+//
+//	go test ./strava -run XXX -bench JSONConsumer -benchmem
+func BenchmarkJSONConsumer(b *testing.B) {
+	b.Run("sutro", func(b *testing.B) {
+		benchmarkConsumer(b, jsonConsumer())
+	})
+	b.Run("runtime", func(b *testing.B) {
+		benchmarkConsumer(b, runtime.JSONConsumer())
+	})
+}
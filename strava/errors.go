@@ -0,0 +1,225 @@
+package strava
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/jsilland/sutro/models"
+)
+
+// Sentinel errors, matched with errors.Is by the typed errors below.
+var (
+	ErrNotFound     = errors.New("Not found")
+	ErrRateLimited  = errors.New("Rate limit exceeded")
+	ErrUnauthorized = errors.New("Unauthorized")
+	ErrValidation   = errors.New("Invalid request")
+)
+
+// APIError is a request that Strava rejected, with the fault it returned.
+// The more specific errors below all wrap it.
+type APIError struct {
+	// Operation is the id of the operation in swagger.json.
+	Operation  string
+	StatusCode int
+	Message    string
+	Fields     []FieldError
+}
+
+// FieldError is one of the reasons given for a fault, such as a missing
+// field or scope.
+type FieldError struct {
+	Resource string
+	Field    string
+	Code     string
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+
+	details := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		details = append(details, strings.Trim(fmt.Sprintf("%s %s %s", field.Resource, field.Field, field.Code), " "))
+	}
+	if len(details) > 0 {
+		message = fmt.Sprintf("%s (%s)", message, strings.Join(details, ", "))
+	}
+	return fmt.Sprintf("%s failed with status %d: %s", e.Operation, e.StatusCode, message)
+}
+
+// NotFoundError is returned for resources that do not exist, or that the
+// authenticated athlete cannot see.
+type NotFoundError struct {
+	*APIError
+}
+
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
+func (e *NotFoundError) Unwrap() error        { return e.APIError }
+
+// RateLimitError is returned once the application made too many requests.
+type RateLimitError struct {
+	*APIError
+	// Reset is when the exceeded limit resets: the next quarter hour for the
+	// short term limit, or midnight UTC for the daily one.
+	Reset time.Time
+}
+
+func (e *RateLimitError) Is(target error) bool { return target == ErrRateLimited }
+func (e *RateLimitError) Unwrap() error        { return e.APIError }
+
+// UnauthorizedError is returned when the token is invalid, or lacks the
+// scopes the operation requires.
+type UnauthorizedError struct {
+	*APIError
+	// MissingScopes are the scopes the token would need, such as
+	// activity:read_all.
+	MissingScopes []string
+}
+
+func (e *UnauthorizedError) Is(target error) bool { return target == ErrUnauthorized }
+func (e *UnauthorizedError) Unwrap() error        { return e.APIError }
+
+func (e *UnauthorizedError) Error() string {
+	if len(e.MissingScopes) == 0 {
+		return e.APIError.Error()
+	}
+	return fmt.Sprintf("%s, authenticate again with the %s scopes", e.APIError.Error(), strings.Join(e.MissingScopes, ","))
+}
+
+// ValidationError is returned for requests with invalid parameters, which
+// are listed in Fields.
+type ValidationError struct {
+	*APIError
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+func (e *ValidationError) Unwrap() error        { return e.APIError }
+
+// faultError converts the errors of the generated client, which carry the
+// fault returned by Strava in their payload, into the errors above. Other
+// errors, such as network failures, are returned as they are.
+func faultError(operation string, err error, header http.Header, now time.Time) error {
+	var status int
+	var fault *models.Fault
+
+	var apiError *runtime.APIError
+	var defaultResponse interface {
+		Code() int
+		GetPayload() *models.Fault
+	}
+	switch {
+	case errors.As(err, &defaultResponse):
+		status, fault = defaultResponse.Code(), defaultResponse.GetPayload()
+	case errors.As(err, &apiError):
+		status = apiError.Code
+	default:
+		return err
+	}
+
+	base := &APIError{Operation: operation, StatusCode: status}
+	if fault != nil {
+		base.Message = fault.Message
+		for _, field := range fault.Errors {
+			if field != nil {
+				base.Fields = append(base.Fields, FieldError{Resource: field.Resource, Field: field.Field, Code: field.Code})
+			}
+		}
+	}
+
+	switch status {
+	case http.StatusNotFound:
+		return &NotFoundError{base}
+	case http.StatusTooManyRequests:
+		return &RateLimitError{APIError: base, Reset: rateLimitReset(header, now)}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		unauthorized := &UnauthorizedError{APIError: base}
+		for _, field := range base.Fields {
+			// Missing scopes are reported as fields such as
+			// activity:read_permission.
+			if field.Code == "missing" && strings.HasSuffix(field.Field, "_permission") {
+				unauthorized.MissingScopes = append(unauthorized.MissingScopes, strings.TrimSuffix(field.Field, "_permission"))
+			}
+		}
+		return unauthorized
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return &ValidationError{base}
+	}
+	return base
+}
+
+// rateLimitReset reads the limits and usage of the response, given as short
+// term and daily counts such as 100,1000, to tell which limit was exceeded.
+func rateLimitReset(header http.Header, now time.Time) time.Time {
+	limits, usage := counts(header.Get("X-RateLimit-Limit")), counts(header.Get("X-RateLimit-Usage"))
+	if len(limits) == 2 && len(usage) == 2 && usage[1] >= limits[1] {
+		year, month, day := now.UTC().Date()
+		return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+	}
+	return now.Truncate(rateLimitWindow).Add(rateLimitWindow)
+}
+
+func counts(value string) []int {
+	var parsed []int
+	for _, part := range strings.Split(value, ",") {
+		count, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil
+		}
+		parsed = append(parsed, count)
+	}
+	return parsed
+}
+
+// mapFaults maps the errors of every operation with faultError, and passes
+// the status and headers of every response to record, along with all of
+// its headers as the transport captured them and the times the request was
+// sent and its response received.
+func mapFaults(next Runner, record func(operation *runtime.ClientOperation, code int, header http.Header, response *headerCapture, sent, received time.Time)) Runner {
+	return func(operation *runtime.ClientOperation) (interface{}, error) {
+		reader := &headerReader{ClientResponseReader: operation.Reader}
+		capture := &headerCapture{}
+		wrapped := *operation
+		wrapped.Reader = reader
+		var cancel context.CancelFunc
+		wrapped.Context, cancel = withHeaderCapture(operation.Context, capture)
+		defer cancel()
+
+		sent := time.Now()
+		result, err := next(&wrapped)
+		now := time.Now()
+		if reader.header != nil && record != nil {
+			record(operation, reader.code, reader.header, capture, sent, now)
+		}
+		if err != nil {
+			return nil, faultError(operation.ID, err, reader.header, now)
+		}
+		return result, nil
+	}
+}
+
+// headerReader keeps the status and headers of the response, which the
+// generated client drops.
+type headerReader struct {
+	runtime.ClientResponseReader
+	code   int
+	header http.Header
+}
+
+func (r *headerReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	r.code = response.Code()
+	r.header = http.Header{}
+	for _, name := range []string{"X-RateLimit-Limit", "X-RateLimit-Usage", "Date", "X-Request-Id"} {
+		if value := response.GetHeader(name); value != "" {
+			r.header.Set(name, value)
+		}
+	}
+	return r.ClientResponseReader.ReadResponse(response, consumer)
+}
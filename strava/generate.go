@@ -0,0 +1,142 @@
+//go:build ignore
+// +build ignore
+
+// generate writes parameters.go, the parameters of the operations of the
+// API with their constraints, from swagger.json.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+)
+
+type swagger struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Parameters map[string]parameter                  `json:"parameters"`
+}
+
+type operation struct {
+	ID         string      `json:"operationId"`
+	Parameters []parameter `json:"parameters"`
+}
+
+type parameter struct {
+	Ref      string        `json:"$ref"`
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Type     string        `json:"type"`
+	Format   string        `json:"format"`
+	Required bool          `json:"required"`
+	Minimum  *float64      `json:"minimum"`
+	Maximum  *float64      `json:"maximum"`
+	MinItems *int          `json:"minItems"`
+	MaxItems *int          `json:"maxItems"`
+	Enum     []interface{} `json:"enum"`
+	Items    *parameter    `json:"items"`
+}
+
+var methods = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "patch": true}
+
+func main() {
+	data, err := ioutil.ReadFile("../swagger.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var spec swagger
+	if err := json.Unmarshal(data, &spec); err != nil {
+		log.Fatal(err)
+	}
+
+	var operations []operation
+	for path, byMethod := range spec.Paths {
+		for method, raw := range byMethod {
+			if !methods[method] {
+				continue
+			}
+			var op operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				log.Fatalf("Unable to read %s %s: %v", method, path, err)
+			}
+			operations = append(operations, op)
+		}
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].ID < operations[j].ID })
+
+	var source bytes.Buffer
+	source.WriteString("// Code generated by generate.go from swagger.json; DO NOT EDIT.\n\n")
+	source.WriteString("package strava\n\n")
+	source.WriteString("var parameters = map[string][]parameter{\n")
+	for _, op := range operations {
+		fmt.Fprintf(&source, "\t%q: {\n", op.ID)
+		for _, p := range op.Parameters {
+			if p.Ref != "" {
+				resolved, ok := spec.Parameters[strings.TrimPrefix(p.Ref, "#/parameters/")]
+				if !ok {
+					log.Fatalf("Unknown parameter %s of %s", p.Ref, op.ID)
+				}
+				p = resolved
+			}
+			// Bodies are validated by their models.
+			if p.In == "body" {
+				continue
+			}
+			fmt.Fprintf(&source, "\t\t{%s},\n", strings.Join(fields(p), ", "))
+		}
+		source.WriteString("\t},\n")
+	}
+	source.WriteString("}\n")
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("parameters.go", formatted, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// fields returns the fields of the literal of a parameter, leaving out
+// those without constraints.
+func fields(p parameter) []string {
+	result := []string{fmt.Sprintf("Name: %q", p.Name), fmt.Sprintf("In: %q", p.In), fmt.Sprintf("Type: %q", p.Type)}
+	enum := p.Enum
+	if p.Items != nil {
+		result = append(result, fmt.Sprintf("ItemType: %q", p.Items.Type))
+		enum = append(enum, p.Items.Enum...)
+		if p.Format == "" {
+			p.Format = p.Items.Format
+		}
+	}
+	if p.Format != "" {
+		result = append(result, fmt.Sprintf("Format: %q", p.Format))
+	}
+	if p.Required || p.In == "path" {
+		result = append(result, "Required: true")
+	}
+	if p.Minimum != nil {
+		result = append(result, fmt.Sprintf("Minimum: bound(%v)", *p.Minimum))
+	}
+	if p.Maximum != nil {
+		result = append(result, fmt.Sprintf("Maximum: bound(%v)", *p.Maximum))
+	}
+	if p.MinItems != nil {
+		result = append(result, fmt.Sprintf("MinItems: %d", *p.MinItems))
+	}
+	if p.MaxItems != nil {
+		result = append(result, fmt.Sprintf("MaxItems: %d", *p.MaxItems))
+	}
+	if len(enum) > 0 {
+		values := make([]string, len(enum))
+		for i, value := range enum {
+			values[i] = fmt.Sprint(value)
+		}
+		result = append(result, fmt.Sprintf("Enum: %#v", values))
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package strava
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	runtimeClient "github.com/go-openapi/runtime/client"
+)
+
+// redacted replaces the values of sensitive headers.
+const redacted = "REDACTED"
+
+// sensitiveHeaders are the headers whose values are redacted, along with
+// those whose names mention a token, a secret or a session.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// Redact returns a copy of header whose sensitive values, such as cookies
+// and tokens, are redacted, so that it can be printed or logged.
+func Redact(header http.Header) http.Header {
+	copied := make(http.Header, len(header))
+	for name, values := range header {
+		canonical := http.CanonicalHeaderKey(name)
+		lower := strings.ToLower(canonical)
+		if sensitiveHeaders[canonical] || strings.Contains(lower, "token") || strings.Contains(lower, "secret") || strings.Contains(lower, "session") {
+			values = []string{redacted}
+		}
+		copied[canonical] = append([]string(nil), values...)
+	}
+	return copied
+}
+
+// Select returns the headers of the exchange with the given names, or all
+// of them when names is empty or holds *.
+func (e Exchange) Select(names []string) http.Header {
+	for _, name := range names {
+		if name == "*" {
+			return e.Header
+		}
+	}
+	if len(names) == 0 {
+		return e.Header
+	}
+	selected := http.Header{}
+	for _, name := range names {
+		if values, ok := e.Header[http.CanonicalHeaderKey(name)]; ok {
+			selected[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return selected
+}
+
+// PrintHeaders returns an observer printing the status line and the
+// headers with the given names of each response to writer, as curl -i
+// does, or all of them when names is empty or holds *.
+func PrintHeaders(writer io.Writer, names []string) func(Exchange) {
+	return func(exchange Exchange) {
+		header := exchange.Select(names)
+		keys := make([]string, 0, len(header))
+		for name := range header {
+			keys = append(keys, name)
+		}
+		sort.Strings(keys)
+
+		var builder strings.Builder
+		fmt.Fprintf(&builder, "< %s %d %s (%s)\n", exchange.Proto, exchange.Status, http.StatusText(exchange.Status), exchange.Operation)
+		for _, name := range keys {
+			for _, value := range header[name] {
+				fmt.Fprintf(&builder, "< %s: %s\n", name, value)
+			}
+		}
+		io.WriteString(writer, builder.String())
+	}
+}
+
+// headerCapture receives the protocol and headers of a response, which the
+// runtime only exposes one by one, from headerTransport.
+type headerCapture struct {
+	proto  string
+	header http.Header
+}
+
+type headerCaptureKey struct{}
+
+// withHeaderCapture returns ctx with capture to receive the headers of the
+// response to the request it sends. The runtime only applies its default
+// timeout to the operations without a context, which a nil ctx is given
+// instead.
+func withHeaderCapture(ctx context.Context, capture *headerCapture) (context.Context, context.CancelFunc) {
+	cancel := func() {}
+	if ctx == nil {
+		ctx, cancel = context.WithTimeout(context.Background(), runtimeClient.DefaultTimeout)
+	}
+	return context.WithValue(ctx, headerCaptureKey{}, capture), cancel
+}
+
+// headerTransport passes the headers of each response to the capture of
+// the context of its request, if any, redacted.
+type headerTransport struct {
+	base http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.base.RoundTrip(request)
+	if capture, ok := request.Context().Value(headerCaptureKey{}).(*headerCapture); ok && response != nil {
+		capture.proto, capture.header = response.Proto, Redact(response.Header)
+	}
+	return response, err
+}
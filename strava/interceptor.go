@@ -0,0 +1,65 @@
+package strava
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/runtime"
+)
+
+// Runner submits an operation of the API and returns its result.
+type Runner func(operation *runtime.ClientOperation) (interface{}, error)
+
+// Interceptor wraps the runner of every operation, for instance to log,
+// measure, cache or retry them. It calls next to submit the operation.
+type Interceptor func(next Runner) Runner
+
+// chainTransport submits operations through the interceptors of a client.
+// Faults are mapped to errors before any interceptor sees them.
+type chainTransport struct {
+	transport    runtime.ClientTransport
+	interceptors []Interceptor
+
+	mutex     sync.Mutex
+	usage     *Usage
+	observers []func(Exchange)
+}
+
+func (t *chainTransport) Submit(operation *runtime.ClientOperation) (interface{}, error) {
+	// Invalid requests are rejected before the interceptors, which would
+	// count them against the budget.
+	if err := validate(operation); err != nil {
+		return nil, err
+	}
+	run := mapFaults(t.transport.Submit, t.record)
+	for i := len(t.interceptors) - 1; i >= 0; i-- {
+		run = t.interceptors[i](run)
+	}
+	return run(operation)
+}
+
+// Use adds interceptors to the client, which apply to the services and to
+// the generated client alike. The first interceptor added is the outermost
+// one. Use is not safe to call while requests are in flight.
+func (c *Client) Use(interceptors ...Interceptor) {
+	c.transport.interceptors = append(c.transport.interceptors, interceptors...)
+}
+
+// Verbose logs each operation with its outcome and duration.
+func Verbose(writer io.Writer) Interceptor {
+	return func(next Runner) Runner {
+		return func(operation *runtime.ClientOperation) (interface{}, error) {
+			start := time.Now()
+			result, err := next(operation)
+
+			outcome := "ok"
+			if err != nil {
+				outcome = err.Error()
+			}
+			fmt.Fprintf(writer, "%s %s (%s): %s in %s\n", operation.Method, operation.PathPattern, operation.ID, outcome, time.Since(start).Round(time.Millisecond))
+			return result, err
+		}
+	}
+}
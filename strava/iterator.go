@@ -0,0 +1,357 @@
+package strava
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jsilland/sutro/models"
+)
+
+// maxPerPage is the largest page the API serves.
+const maxPerPage = 200
+
+// rateLimitWindow is the period over which Strava counts requests for its
+// short term rate limit. Windows start on the quarter hour.
+const rateLimitWindow = 15 * time.Minute
+
+// ListOptions select the items of paged endpoints.
+type ListOptions struct {
+	// After and Before, when set, bound the start of the listed activities.
+	After  time.Time
+	Before time.Time
+	// PerPage is the number of items fetched by each request, which
+	// defaults to the maximum of 200.
+	PerPage int
+}
+
+func (o ListOptions) perPage() int64 {
+	if o.PerPage <= 0 || o.PerPage > maxPerPage {
+		return maxPerPage
+	}
+	return int64(o.PerPage)
+}
+
+// pager keeps track of the position of an iterator within paged results.
+// Its exported methods are shared by all iterators.
+type pager struct {
+	ctx         context.Context
+	perPage     int64
+	page        int64
+	index       int
+	size        int
+	done        bool
+	err         error
+	onRateLimit func(resume time.Time) bool
+}
+
+func newPager(ctx context.Context, perPage *int64) pager {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	size := int64(30)
+	if perPage != nil && *perPage > 0 {
+		size = *perPage
+	}
+	return pager{ctx: ctx, perPage: size}
+}
+
+// OnRateLimit sets the function called when a request exceeds the rate
+// limit, with the time at which the limit resets. The iterator waits
+// for it and retries when handler returns true, and stops with the rate
+// limit error otherwise. Without a handler, iterators wait.
+func (p *pager) OnRateLimit(handler func(resume time.Time) bool) {
+	p.onRateLimit = handler
+}
+
+// Page returns the number of the last page fetched.
+func (p *pager) Page() int64 {
+	return p.page
+}
+
+// advance moves to the next item, calling load for the next page once the
+// current one is exhausted. load returns the number of items on the page.
+func (p *pager) advance(load func(page, perPage int64) (int, error)) bool {
+	if p.err != nil {
+		return false
+	}
+
+	p.index++
+	if p.index < p.size {
+		return true
+	}
+	if p.done {
+		return false
+	}
+
+	for {
+		if err := p.ctx.Err(); err != nil {
+			p.err = err
+			return false
+		}
+
+		size, err := load(p.page+1, p.perPage)
+		if err == nil {
+			p.page++
+			// A short page is the last one, which saves requesting an empty
+			// page.
+			p.index, p.size, p.done = 0, size, int64(size) < p.perPage
+			return size > 0
+		}
+		if !p.wait(err) {
+			p.err = err
+			return false
+		}
+	}
+}
+
+// wait blocks until the rate limit resets when err is a rate limit error,
+// and reports whether the request should be retried.
+func (p *pager) wait(err error) bool {
+	var limited *RateLimitError
+	if !errors.As(err, &limited) {
+		return false
+	}
+
+	resume := limited.Reset
+	if p.onRateLimit != nil && !p.onRateLimit(resume) {
+		return false
+	}
+
+	timer := time.NewTimer(time.Until(resume))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-p.ctx.Done():
+		return false
+	}
+}
+
+// ActivityIterator iterates over paged activities, fetching pages as needed.
+type ActivityIterator struct {
+	pager
+	fetch func(page, perPage int64) ([]*models.SummaryActivity, error)
+	items []*models.SummaryActivity
+}
+
+// Next advances to the next activity, and returns false at the end or on
+// error.
+func (it *ActivityIterator) Next() bool {
+	return it.advance(func(page, perPage int64) (int, error) {
+		items, err := it.fetch(page, perPage)
+		if err == nil {
+			it.items = items
+		}
+		return len(items), err
+	})
+}
+
+// Value returns the current activity.
+func (it *ActivityIterator) Value() *models.SummaryActivity {
+	return it.items[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ActivityIterator) Err() error {
+	return it.err
+}
+
+// All returns the remaining activities.
+func (it *ActivityIterator) All() ([]*models.SummaryActivity, error) {
+	var all []*models.SummaryActivity
+	for it.Next() {
+		all = append(all, it.Value())
+	}
+	return all, it.Err()
+}
+
+// AthleteIterator iterates over paged athletes, fetching pages as needed.
+type AthleteIterator struct {
+	pager
+	fetch func(page, perPage int64) ([]*models.SummaryAthlete, error)
+	items []*models.SummaryAthlete
+}
+
+// Next advances to the next athlete, and returns false at the end or on
+// error.
+func (it *AthleteIterator) Next() bool {
+	return it.advance(func(page, perPage int64) (int, error) {
+		items, err := it.fetch(page, perPage)
+		if err == nil {
+			it.items = items
+		}
+		return len(items), err
+	})
+}
+
+// Value returns the current athlete.
+func (it *AthleteIterator) Value() *models.SummaryAthlete {
+	return it.items[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *AthleteIterator) Err() error {
+	return it.err
+}
+
+// All returns the remaining athletes.
+func (it *AthleteIterator) All() ([]*models.SummaryAthlete, error) {
+	var all []*models.SummaryAthlete
+	for it.Next() {
+		all = append(all, it.Value())
+	}
+	return all, it.Err()
+}
+
+// ClubIterator iterates over paged clubs, fetching pages as needed.
+type ClubIterator struct {
+	pager
+	fetch func(page, perPage int64) ([]*models.SummaryClub, error)
+	items []*models.SummaryClub
+}
+
+// Next advances to the next club, and returns false at the end or on
+// error.
+func (it *ClubIterator) Next() bool {
+	return it.advance(func(page, perPage int64) (int, error) {
+		items, err := it.fetch(page, perPage)
+		if err == nil {
+			it.items = items
+		}
+		return len(items), err
+	})
+}
+
+// Value returns the current club.
+func (it *ClubIterator) Value() *models.SummaryClub {
+	return it.items[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ClubIterator) Err() error {
+	return it.err
+}
+
+// All returns the remaining clubs.
+func (it *ClubIterator) All() ([]*models.SummaryClub, error) {
+	var all []*models.SummaryClub
+	for it.Next() {
+		all = append(all, it.Value())
+	}
+	return all, it.Err()
+}
+
+// CommentIterator iterates over paged comments, fetching pages as needed.
+type CommentIterator struct {
+	pager
+	fetch func(page, perPage int64) ([]*models.Comment, error)
+	items []*models.Comment
+}
+
+// Next advances to the next comment, and returns false at the end or on
+// error.
+func (it *CommentIterator) Next() bool {
+	return it.advance(func(page, perPage int64) (int, error) {
+		items, err := it.fetch(page, perPage)
+		if err == nil {
+			it.items = items
+		}
+		return len(items), err
+	})
+}
+
+// Value returns the current comment.
+func (it *CommentIterator) Value() *models.Comment {
+	return it.items[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *CommentIterator) Err() error {
+	return it.err
+}
+
+// All returns the remaining comments.
+func (it *CommentIterator) All() ([]*models.Comment, error) {
+	var all []*models.Comment
+	for it.Next() {
+		all = append(all, it.Value())
+	}
+	return all, it.Err()
+}
+
+// RouteIterator iterates over paged routes, fetching pages as needed.
+type RouteIterator struct {
+	pager
+	fetch func(page, perPage int64) ([]*models.Route, error)
+	items []*models.Route
+}
+
+// Next advances to the next route, and returns false at the end or on
+// error.
+func (it *RouteIterator) Next() bool {
+	return it.advance(func(page, perPage int64) (int, error) {
+		items, err := it.fetch(page, perPage)
+		if err == nil {
+			it.items = items
+		}
+		return len(items), err
+	})
+}
+
+// Value returns the current route.
+func (it *RouteIterator) Value() *models.Route {
+	return it.items[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *RouteIterator) Err() error {
+	return it.err
+}
+
+// All returns the remaining routes.
+func (it *RouteIterator) All() ([]*models.Route, error) {
+	var all []*models.Route
+	for it.Next() {
+		all = append(all, it.Value())
+	}
+	return all, it.Err()
+}
+
+// SegmentIterator iterates over paged segments, fetching pages as needed.
+type SegmentIterator struct {
+	pager
+	fetch func(page, perPage int64) ([]*models.SummarySegment, error)
+	items []*models.SummarySegment
+}
+
+// Next advances to the next segment, and returns false at the end or on
+// error.
+func (it *SegmentIterator) Next() bool {
+	return it.advance(func(page, perPage int64) (int, error) {
+		items, err := it.fetch(page, perPage)
+		if err == nil {
+			it.items = items
+		}
+		return len(items), err
+	})
+}
+
+// Value returns the current segment.
+func (it *SegmentIterator) Value() *models.SummarySegment {
+	return it.items[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *SegmentIterator) Err() error {
+	return it.err
+}
+
+// All returns the remaining segments.
+func (it *SegmentIterator) All() ([]*models.SummarySegment, error) {
+	var all []*models.SummarySegment
+	for it.Next() {
+		all = append(all, it.Value())
+	}
+	return all, it.Err()
+}
@@ -0,0 +1,124 @@
+package strava
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+)
+
+// Mutation is an operation changing the data of the athlete, such as the
+// update of an activity or an upload.
+type Mutation struct {
+	// Operation is the id of the operation in swagger.json.
+	Operation string
+	Method    string
+	// Path is the path of the operation with its parameters, such as
+	// /activities/1234.
+	Path string
+	// Resource is the id of the resource changed, or of the one created
+	// when the request names none.
+	Resource string
+	// Digest is the SHA-256 digest of the parameters and body sent, which
+	// tells identical changes apart from different ones without keeping
+	// their content.
+	Digest string
+}
+
+// Mutations returns an interceptor calling record with each operation that
+// changes data once it returned, with its error. Requests that were not
+// sent, as a budget did not allow them, are not recorded.
+func Mutations(record func(Mutation, error)) Interceptor {
+	return func(next Runner) Runner {
+		return func(operation *runtime.ClientOperation) (interface{}, error) {
+			if operation.Method == http.MethodGet || operation.Method == http.MethodHead {
+				return next(operation)
+			}
+
+			mutation := Mutation{Operation: operation.ID, Method: operation.Method, Path: operation.PathPattern}
+			request := newRecordingRequest(operation.Method, operation.PathPattern)
+			if operation.Params != nil && operation.Params.WriteToRequest(request, strfmt.Default) == nil {
+				mutation.Path, mutation.Resource = request.expandedPath()
+				mutation.Digest = request.digest()
+			}
+
+			result, err := next(operation)
+			if errors.Is(err, ErrBudgetExhausted) {
+				return result, err
+			}
+			if mutation.Resource == "" && err == nil {
+				mutation.Resource = createdID(result)
+			}
+			record(mutation, err)
+			return result, err
+		}
+	}
+}
+
+// expandedPath returns the path of the request with its parameters, and the
+// last of them, which names the resource of the operation.
+func (r *recordingRequest) expandedPath() (string, string) {
+	path, resource := r.path, ""
+	for _, segment := range strings.Split(r.path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if value, ok := r.params[strings.Trim(segment, "{}")]; ok {
+				path = strings.Replace(path, segment, value, 1)
+				resource = value
+			}
+		}
+	}
+	return path, resource
+}
+
+// digest returns the SHA-256 digest of the query, form, files and body of
+// the request. Files are only digested by name.
+func (r *recordingRequest) digest() string {
+	files := make([]string, 0, len(r.files))
+	for name, named := range r.files {
+		for _, file := range named {
+			files = append(files, name+"="+file.Name())
+		}
+	}
+	sort.Strings(files)
+	encoded, err := json.Marshal(struct {
+		Query url.Values  `json:"query,omitempty"`
+		Form  url.Values  `json:"form,omitempty"`
+		Files []string    `json:"files,omitempty"`
+		Body  interface{} `json:"body,omitempty"`
+	}{r.query, r.form, files, r.body})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// createdID returns the id of the resource an operation responded with,
+// such as the upload it created, or an empty string.
+func createdID(result interface{}) string {
+	value := reflect.Indirect(reflect.ValueOf(result))
+	if value.Kind() != reflect.Struct {
+		return ""
+	}
+	payload := reflect.Indirect(value.FieldByName("Payload"))
+	if payload.Kind() != reflect.Struct {
+		return ""
+	}
+	id := payload.FieldByName("ID")
+	switch id.Kind() {
+	case reflect.Int64, reflect.Int:
+		return strconv.FormatInt(id.Int(), 10)
+	case reflect.String:
+		return id.String()
+	}
+	return ""
+}
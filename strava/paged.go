@@ -0,0 +1,158 @@
+package strava
+
+import (
+	"github.com/jsilland/sutro/client"
+	"github.com/jsilland/sutro/client/activities"
+	"github.com/jsilland/sutro/client/clubs"
+	"github.com/jsilland/sutro/client/routes"
+	"github.com/jsilland/sutro/client/segments"
+	"github.com/jsilland/sutro/models"
+)
+
+// The constructors below iterate over every paged endpoint of the API. They
+// take the parameters of the generated client, whose context bounds the
+// iteration and whose page size is kept, and set the page of each request.
+
+// NewActivityIterator iterates over the activities of the authenticated athlete, from the most recent.
+func NewActivityIterator(api *client.StravaAPIV3, params *activities.GetLoggedInAthleteActivitiesParams) *ActivityIterator {
+	return &ActivityIterator{
+		pager: newPager(params.Context, params.PerPage),
+		fetch: func(page, perPage int64) ([]*models.SummaryActivity, error) {
+			params.SetPage(&page)
+			params.SetPerPage(&perPage)
+			response, err := api.Activities.GetLoggedInAthleteActivities(params, nil)
+			if err != nil {
+				return nil, err
+			}
+			return response.Payload, nil
+		},
+	}
+}
+
+// NewClubActivityIterator iterates over the recent activities of the members of a club.
+func NewClubActivityIterator(api *client.StravaAPIV3, params *clubs.GetClubActivitiesByIDParams) *ActivityIterator {
+	return &ActivityIterator{
+		pager: newPager(params.Context, params.PerPage),
+		fetch: func(page, perPage int64) ([]*models.SummaryActivity, error) {
+			params.SetPage(&page)
+			params.SetPerPage(&perPage)
+			response, err := api.Clubs.GetClubActivitiesByID(params, nil)
+			if err != nil {
+				return nil, err
+			}
+			return response.Payload, nil
+		},
+	}
+}
+
+// NewCommentIterator iterates over the comments on an activity.
+func NewCommentIterator(api *client.StravaAPIV3, params *activities.GetCommentsByActivityIDParams) *CommentIterator {
+	return &CommentIterator{
+		pager: newPager(params.Context, params.PerPage),
+		fetch: func(page, perPage int64) ([]*models.Comment, error) {
+			params.SetPage(&page)
+			params.SetPerPage(&perPage)
+			response, err := api.Activities.GetCommentsByActivityID(params, nil)
+			if err != nil {
+				return nil, err
+			}
+			return response.Payload, nil
+		},
+	}
+}
+
+// NewKudoerIterator iterates over the athletes who gave kudos to an activity.
+func NewKudoerIterator(api *client.StravaAPIV3, params *activities.GetKudoersByActivityIDParams) *AthleteIterator {
+	return &AthleteIterator{
+		pager: newPager(params.Context, params.PerPage),
+		fetch: func(page, perPage int64) ([]*models.SummaryAthlete, error) {
+			params.SetPage(&page)
+			params.SetPerPage(&perPage)
+			response, err := api.Activities.GetKudoersByActivityID(params, nil)
+			if err != nil {
+				return nil, err
+			}
+			return response.Payload, nil
+		},
+	}
+}
+
+// NewClubAdminIterator iterates over the administrators of a club.
+func NewClubAdminIterator(api *client.StravaAPIV3, params *clubs.GetClubAdminsByIDParams) *AthleteIterator {
+	return &AthleteIterator{
+		pager: newPager(params.Context, params.PerPage),
+		fetch: func(page, perPage int64) ([]*models.SummaryAthlete, error) {
+			params.SetPage(&page)
+			params.SetPerPage(&perPage)
+			response, err := api.Clubs.GetClubAdminsByID(params, nil)
+			if err != nil {
+				return nil, err
+			}
+			return response.Payload, nil
+		},
+	}
+}
+
+// NewClubMemberIterator iterates over the members of a club.
+func NewClubMemberIterator(api *client.StravaAPIV3, params *clubs.GetClubMembersByIDParams) *AthleteIterator {
+	return &AthleteIterator{
+		pager: newPager(params.Context, params.PerPage),
+		fetch: func(page, perPage int64) ([]*models.SummaryAthlete, error) {
+			params.SetPage(&page)
+			params.SetPerPage(&perPage)
+			response, err := api.Clubs.GetClubMembersByID(params, nil)
+			if err != nil {
+				return nil, err
+			}
+			return response.Payload, nil
+		},
+	}
+}
+
+// NewClubIterator iterates over the clubs of the authenticated athlete.
+func NewClubIterator(api *client.StravaAPIV3, params *clubs.GetLoggedInAthleteClubsParams) *ClubIterator {
+	return &ClubIterator{
+		pager: newPager(params.Context, params.PerPage),
+		fetch: func(page, perPage int64) ([]*models.SummaryClub, error) {
+			params.SetPage(&page)
+			params.SetPerPage(&perPage)
+			response, err := api.Clubs.GetLoggedInAthleteClubs(params, nil)
+			if err != nil {
+				return nil, err
+			}
+			return response.Payload, nil
+		},
+	}
+}
+
+// NewRouteIterator iterates over the routes of an athlete.
+func NewRouteIterator(api *client.StravaAPIV3, params *routes.GetRoutesByAthleteIDParams) *RouteIterator {
+	return &RouteIterator{
+		pager: newPager(params.Context, params.PerPage),
+		fetch: func(page, perPage int64) ([]*models.Route, error) {
+			params.SetPage(&page)
+			params.SetPerPage(&perPage)
+			response, err := api.Routes.GetRoutesByAthleteID(params, nil)
+			if err != nil {
+				return nil, err
+			}
+			return response.Payload, nil
+		},
+	}
+}
+
+// NewStarredSegmentIterator iterates over the segments starred by the authenticated athlete.
+func NewStarredSegmentIterator(api *client.StravaAPIV3, params *segments.GetLoggedInAthleteStarredSegmentsParams) *SegmentIterator {
+	return &SegmentIterator{
+		pager: newPager(params.Context, params.PerPage),
+		fetch: func(page, perPage int64) ([]*models.SummarySegment, error) {
+			params.SetPage(&page)
+			params.SetPerPage(&perPage)
+			response, err := api.Segments.GetLoggedInAthleteStarredSegments(params, nil)
+			if err != nil {
+				return nil, err
+			}
+			return response.Payload, nil
+		},
+	}
+}
@@ -0,0 +1,157 @@
+// Code generated by generate.go from swagger.json; DO NOT EDIT.
+
+package strava
+
+var parameters = map[string][]parameter{
+	"createActivity": {
+		{Name: "name", In: "formData", Type: "string", Required: true},
+		{Name: "type", In: "formData", Type: "string", Required: true},
+		{Name: "start_date_local", In: "formData", Type: "string", Format: "date-time", Required: true},
+		{Name: "elapsed_time", In: "formData", Type: "integer", Required: true},
+		{Name: "description", In: "formData", Type: "string"},
+		{Name: "distance", In: "formData", Type: "number", Format: "float"},
+		{Name: "trainer", In: "formData", Type: "integer"},
+		{Name: "commute", In: "formData", Type: "integer"},
+	},
+	"createUpload": {
+		{Name: "file", In: "formData", Type: "file"},
+		{Name: "name", In: "formData", Type: "string"},
+		{Name: "description", In: "formData", Type: "string"},
+		{Name: "trainer", In: "formData", Type: "string"},
+		{Name: "commute", In: "formData", Type: "string"},
+		{Name: "data_type", In: "formData", Type: "string", Enum: []string{"fit", "fit.gz", "tcx", "tcx.gz", "gpx", "gpx.gz"}},
+		{Name: "external_id", In: "formData", Type: "string"},
+	},
+	"exploreSegments": {
+		{Name: "bounds", In: "query", Type: "array", ItemType: "number", Format: "float", Required: true, MinItems: 4, MaxItems: 4},
+		{Name: "activity_type", In: "query", Type: "string", Enum: []string{"running", "riding"}},
+		{Name: "min_cat", In: "query", Type: "integer", Minimum: bound(0), Maximum: bound(5)},
+		{Name: "max_cat", In: "query", Type: "integer", Minimum: bound(0), Maximum: bound(5)},
+	},
+	"getActivityById": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "include_all_efforts", In: "query", Type: "boolean"},
+	},
+	"getActivityStreams": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "keys", In: "query", Type: "array", ItemType: "string", Required: true, MinItems: 1, Enum: []string{"time", "distance", "latlng", "altitude", "velocity_smooth", "heartrate", "cadence", "watts", "temp", "moving", "grade_smooth"}},
+		{Name: "key_by_type", In: "query", Type: "boolean", Required: true},
+	},
+	"getClubActivitiesById": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "page", In: "query", Type: "integer"},
+		{Name: "per_page", In: "query", Type: "integer"},
+	},
+	"getClubAdminsById": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "page", In: "query", Type: "integer"},
+		{Name: "per_page", In: "query", Type: "integer"},
+	},
+	"getClubById": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getClubMembersById": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "page", In: "query", Type: "integer"},
+		{Name: "per_page", In: "query", Type: "integer"},
+	},
+	"getCommentsByActivityId": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "page", In: "query", Type: "integer"},
+		{Name: "per_page", In: "query", Type: "integer"},
+	},
+	"getEffortsBySegmentId": {
+		{Name: "segment_id", In: "query", Type: "integer", Required: true},
+		{Name: "start_date_local", In: "query", Type: "string", Format: "date-time"},
+		{Name: "end_date_local", In: "query", Type: "string", Format: "date-time"},
+		{Name: "per_page", In: "query", Type: "integer"},
+	},
+	"getGearById": {
+		{Name: "id", In: "path", Type: "string", Required: true},
+	},
+	"getKudoersByActivityId": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "page", In: "query", Type: "integer"},
+		{Name: "per_page", In: "query", Type: "integer"},
+	},
+	"getLapsByActivityId": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getLoggedInAthlete": {},
+	"getLoggedInAthleteActivities": {
+		{Name: "before", In: "query", Type: "integer"},
+		{Name: "after", In: "query", Type: "integer"},
+		{Name: "page", In: "query", Type: "integer"},
+		{Name: "per_page", In: "query", Type: "integer"},
+	},
+	"getLoggedInAthleteClubs": {
+		{Name: "page", In: "query", Type: "integer"},
+		{Name: "per_page", In: "query", Type: "integer"},
+	},
+	"getLoggedInAthleteStarredSegments": {
+		{Name: "page", In: "query", Type: "integer"},
+		{Name: "per_page", In: "query", Type: "integer"},
+	},
+	"getLoggedInAthleteZones": {},
+	"getPhotosByActivityId": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "size", In: "query", Type: "integer"},
+		{Name: "photo_sources", In: "query", Type: "boolean"},
+	},
+	"getRouteAsGPX": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getRouteAsTCX": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getRouteById": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getRouteStreams": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getRoutesByAthleteId": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "page", In: "query", Type: "integer"},
+		{Name: "per_page", In: "query", Type: "integer"},
+	},
+	"getRunningRaceById": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getRunningRaces": {
+		{Name: "year", In: "query", Type: "integer"},
+	},
+	"getSegmentById": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getSegmentEffortById": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getSegmentEffortStreams": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "keys", In: "query", Type: "array", ItemType: "string", Required: true, MinItems: 1, Enum: []string{"time", "distance", "latlng", "altitude", "velocity_smooth", "heartrate", "cadence", "watts", "temp", "moving", "grade_smooth"}},
+		{Name: "key_by_type", In: "query", Type: "boolean", Required: true},
+	},
+	"getSegmentStreams": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "keys", In: "query", Type: "array", ItemType: "string", Required: true, MinItems: 1, Enum: []string{"distance", "latlng", "altitude"}},
+		{Name: "key_by_type", In: "query", Type: "boolean", Required: true},
+	},
+	"getStats": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getUploadById": {
+		{Name: "uploadId", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"getZonesByActivityId": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"starSegment": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+		{Name: "starred", In: "formData", Type: "boolean", Required: true},
+	},
+	"updateActivityById": {
+		{Name: "id", In: "path", Type: "integer", Format: "int64", Required: true},
+	},
+	"updateLoggedInAthlete": {},
+}
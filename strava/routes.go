@@ -0,0 +1,93 @@
+package strava
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+	"github.com/jsilland/sutro/client"
+	"github.com/jsilland/sutro/client/routes"
+	"github.com/jsilland/sutro/models"
+)
+
+// RoutesService reads the routes of athletes.
+type RoutesService struct {
+	api       *client.StravaAPIV3
+	transport runtime.ClientTransport
+}
+
+// Get returns the route with the given id.
+func (s *RoutesService) Get(ctx context.Context, id int64) (*models.Route, error) {
+	response, err := s.api.Routes.GetRouteByID(routes.NewGetRouteByIDParamsWithContext(ctx).WithID(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.Payload == nil {
+		return nil, fmt.Errorf("Failed to obtain route %d from the API", id)
+	}
+	return response.Payload, nil
+}
+
+// Formats of the files routes are exported to.
+const (
+	RouteGPX = "gpx"
+	RouteTCX = "tcx"
+)
+
+// Export writes the route with the given id to writer as a GPX or TCX file,
+// as it is received, and returns the number of bytes written.
+func (s *RoutesService) Export(ctx context.Context, id int64, format string, writer io.Writer) (int64, error) {
+	operation := &runtime.ClientOperation{
+		Method:             http.MethodGet,
+		ConsumesMediaTypes: []string{runtime.JSONMime},
+		Schemes:            []string{"https"},
+		Context:            ctx,
+	}
+	switch format {
+	case RouteGPX:
+		operation.ID = "getRouteAsGPX"
+		operation.PathPattern = "/routes/{id}/export_gpx"
+		operation.ProducesMediaTypes = []string{"application/gpx+xml", runtime.JSONMime}
+		operation.Params = routes.NewGetRouteAsGPXParamsWithContext(ctx).WithID(id)
+		operation.Reader = &fileReader{writer: writer, faults: &routes.GetRouteAsGPXReader{}}
+	case RouteTCX:
+		operation.ID = "getRouteAsTCX"
+		operation.PathPattern = "/routes/{id}/export_tcx"
+		operation.ProducesMediaTypes = []string{"application/vnd.garmin.tcx+xml", runtime.JSONMime}
+		operation.Params = routes.NewGetRouteAsTCXParamsWithContext(ctx).WithID(id)
+		operation.Reader = &fileReader{writer: writer, faults: &routes.GetRouteAsTCXReader{}}
+	default:
+		return 0, fmt.Errorf("Unknown route format %q, expected %s or %s", format, RouteGPX, RouteTCX)
+	}
+
+	result, err := s.transport.Submit(operation)
+	if err != nil {
+		return 0, err
+	}
+	written, ok := result.(int64)
+	if !ok {
+		return 0, fmt.Errorf("Strava did not return the %s file of route %d", format, id)
+	}
+	return written, nil
+}
+
+// fileReader copies successful responses, which are files rather than
+// JSON, to writer. The generated client reads them as empty responses.
+type fileReader struct {
+	writer io.Writer
+	// faults reads the other responses, which are faults.
+	faults runtime.ClientResponseReader
+}
+
+func (r *fileReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	if response.Code() != http.StatusOK {
+		return r.faults.ReadResponse(response, consumer)
+	}
+	written, err := io.Copy(r.writer, response.Body())
+	if err != nil {
+		return nil, err
+	}
+	return written, nil
+}
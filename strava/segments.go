@@ -0,0 +1,171 @@
+package strava
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/jsilland/sutro/client"
+	"github.com/jsilland/sutro/client/segment_efforts"
+	"github.com/jsilland/sutro/client/segments"
+	"github.com/jsilland/sutro/models"
+)
+
+// SegmentsService reads segments and the efforts of the authenticated
+// athlete on them.
+type SegmentsService struct {
+	api       *client.StravaAPIV3
+	transport runtime.ClientTransport
+}
+
+// Segment is a segment along with what the API returns beyond the schema
+// of swagger.json.
+type Segment struct {
+	models.DetailedSegment
+	// Xoms are the times of the leaders of the segment, if the API
+	// reports them.
+	Xoms *Xoms
+	// AthleteStats are the efforts of the authenticated athlete on the
+	// segment, if the API reports them.
+	AthleteStats *AthleteSegmentStats
+}
+
+// Xoms are the times of the leaders of a segment, such as 5:32 or 48s.
+type Xoms struct {
+	KOM     string `json:"kom"`
+	QOM     string `json:"qom"`
+	Overall string `json:"overall"`
+}
+
+// AthleteSegmentStats are the efforts of an athlete on a segment.
+type AthleteSegmentStats struct {
+	// PRElapsedTime is the time of the best effort, in seconds.
+	PRElapsedTime int64  `json:"pr_elapsed_time"`
+	PRDate        string `json:"pr_date"`
+	EffortCount   int64  `json:"effort_count"`
+}
+
+// Starred iterates over the segments starred by the authenticated athlete.
+func (s *SegmentsService) Starred(ctx context.Context, options ListOptions) *SegmentIterator {
+	perPage := options.perPage()
+	return NewStarredSegmentIterator(s.api, segments.NewGetLoggedInAthleteStarredSegmentsParamsWithContext(ctx).WithPerPage(&perPage))
+}
+
+// Get returns the segment with the given id, with its leaders.
+func (s *SegmentsService) Get(ctx context.Context, id int64) (*Segment, error) {
+	result, err := s.transport.Submit(&runtime.ClientOperation{
+		ID:                 "getSegmentById",
+		Method:             http.MethodGet,
+		PathPattern:        "/segments/{id}",
+		ProducesMediaTypes: []string{runtime.JSONMime},
+		ConsumesMediaTypes: []string{runtime.JSONMime},
+		Schemes:            []string{"https"},
+		Params:             segments.NewGetSegmentByIDParamsWithContext(ctx).WithID(id),
+		Reader:             &segmentReader{faults: &segments.GetSegmentByIDReader{}},
+		Context:            ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	segment, ok := result.(*Segment)
+	if !ok {
+		return nil, fmt.Errorf("Failed to obtain segment %d from the API", id)
+	}
+	return segment, nil
+}
+
+// firstEffort bounds the efforts listed without a start date, as Strava
+// has none before it opened.
+var firstEffort = time.Date(2009, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Efforts returns the efforts of the authenticated athlete on a segment that
+// started within the bounds of options on the local clock, in no particular
+// order. The endpoint has no pages, so the bounds are split in halves until
+// each fits in a response.
+func (s *SegmentsService) Efforts(ctx context.Context, id int64, options ListOptions) ([]*models.DetailedSegmentEffort, error) {
+	after, before := options.After, options.Before
+	if after.IsZero() {
+		after = firstEffort
+	}
+	if before.IsZero() {
+		// Local clocks are up to 14 hours ahead of UTC.
+		before = time.Now().Add(14 * time.Hour)
+	}
+
+	perPage := options.perPage()
+	seen := map[int64]bool{}
+	var all []*models.DetailedSegmentEffort
+	var fetch func(after, before time.Time) error
+	fetch = func(after, before time.Time) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start, end := strfmt.DateTime(after), strfmt.DateTime(before)
+		params := segment_efforts.NewGetEffortsBySegmentIDParamsWithContext(ctx).
+			WithSegmentID(id).
+			WithStartDateLocal(&start).
+			WithEndDateLocal(&end).
+			WithPerPage(&perPage)
+		response, err := s.api.SegmentEfforts.GetEffortsBySegmentID(params, nil)
+		if err != nil {
+			return err
+		}
+		if int64(len(response.Payload)) >= perPage && before.Sub(after) > time.Minute {
+			middle := after.Add(before.Sub(after) / 2)
+			if err := fetch(after, middle); err != nil {
+				return err
+			}
+			return fetch(middle, before)
+		}
+		for _, effort := range response.Payload {
+			if !seen[effort.ID] {
+				seen[effort.ID] = true
+				all = append(all, effort)
+			}
+		}
+		return nil
+	}
+
+	if err := fetch(after, before); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// segmentReader decodes segments along with the fields that the generated
+// models drop.
+type segmentReader struct {
+	// faults reads the other responses, which are faults.
+	faults runtime.ClientResponseReader
+}
+
+func (r *segmentReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	if response.Code() != http.StatusOK {
+		return r.faults.ReadResponse(response, consumer)
+	}
+	data, err := ioutil.ReadAll(response.Body())
+	if err != nil {
+		return nil, err
+	}
+
+	// The models decode themselves, which would ignore the other fields of
+	// a struct embedding them.
+	var segment Segment
+	if err := json.Unmarshal(data, &segment.DetailedSegment); err != nil {
+		return nil, err
+	}
+	var extra struct {
+		Xoms         *Xoms                `json:"xoms"`
+		AthleteStats *AthleteSegmentStats `json:"athlete_segment_stats"`
+	}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, err
+	}
+	segment.Xoms, segment.AthleteStats = extra.Xoms, extra.AthleteStats
+	return &segment, nil
+}
@@ -0,0 +1,124 @@
+// Package strava is a client for the Strava API. It wraps the client
+// generated from swagger.json with services for the most common operations
+// and iterators over paged endpoints, and is independent of the sutro
+// command line so that other programs can embed it.
+//
+// A client is built from an HTTP client that authenticates its requests,
+// typically with an OAuth token:
+//
+//	client := strava.NewWithToken(ctx, strava.OAuthConfig(id, secret), token)
+//	activities := client.Activities.List(ctx, strava.ListOptions{After: lastWeek})
+//	for activities.Next() {
+//		fmt.Println(activities.Value().Name)
+//	}
+//	if err := activities.Err(); err != nil {
+//		return err
+//	}
+package strava
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-openapi/runtime"
+	runtimeClient "github.com/go-openapi/runtime/client"
+	"github.com/jsilland/sutro/client"
+	"golang.org/x/oauth2"
+)
+
+// Endpoint is the OAuth endpoint of Strava.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://www.strava.com/oauth/authorize",
+	TokenURL: "https://www.strava.com/oauth/token",
+}
+
+// Client gives access to the Strava API. The errors Strava returns are
+// converted to the errors of this package, such as *NotFoundError.
+type Client struct {
+	// API is the generated client, which covers every endpoint of the API
+	// including those without a service.
+	API *client.StravaAPIV3
+
+	Activities *ActivitiesService
+	Athletes   *AthletesService
+	Clubs      *ClubsService
+	Routes     *RoutesService
+	Segments   *SegmentsService
+	Streams    *StreamsService
+	Uploads    *UploadsService
+	// Subscriptions authenticate with the credentials of the application
+	// instead of the token of the client.
+	Subscriptions *SubscriptionsService
+
+	transport *chainTransport
+}
+
+// New returns a client sending its requests with httpClient, which must
+// authenticate them.
+func New(httpClient *http.Client) *Client {
+	transportConfig := client.DefaultTransportConfig()
+	return newClient(httpClient, transportConfig.Host, transportConfig.BasePath, transportConfig.Schemes)
+}
+
+// NewWithURL returns a client sending its requests to the API at baseURL,
+// such as http://localhost:8080/api/v3, instead of Strava. It is meant for
+// fakes of the API in tests, such as the one of package sutrotest.
+func NewWithURL(httpClient *http.Client, baseURL string) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("Invalid API URL %q, expected an http or https URL", baseURL)
+	}
+	return newClient(httpClient, u.Host, u.Path, []string{u.Scheme}), nil
+}
+
+func newClient(httpClient *http.Client, host, basePath string, schemes []string) *Client {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	withContentType := *httpClient
+	withContentType.Transport = &contentTypeTransport{base: &headerTransport{base: base}}
+
+	transport := runtimeClient.NewWithClient(host, basePath, schemes, &withContentType)
+	transport.Consumers[runtime.JSONMime] = jsonConsumer()
+	// Exports of files, such as the GPX files of routes, are not JSON.
+	transport.Consumers["*/*"] = runtime.ByteStreamConsumer()
+	chain := &chainTransport{transport: transport}
+	api := client.New(chain, nil)
+
+	return &Client{
+		API:        api,
+		transport:  chain,
+		Activities: &ActivitiesService{api: api},
+		Athletes:   &AthletesService{api: api},
+		Clubs:      &ClubsService{api: api, transport: chain},
+		Routes:     &RoutesService{api: api, transport: chain},
+		Segments:   &SegmentsService{api: api, transport: chain},
+		Streams:    &StreamsService{api: api},
+		Uploads:    &UploadsService{api: api, transport: chain},
+
+		Subscriptions: &SubscriptionsService{transport: chain},
+	}
+}
+
+// NewWithToken returns a client authenticating as the owner of token, which
+// is refreshed as needed.
+func NewWithToken(ctx context.Context, config oauth2.Config, token *oauth2.Token) *Client {
+	return New(oauth2.NewClient(ctx, config.TokenSource(ctx, token)))
+}
+
+// OAuthConfig returns the OAuth configuration of the application with the
+// given credentials, as registered at https://www.strava.com/settings/api.
+func OAuthConfig(clientID, clientSecret string, scopes ...string) oauth2.Config {
+	return oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Endpoint:     Endpoint,
+		Scopes:       scopes,
+	}
+}
@@ -0,0 +1,127 @@
+package strava
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jsilland/sutro/client"
+	"github.com/jsilland/sutro/client/streams"
+	"github.com/jsilland/sutro/models"
+)
+
+// Cache keeps data between runs, such as *cache.Cache. Failing to store an
+// entry is not an error of the operation being cached.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Put(key string, data []byte) error
+}
+
+// StreamsService reads the recorded samples of activities.
+type StreamsService struct {
+	api   *client.StravaAPIV3
+	cache Cache
+}
+
+// CacheStreams keeps the streams of activities in cache, from which they
+// are read instead of fetched again. Streams do not change once recorded,
+// so cached streams never expire.
+func (c *Client) CacheStreams(cache Cache) {
+	c.Streams.cache = cache
+}
+
+// Activity returns the given stream types of an activity, such as latlng,
+// time or heartrate, keyed by type. Each type is cached on its own, so that
+// only the types missing from the cache are fetched.
+func (s *StreamsService) Activity(ctx context.Context, id int64, keys ...string) (*models.StreamSet, error) {
+	streams := map[string]json.RawMessage{}
+	missing := keys
+	if s.cache != nil {
+		missing = nil
+		for _, key := range keys {
+			if data, ok := s.cache.Get(streamsKey(id, key)); ok && json.Valid(data) {
+				streams[key] = data
+			} else {
+				missing = append(missing, key)
+			}
+		}
+	}
+
+	if len(missing) > 0 || len(keys) == 0 {
+		fetched, err := s.fetch(ctx, id, missing)
+		if err != nil {
+			return nil, err
+		}
+		for key, data := range fetched {
+			streams[key] = data
+		}
+		if s.cache != nil {
+			// The types the activity did not record are cached as null, so
+			// that they are not requested again.
+			for _, key := range missing {
+				data, ok := fetched[key]
+				if !ok {
+					data = json.RawMessage("null")
+				}
+				_ = s.cache.Put(streamsKey(id, key), data)
+			}
+		}
+	}
+
+	data, err := json.Marshal(streams)
+	if err != nil {
+		return nil, err
+	}
+	var set models.StreamSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// Cached reports whether the given stream types of an activity are all
+// cached, and would be read without a request.
+func (s *StreamsService) Cached(id int64, keys ...string) bool {
+	if s.cache == nil {
+		return false
+	}
+	for _, key := range keys {
+		if _, ok := s.cache.Get(streamsKey(id, key)); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// fetch requests stream types of an activity, and returns the streams it
+// recorded as JSON keyed by type.
+func (s *StreamsService) fetch(ctx context.Context, id int64, keys []string) (map[string]json.RawMessage, error) {
+	params := streams.NewGetActivityStreamsParamsWithContext(ctx).
+		WithID(id).
+		WithKeys(keys).
+		WithKeyByType(true)
+
+	response, err := s.api.Streams.GetActivityStreams(params, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.Payload == nil {
+		return nil, errors.New("Failed to obtain streams from the API")
+	}
+
+	data, err := json.Marshal(response.Payload)
+	if err != nil {
+		return nil, err
+	}
+	var fetched map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fetched); err != nil {
+		return nil, err
+	}
+	return fetched, nil
+}
+
+// streamsKey names a stream type of an activity in the cache.
+func streamsKey(id int64, key string) string {
+	return fmt.Sprintf("streams-%d-%s.json", id, key)
+}
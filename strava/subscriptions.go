@@ -0,0 +1,161 @@
+package strava
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/jsilland/sutro/client/clubs"
+)
+
+// SubscriptionsService manages the push subscription of an application,
+// through which Strava posts events such as new activities to a callback
+// URL. An application has at most one subscription, which is authenticated
+// by the credentials of the application rather than the token of an
+// athlete.
+type SubscriptionsService struct {
+	transport runtime.ClientTransport
+}
+
+// Subscription is the push subscription of an application. Subscriptions
+// are missing from swagger.json.
+type Subscription struct {
+	ID            int64     `json:"id"`
+	ApplicationID int64     `json:"application_id"`
+	CallbackURL   string    `json:"callback_url"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// List returns the push subscriptions of the application with the given
+// credentials, none or one.
+func (s *SubscriptionsService) List(ctx context.Context, clientID, clientSecret string) ([]*Subscription, error) {
+	result, err := s.transport.Submit(&runtime.ClientOperation{
+		ID:                 "getPushSubscriptions",
+		Method:             http.MethodGet,
+		PathPattern:        "/push_subscriptions",
+		ProducesMediaTypes: []string{runtime.JSONMime},
+		ConsumesMediaTypes: []string{runtime.JSONMime},
+		Schemes:            []string{"https"},
+		Params: runtime.ClientRequestWriterFunc(func(request runtime.ClientRequest, _ strfmt.Registry) error {
+			if err := request.SetQueryParam("client_id", clientID); err != nil {
+				return err
+			}
+			return request.SetQueryParam("client_secret", clientSecret)
+		}),
+		Reader:  &subscriptionsReader{faults: &clubs.GetClubByIDReader{}},
+		Context: ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, ok := result.([]*Subscription)
+	if !ok {
+		return nil, errors.New("Failed to obtain the push subscriptions from the API")
+	}
+	return subscriptions, nil
+}
+
+// Create subscribes the application with the given credentials to the
+// events of the athletes who authorized it, posted to callbackURL. Strava
+// validates the callback before it returns, by sending it a challenge with
+// verifyToken, which the callback must answer.
+func (s *SubscriptionsService) Create(ctx context.Context, clientID, clientSecret, callbackURL, verifyToken string) (*Subscription, error) {
+	result, err := s.transport.Submit(&runtime.ClientOperation{
+		ID:                 "createPushSubscription",
+		Method:             http.MethodPost,
+		PathPattern:        "/push_subscriptions",
+		ProducesMediaTypes: []string{runtime.JSONMime},
+		ConsumesMediaTypes: []string{runtime.URLencodedFormMime},
+		Schemes:            []string{"https"},
+		Params: runtime.ClientRequestWriterFunc(func(request runtime.ClientRequest, _ strfmt.Registry) error {
+			for name, value := range map[string]string{
+				"client_id":     clientID,
+				"client_secret": clientSecret,
+				"callback_url":  callbackURL,
+				"verify_token":  verifyToken,
+			} {
+				if err := request.SetFormParam(name, value); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+		Reader:  &subscriptionsReader{faults: &clubs.GetClubByIDReader{}},
+		Context: ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	subscription, ok := result.(*Subscription)
+	if !ok {
+		return nil, errors.New("Failed to obtain the push subscription created from the API")
+	}
+	subscription.CallbackURL = callbackURL
+	return subscription, nil
+}
+
+// Delete deletes the push subscription with the given id of the
+// application with the given credentials.
+func (s *SubscriptionsService) Delete(ctx context.Context, id int64, clientID, clientSecret string) error {
+	_, err := s.transport.Submit(&runtime.ClientOperation{
+		ID:                 "deletePushSubscription",
+		Method:             http.MethodDelete,
+		PathPattern:        "/push_subscriptions/{id}",
+		ProducesMediaTypes: []string{runtime.JSONMime},
+		ConsumesMediaTypes: []string{runtime.JSONMime},
+		Schemes:            []string{"https"},
+		Params: runtime.ClientRequestWriterFunc(func(request runtime.ClientRequest, _ strfmt.Registry) error {
+			if err := request.SetPathParam("id", strconv.FormatInt(id, 10)); err != nil {
+				return err
+			}
+			if err := request.SetQueryParam("client_id", clientID); err != nil {
+				return err
+			}
+			return request.SetQueryParam("client_secret", clientSecret)
+		}),
+		Reader:  &subscriptionsReader{faults: &clubs.GetClubByIDReader{}},
+		Context: ctx,
+	})
+	return err
+}
+
+// subscriptionsReader decodes push subscriptions, which have no generated
+// model: the list of those of the application, or the one created.
+type subscriptionsReader struct {
+	// faults reads the other responses, which are faults.
+	faults runtime.ClientResponseReader
+}
+
+func (r *subscriptionsReader) ReadResponse(response runtime.ClientResponse, consumer runtime.Consumer) (interface{}, error) {
+	switch response.Code() {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNoContent:
+		return nil, nil
+	default:
+		return r.faults.ReadResponse(response, consumer)
+	}
+	data, err := ioutil.ReadAll(response.Body())
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var subscription Subscription
+		if err := json.Unmarshal(data, &subscription); err != nil {
+			return nil, err
+		}
+		return &subscription, nil
+	}
+	var subscriptions []*Subscription
+	if err := json.Unmarshal(data, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
@@ -0,0 +1,62 @@
+package strava
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jsilland/sutro/models"
+)
+
+// ActivityTypes are the types Strava accepts for activities.
+var ActivityTypes = []models.ActivityType{
+	models.ActivityTypeAlpineSki,
+	models.ActivityTypeBackcountrySki,
+	models.ActivityTypeCanoeing,
+	models.ActivityTypeCrossfit,
+	models.ActivityTypeEBikeRide,
+	models.ActivityTypeElliptical,
+	models.ActivityTypeGolf,
+	models.ActivityTypeHandcycle,
+	models.ActivityTypeHike,
+	models.ActivityTypeIceSkate,
+	models.ActivityTypeInlineSkate,
+	models.ActivityTypeKayaking,
+	models.ActivityTypeKitesurf,
+	models.ActivityTypeNordicSki,
+	models.ActivityTypeRide,
+	models.ActivityTypeRockClimbing,
+	models.ActivityTypeRollerSki,
+	models.ActivityTypeRowing,
+	models.ActivityTypeRun,
+	models.ActivityTypeSail,
+	models.ActivityTypeSkateboard,
+	models.ActivityTypeSnowboard,
+	models.ActivityTypeSnowshoe,
+	models.ActivityTypeSoccer,
+	models.ActivityTypeStairStepper,
+	models.ActivityTypeStandUpPaddling,
+	models.ActivityTypeSurfing,
+	models.ActivityTypeSwim,
+	models.ActivityTypeVelomobile,
+	models.ActivityTypeVirtualRide,
+	models.ActivityTypeVirtualRun,
+	models.ActivityTypeWalk,
+	models.ActivityTypeWeightTraining,
+	models.ActivityTypeWheelchair,
+	models.ActivityTypeWindsurf,
+	models.ActivityTypeWorkout,
+	models.ActivityTypeYoga,
+}
+
+// ParseActivityType matches a type regardless of case, so that workout is
+// accepted for Workout.
+func ParseActivityType(value string) (models.ActivityType, error) {
+	names := make([]string, len(ActivityTypes))
+	for i, activityType := range ActivityTypes {
+		if strings.EqualFold(string(activityType), strings.TrimSpace(value)) {
+			return activityType, nil
+		}
+		names[i] = string(activityType)
+	}
+	return "", fmt.Errorf("Unknown activity type %q, expected one of %s", value, strings.Join(names, ", "))
+}
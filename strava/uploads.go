@@ -0,0 +1,150 @@
+package strava
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/jsilland/sutro/client"
+	"github.com/jsilland/sutro/client/uploads"
+	"github.com/jsilland/sutro/models"
+)
+
+// UploadsService uploads activity files and follows their processing.
+type UploadsService struct {
+	api       *client.StravaAPIV3
+	transport runtime.ClientTransport
+}
+
+// NewUpload is an activity file to upload. The file is streamed to Strava
+// as it is read, so that large files are never held in memory.
+type NewUpload struct {
+	File runtime.NamedReadCloser
+	// DataType is the format of the file: fit, tcx or gpx, with a .gz
+	// suffix when it is gzipped.
+	DataType    string
+	Name        string
+	Description string
+	ExternalID  string
+	Trainer     bool
+	Commute     bool
+}
+
+type contentTypeKey struct{}
+
+// Create uploads a file, and returns the upload, whose processing into an
+// activity Strava completes asynchronously. It closes the file.
+func (s *UploadsService) Create(ctx context.Context, upload NewUpload) (*models.Upload, error) {
+	if upload.File == nil {
+		return nil, errors.New("No file to upload")
+	}
+	if upload.DataType == "" {
+		upload.File.Close()
+		return nil, errors.New("The data type of the file to upload is required")
+	}
+
+	// The runtime reads files in full before sending them, so the form is
+	// written to the request as it is sent instead.
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		defer upload.File.Close()
+		writer.CloseWithError(writeUpload(form, upload))
+	}()
+
+	operation := &runtime.ClientOperation{
+		ID:                 "createUpload",
+		Method:             http.MethodPost,
+		PathPattern:        "/uploads",
+		ProducesMediaTypes: []string{runtime.JSONMime},
+		ConsumesMediaTypes: []string{runtime.MultipartFormMime},
+		Schemes:            []string{"https"},
+		Params: runtime.ClientRequestWriterFunc(func(request runtime.ClientRequest, formats strfmt.Registry) error {
+			return request.SetBodyParam(reader)
+		}),
+		Reader: &uploads.CreateUploadReader{},
+		// The runtime sets the content type without the boundary of the
+		// form, which contentTypeTransport restores.
+		Context: context.WithValue(ctx, contentTypeKey{}, form.FormDataContentType()),
+	}
+
+	result, err := s.transport.Submit(operation)
+	// Unblocks the writer when the request failed before reading the form.
+	reader.Close()
+	if err != nil {
+		return nil, err
+	}
+	created, ok := result.(*uploads.CreateUploadCreated)
+	if !ok || created.Payload == nil {
+		return nil, errors.New("Strava did not return the upload")
+	}
+	return created.Payload, nil
+}
+
+func writeUpload(form *multipart.Writer, upload NewUpload) error {
+	fields := [][2]string{
+		{"data_type", upload.DataType},
+		{"name", upload.Name},
+		{"description", upload.Description},
+		{"external_id", upload.ExternalID},
+	}
+	if upload.Trainer {
+		fields = append(fields, [2]string{"trainer", "1"})
+	}
+	if upload.Commute {
+		fields = append(fields, [2]string{"commute", "1"})
+	}
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+
+	part, err := form.CreateFormFile("file", filepath.Base(upload.File.Name()))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, upload.File); err != nil {
+		return err
+	}
+	return form.Close()
+}
+
+// Get returns an upload, whose status tells whether Strava is still
+// processing it, and whose activity id is set once it is done.
+func (s *UploadsService) Get(ctx context.Context, id int64) (*models.Upload, error) {
+	response, err := s.api.Uploads.GetUploadByID(uploads.NewGetUploadByIDParamsWithContext(ctx).WithUploadID(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.Payload == nil {
+		return nil, fmt.Errorf("Failed to obtain upload %d from the API", id)
+	}
+	return response.Payload, nil
+}
+
+// contentTypeTransport sets the content type of requests whose context
+// carries one, which the runtime would otherwise override.
+type contentTypeTransport struct {
+	base http.RoundTripper
+}
+
+func (t *contentTypeTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	contentType, ok := request.Context().Value(contentTypeKey{}).(string)
+	if !ok {
+		return t.base.RoundTrip(request)
+	}
+	// Round trippers must not modify the request they are given.
+	request = request.Clone(request.Context())
+	request.Header.Set("Content-Type", contentType)
+	return t.base.RoundTrip(request)
+}
@@ -0,0 +1,101 @@
+package strava
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-openapi/runtime"
+)
+
+// Usage is the usage of the rate limits of the application as of the last
+// response of the API, along with the clock of the server.
+type Usage struct {
+	// ShortTerm and Daily are the requests made in the current quarter hour
+	// and day, out of ShortTermLimit and DailyLimit. They are zero when the
+	// response did not report them.
+	ShortTerm      int
+	ShortTermLimit int
+	Daily          int
+	DailyLimit     int
+	// ServerTime is the date of the response according to the server, and
+	// Received the local time at which it was received.
+	ServerTime time.Time
+	Received   time.Time
+}
+
+// Exchange is an operation of the API as it was sent and answered.
+type Exchange struct {
+	// Operation is the id of the operation, such as getActivityById, and
+	// Path the pattern of its path, such as /activities/{id}.
+	Operation string
+	Method    string
+	Path      string
+	// Status is the HTTP status of the response, Proto its protocol, such
+	// as HTTP/1.1, and RequestID the id the API gave the request, when it
+	// reported one.
+	Status    int
+	Proto     string
+	RequestID string
+	// Header holds every header of the response, with the values of the
+	// sensitive ones redacted.
+	Header http.Header
+	// Duration is the time between sending the request and reading the
+	// response.
+	Duration time.Duration
+	// Usage is the usage of the rate limits the response reported.
+	Usage Usage
+}
+
+// Observe calls observe with each operation that received a response, once
+// it was read.
+func (c *Client) Observe(observe func(Exchange)) {
+	c.transport.mutex.Lock()
+	defer c.transport.mutex.Unlock()
+	c.transport.observers = append(c.transport.observers, observe)
+}
+
+// Usage returns the usage reported by the last response of the API, and
+// false until a response was received.
+func (c *Client) Usage() (Usage, bool) {
+	c.transport.mutex.Lock()
+	defer c.transport.mutex.Unlock()
+	if c.transport.usage == nil {
+		return Usage{}, false
+	}
+	return *c.transport.usage, true
+}
+
+func (t *chainTransport) record(operation *runtime.ClientOperation, code int, header http.Header, response *headerCapture, sent, received time.Time) {
+	usage := &Usage{Received: received}
+	limits, counted := counts(header.Get("X-RateLimit-Limit")), counts(header.Get("X-RateLimit-Usage"))
+	if len(limits) == 2 && len(counted) == 2 {
+		usage.ShortTermLimit, usage.DailyLimit = limits[0], limits[1]
+		usage.ShortTerm, usage.Daily = counted[0], counted[1]
+	}
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		usage.ServerTime = date
+	}
+
+	t.mutex.Lock()
+	t.usage = usage
+	observers := t.observers
+	t.mutex.Unlock()
+
+	if len(observers) == 0 {
+		return
+	}
+	exchange := Exchange{
+		Operation: operation.ID,
+		Method:    operation.Method,
+		Path:      operation.PathPattern,
+		Status:    code,
+		Proto:     response.proto,
+		Header:    response.header,
+		RequestID: header.Get("X-Request-Id"),
+		Duration:  received.Sub(sent),
+		Usage:     *usage,
+	}
+	for _, observe := range observers {
+		observe(exchange)
+	}
+}
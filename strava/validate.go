@@ -0,0 +1,266 @@
+package strava
+
+//go:generate go run generate.go
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+)
+
+// parameter is a parameter of an operation of swagger.json, with the
+// constraints its values must satisfy. Bodies are left to their models.
+type parameter struct {
+	Name     string
+	In       string
+	Type     string
+	ItemType string
+	Format   string
+	Required bool
+	Minimum  *float64
+	Maximum  *float64
+	MinItems int
+	MaxItems int
+	Enum     []string
+}
+
+func bound(value float64) *float64 {
+	return &value
+}
+
+// limits are constraints that the API enforces without documenting them in
+// swagger.json, by name of parameter.
+var limits = map[string]parameter{
+	"page":     {Minimum: bound(1)},
+	"per_page": {Minimum: bound(1), Maximum: bound(200)},
+}
+
+// InvalidParameterError is a request that was not sent, as one of its
+// parameters would have been rejected by the API.
+type InvalidParameterError struct {
+	// Operation is the id of the operation in swagger.json.
+	Operation string
+	Parameter string
+	Reason    string
+}
+
+func (e *InvalidParameterError) Error() string {
+	return fmt.Sprintf("Invalid %s for %s: %s", e.Parameter, e.Operation, e.Reason)
+}
+
+func (e *InvalidParameterError) Is(target error) bool { return target == ErrValidation }
+
+// validate checks the parameters of an operation against the constraints of
+// swagger.json, so that requests bound to fail are not spent on the rate
+// limit. Operations unknown to swagger.json are not checked.
+func validate(operation *runtime.ClientOperation) error {
+	constraints, ok := parameters[operation.ID]
+	if !ok || operation.Params == nil {
+		return nil
+	}
+
+	request := newRecordingRequest(operation.Method, operation.PathPattern)
+	// Failures to write the parameters are reported by the runtime.
+	if err := operation.Params.WriteToRequest(request, strfmt.Default); err != nil {
+		return nil
+	}
+
+	for _, constraint := range constraints {
+		if limit, ok := limits[constraint.Name]; ok {
+			if constraint.Minimum == nil {
+				constraint.Minimum = limit.Minimum
+			}
+			if constraint.Maximum == nil {
+				constraint.Maximum = limit.Maximum
+			}
+		}
+		if reason := check(constraint, request.values(constraint.In, constraint.Name)); reason != "" {
+			return &InvalidParameterError{Operation: operation.ID, Parameter: constraint.Name, Reason: reason}
+		}
+	}
+	return nil
+}
+
+// check returns why values do not satisfy the constraints of a parameter,
+// or the empty string if they do.
+func check(constraint parameter, values []string) string {
+	if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+		if constraint.Required {
+			return fmt.Sprintf("it is a required %s parameter", constraint.In)
+		}
+		return ""
+	}
+
+	kind, format := constraint.Type, constraint.Format
+	if kind == "array" {
+		// Arrays are sent as comma separated values.
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		if constraint.MinItems > 0 && len(values) < constraint.MinItems {
+			return fmt.Sprintf("expected at least %d values, got %d", constraint.MinItems, len(values))
+		}
+		if constraint.MaxItems > 0 && len(values) > constraint.MaxItems {
+			return fmt.Sprintf("expected at most %d values, got %d", constraint.MaxItems, len(values))
+		}
+		kind = constraint.ItemType
+	}
+
+	for _, value := range values {
+		if reason := checkValue(constraint, kind, format, value); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func checkValue(constraint parameter, kind, format, value string) string {
+	if len(constraint.Enum) > 0 && !contains(constraint.Enum, value) {
+		return fmt.Sprintf("%q is not one of %s", value, strings.Join(constraint.Enum, ", "))
+	}
+
+	var number float64
+	switch kind {
+	case "integer":
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("%q is not an integer", value)
+		}
+		number = float64(parsed)
+	case "number":
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Sprintf("%q is not a number", value)
+		}
+		number = parsed
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Sprintf("%q is not true or false", value)
+		}
+		return ""
+	case "string":
+		if format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				return fmt.Sprintf("%q is not a date and time such as 2020-05-17T08:30:00Z", value)
+			}
+		}
+		return ""
+	default:
+		return ""
+	}
+
+	if constraint.Minimum != nil && number < *constraint.Minimum {
+		return fmt.Sprintf("%s is less than the minimum of %v", value, *constraint.Minimum)
+	}
+	if constraint.Maximum != nil && number > *constraint.Maximum {
+		return fmt.Sprintf("%s is more than the maximum of %v", value, *constraint.Maximum)
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// recordingRequest is a runtime.ClientRequest that keeps the parameters
+// written to it, without sending anything.
+type recordingRequest struct {
+	method string
+	path   string
+	header http.Header
+	query  url.Values
+	form   url.Values
+	params map[string]string
+	files  map[string][]runtime.NamedReadCloser
+	body   interface{}
+}
+
+func newRecordingRequest(method, path string) *recordingRequest {
+	return &recordingRequest{
+		method: method,
+		path:   path,
+		header: http.Header{},
+		query:  url.Values{},
+		form:   url.Values{},
+		params: map[string]string{},
+		files:  map[string][]runtime.NamedReadCloser{},
+	}
+}
+
+// values returns the values of the parameter name written in the given
+// location of the request.
+func (r *recordingRequest) values(in, name string) []string {
+	switch in {
+	case "path":
+		if value, ok := r.params[name]; ok {
+			return []string{value}
+		}
+	case "query":
+		return r.query[name]
+	case "header":
+		return r.header[http.CanonicalHeaderKey(name)]
+	case "formData":
+		if _, ok := r.files[name]; ok {
+			return []string{name}
+		}
+		return r.form[name]
+	}
+	return nil
+}
+
+func (r *recordingRequest) SetHeaderParam(name string, values ...string) error {
+	r.header[http.CanonicalHeaderKey(name)] = values
+	return nil
+}
+
+func (r *recordingRequest) GetHeaderParams() http.Header { return r.header }
+
+func (r *recordingRequest) SetQueryParam(name string, values ...string) error {
+	r.query[name] = values
+	return nil
+}
+
+func (r *recordingRequest) SetFormParam(name string, values ...string) error {
+	r.form[name] = values
+	return nil
+}
+
+func (r *recordingRequest) SetPathParam(name string, value string) error {
+	r.params[name] = value
+	return nil
+}
+
+func (r *recordingRequest) GetQueryParams() url.Values { return r.query }
+
+func (r *recordingRequest) SetFileParam(name string, files ...runtime.NamedReadCloser) error {
+	r.files[name] = files
+	return nil
+}
+
+func (r *recordingRequest) SetBodyParam(body interface{}) error {
+	r.body = body
+	return nil
+}
+
+func (r *recordingRequest) SetTimeout(time.Duration) error { return nil }
+
+func (r *recordingRequest) GetMethod() string { return r.method }
+
+func (r *recordingRequest) GetPath() string { return r.path }
+
+func (r *recordingRequest) GetBody() []byte { return nil }
+
+func (r *recordingRequest) GetBodyParam() interface{} { return r.body }
+
+func (r *recordingRequest) GetFileParam() map[string][]runtime.NamedReadCloser { return r.files }
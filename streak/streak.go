@@ -0,0 +1,106 @@
+// Package streak finds the runs of consecutive days, or weeks, on which an
+// athlete was active.
+package streak
+
+import (
+	"sort"
+	"time"
+)
+
+// Period is the span of time a streak is counted in.
+type Period int
+
+const (
+	// Daily streaks are counted in days.
+	Daily Period = iota
+	// Weekly streaks are counted in weeks starting on Monday.
+	Weekly
+)
+
+// Of returns the day t falls on, or the Monday of its week, at midnight in
+// UTC, so that periods can be compared and counted regardless of time
+// zones and daylight saving time.
+func (p Period) Of(t time.Time) time.Time {
+	day := Day(t)
+	if p == Weekly {
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
+
+func (p Period) next(start time.Time) time.Time {
+	if p == Weekly {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Streak is a run of consecutive days, or weeks, from Start to End
+// inclusive. The Start and End of weekly streaks are the Mondays of their
+// first and last weeks.
+type Streak struct {
+	Start, End time.Time
+	// Length is the number of days, or weeks, of the streak.
+	Length int
+}
+
+// Day returns the day t falls on, at midnight in UTC.
+func Day(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Find returns the streaks of consecutive periods containing any of days,
+// as returned by Day, oldest first. Days may be given in any order and more
+// than once.
+func Find(days []time.Time, p Period) []Streak {
+	sorted := make([]time.Time, len(days))
+	for i, day := range days {
+		sorted[i] = p.Of(day)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	var streaks []Streak
+	for _, start := range sorted {
+		if n := len(streaks); n > 0 {
+			last := &streaks[n-1]
+			if start.Equal(last.End) {
+				continue
+			}
+			if start.Equal(p.next(last.End)) {
+				last.End = start
+				last.Length++
+				continue
+			}
+		}
+		streaks = append(streaks, Streak{Start: start, End: start, Length: 1})
+	}
+	return streaks
+}
+
+// Longest returns the longest of streaks, the earliest of them when several
+// are as long, or the zero Streak when there are none.
+func Longest(streaks []Streak) Streak {
+	var longest Streak
+	for _, s := range streaks {
+		if s.Length > longest.Length {
+			longest = s
+		}
+	}
+	return longest
+}
+
+// Current returns the last of streaks, oldest first, when it is still going
+// on at now: when it reaches the period of now, or the one before, which
+// activity later in the period of now would extend. It returns the zero
+// Streak otherwise.
+func Current(streaks []Streak, now time.Time, p Period) Streak {
+	if len(streaks) == 0 {
+		return Streak{}
+	}
+	last, current := streaks[len(streaks)-1], p.Of(now)
+	if last.End.Equal(current) || p.next(last.End).Equal(current) {
+		return last
+	}
+	return Streak{}
+}
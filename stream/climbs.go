@@ -0,0 +1,59 @@
+package stream
+
+import "math"
+
+// Climbs go on through dips and flats until the altitude dropped by more
+// than climbDescent meters from their top, or they went on for more than
+// climbFlat meters without reaching a higher one.
+const (
+	climbDescent = 10
+	climbFlat    = 500
+)
+
+// Climb is a stretch of an activity going uphill, between the indexes of
+// its lowest and its highest sample.
+type Climb struct {
+	From, To int
+}
+
+// Climbs returns the climbs of an altitude stream sampled along distances,
+// in meters, that are at least minLength meters long and rise by minGrade
+// percent on average.
+func Climbs(distances, altitudes []float64, minLength, minGrade float64) []Climb {
+	if len(distances) != len(altitudes) {
+		return nil
+	}
+
+	var climbs []Climb
+	keep := func(from, to int) {
+		length := distances[to] - distances[from]
+		if length > 0 && length >= minLength && (altitudes[to]-altitudes[from])/length*100 >= minGrade {
+			climbs = append(climbs, Climb{From: from, To: to})
+		}
+	}
+
+	bottom, top := -1, -1
+	for i, altitude := range altitudes {
+		if math.IsNaN(altitude) || math.IsNaN(distances[i]) {
+			continue
+		}
+		switch {
+		case bottom < 0 || altitude <= altitudes[bottom] && top == bottom:
+			bottom, top = i, i
+		case altitude > altitudes[top]:
+			top = i
+		case altitudes[top]-altitude > climbDescent || distances[i]-distances[top] > climbFlat:
+			if top > bottom {
+				keep(bottom, top)
+			}
+			bottom, top = i, i
+		case altitude < altitudes[bottom]:
+			// The climb did not rise enough to end: it starts again from here.
+			bottom, top = i, i
+		}
+	}
+	if bottom >= 0 && top > bottom {
+		keep(bottom, top)
+	}
+	return climbs
+}
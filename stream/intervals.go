@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"math"
+	"sort"
+)
+
+// Resample returns the values of ys at every step of xs, from its first to
+// its last sample, linearly interpolated between samples. It turns streams
+// recorded at an irregular pace into signals sampled evenly in time.
+func Resample(xs, ys []float64, step float64) []float64 {
+	if len(xs) == 0 || len(xs) != len(ys) || step <= 0 {
+		return nil
+	}
+
+	count := int((xs[len(xs)-1]-xs[0])/step) + 1
+	resampled := make([]float64, count)
+	for i := range resampled {
+		resampled[i] = Interpolate(xs, ys, xs[0]+float64(i)*step)
+	}
+	return resampled
+}
+
+// Changepoints splits signal into segments of at least minimum samples
+// whose means differ significantly, and returns the index each segment
+// starts at, beginning with 0.
+//
+// Segments are split in two by binary segmentation wherever it reduces the
+// squared error the most, as long as the reduction outweighs a penalty
+// growing with the noise of the signal and the logarithm of its length.
+func Changepoints(signal []float64, minimum int) []int {
+	if minimum < 1 {
+		minimum = 1
+	}
+	if len(signal) < 2*minimum {
+		return []int{0}
+	}
+
+	// Prefix sums give the mean and squared error of any segment in constant
+	// time.
+	sums := make([]float64, len(signal)+1)
+	for i, value := range signal {
+		sums[i+1] = sums[i] + value
+	}
+	variance := noise(signal)
+	penalty := 3 * variance * math.Log(float64(len(signal)))
+	if penalty == 0 {
+		penalty = math.SmallestNonzeroFloat64
+	}
+
+	starts := []int{0}
+	stack := [][2]int{{0, len(signal)}}
+	for len(stack) > 0 {
+		from, to := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		best, reduction := -1, penalty
+		total := sums[to] - sums[from]
+		for split := from + minimum; split <= to-minimum; split++ {
+			left, right := float64(split-from), float64(to-split)
+			leftMean, rightMean := (sums[split]-sums[from])/left, (total-sums[split]+sums[from])/right
+			if r := left * right / (left + right) * (leftMean - rightMean) * (leftMean - rightMean); r > reduction {
+				best, reduction = split, r
+			}
+		}
+		if best >= 0 {
+			starts = append(starts, best)
+			stack = append(stack, [2]int{from, best}, [2]int{best, to})
+		}
+	}
+	sort.Ints(starts)
+	return starts
+}
+
+// noise estimates the variance of the noise of signal from the median of
+// the differences between consecutive samples, which steps between
+// segments barely move.
+func noise(signal []float64) float64 {
+	differences := make([]float64, len(signal)-1)
+	for i := range differences {
+		differences[i] = math.Abs(signal[i+1] - signal[i])
+	}
+	sort.Float64s(differences)
+	median := differences[len(differences)/2]
+	// The median absolute value of the difference of two normal samples is
+	// 0.6745 times their standard deviation times the square root of two.
+	deviation := median / (0.6745 * math.Sqrt2)
+	return deviation * deviation
+}
@@ -0,0 +1,202 @@
+package stream
+
+import (
+	"math"
+	"sort"
+
+	"github.com/jsilland/sutro/geo"
+	"github.com/jsilland/sutro/models"
+)
+
+// Times returns the time stream of a stream set, in seconds, or nil.
+func Times(set *models.StreamSet) []float64 {
+	if set.Time == nil {
+		return nil
+	}
+	return fromInts(set.Time.Data)
+}
+
+// Distances returns the distance stream of a stream set, in meters, or nil.
+func Distances(set *models.StreamSet) []float64 {
+	if set.Distance == nil {
+		return nil
+	}
+	return fromFloats(set.Distance.Data)
+}
+
+// Altitudes returns the altitude stream of a stream set, in meters, or nil.
+func Altitudes(set *models.StreamSet) []float64 {
+	if set.Altitude == nil {
+		return nil
+	}
+	return fromFloats(set.Altitude.Data)
+}
+
+// Speeds returns the smoothed speed stream of a stream set, in meters per
+// second, or nil.
+func Speeds(set *models.StreamSet) []float64 {
+	if set.VelocitySmooth == nil {
+		return nil
+	}
+	return fromFloats(set.VelocitySmooth.Data)
+}
+
+// Grades returns the smoothed grade stream of a stream set, in percent, or
+// nil.
+func Grades(set *models.StreamSet) []float64 {
+	if set.GradeSmooth == nil {
+		return nil
+	}
+	return fromFloats(set.GradeSmooth.Data)
+}
+
+// Heartrates returns the heart rate stream of a stream set, in beats per
+// minute, or nil.
+func Heartrates(set *models.StreamSet) []float64 {
+	if set.Heartrate == nil {
+		return nil
+	}
+	return fromInts(set.Heartrate.Data)
+}
+
+// Watts returns the power stream of a stream set, in watts, or nil.
+func Watts(set *models.StreamSet) []float64 {
+	if set.Watts == nil {
+		return nil
+	}
+	return fromInts(set.Watts.Data)
+}
+
+// Cadences returns the cadence stream of a stream set, in revolutions or
+// steps per minute, or nil.
+func Cadences(set *models.StreamSet) []float64 {
+	if set.Cadence == nil {
+		return nil
+	}
+	return fromInts(set.Cadence.Data)
+}
+
+// Positions returns the latitude/longitude stream of a stream set, or nil.
+// Malformed samples are returned as NaN coordinates so that positions stay
+// aligned with the other streams.
+func Positions(set *models.StreamSet) []geo.Point {
+	if set.Latlng == nil {
+		return nil
+	}
+
+	points := make([]geo.Point, len(set.Latlng.Data))
+	for i, latlng := range set.Latlng.Data {
+		if len(latlng) != 2 {
+			points[i] = geo.Point{Lat: math.NaN(), Lng: math.NaN()}
+			continue
+		}
+		points[i] = geo.Point{Lat: float64(latlng[0]), Lng: float64(latlng[1])}
+	}
+	return points
+}
+
+// Interpolate returns the value of ys at x, linearly interpolated between
+// the two closest samples of xs, which must be sorted in ascending order.
+func Interpolate(xs, ys []float64, x float64) float64 {
+	if len(xs) == 0 || len(xs) != len(ys) {
+		return math.NaN()
+	}
+
+	i := sort.SearchFloat64s(xs, x)
+	switch {
+	case i == 0:
+		return ys[0]
+	case i == len(xs):
+		return ys[len(ys)-1]
+	case xs[i] == xs[i-1]:
+		return ys[i]
+	}
+
+	ratio := (x - xs[i-1]) / (xs[i] - xs[i-1])
+	return ys[i-1] + ratio*(ys[i]-ys[i-1])
+}
+
+// Mean returns the average of the samples of ys whose matching xs lies in
+// [from, to), or NaN if there are none.
+func Mean(xs, ys []float64, from, to float64) float64 {
+	if len(xs) != len(ys) {
+		return math.NaN()
+	}
+
+	total, count := 0.0, 0
+	for i := sort.SearchFloat64s(xs, from); i < len(xs) && xs[i] < to; i++ {
+		total += ys[i]
+		count++
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return total / float64(count)
+}
+
+// Smooth returns the rolling average of signal over the window samples up
+// to each of them, or over as many as there are at its start. NaN samples
+// are left out of the averages, which are NaN when the window has none.
+func Smooth(signal []float64, window int) []float64 {
+	if window <= 1 {
+		return append([]float64(nil), signal...)
+	}
+
+	smoothed := make([]float64, len(signal))
+	sum, count := 0.0, 0
+	for i, value := range signal {
+		if !math.IsNaN(value) {
+			sum += value
+			count++
+		}
+		if i >= window && !math.IsNaN(signal[i-window]) {
+			sum -= signal[i-window]
+			count--
+		}
+		if count == 0 {
+			smoothed[i] = math.NaN()
+			continue
+		}
+		smoothed[i] = sum / float64(count)
+	}
+	return smoothed
+}
+
+// Ascent returns the total elevation gain of an altitude stream, in meters.
+// Changes smaller than threshold meters are treated as noise: the gain is
+// only counted once the altitude has moved by more than threshold from the
+// last turning point.
+func Ascent(altitudes []float64, threshold float64) float64 {
+	if len(altitudes) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	reference := altitudes[0]
+	for _, altitude := range altitudes[1:] {
+		switch {
+		case altitude-reference > threshold:
+			total += altitude - reference
+			reference = altitude
+		case reference-altitude > threshold:
+			reference = altitude
+		}
+	}
+	return total
+}
+
+func fromInts(values []int64) []float64 {
+	result := make([]float64, len(values))
+	for i, value := range values {
+		result[i] = float64(value)
+	}
+	return result
+}
+
+func fromFloats(values []float32) []float64 {
+	result := make([]float64, len(values))
+	for i, value := range values {
+		result[i] = float64(value)
+	}
+	return result
+}
@@ -0,0 +1,178 @@
+//go:build ignore
+// +build ignore
+
+// generate writes operations.go, the operations of the API with an example
+// response for each of them, from swagger.json.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type swagger struct {
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+type operation struct {
+	ID        string `json:"operationId"`
+	Responses map[string]struct {
+		Schema   map[string]interface{}     `json:"schema"`
+		Examples map[string]json.RawMessage `json:"examples"`
+	} `json:"responses"`
+}
+
+var methods = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "patch": true}
+
+func main() {
+	data, err := ioutil.ReadFile("../swagger.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var spec swagger
+	if err := json.Unmarshal(data, &spec); err != nil {
+		log.Fatal(err)
+	}
+
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var source bytes.Buffer
+	source.WriteString("// Code generated by generate.go from swagger.json; DO NOT EDIT.\n\n")
+	source.WriteString("package sutrotest\n\n")
+	source.WriteString("var operations = []operation{\n")
+	for _, path := range paths {
+		names := make([]string, 0, len(spec.Paths[path]))
+		for method := range spec.Paths[path] {
+			if methods[method] {
+				names = append(names, method)
+			}
+		}
+		sort.Strings(names)
+
+		for _, method := range names {
+			var op operation
+			if err := json.Unmarshal(spec.Paths[path][method], &op); err != nil {
+				log.Fatalf("Unable to read %s %s: %v", method, path, err)
+			}
+			status, example := exampleResponse(op)
+			fmt.Fprintf(&source, "\t{ID: %q, Method: %q, Path: %q, Status: %d, Example: %s},\n",
+				op.ID, strings.ToUpper(method), path, status, literal(example))
+		}
+	}
+	source.WriteString("}\n")
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("operations.go", formatted, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// exampleResponse returns the successful status of an operation and its
+// example response, or an empty object or list matching its schema when
+// swagger.json has no example.
+func exampleResponse(op operation) (int, string) {
+	for _, code := range []string{"200", "201", "202", "204"} {
+		response, ok := op.Responses[code]
+		if !ok {
+			continue
+		}
+		status, _ := strconv.Atoi(code)
+
+		if example, ok := response.Examples["application/json"]; ok {
+			decoder := json.NewDecoder(bytes.NewReader(example))
+			decoder.UseNumber()
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				log.Fatalf("Invalid example of %s: %v", op.ID, err)
+			}
+			value = conform(value, response.Schema["type"] == "array")
+			compact, err := json.Marshal(sanitize(value))
+			if err != nil {
+				log.Fatal(err)
+			}
+			return status, string(compact)
+		}
+		switch {
+		case response.Schema == nil:
+			return status, ""
+		case response.Schema["type"] == "array":
+			return status, "[]"
+		}
+		return status, "{}"
+	}
+	return 200, "{}"
+}
+
+// conform reshapes the examples of swagger.json that disagree with their
+// schema: the stream sets the SDK requests with key_by_type come as a list
+// of streams, which are keyed by their type, and some lists come as a single
+// element, which is wrapped in a list.
+func conform(value interface{}, array bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if array {
+			return []interface{}{v}
+		}
+	case []interface{}:
+		if array {
+			return v
+		}
+		keyed := map[string]interface{}{}
+		for _, element := range v {
+			object, ok := element.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if kind, ok := object["type"].(string); ok {
+				keyed[kind] = object
+			}
+		}
+		return keyed
+	}
+	return value
+}
+
+// sanitize replaces the integers of an example that overflow 64 bits, such
+// as some of the ids the documentation makes up, with 0 so that the models
+// can decode the example.
+func sanitize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, element := range v {
+			v[key] = sanitize(element)
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = sanitize(element)
+		}
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return v
+		}
+		if _, err := v.Int64(); err != nil {
+			return json.Number("0")
+		}
+	}
+	return value
+}
+
+func literal(value string) string {
+	if strings.Contains(value, "`") {
+		return strconv.Quote(value)
+	}
+	return "`" + value + "`"
+}
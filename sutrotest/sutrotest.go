@@ -0,0 +1,258 @@
+// Package sutrotest provides a fake Strava API for tests. Every operation of
+// swagger.json answers with the example response of its documentation until
+// a test sets another response, and the server records the requests it
+// receives so that tests can assert on them:
+//
+//	server := sutrotest.NewServer()
+//	defer server.Close()
+//	server.Respond("getActivityById", http.StatusOK, activity)
+//
+//	client := server.Client()
+//	...
+//	request := server.AssertCalled(t, "updateActivityById")
+//
+// Commands run as separate processes reach the server through the
+// SUTRO_API_URL environment variable, which Environment returns.
+package sutrotest
+
+//go:generate go run generate.go
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/jsilland/sutro/strava"
+)
+
+// BasePath is the path of the API on the server, as on Strava.
+const BasePath = "/api/v3"
+
+// operation is an operation of the API, with its successful status and
+// the example response of its documentation.
+type operation struct {
+	ID      string
+	Method  string
+	Path    string
+	Status  int
+	Example string
+}
+
+// Request is a request received by the server.
+type Request struct {
+	// Operation is the id of the operation of the request in swagger.json,
+	// such as getActivityById, or empty when it matches no operation.
+	Operation string
+	Method    string
+	// Path is the path of the request below BasePath.
+	Path string
+	// Params are the path parameters of the operation, such as id.
+	Params map[string]string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// TB is the part of testing.TB assertions need.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+type response struct {
+	status int
+	body   []byte
+}
+
+// Server is a fake Strava API listening on a local port.
+type Server struct {
+	// URL is the base URL of the API, including BasePath.
+	URL string
+
+	server    *httptest.Server
+	mutex     sync.Mutex
+	responses map[string]response
+	requests  []*Request
+}
+
+// NewServer starts a server, which Close stops.
+func NewServer() *Server {
+	s := &Server{responses: map[string]response{}}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = s.server.URL + BasePath
+	return s
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a client of the server. Its requests are not
+// authenticated, which the server does not check.
+func (s *Server) Client() *strava.Client {
+	client, err := strava.NewWithURL(s.server.Client(), s.URL)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// Environment returns the environment variables pointing sutro at the
+// server, to be added to the environment of commands under test.
+func (s *Server) Environment() []string {
+	return []string{"SUTRO_API_URL=" + s.URL}
+}
+
+// Respond sets the response of an operation, by id. The body is encoded as
+// JSON unless it is a string or a byte slice, which are sent as is.
+func (s *Server) Respond(operation string, status int, body interface{}) {
+	var encoded []byte
+	switch b := body.(type) {
+	case []byte:
+		encoded = b
+	case string:
+		encoded = []byte(b)
+	default:
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			panic(fmt.Sprintf("Unable to encode the response of %s: %v", operation, err))
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.responses[operation] = response{status: status, body: encoded}
+}
+
+// Fail makes an operation answer with a fault of Strava, such as
+// http.StatusNotFound or http.StatusTooManyRequests.
+func (s *Server) Fail(operation string, status int, message string) {
+	s.Respond(operation, status, map[string]interface{}{
+		"message": message,
+		"errors":  []interface{}{},
+	})
+}
+
+// Requests returns the requests received for an operation, by id, oldest
+// first. The empty id returns the requests matching no operation.
+func (s *Server) Requests(operation string) []*Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var requests []*Request
+	for _, r := range s.requests {
+		if r.Operation == operation {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// AssertCalled fails the test unless the server received a request for an
+// operation, and returns the last one.
+func (s *Server) AssertCalled(t TB, operation string) *Request {
+	t.Helper()
+	requests := s.Requests(operation)
+	if len(requests) == 0 {
+		t.Errorf("Expected a request for %s, got none", operation)
+		return nil
+	}
+	return requests[len(requests)-1]
+}
+
+// AssertNotCalled fails the test if the server received a request for an
+// operation.
+func (s *Server) AssertNotCalled(t TB, operation string) {
+	t.Helper()
+	if requests := s.Requests(operation); len(requests) > 0 {
+		t.Errorf("Expected no request for %s, got %d", operation, len(requests))
+	}
+}
+
+// AssertAllMatched fails the test if the server received requests matching
+// no operation of the API.
+func (s *Server) AssertAllMatched(t TB) {
+	t.Helper()
+	for _, r := range s.Requests("") {
+		t.Errorf("Unexpected request %s %s", r.Method, r.Path)
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	request := &Request{
+		Method: r.Method,
+		Path:   strings.TrimPrefix(r.URL.Path, BasePath),
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+
+	op, params := match(r.Method, request.Path)
+	if op != nil {
+		request.Operation, request.Params = op.ID, params
+	}
+
+	s.mutex.Lock()
+	s.requests = append(s.requests, request)
+	canned, ok := s.responses[request.Operation]
+	s.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case op == nil || !strings.HasPrefix(r.URL.Path, BasePath+"/"):
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Record Not Found","errors":[{"resource":"resource","field":"path","code":"invalid"}]}`)
+	case ok:
+		w.WriteHeader(canned.status)
+		_, _ = w.Write(canned.body)
+	default:
+		w.WriteHeader(op.Status)
+		fmt.Fprint(w, op.Example)
+	}
+}
+
+// match returns the operation of a request with its path parameters, or
+// nil. Literal segments take precedence over parameters, so that
+// /segments/explore is not taken for the segment named explore.
+func match(method, path string) (*operation, map[string]string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	var best *operation
+	var bestParams map[string]string
+	bestLiterals := -1
+	for i := range operations {
+		op := &operations[i]
+		if op.Method != method {
+			continue
+		}
+
+		pattern := strings.Split(strings.Trim(op.Path, "/"), "/")
+		if len(pattern) != len(segments) {
+			continue
+		}
+
+		params, literals := map[string]string{}, 0
+		for j, part := range pattern {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+				params[strings.Trim(part, "{}")] = segments[j]
+				continue
+			}
+			if part != segments[j] {
+				params = nil
+				break
+			}
+			literals++
+		}
+		if params != nil && literals > bestLiterals {
+			best, bestParams, bestLiterals = op, params, literals
+		}
+	}
+	return best, bestParams
+}
@@ -0,0 +1,91 @@
+// Code generated by generate.go from swagger.json; DO NOT EDIT.
+
+package table
+
+var properties = map[string][]string{
+	"activityStats":         []string{"biggest_climb_elevation_gain", "biggest_ride_distance"},
+	"activityTotal":         []string{"achievement_count", "count", "distance", "elapsed_time", "elevation_gain", "moving_time"},
+	"activityZone":          []string{"custom_zones", "max", "points", "score", "sensor_based", "type"},
+	"altitudeStream":        []string{"original_size", "resolution", "series_type"},
+	"baseStream":            []string{"original_size", "resolution", "series_type"},
+	"cadenceStream":         []string{"original_size", "resolution", "series_type"},
+	"comment":               []string{"id", "activity_id", "created_at", "text"},
+	"detailedActivity":      []string{"id", "achievement_count", "athlete_count", "average_heartrate", "average_speed", "average_watts", "calories", "comment_count", "commute", "description", "device_name", "device_watts", "distance", "elapsed_time", "elev_high", "elev_low", "embed_token", "external_id", "flagged", "gear_id", "has_heartrate", "has_kudoed", "kilojoules", "kudos_count", "manual", "max_heartrate", "max_speed", "max_watts", "moving_time", "name", "photo_count", "private", "start_date", "start_date_local", "timezone", "total_elevation_gain", "total_photo_count", "trainer", "type", "upload_id", "upload_id_str", "weighted_average_watts", "workout_type"},
+	"detailedAthlete":       []string{"id", "city", "country", "created_at", "firstname", "follower_count", "friend_count", "ftp", "lastname", "measurement_preference", "premium", "profile", "profile_medium", "resource_state", "sex", "state", "summit", "updated_at", "weight"},
+	"detailedClub":          []string{"id", "admin", "city", "country", "cover_photo", "cover_photo_small", "featured", "following_count", "member_count", "membership", "name", "owner", "private", "profile_medium", "resource_state", "sport_type", "state", "url", "verified"},
+	"detailedGear":          []string{"id", "brand_name", "description", "distance", "frame_type", "model_name", "name", "primary", "resource_state"},
+	"detailedSegment":       []string{"id", "activity_type", "athlete_count", "average_grade", "city", "climb_category", "country", "created_at", "distance", "effort_count", "elevation_high", "elevation_low", "hazardous", "maximum_grade", "name", "private", "star_count", "state", "total_elevation_gain", "updated_at"},
+	"detailedSegmentEffort": []string{"id", "average_cadence", "average_heartrate", "average_watts", "device_watts", "distance", "elapsed_time", "end_index", "hidden", "is_kom", "kom_rank", "max_heartrate", "moving_time", "name", "pr_rank", "start_date", "start_date_local", "start_index"},
+	"distanceStream":        []string{"original_size", "resolution", "series_type"},
+	"error":                 []string{"code", "field", "resource"},
+	"explorerSegment":       []string{"id", "avg_grade", "climb_category", "climb_category_desc", "distance", "elev_difference", "name", "points"},
+	"fault":                 []string{"message"},
+	"heartRateZoneRanges":   []string{"custom_zones"},
+	"heartrateStream":       []string{"original_size", "resolution", "series_type"},
+	"lap":                   []string{"id", "average_cadence", "average_speed", "distance", "elapsed_time", "end_index", "lap_index", "max_speed", "moving_time", "name", "pace_zone", "split", "start_date", "start_date_local", "start_index", "total_elevation_gain"},
+	"latLngStream":          []string{"original_size", "resolution", "series_type"},
+	"metaActivity":          []string{"id"},
+	"metaAthlete":           []string{"id"},
+	"metaClub":              []string{"id", "name", "resource_state"},
+	"movingStream":          []string{"original_size", "resolution", "series_type"},
+	"photo":                 []string{"activity_id", "caption", "created_at", "source", "unique_id", "uploaded_at"},
+	"photosSummary":         []string{"count"},
+	"polylineMap":           []string{"id", "polyline", "summary_polyline"},
+	"powerStream":           []string{"original_size", "resolution", "series_type"},
+	"route":                 []string{"id", "description", "distance", "elevation_gain", "id_str", "name", "private", "starred", "sub_type", "timestamp", "type"},
+	"runningRace":           []string{"id", "city", "country", "distance", "measurement_preference", "name", "running_race_type", "start_date_local", "state", "url", "website_url"},
+	"smoothGradeStream":     []string{"original_size", "resolution", "series_type"},
+	"smoothVelocityStream":  []string{"original_size", "resolution", "series_type"},
+	"split":                 []string{"average_speed", "distance", "elapsed_time", "elevation_difference", "moving_time", "pace_zone", "split"},
+	"summaryActivity":       []string{"id", "achievement_count", "athlete_count", "average_heartrate", "average_speed", "average_watts", "comment_count", "commute", "device_watts", "distance", "elapsed_time", "elev_high", "elev_low", "external_id", "flagged", "gear_id", "has_heartrate", "has_kudoed", "kilojoules", "kudos_count", "manual", "max_heartrate", "max_speed", "max_watts", "moving_time", "name", "photo_count", "private", "start_date", "start_date_local", "timezone", "total_elevation_gain", "total_photo_count", "trainer", "type", "upload_id", "upload_id_str", "weighted_average_watts", "workout_type"},
+	"summaryAthlete":        []string{"id", "city", "country", "created_at", "firstname", "lastname", "premium", "profile", "profile_medium", "resource_state", "sex", "state", "summit", "updated_at"},
+	"summaryClub":           []string{"id", "city", "country", "cover_photo", "cover_photo_small", "featured", "member_count", "name", "private", "profile_medium", "resource_state", "sport_type", "state", "url", "verified"},
+	"summaryGear":           []string{"id", "distance", "name", "primary", "resource_state"},
+	"summarySegment":        []string{"id", "activity_type", "average_grade", "city", "climb_category", "country", "distance", "elevation_high", "elevation_low", "maximum_grade", "name", "private", "state"},
+	"summarySegmentEffort":  []string{"id", "distance", "elapsed_time", "is_kom", "start_date", "start_date_local"},
+	"temperatureStream":     []string{"original_size", "resolution", "series_type"},
+	"timeStream":            []string{"original_size", "resolution", "series_type"},
+	"timedZoneRange":        []string{"max", "min", "time"},
+	"updatableActivity":     []string{"commute", "description", "gear_id", "name", "trainer", "type"},
+	"updatableAthlete":      []string{"weight"},
+	"upload":                []string{"id", "activity_id", "error", "external_id", "id_str", "status"},
+	"zoneRange":             []string{"max", "min"},
+}
+
+var responses = map[string]string{
+	"createActivity":                    "detailedActivity",
+	"createUpload":                      "upload",
+	"exploreSegments":                   "explorerResponse",
+	"getActivityById":                   "detailedActivity",
+	"getActivityStreams":                "streamSet",
+	"getClubActivitiesById":             "summaryActivity",
+	"getClubAdminsById":                 "summaryAthlete",
+	"getClubById":                       "detailedClub",
+	"getClubMembersById":                "summaryAthlete",
+	"getCommentsByActivityId":           "comment",
+	"getEffortsBySegmentId":             "detailedSegmentEffort",
+	"getGearById":                       "detailedGear",
+	"getKudoersByActivityId":            "summaryAthlete",
+	"getLapsByActivityId":               "lap",
+	"getLoggedInAthlete":                "detailedAthlete",
+	"getLoggedInAthleteActivities":      "summaryActivity",
+	"getLoggedInAthleteClubs":           "summaryClub",
+	"getLoggedInAthleteStarredSegments": "summarySegment",
+	"getLoggedInAthleteZones":           "zones",
+	"getPhotosByActivityId":             "photo",
+	"getRouteById":                      "route",
+	"getRouteStreams":                   "streamSet",
+	"getRoutesByAthleteId":              "route",
+	"getRunningRaceById":                "runningRace",
+	"getRunningRaces":                   "runningRace",
+	"getSegmentById":                    "detailedSegment",
+	"getSegmentEffortById":              "detailedSegmentEffort",
+	"getSegmentEffortStreams":           "streamSet",
+	"getSegmentStreams":                 "streamSet",
+	"getStats":                          "activityStats",
+	"getUploadById":                     "upload",
+	"getZonesByActivityId":              "activityZone",
+	"starSegment":                       "detailedSegment",
+	"updateActivityById":                "detailedActivity",
+	"updateLoggedInAthlete":             "detailedAthlete",
+}
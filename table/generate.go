@@ -0,0 +1,164 @@
+//go:build ignore
+// +build ignore
+
+// generate writes definitions.go, the scalar properties of the definitions
+// of swagger.json and the definition each operation responds with.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+)
+
+type swagger struct {
+	Paths       map[string]map[string]json.RawMessage `json:"paths"`
+	Definitions map[string]*schema                    `json:"definitions"`
+}
+
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	AllOf      []*schema          `json:"allOf"`
+	Properties map[string]*schema `json:"properties"`
+	Items      *schema            `json:"items"`
+}
+
+type operation struct {
+	ID        string `json:"operationId"`
+	Responses map[string]struct {
+		Schema *schema `json:"schema"`
+	} `json:"responses"`
+}
+
+var methods = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "patch": true}
+
+var spec swagger
+
+func main() {
+	data, err := ioutil.ReadFile("../swagger.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		log.Fatal(err)
+	}
+
+	names := make([]string, 0, len(spec.Definitions))
+	for name := range spec.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var source bytes.Buffer
+	source.WriteString("// Code generated by generate.go from swagger.json; DO NOT EDIT.\n\n")
+	source.WriteString("package table\n\n")
+	source.WriteString("var properties = map[string][]string{\n")
+	for _, name := range names {
+		if scalars := scalarProperties(spec.Definitions[name]); len(scalars) > 0 {
+			fmt.Fprintf(&source, "\t%q: %#v,\n", name, scalars)
+		}
+	}
+	source.WriteString("}\n\n")
+
+	source.WriteString("var responses = map[string]string{\n")
+	for _, op := range operations() {
+		response, ok := op.Responses["200"]
+		if !ok {
+			response, ok = op.Responses["201"]
+		}
+		if !ok || response.Schema == nil {
+			continue
+		}
+		definition := response.Schema
+		if definition.Type == "array" && definition.Items != nil {
+			definition = definition.Items
+		}
+		if definition.Ref != "" {
+			fmt.Fprintf(&source, "\t%q: %q,\n", op.ID, refName(definition.Ref))
+		}
+	}
+	source.WriteString("}\n")
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("definitions.go", formatted, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func operations() []operation {
+	var ops []operation
+	for path, byMethod := range spec.Paths {
+		for method, raw := range byMethod {
+			if !methods[method] {
+				continue
+			}
+			var op operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				log.Fatalf("Unable to read %s %s: %v", method, path, err)
+			}
+			ops = append(ops, op)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].ID < ops[j].ID })
+	return ops
+}
+
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/definitions/")
+}
+
+// scalarProperties returns the properties of a definition, including those
+// it composes with allOf, that render in a cell: numbers, strings, booleans
+// and enumerations. The id comes first, then the others by name.
+func scalarProperties(s *schema) []string {
+	seen := map[string]bool{}
+	var collect func(s *schema)
+	collect = func(s *schema) {
+		if s.Ref != "" {
+			collect(spec.Definitions[refName(s.Ref)])
+			return
+		}
+		for _, composed := range s.AllOf {
+			collect(composed)
+		}
+		for name, property := range s.Properties {
+			if isScalar(property) {
+				seen[name] = true
+			}
+		}
+	}
+	collect(s)
+
+	var scalars []string
+	for name := range seen {
+		if name != "id" {
+			scalars = append(scalars, name)
+		}
+	}
+	sort.Strings(scalars)
+	if seen["id"] {
+		scalars = append([]string{"id"}, scalars...)
+	}
+	return scalars
+}
+
+func isScalar(s *schema) bool {
+	if s.Ref != "" {
+		referenced := spec.Definitions[refName(s.Ref)]
+		return referenced.Type != "" && referenced.Type != "object" && referenced.Type != "array" && len(referenced.Properties) == 0
+	}
+	switch s.Type {
+	case "string", "integer", "number", "boolean":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+// Package table renders the responses of the API as tables, with columns
+// chosen for each definition of swagger.json: curated ones for the common
+// resources, such as the date, type and distance of activities, and the
+// scalar properties of the definition for the others.
+package table
+
+//go:generate go run generate.go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// columns are the curated columns of lists of definitions, overriding
+// their scalar properties.
+var columns = map[string][]string{
+	"comment":               {"id", "created_at", "text"},
+	"detailedActivity":      {"id", "start_date_local", "type", "name", "distance", "moving_time", "total_elevation_gain"},
+	"detailedAthlete":       {"id", "firstname", "lastname", "city", "country"},
+	"detailedClub":          {"id", "name", "sport_type", "member_count", "city", "country"},
+	"detailedGear":          {"id", "name", "brand_name", "model_name", "distance", "primary"},
+	"detailedSegment":       {"id", "name", "activity_type", "distance", "average_grade", "climb_category", "city"},
+	"detailedSegmentEffort": {"id", "name", "start_date_local", "elapsed_time", "distance", "pr_rank", "kom_rank"},
+	"lap":                   {"lap_index", "name", "distance", "elapsed_time", "moving_time", "average_speed"},
+	"photo":                 {"unique_id", "created_at", "caption"},
+	"route":                 {"id", "name", "type", "distance", "elevation_gain", "starred"},
+	"runningRace":           {"id", "name", "start_date_local", "running_race_type", "distance", "city", "country"},
+	"summaryActivity":       {"id", "start_date_local", "type", "name", "distance", "moving_time", "total_elevation_gain"},
+	"summaryAthlete":        {"id", "firstname", "lastname", "city", "country"},
+	"summaryClub":           {"id", "name", "sport_type", "member_count", "city", "country"},
+	"summaryGear":           {"id", "name", "distance", "primary"},
+	"summarySegment":        {"id", "name", "activity_type", "distance", "average_grade", "climb_category", "city"},
+	"summarySegmentEffort":  {"id", "start_date_local", "elapsed_time", "distance", "is_kom"},
+	"upload":                {"id", "status", "activity_id", "error"},
+}
+
+// Response returns the definition an operation responds with, such as
+// summaryActivity for getLoggedInAthleteActivities.
+func Response(operationID string) (string, bool) {
+	definition, ok := responses[operationID]
+	return definition, ok
+}
+
+// Columns returns the columns of lists of a definition, or nil when it has
+// no property that renders in a cell.
+func Columns(definition string) []string {
+	if curated, ok := columns[definition]; ok {
+		return curated
+	}
+	return properties[definition]
+}
+
+// Render writes data, the JSON of a definition or of a list of them, as a
+// table. Lists have a row per element and the columns of the definition,
+// while single values have a row per scalar property.
+func Render(writer io.Writer, definition string, data []byte) error {
+	if len(properties[definition]) == 0 {
+		return fmt.Errorf("No columns to render %s as a table", definition)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	tab := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	switch v := value.(type) {
+	case []interface{}:
+		names := Columns(definition)
+		fmt.Fprintln(tab, strings.ToUpper(strings.Join(names, "\t")))
+		for _, element := range v {
+			object, _ := element.(map[string]interface{})
+			cells := make([]string, len(names))
+			for i, name := range names {
+				cells[i] = cell(object[name])
+			}
+			fmt.Fprintln(tab, strings.Join(cells, "\t"))
+		}
+	case map[string]interface{}:
+		for _, name := range properties[definition] {
+			if property, ok := v[name]; ok && property != nil {
+				fmt.Fprintf(tab, "%s\t%s\n", name, cell(property))
+			}
+		}
+	default:
+		return fmt.Errorf("Expected %s or a list of them, got %s", definition, data)
+	}
+	return tab.Flush()
+}
+
+// cell renders a scalar on a single line, so that it does not break the
+// alignment of the table.
+func cell(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(fmt.Sprint(value)), " ")
+}
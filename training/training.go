@@ -0,0 +1,188 @@
+// Package training measures the intensity of activities against the
+// thresholds of the athlete: the time spent in each zone of power and heart
+// rate, and the training stress of the activity. It also derives how hard
+// and how efficiently the athlete moved from the streams of an activity.
+package training
+
+import (
+	"math"
+)
+
+// Zone is a range of intensity, as fractions of a threshold from From
+// included to To excluded.
+type Zone struct {
+	Name     string
+	From, To float64
+}
+
+// PowerZones are the zones of Andrew Coggan, as fractions of the
+// functional threshold power.
+var PowerZones = []Zone{
+	{Name: "Z1 Active recovery", From: 0, To: 0.56},
+	{Name: "Z2 Endurance", From: 0.56, To: 0.76},
+	{Name: "Z3 Tempo", From: 0.76, To: 0.91},
+	{Name: "Z4 Threshold", From: 0.91, To: 1.06},
+	{Name: "Z5 VO2max", From: 1.06, To: 1.21},
+	{Name: "Z6 Anaerobic", From: 1.21, To: 1.51},
+	{Name: "Z7 Neuromuscular", From: 1.51, To: math.Inf(1)},
+}
+
+// HeartrateZones are the zones of Joe Friel, as fractions of the lactate
+// threshold heart rate.
+var HeartrateZones = []Zone{
+	{Name: "Z1 Recovery", From: 0, To: 0.85},
+	{Name: "Z2 Aerobic", From: 0.85, To: 0.9},
+	{Name: "Z3 Tempo", From: 0.9, To: 0.95},
+	{Name: "Z4 Sub-threshold", From: 0.95, To: 1},
+	{Name: "Z5a Super-threshold", From: 1, To: 1.03},
+	{Name: "Z5b Aerobic capacity", From: 1.03, To: 1.07},
+	{Name: "Z5c Anaerobic capacity", From: 1.07, To: math.Inf(1)},
+}
+
+// TimeInZones returns the number of samples of signal, sampled every
+// second, within each of zones of threshold.
+func TimeInZones(signal []float64, threshold float64, zones []Zone) []float64 {
+	seconds := make([]float64, len(zones))
+	for _, value := range signal {
+		if math.IsNaN(value) {
+			continue
+		}
+		for i, zone := range zones {
+			if value >= zone.From*threshold && value < zone.To*threshold {
+				seconds[i]++
+				break
+			}
+		}
+	}
+	return seconds
+}
+
+// NormalizedPower returns the normalized power of watts, sampled every
+// second: the fourth root of the mean of the fourth power of its 30-second
+// rolling average, which weighs the efforts above threshold as the body
+// feels them.
+func NormalizedPower(watts []float64) float64 {
+	const window = 30
+	if len(watts) < window {
+		return mean(watts)
+	}
+
+	sum, total := 0.0, 0.0
+	for i, value := range watts {
+		sum += value
+		if i >= window {
+			sum -= watts[i-window]
+		}
+		if i >= window-1 {
+			total += math.Pow(sum/window, 4)
+		}
+	}
+	return math.Pow(total/float64(len(watts)-window+1), 0.25)
+}
+
+// CumulativeNormalizedPower returns the normalized power of watts, sampled
+// every second, up to each of its samples.
+func CumulativeNormalizedPower(watts []float64) []float64 {
+	const window = 30
+	series := make([]float64, len(watts))
+	sum, prefix, total := 0.0, 0.0, 0.0
+	for i, value := range watts {
+		sum += value
+		prefix += value
+		if i >= window {
+			sum -= watts[i-window]
+		}
+		// As NormalizedPower, shorter series are only averaged.
+		if i < window-1 {
+			series[i] = prefix / float64(i+1)
+			continue
+		}
+		total += math.Pow(sum/window, 4)
+		series[i] = math.Pow(total/float64(i-window+2), 0.25)
+	}
+	return series
+}
+
+// runningCost returns the energy cost of running on grade, a fraction such
+// as 0.05 for 5%, in joules per kilogram and meter, as measured by Minetti
+// et al. (2002) on grades between -45% and 45%.
+func runningCost(grade float64) float64 {
+	grade = math.Max(-0.45, math.Min(0.45, grade))
+	return ((((155.4*grade-30.4)*grade-43.3)*grade+46.3)*grade+19.5)*grade + 3.6
+}
+
+// GradeAdjustedSpeed returns the speed on flat ground that costs as much
+// energy as running at speed on grade, a fraction such as 0.05 for 5%.
+func GradeAdjustedSpeed(speed, grade float64) float64 {
+	return speed * runningCost(grade) / runningCost(0)
+}
+
+// EfficiencyFactor returns the ratio of the average output, power or speed,
+// to the average heart rate over the samples where both are known.
+func EfficiencyFactor(output, heartrates []float64) float64 {
+	outputs, total, count := 0.0, 0.0, 0
+	for i := 0; i < len(output) && i < len(heartrates); i++ {
+		if math.IsNaN(output[i]) || math.IsNaN(heartrates[i]) {
+			continue
+		}
+		outputs += output[i]
+		total += heartrates[i]
+		count++
+	}
+	if count == 0 || total == 0 {
+		return math.NaN()
+	}
+	return outputs / total
+}
+
+// Decoupling returns how much the efficiency factor of output to heart rate
+// dropped from the first half of an activity to its second, in percent. A
+// decoupling under 5% tells that the athlete is fit for the duration.
+func Decoupling(output, heartrates []float64) float64 {
+	half := len(output) / 2
+	if len(heartrates) < len(output) || half == 0 {
+		return math.NaN()
+	}
+	first := EfficiencyFactor(output[:half], heartrates[:half])
+	second := EfficiencyFactor(output[half:], heartrates[half:len(output)])
+	return (first - second) / first * 100
+}
+
+// PowerStress returns the training stress score of an activity of watts,
+// sampled every second, for a functional threshold power of ftp: 100 for
+// an hour at threshold.
+func PowerStress(watts []float64, ftp float64) float64 {
+	if ftp <= 0 || len(watts) == 0 {
+		return 0
+	}
+	intensity := NormalizedPower(watts) / ftp
+	return float64(len(watts)) * intensity * intensity / 3600 * 100
+}
+
+// HeartrateStress returns the training stress score of an activity of
+// heartrates, sampled every second, for a lactate threshold heart rate of
+// lthr, with each second weighing as the square of its fraction of lthr, so
+// that an hour at threshold also scores 100.
+func HeartrateStress(heartrates []float64, lthr float64) float64 {
+	if lthr <= 0 {
+		return 0
+	}
+	total := 0.0
+	for _, heartrate := range heartrates {
+		if !math.IsNaN(heartrate) {
+			total += (heartrate / lthr) * (heartrate / lthr)
+		}
+	}
+	return total / 3600 * 100
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
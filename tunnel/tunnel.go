@@ -0,0 +1,163 @@
+// Package tunnel exposes a local port at a public URL, so that services
+// such as the push subscriptions of Strava can reach a server running on a
+// development machine.
+package tunnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultServer is the localtunnel server tunnels are opened through unless
+// given another one.
+const DefaultServer = "https://localtunnel.me"
+
+// Tunnel forwards the requests sent to its public URL to a local address
+// until it is closed.
+type Tunnel interface {
+	// URL is the public URL of the tunnel, such as https://abc.loca.lt.
+	URL() string
+	Close() error
+}
+
+// Provider opens tunnels, so that other services than localtunnel can be
+// plugged in.
+type Provider interface {
+	// Open opens a tunnel to the local address, such as localhost:8080.
+	Open(ctx context.Context, address string) (Tunnel, error)
+}
+
+// Localtunnel opens tunnels through a server compatible with localtunnel,
+// at its URL, such as DefaultServer or one hosted with
+// github.com/localtunnel/server.
+type Localtunnel string
+
+// retryDelay is how long a connection of a tunnel waits before connecting
+// again when the server or the local port refused it.
+const retryDelay = time.Second
+
+var tunnelClient = &http.Client{Timeout: 30 * time.Second}
+
+// assignment is the tunnel a localtunnel server assigns to a client, which
+// opens up to MaxConnections connections to Port of the server to forward
+// the requests it receives.
+type assignment struct {
+	ID             string `json:"id"`
+	Port           int    `json:"port"`
+	MaxConnections int    `json:"max_conn_count"`
+	URL            string `json:"url"`
+	Message        string `json:"message"`
+}
+
+func (l Localtunnel) Open(ctx context.Context, address string) (Tunnel, error) {
+	server, err := url.Parse(string(l))
+	if err != nil || server.Host == "" {
+		return nil, fmt.Errorf("Invalid tunnel server %q, expected a URL such as %s", string(l), DefaultServer)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, server.String()+"/?new", nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := tunnelClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open a tunnel through %s: %v", server.Host, err)
+	}
+	defer response.Body.Close()
+	var assigned assignment
+	if err := json.NewDecoder(response.Body).Decode(&assigned); err != nil {
+		return nil, fmt.Errorf("Unable to open a tunnel through %s: %v", server.Host, err)
+	}
+	if response.StatusCode != http.StatusOK || assigned.URL == "" {
+		return nil, fmt.Errorf("Unable to open a tunnel through %s: %s (%s)", server.Host, assigned.Message, response.Status)
+	}
+	if assigned.MaxConnections <= 0 {
+		assigned.MaxConnections = 1
+	}
+
+	tunnelCtx, cancel := context.WithCancel(context.Background())
+	t := &localtunnel{
+		url:    assigned.URL,
+		remote: net.JoinHostPort(server.Hostname(), strconv.Itoa(assigned.Port)),
+		local:  address,
+		cancel: cancel,
+	}
+	for i := 0; i < assigned.MaxConnections; i++ {
+		t.group.Add(1)
+		go t.forward(tunnelCtx)
+	}
+	return t, nil
+}
+
+type localtunnel struct {
+	url           string
+	remote, local string
+	cancel        context.CancelFunc
+	group         sync.WaitGroup
+}
+
+func (t *localtunnel) URL() string {
+	return t.url
+}
+
+func (t *localtunnel) Close() error {
+	t.cancel()
+	t.group.Wait()
+	return nil
+}
+
+// forward keeps a connection to the server open, piping the requests it
+// receives to the local address, until ctx is done.
+func (t *localtunnel) forward(ctx context.Context) {
+	defer t.group.Done()
+	var dialer net.Dialer
+	for ctx.Err() == nil {
+		remote, err := dialer.DialContext(ctx, "tcp", t.remote)
+		if err != nil {
+			wait(ctx, retryDelay)
+			continue
+		}
+		local, err := dialer.DialContext(ctx, "tcp", t.local)
+		if err != nil {
+			remote.Close()
+			wait(ctx, retryDelay)
+			continue
+		}
+		pipe(ctx, remote, local)
+	}
+}
+
+// pipe copies between a and b until either is closed or ctx is done, then
+// closes both.
+func pipe(ctx context.Context, a, b net.Conn) {
+	copied := make(chan struct{}, 2)
+	go func() {
+		io.Copy(a, b)
+		copied <- struct{}{}
+	}()
+	go func() {
+		io.Copy(b, a)
+		copied <- struct{}{}
+	}()
+	select {
+	case <-copied:
+	case <-ctx.Done():
+	}
+	a.Close()
+	b.Close()
+}
+
+func wait(ctx context.Context, delay time.Duration) {
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,198 @@
+// Package undo saves the fields of activities before sutro updates them,
+// whichever command does, so that the updates can be undone.
+package undo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/jsilland/sutro/client/activities"
+	"github.com/jsilland/sutro/models"
+	"github.com/jsilland/sutro/store"
+	"github.com/jsilland/sutro/strava"
+)
+
+type restoringKey struct{}
+
+// Interceptor returns the interceptor saving in archive the fields that
+// each update of an activity changes, as made by the command that command
+// returns. The activity is read before it is updated, which costs a
+// request.
+func Interceptor(apiClient *strava.Client, archive *store.Store, command func() string) strava.Interceptor {
+	return func(next strava.Runner) strava.Runner {
+		return func(operation *runtime.ClientOperation) (interface{}, error) {
+			params, ok := operation.Params.(*activities.UpdateActivityByIDParams)
+			ctx := operation.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if !ok || params.Body == nil || ctx.Value(restoringKey{}) != nil {
+				return next(operation)
+			}
+
+			current, err := apiClient.Activities.Get(ctx, params.ID)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to read activity %d before updating it, to be able to undo the update: %v", params.ID, err)
+			}
+
+			result, err := next(operation)
+			if err != nil {
+				return result, err
+			}
+			update := store.Update{
+				ActivityID: params.ID,
+				Time:       time.Now(),
+				Command:    command(),
+				Previous:   previous(current, params.Body),
+			}
+			if err := archive.AddUpdate(update); err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to save activity %d to undo its update: %v\n", params.ID, err)
+			}
+			return result, nil
+		}
+	}
+}
+
+// previous returns the values of the fields of activity that update
+// changes.
+func previous(activity *models.DetailedActivity, update *models.UpdatableActivity) store.PreviousFields {
+	var fields store.PreviousFields
+	if update.Name != "" {
+		fields.Name = stringOf(activity.Name)
+	}
+	if update.Description != "" {
+		fields.Description = stringOf(activity.Description)
+	}
+	if update.Type != "" {
+		fields.Type = stringOf(string(activity.Type))
+	}
+	if update.GearID != "" {
+		fields.GearID = stringOf(activity.GearID)
+	}
+	if update.Commute != nil {
+		commute := activity.Commute
+		fields.Commute = &commute
+	}
+	if update.Trainer {
+		trainer := activity.Trainer
+		fields.Trainer = &trainer
+	}
+	return fields
+}
+
+func stringOf(s string) *string {
+	return &s
+}
+
+// Restoration is the update restoring the fields of an activity, with the
+// fields that the API does not let sutro restore.
+type Restoration struct {
+	Update *store.Update
+	Change models.UpdatableActivity
+	// Unrestorable describes the fields that cannot be restored, such as
+	// a description that was empty, which the API cannot clear.
+	Unrestorable []string
+}
+
+// Plan returns the restoration of the last update of activityID, or of the
+// last update of any activity when it is zero, or nil when there is none
+// left to undo.
+func Plan(archive *store.Store, activityID int64) (*Restoration, error) {
+	update, err := archive.LastUpdate(activityID)
+	if err != nil || update == nil {
+		return nil, err
+	}
+
+	r := &Restoration{Update: update}
+	p := update.Previous
+	if p.Name != nil {
+		r.Change.Name = *p.Name
+	}
+	if p.Description != nil {
+		if *p.Description == "" {
+			r.Unrestorable = append(r.Unrestorable, "the empty description")
+		}
+		r.Change.Description = *p.Description
+	}
+	if p.Type != nil {
+		r.Change.Type = models.ActivityType(*p.Type)
+	}
+	if p.GearID != nil {
+		r.Change.GearID = *p.GearID
+		// The API clears the gear of an activity with the id none.
+		if r.Change.GearID == "" {
+			r.Change.GearID = "none"
+		}
+	}
+	if p.Commute != nil {
+		commute := *p.Commute
+		r.Change.Commute = &commute
+	}
+	if p.Trainer != nil {
+		if !*p.Trainer {
+			r.Unrestorable = append(r.Unrestorable, "the trainer flag, which was not set")
+		}
+		r.Change.Trainer = *p.Trainer
+	}
+	return r, nil
+}
+
+// String summarizes the fields the restoration sets.
+func (r *Restoration) String() string {
+	var described []string
+	if r.Change.Name != "" {
+		described = append(described, "name "+strconv.Quote(r.Change.Name))
+	}
+	if r.Change.Description != "" {
+		described = append(described, "description")
+	}
+	if r.Change.Type != "" {
+		described = append(described, "type "+string(r.Change.Type))
+	}
+	if r.Change.GearID != "" {
+		described = append(described, "gear "+r.Change.GearID)
+	}
+	if r.Change.Commute != nil {
+		described = append(described, fmt.Sprintf("commute %t", *r.Change.Commute))
+	}
+	if r.Change.Trainer {
+		described = append(described, "trainer true")
+	}
+	if len(described) == 0 {
+		return "nothing"
+	}
+	return strings.Join(described, ", ")
+}
+
+// empty reports whether the restoration leaves every field as it is.
+func (r *Restoration) empty() bool {
+	c := r.Change
+	return c.Name == "" && c.Description == "" && c.Type == "" && c.GearID == "" && c.Commute == nil && !c.Trainer
+}
+
+// Apply restores the fields of the activity on Strava and in the archive,
+// and marks the update undone. It returns the restored activity, or nil
+// when no field could be restored. Restoring is not saved as an update of
+// its own, so that undoing again restores the update before.
+func Apply(ctx context.Context, apiClient *strava.Client, archive *store.Store, r *Restoration) (*models.SummaryActivity, error) {
+	if r.empty() {
+		return nil, archive.MarkUndone(r.Update.ID)
+	}
+	ctx = context.WithValue(ctx, restoringKey{}, true)
+	updated, err := apiClient.Activities.Update(ctx, r.Update.ActivityID, &r.Change)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to restore activity %d: %v", r.Update.ActivityID, err)
+	}
+	if err := archive.MarkUndone(r.Update.ID); err != nil {
+		return nil, err
+	}
+	if err := archive.PutActivities([]*models.SummaryActivity{&updated.SummaryActivity}); err != nil {
+		return nil, err
+	}
+	return &updated.SummaryActivity, nil
+}
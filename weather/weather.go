@@ -0,0 +1,143 @@
+// Package weather looks up the weather at a place and time from the
+// historical and forecast data of Open-Meteo (https://open-meteo.com),
+// which requires no key.
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jsilland/sutro/geo"
+)
+
+const (
+	// ForecastURL serves the recent days, ArchiveURL the older ones.
+	ForecastURL = "https://api.open-meteo.com/v1/forecast"
+	ArchiveURL  = "https://archive-api.open-meteo.com/v1/archive"
+)
+
+// archiveDelay is how long the archive of Open-Meteo lags behind, during
+// which the weather is read from the forecast instead.
+const archiveDelay = 5 * 24 * time.Hour
+
+// Conditions are the weather during an hour.
+type Conditions struct {
+	// Temperature is in degrees Celsius.
+	Temperature float64
+	// Humidity is the relative humidity, in percent.
+	Humidity float64
+	// Precipitation is in millimeters.
+	Precipitation float64
+	// Wind is the speed of the wind, in kilometers per hour.
+	Wind float64
+	// Summary describes the sky, such as clear or light rain.
+	Summary string
+}
+
+// String renders the conditions as 12°C, light rain, 15 km/h wind.
+func (c Conditions) String() string {
+	return fmt.Sprintf("%.0f°C, %s, %.0f km/h wind", c.Temperature, c.Summary, c.Wind)
+}
+
+// Client looks up the weather, from Open-Meteo unless its URLs are set
+// otherwise.
+type Client struct {
+	HTTPClient  *http.Client
+	ForecastURL string
+	ArchiveURL  string
+}
+
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
+
+// At returns the conditions at point during the hour of t.
+func (c *Client) At(ctx context.Context, point geo.Point, t time.Time) (*Conditions, error) {
+	endpoint := c.ArchiveURL
+	if endpoint == "" {
+		endpoint = ArchiveURL
+	}
+	if time.Since(t) < archiveDelay {
+		endpoint = c.ForecastURL
+		if endpoint == "" {
+			endpoint = ForecastURL
+		}
+	}
+
+	day := t.UTC().Format("2006-01-02")
+	query := url.Values{
+		"latitude":   {fmt.Sprintf("%.4f", point.Lat)},
+		"longitude":  {fmt.Sprintf("%.4f", point.Lng)},
+		"hourly":     {"temperature_2m,relative_humidity_2m,precipitation,weather_code,wind_speed_10m"},
+		"start_date": {day},
+		"end_date":   {day},
+		"timezone":   {"GMT"},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to look up the weather: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unable to look up the weather: %s", response.Status)
+	}
+
+	var forecast struct {
+		Hourly struct {
+			Time          []string  `json:"time"`
+			Temperature   []float64 `json:"temperature_2m"`
+			Humidity      []float64 `json:"relative_humidity_2m"`
+			Precipitation []float64 `json:"precipitation"`
+			Code          []int     `json:"weather_code"`
+			Wind          []float64 `json:"wind_speed_10m"`
+		} `json:"hourly"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&forecast); err != nil {
+		return nil, fmt.Errorf("Unable to read the weather: %v", err)
+	}
+
+	hour := t.UTC().Truncate(time.Hour).Format("2006-01-02T15:04")
+	hourly := forecast.Hourly
+	for i, at := range hourly.Time {
+		if at != hour || i >= len(hourly.Temperature) || i >= len(hourly.Humidity) ||
+			i >= len(hourly.Precipitation) || i >= len(hourly.Code) || i >= len(hourly.Wind) {
+			continue
+		}
+		return &Conditions{
+			Temperature:   hourly.Temperature[i],
+			Humidity:      hourly.Humidity[i],
+			Precipitation: hourly.Precipitation[i],
+			Wind:          hourly.Wind[i],
+			Summary:       summary(hourly.Code[i]),
+		}, nil
+	}
+	return nil, fmt.Errorf("No weather reported for %s", hour)
+}
+
+// summaries describe the WMO weather codes that Open-Meteo reports.
+var summaries = map[int]string{
+	0: "clear", 1: "mostly clear", 2: "partly cloudy", 3: "overcast",
+	45: "fog", 48: "freezing fog",
+	51: "light drizzle", 53: "drizzle", 55: "heavy drizzle", 56: "freezing drizzle", 57: "freezing drizzle",
+	61: "light rain", 63: "rain", 65: "heavy rain", 66: "freezing rain", 67: "freezing rain",
+	71: "light snow", 73: "snow", 75: "heavy snow", 77: "snow grains",
+	80: "light showers", 81: "showers", 82: "heavy showers", 85: "snow showers", 86: "heavy snow showers",
+	95: "thunderstorm", 96: "thunderstorm with hail", 99: "thunderstorm with hail",
+}
+
+func summary(code int) string {
+	if s, ok := summaries[code]; ok {
+		return s
+	}
+	return fmt.Sprintf("weather code %d", code)
+}
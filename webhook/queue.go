@@ -0,0 +1,276 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// Status is where an event of a queue is in its processing.
+type Status string
+
+const (
+	// Pending events are yet to be processed, or failed and are retried.
+	Pending Status = "pending"
+	// Done events were processed.
+	Done Status = "done"
+	// Failed events failed as many times as they were retried.
+	Failed Status = "failed"
+)
+
+// ErrLeftPending is returned by the handlers of Process to leave an event
+// pending without recording an attempt, such as one received without a hook
+// to run.
+var ErrLeftPending = errors.New("The event was left pending")
+
+// Entry is an event of a queue with the outcome of its processing.
+type Entry struct {
+	ID       int64     `json:"id"`
+	Received time.Time `json:"received"`
+	Event    Event     `json:"event"`
+	// Hook is the command the event was received to be processed with.
+	Hook     string `json:"hook,omitempty"`
+	Status   Status `json:"status"`
+	Attempts int    `json:"attempts"`
+	// Error is the error of the last attempt, when it failed.
+	Error string `json:"error,omitempty"`
+	// RetryAt is when an event that failed is attempted again.
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+}
+
+// record is a line of a queue: an event received, or an attempt to process
+// the event with the id.
+type record struct {
+	ID    int64     `json:"id"`
+	Time  time.Time `json:"time"`
+	Event *Event    `json:"event,omitempty"`
+	Hook  string    `json:"hook,omitempty"`
+	// Result is ok, or the error the attempt failed with.
+	Result  string     `json:"result,omitempty"`
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+}
+
+// Queue is the durable queue of the events received, so that they are
+// processed after they were acknowledged to Strava, even when sutro stops
+// in between, and can be processed again.
+//
+// A queue is an append-only file of JSON lines, one per event received and
+// one per attempt to process an event.
+type Queue struct {
+	filename string
+	mutex    sync.Mutex
+	added    chan struct{}
+}
+
+// QueuePath returns the path of the queue of events in the state directory
+// of a profile.
+func QueuePath(stateDirectory string) string {
+	return path.Join(stateDirectory, "webhooks", "events.jsonl")
+}
+
+// OpenQueue returns the queue at filename, which is created with its first
+// event.
+func OpenQueue(filename string) *Queue {
+	return &Queue{filename: filename, added: make(chan struct{}, 1)}
+}
+
+// Add appends event, to be processed with hook, and returns its entry.
+func (q *Queue) Add(event Event, hook string) (Entry, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	entries, err := q.entries()
+	if err != nil {
+		return Entry{}, err
+	}
+	r := record{ID: 1, Time: time.Now().UTC(), Event: &event, Hook: hook}
+	if len(entries) > 0 {
+		r.ID = entries[len(entries)-1].ID + 1
+	}
+	if err := q.append(r); err != nil {
+		return Entry{}, err
+	}
+
+	select {
+	case q.added <- struct{}{}:
+	default:
+	}
+	return Entry{ID: r.ID, Received: r.Time, Event: event, Hook: hook, Status: Pending}, nil
+}
+
+// Record appends the outcome of an attempt to process the event with the
+// given id, which is attempted again at retryAt unless it is zero.
+func (q *Queue) Record(id int64, err error, retryAt time.Time) error {
+	r := record{ID: id, Time: time.Now().UTC(), Result: "ok"}
+	if err != nil {
+		r.Result = err.Error()
+		if !retryAt.IsZero() {
+			r.RetryAt = &retryAt
+		}
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.append(r)
+}
+
+func (q *Queue) append(r record) error {
+	encoded, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(q.filename), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(q.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(encoded, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Entries returns the events of the queue, oldest first.
+func (q *Queue) Entries() ([]Entry, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.entries()
+}
+
+// Entry returns the event of the queue with the given id.
+func (q *Queue) Entry(id int64) (Entry, error) {
+	entries, err := q.Entries()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("No event %d in the queue", id)
+}
+
+func (q *Queue) entries() ([]Entry, error) {
+	file, err := os.Open(q.filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	byID := map[int64]int{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("Invalid record on line %d of %s: %v", line, q.filename, err)
+		}
+		if r.Event != nil {
+			byID[r.ID] = len(entries)
+			entries = append(entries, Entry{ID: r.ID, Received: r.Time, Event: *r.Event, Hook: r.Hook, Status: Pending})
+			continue
+		}
+		i, ok := byID[r.ID]
+		if !ok {
+			continue
+		}
+		entry := &entries[i]
+		entry.Attempts++
+		entry.Error, entry.RetryAt = "", nil
+		switch {
+		case r.Result == "ok":
+			entry.Status = Done
+		case r.RetryAt != nil:
+			entry.Status, entry.Error, entry.RetryAt = Pending, r.Result, r.RetryAt
+		default:
+			entry.Status, entry.Error = Failed, r.Result
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Retry is how the events that fail to be processed are retried.
+type Retry struct {
+	// Retries is how many times an event is attempted again after it first
+	// failed.
+	Retries int
+	// Delay is how long after the first failure an event is attempted
+	// again, doubled on each later attempt.
+	Delay time.Duration
+}
+
+// next returns when an event that failed on its attempts-th attempt is
+// attempted again, or the zero time when it is not.
+func (r Retry) next(attempts int, now time.Time) time.Time {
+	if attempts > r.Retries {
+		return time.Time{}
+	}
+	return now.Add(r.Delay << uint(attempts-1))
+}
+
+// Process runs handle for the pending events of the queue, oldest first,
+// and for those added afterwards, until ctx is done. Events left pending by
+// a previous run are processed too, and those handle returns ErrLeftPending
+// for stay pending.
+func (q *Queue) Process(ctx context.Context, handle func(Entry) error, retry Retry) error {
+	for {
+		entries, err := q.Entries()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var wake time.Time
+		for _, entry := range entries {
+			if entry.Status != Pending {
+				continue
+			}
+			if entry.RetryAt != nil && entry.RetryAt.After(now) {
+				if wake.IsZero() || entry.RetryAt.Before(wake) {
+					wake = *entry.RetryAt
+				}
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			err := handle(entry)
+			if errors.Is(err, ErrLeftPending) {
+				continue
+			}
+			var retryAt time.Time
+			if err != nil {
+				retryAt = retry.next(entry.Attempts+1, time.Now())
+				if !retryAt.IsZero() && (wake.IsZero() || retryAt.Before(wake)) {
+					wake = retryAt
+				}
+			}
+			if err := q.Record(entry.ID, err, retryAt); err != nil {
+				return err
+			}
+		}
+
+		var timer <-chan time.Time
+		if !wake.IsZero() {
+			timer = time.After(time.Until(wake))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.added:
+		case <-timer:
+		}
+	}
+}
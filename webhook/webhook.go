@@ -0,0 +1,172 @@
+// Package webhook receives the events Strava posts to the callback of a
+// push subscription, such as the activities athletes create, update and
+// delete. Strava does not sign its events, so a receiver rejects those that
+// do not come from the subscription it expects or from an allowed network.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Event is an event of a push subscription. The object is the activity or
+// athlete the event is about, which is only given by its id.
+type Event struct {
+	// ObjectType is activity or athlete.
+	ObjectType string `json:"object_type"`
+	ObjectID   int64  `json:"object_id"`
+	// AspectType is create, update or delete.
+	AspectType string `json:"aspect_type"`
+	// Updates are the fields that changed, such as title, type or private,
+	// and authorized, set to false, when an athlete revoked the access of
+	// the application.
+	Updates        map[string]string `json:"updates,omitempty"`
+	OwnerID        int64             `json:"owner_id"`
+	SubscriptionID int64             `json:"subscription_id"`
+	// EventTime is when the event happened, in seconds since the epoch.
+	EventTime int64 `json:"event_time"`
+}
+
+// Receiver serves the callback URL of a push subscription. It answers the
+// request Strava sends to validate the callback when the subscription is
+// created, and passes the events it accepts to Handle.
+type Receiver struct {
+	// SubscriptionID, when set, is the only subscription whose events are
+	// accepted. It is changed with Expect while the receiver serves, and is
+	// first in the struct to be aligned for atomic access.
+	SubscriptionID int64
+	// VerifyToken, when set, must be the token given when the subscription
+	// was created, which Strava sends back to validate the callback.
+	VerifyToken string
+	// Allowed, when set, are the only networks requests are accepted from.
+	Allowed []*net.IPNet
+	// BehindProxy takes the address of clients from the X-Forwarded-For
+	// header the reverse proxy in front of the receiver sets, since requests
+	// all come from the proxy.
+	BehindProxy bool
+	// Handle is called with each event accepted. Strava expects a response
+	// within two seconds, and sends an event again up to three times when
+	// Handle fails.
+	Handle func(Event) error
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	client := r.client(request)
+	if !r.allowed(client) {
+		log.Printf("Rejected a request from %s, which is not an allowed network", client)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	switch request.Method {
+	case http.MethodGet:
+		r.validate(w, request)
+	case http.MethodPost:
+		r.receive(w, request)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Expect only accepts the events of the subscription with the given id from
+// now on, such as the one created once the callback is served.
+func (r *Receiver) Expect(id int64) {
+	atomic.StoreInt64(&r.SubscriptionID, id)
+}
+
+// validate answers the challenge Strava sends when a subscription is
+// created, which proves that the callback expects its events.
+func (r *Receiver) validate(w http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	if query.Get("hub.mode") != "subscribe" || query.Get("hub.challenge") == "" {
+		http.Error(w, "Expected the validation of a subscription", http.StatusBadRequest)
+		return
+	}
+	if r.VerifyToken != "" && query.Get("hub.verify_token") != r.VerifyToken {
+		log.Printf("Rejected the validation of a subscription with the wrong verify token")
+		http.Error(w, "Invalid verify token", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"hub.challenge": query.Get("hub.challenge")})
+}
+
+func (r *Receiver) receive(w http.ResponseWriter, request *http.Request) {
+	var event Event
+	if err := json.NewDecoder(http.MaxBytesReader(w, request.Body, 1<<20)).Decode(&event); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid event: %v", err), http.StatusBadRequest)
+		return
+	}
+	if expected := atomic.LoadInt64(&r.SubscriptionID); expected != 0 && event.SubscriptionID != expected {
+		log.Printf("Rejected an event of subscription %d, expected subscription %d", event.SubscriptionID, expected)
+		http.Error(w, "Unknown subscription", http.StatusForbidden)
+		return
+	}
+	if r.Handle != nil {
+		if err := r.Handle(event); err != nil {
+			log.Printf("Failed to handle the %s event of %s %d: %v", event.AspectType, event.ObjectType, event.ObjectID, err)
+			http.Error(w, "Failed to handle the event", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// client returns the address of the client of request, or nil when it is
+// not an IP address.
+func (r *Receiver) client(request *http.Request) net.IP {
+	if r.BehindProxy {
+		// The proxy appends the address it received the request from to
+		// those the client claims, so only the last one can be trusted.
+		if forwarded := request.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			addresses := strings.Split(forwarded[len(forwarded)-1], ",")
+			return net.ParseIP(strings.TrimSpace(addresses[len(addresses)-1]))
+		}
+	}
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+func (r *Receiver) allowed(client net.IP) bool {
+	if len(r.Allowed) == 0 {
+		return true
+	}
+	for _, network := range r.Allowed {
+		if client != nil && network.Contains(client) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseNetworks parses networks in CIDR notation, such as 10.0.0.0/8, or
+// single addresses.
+func ParseNetworks(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if ip := net.ParseIP(value); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid network %q, expected an address or a CIDR such as 10.0.0.0/8", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}